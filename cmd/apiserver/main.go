@@ -23,7 +23,9 @@ import (
 
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/server"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/settings"
 	"github.com/llm-d-incubation/batch-gateway/internal/util/interrupt"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/version"
 	"k8s.io/klog/v2"
 )
 
@@ -45,7 +47,12 @@ func main() {
 	// start server
 	logger := klog.FromContext(ctx)
 
-	logger.Info("starting api server")
+	if err := settings.ResolveSecrets(ctx, config.Secrets, config); err != nil {
+		logger.Error(err, "failed to resolve secrets")
+		return
+	}
+
+	logger.Info("starting api server", "version", version.Get())
 
 	server, err := server.New(config)
 	if err != nil {
@@ -0,0 +1,118 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The entry point of the optional BatchJob controller, the Kubernetes-native interface of the batch gateway.
+// It creates the batches of the BatchJob custom resources via the API server and mirrors their status back.
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/controller"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/kube"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/interrupt"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/tls"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/version"
+)
+
+const (
+	// component identifies the controller in the JSON log entries
+	component = "batch-controller"
+
+	// apiKeyEnv is the default of the -api-key flag.
+	apiKeyEnv = "BATCH_CONTROLLER_API_KEY"
+)
+
+func main() {
+	// initialize klog
+	klog.InitFlags(nil)
+	defer klog.Flush()
+
+	rootLogger := klog.Background()
+	hostname, _ := os.Hostname()
+	rootLogger = rootLogger.WithValues("hostname", hostname)
+	ctx := klog.NewContext(context.Background(), rootLogger)
+	logger := klog.FromContext(ctx)
+
+	// the Kubernetes API is reached in-cluster by default, or e.g. through kubectl proxy
+	inCluster := kube.InClusterServer() != ""
+	defaultTokenFile, defaultKubeCACert := "", ""
+	if inCluster {
+		defaultTokenFile, defaultKubeCACert = kube.InClusterTokenFile, kube.InClusterCACertFile
+	}
+
+	fs := flag.NewFlagSet("batch-gateway-controller", flag.ExitOnError)
+	server := fs.String("server", "http://localhost:8000", "URL of the API server the batches are created with")
+	apiKey := fs.String("api-key", os.Getenv(apiKeyEnv), "API key sent to the API server, one of its tenant_delegate_keys, defaults to $"+apiKeyEnv)
+	caCert := fs.String("ca-cert", "", "CA certificate file verifying the API server certificate")
+	kubeServer := fs.String("kube-server", kube.InClusterServer(), "URL of the Kubernetes API server, defaults to the in-cluster one")
+	kubeTokenFile := fs.String("kube-token-file", defaultTokenFile, "file of the bearer token sent to the Kubernetes API server")
+	kubeCACert := fs.String("kube-ca-cert", defaultKubeCACert, "CA certificate file verifying the Kubernetes API server certificate")
+	namespace := fs.String("namespace", "", "namespace of the BatchJobs to reconcile, all the namespaces when empty")
+	resyncInterval := fs.Duration("resync-interval", 10*time.Second, "interval between two reconciliations of the BatchJobs")
+	logFormat := logging.AddFlags(fs)
+	klog.InitFlags(fs)
+	fs.Parse(os.Args[1:])
+	if err := logging.Setup(*logFormat, component); err != nil {
+		logger.V(logging.ERROR).Error(err, "Invalid log format. Controller cannot start")
+		os.Exit(1)
+	}
+	logger.V(logging.INFO).Info("Version", "version", version.Get())
+
+	if *kubeServer == "" {
+		logger.V(logging.ERROR).Info("The Kubernetes API server is required outside of a cluster, set -kube-server. Controller cannot start")
+		os.Exit(1)
+	}
+	if *resyncInterval <= 0 {
+		logger.V(logging.ERROR).Info("The resync interval must be positive. Controller cannot start", "resyncInterval", *resyncInterval)
+		os.Exit(1)
+	}
+
+	tlsConfig, err := tls.GetTlsConfig(tls.LOAD_TYPE_CLIENT, false, "", "", *caCert)
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to configure TLS for the API server. Controller cannot start")
+		os.Exit(1)
+	}
+	batches := controller.NewBatchClient(*server, *apiKey, &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		Timeout:   30 * time.Second,
+	})
+
+	kubeTLSConfig, err := tls.GetTlsConfig(tls.LOAD_TYPE_CLIENT, false, "", "", *kubeCACert)
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to configure TLS for the Kubernetes API server. Controller cannot start")
+		os.Exit(1)
+	}
+	kubeClient := controller.NewKubeClient(*kubeServer, *kubeTokenFile, &http.Client{
+		Transport: &http.Transport{TLSClientConfig: kubeTLSConfig},
+		Timeout:   30 * time.Second,
+	})
+
+	// setup context with graceful shutdown
+	ctx, cancel := interrupt.ContextWithSignal(ctx)
+	defer cancel()
+
+	logger.V(logging.INFO).Info("Start controller", "server", *server, "kubeServer", *kubeServer, "namespace", *namespace, "resyncInterval", *resyncInterval)
+	controller.New(kubeClient, batches, *namespace).Run(ctx, *resyncInterval)
+	logger.V(logging.INFO).Info("Controller stopped")
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Dev mode: the API server runs in the processor process, and both use in-memory storage backends.
+// Unless inference gateways are configured, the requests are answered by a mock inference server in the process.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/server"
+	mockapi "github.com/llm-d-incubation/batch-gateway/internal/database/mock"
+	filesapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/inference"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/settings"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+const (
+	// devEnv enables dev mode when set to true, like the -dev flag.
+	devEnv = "BATCH_PROCESSOR_DEV"
+
+	// defaultDevInferenceAddr is the address the mock inference server of dev mode listens on.
+	defaultDevInferenceAddr = "localhost:8100"
+)
+
+// newDevClients returns in-memory clients for the processor and the API server. They share the files client.
+func newDevClients(filesClient filesapi.BatchFilesClient) *server.Clients {
+	return &server.Clients{
+		DB:         mockapi.NewMockBatchDBClient(),
+		FileDB:     mockapi.NewMockBatchFileDBClient(),
+		Queue:      mockapi.NewMockBatchPriorityQueueClient(),
+		DeadLetter: mockapi.NewMockBatchDeadLetterClient(),
+		Event:      mockapi.NewMockBatchEventChannelClient(),
+		Status:     mockapi.NewMockBatchStatusClient(),
+		Files:      filesClient,
+	}
+}
+
+// newDevAPIServer creates the API server of dev mode from its configuration file.
+// The files directory of the configuration is replaced by the one of the processor.
+func newDevAPIServer(ctx context.Context, configPath, filesDir string, clients *server.Clients) (*server.Server, error) {
+	cfg := common.NewConfig()
+	if err := cfg.LoadFromFile(configPath); err != nil {
+		return nil, err
+	}
+	cfg.FileStore.Dir = filesDir
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid api server config: %w", err)
+	}
+	if err := settings.ResolveSecrets(ctx, cfg.Secrets, cfg); err != nil {
+		return nil, err
+	}
+	return server.NewWithClients(cfg, clients)
+}
+
+// runDevAPIServer runs the API server until the context is canceled.
+func runDevAPIServer(ctx context.Context, apiServer *server.Server) {
+	logger := klog.FromContext(ctx)
+	if err := apiServer.Start(ctx); err != nil {
+		logger.V(logging.ERROR).Error(err, "Dev mode API server failed")
+	}
+}
+
+// startDevInferenceServer starts the mock inference server of dev mode on addr, until the context is canceled,
+// and returns its URL.
+func startDevInferenceServer(ctx context.Context, addr string) (string, error) {
+	logger := klog.FromContext(ctx)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	server := &http.Server{Handler: inference.NewEchoHandler()}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.V(logging.ERROR).Error(err, "Dev mode inference server failed")
+		}
+	}()
+	return "http://" + listener.Addr().String(), nil
+}
@@ -23,18 +23,37 @@ import (
 	"flag"
 	"net/http"
 	"os"
-	"time"
 
 	"k8s.io/klog/v2"
 
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/health"
 	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	fsapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/fs"
 	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/inference"
 	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/notify"
 	"github.com/llm-d-incubation/batch-gateway/internal/processor/worker"
 	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/kube"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/settings"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/store"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/features"
 	"github.com/llm-d-incubation/batch-gateway/internal/util/interrupt"
 	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/redact"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/slowop"
 	"github.com/llm-d-incubation/batch-gateway/internal/util/tls"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/tracing"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/version"
+)
+
+const (
+	// component identifies the processor in the JSON log entries
+	component = "batch-processor"
+
+	// chaosEnv enables chaos mode when set to true, like the -chaos flag.
+	chaosEnv = "BATCH_PROCESSOR_CHAOS"
 )
 
 func main() {
@@ -47,7 +66,7 @@ func main() {
 	ctx := klog.NewContext(context.Background(), rootLogger)
 
 	hostname, _ := os.Hostname()
-	rootLogger = rootLogger.WithValues("hostname", hostname, "service", "batch-processor")
+	rootLogger = rootLogger.WithValues("hostname", hostname)
 	ctx = klog.NewContext(ctx, rootLogger)
 
 	logger := klog.FromContext(ctx)
@@ -56,13 +75,38 @@ func main() {
 	fs := flag.NewFlagSet("batch-gateway-processor", flag.ExitOnError)
 
 	cfgFilePath := fs.String("config", "cmd/batch-processor/config.yaml", "Path to configuration file")
+	chaosMode := fs.Bool("chaos", os.Getenv(chaosEnv) == "true",
+		"Inject the faults of the chaos configuration into inference requests. For tests only (env "+chaosEnv+")")
+	devMode := fs.Bool("dev", os.Getenv(devEnv) == "true",
+		"Run the API server in this process, with in-memory storage backends and files in a temporary directory. For local development only (env "+devEnv+")")
+	apiServerCfgFilePath := fs.String("apiserver-config", "cmd/apiserver/config.yaml", "Path to the API server configuration file, used in dev mode")
+	devInferenceAddr := fs.String("dev-inference-addr", defaultDevInferenceAddr,
+		"Address of the mock inference server answering the requests in dev mode, when no inference gateway is configured")
+	logFormat := logging.AddFlags(fs)
 	klog.InitFlags(fs)
 	fs.Parse(os.Args[1:])
+	if err := logging.Setup(*logFormat, component); err != nil {
+		logger.V(logging.ERROR).Error(err, "Invalid log format. Processor cannot start")
+		os.Exit(1)
+	}
 
 	if err := cfg.LoadFromYAML(*cfgFilePath); err != nil {
 		logger.V(logging.ERROR).Error(err, "Failed to load config file. Processor cannot start", "path", *cfgFilePath, "err", err)
 		os.Exit(1)
 	}
+	if *devMode {
+		filesDir, err := os.MkdirTemp("", "batch-gateway-dev-")
+		if err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to create dev mode files directory")
+			os.Exit(1)
+		}
+		defer os.RemoveAll(filesDir)
+		cfg.FileStore.Dir = filesDir
+	}
+	if err := cfg.Validate(); err != nil {
+		logger.V(logging.ERROR).Error(err, "Invalid config. Processor cannot start", "path", *cfgFilePath)
+		os.Exit(1)
+	}
 
 	// metrics setup
 	if err := metrics.InitMetrics(*cfg); err != nil {
@@ -70,19 +114,36 @@ func main() {
 		os.Exit(1)
 	}
 	logger.V(logging.INFO).Info("Metrics initialized", "numWorkers", cfg.NumWorkers)
+	logger.V(logging.INFO).Info("Version", "version", version.Get())
+	slowop.SetThresholds(cfg.Observability.SlowOps)
+	if err := redact.Configure(cfg.Observability.Redaction); err != nil {
+		logger.V(logging.ERROR).Error(err, "Invalid redaction rules. Processor cannot start")
+		os.Exit(1)
+	}
+	features.Set(cfg.Features)
+	logger.V(logging.INFO).Info("Feature flags", "features", features.States())
+	if cfg.Observability.LogSpans {
+		tracing.SetExporter(tracing.NewLogExporter(logger.WithName("tracing").V(logging.INFO)))
+	}
 
 	// setup context with graceful shutdown
 	ctx, cancel := interrupt.ContextWithSignal(ctx)
 	defer cancel()
 
-	go func() {
-		m := http.NewServeMux()
-		m.Handle("/metrics", metrics.NewMetricsHandler())
-		m.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("ok"))
-		})
+	// the storage clients checked by the readiness endpoint, registered once they are set up
+	clientset := store.NewClientset()
+
+	// the drain endpoint is added once the processor is created
+	m := http.NewServeMux()
+	m.Handle("/metrics", metrics.NewMetricsHandler())
+	m.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+	m.HandleFunc(health.ReadyPath, health.NewHealthApiHandler(clientset).ReadyHandler)
+	m.HandleFunc(version.Path, version.Handler)
 
+	go func() {
 		server := &http.Server{
 			Addr:    cfg.Addr,
 			Handler: m,
@@ -90,7 +151,7 @@ func main() {
 
 		// tls setup
 		if cfg.SSLEnabled() {
-			tlsConfig, err := tls.GetTlsConfig(tls.LOAD_TYPE_SERVER, false, cfg.SSLCertFile, cfg.SSLKeyFile, "")
+			tlsConfig, err := tls.GetServerTlsConfig(cfg.SSLCertFile, cfg.SSLKeyFile, cfg.SSLClientCAFile)
 			if err != nil {
 				logger.V(logging.ERROR).Error(err, "Failed to configure TLS for observability server")
 				return
@@ -103,7 +164,7 @@ func main() {
 		go func() {
 			<-ctx.Done()
 			logger.V(logging.INFO).Info("Shutting down observability server")
-			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 			defer cancel()
 			if err := server.Shutdown(shutdownCtx); err != nil {
 				logger.V(logging.ERROR).Error(err, "Observability server shutdown failed")
@@ -125,20 +186,118 @@ func main() {
 
 	}()
 
+	if err := settings.ResolveSecrets(ctx, cfg.Secrets, cfg); err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to resolve secrets. Processor cannot start")
+		os.Exit(1)
+	}
+
 	// Todo:: db/llmd client setup
 	var dbClient db.BatchDBClient
+	var fileDBClient db.BatchFileDBClient
 	var pqClient db.BatchPriorityQueueClient
+	var dlqClient db.BatchDeadLetterClient
 	var statusClient db.BatchStatusClient
 	var eventClient db.BatchEventChannelClient
 	var inferenceClient batch.InferenceClient
+
+	filesClient, err := fsapi.NewFSFilesClient(cfg.FileStore.Dir)
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to create files client", "dir", cfg.FileStore.Dir)
+		os.Exit(1)
+	}
+	// in chaos mode, faults are injected into the requests of every inference client
+	withChaos := func(client batch.InferenceClient) batch.InferenceClient {
+		if !*chaosMode || client == nil {
+			return client
+		}
+		return inference.NewChaosClient(client, cfg.Chaos)
+	}
+	// in dev mode, the storage backends are in memory and shared with an API server running in this process
+	if *devMode {
+		devClients := newDevClients(filesClient)
+		dbClient, fileDBClient, pqClient = devClients.DB, devClients.FileDB, devClients.Queue
+		dlqClient, statusClient, eventClient = devClients.DeadLetter, devClients.Status, devClients.Event
+
+		apiServer, err := newDevAPIServer(ctx, *apiServerCfgFilePath, cfg.FileStore.Dir, devClients)
+		if err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to create dev mode API server", "path", *apiServerCfgFilePath)
+			os.Exit(1)
+		}
+		go runDevAPIServer(ctx, apiServer)
+		if len(cfg.InferenceGateways) == 0 {
+			inferenceURL, err := startDevInferenceServer(ctx, *devInferenceAddr)
+			if err != nil {
+				logger.V(logging.ERROR).Error(err, "Failed to start dev mode inference server")
+				os.Exit(1)
+			}
+			inferenceClient = inference.NewHTTPClient(inference.HTTPClientConfig{URL: inferenceURL})
+			logger.V(logging.WARNING).Info("DEV MODE: requests are answered by the mock inference server", "url", inferenceURL)
+		}
+		logger.V(logging.WARNING).Info("DEV MODE: storage is in memory and is lost on exit", "filesDir", cfg.FileStore.Dir)
+	}
+	if *chaosMode {
+		logger.V(logging.WARNING).Info("CHAOS MODE: injecting faults into inference requests", "chaos", cfg.Chaos)
+	}
+	// the status store holds request content, e.g. the cached inference responses
+	payloadCipher, err := cfg.PayloadEncryption.Cipher()
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to create payload cipher")
+		os.Exit(1)
+	}
+	if payloadCipher != nil {
+		logger.V(logging.INFO).Info("Status payloads are encrypted", "keyID", payloadCipher.KeyID())
+	}
 	processorClients := worker.NewProcessorClients(
-		dbClient, pqClient, statusClient, eventClient, inferenceClient,
+		db.NewTimedDBClient(dbClient), db.NewTimedFileDBClient(fileDBClient), pqClient, dlqClient,
+		db.NewEncryptedStatusClient(statusClient, payloadCipher), eventClient,
+		filesClient, withChaos(inferenceClient),
 	)
+	for _, gateway := range cfg.InferenceGateways {
+		gatewayClient, err := inference.NewGatewayClient(gateway)
+		if err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to create inference gateway client", "gateway", gateway.Name)
+			os.Exit(1)
+		}
+		processorClients.AddInferenceClient(gateway.Name, withChaos(gatewayClient))
+		logger.V(logging.INFO).Info("Inference gateway configured", "gateway", gateway.Name, "url", gateway.URL, "models", gateway.Models)
+	}
+	for _, sinkCfg := range cfg.EventSinks {
+		sink, err := notify.NewWebhookSink(sinkCfg)
+		if err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to create event sink", "sink", sinkCfg.Name)
+			os.Exit(1)
+		}
+		processorClients.AddEventSink(sink)
+		logger.V(logging.INFO).Info("Event sink configured", "sink", sinkCfg.Name, "url", sinkCfg.URL, "format", sinkCfg.Format)
+	}
+	if cfg.EventArchive.Interval > 0 {
+		processorClients.AddEventSink(notify.NewArchiveSink(cfg.EventArchive, filesClient))
+		logger.V(logging.INFO).Info("Event archive configured", "prefix", cfg.EventArchive.Prefix, "interval", cfg.EventArchive.Interval)
+	}
+
+	processorClients.AddHealthChecks(clientset)
 
 	// initialize processor (worker pool manager)
 	// get max worker from cfg then decide the worker pool size
 	logger.V(logging.INFO).Info("Initializing worker processor", "maxWorkers", cfg.NumWorkers)
 	proc := worker.NewProcessor(cfg, &processorClients)
+	if cfg.DrainEndpointEnabled {
+		drainHandler := proc.DrainHandler
+		if len(cfg.DrainServiceAccounts) > 0 {
+			reviewer, err := kube.NewInClusterTokenReviewer()
+			if err != nil {
+				logger.V(logging.ERROR).Error(err, "Failed to create the token reviewer of the drain endpoint. Processor cannot start")
+				os.Exit(1)
+			}
+			drainHandler = kube.RequireServiceAccount(reviewer, cfg.DrainServiceAccounts, drainHandler)
+		}
+		m.HandleFunc(worker.DrainPath, drainHandler)
+	}
+
+	// apply configuration changes, e.g. of a mounted ConfigMap, without restarting
+	if cfg.ConfigReloadInterval > 0 {
+		go config.Watch(ctx, *cfgFilePath, cfg.ConfigReloadInterval, proc.Reload)
+	}
 
 	// start the main polling loop
 	// this polls for new tasks, check for empty worker slots, and assign tasks to workers
@@ -0,0 +1,379 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The entry point of batchctl, the operator tool of the batch gateway.
+// Its subcommands call the admin API of an API server to inspect and repair the batches and the queue.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/admin"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/tls"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/version"
+)
+
+const (
+	// serverEnv and adminKeyEnv are the defaults of the -server and -admin-key flags.
+	serverEnv   = "BATCHCTL_SERVER"
+	adminKeyEnv = "BATCHCTL_ADMIN_KEY"
+
+	defaultServer = "http://localhost:8000"
+)
+
+// errUsage reports invalid arguments, the usage having been printed.
+var errUsage = errors.New("invalid arguments")
+
+// command is a batchctl subcommand.
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(ctx context.Context, cli *cli, args []string) error
+}
+
+var commands = []command{
+	{"queue", "", "show the depth of the priority queue and the number of batches per status", runQueue},
+	{"batches", "[-status STATUS]", "list the batches, optionally with the given status", runBatches},
+	{"stuck", "[-older-than DURATION]", "list the non-final batches whose status hasn't changed for a while", runStuck},
+	{"requeue", "[-dead-letter] BATCH_ID", "put a batch back into the priority queue", runRequeue},
+	{"fail", "[-reason REASON] BATCH_ID", "fail a non-final batch and stop its processing", runFail},
+	{"pause", "BATCH_ID", "stop the dispatch of the requests of a batch", runPause},
+	{"resume", "BATCH_ID", "continue the dispatch of the requests of a paused batch", runResume},
+	{"dead-letters", "", "list the batches in the dead-letter queue", runDeadLetters},
+	{"version", "", "show the version of batchctl and of the API server", runVersion},
+}
+
+// cli is the state shared by the subcommands.
+type cli struct {
+	client *admin.Client
+	json   bool
+	out    io.Writer
+
+	// the usage of the running subcommand
+	usage string
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "batchctl:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("batchctl", flag.ContinueOnError)
+	flags.Usage = func() { usage(flags) }
+	server := flags.String("server", envOr(serverEnv, defaultServer), "URL of the API server, defaults to $"+serverEnv)
+	adminKey := flags.String("admin-key", os.Getenv(adminKeyEnv), "admin API key, defaults to $"+adminKeyEnv)
+	caCert := flags.String("ca-cert", "", "CA certificate file verifying the API server certificate")
+	insecure := flags.Bool("insecure", false, "skip the verification of the API server certificate")
+	timeout := flags.Duration("timeout", 30*time.Second, "time limit of the command")
+	output := flags.String("o", "table", "output format: table or json")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() == 0 {
+		usage(flags)
+		return errUsage
+	}
+	if *output != "table" && *output != "json" {
+		return fmt.Errorf("invalid output format %q, must be table or json", *output)
+	}
+	if *adminKey == "" && flags.Arg(0) != "version" {
+		return fmt.Errorf("the admin API key is required, set -admin-key or $%s", adminKeyEnv)
+	}
+
+	name := flags.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		tlsConfig, err := tls.GetTlsConfig(tls.LOAD_TYPE_CLIENT, *insecure, "", "", *caCert)
+		if err != nil {
+			return err
+		}
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+		c := &cli{
+			client: admin.NewClient(*server, *adminKey, httpClient),
+			json:   *output == "json",
+			out:    os.Stdout,
+			usage:  strings.TrimSpace(cmd.name + " " + cmd.args),
+		}
+		return cmd.run(ctx, c, flags.Args()[1:])
+	}
+	fmt.Fprintf(os.Stderr, "batchctl: unknown command %q\n", name)
+	usage(flags)
+	return errUsage
+}
+
+func usage(flags *flag.FlagSet) {
+	w := flags.Output()
+	fmt.Fprintln(w, "Usage: batchctl [flags] COMMAND [args]")
+	fmt.Fprintln(w, "\nCommands:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", cmd.name, cmd.args, cmd.summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w, "\nFlags:")
+	flags.PrintDefaults()
+}
+
+func envOr(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// parseArgs parses the flags of the subcommand and returns its positional arguments,
+// failing when their number isn't want.
+func (c *cli) parseArgs(flags *flag.FlagSet, args []string, want int) ([]string, error) {
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: batchctl %s\n", c.usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, errUsage
+	}
+	if flags.NArg() != want {
+		flags.Usage()
+		return nil, errUsage
+	}
+	return flags.Args(), nil
+}
+
+func runQueue(ctx context.Context, c *cli, args []string) error {
+	if _, err := c.parseArgs(flag.NewFlagSet("queue", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+	stats, err := c.client.GetQueueStats(ctx)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(stats)
+	}
+	tw := c.table("QUEUE DEPTH", "IN FLIGHT")
+	fmt.Fprintf(tw, "%d\t%d\n", stats.QueueDepth, stats.InFlight)
+	tw.Flush()
+	fmt.Fprintln(c.out)
+	tw = c.table("STATUS", "BATCHES")
+	for _, status := range []openai.BatchStatus{
+		openai.BatchStatusValidating, openai.BatchStatusInProgress, openai.BatchStatusFinalizing,
+		openai.BatchStatusCancelling, openai.BatchStatusCompleted, openai.BatchStatusFailed,
+		openai.BatchStatusExpired, openai.BatchStatusCancelled,
+	} {
+		fmt.Fprintf(tw, "%s\t%d\n", status, stats.ByStatus[status])
+	}
+	return tw.Flush()
+}
+
+func runBatches(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("batches", flag.ContinueOnError)
+	status := flags.String("status", "", "status of the batches")
+	if _, err := c.parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+	batches, err := c.client.ListBatches(ctx, openai.BatchStatus(*status))
+	if err != nil {
+		return err
+	}
+	return c.writeBatches(batches)
+}
+
+func runStuck(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("stuck", flag.ContinueOnError)
+	olderThan := flags.Duration("older-than", time.Hour, "time since the last status change of the batches")
+	if _, err := c.parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+	batches, err := c.client.ListStuckBatches(ctx, *olderThan)
+	if err != nil {
+		return err
+	}
+	return c.writeBatches(batches)
+}
+
+func runRequeue(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("requeue", flag.ContinueOnError)
+	deadLetter := flags.Bool("dead-letter", false, "requeue the batch from the dead-letter queue, resetting its attempts")
+	args, err := c.parseArgs(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	if *deadLetter {
+		resp, err := c.client.RequeueDeadLetter(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		if c.json {
+			return c.writeJSON(resp)
+		}
+		fmt.Fprintf(c.out, "batch %s requeued from the dead-letter queue after %d attempts\n", resp.BatchID, resp.Attempts)
+		return nil
+	}
+	batch, err := c.client.RequeueBatch(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(batch)
+	}
+	fmt.Fprintf(c.out, "batch %s requeued\n", batch.ID)
+	return nil
+}
+
+func runFail(ctx context.Context, c *cli, args []string) error {
+	flags := flag.NewFlagSet("fail", flag.ContinueOnError)
+	reason := flags.String("reason", "", "reason recorded in the batch errors")
+	args, err := c.parseArgs(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	batch, err := c.client.FailBatch(ctx, args[0], *reason)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(batch)
+	}
+	fmt.Fprintf(c.out, "batch %s failed\n", batch.ID)
+	return nil
+}
+
+func runPause(ctx context.Context, c *cli, args []string) error {
+	args, err := c.parseArgs(flag.NewFlagSet("pause", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	state, err := c.client.PauseBatch(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(state)
+	}
+	fmt.Fprintf(c.out, "batch %s paused\n", state.BatchID)
+	return nil
+}
+
+func runResume(ctx context.Context, c *cli, args []string) error {
+	args, err := c.parseArgs(flag.NewFlagSet("resume", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	state, err := c.client.ResumeBatch(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(state)
+	}
+	fmt.Fprintf(c.out, "batch %s resumed\n", state.BatchID)
+	return nil
+}
+
+func runDeadLetters(ctx context.Context, c *cli, args []string) error {
+	if _, err := c.parseArgs(flag.NewFlagSet("dead-letters", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+	deadLetters, err := c.client.ListDeadLetters(ctx)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(deadLetters)
+	}
+	tw := c.table("BATCH ID", "ATTEMPTS", "DEAD LETTERED", "ERROR")
+	for _, deadLetter := range deadLetters {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", deadLetter.BatchID, deadLetter.Attempts,
+			formatTime(deadLetter.DeadLetteredAt), deadLetter.Error)
+	}
+	return tw.Flush()
+}
+
+func runVersion(ctx context.Context, c *cli, args []string) error {
+	if _, err := c.parseArgs(flag.NewFlagSet("version", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+	client := version.Get()
+	server, err := c.client.GetServerVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(map[string]*version.Info{"client": &client, "server": server})
+	}
+	tw := c.table("COMPONENT", "VERSION", "GIT SHA", "BUILD DATE", "GO VERSION")
+	for _, row := range []struct {
+		name string
+		info *version.Info
+	}{{"batchctl", &client}, {"apiserver", server}} {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", row.name, row.info.Version, row.info.GitCommit, row.info.BuildDate, row.info.GoVersion)
+	}
+	return tw.Flush()
+}
+
+func (c *cli) writeBatches(batches []openai.Batch) error {
+	if c.json {
+		return c.writeJSON(batches)
+	}
+	tw := c.table("BATCH ID", "STATUS", "CREATED", "LAST TRANSITION", "COMPLETED", "FAILED", "TOTAL")
+	for _, batch := range batches {
+		counts := batch.RequestCounts
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t%d\n", batch.ID, batch.Status, formatTime(batch.CreatedAt),
+			formatTime(admin.LastTransition(&batch)), counts.Completed, counts.Failed, counts.Total)
+	}
+	return tw.Flush()
+}
+
+func (c *cli) writeJSON(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// table returns a writer aligning the columns of the rows written to it, after the header.
+func (c *cli) table(header ...string) *tabwriter.Writer {
+	tw := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	return tw
+}
+
+func formatTime(unix int64) string {
+	if unix == 0 {
+		return "-"
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
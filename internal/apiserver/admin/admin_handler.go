@@ -0,0 +1,722 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file provides HTTP handlers for admin API endpoints.
+// It implements operational actions on batches and the queue that are not part of the OpenAI API.
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	batchapi "github.com/llm-d-incubation/batch-gateway/internal/apiserver/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	sharedbatch "github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/kube"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+const (
+	AdminPathPrefix = "/admin/v1"
+
+	pathParamBatchID = "batch_id"
+	pathParamStatus  = "status"
+
+	// ErrorCodeForceFailed is the batch error code set when a batch is failed by an operator.
+	ErrorCodeForceFailed = "force_failed"
+)
+
+// QueueStats is the response of the queue inspection endpoint.
+type QueueStats struct {
+	// The number of batches waiting in the priority queue.
+	QueueDepth int `json:"queue_depth"`
+
+	// The number of batches that are currently being processed.
+	InFlight int `json:"in_flight"`
+
+	// The number of batches per status.
+	ByStatus map[openai.BatchStatus]int `json:"by_status"`
+}
+
+// DeadLetter is a batch moved to the dead-letter queue after it repeatedly failed to be processed.
+type DeadLetter struct {
+	// The ID of the batch.
+	BatchID string `json:"batch_id"`
+
+	// The number of times the processor failed to process the batch.
+	Attempts int `json:"attempts"`
+
+	// The error of the last attempt.
+	Error string `json:"error"`
+
+	// The failures of the attempts, oldest first.
+	Failures []DeliveryFailure `json:"failures,omitempty"`
+
+	// The Unix timestamp (in seconds) for when the batch was moved to the dead-letter queue.
+	DeadLetteredAt int64 `json:"dead_lettered_at"`
+}
+
+// DeliveryFailure is a failed attempt to process a batch.
+type DeliveryFailure struct {
+	// The Unix timestamp (in seconds) for when the attempt failed.
+	FailedAt int64 `json:"failed_at"`
+
+	// Why the attempt failed.
+	Error string `json:"error"`
+}
+
+type ListDeadLettersResponse struct {
+	Object string       `json:"object"`
+	Data   []DeadLetter `json:"data"`
+}
+
+// PauseState is the response of the pause and resume endpoints.
+type PauseState struct {
+	// The ID of the batch.
+	BatchID string `json:"batch_id"`
+
+	// Whether the dispatch of the requests of the batch is paused.
+	Paused bool `json:"paused"`
+
+	// The Unix timestamp (in seconds) for when the batch was paused, if it is paused.
+	PausedAt *int64 `json:"paused_at,omitempty"`
+}
+
+type FailBatchRequest struct {
+	// optional. The reason recorded in the batch errors.
+	Reason string `json:"reason"`
+}
+
+type AdminApiHandler struct {
+	config           *common.ServerConfig
+	dbClient         api.BatchDBClient
+	fileDBClient     api.BatchFileDBClient
+	queueClient      api.BatchPriorityQueueClient
+	deadLetterClient api.BatchDeadLetterClient
+	eventClient      api.BatchEventChannelClient
+	statusClient     api.BatchStatusClient
+	// tokenReviewer authenticates the tokens of the admin service accounts, nil when none is allowed
+	tokenReviewer *kube.TokenReviewer
+}
+
+func NewAdminApiHandler(config *common.ServerConfig, dbClient api.BatchDBClient, fileDBClient api.BatchFileDBClient, queueClient api.BatchPriorityQueueClient, deadLetterClient api.BatchDeadLetterClient, eventClient api.BatchEventChannelClient, statusClient api.BatchStatusClient) *AdminApiHandler {
+	return &AdminApiHandler{
+		config:           config,
+		dbClient:         dbClient,
+		fileDBClient:     fileDBClient,
+		queueClient:      queueClient,
+		deadLetterClient: deadLetterClient,
+		eventClient:      eventClient,
+		statusClient:     statusClient,
+	}
+}
+
+// SetTokenReviewer sets the reviewer authenticating the tokens of the service accounts allowed to call the
+// admin API (AdminServiceAccounts).
+func (c *AdminApiHandler) SetTokenReviewer(reviewer *kube.TokenReviewer) {
+	c.tokenReviewer = reviewer
+}
+
+func (c *AdminApiHandler) GetRoutes() []common.Route {
+	return []common.Route{
+		{
+			Method:      http.MethodGet,
+			Pattern:     AdminPathPrefix + "/queue",
+			HandlerFunc: c.authorize(common.AdminScopeRead, c.GetQueueStats),
+		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     AdminPathPrefix + "/batches",
+			HandlerFunc: c.authorize(common.AdminScopeRead, c.ListBatchesByStatus),
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     AdminPathPrefix + "/batches/{batch_id}/requeue",
+			HandlerFunc: c.authorize(common.AdminScopeAdmin, c.RequeueBatch),
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     AdminPathPrefix + "/batches/{batch_id}/fail",
+			HandlerFunc: c.authorize(common.AdminScopeAdmin, c.FailBatch),
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     AdminPathPrefix + "/batches/{batch_id}/pause",
+			HandlerFunc: c.authorize(common.AdminScopeWrite, c.PauseBatch),
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     AdminPathPrefix + "/batches/{batch_id}/resume",
+			HandlerFunc: c.authorize(common.AdminScopeWrite, c.ResumeBatch),
+		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     AdminPathPrefix + "/dead-letters",
+			HandlerFunc: c.authorize(common.AdminScopeRead, c.ListDeadLetters),
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     AdminPathPrefix + "/dead-letters/{batch_id}/requeue",
+			HandlerFunc: c.authorize(common.AdminScopeAdmin, c.RequeueDeadLetter),
+		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     AdminPathPrefix + "/usage",
+			HandlerFunc: c.authorize(common.AdminScopeRead, c.GetUsage),
+		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     AdminPathPrefix + "/scaling",
+			HandlerFunc: c.authorize(common.AdminScopeRead, c.GetScalingMetrics),
+		},
+	}
+}
+
+// authorize rejects requests that carry neither an admin API key nor the token of one of the admin service
+// accounts as a bearer token, and the requests whose key doesn't grant scope.
+func (c *AdminApiHandler) authorize(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := kube.BearerToken(r)
+		if token == "" {
+			apiErr := openai.NewAPIError(http.StatusUnauthorized, "", "invalid or missing admin credentials", nil)
+			common.WriteAPIError(r.Context(), w, apiErr)
+			return
+		}
+		if name, granted, ok := c.keyScope(token); ok {
+			if !common.AdminScopeGrants(granted, scope) {
+				logging.GetRequestLogger(r).V(logging.WARNING).Info("admin API key not allowed", "key", name, "scope", granted, "required", scope)
+				apiErr := openai.NewAPIError(http.StatusForbidden, "", fmt.Sprintf("the admin API key doesn't have the %s scope", scope), nil)
+				common.WriteAPIError(r.Context(), w, apiErr)
+				return
+			}
+			next(w, r)
+			return
+		}
+		if c.tokenReviewer != nil {
+			user, err := c.tokenReviewer.Review(r.Context(), token)
+			if err != nil {
+				logging.GetRequestLogger(r).Error(err, "failed to review admin token")
+				apiErr := openai.NewAPIError(http.StatusServiceUnavailable, "", "admin authentication is unavailable", nil)
+				common.WriteAPIError(r.Context(), w, apiErr)
+				return
+			}
+			if kube.Allowed(user, c.config.AdminServiceAccounts) {
+				next(w, r)
+				return
+			}
+		}
+		apiErr := openai.NewAPIError(http.StatusUnauthorized, "", "invalid or missing admin credentials", nil)
+		common.WriteAPIError(r.Context(), w, apiErr)
+	}
+}
+
+// keyScope returns the name and scope of the admin API key token, and false if token isn't an admin API key.
+// All the keys are compared, so the time taken doesn't tell which one matched.
+func (c *AdminApiHandler) keyScope(token string) (name, scope string, ok bool) {
+	if adminKey := c.config.AdminAPIKey.Value(); adminKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminKey)) == 1 {
+		name, scope, ok = "admin_api_key", common.AdminScopeAdmin, true
+	}
+	for _, key := range c.config.AdminAPIKeys {
+		value := key.Key.Value()
+		if value != "" && subtle.ConstantTimeCompare([]byte(token), []byte(value)) == 1 && !ok {
+			name, scope, ok = key.Name, key.Scope, true
+		}
+	}
+	return name, scope, ok
+}
+
+// listBatches returns all batches known to the database.
+func (c *AdminApiHandler) listBatches(r *http.Request) ([]*api.BatchJob, []*openai.Batch, error) {
+	logger := logging.GetRequestLogger(r)
+
+	jobs, _, err := c.dbClient.Get(r.Context(), nil, nil, api.TagsLogicalCondNa, true, 0, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	validJobs := make([]*api.BatchJob, 0, len(jobs))
+	batches := make([]*openai.Batch, 0, len(jobs))
+	for _, job := range jobs {
+		batch, err := batchapi.JobToBatch(job)
+		if err != nil {
+			logger.Error(err, "failed to convert job to batch", "batch_id", job.ID)
+			continue
+		}
+		validJobs = append(validJobs, job)
+		batches = append(batches, batch)
+	}
+	return validJobs, batches, nil
+}
+
+func isInFlight(status openai.BatchStatus) bool {
+	return status == openai.BatchStatusInProgress || status == openai.BatchStatusFinalizing || status == openai.BatchStatusCancelling
+}
+
+func (c *AdminApiHandler) GetQueueStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	depth, err := c.queueClient.Len(ctx)
+	if err != nil {
+		logger.Error(err, "failed to get queue length")
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	_, batches, err := c.listBatches(r)
+	if err != nil {
+		logger.Error(err, "failed to list batches from database")
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	stats := QueueStats{
+		QueueDepth: depth,
+		ByStatus:   make(map[openai.BatchStatus]int),
+	}
+	for _, batch := range batches {
+		stats.ByStatus[batch.Status]++
+		if isInFlight(batch.Status) {
+			stats.InFlight++
+		}
+	}
+
+	common.WriteJSONResponse(ctx, w, http.StatusOK, stats)
+}
+
+func (c *AdminApiHandler) ListBatchesByStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	status := openai.BatchStatus(r.URL.Query().Get(pathParamStatus))
+
+	_, batches, err := c.listBatches(r)
+	if err != nil {
+		logger.Error(err, "failed to list batches from database")
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	data := make([]openai.Batch, 0, len(batches))
+	for _, batch := range batches {
+		if status != "" && batch.Status != status {
+			continue
+		}
+		data = append(data, *batch)
+	}
+
+	resp := openai.ListBatchResponse{
+		Object: "list",
+		Data:   data,
+	}
+	if len(data) > 0 {
+		resp.FirstID = data[0].ID
+		resp.LastID = data[len(data)-1].ID
+	}
+
+	common.WriteJSONResponse(ctx, w, http.StatusOK, resp)
+}
+
+// getBatch gets a batch by the path parameter. It writes an error response and returns nil on failure.
+func (c *AdminApiHandler) getBatch(w http.ResponseWriter, r *http.Request) (*api.BatchJob, *openai.Batch) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	batchID := r.PathValue(pathParamBatchID)
+	if batchID == "" {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", pathParamBatchID+" is required", nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return nil, nil
+	}
+
+	jobs, _, err := c.dbClient.Get(ctx, []string{batchID}, nil, api.TagsLogicalCondNa, true, 0, 1)
+	if err != nil {
+		logger.Error(err, "failed to get batch from database", "batch_id", batchID)
+		common.WriteInternalServerError(ctx, w)
+		return nil, nil
+	}
+	if len(jobs) == 0 {
+		apiErr := openai.NewAPIError(http.StatusNotFound, "", fmt.Sprintf("Batch with ID %s not found", batchID), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return nil, nil
+	}
+
+	batch, err := batchapi.JobToBatch(jobs[0])
+	if err != nil {
+		logger.Error(err, "failed to convert job to batch", "batch_id", batchID)
+		common.WriteInternalServerError(ctx, w)
+		return nil, nil
+	}
+	return jobs[0], batch
+}
+
+// updateStatus persists the dynamic part of the batch to the database.
+func (c *AdminApiHandler) updateStatus(r *http.Request, job *api.BatchJob, batch *openai.Batch) error {
+	statusData, err := json.Marshal(batch.BatchStatusInfo)
+	if err != nil {
+		return err
+	}
+	job.Status = statusData
+	return c.dbClient.Update(r.Context(), job)
+}
+
+// RequeueBatch puts a non-final batch back into the priority queue, resetting its status. The batches held by a
+// processor, i.e. leased or with the heartbeat of a worker, are not requeued whatever their status, as the
+// processor would keep writing their status and results; they are requeued by the processors when their lease
+// expires, or can be failed. The batches being cancelled are not requeued either.
+func (c *AdminApiHandler) RequeueBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	job, batch := c.getBatch(w, r)
+	if job == nil {
+		return
+	}
+
+	if batch.Status.IsFinal() || batch.Status == openai.BatchStatusCancelling {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Batch with status %s cannot be requeued", batch.Status), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+	// a worker records its heartbeat until it's done with the batch, whichever queue the batch was leased from
+	heartbeat, err := c.statusClient.Get(ctx, sharedbatch.HeartbeatKey(job.ID))
+	if err != nil {
+		logger.Error(err, "failed to get batch worker heartbeat", "batch_id", job.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	if heartbeat != nil {
+		c.writeBatchHeld(w, r, job.ID)
+		return
+	}
+
+	jobPriority := &api.BatchJobPriority{
+		ID:       job.ID,
+		SLO:      job.SLO,
+		Priority: batch.Priority,
+
+		TraceContext: job.TraceContext,
+	}
+	// the batch removed from the queue can't be leased anymore, the batch that isn't in the queue may be leased,
+	// e.g. ahead of its processing while it's still validating
+	if err := c.queueClient.Remove(ctx, jobPriority); err != nil {
+		leased, leaseErr := c.queueClient.IsLeased(ctx, job.ID)
+		if leaseErr != nil {
+			logger.Error(leaseErr, "failed to get batch lease", "batch_id", job.ID)
+			common.WriteInternalServerError(ctx, w)
+			return
+		}
+		if leased {
+			c.writeBatchHeld(w, r, job.ID)
+			return
+		}
+		// the batch may not be in the queue
+		logger.V(logging.WARNING).Info("failed to remove batch from the queue", "batch_id", job.ID, "error", err.Error())
+	}
+
+	if err := c.statusClient.Delete(ctx, job.ID); err != nil {
+		logger.Error(err, "failed to reset batch status", "batch_id", job.ID)
+	}
+
+	// the status is reset and the job enqueued together, the status is restored if the job can't be enqueued
+	batch.Status = openai.BatchStatusValidating
+	batch.InProgressAt = nil
+	batch.FinalizingAt = nil
+	previousStatus := job.Status
+	statusData, err := json.Marshal(batch.BatchStatusInfo)
+	if err != nil {
+		logger.Error(err, "failed to marshal batch status", "batch_id", job.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	job.Status = statusData
+	if err := api.UpdateAndEnqueue(ctx, c.dbClient, c.queueClient, job, previousStatus, jobPriority); err != nil {
+		metrics.RecordJobPublishFailure("requeue", api.PublishStage(err))
+		logger.Error(err, "failed to requeue batch", "batch_id", job.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	logger.Info("batch requeued by admin", "batch_id", job.ID)
+	common.WriteJSONResponse(ctx, w, http.StatusOK, batch)
+}
+
+// writeBatchHeld responds that the batch is held by a processor.
+func (c *AdminApiHandler) writeBatchHeld(w http.ResponseWriter, r *http.Request, batchID string) {
+	apiErr := openai.NewAPIError(http.StatusConflict, "", fmt.Sprintf("Batch with ID %s is being processed and cannot be requeued", batchID), nil)
+	common.WriteAPIError(r.Context(), w, apiErr)
+}
+
+// FailBatch transitions a non-final batch to failed, removing it from the queue and stopping its processing.
+func (c *AdminApiHandler) FailBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	failReq := &FailBatchRequest{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(failReq); err != nil {
+			apiErr := openai.NewAPIError(http.StatusBadRequest, "", "invalid request body", nil)
+			common.WriteAPIError(ctx, w, apiErr)
+			return
+		}
+	}
+
+	job, batch := c.getBatch(w, r)
+	if job == nil {
+		return
+	}
+
+	if batch.Status.IsFinal() {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Batch with status %s cannot be failed", batch.Status), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	reason := failReq.Reason
+	if reason == "" {
+		reason = "The batch was failed by an operator"
+	}
+	now := time.Now().UTC().Unix()
+	batch.Status = openai.BatchStatusFailed
+	batch.FailedAt = &now
+	if batch.Errors == nil {
+		batch.Errors = &openai.BatchErrors{Object: "list"}
+	}
+	batch.Errors.Data = append(batch.Errors.Data, openai.BatchError{
+		Code:    ErrorCodeForceFailed,
+		Message: reason,
+	})
+	if err := c.updateStatus(r, job, batch); err != nil {
+		logger.Error(err, "failed to update batch in database", "batch_id", job.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	// Remove the job from the priority queue and stop the processor if it's working on the job.
+	if err := c.queueClient.Remove(ctx, &api.BatchJobPriority{ID: job.ID}); err != nil {
+		// the batch may not be in the queue
+		logger.V(logging.WARNING).Info("failed to remove batch from the queue", "batch_id", job.ID, "error", err.Error())
+	}
+	if _, err := c.eventClient.ProducerSendEvents(ctx, []api.BatchEvent{
+		{
+			ID:   job.ID,
+			Type: api.BatchEventCancel,
+			TTL:  c.config.BatchTTLSeconds,
+		},
+	}); err != nil {
+		// the processor keeps the failed status, but dispatches the remaining requests until it finalizes the batch
+		logger.Error(err, "failed to send batch cancel event", "batch_id", job.ID)
+	}
+
+	logger.Info("batch failed by admin", "batch_id", job.ID, "reason", reason)
+	common.WriteJSONResponse(ctx, w, http.StatusOK, batch)
+}
+
+// PauseBatch stops the dispatch of further requests of a non-final batch. The requests in flight are completed
+// and the results of the completed requests are kept until the batch is resumed.
+func (c *AdminApiHandler) PauseBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	job, batch := c.getBatch(w, r)
+	if job == nil {
+		return
+	}
+
+	if batch.Status.IsFinal() {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Batch with status %s cannot be paused", batch.Status), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	pausedAt, err := c.pausedAt(r, job.ID)
+	if err != nil {
+		logger.Error(err, "failed to get batch pause state", "batch_id", job.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	stored := pausedAt == nil
+	if stored {
+		now := time.Now().UTC().Unix()
+		pausedAt = &now
+		// the pause state is kept for the batches whose processing starts or resumes while they are paused
+		if err := c.statusClient.Set(ctx, sharedbatch.PausedKey(job.ID), c.config.BatchTTLSeconds,
+			[]byte(strconv.FormatInt(now, 10))); err != nil {
+			logger.Error(err, "failed to store batch pause state", "batch_id", job.ID)
+			common.WriteInternalServerError(ctx, w)
+			return
+		}
+	}
+
+	// Stop the dispatch of the batch requests if a processor is working on the batch.
+	if _, err := c.eventClient.ProducerSendEvents(ctx, []api.BatchEvent{
+		{
+			ID:   job.ID,
+			Type: api.BatchEventPause,
+			TTL:  c.config.BatchTTLSeconds,
+		},
+	}); err != nil {
+		logger.Error(err, "failed to send batch pause event", "batch_id", job.ID)
+		// the batch isn't left paused for the processors starting it while the processor working on it isn't
+		if stored {
+			if err := c.statusClient.Delete(ctx, sharedbatch.PausedKey(job.ID)); err != nil {
+				logger.Error(err, "failed to delete batch pause state", "batch_id", job.ID)
+			}
+		}
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	logger.Info("batch paused by admin", "batch_id", job.ID)
+	common.WriteJSONResponse(ctx, w, http.StatusOK, PauseState{BatchID: job.ID, Paused: true, PausedAt: pausedAt})
+}
+
+// ResumeBatch continues the dispatch of the requests of a paused batch.
+func (c *AdminApiHandler) ResumeBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	job, _ := c.getBatch(w, r)
+	if job == nil {
+		return
+	}
+
+	pausedAt, err := c.pausedAt(r, job.ID)
+	if err != nil {
+		logger.Error(err, "failed to get batch pause state", "batch_id", job.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	if pausedAt == nil {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Batch with ID %s is not paused", job.ID), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	// the pause state is kept until the processor working on the batch is resumed, so the resume can be retried
+	if _, err := c.eventClient.ProducerSendEvents(ctx, []api.BatchEvent{
+		{
+			ID:   job.ID,
+			Type: api.BatchEventResume,
+			TTL:  c.config.BatchTTLSeconds,
+		},
+	}); err != nil {
+		logger.Error(err, "failed to send batch resume event", "batch_id", job.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	if err := c.statusClient.Delete(ctx, sharedbatch.PausedKey(job.ID)); err != nil {
+		logger.Error(err, "failed to delete batch pause state", "batch_id", job.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	logger.Info("batch resumed by admin", "batch_id", job.ID, "paused_at", *pausedAt)
+	common.WriteJSONResponse(ctx, w, http.StatusOK, PauseState{BatchID: job.ID})
+}
+
+// pausedAt returns the time the batch was paused, or nil if it isn't paused.
+func (c *AdminApiHandler) pausedAt(r *http.Request, batchID string) (*int64, error) {
+	data, err := c.statusClient.Get(r.Context(), sharedbatch.PausedKey(batchID))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	pausedAt, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pause state %q: %w", data, err)
+	}
+	return &pausedAt, nil
+}
+
+func toDeadLetter(deadLetter *api.BatchDeadLetter) DeadLetter {
+	resp := DeadLetter{
+		BatchID:        deadLetter.ID,
+		Attempts:       deadLetter.JobPriority.Attempts,
+		Error:          deadLetter.Error,
+		DeadLetteredAt: deadLetter.DeadLetteredAt.Unix(),
+	}
+	for _, failure := range deadLetter.JobPriority.Failures {
+		resp.Failures = append(resp.Failures, DeliveryFailure{FailedAt: failure.Time.Unix(), Error: failure.Error})
+	}
+	return resp
+}
+
+func (c *AdminApiHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	deadLetters, err := c.deadLetterClient.List(ctx)
+	if err != nil {
+		logger.Error(err, "failed to list dead-letter queue")
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	resp := ListDeadLettersResponse{
+		Object: "list",
+		Data:   make([]DeadLetter, 0, len(deadLetters)),
+	}
+	for _, deadLetter := range deadLetters {
+		resp.Data = append(resp.Data, toDeadLetter(deadLetter))
+	}
+	common.WriteJSONResponse(ctx, w, http.StatusOK, resp)
+}
+
+// RequeueDeadLetter moves a batch from the dead-letter queue back to the priority queue, resetting its attempts.
+func (c *AdminApiHandler) RequeueDeadLetter(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	batchID := r.PathValue(pathParamBatchID)
+	deadLetter, err := c.deadLetterClient.Remove(ctx, batchID)
+	if err != nil {
+		logger.Error(err, "failed to remove batch from the dead-letter queue", "batch_id", batchID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	if deadLetter == nil {
+		apiErr := openai.NewAPIError(http.StatusNotFound, "", fmt.Sprintf("Batch with ID %s not found in the dead-letter queue", batchID), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	resp := toDeadLetter(deadLetter)
+	attempts, failures := deadLetter.JobPriority.Attempts, deadLetter.JobPriority.Failures
+	deadLetter.JobPriority.Attempts, deadLetter.JobPriority.Failures = 0, nil
+	if err := c.queueClient.Enqueue(ctx, deadLetter.JobPriority); err != nil {
+		logger.Error(err, "failed to enqueue batch job priority", "batch_id", batchID)
+		// keep the batch in the dead-letter queue, so it isn't lost
+		deadLetter.JobPriority.Attempts, deadLetter.JobPriority.Failures = attempts, failures
+		stage := api.PublishStageEnqueue
+		if err := c.deadLetterClient.Add(ctx, deadLetter); err != nil {
+			stage = api.PublishStageCompensate
+			logger.Error(err, "CRITICAL: failed to restore batch to the dead-letter queue", "batch_id", batchID)
+		}
+		metrics.RecordJobPublishFailure("requeue_dead_letter", stage)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	logger.Info("dead-lettered batch requeued by admin", "batch_id", batchID, "attempts", resp.Attempts)
+	common.WriteJSONResponse(ctx, w, http.StatusOK, resp)
+}
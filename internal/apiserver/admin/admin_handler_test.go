@@ -0,0 +1,572 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for admin handler.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	mockapi "github.com/llm-d-incubation/batch-gateway/internal/database/mock"
+	sharedbatch "github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/kube"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/settings"
+)
+
+const testAdminKey = "test-admin-key"
+
+// failingQueueClient fails to enqueue jobs.
+type failingQueueClient struct {
+	api.BatchPriorityQueueClient
+}
+
+func (c *failingQueueClient) Enqueue(ctx context.Context, jobPriority *api.BatchJobPriority) error {
+	return errors.New("queue unavailable")
+}
+
+// failingEventClient fails to send events.
+type failingEventClient struct {
+	api.BatchEventChannelClient
+}
+
+func (c *failingEventClient) ProducerSendEvents(ctx context.Context, events []api.BatchEvent) ([]string, error) {
+	return nil, errors.New("event channel unavailable")
+}
+
+func setupAdminApiHandlerForTest(t *testing.T) (*AdminApiHandler, *http.ServeMux) {
+	t.Helper()
+	config := &common.ServerConfig{
+		BatchTTLSeconds: 86400,
+		AdminAPIKey:     settings.NewSecret(testAdminKey),
+	}
+	handler := NewAdminApiHandler(config,
+		mockapi.NewMockBatchDBClient(),
+		mockapi.NewMockBatchFileDBClient(),
+		mockapi.NewMockBatchPriorityQueueClient(),
+		mockapi.NewMockBatchDeadLetterClient(),
+		mockapi.NewMockBatchEventChannelClient(),
+		mockapi.NewMockBatchStatusClient(),
+	)
+	mux := http.NewServeMux()
+	common.RegisterHandler(mux, handler)
+	return handler, mux
+}
+
+func storeTestBatch(t *testing.T, handler *AdminApiHandler, batchID string, status openai.BatchStatus) {
+	t.Helper()
+	specData, _ := json.Marshal(openai.BatchSpec{
+		InputFileID:      "file-abc123",
+		Endpoint:         openai.EndpointChatCompletions,
+		CompletionWindow: "24h",
+		CreatedAt:        time.Now().UTC().Unix(),
+	})
+	statusData, _ := json.Marshal(openai.BatchStatusInfo{Status: status})
+	handler.dbClient.Store(context.Background(), &api.BatchJob{
+		ID:     batchID,
+		SLO:    time.Now().UTC().Add(24 * time.Hour),
+		TTL:    86400,
+		Spec:   specData,
+		Status: statusData,
+	})
+}
+
+func newAdminRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testAdminKey)
+	return req
+}
+
+func TestAdminHandler(t *testing.T) {
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, mux := setupAdminApiHandlerForTest(t)
+		for _, auth := range []string{"", "Bearer wrong-key", testAdminKey} {
+			req := httptest.NewRequest(http.MethodGet, AdminPathPrefix+"/queue", nil)
+			if auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			if rr.Code != http.StatusUnauthorized {
+				t.Errorf("auth %q: expected status %d, got %d", auth, http.StatusUnauthorized, rr.Code)
+			}
+		}
+	})
+
+	t.Run("Scopes", func(t *testing.T) {
+		handler, mux := setupAdminApiHandlerForTest(t)
+		handler.config.AdminAPIKeys = []common.AdminAPIKeyConfig{
+			{Name: "dashboard", Key: settings.NewSecret("read-key"), Scope: common.AdminScopeRead},
+			{Name: "oncall", Key: settings.NewSecret("write-key"), Scope: common.AdminScopeWrite},
+		}
+		storeTestBatch(t, handler, "batch_scopes", openai.BatchStatusInProgress)
+
+		tests := []struct {
+			key, method, path string
+			want              int
+		}{
+			{"read-key", http.MethodGet, "/queue", http.StatusOK},
+			{"read-key", http.MethodGet, "/batches", http.StatusOK},
+			{"read-key", http.MethodPost, "/batches/batch_scopes/pause", http.StatusForbidden},
+			{"read-key", http.MethodPost, "/batches/batch_scopes/fail", http.StatusForbidden},
+			{"write-key", http.MethodGet, "/queue", http.StatusOK},
+			{"write-key", http.MethodPost, "/batches/batch_scopes/requeue", http.StatusForbidden},
+			{"write-key", http.MethodPost, "/batches/batch_scopes/fail", http.StatusForbidden},
+			{"write-key", http.MethodPost, "/batches/batch_scopes/pause", http.StatusOK},
+			{testAdminKey, http.MethodPost, "/batches/batch_scopes/fail", http.StatusOK},
+			{"unknown-key", http.MethodGet, "/queue", http.StatusUnauthorized},
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest(tt.method, AdminPathPrefix+tt.path, strings.NewReader("{}"))
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("%s %s with %s: expected status %d, got %d: %s", tt.method, tt.path, tt.key, tt.want, rr.Code, rr.Body.String())
+			}
+		}
+	})
+
+	t.Run("ServiceAccount", func(t *testing.T) {
+		// the fake Kubernetes API authenticates the token "sa-token" as the service account ops:automation
+		kubeAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var review struct {
+				Spec struct {
+					Token string `json:"token"`
+				} `json:"spec"`
+			}
+			json.NewDecoder(r.Body).Decode(&review)
+			w.WriteHeader(http.StatusCreated)
+			if review.Spec.Token == "sa-token" {
+				fmt.Fprint(w, `{"status":{"authenticated":true,"user":{"username":"system:serviceaccount:ops:automation"}}}`)
+				return
+			}
+			fmt.Fprint(w, `{"status":{"authenticated":false}}`)
+		}))
+		defer kubeAPI.Close()
+
+		handler, mux := setupAdminApiHandlerForTest(t)
+		handler.config.AdminServiceAccounts = []string{"system:serviceaccount:ops:automation"}
+		handler.SetTokenReviewer(kube.NewTokenReviewer(kubeAPI.URL, "", nil))
+		for auth, want := range map[string]int{
+			"Bearer sa-token":        http.StatusOK,
+			"Bearer " + testAdminKey: http.StatusOK,
+			"Bearer other-token":     http.StatusUnauthorized,
+		} {
+			req := httptest.NewRequest(http.MethodGet, AdminPathPrefix+"/queue", nil)
+			req.Header.Set("Authorization", auth)
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			if rr.Code != want {
+				t.Errorf("auth %q: expected status %d, got %d", auth, want, rr.Code)
+			}
+		}
+	})
+
+	t.Run("QueueStats", func(t *testing.T) {
+		handler, mux := setupAdminApiHandlerForTest(t)
+		storeTestBatch(t, handler, "batch-1", openai.BatchStatusInProgress)
+		storeTestBatch(t, handler, "batch-2", openai.BatchStatusValidating)
+		handler.queueClient.Enqueue(context.Background(), &api.BatchJobPriority{ID: "batch-2", SLO: time.Now()})
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodGet, AdminPathPrefix+"/queue", ""))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		var stats QueueStats
+		if err := json.NewDecoder(rr.Body).Decode(&stats); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if stats.QueueDepth != 1 || stats.InFlight != 1 {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+		if stats.ByStatus[openai.BatchStatusValidating] != 1 {
+			t.Errorf("expected 1 validating batch, got %d", stats.ByStatus[openai.BatchStatusValidating])
+		}
+	})
+
+	t.Run("ListBatchesByStatus", func(t *testing.T) {
+		handler, mux := setupAdminApiHandlerForTest(t)
+		storeTestBatch(t, handler, "batch-1", openai.BatchStatusInProgress)
+		storeTestBatch(t, handler, "batch-2", openai.BatchStatusCompleted)
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodGet, AdminPathPrefix+"/batches?status=in_progress", ""))
+		var resp openai.ListBatchResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if len(resp.Data) != 1 || resp.Data[0].ID != "batch-1" {
+			t.Errorf("unexpected batches: %+v", resp.Data)
+		}
+	})
+
+	t.Run("RequeueBatch", func(t *testing.T) {
+		handler, mux := setupAdminApiHandlerForTest(t)
+		ctx := context.Background()
+		storeTestBatch(t, handler, "batch-stuck", openai.BatchStatusInProgress)
+		storeTestBatch(t, handler, "batch-leased", openai.BatchStatusValidating)
+		storeTestBatch(t, handler, "batch-running", openai.BatchStatusValidating)
+		storeTestBatch(t, handler, "batch-cancelling", openai.BatchStatusCancelling)
+		storeTestBatch(t, handler, "batch-done", openai.BatchStatusCompleted)
+
+		// the batch leased ahead of its processing is still validating
+		if err := handler.queueClient.Enqueue(ctx, &api.BatchJobPriority{ID: "batch-leased"}); err != nil {
+			t.Fatalf("Failed to enqueue batch: %v", err)
+		}
+		if leased, err := handler.queueClient.Lease(ctx, 0, 1, time.Minute); err != nil || len(leased) != 1 {
+			t.Fatalf("Failed to lease batch: %v", err)
+		}
+		// the batch leased from another queue has the heartbeat of its worker
+		if err := handler.statusClient.Set(ctx, sharedbatch.HeartbeatKey("batch-running"), 60, []byte("{}")); err != nil {
+			t.Fatalf("Failed to store heartbeat: %v", err)
+		}
+
+		// the batch in progress that no processor holds is requeued
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/batches/batch-stuck/requeue", ""))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		if depth, _ := handler.queueClient.Len(ctx); depth != 1 {
+			t.Errorf("expected queue depth 1, got %d", depth)
+		}
+
+		// the batches held by a processor aren't requeued, whatever their status
+		for _, batchID := range []string{"batch-leased", "batch-running"} {
+			rr = httptest.NewRecorder()
+			mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/batches/"+batchID+"/requeue", ""))
+			if rr.Code != http.StatusConflict {
+				t.Errorf("%s: expected status %d, got %d", batchID, http.StatusConflict, rr.Code)
+			}
+		}
+		if depth, _ := handler.queueClient.Len(ctx); depth != 1 {
+			t.Errorf("expected queue depth 1, got %d", depth)
+		}
+
+		for _, batchID := range []string{"batch-cancelling", "batch-done"} {
+			rr = httptest.NewRecorder()
+			mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/batches/"+batchID+"/requeue", ""))
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status %d, got %d", batchID, http.StatusBadRequest, rr.Code)
+			}
+		}
+	})
+
+	t.Run("RequeueBatchEnqueueFailure", func(t *testing.T) {
+		handler, mux := setupAdminApiHandlerForTest(t)
+		handler.queueClient = &failingQueueClient{handler.queueClient}
+		storeTestBatch(t, handler, "batch-stuck", openai.BatchStatusInProgress)
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/batches/batch-stuck/requeue", ""))
+		if rr.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+		}
+		// the status of the batch that couldn't be queued is restored
+		jobs, _, err := handler.dbClient.Get(context.Background(), []string{"batch-stuck"}, nil, api.TagsLogicalCondNa, false, 0, 1)
+		if err != nil || len(jobs) != 1 {
+			t.Fatalf("Failed to get batch: %v", err)
+		}
+		var status openai.BatchStatusInfo
+		if err := json.Unmarshal(jobs[0].Status, &status); err != nil || status.Status != openai.BatchStatusInProgress {
+			t.Errorf("expected status %s to be restored, got %s", openai.BatchStatusInProgress, status.Status)
+		}
+	})
+
+	t.Run("FailBatch", func(t *testing.T) {
+		handler, mux := setupAdminApiHandlerForTest(t)
+		storeTestBatch(t, handler, "batch-bad", openai.BatchStatusInProgress)
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/batches/batch-bad/fail", `{"reason":"poisoned input"}`))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var batch openai.Batch
+		if err := json.NewDecoder(rr.Body).Decode(&batch); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if batch.Status != openai.BatchStatusFailed || batch.FailedAt == nil {
+			t.Errorf("expected failed batch, got status %s", batch.Status)
+		}
+		if batch.Errors == nil || len(batch.Errors.Data) != 1 || batch.Errors.Data[0].Message != "poisoned input" {
+			t.Errorf("unexpected batch errors: %+v", batch.Errors)
+		}
+
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/batches/batch-missing/fail", ""))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("PauseAndResumeBatch", func(t *testing.T) {
+		handler, mux := setupAdminApiHandlerForTest(t)
+		storeTestBatch(t, handler, "batch-running", openai.BatchStatusInProgress)
+		storeTestBatch(t, handler, "batch-done", openai.BatchStatusCompleted)
+		events, _ := handler.eventClient.ConsumerGetChannel(context.Background(), "batch-running")
+		defer events.CloseFn()
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/batches/batch-running/pause", ""))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var state PauseState
+		if err := json.NewDecoder(rr.Body).Decode(&state); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if !state.Paused || state.PausedAt == nil {
+			t.Errorf("expected paused batch, got %+v", state)
+		}
+		if data, _ := handler.statusClient.Get(context.Background(), sharedbatch.PausedKey("batch-running")); data == nil {
+			t.Error("expected pause state to be stored")
+		}
+		if event := <-events.Events; event.Type != api.BatchEventPause {
+			t.Errorf("expected pause event, got %v", event.Type)
+		}
+
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/batches/batch-running/resume", ""))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		if data, _ := handler.statusClient.Get(context.Background(), sharedbatch.PausedKey("batch-running")); data != nil {
+			t.Error("expected pause state to be deleted")
+		}
+		if event := <-events.Events; event.Type != api.BatchEventResume {
+			t.Errorf("expected resume event, got %v", event.Type)
+		}
+
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/batches/batch-running/resume", ""))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d for batch not paused, got %d", http.StatusBadRequest, rr.Code)
+		}
+
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/batches/batch-done/pause", ""))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status %d for final batch, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("PauseAndResumeBatchEventFailure", func(t *testing.T) {
+		handler, mux := setupAdminApiHandlerForTest(t)
+		eventClient := handler.eventClient
+		handler.eventClient = &failingEventClient{eventClient}
+		storeTestBatch(t, handler, "batch-running", openai.BatchStatusInProgress)
+
+		// the batch isn't paused if the processor working on it can't be paused
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/batches/batch-running/pause", ""))
+		if rr.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+		}
+		if data, _ := handler.statusClient.Get(context.Background(), sharedbatch.PausedKey("batch-running")); data != nil {
+			t.Error("expected pause state to be deleted")
+		}
+
+		// the batch stays paused if the processor working on it can't be resumed, so the resume can be retried
+		if err := handler.statusClient.Set(context.Background(), sharedbatch.PausedKey("batch-running"), 60, []byte("1700000000")); err != nil {
+			t.Fatalf("Failed to store pause state: %v", err)
+		}
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/batches/batch-running/resume", ""))
+		if rr.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+		}
+		if data, _ := handler.statusClient.Get(context.Background(), sharedbatch.PausedKey("batch-running")); data == nil {
+			t.Error("expected pause state to be kept")
+		}
+
+		handler.eventClient = eventClient
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/batches/batch-running/resume", ""))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		if data, _ := handler.statusClient.Get(context.Background(), sharedbatch.PausedKey("batch-running")); data != nil {
+			t.Error("expected pause state to be deleted")
+		}
+	})
+
+	t.Run("DeadLetters", func(t *testing.T) {
+		handler, mux := setupAdminApiHandlerForTest(t)
+		handler.deadLetterClient.Add(context.Background(), &api.BatchDeadLetter{
+			ID: "batch-poisoned",
+			JobPriority: &api.BatchJobPriority{ID: "batch-poisoned", SLO: time.Now(), Attempts: 3, Failures: []api.BatchDeliveryFailure{
+				{Time: time.Now(), Error: "lease expired"},
+				{Time: time.Now(), Error: "panic while processing job"},
+			}},
+			Error:          "panic while processing job",
+			DeadLetteredAt: time.Now(),
+		})
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodGet, AdminPathPrefix+"/dead-letters", ""))
+		var resp ListDeadLettersResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if len(resp.Data) != 1 || resp.Data[0].BatchID != "batch-poisoned" || resp.Data[0].Attempts != 3 {
+			t.Fatalf("unexpected dead letters: %+v", resp.Data)
+		}
+		if failures := resp.Data[0].Failures; len(failures) != 2 || failures[1].Error != "panic while processing job" || failures[1].FailedAt == 0 {
+			t.Errorf("unexpected failures: %+v", failures)
+		}
+
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/dead-letters/batch-poisoned/requeue", ""))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		tasks, _ := handler.queueClient.Dequeue(context.Background(), 0, 1)
+		if len(tasks) != 1 || tasks[0].ID != "batch-poisoned" || tasks[0].Attempts != 0 || tasks[0].Failures != nil {
+			t.Errorf("unexpected queue content: %+v", tasks)
+		}
+		if deadLetters, _ := handler.deadLetterClient.List(context.Background()); len(deadLetters) != 0 {
+			t.Errorf("expected empty dead-letter queue, got %d entries", len(deadLetters))
+		}
+
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodPost, AdminPathPrefix+"/dead-letters/batch-poisoned/requeue", ""))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		handler, mux := setupAdminApiHandlerForTest(t)
+		now := time.Now().UTC().Unix()
+		storeUsageBatch := func(batchID, tenantID string, status openai.BatchStatus, finishedAt int64, inputFileID string) {
+			specData, _ := json.Marshal(openai.BatchSpec{InputFileID: inputFileID, TenantID: tenantID, CreatedAt: finishedAt - 60})
+			statusInfo := openai.BatchStatusInfo{
+				Status:        status,
+				RequestCounts: openai.BatchRequestCounts{Total: 3, Completed: 2, Failed: 1},
+				Usage:         &openai.BatchUsage{InputTokens: 10, OutputTokens: 20, TotalTokens: 30},
+			}
+			switch status {
+			case openai.BatchStatusCompleted:
+				statusInfo.CompletedAt = &finishedAt
+			case openai.BatchStatusFailed:
+				statusInfo.FailedAt = &finishedAt
+			}
+			statusData, _ := json.Marshal(statusInfo)
+			handler.dbClient.Store(context.Background(), &api.BatchJob{
+				ID: batchID, SLO: time.Now().Add(time.Hour), TTL: 86400, Spec: specData, Status: statusData,
+			})
+		}
+		storeUsageBatch("batch-1", "team-a", openai.BatchStatusCompleted, now-100, "file-a")
+		storeUsageBatch("batch-2", "team-a", openai.BatchStatusFailed, now-50, "file-a")
+		storeUsageBatch("batch-3", "team-b", openai.BatchStatusCompleted, now-50, "file-b")
+		storeUsageBatch("batch-old", "team-a", openai.BatchStatusCompleted, now-10000, "file-old")
+		storeUsageBatch("batch-running", "team-a", openai.BatchStatusInProgress, now, "file-running")
+		fileSpec, _ := json.Marshal(openai.FileObject{ID: "file-a", Bytes: 1000})
+		handler.fileDBClient.Store(context.Background(), &api.BatchFile{ID: "file-a", TTL: 86400, Spec: fileSpec})
+
+		rr := httptest.NewRecorder()
+		path := fmt.Sprintf("%s/usage?start=%d&end=%d", AdminPathPrefix, now-1000, now+1)
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodGet, path, ""))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var resp UsageResponse
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if len(resp.Data) != 2 || resp.Data[0].TenantID != "team-a" || resp.Data[1].TenantID != "team-b" {
+			t.Fatalf("unexpected usage: %+v", resp.Data)
+		}
+		teamA := resp.Data[0]
+		if teamA.Batches[openai.BatchStatusCompleted] != 1 || teamA.Batches[openai.BatchStatusFailed] != 1 ||
+			teamA.CompletedRequests != 4 || teamA.FailedRequests != 2 || teamA.TotalTokens != 60 || teamA.StoredBytes != 1000 {
+			t.Errorf("unexpected usage of team-a: %+v", teamA)
+		}
+
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodGet, path+"&tenant=team-b", ""))
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if len(resp.Data) != 1 || resp.Data[0].TenantID != "team-b" || resp.Data[0].StoredBytes != 0 {
+			t.Errorf("unexpected usage of team-b: %+v", resp.Data)
+		}
+
+		for _, query := range []string{"start=abc", fmt.Sprintf("start=%d&end=%d", now, now-1)} {
+			rr = httptest.NewRecorder()
+			mux.ServeHTTP(rr, newAdminRequest(http.MethodGet, AdminPathPrefix+"/usage?"+query, ""))
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("query %q: expected status %d, got %d", query, http.StatusBadRequest, rr.Code)
+			}
+		}
+	})
+
+	t.Run("ScalingMetrics", func(t *testing.T) {
+		handler, mux := setupAdminApiHandlerForTest(t)
+		storeScalingBatch := func(batchID string, status openai.BatchStatus, models map[string]int64, counts openai.BatchRequestCounts) {
+			specData, _ := json.Marshal(openai.BatchSpec{InputFileID: "file-abc123", ModelRequestCounts: models})
+			statusData, _ := json.Marshal(openai.BatchStatusInfo{Status: status, RequestCounts: counts})
+			handler.dbClient.Store(context.Background(), &api.BatchJob{
+				ID: batchID, SLO: time.Now().Add(time.Hour), TTL: 86400, Spec: specData, Status: statusData,
+			})
+		}
+		storeScalingBatch("batch-queued", openai.BatchStatusValidating, map[string]int64{"m1": 30, "m2": 10}, openai.BatchRequestCounts{})
+		storeScalingBatch("batch-running", openai.BatchStatusInProgress, map[string]int64{"m1": 100},
+			openai.BatchRequestCounts{Total: 100, Completed: 55, Failed: 5})
+		storeScalingBatch("batch-legacy", openai.BatchStatusInProgress, nil, openai.BatchRequestCounts{Total: 8, Completed: 3})
+		storeScalingBatch("batch-done", openai.BatchStatusCompleted, map[string]int64{"m1": 100},
+			openai.BatchRequestCounts{Total: 100, Completed: 100})
+		handler.queueClient.Enqueue(context.Background(), &api.BatchJobPriority{ID: "batch-queued", SLO: time.Now()})
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, newAdminRequest(http.MethodGet, AdminPathPrefix+"/scaling", ""))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+		}
+		var resp ScalingMetrics
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		want := ScalingMetrics{
+			QueueDepth:      1,
+			InFlight:        2,
+			PendingBatches:  3,
+			PendingRequests: 40 + 40 + 5,
+			Models: map[string]ModelBacklog{
+				"m1": {PendingBatches: 2, PendingRequests: 30 + 40},
+				"m2": {PendingBatches: 1, PendingRequests: 10},
+			},
+		}
+		if !reflect.DeepEqual(resp, want) {
+			t.Errorf("expected %+v, got %+v", want, resp)
+		}
+	})
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file provides a client of the admin API endpoints, used by the batchctl operator tool.
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/version"
+)
+
+// Client calls the admin API of an API server.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient creates a client of the admin API of the API server at baseURL, authenticated with the admin API key.
+// The default HTTP client is used when httpClient is nil.
+func NewClient(baseURL, apiKey string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: httpClient,
+	}
+}
+
+// GetQueueStats returns the depth of the priority queue and the number of batches per status.
+func (c *Client) GetQueueStats(ctx context.Context) (*QueueStats, error) {
+	stats := &QueueStats{}
+	if err := c.do(ctx, http.MethodGet, AdminPathPrefix+"/queue", nil, stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// ListBatches returns the batches with the given status, or all the batches when status is empty.
+func (c *Client) ListBatches(ctx context.Context, status openai.BatchStatus) ([]openai.Batch, error) {
+	path := AdminPathPrefix + "/batches"
+	if status != "" {
+		path += "?" + url.Values{pathParamStatus: {string(status)}}.Encode()
+	}
+	resp := &openai.ListBatchResponse{}
+	if err := c.do(ctx, http.MethodGet, path, nil, resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// ListStuckBatches returns the non-final batches whose status hasn't changed for longer than olderThan.
+func (c *Client) ListStuckBatches(ctx context.Context, olderThan time.Duration) ([]openai.Batch, error) {
+	batches, err := c.ListBatches(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-olderThan).Unix()
+	stuck := make([]openai.Batch, 0, len(batches))
+	for _, batch := range batches {
+		if !batch.Status.IsFinal() && LastTransition(&batch) < cutoff {
+			stuck = append(stuck, batch)
+		}
+	}
+	return stuck, nil
+}
+
+// LastTransition returns the Unix timestamp (in seconds) of the last status change of a non-final batch.
+func LastTransition(batch *openai.Batch) int64 {
+	last := batch.CreatedAt
+	for _, at := range []*int64{batch.InProgressAt, batch.FinalizingAt, batch.CancellingAt} {
+		if at != nil && *at > last {
+			last = *at
+		}
+	}
+	return last
+}
+
+// RequeueBatch puts a non-final batch back into the priority queue, resetting its status.
+func (c *Client) RequeueBatch(ctx context.Context, batchID string) (*openai.Batch, error) {
+	batch := &openai.Batch{}
+	if err := c.do(ctx, http.MethodPost, AdminPathPrefix+"/batches/"+url.PathEscape(batchID)+"/requeue", nil, batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// FailBatch transitions a non-final batch to failed. The default reason is recorded when reason is empty.
+func (c *Client) FailBatch(ctx context.Context, batchID, reason string) (*openai.Batch, error) {
+	batch := &openai.Batch{}
+	if err := c.do(ctx, http.MethodPost, AdminPathPrefix+"/batches/"+url.PathEscape(batchID)+"/fail", &FailBatchRequest{Reason: reason}, batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// PauseBatch stops the dispatch of further requests of a non-final batch.
+func (c *Client) PauseBatch(ctx context.Context, batchID string) (*PauseState, error) {
+	state := &PauseState{}
+	if err := c.do(ctx, http.MethodPost, AdminPathPrefix+"/batches/"+url.PathEscape(batchID)+"/pause", nil, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// ResumeBatch continues the dispatch of the requests of a paused batch.
+func (c *Client) ResumeBatch(ctx context.Context, batchID string) (*PauseState, error) {
+	state := &PauseState{}
+	if err := c.do(ctx, http.MethodPost, AdminPathPrefix+"/batches/"+url.PathEscape(batchID)+"/resume", nil, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// ListDeadLetters returns the batches in the dead-letter queue.
+func (c *Client) ListDeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	resp := &ListDeadLettersResponse{}
+	if err := c.do(ctx, http.MethodGet, AdminPathPrefix+"/dead-letters", nil, resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// RequeueDeadLetter moves a batch from the dead-letter queue back to the priority queue.
+func (c *Client) RequeueDeadLetter(ctx context.Context, batchID string) (*DeadLetter, error) {
+	deadLetter := &DeadLetter{}
+	if err := c.do(ctx, http.MethodPost, AdminPathPrefix+"/dead-letters/"+url.PathEscape(batchID)+"/requeue", nil, deadLetter); err != nil {
+		return nil, err
+	}
+	return deadLetter, nil
+}
+
+// GetServerVersion returns the version and build information of the API server.
+func (c *Client) GetServerVersion(ctx context.Context) (*version.Info, error) {
+	info := &version.Info{}
+	if err := c.do(ctx, http.MethodGet, version.Path, nil, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// do sends a request to the endpoint at path, with the JSON encoding of body if it isn't nil,
+// and decodes the JSON response into out. The API error of a failed request is returned as an error.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		errResp := &openai.ErrorResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(errResp); err != nil || errResp.Error.Message == "" {
+			return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+		}
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, errResp.Error.Message)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: invalid response: %w", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for the admin API client.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+func setupAdminClientForTest(t *testing.T) (*AdminApiHandler, *Client) {
+	t.Helper()
+	handler, mux := setupAdminApiHandlerForTest(t)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return handler, NewClient(server.URL+"/", testAdminKey, server.Client())
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, mux := setupAdminApiHandlerForTest(t)
+		server := httptest.NewServer(mux)
+		defer server.Close()
+		_, err := NewClient(server.URL, "wrong-key", nil).GetQueueStats(ctx)
+		if err == nil || !strings.Contains(err.Error(), "invalid or missing admin credentials") {
+			t.Errorf("expected the API error, got %v", err)
+		}
+	})
+
+	t.Run("QueueStatsAndBatches", func(t *testing.T) {
+		handler, client := setupAdminClientForTest(t)
+		storeTestBatch(t, handler, "batch-1", openai.BatchStatusInProgress)
+		storeTestBatch(t, handler, "batch-2", openai.BatchStatusCompleted)
+
+		stats, err := client.GetQueueStats(ctx)
+		if err != nil {
+			t.Fatalf("GetQueueStats failed: %v", err)
+		}
+		if stats.InFlight != 1 || stats.ByStatus[openai.BatchStatusCompleted] != 1 {
+			t.Errorf("unexpected queue stats: %+v", stats)
+		}
+
+		batches, err := client.ListBatches(ctx, openai.BatchStatusInProgress)
+		if err != nil {
+			t.Fatalf("ListBatches failed: %v", err)
+		}
+		if len(batches) != 1 || batches[0].ID != "batch-1" {
+			t.Errorf("unexpected batches: %+v", batches)
+		}
+	})
+
+	t.Run("StuckBatches", func(t *testing.T) {
+		handler, client := setupAdminClientForTest(t)
+		storeTestBatch(t, handler, "batch-recent", openai.BatchStatusInProgress)
+		old := time.Now().Add(-2 * time.Hour).Unix()
+		specData, _ := json.Marshal(openai.BatchSpec{
+			InputFileID:      "file-abc123",
+			Endpoint:         openai.EndpointChatCompletions,
+			CompletionWindow: "24h",
+			CreatedAt:        old,
+		})
+		for _, info := range []openai.BatchStatusInfo{
+			{Status: openai.BatchStatusInProgress, InProgressAt: &old},
+			{Status: openai.BatchStatusFailed, FailedAt: &old},
+		} {
+			statusData, _ := json.Marshal(info)
+			handler.dbClient.Store(ctx, &api.BatchJob{
+				ID:     "batch-old-" + string(info.Status),
+				SLO:    time.Now().Add(22 * time.Hour),
+				TTL:    86400,
+				Spec:   specData,
+				Status: statusData,
+			})
+		}
+
+		stuck, err := client.ListStuckBatches(ctx, time.Hour)
+		if err != nil {
+			t.Fatalf("ListStuckBatches failed: %v", err)
+		}
+		if len(stuck) != 1 || stuck[0].ID != "batch-old-in_progress" {
+			t.Errorf("expected only the old in progress batch, got %+v", stuck)
+		}
+	})
+
+	t.Run("Actions", func(t *testing.T) {
+		handler, client := setupAdminClientForTest(t)
+		storeTestBatch(t, handler, "batch-1", openai.BatchStatusValidating)
+
+		if batch, err := client.RequeueBatch(ctx, "batch-1"); err != nil || batch.Status != openai.BatchStatusValidating {
+			t.Fatalf("RequeueBatch: unexpected batch %+v, error %v", batch, err)
+		}
+		if state, err := client.PauseBatch(ctx, "batch-1"); err != nil || !state.Paused {
+			t.Fatalf("PauseBatch: unexpected state %+v, error %v", state, err)
+		}
+		if state, err := client.ResumeBatch(ctx, "batch-1"); err != nil || state.Paused {
+			t.Fatalf("ResumeBatch: unexpected state %+v, error %v", state, err)
+		}
+		batch, err := client.FailBatch(ctx, "batch-1", "bad input")
+		if err != nil || batch.Status != openai.BatchStatusFailed {
+			t.Fatalf("FailBatch: unexpected batch %+v, error %v", batch, err)
+		}
+		if batch.Errors == nil || batch.Errors.Data[0].Message != "bad input" {
+			t.Errorf("expected the reason in the batch errors, got %+v", batch.Errors)
+		}
+
+		_, err = client.RequeueBatch(ctx, "batch-1")
+		if err == nil || !strings.Contains(err.Error(), "cannot be requeued") {
+			t.Errorf("expected the API error, got %v", err)
+		}
+		_, err = client.PauseBatch(ctx, "batch-missing")
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Errorf("expected the not found error, got %v", err)
+		}
+	})
+
+	t.Run("DeadLetters", func(t *testing.T) {
+		handler, client := setupAdminClientForTest(t)
+		handler.deadLetterClient.Add(ctx, &api.BatchDeadLetter{
+			ID:             "batch-poisoned",
+			JobPriority:    &api.BatchJobPriority{ID: "batch-poisoned", SLO: time.Now(), Attempts: 3},
+			Error:          "panic while processing job",
+			DeadLetteredAt: time.Now(),
+		})
+
+		deadLetters, err := client.ListDeadLetters(ctx)
+		if err != nil {
+			t.Fatalf("ListDeadLetters failed: %v", err)
+		}
+		if len(deadLetters) != 1 || deadLetters[0].BatchID != "batch-poisoned" {
+			t.Fatalf("unexpected dead letters: %+v", deadLetters)
+		}
+		deadLetter, err := client.RequeueDeadLetter(ctx, "batch-poisoned")
+		if err != nil || deadLetter.Attempts != 3 {
+			t.Fatalf("RequeueDeadLetter: unexpected dead letter %+v, error %v", deadLetter, err)
+		}
+		if depth, _ := handler.queueClient.Len(ctx); depth != 1 {
+			t.Errorf("expected the batch to be requeued, got queue depth %d", depth)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file provides the admin endpoint reporting the pending work of the processors, so they can be scaled on it,
+// e.g. by the metrics-api scaler of KEDA.
+
+package admin
+
+import (
+	"net/http"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// ScalingMetrics is the response of the scaling endpoint. With the KEDA metrics-api scaler, its values are read with
+// a valueLocation such as "pending_requests" or "models.<model>.pending_requests" (dots in model names escaped).
+type ScalingMetrics struct {
+	// The number of batches waiting in the priority queue.
+	QueueDepth int `json:"queue_depth"`
+
+	// The number of batches that are currently being processed.
+	InFlight int `json:"in_flight"`
+
+	// The number of batches validating or in progress.
+	PendingBatches int `json:"pending_batches"`
+
+	// The number of requests of the pending batches that are not completed yet.
+	PendingRequests int64 `json:"pending_requests"`
+
+	// The backlog per model, of the batches whose requests were counted per model when they were created.
+	Models map[string]ModelBacklog `json:"models"`
+}
+
+// ModelBacklog is the pending work of the processors for a model.
+type ModelBacklog struct {
+	// The number of pending batches with requests for the model.
+	PendingBatches int `json:"pending_batches"`
+
+	// The estimated number of requests for the model that are not completed yet. The requests of a batch in
+	// progress are assumed to complete at the same pace for each of its models.
+	PendingRequests int64 `json:"pending_requests"`
+}
+
+// GetScalingMetrics reports the pending work of the processors: the queue depth, the batches waiting or in progress
+// and their requests not completed yet, in total and per model.
+func (c *AdminApiHandler) GetScalingMetrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	depth, err := c.queueClient.Len(ctx)
+	if err != nil {
+		logger.Error(err, "failed to get queue length")
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	_, batches, err := c.listBatches(r)
+	if err != nil {
+		logger.Error(err, "failed to list batches from database")
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	resp := ScalingMetrics{
+		QueueDepth: depth,
+		Models:     make(map[string]ModelBacklog),
+	}
+	for _, batch := range batches {
+		if isInFlight(batch.Status) {
+			resp.InFlight++
+		}
+		if batch.Status != openai.BatchStatusValidating && batch.Status != openai.BatchStatusInProgress {
+			continue
+		}
+		pending, byModel := pendingRequests(batch)
+		resp.PendingBatches++
+		resp.PendingRequests += pending
+		for model, requests := range byModel {
+			backlog := resp.Models[model]
+			backlog.PendingBatches++
+			backlog.PendingRequests += requests
+			resp.Models[model] = backlog
+		}
+	}
+
+	common.WriteJSONResponse(ctx, w, http.StatusOK, resp)
+}
+
+// pendingRequests returns the number of requests of a batch that are not completed yet, and its estimate per model.
+func pendingRequests(batch *openai.Batch) (int64, map[string]int64) {
+	var counted int64
+	for _, requests := range batch.ModelRequestCounts {
+		counted += requests
+	}
+	total := batch.RequestCounts.Total
+	if total == 0 {
+		// the processing didn't start, the requests were counted when the batch was created
+		total = counted
+	}
+	pending := max(total-batch.RequestCounts.Completed-batch.RequestCounts.Failed, 0)
+	if counted == 0 {
+		return pending, nil
+	}
+
+	byModel := make(map[string]int64, len(batch.ModelRequestCounts))
+	for model, requests := range batch.ModelRequestCounts {
+		byModel[model] = requests * pending / counted
+	}
+	return pending, byModel
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file provides the admin endpoint reporting the usage of the tenants over a time range, so platform
+// teams can bill their internal customers.
+
+package admin
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+const (
+	queryParamStart  = "start"
+	queryParamEnd    = "end"
+	queryParamTenant = "tenant"
+)
+
+// TenantUsage is the usage of a tenant by the batches that reached a final status in a time range.
+type TenantUsage struct {
+	// The ID of the tenant.
+	TenantID string `json:"tenant_id"`
+
+	// The number of batches, by final status.
+	Batches map[openai.BatchStatus]int `json:"batches"`
+
+	// The number of requests of the batches that succeeded and failed.
+	CompletedRequests int64 `json:"completed_requests"`
+	FailedRequests    int64 `json:"failed_requests"`
+
+	// The tokens used by the successful requests of the batches.
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+	TotalTokens  int64 `json:"total_tokens"`
+
+	// The size of the input and result files of the batches that are still stored.
+	StoredBytes int64 `json:"stored_bytes"`
+}
+
+// UsageResponse is the response of the usage endpoint.
+type UsageResponse struct {
+	Object string `json:"object"`
+
+	// The Unix timestamps (in seconds) of the start (inclusive) and end (exclusive) of the time range.
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+
+	// The usage of each tenant, sorted by tenant ID.
+	Data []TenantUsage `json:"data"`
+}
+
+// GetUsage aggregates the usage of the tenants over the time range given by the start and end query parameters,
+// Unix timestamps in seconds defaulting to the epoch and now. A batch counts in the range in which it reached its
+// final status. The tenant query parameter restricts the response to a tenant.
+// The usage is computed from the batches still stored in the database, so the range should not go further back
+// than the batch TTL.
+func (c *AdminApiHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	start, end, err := usageRange(r)
+	if err != nil {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", err.Error(), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+	tenantFilter := r.URL.Query().Get(queryParamTenant)
+
+	_, batches, err := c.listBatches(r)
+	if err != nil {
+		logger.Error(err, "failed to list batches from database")
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	usages := map[string]*TenantUsage{}
+	fileTenants := map[string]string{}
+	for _, batch := range batches {
+		finishedAt := finalStatusTime(batch)
+		if finishedAt == nil || *finishedAt < start || *finishedAt >= end {
+			continue
+		}
+		tenantID := batch.TenantID
+		if tenantID == "" {
+			tenantID = common.DefaultTenantID
+		}
+		if tenantFilter != "" && tenantID != tenantFilter {
+			continue
+		}
+		usage, ok := usages[tenantID]
+		if !ok {
+			usage = &TenantUsage{TenantID: tenantID, Batches: map[openai.BatchStatus]int{}}
+			usages[tenantID] = usage
+		}
+		usage.Batches[batch.Status]++
+		usage.CompletedRequests += batch.RequestCounts.Completed
+		usage.FailedRequests += batch.RequestCounts.Failed
+		if batch.Usage != nil {
+			usage.InputTokens += batch.Usage.InputTokens
+			usage.OutputTokens += batch.Usage.OutputTokens
+			usage.TotalTokens += batch.Usage.TotalTokens
+		}
+		for _, fileID := range batchFileIDs(batch) {
+			fileTenants[fileID] = tenantID
+		}
+	}
+
+	if len(fileTenants) > 0 {
+		fileIDs := make([]string, 0, len(fileTenants))
+		for fileID := range fileTenants {
+			fileIDs = append(fileIDs, fileID)
+		}
+		files, _, err := c.fileDBClient.Get(ctx, fileIDs, nil, api.TagsLogicalCondNa, 0, 0)
+		if err != nil {
+			logger.Error(err, "failed to get files from database")
+			common.WriteInternalServerError(ctx, w)
+			return
+		}
+		for _, file := range files {
+			var fileObj openai.FileObject
+			if err := json.Unmarshal(file.Spec, &fileObj); err != nil {
+				logger.Error(err, "failed to unmarshal file object", "file_id", file.ID)
+				continue
+			}
+			usages[fileTenants[file.ID]].StoredBytes += fileObj.Bytes
+		}
+	}
+
+	resp := UsageResponse{
+		Object: "list",
+		Start:  start,
+		End:    end,
+		Data:   make([]TenantUsage, 0, len(usages)),
+	}
+	for _, usage := range usages {
+		resp.Data = append(resp.Data, *usage)
+	}
+	slices.SortFunc(resp.Data, func(a, b TenantUsage) int {
+		return cmp.Compare(a.TenantID, b.TenantID)
+	})
+	common.WriteJSONResponse(ctx, w, http.StatusOK, resp)
+}
+
+// usageRange returns the time range of the usage request.
+func usageRange(r *http.Request) (start, end int64, err error) {
+	end = time.Now().UTC().Unix()
+	query := r.URL.Query()
+	if v := query.Get(queryParamStart); v != "" {
+		if start, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid %s value: %s", queryParamStart, v)
+		}
+	}
+	if v := query.Get(queryParamEnd); v != "" {
+		if end, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid %s value: %s", queryParamEnd, v)
+		}
+	}
+	if end <= start {
+		return 0, 0, fmt.Errorf("%s must be after %s", queryParamEnd, queryParamStart)
+	}
+	return start, end, nil
+}
+
+// finalStatusTime returns the time a batch reached its final status, nil if it isn't in a final status.
+func finalStatusTime(batch *openai.Batch) *int64 {
+	switch batch.Status {
+	case openai.BatchStatusCompleted:
+		return batch.CompletedAt
+	case openai.BatchStatusFailed:
+		return batch.FailedAt
+	case openai.BatchStatusExpired:
+		return batch.ExpiredAt
+	case openai.BatchStatusCancelled:
+		return batch.CancelledAt
+	}
+	return nil
+}
+
+// batchFileIDs returns the IDs of the input and result files of a batch.
+func batchFileIDs(batch *openai.Batch) []string {
+	ids := []string{batch.InputFileID, batch.OutputFileID, batch.ErrorFileID, batch.OutputManifestFileID}
+	ids = append(ids, batch.OutputFileIDs...)
+	ids = append(ids, batch.ErrorFileIDs...)
+	return slices.DeleteFunc(ids, func(id string) bool { return id == "" })
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file defines audit events of mutating API calls and the sinks they are written to.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	filesapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
+	"k8s.io/klog/v2"
+)
+
+const (
+	SinkLog   = "log"
+	SinkFiles = "files_store"
+
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+
+	// FilesLocationPrefix is the files store location under which the files sink writes the audit events.
+	FilesLocationPrefix = "audit/"
+
+	// maxBufferedEvents bounds the number of events the files sink holds before flushing.
+	maxBufferedEvents = 1000
+)
+
+// Actor identifies who made an API call.
+type Actor struct {
+	Tenant     string `json:"tenant"`
+	RemoteAddr string `json:"remote_addr"`
+	UserAgent  string `json:"user_agent,omitempty"`
+}
+
+// Event is the audit record of a mutating API call.
+type Event struct {
+	Time       string `json:"time"`
+	RequestID  string `json:"request_id"`
+	Actor      Actor  `json:"actor"`
+	Action     string `json:"action"` // The matched route, e.g. "POST /v1/batches/{batch_id}/cancel"
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	ResourceID string `json:"resource_id,omitempty"`
+	Status     int    `json:"status"`
+	Result     string `json:"result"`
+}
+
+// Sink receives audit events. Emit is called concurrently and must not block on slow backends for long.
+type Sink interface {
+	Emit(ctx context.Context, event *Event) error
+	Close() error
+}
+
+// LogSink writes every audit event as a JSON line.
+type LogSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewLogSink(out io.Writer) *LogSink {
+	return &LogSink{enc: json.NewEncoder(out)}
+}
+
+func (s *LogSink) Emit(ctx context.Context, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(event)
+}
+
+func (s *LogSink) Close() error {
+	return nil
+}
+
+// FilesSink buffers audit events and writes them to the files store as JSONL files under FilesLocationPrefix.
+// The buffer is written every flushInterval, when it holds maxBufferedEvents events, and on Close.
+type FilesSink struct {
+	logger      klog.Logger
+	filesClient filesapi.BatchFilesClient
+
+	mu     sync.Mutex
+	events []*Event
+
+	flushCh chan struct{}
+	stopCh  chan struct{}
+	doneCh  chan struct{}
+}
+
+func NewFilesSink(filesClient filesapi.BatchFilesClient, flushInterval time.Duration) *FilesSink {
+	s := &FilesSink{
+		logger:      klog.Background().WithName("audit"),
+		filesClient: filesClient,
+		flushCh:     make(chan struct{}, 1),
+		stopCh:      make(chan struct{}),
+		doneCh:      make(chan struct{}),
+	}
+	go s.run(flushInterval)
+	return s
+}
+
+func (s *FilesSink) Emit(ctx context.Context, event *Event) error {
+	s.mu.Lock()
+	s.events = append(s.events, event)
+	full := len(s.events) >= maxBufferedEvents
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close writes the buffered events and stops the sink.
+func (s *FilesSink) Close() error {
+	close(s.stopCh)
+	<-s.doneCh
+	return s.flush(context.Background())
+}
+
+func (s *FilesSink) run(flushInterval time.Duration) {
+	defer close(s.doneCh)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+		case <-s.flushCh:
+		}
+		if err := s.flush(context.Background()); err != nil {
+			s.logger.Error(err, "failed to write audit events")
+		}
+	}
+}
+
+func (s *FilesSink) flush(ctx context.Context) error {
+	s.mu.Lock()
+	events := s.events
+	s.events = nil
+	s.mu.Unlock()
+	if len(events) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+	location := fmt.Sprintf("%s%d_%s.jsonl", FilesLocationPrefix, time.Now().UnixNano(), uuid.NewString())
+	if _, err := s.filesClient.Store(ctx, location, 0, &buf); err != nil {
+		// keep the events, so they are written with the next flush
+		s.mu.Lock()
+		s.events = append(events, s.events...)
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
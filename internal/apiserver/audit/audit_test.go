@@ -0,0 +1,75 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for the audit sinks.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	fsapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/fs"
+)
+
+func TestFilesSink(t *testing.T) {
+	ctx := context.Background()
+	filesClient, err := fsapi.NewFSFilesClient(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create files client: %v", err)
+	}
+
+	sink := NewFilesSink(filesClient, time.Hour)
+	for _, id := range []string{"batch_1", "batch_2"} {
+		if err := sink.Emit(ctx, &Event{Action: "POST /v1/batches", ResourceID: id, Result: ResultSuccess}); err != nil {
+			t.Fatalf("Emit() error = %v", err)
+		}
+	}
+	// the events are written on close, before the flush interval passes
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	files, err := filesClient.List(ctx, FilesLocationPrefix+"*")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected 1 audit file, got %d", len(files))
+	}
+	reader, _, err := filesClient.Retrieve(ctx, files[0].Location)
+	if err != nil {
+		t.Fatalf("Retrieve() error = %v", err)
+	}
+	if c, ok := reader.(io.Closer); ok {
+		defer c.Close()
+	}
+	var ids []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Failed to unmarshal audit event: %v", err)
+		}
+		ids = append(ids, event.ResourceID)
+	}
+	if len(ids) != 2 || ids[0] != "batch_1" || ids[1] != "batch_2" {
+		t.Errorf("audit events = %v, want [batch_1 batch_2]", ids)
+	}
+}
@@ -19,25 +19,86 @@ limitations under the License.
 package batch
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/metrics"
 	"github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	filesapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/models"
 	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
 	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/tracing"
 )
 
 const (
 	pathParamBatchID = "batch_id"
 	pathParamLimit   = "limit"
 	pathParamAfter   = "after"
+
+	// listing can be filtered by metadata with query parameters of the form metadata[key]=value
+	queryParamMetadataPrefix = "metadata["
+	queryParamMetadataSuffix = "]"
+
+	// batch creation only validates the batch when dry_run=true
+	queryParamDryRun = "dry_run"
+
+	metadataTagPrefix = "metadata:"
 )
 
-func jobToBatch(job *api.BatchJob) (*openai.Batch, error) {
+// metadataTag encodes a metadata key-value pair as a database tag, so batches can be selected by their metadata.
+// Keys and values are escaped since tags must not contain the tags separator.
+func metadataTag(key, value string) string {
+	return metadataTagPrefix + url.QueryEscape(key) + "=" + url.QueryEscape(value)
+}
+
+func metadataTags(metadata map[string]string) []string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	tags := make([]string, 0, len(metadata))
+	for k, v := range metadata {
+		tags = append(tags, metadataTag(k, v))
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// parseMetadataFilter extracts the metadata[key]=value query parameters.
+func parseMetadataFilter(query url.Values) map[string]string {
+	var filter map[string]string
+	for param, values := range query {
+		key, ok := strings.CutPrefix(param, queryParamMetadataPrefix)
+		if !ok {
+			continue
+		}
+		key, ok = strings.CutSuffix(key, queryParamMetadataSuffix)
+		if !ok || key == "" || len(values) == 0 {
+			continue
+		}
+		if filter == nil {
+			filter = make(map[string]string)
+		}
+		filter[key] = values[0]
+	}
+	return filter
+}
+
+// JobToBatch converts a batch job database record to the OpenAI batch object.
+func JobToBatch(job *api.BatchJob) (*openai.Batch, error) {
 	batch := &openai.Batch{
 		ID: job.ID,
 	}
@@ -56,18 +117,24 @@ func jobToBatch(job *api.BatchJob) (*openai.Batch, error) {
 type BatchApiHandler struct {
 	config       *common.ServerConfig
 	dbClient     api.BatchDBClient
+	fileDBClient api.BatchFileDBClient
 	queueClient  api.BatchPriorityQueueClient
 	eventClient  api.BatchEventChannelClient
 	statusClient api.BatchStatusClient
+	filesClient  filesapi.BatchFilesClient
+	models       *models.Allowlist
 }
 
-func NewBatchApiHandler(config *common.ServerConfig, dbClient api.BatchDBClient, queueClient api.BatchPriorityQueueClient, eventClient api.BatchEventChannelClient, statusClient api.BatchStatusClient) *BatchApiHandler {
+func NewBatchApiHandler(config *common.ServerConfig, dbClient api.BatchDBClient, fileDBClient api.BatchFileDBClient, queueClient api.BatchPriorityQueueClient, eventClient api.BatchEventChannelClient, statusClient api.BatchStatusClient, filesClient filesapi.BatchFilesClient) *BatchApiHandler {
 	return &BatchApiHandler{
 		config:       config,
 		dbClient:     dbClient,
+		fileDBClient: fileDBClient,
 		queueClient:  queueClient,
 		eventClient:  eventClient,
 		statusClient: statusClient,
+		filesClient:  filesClient,
+		models:       models.NewAllowlist(config.AllowedModels, config.ModelsURL, config.ModelsRefreshInterval),
 	}
 }
 
@@ -109,6 +176,18 @@ func (c *BatchApiHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// dry run, either by query parameter or request field
+	dryRun := batchReq.ValidateOnly
+	if v := r.URL.Query().Get(queryParamDryRun); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("invalid %s value: %s", queryParamDryRun, v), nil)
+			common.WriteAPIError(ctx, w, apiErr)
+			return
+		}
+		dryRun = dryRun || b
+	}
+
 	// validate request
 	if err := batchReq.Validate(); err != nil {
 		logger.Error(err, "failed to validate request")
@@ -117,8 +196,80 @@ func (c *BatchApiHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the file IDs are located in the files of the tenant, they must not locate other files
+	if !batch.ValidFileID(batchReq.InputFileID) {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("invalid input_file_id %q", batchReq.InputFileID), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	tenantID := common.GetTenantID(r)
+	if maxPriority := c.config.MaxPriorityForTenant(tenantID); batchReq.Priority > maxPriority {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("priority must be between 0 and %d", maxPriority), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	if batchReq.InferenceTarget != "" && !slices.Contains(c.config.InferenceTargets, batchReq.InferenceTarget) {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("unknown inference_target %q", batchReq.InferenceTarget), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	// validate input file
+	inputLines := 0
+	var modelRequestCounts map[string]int64
+	if c.filesClient != nil {
+		isModelAllowed, err := c.models.Checker(ctx)
+		if err != nil {
+			logger.Error(err, "failed to get the available models")
+			apiErr := openai.NewAPIError(http.StatusServiceUnavailable, "", "the list of available models could not be fetched", nil)
+			common.WriteAPIError(ctx, w, apiErr)
+			return
+		}
+		if !c.ownsFiles(w, r, "input file", []string{batchReq.InputFileID}) {
+			return
+		}
+		validationStart := time.Now()
+		report, err := c.validateInputFile(ctx, tenantID, batchReq, isModelAllowed)
+		if err != nil {
+			logger.Error(err, "failed to read input file", "input_file_id", batchReq.InputFileID)
+			apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("failed to read input file %s", batchReq.InputFileID), nil)
+			common.WriteAPIError(ctx, w, apiErr)
+			return
+		}
+		metrics.RecordBatchValidation(time.Since(validationStart), report.Lines, report.Valid())
+		if !report.Valid() {
+			logger.Info("input file failed validation", "input_file_id", batchReq.InputFileID, "errors", len(report.Errors))
+			msg := fmt.Sprintf("input file %s failed validation with %d error(s)", batchReq.InputFileID, len(report.Errors))
+			if report.Truncated {
+				msg += " (truncated)"
+			}
+			common.WriteInputValidationError(ctx, w, msg, report.BatchErrors())
+			return
+		}
+		if !c.ownsFiles(w, r, "image file", slices.Sorted(maps.Keys(report.ImageFileIDs))) {
+			return
+		}
+		inputLines = report.Lines
+		if len(report.Models) > 0 {
+			modelRequestCounts = report.Models
+		}
+	}
+
 	batchID := fmt.Sprintf("batch_%s", uuid.NewString())
 
+	// the batch expires at the end of the completion window
+	completionDuration, err := time.ParseDuration(batchReq.CompletionWindow)
+	if err != nil {
+		logger.Error(err, "failed to parse completion window duration")
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	createdAt := time.Now().UTC()
+	slo := createdAt.Add(completionDuration)
+	expiresAt := slo.Unix()
+
 	// construct batch spec
 	batchSpec := openai.BatchSpec{
 		Object:           "batch",
@@ -126,7 +277,14 @@ func (c *BatchApiHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
 		InputFileID:      batchReq.InputFileID,
 		CompletionWindow: batchReq.CompletionWindow,
 		Metadata:         batchReq.Metadata,
-		CreatedAt:        time.Now().UTC().Unix(),
+		CreatedAt:        createdAt.Unix(),
+		Priority:         batchReq.Priority,
+		RetryPolicy:      batchReq.RetryPolicy,
+		TenantID:         tenantID,
+
+		ModelRequestCounts: modelRequestCounts,
+		InferenceTarget:    batchReq.InferenceTarget,
+		OutputFormat:       batchReq.OutputFormat,
 	}
 	batchSpecData, err := json.Marshal(batchSpec)
 	if err != nil {
@@ -137,7 +295,8 @@ func (c *BatchApiHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
 
 	// construct batch status
 	batchStatus := openai.BatchStatusInfo{
-		Status: openai.BatchStatusValidating,
+		Status:    openai.BatchStatusValidating,
+		ExpiresAt: &expiresAt,
 	}
 	batchStatusData, err := json.Marshal(batchStatus)
 	if err != nil {
@@ -146,15 +305,19 @@ func (c *BatchApiHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// store batch job
-	completionDuration, err := time.ParseDuration(batchReq.CompletionWindow)
-	if err != nil {
-		logger.Error(err, "failed to parse completion window duration")
-		common.WriteInternalServerError(ctx, w)
+	// the batch passed validation, return the batch that would have been created
+	if dryRun {
+		batchStatus.RequestCounts.Total = int64(inputLines)
+		logger.V(logging.DEBUG).Info("dry run batch passed validation", "input_file_id", batchReq.InputFileID)
+		common.WriteJSONResponse(ctx, w, http.StatusOK, openai.Batch{
+			ID:              batchID,
+			BatchSpec:       batchSpec,
+			BatchStatusInfo: batchStatus,
+		})
 		return
 	}
-	slo := time.Now().UTC().Add(completionDuration)
 
+	// store batch job
 	ttl := c.config.BatchTTLSeconds
 	if batchReq.OutputExpiresAfter != nil {
 		if batchReq.OutputExpiresAfter.Anchor == "" || batchReq.OutputExpiresAfter.Anchor == "created_at" {
@@ -167,28 +330,28 @@ func (c *BatchApiHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
 		ID:     batchID,
 		SLO:    slo,
 		TTL:    ttl,
-		Tags:   nil,
+		Tags:   append(metadataTags(batchReq.Metadata), batch.TenantTag(tenantID)),
 		Spec:   batchSpecData,
 		Status: batchStatusData,
-	}
 
-	_, err = c.dbClient.Store(ctx, job)
-	if err != nil {
-		logger.Error(err, "failed to store batch job")
-		common.WriteInternalServerError(ctx, w)
-		return
+		TraceContext: tracing.FromContext(ctx).Carrier(),
 	}
 
-	// enqueue job
+	// store and enqueue the job, the job isn't created if it can't be enqueued
 	bjp := &api.BatchJobPriority{
-		ID:  batchID,
-		SLO: slo,
+		ID:       batchID,
+		SLO:      slo,
+		Priority: batchReq.Priority,
+
+		TraceContext: job.TraceContext,
 	}
-	if err := c.queueClient.Enqueue(ctx, bjp); err != nil {
-		logger.Error(err, "failed to enqueue batch job priority")
+	if err := api.StoreAndEnqueue(ctx, c.dbClient, c.queueClient, job, bjp); err != nil {
+		metrics.RecordJobPublishFailure("create", api.PublishStage(err))
+		logger.Error(err, "failed to create batch job", "batch_id", batchID)
 		common.WriteInternalServerError(ctx, w)
 		return
 	}
+	metrics.RecordBatchCreated(tenantID, string(batchReq.Endpoint))
 
 	// construct create response
 	batch := openai.Batch{
@@ -200,6 +363,53 @@ func (c *BatchApiHandler) CreateBatch(w http.ResponseWriter, r *http.Request) {
 	common.WriteJSONResponse(ctx, w, http.StatusOK, batch)
 }
 
+// ownsFiles checks that the files referenced by the batch are files of the tenant of the request, as the processor
+// reads them from the files of the tenant. It writes an error response and returns false otherwise.
+func (c *BatchApiHandler) ownsFiles(w http.ResponseWriter, r *http.Request, kind string, fileIDs []string) bool {
+	ctx := r.Context()
+	if len(fileIDs) == 0 {
+		return true
+	}
+	files, _, err := c.fileDBClient.Get(ctx, fileIDs, nil, api.TagsLogicalCondNa, 0, len(fileIDs))
+	if err != nil {
+		logging.GetRequestLogger(r).Error(err, "failed to get files from database", "kind", kind)
+		common.WriteInternalServerError(ctx, w)
+		return false
+	}
+	owned := make(map[string]bool, len(files))
+	for _, file := range files {
+		owned[file.ID] = batch.TenantOf(file.Tags) == common.GetTenantID(r)
+	}
+	for _, fileID := range fileIDs {
+		if !owned[fileID] {
+			apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s %s not found", kind, fileID), nil)
+			common.WriteAPIError(ctx, w, apiErr)
+			return false
+		}
+	}
+	return true
+}
+
+// validateInputFile reads the input file of the batch from the files of the tenant and validates its content.
+func (c *BatchApiHandler) validateInputFile(ctx context.Context, tenantID string, batchReq *openai.CreateBatchRequest, isModelAllowed func(string) bool) (*batch.InputValidationReport, error) {
+	reader, _, err := c.filesClient.Retrieve(ctx, batch.FileLocation(tenantID, batchReq.InputFileID))
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	return batch.ValidateInput(reader, batch.InputValidationOptions{
+		Endpoint:      batchReq.Endpoint,
+		MaxLines:      c.config.MaxInputLines,
+		MaxLineBytes:  c.config.MaxInputLineBytes,
+		MaxImageBytes: c.config.MaxImageBytes,
+
+		IsModelAllowed: isModelAllowed,
+	})
+}
+
 func (c *BatchApiHandler) ListBatches(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := logging.GetRequestLogger(r)
@@ -240,9 +450,11 @@ func (c *BatchApiHandler) ListBatches(w http.ResponseWriter, r *http.Request) {
 		after = parsedAfter
 	}
 
-	// TODO: We need a way to associate jobs to a tenant / user
+	// select the batches of the tenant by metadata
+	tags := append(metadataTags(parseMetadataFilter(query)), batch.TenantTag(common.GetTenantID(r)))
+
 	// Request limit+1 to check if there are more results
-	jobs, _, err := c.dbClient.Get(ctx, nil, nil, api.TagsLogicalCondNa, true, after, limit+1)
+	jobs, _, err := c.dbClient.Get(ctx, nil, tags, api.TagsLogicalCondAnd, true, after, limit+1)
 	if err != nil {
 		logger.Error(err, "failed to list batches from database")
 		common.WriteInternalServerError(ctx, w)
@@ -258,7 +470,7 @@ func (c *BatchApiHandler) ListBatches(w http.ResponseWriter, r *http.Request) {
 	// Convert jobs to batch responses
 	batches := make([]openai.Batch, 0, len(jobs))
 	for _, job := range jobs {
-		batch, err := jobToBatch(job)
+		batch, err := JobToBatch(job)
 		if err != nil {
 			logger.Error(err, "failed to convert job to batch", "batch_id", job.ID)
 			continue
@@ -284,8 +496,6 @@ func (c *BatchApiHandler) RetrieveBatch(w http.ResponseWriter, r *http.Request)
 	ctx := r.Context()
 	logger := logging.GetRequestLogger(r)
 
-	// TODO: permssion check
-
 	// extract batch_id from path
 	batchID := r.PathValue(pathParamBatchID)
 	if batchID == "" {
@@ -302,7 +512,8 @@ func (c *BatchApiHandler) RetrieveBatch(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if len(jobs) == 0 {
+	// the batches of other tenants are not found
+	if len(jobs) == 0 || batch.TenantOf(jobs[0].Tags) != common.GetTenantID(r) {
 		apiErr := openai.NewAPIError(http.StatusNotFound, "", fmt.Sprintf("Batch with ID %s not found", batchID), nil)
 		common.WriteAPIError(ctx, w, apiErr)
 		return
@@ -310,7 +521,7 @@ func (c *BatchApiHandler) RetrieveBatch(w http.ResponseWriter, r *http.Request)
 
 	job := jobs[0]
 
-	batch, err := jobToBatch(job)
+	batch, err := JobToBatch(job)
 	if err != nil {
 		logger.Error(err, "failed to convert job to batch", "batch_id", batchID)
 		common.WriteInternalServerError(ctx, w)
@@ -324,7 +535,6 @@ func (c *BatchApiHandler) CancelBatch(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := logging.GetRequestLogger(r)
 
-	// TODO: permssion check
 	batchID := r.PathValue(pathParamBatchID)
 	if batchID == "" {
 		apiErr := openai.NewAPIError(http.StatusBadRequest, "", pathParamBatchID+" is required", nil)
@@ -340,7 +550,8 @@ func (c *BatchApiHandler) CancelBatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(jobs) == 0 {
+	// the batches of other tenants are not found
+	if len(jobs) == 0 || batch.TenantOf(jobs[0].Tags) != common.GetTenantID(r) {
 		apiErr := openai.NewAPIError(http.StatusNotFound, "", fmt.Sprintf("Batch with ID %s not found", batchID), nil)
 		common.WriteAPIError(ctx, w, apiErr)
 		return
@@ -348,7 +559,7 @@ func (c *BatchApiHandler) CancelBatch(w http.ResponseWriter, r *http.Request) {
 
 	job := jobs[0]
 
-	batch, err := jobToBatch(job)
+	batch, err := JobToBatch(job)
 	if err != nil {
 		logger.Error(err, "failed to convert job to batch", "batch_id", batchID)
 		common.WriteInternalServerError(ctx, w)
@@ -21,16 +21,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
 	"github.com/llm-d-incubation/batch-gateway/internal/database/api"
 	mockapi "github.com/llm-d-incubation/batch-gateway/internal/database/mock"
+	fsapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/fs"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
 	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/tracing"
 )
 
 func setupBatchApiHandlerForTest() *BatchApiHandler {
@@ -39,10 +44,21 @@ func setupBatchApiHandlerForTest() *BatchApiHandler {
 	eventClient := mockapi.NewMockBatchEventChannelClient()
 	queueClient := mockapi.NewMockBatchPriorityQueueClient()
 	statusClient := mockapi.NewMockBatchStatusClient()
-	handler := NewBatchApiHandler(config, dbClient, queueClient, eventClient, statusClient)
+	handler := NewBatchApiHandler(config, dbClient, mockapi.NewMockBatchFileDBClient(), queueClient, eventClient, statusClient, nil)
 	return handler
 }
 
+// storeTestFile stores a file of the tenant, in the files store and in the database.
+func storeTestFile(t *testing.T, handler *BatchApiHandler, tenantID, fileID, content string) {
+	t.Helper()
+	if _, err := handler.filesClient.Store(context.Background(), batch.FileLocation(tenantID, fileID), 0, strings.NewReader(content)); err != nil {
+		t.Fatalf("Failed to store file %s: %v", fileID, err)
+	}
+	if _, err := handler.fileDBClient.Store(context.Background(), &api.BatchFile{ID: fileID, Tags: []string{batch.TenantTag(tenantID)}}); err != nil {
+		t.Fatalf("Failed to store file %s in database: %v", fileID, err)
+	}
+}
+
 func TestBatchHandler(t *testing.T) {
 
 	t.Run("CreateBatch", func(t *testing.T) {
@@ -93,11 +109,350 @@ func TestBatchHandler(t *testing.T) {
 		if batch.RequestCounts.Total != 0 {
 			t.Errorf("Expected request_counts.total to be 0, got %v", batch.RequestCounts.Total)
 		}
+		if batch.ExpiresAt == nil || *batch.ExpiresAt != batch.CreatedAt+24*60*60 {
+			t.Errorf("Expected expires_at to be created_at + 24h, got %v", batch.ExpiresAt)
+		}
 		if batch.ID == "" {
 			t.Error("Expected batch ID to be generated")
 		}
 	})
 
+	t.Run("CreateBatchWithPriority", func(t *testing.T) {
+		handler := setupBatchApiHandlerForTest()
+		handler.config.MaxBatchPriority = 5
+		handler.config.TenantMaxBatchPriority = map[string]int{"tenant-a": 20}
+
+		createBatch := func(tenantID string, priority int) *httptest.ResponseRecorder {
+			body, _ := json.Marshal(openai.CreateBatchRequest{
+				InputFileID:      "file-abc123",
+				Endpoint:         openai.EndpointChatCompletions,
+				CompletionWindow: "24h",
+				Priority:         priority,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/v1/batches", bytes.NewReader(body))
+			if tenantID != "" {
+				req.Header.Set(common.TenantIDHeader, tenantID)
+			}
+			rr := httptest.NewRecorder()
+			handler.CreateBatch(rr, req)
+			return rr
+		}
+
+		tests := []struct {
+			name           string
+			tenantID       string
+			priority       int
+			expectedStatus int
+		}{
+			{name: "default priority", expectedStatus: http.StatusOK},
+			{name: "within default bound", priority: 5, expectedStatus: http.StatusOK},
+			{name: "above default bound", priority: 6, expectedStatus: http.StatusBadRequest},
+			{name: "negative", priority: -1, expectedStatus: http.StatusBadRequest},
+			{name: "within tenant bound", tenantID: "tenant-a", priority: 20, expectedStatus: http.StatusOK},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := createBatch(tt.tenantID, tt.priority)
+				if rr.Code != tt.expectedStatus {
+					t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+				}
+				// the batch records its tenant, to account its token usage
+				var batch openai.Batch
+				if json.Unmarshal(rr.Body.Bytes(), &batch); rr.Code == http.StatusOK {
+					wantTenant := tt.tenantID
+					if wantTenant == "" {
+						wantTenant = common.DefaultTenantID
+					}
+					if batch.TenantID != wantTenant {
+						t.Errorf("tenant_id = %q, want %q", batch.TenantID, wantTenant)
+					}
+				}
+			})
+		}
+
+		// the batch with the highest priority is dequeued first
+		jobs, err := handler.queueClient.Dequeue(context.Background(), 0, 3)
+		if err != nil || len(jobs) != 3 {
+			t.Fatalf("Dequeue() = %v, %v", jobs, err)
+		}
+		for i, want := range []int{20, 5, 0} {
+			if jobs[i].Priority != want {
+				t.Errorf("jobs[%d].Priority = %d, want %d", i, jobs[i].Priority, want)
+			}
+		}
+	})
+
+	t.Run("CreateBatchWithRetryPolicy", func(t *testing.T) {
+		handler := setupBatchApiHandlerForTest()
+
+		tests := []struct {
+			name           string
+			policy         *openai.RetryPolicy
+			expectedStatus int
+		}{
+			{name: "no retries", policy: &openai.RetryPolicy{MaxAttempts: 1}, expectedStatus: http.StatusOK},
+			{name: "rate limits only", policy: &openai.RetryPolicy{RetryOn: []string{openai.RetryOnRateLimit}, MaxBackoffSeconds: 5}, expectedStatus: http.StatusOK},
+			{name: "too many attempts", policy: &openai.RetryPolicy{MaxAttempts: openai.MaxRetryAttempts + 1}, expectedStatus: http.StatusBadRequest},
+			{name: "unsupported category", policy: &openai.RetryPolicy{RetryOn: []string{"invalid_request"}}, expectedStatus: http.StatusBadRequest},
+			{name: "negative backoff", policy: &openai.RetryPolicy{MaxBackoffSeconds: -1}, expectedStatus: http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				body, _ := json.Marshal(openai.CreateBatchRequest{
+					InputFileID:      "file-abc123",
+					Endpoint:         openai.EndpointChatCompletions,
+					CompletionWindow: "24h",
+					RetryPolicy:      tt.policy,
+				})
+				rr := httptest.NewRecorder()
+				handler.CreateBatch(rr, httptest.NewRequest(http.MethodPost, "/v1/batches", bytes.NewReader(body)))
+				if rr.Code != tt.expectedStatus {
+					t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+				}
+				if rr.Code != http.StatusOK {
+					return
+				}
+				var batch openai.Batch
+				json.NewDecoder(rr.Body).Decode(&batch)
+				jobs, _, err := handler.dbClient.Get(context.Background(), []string{batch.ID}, nil, api.TagsLogicalCondNa, true, 0, 1)
+				if err != nil || len(jobs) != 1 {
+					t.Fatalf("Failed to get batch %s: %v", batch.ID, err)
+				}
+				var spec openai.BatchSpec
+				json.Unmarshal(jobs[0].Spec, &spec)
+				if spec.RetryPolicy == nil || spec.RetryPolicy.MaxAttempts != tt.policy.MaxAttempts ||
+					len(spec.RetryPolicy.RetryOn) != len(tt.policy.RetryOn) {
+					t.Errorf("stored retry policy = %+v, want %+v", spec.RetryPolicy, tt.policy)
+				}
+			})
+		}
+	})
+
+	t.Run("CreateBatchWithInferenceTarget", func(t *testing.T) {
+		handler := setupBatchApiHandlerForTest()
+		handler.config.InferenceTargets = []string{"us-east"}
+
+		tests := []struct {
+			name           string
+			target         string
+			expectedStatus int
+		}{
+			{name: "no target", expectedStatus: http.StatusOK},
+			{name: "configured target", target: "us-east", expectedStatus: http.StatusOK},
+			{name: "unknown target", target: "eu-west", expectedStatus: http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				body, _ := json.Marshal(openai.CreateBatchRequest{
+					InputFileID:      "file-abc123",
+					Endpoint:         openai.EndpointChatCompletions,
+					CompletionWindow: "24h",
+					InferenceTarget:  tt.target,
+				})
+				rr := httptest.NewRecorder()
+				handler.CreateBatch(rr, httptest.NewRequest(http.MethodPost, "/v1/batches", bytes.NewReader(body)))
+				if rr.Code != tt.expectedStatus {
+					t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+				}
+				if rr.Code != http.StatusOK {
+					return
+				}
+				var batch openai.Batch
+				json.NewDecoder(rr.Body).Decode(&batch)
+				jobs, _, err := handler.dbClient.Get(context.Background(), []string{batch.ID}, nil, api.TagsLogicalCondNa, true, 0, 1)
+				if err != nil || len(jobs) != 1 {
+					t.Fatalf("Failed to get batch %s: %v", batch.ID, err)
+				}
+				var spec openai.BatchSpec
+				json.Unmarshal(jobs[0].Spec, &spec)
+				if spec.InferenceTarget != tt.target {
+					t.Errorf("stored inference target = %q, want %q", spec.InferenceTarget, tt.target)
+				}
+			})
+		}
+	})
+
+	t.Run("CreateBatchWithOutputFormat", func(t *testing.T) {
+		handler := setupBatchApiHandlerForTest()
+
+		tests := []struct {
+			name           string
+			format         openai.OutputFormat
+			expectedStatus int
+		}{
+			{name: "default format", expectedStatus: http.StatusOK},
+			{name: "parquet", format: openai.OutputFormatParquet, expectedStatus: http.StatusOK},
+			{name: "json", format: openai.OutputFormatJSON, expectedStatus: http.StatusOK},
+			{name: "unknown format", format: "xml", expectedStatus: http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				body, _ := json.Marshal(openai.CreateBatchRequest{
+					InputFileID:      "file-abc123",
+					Endpoint:         openai.EndpointChatCompletions,
+					CompletionWindow: "24h",
+					OutputFormat:     tt.format,
+				})
+				rr := httptest.NewRecorder()
+				handler.CreateBatch(rr, httptest.NewRequest(http.MethodPost, "/v1/batches", bytes.NewReader(body)))
+				if rr.Code != tt.expectedStatus {
+					t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+				}
+				if rr.Code != http.StatusOK {
+					return
+				}
+				var batch openai.Batch
+				json.NewDecoder(rr.Body).Decode(&batch)
+				if batch.OutputFormat != tt.format {
+					t.Errorf("output format = %q, want %q", batch.OutputFormat, tt.format)
+				}
+			})
+		}
+	})
+
+	t.Run("CreateBatchDryRun", func(t *testing.T) {
+		handler := setupBatchApiHandlerForTest()
+		filesClient, err := fsapi.NewFSFilesClient(t.TempDir())
+		if err != nil {
+			t.Fatalf("Failed to create files client: %v", err)
+		}
+		handler.filesClient = filesClient
+		validLine := `{"custom_id":"r%d","method":"POST","url":"/v1/chat/completions","body":{"model":"m1"}}` + "\n"
+		storeTestFile(t, handler, common.DefaultTenantID, "file-valid", fmt.Sprintf(validLine, 1)+fmt.Sprintf(validLine, 2))
+		storeTestFile(t, handler, common.DefaultTenantID, "file-invalid", "not json\n")
+
+		tests := []struct {
+			name           string
+			query          string
+			inputFileID    string
+			validateOnly   bool
+			expectedStatus int
+		}{
+			{name: "query parameter", query: "?dry_run=true", inputFileID: "file-valid", expectedStatus: http.StatusOK},
+			{name: "request field", inputFileID: "file-valid", validateOnly: true, expectedStatus: http.StatusOK},
+			{name: "invalid input file", query: "?dry_run=true", inputFileID: "file-invalid", expectedStatus: http.StatusBadRequest},
+			{name: "missing input file", query: "?dry_run=true", inputFileID: "file-missing", expectedStatus: http.StatusBadRequest},
+			{name: "invalid dry_run value", query: "?dry_run=maybe", inputFileID: "file-valid", expectedStatus: http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				body, _ := json.Marshal(openai.CreateBatchRequest{
+					InputFileID:      tt.inputFileID,
+					Endpoint:         openai.EndpointChatCompletions,
+					CompletionWindow: "24h",
+					ValidateOnly:     tt.validateOnly,
+				})
+				rr := httptest.NewRecorder()
+				handler.CreateBatch(rr, httptest.NewRequest(http.MethodPost, "/v1/batches"+tt.query, bytes.NewReader(body)))
+				if rr.Code != tt.expectedStatus {
+					t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+				}
+				if rr.Code != http.StatusOK {
+					return
+				}
+				var batch openai.Batch
+				if err := json.NewDecoder(rr.Body).Decode(&batch); err != nil {
+					t.Fatalf("Failed to decode response body: %v", err)
+				}
+				if batch.ID == "" || batch.Status != openai.BatchStatusValidating || batch.RequestCounts.Total != 2 {
+					t.Errorf("unexpected dry run batch: %+v", batch)
+				}
+			})
+		}
+
+		// nothing was stored nor enqueued
+		if jobs, _, _ := handler.dbClient.Get(context.Background(), nil, nil, api.TagsLogicalCondNa, false, 0, 10); len(jobs) != 0 {
+			t.Errorf("expected no stored batches, got %d", len(jobs))
+		}
+		if n, _ := handler.queueClient.Len(context.Background()); n != 0 {
+			t.Errorf("expected empty queue, got %d", n)
+		}
+	})
+
+	t.Run("CreateBatchWithFilesOfOtherTenants", func(t *testing.T) {
+		handler := setupBatchApiHandlerForTest()
+		filesClient, err := fsapi.NewFSFilesClient(t.TempDir())
+		if err != nil {
+			t.Fatalf("Failed to create files client: %v", err)
+		}
+		handler.filesClient = filesClient
+		line := `{"custom_id":"r1","method":"POST","url":"/v1/chat/completions","body":{"model":"m1"}}` + "\n"
+		imageLine := `{"custom_id":"r1","method":"POST","url":"/v1/chat/completions","body":{"model":"m1","messages":[` +
+			`{"role":"user","content":[{"type":"image_url","image_url":{"file_id":%q}}]}]}}` + "\n"
+		storeTestFile(t, handler, "team-a", "file-secret", line)
+		storeTestFile(t, handler, "team-a", "file-image", "GIF89a")
+		storeTestFile(t, handler, "team-b", "file-input", line)
+		storeTestFile(t, handler, "team-b", "file-image-of-a", fmt.Sprintf(imageLine, "file-image"))
+		storeTestFile(t, handler, "team-b", "file-image-path", fmt.Sprintf(imageLine, "../team-a/file-image"))
+
+		tests := []struct {
+			name           string
+			tenantID       string
+			inputFileID    string
+			expectedStatus int
+		}{
+			{name: "own file", tenantID: "team-b", inputFileID: "file-input", expectedStatus: http.StatusOK},
+			{name: "file of another tenant", tenantID: "team-b", inputFileID: "file-secret", expectedStatus: http.StatusBadRequest},
+			{name: "relative path", tenantID: "team-b", inputFileID: "../team-a/file-secret", expectedStatus: http.StatusBadRequest},
+			{name: "tenant path", inputFileID: "tenants/team-a/file-secret", expectedStatus: http.StatusBadRequest},
+			{name: "image of another tenant", tenantID: "team-b", inputFileID: "file-image-of-a", expectedStatus: http.StatusBadRequest},
+			{name: "image path", tenantID: "team-b", inputFileID: "file-image-path", expectedStatus: http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				body, _ := json.Marshal(openai.CreateBatchRequest{
+					InputFileID:      tt.inputFileID,
+					Endpoint:         openai.EndpointChatCompletions,
+					CompletionWindow: "24h",
+				})
+				req := httptest.NewRequest(http.MethodPost, "/v1/batches?dry_run=true", bytes.NewReader(body))
+				if tt.tenantID != "" {
+					req.Header.Set(common.TenantIDHeader, tt.tenantID)
+				}
+				rr := httptest.NewRecorder()
+				handler.CreateBatch(rr, req)
+				if rr.Code != tt.expectedStatus {
+					t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("CreateBatchWithTraceContext", func(t *testing.T) {
+		handler := setupBatchApiHandlerForTest()
+		traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+		body, _ := json.Marshal(openai.CreateBatchRequest{
+			InputFileID:      "file-abc123",
+			Endpoint:         openai.EndpointChatCompletions,
+			CompletionWindow: "24h",
+		})
+		req := httptest.NewRequest(http.MethodPost, "/v1/batches", bytes.NewReader(body))
+		req = req.WithContext(tracing.NewContext(req.Context(), tracing.Parse(traceParent, "vendor=value")))
+		rr := httptest.NewRecorder()
+		handler.CreateBatch(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("CreateBatch returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var batch openai.Batch
+		json.NewDecoder(rr.Body).Decode(&batch)
+
+		jobs, _, err := handler.dbClient.Get(context.Background(), []string{batch.ID}, nil, api.TagsLogicalCondNa, true, 0, 1)
+		if err != nil || len(jobs) != 1 {
+			t.Fatalf("Failed to get batch %s: %v", batch.ID, err)
+		}
+		if got := jobs[0].TraceContext[tracing.TraceParentHeader]; got != traceParent {
+			t.Errorf("stored traceparent = %v, want %v", got, traceParent)
+		}
+		queued, err := handler.queueClient.Dequeue(context.Background(), 0, 1)
+		if err != nil || len(queued) != 1 {
+			t.Fatalf("Dequeue() = %v, %v", queued, err)
+		}
+		if got := queued[0].TraceContext[tracing.TraceStateHeader]; got != "vendor=value" {
+			t.Errorf("queued tracestate = %v, want %v", got, "vendor=value")
+		}
+	})
+
 	t.Run("RetrieveBatch", func(t *testing.T) {
 		handler := setupBatchApiHandlerForTest()
 		dbClient := handler.dbClient
@@ -122,7 +477,7 @@ func TestBatchHandler(t *testing.T) {
 			ID:     batchID,
 			SLO:    time.Now().UTC().Add(24 * time.Hour),
 			TTL:    86400,
-			Tags:   []string{},
+			Tags:   []string{batch.TenantTag(common.DefaultTenantID)},
 			Spec:   specData,
 			Status: statusData,
 		})
@@ -152,6 +507,24 @@ func TestBatchHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("RetrieveUntaggedBatch", func(t *testing.T) {
+		handler := setupBatchApiHandlerForTest()
+		// a record without tenant tag isn't listed for any tenant, so it isn't retrievable either
+		specData, _ := json.Marshal(openai.BatchSpec{Endpoint: openai.EndpointChatCompletions, CompletionWindow: "24h"})
+		statusData, _ := json.Marshal(openai.BatchStatusInfo{Status: openai.BatchStatusValidating})
+		handler.dbClient.Store(context.Background(), &api.BatchJob{
+			ID: "batch-untagged", SLO: time.Now().Add(time.Hour), TTL: 86400, Spec: specData, Status: statusData,
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/batches/batch-untagged", nil)
+		req.SetPathValue("batch_id", "batch-untagged")
+		rr := httptest.NewRecorder()
+		handler.RetrieveBatch(rr, req)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+
 	t.Run("ListBatches", func(t *testing.T) {
 		handler := setupBatchApiHandlerForTest()
 		dbClient := handler.dbClient
@@ -177,7 +550,7 @@ func TestBatchHandler(t *testing.T) {
 				ID:     batchID,
 				SLO:    time.Now().UTC().Add(24 * time.Hour),
 				TTL:    86400,
-				Tags:   []string{},
+				Tags:   []string{batch.TenantTag(common.DefaultTenantID)},
 				Spec:   specData,
 				Status: statusData,
 			})
@@ -221,6 +594,68 @@ func TestBatchHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("BatchMetadata", func(t *testing.T) {
+		handler := setupBatchApiHandlerForTest()
+
+		createBatch := func(metadata map[string]string) *httptest.ResponseRecorder {
+			body, _ := json.Marshal(openai.CreateBatchRequest{
+				InputFileID:      "file-abc123",
+				Endpoint:         openai.EndpointChatCompletions,
+				CompletionWindow: "24h",
+				Metadata:         metadata,
+			})
+			rr := httptest.NewRecorder()
+			handler.CreateBatch(rr, httptest.NewRequest(http.MethodPost, "/v1/batches", bytes.NewReader(body)))
+			return rr
+		}
+
+		for _, metadata := range []map[string]string{
+			{"experiment": "exp-1", "cost_center": "a;;b"},
+			{"experiment": "exp-1", "cost_center": "c"},
+			{"experiment": "exp-2"},
+		} {
+			if rr := createBatch(metadata); rr.Code != http.StatusOK {
+				t.Fatalf("CreateBatch returned wrong status code: got %v want %v: %s", rr.Code, http.StatusOK, rr.Body.String())
+			}
+		}
+
+		tooMany := map[string]string{}
+		for i := range openai.MaxMetadataPairs + 1 {
+			tooMany[fmt.Sprintf("k%d", i)] = "v"
+		}
+		if rr := createBatch(tooMany); rr.Code != http.StatusBadRequest {
+			t.Errorf("CreateBatch with too many metadata pairs: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+
+		tests := []struct {
+			query     string
+			wantCount int
+		}{
+			{query: "", wantCount: 3},
+			{query: "metadata[experiment]=exp-1", wantCount: 2},
+			{query: "metadata[experiment]=exp-1&metadata[cost_center]=a%3B%3Bb", wantCount: 1},
+			{query: "metadata[experiment]=exp-3", wantCount: 0},
+		}
+		for _, tt := range tests {
+			t.Run(tt.query, func(t *testing.T) {
+				rr := httptest.NewRecorder()
+				handler.ListBatches(rr, httptest.NewRequest(http.MethodGet, "/v1/batches?"+tt.query, nil))
+				var resp openai.ListBatchResponse
+				if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+					t.Fatalf("Failed to decode response body: %v", err)
+				}
+				if len(resp.Data) != tt.wantCount {
+					t.Errorf("Expected %d batches, got %d", tt.wantCount, len(resp.Data))
+				}
+				for _, batch := range resp.Data {
+					if batch.Metadata["experiment"] == "" {
+						t.Errorf("Expected metadata to be returned, got %v", batch.Metadata)
+					}
+				}
+			})
+		}
+	})
+
 	t.Run("CancelBatch", func(t *testing.T) {
 		handler := setupBatchApiHandlerForTest()
 		dbClient := handler.dbClient
@@ -245,7 +680,7 @@ func TestBatchHandler(t *testing.T) {
 			ID:     batchID,
 			SLO:    time.Now().UTC().Add(24 * time.Hour),
 			TTL:    86400,
-			Tags:   []string{},
+			Tags:   []string{batch.TenantTag(common.DefaultTenantID)},
 			Spec:   specData,
 			Status: statusData,
 		})
@@ -276,6 +711,41 @@ func TestBatchHandler(t *testing.T) {
 			t.Error("Expected cancelling_at to be set")
 		}
 	})
+
+	t.Run("CreateBatchEnqueueFailure", func(t *testing.T) {
+		handler := setupBatchApiHandlerForTest()
+		handler.queueClient = &failingQueueClient{handler.queueClient}
+
+		body, _ := json.Marshal(openai.CreateBatchRequest{
+			InputFileID:      "file-abc123",
+			Endpoint:         openai.EndpointChatCompletions,
+			CompletionWindow: "24h",
+		})
+		req := httptest.NewRequest(http.MethodPost, "/v1/batches", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		handler.CreateBatch(rr, req)
+
+		if rr.Code != http.StatusInternalServerError {
+			t.Fatalf("Handler returned wrong status code: got %v want %v", rr.Code, http.StatusInternalServerError)
+		}
+		// the batch that couldn't be queued is not created
+		jobs, _, err := handler.dbClient.Get(context.Background(), nil, nil, api.TagsLogicalCondNa, false, 0, 10)
+		if err != nil {
+			t.Fatalf("Failed to list jobs: %v", err)
+		}
+		if len(jobs) != 0 {
+			t.Errorf("Expected no batch to be stored, got %d", len(jobs))
+		}
+	})
+}
+
+// failingQueueClient fails to enqueue jobs.
+type failingQueueClient struct {
+	api.BatchPriorityQueueClient
+}
+
+func (c *failingQueueClient) Enqueue(ctx context.Context, jobPriority *api.BatchJobPriority) error {
+	return errors.New("queue unavailable")
 }
 
 // Benchmark tests for batch handler
@@ -322,7 +792,7 @@ func BenchmarkBatchHandler(b *testing.B) {
 			ID:     batchID,
 			SLO:    time.Now().UTC().Add(24 * time.Hour),
 			TTL:    86400,
-			Tags:   []string{},
+			Tags:   []string{batch.TenantTag(common.DefaultTenantID)},
 			Spec:   specData,
 			Status: statusData,
 		})
@@ -359,7 +829,7 @@ func BenchmarkBatchHandler(b *testing.B) {
 				ID:     batchID,
 				SLO:    time.Now().UTC().Add(24 * time.Hour),
 				TTL:    86400,
-				Tags:   []string{},
+				Tags:   []string{batch.TenantTag(common.DefaultTenantID)},
 				Spec:   specData,
 				Status: statusData,
 			})
@@ -399,7 +869,7 @@ func BenchmarkBatchHandler(b *testing.B) {
 				ID:     batchID,
 				SLO:    time.Now().UTC().Add(24 * time.Hour),
 				TTL:    86400,
-				Tags:   []string{},
+				Tags:   []string{batch.TenantTag(common.DefaultTenantID)},
 				Spec:   specData,
 				Status: statusData,
 			})
@@ -21,21 +21,178 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
-	"gopkg.in/yaml.v3"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/kube"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/settings"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
 	"k8s.io/klog/v2"
 )
 
+const (
+	DefaultMaxBatchPriority = 10
+	DefaultMaxFileSizeBytes = 512 * 1024 * 1024
+	DefaultPresignExpiry    = 15 * time.Minute
+	DefaultLoadShedRetry    = time.Second
+	DefaultAuditFlush       = 10 * time.Second
+	DefaultShutdownTimeout  = 60 * time.Second
+
+	DefaultUploadSessionTTL   = time.Hour
+	DefaultMaxUploadPartBytes = 64 * 1024 * 1024
+)
+
+// ServerConfig is the configuration of the API server: the common blocks and the apiserver section of the
+// configuration file.
 type ServerConfig struct {
+	settings.Common `yaml:"-"`
+
 	Host            string `yaml:"host"`
 	Port            string `yaml:"port"`
 	SSLCertFile     string `yaml:"ssl_cert_file"`
 	SSLKeyFile      string `yaml:"ssl_key_file"`
 	BatchTTLSeconds int    `yaml:"batch_ttl_seconds"`
+
+	// CA used to verify client certificates. Client certificates are not required when empty.
+	SSLClientCAFile string `yaml:"ssl_client_ca_file"`
+
+	// Limits applied when validating batch input files
+	MaxInputLines     int `yaml:"max_input_lines"`
+	MaxInputLineBytes int `yaml:"max_input_line_bytes"`
+	// Maximum size of an image inlined in a chat completion request (base64 data URL), once decoded
+	MaxImageBytes int `yaml:"max_image_bytes"`
+
+	// Upper bound of the batch priority. Tenants without an entry in TenantMaxBatchPriority use MaxBatchPriority.
+	MaxBatchPriority       int            `yaml:"max_batch_priority"`
+	TenantMaxBatchPriority map[string]int `yaml:"tenant_max_batch_priority"`
+
+	// Inference targets (clusters or regions) batches may be created for, matching the targets of the inference
+	// gateways of the processors. A batch with another target is rejected; no target may be set when empty.
+	InferenceTargets []string `yaml:"inference_targets"`
+
+	// Models that batches may reference. All models are allowed when both are empty.
+	// When both are set, a model must be in AllowedModels and served by the models endpoint.
+	AllowedModels []string `yaml:"allowed_models"`
+	// OpenAI compatible models endpoint, e.g. the inference gateway's /v1/models
+	ModelsURL             string        `yaml:"models_url"`
+	ModelsRefreshInterval time.Duration `yaml:"models_refresh_interval"`
+
+	// Maximum size of an uploaded file
+	MaxFileSizeBytes int64 `yaml:"max_file_size_bytes"`
+
+	// Uploads API: files uploaded in parts. An upload must be completed within UploadSessionTTL.
+	UploadSessionTTL   time.Duration `yaml:"upload_session_ttl"`
+	MaxUploadPartBytes int64         `yaml:"max_upload_part_bytes"`
+
+	// When enabled, a subset of the Gemini Batch API is served under /v1beta: batches of inlined generateContent
+	// requests are translated to batches of chat completions of the model.
+	GeminiAPIEnabled bool `yaml:"gemini_api_enabled"`
+
+	// When enabled and supported by the files store, file content downloads are redirected to a presigned URL
+	// valid for PresignExpiry instead of being proxied through the server.
+	PresignedDownloadsEnabled bool          `yaml:"presigned_downloads_enabled"`
+	PresignExpiry             time.Duration `yaml:"presign_expiry"`
+
+	// When enabled, the readiness endpoint checks that the server dependencies are reachable.
+	ReadinessChecksEnabled bool `yaml:"readiness_checks_enabled"`
+
+	// Access logging. A fraction AccessLogSampleRate of the successful requests is logged, failed requests are
+	// always logged. Health, readiness and metrics requests, and requests to AccessLogExcludePaths, are not logged.
+	AccessLogEnabled      bool     `yaml:"access_log_enabled"`
+	AccessLogSampleRate   float64  `yaml:"access_log_sample_rate"`
+	AccessLogExcludePaths []string `yaml:"access_log_exclude_paths"`
+
+	// Audit events of mutating API calls are written to AuditSink: "log" (JSON lines on stdout) or "files_store"
+	// (JSONL files under audit/ in the files store, written every AuditFlushInterval). Disabled when empty.
+	AuditSink          string        `yaml:"audit_sink"`
+	AuditFlushInterval time.Duration `yaml:"audit_flush_interval"`
+
+	// Maximum number of requests processed concurrently. Further requests are rejected with 503 and a Retry-After
+	// header of LoadShedRetryAfter. Health, readiness and metrics requests are not limited. Unlimited when 0.
+	MaxInFlightRequests int           `yaml:"max_in_flight_requests"`
+	LoadShedRetryAfter  time.Duration `yaml:"load_shed_retry_after"`
+
+	// On shutdown, the requests in flight are given ShutdownTimeout to finish before their connections are closed.
+	// It should be shorter than the termination grace period of the pod.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	// The tenant of a request bearing one of TenantAPIKeys is the tenant of the key. Otherwise, the X-Tenant-ID
+	// header is only accepted from the clients acting for the tenants, bearing one of TenantDelegateKeys, e.g.
+	// the BatchJob controller, or from every caller when TrustTenantHeader is set, i.e. when a gateway in front of
+	// the server authenticates the callers and sets the header; requests with the header are rejected otherwise.
+	// The requests without tenant are of the default tenant.
+	TenantAPIKeys      []TenantAPIKeyConfig      `yaml:"tenant_api_keys"`
+	TenantDelegateKeys []TenantDelegateKeyConfig `yaml:"tenant_delegate_keys"`
+	TrustTenantHeader  bool                      `yaml:"trust_tenant_header"`
+
+	// Bearer token required by the admin API, or a reference to it (see settings.Secret). It has the admin scope.
+	AdminAPIKey settings.Secret `yaml:"admin_api_key"`
+	// Bearer tokens of the admin API restricted to a scope, e.g. a read-only key of a monitoring dashboard.
+	AdminAPIKeys []AdminAPIKeyConfig `yaml:"admin_api_keys"`
+	// Kubernetes service accounts (system:serviceaccount:<namespace>:<name>) allowed to call the admin API with
+	// their token, authenticated with the TokenReview API of the cluster. They have the admin scope. The admin API
+	// is disabled when none of AdminAPIKey, AdminAPIKeys and AdminServiceAccounts is set.
+	AdminServiceAccounts []string `yaml:"admin_service_accounts"`
+}
+
+// Scopes of the admin API keys. A scope grants the routes of the scopes before it.
+const (
+	AdminScopeRead  = "read"  // the routes reading the queue, batches, dead letters, usage and scaling metrics
+	AdminScopeWrite = "write" // pausing and resuming batches
+	AdminScopeAdmin = "admin" // requeuing and failing batches, requeuing dead letters
+)
+
+var adminScopes = []string{AdminScopeRead, AdminScopeWrite, AdminScopeAdmin}
+
+// AdminScopeGrants returns whether scope grants the routes requiring the scope required.
+func AdminScopeGrants(scope, required string) bool {
+	i, j := slices.Index(adminScopes, scope), slices.Index(adminScopes, required)
+	return i >= 0 && j >= 0 && i >= j
+}
+
+// TenantAPIKeyConfig is a bearer token identifying the tenant of the requests.
+type TenantAPIKeyConfig struct {
+	Tenant string          `yaml:"tenant"`
+	Key    settings.Secret `yaml:"key"`
+}
+
+// TenantDelegateKeyConfig is a bearer token of a client allowed to act for any tenant, setting the tenant of its
+// requests in the X-Tenant-ID header.
+type TenantDelegateKeyConfig struct {
+	// Name of the key, identifying its holder in the logs
+	Name string          `yaml:"name"`
+	Key  settings.Secret `yaml:"key"`
+}
+
+// AdminAPIKeyConfig is a bearer token of the admin API restricted to a scope.
+type AdminAPIKeyConfig struct {
+	// Name of the key, identifying its holder in the logs
+	Name  string          `yaml:"name"`
+	Key   settings.Secret `yaml:"key"`
+	Scope string          `yaml:"scope"`
 }
 
 func NewConfig() *ServerConfig {
-	return &ServerConfig{}
+	return &ServerConfig{
+		Common: settings.NewCommon(),
+
+		MaxInputLines:     batch.DefaultMaxInputLines,
+		MaxInputLineBytes: batch.DefaultMaxInputLineBytes,
+		MaxImageBytes:     batch.DefaultMaxImageBytes,
+		MaxBatchPriority:  DefaultMaxBatchPriority,
+		MaxFileSizeBytes:  DefaultMaxFileSizeBytes,
+		PresignExpiry:     DefaultPresignExpiry,
+
+		UploadSessionTTL:   DefaultUploadSessionTTL,
+		MaxUploadPartBytes: DefaultMaxUploadPartBytes,
+
+		AccessLogSampleRate: 1,
+		LoadShedRetryAfter:  DefaultLoadShedRetry,
+		AuditFlushInterval:  DefaultAuditFlush,
+		ShutdownTimeout:     DefaultShutdownTimeout,
+	}
 }
 
 func (c *ServerConfig) Load() error {
@@ -45,13 +202,17 @@ func (c *ServerConfig) Load() error {
 
 	var configFile string
 	fs.StringVar(&configFile, "config", "cmd/apiserver/config.yaml", "path to YAML config file")
+	logFormat := logging.AddFlags(fs)
 
 	// Parse all flags (klog flags and application flags)
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		return err
 	}
+	if err := logging.Setup(*logFormat, "apiserver"); err != nil {
+		return err
+	}
 
-	if err := c.loadFromFile(configFile); err != nil {
+	if err := c.LoadFromFile(configFile); err != nil {
 		return err
 	}
 
@@ -59,10 +220,100 @@ func (c *ServerConfig) Load() error {
 }
 
 func (c *ServerConfig) Validate() error {
+	if err := c.Common.Validate(); err != nil {
+		return err
+	}
+
 	if c.Port == "" {
 		return fmt.Errorf("port cannot be empty")
 	}
 
+	if c.MaxInputLines < 0 {
+		return fmt.Errorf("max_input_lines cannot be negative")
+	}
+	if c.MaxInputLineBytes < 0 {
+		return fmt.Errorf("max_input_line_bytes cannot be negative")
+	}
+	if c.MaxImageBytes < 0 {
+		return fmt.Errorf("max_image_bytes cannot be negative")
+	}
+	if c.MaxBatchPriority < 0 {
+		return fmt.Errorf("max_batch_priority cannot be negative")
+	}
+	for tenant, max := range c.TenantMaxBatchPriority {
+		if max < 0 {
+			return fmt.Errorf("tenant_max_batch_priority of tenant %s cannot be negative", tenant)
+		}
+	}
+	names := map[string]bool{}
+	for _, key := range c.AdminAPIKeys {
+		if key.Name == "" || !key.Key.IsSet() {
+			return fmt.Errorf("admin_api_keys must have a name and a key")
+		}
+		if names[key.Name] {
+			return fmt.Errorf("admin_api_keys has several keys named %s", key.Name)
+		}
+		names[key.Name] = true
+		if !slices.Contains(adminScopes, key.Scope) {
+			return fmt.Errorf("scope of admin API key %s must be one of %s, got %q", key.Name, strings.Join(adminScopes, ", "), key.Scope)
+		}
+	}
+	for _, key := range c.TenantAPIKeys {
+		if !batch.ValidTenantID(key.Tenant) || !key.Key.IsSet() {
+			return fmt.Errorf("tenant_api_keys must have a valid tenant and a key, got tenant %q", key.Tenant)
+		}
+	}
+	for _, key := range c.TenantDelegateKeys {
+		if key.Name == "" || !key.Key.IsSet() {
+			return fmt.Errorf("tenant_delegate_keys must have a name and a key")
+		}
+	}
+	for _, account := range c.AdminServiceAccounts {
+		if !strings.HasPrefix(account, kube.ServiceAccountPrefix) {
+			return fmt.Errorf("admin_service_accounts must be %s<namespace>:<name> usernames, got %q", kube.ServiceAccountPrefix, account)
+		}
+	}
+	for _, target := range c.InferenceTargets {
+		if target == "" {
+			return fmt.Errorf("inference_targets cannot contain an empty target")
+		}
+	}
+	if c.ModelsRefreshInterval < 0 {
+		return fmt.Errorf("models_refresh_interval cannot be negative")
+	}
+	if c.MaxFileSizeBytes < 0 {
+		return fmt.Errorf("max_file_size_bytes cannot be negative")
+	}
+	if c.AccessLogSampleRate < 0 || c.AccessLogSampleRate > 1 {
+		return fmt.Errorf("access_log_sample_rate must be between 0 and 1")
+	}
+	switch c.AuditSink {
+	case "", "log", "files_store":
+	default:
+		return fmt.Errorf("audit_sink must be one of log, files_store")
+	}
+	if c.AuditSink == "files_store" && c.AuditFlushInterval <= 0 {
+		return fmt.Errorf("audit_flush_interval must be positive")
+	}
+	if c.MaxInFlightRequests < 0 {
+		return fmt.Errorf("max_in_flight_requests cannot be negative")
+	}
+	if c.LoadShedRetryAfter < 0 {
+		return fmt.Errorf("load_shed_retry_after cannot be negative")
+	}
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("shutdown_timeout must be positive")
+	}
+	if c.UploadSessionTTL <= 0 {
+		return fmt.Errorf("upload_session_ttl must be positive")
+	}
+	if c.MaxUploadPartBytes < 0 {
+		return fmt.Errorf("max_upload_part_bytes cannot be negative")
+	}
+	if c.PresignExpiry < 0 {
+		return fmt.Errorf("presign_expiry cannot be negative")
+	}
+
 	// If one SSL file is provided, both must be provided
 	if (c.SSLCertFile != "" && c.SSLKeyFile == "") || (c.SSLCertFile == "" && c.SSLKeyFile != "") {
 		return fmt.Errorf("both ssl-cert-file and ssl-private-key-file must be provided together")
@@ -77,25 +328,37 @@ func (c *ServerConfig) Validate() error {
 			return fmt.Errorf("ssl key file not found: %w", err)
 		}
 	}
+	if c.SSLClientCAFile != "" {
+		if !c.SSLEnabled() {
+			return fmt.Errorf("ssl_client_ca_file requires ssl_cert_file and ssl_key_file")
+		}
+		if _, err := os.Stat(c.SSLClientCAFile); err != nil {
+			return fmt.Errorf("ssl client ca file not found: %w", err)
+		}
+	}
 
 	return nil
 }
 
-func (c *ServerConfig) loadFromFile(path string) error {
+// LoadFromFile reads the common blocks and the apiserver section of a configuration file. Unknown keys are
+// errors. The configuration is not validated.
+func (c *ServerConfig) LoadFromFile(path string) error {
 	if path == "" {
 		return fmt.Errorf("config file path cannot be empty")
 	}
+	return settings.Load(path, settings.SectionAPIServer, &c.Common, c)
+}
 
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("failed to read config file: %w", err)
-	}
-
-	if err := yaml.Unmarshal(data, c); err != nil {
-		return fmt.Errorf("failed to parse YAML config file: %w", err)
+// MaxPriorityForTenant returns the highest batch priority the tenant may set.
+func (c *ServerConfig) MaxPriorityForTenant(tenantID string) int {
+	if max, ok := c.TenantMaxBatchPriority[tenantID]; ok {
+		return max
 	}
+	return c.MaxBatchPriority
+}
 
-	return nil
+func (c *ServerConfig) AdminEnabled() bool {
+	return c.AdminAPIKey.IsSet() || len(c.AdminAPIKeys) > 0 || len(c.AdminServiceAccounts) > 0
 }
 
 func (c *ServerConfig) SSLEnabled() bool {
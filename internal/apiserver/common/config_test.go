@@ -20,6 +20,7 @@ package common
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -35,10 +36,11 @@ func TestAPIServerConfig(t *testing.T) {
 			{
 				name: "valid yaml config",
 				yamlConfig: `
-host: 0.0.0.0
-port: "8080"
-ssl_cert_file: testdata/cert.pem
-ssl_key_file: testdata/key.pem
+apiserver:
+  host: 0.0.0.0
+  port: "8080"
+  ssl_cert_file: testdata/cert.pem
+  ssl_key_file: testdata/key.pem
 `,
 				fileName: "config.yaml",
 				want: ServerConfig{
@@ -52,8 +54,9 @@ ssl_key_file: testdata/key.pem
 			{
 				name: "valid yml extension",
 				yamlConfig: `
-host: 127.0.0.1
-port: "9000"
+apiserver:
+  host: 127.0.0.1
+  port: "9000"
 `,
 				fileName: "config.yml",
 				want: ServerConfig{
@@ -65,8 +68,9 @@ port: "9000"
 			{
 				name: "yaml config without ssl",
 				yamlConfig: `
-host: localhost
-port: "3000"
+apiserver:
+  host: localhost
+  port: "3000"
 `,
 				fileName: "config.yaml",
 				want: ServerConfig{
@@ -75,6 +79,52 @@ port: "3000"
 				},
 				wantErr: false,
 			},
+			{
+				name: "invalid shutdown timeout",
+				yamlConfig: `
+apiserver:
+  port: "8080"
+  shutdown_timeout: 0s
+`,
+				fileName: "config.yaml",
+				wantErr:  true,
+			},
+			{
+				name: "invalid admin API key scope",
+				yamlConfig: `
+apiserver:
+  port: "8080"
+  admin_api_keys:
+    - name: dashboard
+      key: read-key
+      scope: monitoring
+`,
+				fileName: "config.yaml",
+				wantErr:  true,
+			},
+			{
+				name: "invalid tenant of API key",
+				yamlConfig: `
+apiserver:
+  port: "8080"
+  tenant_api_keys:
+    - tenant: ../team-a
+      key: team-a-key
+`,
+				fileName: "config.yaml",
+				wantErr:  true,
+			},
+			{
+				name: "delegate key without name",
+				yamlConfig: `
+apiserver:
+  port: "8080"
+  tenant_delegate_keys:
+    - key: controller-key
+`,
+				fileName: "config.yaml",
+				wantErr:  true,
+			},
 			{
 				name:       "invalid yaml",
 				yamlConfig: `invalid: yaml: syntax: error`,
@@ -131,6 +181,34 @@ port: "3000"
 		}
 	})
 
+	t.Run("ConfigFiles", func(t *testing.T) {
+		config := NewConfig()
+		if err := config.LoadFromFile("../../../cmd/apiserver/config.yaml"); err != nil {
+			t.Fatalf("LoadFromFile() error = %v", err)
+		}
+		if config.Port != "8000" {
+			t.Errorf("Port = %q, want 8000", config.Port)
+		}
+		if err := config.Validate(); err != nil {
+			t.Errorf("Validate() error = %v", err)
+		}
+		// the processor's configuration file only has the common blocks for the API server
+		if err := NewConfig().LoadFromFile("../../../cmd/batch-processor/config.yaml"); err != nil {
+			t.Errorf("LoadFromFile() of the processor's config error = %v", err)
+		}
+	})
+
+	t.Run("UnknownKey", func(t *testing.T) {
+		configFile := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(configFile, []byte("apiserver:\n  prot: \"8080\"\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test config file: %v", err)
+		}
+		err := NewConfig().LoadFromFile(configFile)
+		if err == nil || !strings.Contains(err.Error(), `did you mean "port"?`) {
+			t.Errorf("LoadFromFile() error = %v, want a suggestion of port", err)
+		}
+	})
+
 	t.Run("LoadNegative", func(t *testing.T) {
 		t.Run("MissingConfigFile", func(t *testing.T) {
 			// Save original os.Args and restore after test
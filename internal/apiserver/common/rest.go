@@ -73,6 +73,15 @@ func WriteAPIError(ctx context.Context, w http.ResponseWriter, oaiErr openai.API
 	WriteJSONResponse(ctx, w, oaiErr.Code, errorResp)
 }
 
+func WriteInputValidationError(ctx context.Context, w http.ResponseWriter, message string, errs *openai.BatchErrors) {
+	errorResp := openai.ErrorResponse{
+		Error:  openai.NewAPIError(http.StatusBadRequest, "", message, nil),
+		Errors: errs,
+	}
+
+	WriteJSONResponse(ctx, w, http.StatusBadRequest, errorResp)
+}
+
 func WriteNotImplementedError(ctx context.Context, w http.ResponseWriter) {
 	apiErr := openai.NewAPIError(http.StatusNotImplemented, "", "This is not yet implemented", nil)
 	WriteAPIError(ctx, w, apiErr)
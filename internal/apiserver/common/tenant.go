@@ -0,0 +1,40 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file provides helpers to identify the tenant of a request.
+package common
+
+import (
+	"net/http"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+)
+
+const (
+	// TenantIDHeader carries the tenant of the request. The tenant middleware sets it to the tenant of the API
+	// key of the request, and only accepts it from the callers when it is set by a trusted gateway.
+	TenantIDHeader  = "X-Tenant-ID"
+	DefaultTenantID = batch.DefaultTenantID
+)
+
+// GetTenantID returns the tenant of the request identified by the tenant middleware, or DefaultTenantID if the
+// request has no tenant.
+func GetTenantID(r *http.Request) string {
+	if tenantID := r.Header.Get(TenantIDHeader); tenantID != "" {
+		return tenantID
+	}
+	return DefaultTenantID
+}
@@ -0,0 +1,431 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file implements the conversion of CSV and Parquet batch input files to the JSONL batch format.
+package files
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+
+	filesapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// Formats of the batch input files. The files in the other formats are converted to JSONL at upload.
+const (
+	InputFormatJSONL   = "jsonl"
+	InputFormatCSV     = "csv"
+	InputFormatParquet = "parquet"
+)
+
+var inputFormats = []string{InputFormatJSONL, InputFormatCSV, InputFormatParquet}
+
+// inputFormatOf returns the format of a batch input file named filename, JSONL unless its extension is the one
+// of another format.
+func inputFormatOf(filename string) string {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(filename), "."))
+	if slices.Contains(inputFormats, ext) {
+		return ext
+	}
+	return InputFormatJSONL
+}
+
+// ColumnMapping maps the columns of a CSV or Parquet file to the requests of a batch input file, one request
+// per row.
+type ColumnMapping struct {
+	// Column of the custom IDs of the requests. The requests are named row-<n> after their row when empty.
+	CustomID string `json:"custom_id"`
+	// Endpoint of the requests, e.g. /v1/chat/completions.
+	URL openai.Endpoint `json:"url"`
+	// Model of the requests, or the column of their models.
+	Model       string `json:"model"`
+	ModelColumn string `json:"model_column"`
+	// Column of the prompts: the user message of a chat completion, the prompt of a completion, or the input of
+	// the other endpoints.
+	Prompt string `json:"prompt"`
+	// System message of the chat completions.
+	SystemPrompt string `json:"system_prompt"`
+	// Fields of the bodies, by column. The CSV values that are valid JSON are decoded, e.g. numbers.
+	Columns map[string]string `json:"columns"`
+	// Fields added to every body, e.g. {"temperature": 0}.
+	Body map[string]json.RawMessage `json:"body"`
+}
+
+// conversionError is an error of the content of a converted file, reported to the caller.
+type conversionError struct {
+	err error
+}
+
+func (e *conversionError) Error() string {
+	return e.err.Error()
+}
+
+func (e *conversionError) Unwrap() error {
+	return e.err
+}
+
+func conversionErrorf(format string, args ...any) error {
+	return &conversionError{err: fmt.Errorf(format, args...)}
+}
+
+// validate checks the mapping against the columns of a file.
+func (m *ColumnMapping) validate(columns []string) error {
+	switch m.URL {
+	case openai.EndpointChatCompletions, openai.EndpointCompletions, openai.EndpointEmbeddings,
+		openai.EndpointResponses, openai.EndpointModerations:
+	case "":
+		return conversionErrorf("column_mapping.url is required")
+	default:
+		return conversionErrorf("column_mapping.url %q is not a supported endpoint", m.URL)
+	}
+	if (m.Model == "") == (m.ModelColumn == "") {
+		return conversionErrorf("column_mapping must have either model or model_column")
+	}
+	if m.Prompt == "" && len(m.Columns) == 0 {
+		return conversionErrorf("column_mapping must have prompt or columns")
+	}
+	if m.SystemPrompt != "" && m.URL != openai.EndpointChatCompletions {
+		return conversionErrorf("column_mapping.system_prompt is only supported by %s", openai.EndpointChatCompletions)
+	}
+	referenced := []string{m.CustomID, m.ModelColumn, m.Prompt}
+	for _, column := range m.Columns {
+		referenced = append(referenced, column)
+	}
+	for _, column := range referenced {
+		if column != "" && !slices.Contains(columns, column) {
+			return conversionErrorf("column %q of column_mapping is not a column of the file", column)
+		}
+	}
+	return nil
+}
+
+// request returns the request of a row, whose values are by column.
+func (m *ColumnMapping) request(rowNum int64, row map[string]any) (*openai.BatchRequestInput, error) {
+	body := make(map[string]any, len(m.Body)+len(m.Columns)+2)
+	for field, value := range m.Body {
+		body[field] = value
+	}
+	for field, column := range m.Columns {
+		body[field] = bodyValue(row[column])
+	}
+	body["model"] = m.Model
+	if m.ModelColumn != "" {
+		model, ok := stringValue(row[m.ModelColumn])
+		if !ok || model == "" {
+			return nil, conversionErrorf("row %d: column %s must be a non-empty string", rowNum, m.ModelColumn)
+		}
+		body["model"] = model
+	}
+	if m.Prompt != "" {
+		prompt, ok := stringValue(row[m.Prompt])
+		if !ok {
+			return nil, conversionErrorf("row %d: column %s must be a string", rowNum, m.Prompt)
+		}
+		switch m.URL {
+		case openai.EndpointChatCompletions:
+			var messages []map[string]string
+			if m.SystemPrompt != "" {
+				messages = append(messages, map[string]string{"role": "system", "content": m.SystemPrompt})
+			}
+			body["messages"] = append(messages, map[string]string{"role": "user", "content": prompt})
+		case openai.EndpointCompletions:
+			body["prompt"] = prompt
+		default:
+			body["input"] = prompt
+		}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, conversionErrorf("row %d: %w", rowNum, err)
+	}
+
+	customID := fmt.Sprintf("row-%d", rowNum)
+	if m.CustomID != "" {
+		customID = fmt.Sprint(row[m.CustomID])
+		if row[m.CustomID] == nil || customID == "" {
+			return nil, conversionErrorf("row %d: column %s is empty", rowNum, m.CustomID)
+		}
+	}
+	return &openai.BatchRequestInput{
+		CustomID: customID,
+		Method:   "POST",
+		URL:      string(m.URL),
+		Body:     data,
+	}, nil
+}
+
+// csvField is a value of a CSV file, whose type is unknown.
+type csvField string
+
+// stringValue returns a value of a row as a string, and false if it isn't a string.
+func stringValue(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case csvField:
+		return string(v), true
+	}
+	return "", false
+}
+
+// bodyValue returns a value of a row as a field of a body. The CSV values that are numbers, booleans, arrays or
+// objects are decoded, so they keep their type in the bodies.
+func bodyValue(value any) any {
+	field, ok := value.(csvField)
+	if !ok {
+		return value
+	}
+	var decoded any
+	if err := json.Unmarshal([]byte(field), &decoded); err == nil && decoded != nil {
+		return decoded
+	}
+	return string(field)
+}
+
+// convertInput converts the CSV or Parquet file stored at location to a JSONL batch input file stored in its place,
+// and returns the metadata and number of lines of the converted file. The errors of the content of the file are
+// conversionErrors.
+func (c *FilesApiHandler) convertInput(ctx context.Context, location, format string, mapping *ColumnMapping) (
+	*filesapi.BatchFileMetadata, int64, error,
+) {
+	// the file is copied locally, since a Parquet file is read at random offsets
+	reader, _, err := c.filesClient.Retrieve(ctx, location)
+	if err != nil {
+		return nil, 0, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	tmp, err := os.CreateTemp("", "batch-input-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+
+	var table tableReader
+	if format == InputFormatParquet {
+		table, err = newParquetTable(tmp, size)
+	} else {
+		table, err = newCSVTable(tmp)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pr, pw := io.Pipe()
+	var lines int64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var err error
+		lines, err = convertTable(table, mapping, pw)
+		pw.CloseWithError(err)
+	}()
+	md, err := c.filesClient.Store(ctx, location, c.config.MaxFileSizeBytes, pr)
+	// unblocks the conversion if the store failed before reading the whole file
+	pr.CloseWithError(io.ErrClosedPipe)
+	<-done
+	if err != nil {
+		return nil, 0, err
+	}
+	return md, lines, nil
+}
+
+// tableReader reads the rows of a CSV or Parquet file.
+type tableReader interface {
+	// columns returns the names of the columns.
+	columns() []string
+	// next returns the values of the next row, in the order of the columns, and io.EOF after the last row.
+	next() ([]any, error)
+}
+
+// convertTable writes the requests of the rows of table to w as JSONL, and returns their number.
+func convertTable(table tableReader, mapping *ColumnMapping, w io.Writer) (int64, error) {
+	columns := table.columns()
+	if err := mapping.validate(columns); err != nil {
+		return 0, err
+	}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	var rows int64
+	for {
+		values, err := table.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows++
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			row[column] = values[i]
+		}
+		req, err := mapping.request(rows, row)
+		if err != nil {
+			return rows, err
+		}
+		if err := enc.Encode(req); err != nil {
+			return rows, err
+		}
+	}
+	if rows == 0 {
+		return 0, conversionErrorf("the file has no rows")
+	}
+	return rows, bw.Flush()
+}
+
+// csvTable reads a CSV file whose first row names the columns.
+type csvTable struct {
+	reader *csv.Reader
+	header []string
+}
+
+func newCSVTable(r io.Reader) (*csvTable, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, conversionErrorf("the CSV file has no header row")
+		}
+		return nil, conversionErrorf("invalid CSV file: %w", err)
+	}
+	return &csvTable{reader: reader, header: slices.Clone(header)}, nil
+}
+
+func (t *csvTable) columns() []string {
+	return t.header
+}
+
+func (t *csvTable) next() ([]any, error) {
+	record, err := t.reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, conversionErrorf("invalid CSV file: %w", err)
+	}
+	values := make([]any, len(record))
+	for i, field := range record {
+		values[i] = csvField(field)
+	}
+	return values, nil
+}
+
+// parquetTable reads the rows of a Parquet file with flat columns.
+type parquetTable struct {
+	names     []string
+	rowGroups []parquet.RowGroup
+	rows      parquet.Rows
+	buf       []parquet.Row
+}
+
+func newParquetTable(r io.ReaderAt, size int64) (*parquetTable, error) {
+	file, err := parquet.OpenFile(r, size)
+	if err != nil {
+		return nil, conversionErrorf("invalid Parquet file: %w", err)
+	}
+	var names []string
+	for _, path := range file.Schema().Columns() {
+		if len(path) != 1 {
+			return nil, conversionErrorf("nested Parquet column %s is not supported", strings.Join(path, "."))
+		}
+		names = append(names, path[0])
+	}
+	return &parquetTable{names: names, rowGroups: file.RowGroups(), buf: make([]parquet.Row, 1)}, nil
+}
+
+func (t *parquetTable) columns() []string {
+	return t.names
+}
+
+func (t *parquetTable) next() ([]any, error) {
+	for {
+		if t.rows == nil {
+			if len(t.rowGroups) == 0 {
+				return nil, io.EOF
+			}
+			t.rows, t.rowGroups = t.rowGroups[0].Rows(), t.rowGroups[1:]
+		}
+		n, err := t.rows.ReadRows(t.buf)
+		if n == 1 {
+			return t.values(t.buf[0])
+		}
+		t.rows.Close()
+		t.rows = nil
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, conversionErrorf("invalid Parquet file: %w", err)
+		}
+	}
+}
+
+func (t *parquetTable) values(row parquet.Row) ([]any, error) {
+	values := make([]any, len(t.names))
+	for _, v := range row {
+		if v.Column() < 0 || v.Column() >= len(values) {
+			return nil, conversionErrorf("invalid Parquet file: value of unknown column %d", v.Column())
+		}
+		if v.RepetitionLevel() > 0 {
+			return nil, conversionErrorf("repeated Parquet column %s is not supported", t.names[v.Column()])
+		}
+		values[v.Column()] = parquetValue(v)
+	}
+	return values, nil
+}
+
+// parquetValue returns the Go value of a Parquet value.
+func parquetValue(v parquet.Value) any {
+	if v.IsNull() {
+		return nil
+	}
+	switch v.Kind() {
+	case parquet.Boolean:
+		return v.Boolean()
+	case parquet.Int32:
+		return int64(v.Int32())
+	case parquet.Int64:
+		return v.Int64()
+	case parquet.Float:
+		return float64(v.Float())
+	case parquet.Double:
+		return v.Double()
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		return string(v.ByteArray())
+	default:
+		return v.String()
+	}
+}
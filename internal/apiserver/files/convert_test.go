@@ -0,0 +1,152 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for the conversion of CSV and Parquet input files.
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+func convertedRequests(t *testing.T, data []byte) []openai.BatchRequestInput {
+	t.Helper()
+	var requests []openai.BatchRequestInput
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var req openai.BatchRequestInput
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			t.Fatalf("converted line %q is not a request: %v", line, err)
+		}
+		requests = append(requests, req)
+	}
+	return requests
+}
+
+func TestConvertTable(t *testing.T) {
+	t.Run("CSV", func(t *testing.T) {
+		csv := "id,question,max_tokens,model\n" +
+			"q1,\"What is 2+2, exactly?\",16,m1\n" +
+			"007,Name a color,32,m2\n"
+		table, err := newCSVTable(strings.NewReader(csv))
+		if err != nil {
+			t.Fatalf("newCSVTable failed: %v", err)
+		}
+		mapping := &ColumnMapping{
+			CustomID:     "id",
+			URL:          openai.EndpointChatCompletions,
+			ModelColumn:  "model",
+			Prompt:       "question",
+			SystemPrompt: "Be brief.",
+			Columns:      map[string]string{"max_tokens": "max_tokens"},
+			Body:         map[string]json.RawMessage{"temperature": json.RawMessage("0")},
+		}
+		var out bytes.Buffer
+		lines, err := convertTable(table, mapping, &out)
+		if err != nil || lines != 2 {
+			t.Fatalf("convertTable = %d, %v, want 2 lines", lines, err)
+		}
+
+		requests := convertedRequests(t, out.Bytes())
+		if requests[0].CustomID != "q1" || requests[1].CustomID != "007" || requests[0].URL != string(openai.EndpointChatCompletions) {
+			t.Errorf("unexpected requests: %+v", requests)
+		}
+		var body struct {
+			Model       string              `json:"model"`
+			Messages    []map[string]string `json:"messages"`
+			MaxTokens   any                 `json:"max_tokens"`
+			Temperature any                 `json:"temperature"`
+		}
+		if err := json.Unmarshal(requests[0].Body, &body); err != nil {
+			t.Fatalf("invalid body: %v", err)
+		}
+		if body.Model != "m1" || body.MaxTokens != float64(16) || body.Temperature != float64(0) {
+			t.Errorf("unexpected body: %s", requests[0].Body)
+		}
+		if len(body.Messages) != 2 || body.Messages[0]["role"] != "system" || body.Messages[1]["content"] != "What is 2+2, exactly?" {
+			t.Errorf("unexpected messages: %v", body.Messages)
+		}
+	})
+
+	t.Run("Parquet", func(t *testing.T) {
+		type row struct {
+			Text  string `parquet:"text"`
+			Score int64  `parquet:"score,optional"`
+		}
+		var file bytes.Buffer
+		if err := parquet.Write(&file, []row{{Text: "first"}, {Text: "second", Score: 3}}); err != nil {
+			t.Fatalf("failed to write Parquet file: %v", err)
+		}
+		table, err := newParquetTable(bytes.NewReader(file.Bytes()), int64(file.Len()))
+		if err != nil {
+			t.Fatalf("newParquetTable failed: %v", err)
+		}
+		mapping := &ColumnMapping{
+			URL:     openai.EndpointEmbeddings,
+			Model:   "embedder",
+			Prompt:  "text",
+			Columns: map[string]string{"score": "score"},
+		}
+		var out bytes.Buffer
+		if lines, err := convertTable(table, mapping, &out); err != nil || lines != 2 {
+			t.Fatalf("convertTable = %d, %v, want 2 lines", lines, err)
+		}
+
+		requests := convertedRequests(t, out.Bytes())
+		if requests[0].CustomID != "row-1" || requests[1].CustomID != "row-2" {
+			t.Errorf("unexpected custom IDs: %+v", requests)
+		}
+		want := `{"input":"second","model":"embedder","score":3}`
+		if string(requests[1].Body) != want {
+			t.Errorf("body = %s, want %s", requests[1].Body, want)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			csv     string
+			mapping ColumnMapping
+			want    string
+		}{
+			{"missing url", "q\nhi\n", ColumnMapping{Model: "m", Prompt: "q"}, "url is required"},
+			{"unknown column", "q\nhi\n", ColumnMapping{URL: openai.EndpointCompletions, Model: "m", Prompt: "question"}, `column "question"`},
+			{"model twice", "q\nhi\n", ColumnMapping{URL: openai.EndpointCompletions, Model: "m", ModelColumn: "q", Prompt: "q"}, "either model or model_column"},
+			{"system prompt", "q\nhi\n", ColumnMapping{URL: openai.EndpointCompletions, Model: "m", Prompt: "q", SystemPrompt: "s"}, "system_prompt"},
+			{"short row", "q,m\nhi\n", ColumnMapping{URL: openai.EndpointCompletions, ModelColumn: "m", Prompt: "q"}, "invalid CSV file"},
+			{"empty model", "q,m\nhi,\n", ColumnMapping{URL: openai.EndpointCompletions, ModelColumn: "m", Prompt: "q"}, "row 1: column m"},
+			{"no rows", "q\n", ColumnMapping{URL: openai.EndpointCompletions, Model: "m", Prompt: "q"}, "no rows"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				table, err := newCSVTable(strings.NewReader(tt.csv))
+				if err == nil {
+					_, err = convertTable(table, &tt.mapping, &bytes.Buffer{})
+				}
+				var convErr *conversionError
+				if !errors.As(err, &convErr) || !strings.Contains(err.Error(), tt.want) {
+					t.Errorf("convertTable error = %v, want a conversion error with %q", err, tt.want)
+				}
+			})
+		}
+	})
+}
@@ -19,20 +19,74 @@ limitations under the License.
 package files
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	filesapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
 )
 
+const (
+	pathParamFileID  = "file_id"
+	pathParamLimit   = "limit"
+	pathParamAfter   = "after"
+	pathParamPurpose = "purpose"
+	pathParamLines   = "lines"
+
+	formFieldFile          = "file"
+	formFieldPurpose       = "purpose"
+	formFieldInputFormat   = "input_format"
+	formFieldColumnMapping = "column_mapping"
+
+	// maxFormFieldBytes bounds the size of non-file form fields.
+	maxFormFieldBytes = 1024
+	// maxColumnMappingBytes bounds the size of the column mapping form field.
+	maxColumnMappingBytes = 64 * 1024
+	// multipartOverheadBytes is the slack allowed on top of the file size limit for multipart headers and fields.
+	multipartOverheadBytes = 1024 * 1024
+
+	// preview returns the first lines of a file, read from at most maxPreviewBytes bytes of the file.
+	defaultPreviewLines = 10
+	maxPreviewLines     = 1000
+	maxPreviewBytes     = 4 * 1024 * 1024
+)
+
+var supportedPurposes = map[openai.FileObjectPurpose]bool{
+	openai.FileObjectPurposeBatch:       true,
+	openai.FileObjectPurposeBatchOutput: true,
+}
+
 type FilesApiHandler struct {
+	config       *common.ServerConfig
+	fileDBClient api.BatchFileDBClient
+	filesClient  filesapi.BatchFilesClient
 }
 
-func NewFilesApiHandler() *FilesApiHandler {
-	return &FilesApiHandler{}
+func NewFilesApiHandler(config *common.ServerConfig, fileDBClient api.BatchFileDBClient, filesClient filesapi.BatchFilesClient) *FilesApiHandler {
+	return &FilesApiHandler{
+		config:       config,
+		fileDBClient: fileDBClient,
+		filesClient:  filesClient,
+	}
 }
 
 func (c *FilesApiHandler) GetRoutes() []common.Route {
-	return []common.Route{
+	return append([]common.Route{
 		{
 			Method:      http.MethodPost,
 			Pattern:     "/v1/files",
@@ -48,6 +102,11 @@ func (c *FilesApiHandler) GetRoutes() []common.Route {
 			Pattern:     "/v1/files/{file_id}/content",
 			HandlerFunc: c.DownloadFile,
 		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     "/v1/files/{file_id}/preview",
+			HandlerFunc: c.PreviewFile,
+		},
 		{
 			Method:      http.MethodGet,
 			Pattern:     "/v1/files",
@@ -58,25 +117,490 @@ func (c *FilesApiHandler) GetRoutes() []common.Route {
 			Pattern:     "/v1/files/{file_id}",
 			HandlerFunc: c.RetrieveFile,
 		},
-	}
+	}, c.uploadRoutes()...)
 }
 
+// CreateFile handles multipart file uploads.
+// The request body is streamed part by part, and the file part is written directly to the files store,
+// so the file content is never buffered in memory.
 func (c *FilesApiHandler) CreateFile(w http.ResponseWriter, r *http.Request) {
-	common.WriteNotImplementedError(r.Context(), w)
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	maxFileSize := c.config.MaxFileSizeBytes
+	if maxFileSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxFileSize+multipartOverheadBytes)
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", "request must be multipart/form-data", nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	fileID := fmt.Sprintf("file_%s", uuid.NewString())
+	location := fileLocation(r, fileID)
+	var (
+		purpose     openai.FileObjectPurpose
+		filename    string
+		fileMd      *filesapi.BatchFileMetadata
+		inputFormat string
+		mapping     *ColumnMapping
+	)
+
+	// remove the stored file if the upload doesn't complete successfully
+	committed := false
+	defer func() {
+		if fileMd != nil && !committed {
+			if err := c.filesClient.Delete(ctx, location); err != nil {
+				logger.Error(err, "failed to remove incomplete upload", "file_id", fileID)
+			}
+		}
+	}()
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.writeUploadError(w, r, err)
+			return
+		}
+
+		switch part.FormName() {
+		case formFieldPurpose:
+			value, err := io.ReadAll(io.LimitReader(part, maxFormFieldBytes))
+			if err != nil {
+				c.writeUploadError(w, r, err)
+				return
+			}
+			purpose = openai.FileObjectPurpose(strings.TrimSpace(string(value)))
+
+		case formFieldInputFormat:
+			value, err := io.ReadAll(io.LimitReader(part, maxFormFieldBytes))
+			if err != nil {
+				c.writeUploadError(w, r, err)
+				return
+			}
+			inputFormat = strings.ToLower(strings.TrimSpace(string(value)))
+			if !slices.Contains(inputFormats, inputFormat) {
+				apiErr := openai.NewAPIError(http.StatusBadRequest, "",
+					fmt.Sprintf("%s must be one of %s", formFieldInputFormat, strings.Join(inputFormats, ", ")), nil)
+				common.WriteAPIError(ctx, w, apiErr)
+				return
+			}
+
+		case formFieldColumnMapping:
+			value, err := io.ReadAll(io.LimitReader(part, maxColumnMappingBytes))
+			if err != nil {
+				c.writeUploadError(w, r, err)
+				return
+			}
+			mapping = &ColumnMapping{}
+			if err := json.Unmarshal(value, mapping); err != nil {
+				apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("invalid %s: %v", formFieldColumnMapping, err), nil)
+				common.WriteAPIError(ctx, w, apiErr)
+				return
+			}
+
+		case formFieldFile:
+			if fileMd != nil {
+				apiErr := openai.NewAPIError(http.StatusBadRequest, "", "only one file can be uploaded per request", nil)
+				common.WriteAPIError(ctx, w, apiErr)
+				return
+			}
+			filename = part.FileName()
+			fileMd, err = c.filesClient.Store(ctx, location, maxFileSize, part)
+			if err != nil {
+				fileMd = nil
+				c.writeUploadError(w, r, err)
+				return
+			}
+
+		default:
+			// drain unknown fields
+			if _, err := io.Copy(io.Discard, io.LimitReader(part, maxFormFieldBytes)); err != nil {
+				c.writeUploadError(w, r, err)
+				return
+			}
+		}
+		part.Close()
+	}
+
+	if fileMd == nil {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", formFieldFile+" is required", nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+	if purpose == "" {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", formFieldPurpose+" is required", nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+	if !supportedPurposes[purpose] {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("purpose %q is not supported", purpose), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	// CSV and Parquet batch input files are converted to JSONL
+	if inputFormat == "" && purpose == openai.FileObjectPurposeBatch {
+		inputFormat = inputFormatOf(filename)
+	}
+	if inputFormat != "" && inputFormat != InputFormatJSONL {
+		if purpose != openai.FileObjectPurposeBatch || mapping == nil {
+			apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s files are converted to batch input files, "+
+				"the purpose must be %s and %s is required", inputFormat, openai.FileObjectPurposeBatch, formFieldColumnMapping), nil)
+			common.WriteAPIError(ctx, w, apiErr)
+			return
+		}
+		convertedMd, lines, err := c.convertInput(ctx, location, inputFormat, mapping)
+		if err != nil {
+			var convErr *conversionError
+			if errors.As(err, &convErr) {
+				apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("failed to convert %s file: %v", inputFormat, convErr), nil)
+				common.WriteAPIError(ctx, w, apiErr)
+				return
+			}
+			c.writeUploadError(w, r, err)
+			return
+		}
+		logger.Info("input file converted", "file_id", fileID, "format", inputFormat, "bytes", convertedMd.Size, "lines", lines)
+		fileMd = convertedMd
+		filename = strings.TrimSuffix(filename, path.Ext(filename)) + ".jsonl"
+	}
+
+	fileObj := openai.FileObject{
+		ID:        fileID,
+		Object:    "file",
+		Bytes:     fileMd.Size,
+		CreatedAt: time.Now().UTC().Unix(),
+		Filename:  filename,
+		Purpose:   purpose,
+		Status:    openai.FileObjectStatusUploaded,
+	}
+	if err := c.storeFileObject(r, &fileObj); err != nil {
+		logger.Error(err, "failed to store file metadata", "file_id", fileID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	committed = true
+
+	logger.Info("file uploaded", "file_id", fileID, "bytes", fileMd.Size, "purpose", purpose)
+	common.WriteJSONResponse(ctx, w, http.StatusOK, fileObj)
+}
+
+// fileLocation returns the location in the files store of a file of the tenant of the request.
+func fileLocation(r *http.Request, fileID string) string {
+	return batch.FileLocation(common.GetTenantID(r), fileID)
+}
+
+// storeFileObject stores the metadata of a file, owned by the tenant of the request.
+func (c *FilesApiHandler) storeFileObject(r *http.Request, fileObj *openai.FileObject) error {
+	spec, err := json.Marshal(fileObj)
+	if err != nil {
+		return err
+	}
+	if _, err := c.fileDBClient.Store(r.Context(), &api.BatchFile{
+		ID:   fileObj.ID,
+		TTL:  c.config.BatchTTLSeconds,
+		Tags: []string{batch.TenantTag(common.GetTenantID(r))},
+		Spec: spec,
+	}); err != nil {
+		return err
+	}
+	metrics.RecordFileStored(common.GetTenantID(r), fileObj.Bytes)
+	return nil
+}
+
+func (c *FilesApiHandler) writeUploadError(w http.ResponseWriter, r *http.Request, err error) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.Is(err, filesapi.ErrFileTooLarge) || errors.As(err, &maxBytesErr) {
+		apiErr := openai.NewAPIError(http.StatusRequestEntityTooLarge, "",
+			fmt.Sprintf("file exceeds the maximum size of %d bytes", c.config.MaxFileSizeBytes), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	logger.Error(err, "failed to upload file")
+	apiErr := openai.NewAPIError(http.StatusBadRequest, "", "failed to read upload request", nil)
+	common.WriteAPIError(ctx, w, apiErr)
+}
+
+// getFileObject gets the file object from the database. It writes an error response and returns nil on failure.
+// The files of other tenants are not found.
+func (c *FilesApiHandler) getFileObject(w http.ResponseWriter, r *http.Request) *openai.FileObject {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	fileID := r.PathValue(pathParamFileID)
+	if fileID == "" {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", pathParamFileID+" is required", nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return nil
+	}
+
+	files, _, err := c.fileDBClient.Get(ctx, []string{fileID}, nil, api.TagsLogicalCondNa, 0, 1)
+	if err != nil {
+		logger.Error(err, "failed to get file from database", "file_id", fileID)
+		common.WriteInternalServerError(ctx, w)
+		return nil
+	}
+	if len(files) == 0 || batch.TenantOf(files[0].Tags) != common.GetTenantID(r) {
+		apiErr := openai.NewAPIError(http.StatusNotFound, "", fmt.Sprintf("File with ID %s not found", fileID), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return nil
+	}
+
+	fileObj := &openai.FileObject{}
+	if err := json.Unmarshal(files[0].Spec, fileObj); err != nil {
+		logger.Error(err, "failed to unmarshal file object", "file_id", fileID)
+		common.WriteInternalServerError(ctx, w)
+		return nil
+	}
+	return fileObj
 }
 
 func (c *FilesApiHandler) DeleteFile(w http.ResponseWriter, r *http.Request) {
-	common.WriteNotImplementedError(r.Context(), w)
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	fileObj := c.getFileObject(w, r)
+	if fileObj == nil {
+		return
+	}
+
+	if err := c.filesClient.Delete(ctx, fileLocation(r, fileObj.ID)); err != nil && !errors.Is(err, filesapi.ErrFileNotFound) {
+		logger.Error(err, "failed to delete file from files store", "file_id", fileObj.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	if _, err := c.fileDBClient.Delete(ctx, []string{fileObj.ID}); err != nil {
+		logger.Error(err, "failed to delete file from database", "file_id", fileObj.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	common.WriteJSONResponse(ctx, w, http.StatusOK, openai.FileDeleted{
+		ID:      fileObj.ID,
+		Object:  "file",
+		Deleted: true,
+	})
 }
 
 func (c *FilesApiHandler) DownloadFile(w http.ResponseWriter, r *http.Request) {
-	common.WriteNotImplementedError(r.Context(), w)
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	fileObj := c.getFileObject(w, r)
+	if fileObj == nil {
+		return
+	}
+
+	if url := c.presignDownload(r, fileObj.ID); url != "" {
+		http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+		return
+	}
+
+	reader, fileMd, err := c.filesClient.Retrieve(ctx, fileLocation(r, fileObj.ID))
+	if err != nil {
+		if errors.Is(err, filesapi.ErrFileNotFound) {
+			apiErr := openai.NewAPIError(http.StatusNotFound, "", fmt.Sprintf("Content of file %s not found", fileObj.ID), nil)
+			common.WriteAPIError(ctx, w, apiErr)
+			return
+		}
+		logger.Error(err, "failed to retrieve file from files store", "file_id", fileObj.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	contentType := fileObj.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(fileMd.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, reader); err != nil {
+		logger.Error(err, "failed to write file content", "file_id", fileObj.ID)
+	}
+}
+
+// PreviewFile streams the first lines of a file, so large files can be inspected without downloading them.
+// Only the beginning of the file is read from the files store.
+func (c *FilesApiHandler) PreviewFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	lines := defaultPreviewLines
+	if linesStr := r.URL.Query().Get(pathParamLines); linesStr != "" {
+		n, err := strconv.Atoi(linesStr)
+		if err != nil || n < 1 || n > maxPreviewLines {
+			apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("lines must be between 1 and %d", maxPreviewLines), nil)
+			common.WriteAPIError(ctx, w, apiErr)
+			return
+		}
+		lines = n
+	}
+
+	fileObj := c.getFileObject(w, r)
+	if fileObj == nil {
+		return
+	}
+	if fileObj.ContentType != "" && fileObj.ContentType != openai.OutputFormatJSONL.ContentType() {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("File %s is not a JSONL file and cannot be previewed", fileObj.ID), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	reader, fileMd, err := c.retrieveHead(r, fileObj.ID, maxPreviewBytes)
+	if err != nil {
+		if errors.Is(err, filesapi.ErrFileNotFound) {
+			apiErr := openai.NewAPIError(http.StatusNotFound, "", fmt.Sprintf("Content of file %s not found", fileObj.ID), nil)
+			common.WriteAPIError(ctx, w, apiErr)
+			return
+		}
+		logger.Error(err, "failed to retrieve file from files store", "file_id", fileObj.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	w.Header().Set("Content-Type", openai.OutputFormatJSONL.ContentType())
+	w.WriteHeader(http.StatusOK)
+	br := bufio.NewReader(io.LimitReader(reader, maxPreviewBytes))
+	for i := 0; i < lines; i++ {
+		line, readErr := br.ReadBytes('\n')
+		// a line cut by the byte limit is not returned
+		complete := readErr == nil || (readErr == io.EOF && fileMd.Size <= maxPreviewBytes)
+		if len(line) > 0 && complete {
+			if _, err := w.Write(line); err != nil {
+				logger.Error(err, "failed to write file preview", "file_id", fileObj.ID)
+				return
+			}
+		}
+		if readErr != nil {
+			if readErr != io.EOF {
+				logger.Error(readErr, "failed to read file preview", "file_id", fileObj.ID)
+			}
+			return
+		}
+	}
+}
+
+// retrieveHead retrieves the first bytes of a file, with a ranged read if the files store supports it.
+func (c *FilesApiHandler) retrieveHead(r *http.Request, fileID string, length int64) (io.Reader, *filesapi.BatchFileMetadata, error) {
+	if ranged, ok := c.filesClient.(filesapi.BatchFilesRangeRetriever); ok {
+		return ranged.RetrieveRange(r.Context(), fileLocation(r, fileID), 0, length)
+	}
+	return c.filesClient.Retrieve(r.Context(), fileLocation(r, fileID))
+}
+
+// presignDownload returns a presigned URL of the file content, or an empty string if presigned downloads
+// are disabled or not supported by the files store. Failing to presign falls back to proxying the content.
+func (c *FilesApiHandler) presignDownload(r *http.Request, fileID string) string {
+	if !c.config.PresignedDownloadsEnabled {
+		return ""
+	}
+	presigner, ok := c.filesClient.(filesapi.BatchFilesPresigner)
+	if !ok {
+		return ""
+	}
+	expiry := c.config.PresignExpiry
+	if expiry <= 0 {
+		expiry = common.DefaultPresignExpiry
+	}
+	url, err := presigner.PresignRetrieve(r.Context(), fileLocation(r, fileID), expiry)
+	if err != nil {
+		logging.GetRequestLogger(r).Error(err, "failed to presign file download, proxying content", "file_id", fileID)
+		return ""
+	}
+	return url
 }
 
 func (c *FilesApiHandler) ListFiles(w http.ResponseWriter, r *http.Request) {
-	common.WriteNotImplementedError(r.Context(), w)
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	// Parse query parameters
+	query := r.URL.Query()
+	limit := 10000
+	if limitStr := query.Get(pathParamLimit); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit < 1 || parsedLimit > 10000 {
+			apiErr := openai.NewAPIError(http.StatusBadRequest, "", "invalid limit parameter: must be an integer between 1 and 10000", nil)
+			common.WriteAPIError(ctx, w, apiErr)
+			return
+		}
+		limit = parsedLimit
+	}
+
+	after := 0
+	if afterStr := query.Get(pathParamAfter); afterStr != "" {
+		parsedAfter, err := strconv.Atoi(afterStr)
+		if err != nil || parsedAfter < 0 {
+			apiErr := openai.NewAPIError(http.StatusBadRequest, "", "invalid after parameter: must be an integer equal to or greater than 0", nil)
+			common.WriteAPIError(ctx, w, apiErr)
+			return
+		}
+		after = parsedAfter
+	}
+	purpose := openai.FileObjectPurpose(query.Get(pathParamPurpose))
+
+	// Request limit+1 to check if there are more results, the files of the tenant only
+	tenantTags := []string{batch.TenantTag(common.GetTenantID(r))}
+	files, _, err := c.fileDBClient.Get(ctx, nil, tenantTags, api.TagsLogicalCondAnd, after, limit+1)
+	if err != nil {
+		logger.Error(err, "failed to list files from database")
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	hasMore := len(files) > limit
+	if hasMore {
+		files = files[:limit]
+	}
+
+	data := make([]openai.FileObject, 0, len(files))
+	for _, file := range files {
+		var fileObj openai.FileObject
+		if err := json.Unmarshal(file.Spec, &fileObj); err != nil {
+			logger.Error(err, "failed to unmarshal file object", "file_id", file.ID)
+			continue
+		}
+		if purpose != "" && fileObj.Purpose != purpose {
+			continue
+		}
+		data = append(data, fileObj)
+	}
+
+	resp := openai.ListFilesResponse{
+		Object:  "list",
+		Data:    data,
+		HasMore: hasMore,
+	}
+	if len(data) > 0 {
+		resp.FirstID = data[0].ID
+		resp.LastID = data[len(data)-1].ID
+	}
+
+	common.WriteJSONResponse(ctx, w, http.StatusOK, resp)
 }
 
 func (c *FilesApiHandler) RetrieveFile(w http.ResponseWriter, r *http.Request) {
-	common.WriteNotImplementedError(r.Context(), w)
+	fileObj := c.getFileObject(w, r)
+	if fileObj == nil {
+		return
+	}
+	common.WriteJSONResponse(r.Context(), w, http.StatusOK, fileObj)
 }
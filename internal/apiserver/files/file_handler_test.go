@@ -0,0 +1,373 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for file handler.
+package files
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	mockapi "github.com/llm-d-incubation/batch-gateway/internal/database/mock"
+	filesapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
+	fsapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/fs"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+func setupFilesApiHandlerForTest(t *testing.T, maxFileSize int64) (*FilesApiHandler, *http.ServeMux) {
+	t.Helper()
+	config := &common.ServerConfig{
+		BatchTTLSeconds:    86400,
+		MaxFileSizeBytes:   maxFileSize,
+		UploadSessionTTL:   common.DefaultUploadSessionTTL,
+		MaxUploadPartBytes: common.DefaultMaxUploadPartBytes,
+	}
+	filesClient, err := fsapi.NewFSFilesClient(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create files client: %v", err)
+	}
+	handler := NewFilesApiHandler(config, mockapi.NewMockBatchFileDBClient(), filesClient)
+	mux := http.NewServeMux()
+	common.RegisterHandler(mux, handler)
+	return handler, mux
+}
+
+func newUploadRequest(t *testing.T, purpose, content string) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	if purpose != "" {
+		if err := mw.WriteField(formFieldPurpose, purpose); err != nil {
+			t.Fatalf("Failed to write purpose field: %v", err)
+		}
+	}
+	fw, err := mw.CreateFormFile(formFieldFile, "input.jsonl")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	fw.Write([]byte(content))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/files", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestFilesHandler(t *testing.T) {
+
+	t.Run("UploadRetrieveDownloadDelete", func(t *testing.T) {
+		_, mux := setupFilesApiHandlerForTest(t, 1024)
+		content := `{"custom_id":"r1","method":"POST","url":"/v1/chat/completions","body":{}}` + "\n"
+
+		// upload
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, newUploadRequest(t, string(openai.FileObjectPurposeBatch), content))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("CreateFile returned wrong status code: got %v want %v, body: %s", rr.Code, http.StatusOK, rr.Body.String())
+		}
+		var fileObj openai.FileObject
+		if err := json.NewDecoder(rr.Body).Decode(&fileObj); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if fileObj.ID == "" || fileObj.Bytes != int64(len(content)) || fileObj.Filename != "input.jsonl" {
+			t.Errorf("unexpected file object: %+v", fileObj)
+		}
+
+		// retrieve
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/files/"+fileObj.ID, nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("RetrieveFile returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+
+		// download
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/files/"+fileObj.ID+"/content", nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("DownloadFile returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		if rr.Body.String() != content {
+			t.Errorf("DownloadFile returned %q, want %q", rr.Body.String(), content)
+		}
+
+		// list
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/files?purpose=batch", nil))
+		var list openai.ListFilesResponse
+		if err := json.NewDecoder(rr.Body).Decode(&list); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		if len(list.Data) != 1 {
+			t.Errorf("Expected 1 file, got %d", len(list.Data))
+		}
+
+		// delete
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/v1/files/"+fileObj.ID, nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("DeleteFile returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/files/"+fileObj.ID, nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("RetrieveFile after delete returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("TenantIsolation", func(t *testing.T) {
+		_, mux := setupFilesApiHandlerForTest(t, 1024)
+		content := `{"custom_id":"r1","method":"POST","url":"/v1/chat/completions","body":{}}` + "\n"
+		withTenant := func(req *http.Request, tenantID string) *http.Request {
+			req.Header.Set(common.TenantIDHeader, tenantID)
+			return req
+		}
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, withTenant(newUploadRequest(t, string(openai.FileObjectPurposeBatch), content), "tenant-a"))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("CreateFile returned wrong status code: got %v want %v, body: %s", rr.Code, http.StatusOK, rr.Body.String())
+		}
+		var fileObj openai.FileObject
+		if err := json.NewDecoder(rr.Body).Decode(&fileObj); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		for _, tenantID := range []string{"tenant-b", common.DefaultTenantID} {
+			for _, req := range []*http.Request{
+				httptest.NewRequest(http.MethodGet, "/v1/files/"+fileObj.ID, nil),
+				httptest.NewRequest(http.MethodGet, "/v1/files/"+fileObj.ID+"/content", nil),
+				httptest.NewRequest(http.MethodDelete, "/v1/files/"+fileObj.ID, nil),
+			} {
+				rr = httptest.NewRecorder()
+				mux.ServeHTTP(rr, withTenant(req, tenantID))
+				if rr.Code != http.StatusNotFound {
+					t.Errorf("%s %s of tenant %s returned wrong status code: got %v want %v", req.Method, req.URL.Path, tenantID, rr.Code, http.StatusNotFound)
+				}
+			}
+			rr = httptest.NewRecorder()
+			mux.ServeHTTP(rr, withTenant(httptest.NewRequest(http.MethodGet, "/v1/files", nil), tenantID))
+			var list openai.ListFilesResponse
+			if err := json.NewDecoder(rr.Body).Decode(&list); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if len(list.Data) != 0 {
+				t.Errorf("Expected no file for tenant %s, got %d", tenantID, len(list.Data))
+			}
+		}
+
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, withTenant(httptest.NewRequest(http.MethodGet, "/v1/files/"+fileObj.ID+"/content", nil), "tenant-a"))
+		if rr.Code != http.StatusOK || rr.Body.String() != content {
+			t.Errorf("DownloadFile of owner returned %v %q, want %v %q", rr.Code, rr.Body.String(), http.StatusOK, content)
+		}
+	})
+
+	t.Run("UploadCSV", func(t *testing.T) {
+		_, mux := setupFilesApiHandlerForTest(t, 1024)
+		newCSVUploadRequest := func(mapping string) *http.Request {
+			body := &bytes.Buffer{}
+			mw := multipart.NewWriter(body)
+			mw.WriteField(formFieldPurpose, string(openai.FileObjectPurposeBatch))
+			if mapping != "" {
+				mw.WriteField(formFieldColumnMapping, mapping)
+			}
+			fw, _ := mw.CreateFormFile(formFieldFile, "export.csv")
+			fw.Write([]byte("id,question\nq1,Hello\n"))
+			mw.Close()
+			req := httptest.NewRequest(http.MethodPost, "/v1/files", body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			return req
+		}
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, newCSVUploadRequest(""))
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), formFieldColumnMapping) {
+			t.Errorf("CreateFile without column mapping returned %v: %s", rr.Code, rr.Body.String())
+		}
+
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, newCSVUploadRequest(`{"custom_id":"id","url":"/v1/completions","model":"m1"}`))
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "prompt or columns") {
+			t.Errorf("CreateFile with an invalid column mapping returned %v: %s", rr.Code, rr.Body.String())
+		}
+
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, newCSVUploadRequest(`{"custom_id":"id","url":"/v1/completions","model":"m1","prompt":"question"}`))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("CreateFile returned wrong status code: got %v want %v, body: %s", rr.Code, http.StatusOK, rr.Body.String())
+		}
+		var fileObj openai.FileObject
+		if err := json.NewDecoder(rr.Body).Decode(&fileObj); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+		want := `{"custom_id":"q1","method":"POST","url":"/v1/completions","body":{"model":"m1","prompt":"Hello"}}` + "\n"
+		if fileObj.Filename != "export.jsonl" || fileObj.Bytes != int64(len(want)) {
+			t.Errorf("unexpected file object: %+v", fileObj)
+		}
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/files/"+fileObj.ID+"/content", nil))
+		if rr.Body.String() != want {
+			t.Errorf("DownloadFile returned %q, want %q", rr.Body.String(), want)
+		}
+	})
+
+	t.Run("UploadNegative", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			purpose        string
+			content        string
+			expectedStatus int
+		}{
+			{
+				name:           "missing purpose",
+				content:        "{}",
+				expectedStatus: http.StatusBadRequest,
+			},
+			{
+				name:           "unsupported purpose",
+				purpose:        "fine-tune",
+				content:        "{}",
+				expectedStatus: http.StatusBadRequest,
+			},
+			{
+				name:           "file too large",
+				purpose:        string(openai.FileObjectPurposeBatch),
+				content:        strings.Repeat("x", 2048),
+				expectedStatus: http.StatusRequestEntityTooLarge,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				handler, _ := setupFilesApiHandlerForTest(t, 1024)
+				rr := httptest.NewRecorder()
+				handler.CreateFile(rr, newUploadRequest(t, tt.purpose, tt.content))
+				if rr.Code != tt.expectedStatus {
+					t.Errorf("expected status %d, got %d, body: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("PreviewFile", func(t *testing.T) {
+		_, mux := setupFilesApiHandlerForTest(t, 1024)
+		content := "{\"n\":1}\n{\"n\":2}\n{\"n\":3}"
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, newUploadRequest(t, string(openai.FileObjectPurposeBatch), content))
+		var fileObj openai.FileObject
+		if err := json.NewDecoder(rr.Body).Decode(&fileObj); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		tests := []struct {
+			name           string
+			query          string
+			expectedStatus int
+			expectedBody   string
+		}{
+			{name: "default lines", expectedStatus: http.StatusOK, expectedBody: content},
+			{name: "first lines", query: "?lines=2", expectedStatus: http.StatusOK, expectedBody: "{\"n\":1}\n{\"n\":2}\n"},
+			{name: "zero lines", query: "?lines=0", expectedStatus: http.StatusBadRequest},
+			{name: "too many lines", query: "?lines=1001", expectedStatus: http.StatusBadRequest},
+			{name: "invalid lines", query: "?lines=abc", expectedStatus: http.StatusBadRequest},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := httptest.NewRecorder()
+				mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/files/"+fileObj.ID+"/preview"+tt.query, nil))
+				if rr.Code != tt.expectedStatus {
+					t.Fatalf("PreviewFile returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+				}
+				if tt.expectedStatus == http.StatusOK && rr.Body.String() != tt.expectedBody {
+					t.Errorf("PreviewFile returned %q, want %q", rr.Body.String(), tt.expectedBody)
+				}
+			})
+		}
+
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/files/file-missing/preview", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("PreviewFile of missing file returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("PresignedDownload", func(t *testing.T) {
+		handler, mux := setupFilesApiHandlerForTest(t, 1024)
+		presigner := &presigningFilesClient{BatchFilesClient: handler.filesClient}
+		handler.filesClient = presigner
+		content := `{"custom_id":"r1","method":"POST","url":"/v1/chat/completions","body":{}}` + "\n"
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, newUploadRequest(t, string(openai.FileObjectPurposeBatch), content))
+		var fileObj openai.FileObject
+		if err := json.NewDecoder(rr.Body).Decode(&fileObj); err != nil {
+			t.Fatalf("Failed to decode response body: %v", err)
+		}
+
+		tests := []struct {
+			name           string
+			enabled        bool
+			presignErr     error
+			expectedStatus int
+		}{
+			{name: "disabled", expectedStatus: http.StatusOK},
+			{name: "enabled", enabled: true, expectedStatus: http.StatusTemporaryRedirect},
+			{name: "presign failure", enabled: true, presignErr: errors.New("boom"), expectedStatus: http.StatusOK},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				handler.config.PresignedDownloadsEnabled = tt.enabled
+				presigner.err = tt.presignErr
+
+				rr := httptest.NewRecorder()
+				mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/files/"+fileObj.ID+"/content", nil))
+				if rr.Code != tt.expectedStatus {
+					t.Fatalf("DownloadFile returned wrong status code: got %v want %v", rr.Code, tt.expectedStatus)
+				}
+				if rr.Code == http.StatusTemporaryRedirect {
+					if want := "https://files.example.com/" + fileObj.ID; rr.Header().Get("Location") != want {
+						t.Errorf("Location = %v, want %v", rr.Header().Get("Location"), want)
+					}
+				} else if rr.Body.String() != content {
+					t.Errorf("DownloadFile returned %q, want %q", rr.Body.String(), content)
+				}
+			})
+		}
+	})
+}
+
+// presigningFilesClient adds presigned URL support to a files client.
+type presigningFilesClient struct {
+	filesapi.BatchFilesClient
+	err error
+}
+
+func (c *presigningFilesClient) PresignRetrieve(ctx context.Context, location string, expiry time.Duration) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	return "https://files.example.com/" + location, nil
+}
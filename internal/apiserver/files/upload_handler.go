@@ -0,0 +1,449 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file provides HTTP handlers for the OpenAI compatible Uploads API endpoints.
+// Large files are uploaded in parts, backed by the multipart uploads of the files store, so a client can
+// resume an interrupted upload by re-uploading only the parts that failed.
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	filesapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+const (
+	pathParamUploadID   = "upload_id"
+	pathParamPartNumber = "part_number"
+
+	formFieldData = "data"
+
+	partIDPrefix  = "part_"
+	maxPartNumber = 10000
+
+	// upload sessions are stored as objects in the files store, so they are shared by all server replicas
+	uploadSessionsLocation = "uploads"
+)
+
+// uploadSession is the state of an upload in progress.
+type uploadSession struct {
+	Upload openai.Upload `json:"upload"`
+
+	// FileID is the ID of the file that is created when the upload completes.
+	FileID string `json:"file_id"`
+
+	// StoreUploadID is the ID of the multipart upload in the files store.
+	StoreUploadID string `json:"store_upload_id"`
+}
+
+func (c *FilesApiHandler) uploadRoutes() []common.Route {
+	return []common.Route{
+		{
+			Method:      http.MethodPost,
+			Pattern:     "/v1/uploads",
+			HandlerFunc: c.CreateUpload,
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     "/v1/uploads/{upload_id}/parts",
+			HandlerFunc: c.AddUploadPart,
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     "/v1/uploads/{upload_id}/complete",
+			HandlerFunc: c.CompleteUpload,
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     "/v1/uploads/{upload_id}/cancel",
+			HandlerFunc: c.CancelUpload,
+		},
+	}
+}
+
+// multipartUploader returns the files store as a multipart uploader. It writes an error response and returns nil
+// if the files store doesn't support multipart uploads.
+func (c *FilesApiHandler) multipartUploader(w http.ResponseWriter, r *http.Request) filesapi.BatchFilesMultipartUploader {
+	uploader, ok := c.filesClient.(filesapi.BatchFilesMultipartUploader)
+	if !ok {
+		apiErr := openai.NewAPIError(http.StatusNotImplemented, "", "uploads are not supported by the files store", nil)
+		common.WriteAPIError(r.Context(), w, apiErr)
+		return nil
+	}
+	return uploader
+}
+
+func (c *FilesApiHandler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	uploader := c.multipartUploader(w, r)
+	if uploader == nil {
+		return
+	}
+
+	req := &openai.CreateUploadRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", "invalid request body", nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+	if msg := c.validateCreateUpload(req); msg != "" {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", msg, nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	session := &uploadSession{
+		FileID: fmt.Sprintf("file_%s", uuid.NewString()),
+	}
+	storeUploadID, err := uploader.CreateMultipartUpload(ctx, fileLocation(r, session.FileID))
+	if err != nil {
+		logger.Error(err, "failed to create multipart upload")
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	now := time.Now().UTC()
+	session.StoreUploadID = storeUploadID
+	session.Upload = openai.Upload{
+		ID:        fmt.Sprintf("upload_%s", uuid.NewString()),
+		Object:    "upload",
+		Bytes:     req.Bytes,
+		CreatedAt: now.Unix(),
+		ExpiresAt: now.Add(c.config.UploadSessionTTL).Unix(),
+		Filename:  req.Filename,
+		Purpose:   req.Purpose,
+		Status:    openai.UploadStatusPending,
+	}
+	if err := c.storeUploadSession(r, session); err != nil {
+		logger.Error(err, "failed to store upload session", "upload_id", session.Upload.ID)
+		uploader.AbortMultipartUpload(ctx, fileLocation(r, session.FileID), storeUploadID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	logger.Info("upload created", "upload_id", session.Upload.ID, "bytes", req.Bytes)
+	common.WriteJSONResponse(ctx, w, http.StatusOK, session.Upload)
+}
+
+func (c *FilesApiHandler) validateCreateUpload(req *openai.CreateUploadRequest) string {
+	if strings.TrimSpace(req.Filename) == "" {
+		return "filename is required"
+	}
+	if req.Purpose == "" {
+		return "purpose is required"
+	}
+	if !supportedPurposes[req.Purpose] {
+		return fmt.Sprintf("purpose %q is not supported", req.Purpose)
+	}
+	if req.Bytes <= 0 {
+		return "bytes must be positive"
+	}
+	if c.config.MaxFileSizeBytes > 0 && req.Bytes > c.config.MaxFileSizeBytes {
+		return fmt.Sprintf("bytes exceeds the maximum file size of %d bytes", c.config.MaxFileSizeBytes)
+	}
+	return ""
+}
+
+// AddUploadPart stores a part of an upload. The part number is given by the part_number query parameter;
+// uploading a part with the same number again replaces it.
+func (c *FilesApiHandler) AddUploadPart(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	uploader := c.multipartUploader(w, r)
+	if uploader == nil {
+		return
+	}
+
+	partNumber, err := strconv.Atoi(r.URL.Query().Get(pathParamPartNumber))
+	if err != nil || partNumber < 1 || partNumber > maxPartNumber {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s must be between 1 and %d", pathParamPartNumber, maxPartNumber), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	session := c.getUploadSession(w, r)
+	if session == nil {
+		return
+	}
+
+	maxPartSize := c.config.MaxUploadPartBytes
+	if maxPartSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxPartSize+multipartOverheadBytes)
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", "request must be multipart/form-data", nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	stored := false
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.writeUploadPartError(w, r, err)
+			return
+		}
+		if part.FormName() == formFieldData && !stored {
+			if _, err := uploader.UploadPart(ctx, fileLocation(r, session.FileID), session.StoreUploadID, partNumber, maxPartSize, part); err != nil {
+				c.writeUploadPartError(w, r, err)
+				return
+			}
+			stored = true
+		} else if _, err := io.Copy(io.Discard, io.LimitReader(part, maxFormFieldBytes)); err != nil {
+			c.writeUploadPartError(w, r, err)
+			return
+		}
+		part.Close()
+	}
+	if !stored {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", formFieldData+" is required", nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	logger.V(logging.DEBUG).Info("upload part stored", "upload_id", session.Upload.ID, "part_number", partNumber)
+	common.WriteJSONResponse(ctx, w, http.StatusOK, openai.UploadPart{
+		ID:        partIDPrefix + strconv.Itoa(partNumber),
+		Object:    "upload.part",
+		CreatedAt: time.Now().UTC().Unix(),
+		UploadID:  session.Upload.ID,
+	})
+}
+
+func (c *FilesApiHandler) writeUploadPartError(w http.ResponseWriter, r *http.Request, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.Is(err, filesapi.ErrFileTooLarge) || errors.As(err, &maxBytesErr) {
+		apiErr := openai.NewAPIError(http.StatusRequestEntityTooLarge, "",
+			fmt.Sprintf("part exceeds the maximum size of %d bytes", c.config.MaxUploadPartBytes), nil)
+		common.WriteAPIError(r.Context(), w, apiErr)
+		return
+	}
+	if errors.Is(err, filesapi.ErrUploadNotFound) {
+		apiErr := openai.NewAPIError(http.StatusNotFound, "", fmt.Sprintf("Upload with ID %s not found", r.PathValue(pathParamUploadID)), nil)
+		common.WriteAPIError(r.Context(), w, apiErr)
+		return
+	}
+	logging.GetRequestLogger(r).Error(err, "failed to upload part")
+	apiErr := openai.NewAPIError(http.StatusBadRequest, "", "failed to read upload part request", nil)
+	common.WriteAPIError(r.Context(), w, apiErr)
+}
+
+// CompleteUpload assembles the file from the given parts and creates its file object.
+// The size of the assembled file must match the bytes declared when the upload was created, otherwise
+// the upload is discarded.
+func (c *FilesApiHandler) CompleteUpload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	uploader := c.multipartUploader(w, r)
+	if uploader == nil {
+		return
+	}
+
+	req := &openai.CompleteUploadRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", "invalid request body", nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+	partNumbers, err := parsePartIDs(req.PartIDs)
+	if err != nil {
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", err.Error(), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	session := c.getUploadSession(w, r)
+	if session == nil {
+		return
+	}
+	upload := &session.Upload
+
+	fileMd, err := uploader.CompleteMultipartUpload(ctx, fileLocation(r, session.FileID), session.StoreUploadID, partNumbers)
+	if err != nil {
+		if errors.Is(err, filesapi.ErrPartNotFound) {
+			apiErr := openai.NewAPIError(http.StatusBadRequest, "", err.Error(), nil)
+			common.WriteAPIError(ctx, w, apiErr)
+			return
+		}
+		logger.Error(err, "failed to complete multipart upload", "upload_id", upload.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	c.deleteUploadSession(r, upload.ID)
+
+	if fileMd.Size != upload.Bytes {
+		c.filesClient.Delete(ctx, fileLocation(r, session.FileID))
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "",
+			fmt.Sprintf("uploaded %d bytes, but the upload was created with %d bytes; the upload was discarded", fileMd.Size, upload.Bytes), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return
+	}
+
+	fileObj := &openai.FileObject{
+		ID:        session.FileID,
+		Object:    "file",
+		Bytes:     fileMd.Size,
+		CreatedAt: time.Now().UTC().Unix(),
+		Filename:  upload.Filename,
+		Purpose:   upload.Purpose,
+		Status:    openai.FileObjectStatusUploaded,
+	}
+	if err := c.storeFileObject(r, fileObj); err != nil {
+		logger.Error(err, "failed to store file metadata", "file_id", fileObj.ID)
+		c.filesClient.Delete(ctx, fileLocation(r, session.FileID))
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+
+	upload.Status = openai.UploadStatusCompleted
+	upload.File = fileObj
+	logger.Info("upload completed", "upload_id", upload.ID, "file_id", fileObj.ID, "parts", len(partNumbers))
+	common.WriteJSONResponse(ctx, w, http.StatusOK, upload)
+}
+
+// parsePartIDs returns the part numbers of the part IDs, which must be in ascending order.
+func parsePartIDs(partIDs []string) ([]int, error) {
+	if len(partIDs) == 0 {
+		return nil, fmt.Errorf("part_ids is required")
+	}
+	partNumbers := make([]int, 0, len(partIDs))
+	for _, id := range partIDs {
+		n, err := strconv.Atoi(strings.TrimPrefix(id, partIDPrefix))
+		if err != nil || !strings.HasPrefix(id, partIDPrefix) || n < 1 || n > maxPartNumber {
+			return nil, fmt.Errorf("invalid part ID %q", id)
+		}
+		partNumbers = append(partNumbers, n)
+	}
+	for i := 1; i < len(partNumbers); i++ {
+		if partNumbers[i] <= partNumbers[i-1] {
+			return nil, fmt.Errorf("part_ids must be unique and in ascending order of their part numbers")
+		}
+	}
+	return partNumbers, nil
+}
+
+func (c *FilesApiHandler) CancelUpload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	uploader := c.multipartUploader(w, r)
+	if uploader == nil {
+		return
+	}
+	session := c.getUploadSession(w, r)
+	if session == nil {
+		return
+	}
+
+	if err := uploader.AbortMultipartUpload(ctx, fileLocation(r, session.FileID), session.StoreUploadID); err != nil && !errors.Is(err, filesapi.ErrUploadNotFound) {
+		logger.Error(err, "failed to abort multipart upload", "upload_id", session.Upload.ID)
+		common.WriteInternalServerError(ctx, w)
+		return
+	}
+	c.deleteUploadSession(r, session.Upload.ID)
+
+	session.Upload.Status = openai.UploadStatusCancelled
+	common.WriteJSONResponse(ctx, w, http.StatusOK, session.Upload)
+}
+
+// uploadSessionLocation returns the location of an upload session of the tenant of the request, so the
+// uploads of other tenants are not found.
+func uploadSessionLocation(r *http.Request, uploadID string) string {
+	return fileLocation(r, uploadSessionsLocation+"/"+uploadID+".json")
+}
+
+func (c *FilesApiHandler) storeUploadSession(r *http.Request, session *uploadSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	_, err = c.filesClient.Store(r.Context(), uploadSessionLocation(r, session.Upload.ID), 0, bytes.NewReader(data))
+	return err
+}
+
+func (c *FilesApiHandler) deleteUploadSession(r *http.Request, uploadID string) {
+	if err := c.filesClient.Delete(r.Context(), uploadSessionLocation(r, uploadID)); err != nil && !errors.Is(err, filesapi.ErrFileNotFound) {
+		logging.GetRequestLogger(r).Error(err, "failed to delete upload session", "upload_id", uploadID)
+	}
+}
+
+// getUploadSession gets the pending upload session of the request. It writes an error response and returns nil
+// on failure. Expired uploads are discarded.
+func (c *FilesApiHandler) getUploadSession(w http.ResponseWriter, r *http.Request) *uploadSession {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	uploadID := r.PathValue(pathParamUploadID)
+	notFound := func() {
+		apiErr := openai.NewAPIError(http.StatusNotFound, "", fmt.Sprintf("Upload with ID %s not found", uploadID), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+	}
+	// upload IDs are generated by the server, anything else can't be a valid session location
+	if _, err := uuid.Parse(strings.TrimPrefix(uploadID, "upload_")); err != nil || !strings.HasPrefix(uploadID, "upload_") {
+		notFound()
+		return nil
+	}
+
+	reader, _, err := c.filesClient.Retrieve(ctx, uploadSessionLocation(r, uploadID))
+	if err != nil {
+		if errors.Is(err, filesapi.ErrFileNotFound) {
+			notFound()
+			return nil
+		}
+		logger.Error(err, "failed to retrieve upload session", "upload_id", uploadID)
+		common.WriteInternalServerError(ctx, w)
+		return nil
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	session := &uploadSession{}
+	if err := json.NewDecoder(reader).Decode(session); err != nil {
+		logger.Error(err, "failed to decode upload session", "upload_id", uploadID)
+		common.WriteInternalServerError(ctx, w)
+		return nil
+	}
+
+	if time.Now().Unix() >= session.Upload.ExpiresAt {
+		if uploader, ok := c.filesClient.(filesapi.BatchFilesMultipartUploader); ok {
+			uploader.AbortMultipartUpload(ctx, fileLocation(r, session.FileID), session.StoreUploadID)
+		}
+		c.deleteUploadSession(r, uploadID)
+		apiErr := openai.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Upload with ID %s has expired", uploadID), nil)
+		common.WriteAPIError(ctx, w, apiErr)
+		return nil
+	}
+	return session
+}
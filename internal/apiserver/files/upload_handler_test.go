@@ -0,0 +1,217 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for the upload handler.
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+func createUploadForTest(t *testing.T, mux *http.ServeMux, size int64) openai.Upload {
+	t.Helper()
+	body, _ := json.Marshal(openai.CreateUploadRequest{
+		Filename: "input.jsonl",
+		Purpose:  openai.FileObjectPurposeBatch,
+		Bytes:    size,
+		MimeType: "application/jsonl",
+	})
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/uploads", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("CreateUpload returned wrong status code: got %v want %v, body: %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var upload openai.Upload
+	if err := json.NewDecoder(rr.Body).Decode(&upload); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	return upload
+}
+
+func addUploadPartForTest(mux *http.ServeMux, uploadID string, partNumber int, data string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, _ := mw.CreateFormFile(formFieldData, "part")
+	fw.Write([]byte(data))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/uploads/%s/parts?part_number=%d", uploadID, partNumber), body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	return rr
+}
+
+func completeUploadForTest(mux *http.ServeMux, uploadID string, partIDs ...string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(openai.CompleteUploadRequest{PartIDs: partIDs})
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/uploads/"+uploadID+"/complete", bytes.NewReader(body)))
+	return rr
+}
+
+func TestUploadHandler(t *testing.T) {
+
+	t.Run("UploadPartsAndComplete", func(t *testing.T) {
+		_, mux := setupFilesApiHandlerForTest(t, 1024)
+		part1 := `{"custom_id":"r1","method":"POST","url":"/v1/chat/completions","body":{}}` + "\n"
+		part2 := `{"custom_id":"r2","method":"POST","url":"/v1/chat/completions","body":{}}` + "\n"
+		upload := createUploadForTest(t, mux, int64(len(part1)+len(part2)))
+		if upload.Status != openai.UploadStatusPending || upload.ExpiresAt <= upload.CreatedAt {
+			t.Errorf("unexpected upload: %+v", upload)
+		}
+
+		// parts are uploaded out of order, and a failed part is retried
+		for _, part := range []struct {
+			number int
+			data   string
+		}{{2, part2}, {1, "partial"}, {1, part1}} {
+			rr := addUploadPartForTest(mux, upload.ID, part.number, part.data)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("AddUploadPart returned wrong status code: got %v want %v, body: %s", rr.Code, http.StatusOK, rr.Body.String())
+			}
+			var uploadPart openai.UploadPart
+			json.NewDecoder(rr.Body).Decode(&uploadPart)
+			if uploadPart.ID != fmt.Sprintf("part_%d", part.number) || uploadPart.UploadID != upload.ID {
+				t.Errorf("unexpected upload part: %+v", uploadPart)
+			}
+		}
+
+		rr := completeUploadForTest(mux, upload.ID, "part_1", "part_2")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("CompleteUpload returned wrong status code: got %v want %v, body: %s", rr.Code, http.StatusOK, rr.Body.String())
+		}
+		var completed openai.Upload
+		json.NewDecoder(rr.Body).Decode(&completed)
+		if completed.Status != openai.UploadStatusCompleted || completed.File == nil || completed.File.Bytes != upload.Bytes {
+			t.Fatalf("unexpected completed upload: %+v", completed)
+		}
+
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/files/"+completed.File.ID+"/content", nil))
+		if rr.Body.String() != part1+part2 {
+			t.Errorf("DownloadFile returned %q, want %q", rr.Body.String(), part1+part2)
+		}
+
+		// the upload is gone once completed
+		if rr := completeUploadForTest(mux, upload.ID, "part_1", "part_2"); rr.Code != http.StatusNotFound {
+			t.Errorf("CompleteUpload after completion returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("CreateUploadNegative", func(t *testing.T) {
+		_, mux := setupFilesApiHandlerForTest(t, 1024)
+		tests := []struct {
+			name string
+			req  openai.CreateUploadRequest
+		}{
+			{name: "missing filename", req: openai.CreateUploadRequest{Purpose: openai.FileObjectPurposeBatch, Bytes: 10}},
+			{name: "unsupported purpose", req: openai.CreateUploadRequest{Filename: "f", Purpose: "fine-tune", Bytes: 10}},
+			{name: "zero bytes", req: openai.CreateUploadRequest{Filename: "f", Purpose: openai.FileObjectPurposeBatch}},
+			{name: "too large", req: openai.CreateUploadRequest{Filename: "f", Purpose: openai.FileObjectPurposeBatch, Bytes: 2048}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				body, _ := json.Marshal(tt.req)
+				rr := httptest.NewRecorder()
+				mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/uploads", bytes.NewReader(body)))
+				if rr.Code != http.StatusBadRequest {
+					t.Errorf("expected status %d, got %d, body: %s", http.StatusBadRequest, rr.Code, rr.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("CompleteUploadNegative", func(t *testing.T) {
+		_, mux := setupFilesApiHandlerForTest(t, 1024)
+		upload := createUploadForTest(t, mux, 100)
+		addUploadPartForTest(mux, upload.ID, 1, "hello")
+		addUploadPartForTest(mux, upload.ID, 2, "world")
+
+		tests := []struct {
+			name           string
+			partIDs        []string
+			expectedStatus int
+		}{
+			{name: "no parts", expectedStatus: http.StatusBadRequest},
+			{name: "invalid part ID", partIDs: []string{"chunk_1"}, expectedStatus: http.StatusBadRequest},
+			{name: "descending parts", partIDs: []string{"part_2", "part_1"}, expectedStatus: http.StatusBadRequest},
+			{name: "missing part", partIDs: []string{"part_1", "part_3"}, expectedStatus: http.StatusBadRequest},
+			// the size doesn't match the declared bytes, so the upload is discarded
+			{name: "size mismatch", partIDs: []string{"part_1", "part_2"}, expectedStatus: http.StatusBadRequest},
+			{name: "discarded", partIDs: []string{"part_1", "part_2"}, expectedStatus: http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if rr := completeUploadForTest(mux, upload.ID, tt.partIDs...); rr.Code != tt.expectedStatus {
+					t.Errorf("expected status %d, got %d, body: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+				}
+			})
+		}
+	})
+
+	t.Run("CancelUpload", func(t *testing.T) {
+		_, mux := setupFilesApiHandlerForTest(t, 1024)
+		upload := createUploadForTest(t, mux, 5)
+		addUploadPartForTest(mux, upload.ID, 1, "hello")
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/uploads/"+upload.ID+"/cancel", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("CancelUpload returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+		}
+		var cancelled openai.Upload
+		json.NewDecoder(rr.Body).Decode(&cancelled)
+		if cancelled.Status != openai.UploadStatusCancelled {
+			t.Errorf("Status = %v, want %v", cancelled.Status, openai.UploadStatusCancelled)
+		}
+		if rr := addUploadPartForTest(mux, upload.ID, 2, "world"); rr.Code != http.StatusNotFound {
+			t.Errorf("AddUploadPart after cancel returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("ExpiredUpload", func(t *testing.T) {
+		handler, mux := setupFilesApiHandlerForTest(t, 1024)
+		handler.config.UploadSessionTTL = -time.Second
+		upload := createUploadForTest(t, mux, 5)
+
+		if rr := addUploadPartForTest(mux, upload.ID, 1, "hello"); rr.Code != http.StatusBadRequest {
+			t.Errorf("AddUploadPart of expired upload returned wrong status code: got %v want %v", rr.Code, http.StatusBadRequest)
+		}
+		if rr := addUploadPartForTest(mux, upload.ID, 1, "hello"); rr.Code != http.StatusNotFound {
+			t.Errorf("AddUploadPart of discarded upload returned wrong status code: got %v want %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("UnsupportedFilesStore", func(t *testing.T) {
+		handler, mux := setupFilesApiHandlerForTest(t, 1024)
+		handler.filesClient = &presigningFilesClient{BatchFilesClient: handler.filesClient}
+		body, _ := json.Marshal(openai.CreateUploadRequest{Filename: "f", Purpose: openai.FileObjectPurposeBatch, Bytes: 5})
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/uploads", bytes.NewReader(body)))
+		if rr.Code != http.StatusNotImplemented {
+			t.Errorf("expected status %d, got %d", http.StatusNotImplemented, rr.Code)
+		}
+	})
+}
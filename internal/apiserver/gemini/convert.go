@@ -0,0 +1,366 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file translates the Gemini requests and responses to and from the OpenAI chat completions the batches
+// are processed with.
+package gemini
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// chatCompletionRequest is the body of the chat completion request a Gemini request is translated to.
+type chatCompletionRequest struct {
+	Model            string          `json:"model"`
+	Messages         []chatMessage   `json:"messages"`
+	MaxTokens        *int            `json:"max_tokens,omitempty"`
+	Temperature      *float64        `json:"temperature,omitempty"`
+	TopP             *float64        `json:"top_p,omitempty"`
+	TopK             *int            `json:"top_k,omitempty"`
+	N                *int            `json:"n,omitempty"`
+	Stop             []string        `json:"stop,omitempty"`
+	Seed             *int64          `json:"seed,omitempty"`
+	PresencePenalty  *float64        `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64        `json:"frequency_penalty,omitempty"`
+	ResponseFormat   *responseFormat `json:"response_format,omitempty"`
+}
+
+type chatMessage struct {
+	Role string `json:"role"`
+	// the text of the message, or its parts when it has images
+	Content any `json:"content"`
+}
+
+type chatContentPart struct {
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	ImageURL *chatImageURL `json:"image_url,omitempty"`
+}
+
+type chatImageURL struct {
+	URL string `json:"url"`
+}
+
+type responseFormat struct {
+	Type string `json:"type"`
+}
+
+// chatCompletionResponse is the part of a chat completion response translated to a Gemini response.
+type chatCompletionResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Index   int `json:"index"`
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int64 `json:"prompt_tokens"`
+		CompletionTokens int64 `json:"completion_tokens"`
+		TotalTokens      int64 `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// requestLine translates an inlined request of a batch of the model to a line of the batch input file.
+func requestLine(customID, model string, req *GenerateContentRequest) (*openai.BatchRequestInput, error) {
+	if req.Model != "" && strings.TrimPrefix(req.Model, modelNamePrefix) != model {
+		return nil, fmt.Errorf("model %s is not the model of the batch", req.Model)
+	}
+	if len(req.Tools) > 0 || len(req.ToolConfig) > 0 {
+		return nil, errors.New("tools are not supported")
+	}
+	if req.CachedContent != "" {
+		return nil, errors.New("cached contents are not supported")
+	}
+	if len(req.Contents) == 0 {
+		return nil, errors.New("contents is required")
+	}
+
+	body := chatCompletionRequest{Model: model}
+	if req.SystemInstruction != nil {
+		message, err := chatMessageOf("system", req.SystemInstruction.Parts)
+		if err != nil {
+			return nil, fmt.Errorf("systemInstruction: %w", err)
+		}
+		body.Messages = append(body.Messages, message)
+	}
+	for i, content := range req.Contents {
+		role := "user"
+		switch content.Role {
+		case "", "user":
+		case "model":
+			role = "assistant"
+		default:
+			return nil, fmt.Errorf("contents[%d]: unsupported role %q", i, content.Role)
+		}
+		message, err := chatMessageOf(role, content.Parts)
+		if err != nil {
+			return nil, fmt.Errorf("contents[%d]: %w", i, err)
+		}
+		body.Messages = append(body.Messages, message)
+	}
+	if cfg := req.GenerationConfig; cfg != nil {
+		body.MaxTokens = cfg.MaxOutputTokens
+		body.Temperature = cfg.Temperature
+		body.TopP = cfg.TopP
+		body.TopK = cfg.TopK
+		body.N = cfg.CandidateCount
+		body.Stop = cfg.StopSequences
+		body.Seed = cfg.Seed
+		body.PresencePenalty = cfg.PresencePenalty
+		body.FrequencyPenalty = cfg.FrequencyPenalty
+		switch cfg.ResponseMimeType {
+		case "", "text/plain":
+		case "application/json":
+			body.ResponseFormat = &responseFormat{Type: "json_object"}
+		default:
+			return nil, fmt.Errorf("unsupported responseMimeType %q", cfg.ResponseMimeType)
+		}
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &openai.BatchRequestInput{
+		CustomID: customID,
+		Method:   http.MethodPost,
+		URL:      openai.EndpointChatCompletions.String(),
+		Body:     data,
+	}, nil
+}
+
+// chatMessageOf translates the parts of a content to a chat message. A message with text parts only has
+// the text of the parts as content.
+func chatMessageOf(role string, parts []Part) (chatMessage, error) {
+	if len(parts) == 0 {
+		return chatMessage{}, errors.New("parts is required")
+	}
+	contentParts := make([]chatContentPart, 0, len(parts))
+	textOnly := true
+	for i, part := range parts {
+		switch {
+		case part.InlineData != nil:
+			if !strings.HasPrefix(part.InlineData.MimeType, "image/") {
+				return chatMessage{}, fmt.Errorf("parts[%d]: unsupported media type %q", i, part.InlineData.MimeType)
+			}
+			url := "data:" + part.InlineData.MimeType + ";base64," + part.InlineData.Data
+			contentParts = append(contentParts, chatContentPart{Type: "image_url", ImageURL: &chatImageURL{URL: url}})
+			textOnly = false
+		case part.FileData != nil:
+			if part.FileData.MimeType != "" && !strings.HasPrefix(part.FileData.MimeType, "image/") {
+				return chatMessage{}, fmt.Errorf("parts[%d]: unsupported media type %q", i, part.FileData.MimeType)
+			}
+			contentParts = append(contentParts, chatContentPart{Type: "image_url", ImageURL: &chatImageURL{URL: part.FileData.FileURI}})
+			textOnly = false
+		case len(part.FunctionCall) > 0 || len(part.FunctionResponse) > 0 || len(part.ExecutableCode) > 0:
+			return chatMessage{}, fmt.Errorf("parts[%d]: function calling and code execution are not supported", i)
+		default:
+			contentParts = append(contentParts, chatContentPart{Type: "text", Text: part.Text})
+		}
+	}
+	if role != "user" && !textOnly {
+		return chatMessage{}, fmt.Errorf("images are only supported in user contents")
+	}
+	if textOnly {
+		texts := make([]string, len(contentParts))
+		for i, part := range contentParts {
+			texts[i] = part.Text
+		}
+		return chatMessage{Role: role, Content: strings.Join(texts, "")}, nil
+	}
+	return chatMessage{Role: role, Content: contentParts}, nil
+}
+
+// inlinedResponse translates a line of the output or error file of a batch to the response of the inlined request.
+func inlinedResponse(line *openai.BatchRequestOutput) InlinedResponse {
+	resp := InlinedResponse{Metadata: map[string]any{metadataKey: line.CustomID}}
+	switch {
+	case line.Error != nil:
+		code := rpcInternal
+		switch line.Error.Code {
+		case openai.BatchRequestErrorExpired:
+			code = rpcDeadlineExceeded
+		case openai.BatchRequestErrorInvalidLine:
+			code = rpcInvalidArgument
+		}
+		resp.Error = &Status{Code: code, Message: line.Error.Message}
+	case line.Response == nil:
+		resp.Error = &Status{Code: rpcInternal, Message: "the request has no response"}
+	case line.Response.StatusCode != http.StatusOK:
+		message := http.StatusText(line.Response.StatusCode)
+		var body openai.ErrorResponse
+		if err := json.Unmarshal(line.Response.Body, &body); err == nil && body.Error.Message != "" {
+			message = body.Error.Message
+		}
+		code, _ := rpcStatus(line.Response.StatusCode)
+		resp.Error = &Status{Code: code, Message: message}
+	default:
+		generated, err := generateContentResponse(line.Response.Body)
+		if err != nil {
+			resp.Error = &Status{Code: rpcInternal, Message: fmt.Sprintf("failed to translate the response: %v", err)}
+			break
+		}
+		resp.Response = generated
+	}
+	return resp
+}
+
+// generateContentResponse translates the body of a chat completion response.
+func generateContentResponse(data []byte) (*GenerateContentResponse, error) {
+	var body chatCompletionResponse
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, err
+	}
+	resp := &GenerateContentResponse{
+		Candidates:   make([]Candidate, 0, len(body.Choices)),
+		ModelVersion: body.Model,
+	}
+	for _, choice := range body.Choices {
+		resp.Candidates = append(resp.Candidates, Candidate{
+			Content: Content{
+				Role:  "model",
+				Parts: []Part{{Text: choice.Message.Content}},
+			},
+			FinishReason: finishReason(choice.FinishReason),
+			Index:        choice.Index,
+		})
+	}
+	if body.Usage != nil {
+		resp.UsageMetadata = &UsageMetadata{
+			PromptTokenCount:     body.Usage.PromptTokens,
+			CandidatesTokenCount: body.Usage.CompletionTokens,
+			TotalTokenCount:      body.Usage.TotalTokens,
+		}
+	}
+	return resp, nil
+}
+
+func finishReason(reason string) string {
+	switch reason {
+	case "stop":
+		return "STOP"
+	case "length":
+		return "MAX_TOKENS"
+	case "content_filter":
+		return "SAFETY"
+	case "":
+		return ""
+	default:
+		return "OTHER"
+	}
+}
+
+// batchOperation translates a batch created through the Gemini API to its operation, without its responses.
+func batchOperation(b *openai.Batch) *Operation {
+	counts := b.RequestCounts
+	meta := &GenerateContentBatch{
+		Type:        TypeGenerateContentBatch,
+		Name:        batchNamePrefix + b.ID,
+		Model:       modelNamePrefix + b.Metadata[metadataModel],
+		DisplayName: b.Metadata[metadataDisplayName],
+		CreateTime:  timestamp(&b.CreatedAt),
+		BatchStats: &BatchStats{
+			RequestCount:           counts.Total,
+			SuccessfulRequestCount: counts.Completed,
+			FailedRequestCount:     counts.Failed,
+			PendingRequestCount:    max(counts.Total-counts.Completed-counts.Failed, 0),
+		},
+		State: batchState(b.Status),
+	}
+	for _, end := range []*int64{b.CompletedAt, b.FailedAt, b.ExpiredAt, b.CancelledAt} {
+		if end != nil {
+			meta.EndTime = timestamp(end)
+			break
+		}
+	}
+	op := &Operation{Name: meta.Name, Metadata: meta, Done: b.Status.IsFinal()}
+	if b.Status == openai.BatchStatusFailed {
+		op.Error = &Status{Code: rpcInvalidArgument, Message: "the batch failed"}
+		if b.Errors != nil && len(b.Errors.Data) > 0 {
+			op.Error.Message = b.Errors.Data[0].Message
+		}
+	}
+	return op
+}
+
+func batchState(status openai.BatchStatus) string {
+	switch status {
+	case openai.BatchStatusValidating:
+		return BatchStatePending
+	case openai.BatchStatusCompleted:
+		return BatchStateSucceeded
+	case openai.BatchStatusFailed:
+		return BatchStateFailed
+	case openai.BatchStatusCancelled:
+		return BatchStateCancelled
+	case openai.BatchStatusExpired:
+		return BatchStateExpired
+	default:
+		// in progress, finalizing and cancelling
+		return BatchStateRunning
+	}
+}
+
+func timestamp(unix *int64) string {
+	return time.Unix(*unix, 0).UTC().Format(time.RFC3339)
+}
+
+// gRPC status codes of the errors
+const (
+	rpcCancelled         = 1
+	rpcInvalidArgument   = 3
+	rpcDeadlineExceeded  = 4
+	rpcNotFound          = 5
+	rpcPermissionDenied  = 7
+	rpcResourceExhausted = 8
+	rpcUnimplemented     = 12
+	rpcInternal          = 13
+	rpcUnavailable       = 14
+	rpcUnauthenticated   = 16
+)
+
+// rpcStatus returns the gRPC status code matching an HTTP status code, and its name.
+func rpcStatus(httpStatus int) (int, string) {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return rpcInvalidArgument, "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return rpcUnauthenticated, "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return rpcPermissionDenied, "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return rpcNotFound, "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		return rpcResourceExhausted, "RESOURCE_EXHAUSTED"
+	case http.StatusNotImplemented:
+		return rpcUnimplemented, "UNIMPLEMENTED"
+	case http.StatusServiceUnavailable:
+		return rpcUnavailable, "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return rpcDeadlineExceeded, "DEADLINE_EXCEEDED"
+	default:
+		return rpcInternal, "INTERNAL"
+	}
+}
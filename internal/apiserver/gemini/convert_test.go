@@ -0,0 +1,171 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for the translation of the Gemini requests and responses.
+package gemini
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+func TestRequestLine(t *testing.T) {
+	temperature := 0.5
+
+	t.Run("ChatCompletion", func(t *testing.T) {
+		line, err := requestLine("k1", "m1", &GenerateContentRequest{
+			SystemInstruction: &Content{Parts: []Part{{Text: "Be brief."}}},
+			Contents: []Content{
+				{Role: "user", Parts: []Part{{Text: "What is this?"}, {InlineData: &Blob{MimeType: "image/png", Data: "aGk="}}}},
+				{Role: "model", Parts: []Part{{Text: "A cat."}}},
+				{Parts: []Part{{Text: "Sure?"}}},
+			},
+			GenerationConfig: &GenerationConfig{
+				Temperature:      &temperature,
+				StopSequences:    []string{"\n"},
+				ResponseMimeType: "application/json",
+			},
+		})
+		if err != nil {
+			t.Fatalf("requestLine failed: %v", err)
+		}
+		if line.CustomID != "k1" || line.Method != "POST" || line.URL != openai.EndpointChatCompletions.String() {
+			t.Errorf("unexpected line: %+v", line)
+		}
+		var body map[string]any
+		if err := json.Unmarshal(line.Body, &body); err != nil {
+			t.Fatalf("invalid body: %v", err)
+		}
+		messages := body["messages"].([]any)
+		if body["model"] != "m1" || body["temperature"] != 0.5 || len(messages) != 4 {
+			t.Fatalf("unexpected body: %s", line.Body)
+		}
+		roles := []string{"system", "user", "assistant", "user"}
+		for i, message := range messages {
+			if role := message.(map[string]any)["role"]; role != roles[i] {
+				t.Errorf("role of message %d = %v, want %s", i, role, roles[i])
+			}
+		}
+		if !strings.Contains(string(line.Body), `"url":"data:image/png;base64,aGk="`) ||
+			!strings.Contains(string(line.Body), `"response_format":{"type":"json_object"}`) {
+			t.Errorf("unexpected body: %s", line.Body)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		text := []Content{{Parts: []Part{{Text: "x"}}}}
+		tests := []struct {
+			name string
+			req  GenerateContentRequest
+		}{
+			{"other model", GenerateContentRequest{Model: "models/m2", Contents: text}},
+			{"no contents", GenerateContentRequest{}},
+			{"tools", GenerateContentRequest{Contents: text, Tools: json.RawMessage(`[{}]`)}},
+			{"function call", GenerateContentRequest{Contents: []Content{{Parts: []Part{{FunctionCall: json.RawMessage(`{}`)}}}}}},
+			{"audio", GenerateContentRequest{Contents: []Content{{Parts: []Part{{InlineData: &Blob{MimeType: "audio/wav"}}}}}}},
+			{"role", GenerateContentRequest{Contents: []Content{{Role: "tool", Parts: []Part{{Text: "x"}}}}}},
+			{"response type", GenerateContentRequest{Contents: text, GenerationConfig: &GenerationConfig{ResponseMimeType: "text/x.enum"}}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, err := requestLine("k", "m1", &tt.req); err == nil {
+					t.Error("expected an error")
+				}
+			})
+		}
+		if _, err := requestLine("k", "m1", &GenerateContentRequest{Model: "models/m1", Contents: text}); err != nil {
+			t.Errorf("unexpected error for the model of the batch: %v", err)
+		}
+	})
+}
+
+func TestInlinedResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       openai.BatchRequestOutput
+		wantText   string
+		wantReason string
+		wantCode   int
+	}{
+		{
+			name: "response",
+			line: openai.BatchRequestOutput{CustomID: "k", Response: &openai.BatchRequestResponse{StatusCode: 200,
+				Body: json.RawMessage(`{"choices":[{"message":{"content":"Hi"},"finish_reason":"length"}]}`)}},
+			wantText:   "Hi",
+			wantReason: "MAX_TOKENS",
+		},
+		{
+			name: "http error",
+			line: openai.BatchRequestOutput{CustomID: "k", Response: &openai.BatchRequestResponse{StatusCode: 404,
+				Body: json.RawMessage(`{"error":{"message":"model not found"}}`)}},
+			wantCode: rpcNotFound,
+		},
+		{
+			name:     "expired",
+			line:     openai.BatchRequestOutput{CustomID: "k", Error: &openai.BatchRequestError{Code: openai.BatchRequestErrorExpired}},
+			wantCode: rpcDeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := inlinedResponse(&tt.line)
+			if resp.Metadata[metadataKey] != "k" {
+				t.Errorf("metadata = %v, want the key", resp.Metadata)
+			}
+			if tt.wantCode != 0 {
+				if resp.Error == nil || resp.Error.Code != tt.wantCode {
+					t.Errorf("error = %+v, want code %d", resp.Error, tt.wantCode)
+				}
+				return
+			}
+			if resp.Response == nil || len(resp.Response.Candidates) != 1 {
+				t.Fatalf("unexpected response: %+v", resp)
+			}
+			candidate := resp.Response.Candidates[0]
+			if candidate.Content.Role != "model" || candidate.Content.Parts[0].Text != tt.wantText || candidate.FinishReason != tt.wantReason {
+				t.Errorf("unexpected candidate: %+v", candidate)
+			}
+		})
+	}
+}
+
+func TestBatchOperation(t *testing.T) {
+	failedAt := int64(1700000000)
+	op := batchOperation(&openai.Batch{
+		ID: "batch_1",
+		BatchSpec: openai.BatchSpec{
+			CreatedAt: 1690000000,
+			Metadata:  map[string]string{metadataModel: "m1", metadataDisplayName: "nightly"},
+		},
+		BatchStatusInfo: openai.BatchStatusInfo{
+			Status:        openai.BatchStatusFailed,
+			FailedAt:      &failedAt,
+			RequestCounts: openai.BatchRequestCounts{Total: 3},
+			Errors:        &openai.BatchErrors{Data: []openai.BatchError{{Message: "invalid input"}}},
+		},
+	})
+	if op.Name != "batches/batch_1" || !op.Done || op.Error == nil || op.Error.Message != "invalid input" {
+		t.Errorf("unexpected operation: %+v", op)
+	}
+	meta := op.Metadata
+	if meta.State != BatchStateFailed || meta.Model != "models/m1" || meta.DisplayName != "nightly" ||
+		meta.EndTime != "2023-11-14T22:13:20Z" || meta.BatchStats.PendingRequestCount != 3 {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+}
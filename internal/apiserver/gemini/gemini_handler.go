@@ -0,0 +1,473 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file provides HTTP handlers for a subset of the Google Gemini Batch API: creating batches of inlined
+// `generateContent` requests, retrieving them with their responses, and cancelling them. The requests are
+// translated to chat completions and the batches are created and read through the OpenAI compatible Batch API.
+package gemini
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	filesapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
+	sharedbatch "github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// Gemini resource names are the last path segment followed by the method, e.g. models/{model}:batchGenerateContent
+	pathParamModelMethod = "model_method"
+	pathParamBatchID     = "batch_id"
+	pathParamBatchMethod = "batch_method"
+
+	methodBatchGenerateContent = "batchGenerateContent"
+	methodCancel               = "cancel"
+
+	modelNamePrefix = "models/"
+	batchNamePrefix = "batches/"
+
+	// metadata of the batches created through the Gemini API
+	metadataModel       = "gemini_model"
+	metadataDisplayName = "gemini_display_name"
+
+	// metadata of the inlined requests identifying them in the responses
+	metadataKey = "key"
+
+	// the size of a batch creation request with inlined requests is limited to 20MB, as in the Gemini API
+	maxInlinedRequestsBytes = 20 * 1024 * 1024
+
+	completionWindow = "24h"
+)
+
+type GeminiApiHandler struct {
+	config       *common.ServerConfig
+	fileDBClient api.BatchFileDBClient
+	filesClient  filesapi.BatchFilesClient
+	batches      *batch.BatchApiHandler
+}
+
+// NewGeminiApiHandler creates the handler of the Gemini Batch API, delegating to the handler of the OpenAI
+// compatible Batch API.
+func NewGeminiApiHandler(config *common.ServerConfig, fileDBClient api.BatchFileDBClient, filesClient filesapi.BatchFilesClient, batches *batch.BatchApiHandler) *GeminiApiHandler {
+	return &GeminiApiHandler{
+		config:       config,
+		fileDBClient: fileDBClient,
+		filesClient:  filesClient,
+		batches:      batches,
+	}
+}
+
+func (c *GeminiApiHandler) GetRoutes() []common.Route {
+	return []common.Route{
+		{
+			Method:      http.MethodPost,
+			Pattern:     "/v1beta/models/{" + pathParamModelMethod + "}",
+			HandlerFunc: c.BatchGenerateContent,
+		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     "/v1beta/batches/{" + pathParamBatchID + "}",
+			HandlerFunc: c.GetBatch,
+		},
+		{
+			Method:      http.MethodPost,
+			Pattern:     "/v1beta/batches/{" + pathParamBatchMethod + "}",
+			HandlerFunc: c.CancelBatch,
+		},
+	}
+}
+
+// BatchGenerateContent creates a batch of inlined requests, stored as the input file of the batch.
+func (c *GeminiApiHandler) BatchGenerateContent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	model, method, _ := strings.Cut(r.PathValue(pathParamModelMethod), ":")
+	if method != methodBatchGenerateContent || model == "" {
+		writeError(ctx, w, http.StatusNotFound, fmt.Sprintf("method %s is not supported", r.URL.Path))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxInlinedRequestsBytes)
+	req := &BatchGenerateContentRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(ctx, w, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("request exceeds the maximum size of %d bytes", maxInlinedRequestsBytes))
+			return
+		}
+		logger.Error(err, "failed to decode request")
+		writeError(ctx, w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	input := req.Batch.InputConfig
+	if input == nil || input.Requests == nil || len(input.Requests.Requests) == 0 {
+		msg := "batch.inputConfig.requests is required"
+		if input != nil && input.FileName != "" {
+			msg = "input files are not supported, the requests must be inlined in batch.inputConfig.requests"
+		}
+		writeError(ctx, w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// the inlined requests are translated to the lines of the input file
+	var buf bytes.Buffer
+	for i, inlined := range input.Requests.Requests {
+		customID := fmt.Sprintf("request-%d", i+1)
+		if key, ok := inlined.Metadata[metadataKey].(string); ok && key != "" {
+			customID = key
+		}
+		line, err := requestLine(customID, model, &inlined.Request)
+		if err == nil {
+			var data []byte
+			if data, err = json.Marshal(line); err == nil {
+				buf.Write(data)
+				buf.WriteByte('\n')
+			}
+		}
+		if err != nil {
+			writeError(ctx, w, http.StatusBadRequest, fmt.Sprintf("batch.inputConfig.requests[%d]: %v", i, err))
+			return
+		}
+	}
+	tenantID := common.GetTenantID(r)
+	fileID, err := c.storeInputFile(ctx, tenantID, buf.Bytes())
+	if err != nil {
+		logger.Error(err, "failed to store input file")
+		writeError(ctx, w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	batchReq := openai.CreateBatchRequest{
+		InputFileID:      fileID,
+		Endpoint:         openai.EndpointChatCompletions,
+		CompletionWindow: completionWindow,
+		Metadata:         map[string]string{metadataModel: model},
+	}
+	if req.Batch.DisplayName != "" {
+		batchReq.Metadata[metadataDisplayName] = req.Batch.DisplayName
+	}
+	rec, err := delegate(c.batches.CreateBatch, r, batchReq)
+	if err != nil {
+		logger.Error(err, "failed to encode batch request")
+		writeError(ctx, w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	if rec.status != http.StatusOK {
+		c.deleteInputFile(ctx, tenantID, fileID)
+		writeDelegateError(ctx, w, rec)
+		return
+	}
+	var created openai.Batch
+	if err := json.Unmarshal(rec.body.Bytes(), &created); err != nil {
+		logger.Error(err, "failed to decode created batch")
+		writeError(ctx, w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	logger.Info("gemini batch created", "batch_id", created.ID, "model", model, "requests", len(input.Requests.Requests))
+	common.WriteJSONResponse(ctx, w, http.StatusOK, batchOperation(&created))
+}
+
+// GetBatch returns the operation of a batch, with the responses of its requests once it is done.
+func (c *GeminiApiHandler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	b, ok := c.retrieveBatch(w, r, r.PathValue(pathParamBatchID))
+	if !ok {
+		return
+	}
+	op := batchOperation(b)
+	if op.Done && (b.OutputFileID != "" || b.ErrorFileID != "") {
+		responses, err := c.inlinedResponses(ctx, common.GetTenantID(r), b)
+		if err != nil {
+			logger.Error(err, "failed to read the responses of the batch", "batch_id", b.ID)
+			writeError(ctx, w, http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+		op.Response = &GenerateContentBatchOutput{
+			Type:             TypeGenerateContentBatchOutput,
+			InlinedResponses: &InlinedResponses{InlinedResponses: responses},
+		}
+	}
+	common.WriteJSONResponse(ctx, w, http.StatusOK, op)
+}
+
+// CancelBatch cancels a batch.
+func (c *GeminiApiHandler) CancelBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	batchID, method, _ := strings.Cut(r.PathValue(pathParamBatchMethod), ":")
+	if method != methodCancel {
+		writeError(ctx, w, http.StatusNotFound, fmt.Sprintf("method %s is not supported", r.URL.Path))
+		return
+	}
+	if _, ok := c.retrieveBatch(w, r, batchID); !ok {
+		return
+	}
+	rec, err := delegate(c.batches.CancelBatch, r, nil, pathParamBatchID, batchID)
+	if err != nil {
+		writeError(ctx, w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	if rec.status != http.StatusOK {
+		writeDelegateError(ctx, w, rec)
+		return
+	}
+	common.WriteJSONResponse(ctx, w, http.StatusOK, struct{}{})
+}
+
+// retrieveBatch gets a batch created through the Gemini API. It writes an error response and returns false
+// on failure; the batches created through the OpenAI API are not found.
+func (c *GeminiApiHandler) retrieveBatch(w http.ResponseWriter, r *http.Request, batchID string) (*openai.Batch, bool) {
+	ctx := r.Context()
+
+	rec, err := delegate(c.batches.RetrieveBatch, r, nil, pathParamBatchID, batchID)
+	if err != nil {
+		writeError(ctx, w, http.StatusInternalServerError, "Internal Server Error")
+		return nil, false
+	}
+	if rec.status != http.StatusOK {
+		writeDelegateError(ctx, w, rec)
+		return nil, false
+	}
+	var b openai.Batch
+	if err := json.Unmarshal(rec.body.Bytes(), &b); err != nil {
+		logging.GetRequestLogger(r).Error(err, "failed to decode batch", "batch_id", batchID)
+		writeError(ctx, w, http.StatusInternalServerError, "Internal Server Error")
+		return nil, false
+	}
+	if b.Metadata[metadataModel] == "" {
+		writeError(ctx, w, http.StatusNotFound, fmt.Sprintf("Batch %s%s not found", batchNamePrefix, batchID))
+		return nil, false
+	}
+	return &b, true
+}
+
+// storeInputFile stores the translated requests as an input file of the tenant.
+func (c *GeminiApiHandler) storeInputFile(ctx context.Context, tenantID string, data []byte) (string, error) {
+	fileID := fmt.Sprintf("file_%s", uuid.NewString())
+	location := sharedbatch.FileLocation(tenantID, fileID)
+	md, err := c.filesClient.Store(ctx, location, 0, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	spec, err := json.Marshal(openai.FileObject{
+		ID:        fileID,
+		Object:    "file",
+		Bytes:     md.Size,
+		CreatedAt: time.Now().UTC().Unix(),
+		Filename:  "gemini_batch_requests.jsonl",
+		Purpose:   openai.FileObjectPurposeBatch,
+		Status:    openai.FileObjectStatusProcessed,
+	})
+	if err == nil {
+		_, err = c.fileDBClient.Store(ctx, &api.BatchFile{
+			ID:   fileID,
+			TTL:  c.config.BatchTTLSeconds,
+			Tags: []string{sharedbatch.TenantTag(tenantID)},
+			Spec: spec,
+		})
+	}
+	if err != nil {
+		c.filesClient.Delete(ctx, location)
+		return "", err
+	}
+	metrics.RecordFileStored(tenantID, md.Size)
+	return fileID, nil
+}
+
+// deleteInputFile deletes the input file of a batch that could not be created.
+func (c *GeminiApiHandler) deleteInputFile(ctx context.Context, tenantID, fileID string) {
+	logger := klog.FromContext(ctx)
+	if err := c.filesClient.Delete(ctx, sharedbatch.FileLocation(tenantID, fileID)); err != nil {
+		logger.Error(err, "failed to delete input file", "file_id", fileID)
+	}
+	if _, err := c.fileDBClient.Delete(ctx, []string{fileID}); err != nil {
+		logger.Error(err, "failed to delete input file metadata", "file_id", fileID)
+	}
+}
+
+// inlinedResponses reads the output and error files of a batch, and returns the responses in the order of
+// the requests of its input file.
+func (c *GeminiApiHandler) inlinedResponses(ctx context.Context, tenantID string, b *openai.Batch) ([]InlinedResponse, error) {
+	order := make(map[string]int)
+	err := c.readLines(ctx, tenantID, b.InputFileID, func(data []byte) error {
+		var line openai.BatchRequestInput
+		if err := json.Unmarshal(data, &line); err != nil {
+			return err
+		}
+		order[line.CustomID] = len(order)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	responses := make([]InlinedResponse, len(order))
+	for customID, i := range order {
+		// the requests that were not processed, e.g. of a cancelled batch, have no result line
+		responses[i] = InlinedResponse{
+			Error:    &Status{Code: rpcCancelled, Message: "the request was not processed"},
+			Metadata: map[string]any{metadataKey: customID},
+		}
+	}
+	for _, fileID := range append(resultFileIDs(b.OutputFileID, b.OutputFileIDs), resultFileIDs(b.ErrorFileID, b.ErrorFileIDs)...) {
+		err := c.readLines(ctx, tenantID, fileID, func(data []byte) error {
+			var line openai.BatchRequestOutput
+			if err := json.Unmarshal(data, &line); err != nil {
+				return err
+			}
+			if i, ok := order[line.CustomID]; ok {
+				responses[i] = inlinedResponse(&line)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read result file %s: %w", fileID, err)
+		}
+	}
+	return responses, nil
+}
+
+// resultFileIDs returns the IDs of the shards of a result file, or of the file itself when it is not sharded.
+func resultFileIDs(fileID string, shardIDs []string) []string {
+	if len(shardIDs) > 0 {
+		return shardIDs
+	}
+	if fileID != "" {
+		return []string{fileID}
+	}
+	return nil
+}
+
+// readLines calls fn with each line of a JSONL file of the tenant.
+func (c *GeminiApiHandler) readLines(ctx context.Context, tenantID, fileID string, fn func(data []byte) error) error {
+	reader, _, err := c.filesClient.Retrieve(ctx, sharedbatch.FileLocation(tenantID, fileID))
+	if err != nil {
+		return err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	br := bufio.NewReader(reader)
+	for {
+		data, readErr := br.ReadBytes('\n')
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			if err := fn(data); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// responseRecorder records the response of the OpenAI handler a request is delegated to.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(data)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// delegate calls an OpenAI handler with a copy of the request, with body as JSON body when it is not nil and
+// the given path values, as pairs of name and value.
+func delegate(handler http.HandlerFunc, r *http.Request, body any, pathValues ...string) (*responseRecorder, error) {
+	req := r.Clone(r.Context())
+	req.URL.RawQuery = ""
+	req.Body = http.NoBody
+	req.ContentLength = 0
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		req.ContentLength = int64(len(data))
+	}
+	for i := 0; i+1 < len(pathValues); i += 2 {
+		req.SetPathValue(pathValues[i], pathValues[i+1])
+	}
+	rec := &responseRecorder{header: make(http.Header)}
+	handler(rec, req)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec, nil
+}
+
+// writeDelegateError writes the error response of an OpenAI handler as a Gemini error response.
+func writeDelegateError(ctx context.Context, w http.ResponseWriter, rec *responseRecorder) {
+	message := http.StatusText(rec.status)
+	var resp openai.ErrorResponse
+	if err := json.Unmarshal(rec.body.Bytes(), &resp); err == nil && resp.Error.Message != "" {
+		message = resp.Error.Message
+		if resp.Errors != nil && len(resp.Errors.Data) > 0 {
+			// the lines of the input file are the inlined requests
+			first := resp.Errors.Data[0]
+			message += ": request " + strconv.FormatInt(first.Line, 10) + ": " + first.Message
+		}
+	}
+	writeError(ctx, w, rec.status, message)
+}
+
+// writeError writes a Gemini error response.
+func writeError(ctx context.Context, w http.ResponseWriter, httpStatus int, message string) {
+	_, status := rpcStatus(httpStatus)
+	common.WriteJSONResponse(ctx, w, httpStatus, ErrorResponse{
+		Error: Status{Code: httpStatus, Message: message, Status: status},
+	})
+}
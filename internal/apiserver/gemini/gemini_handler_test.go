@@ -0,0 +1,249 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for the Gemini Batch API handlers.
+package gemini
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	mockapi "github.com/llm-d-incubation/batch-gateway/internal/database/mock"
+	fsapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/fs"
+	sharedbatch "github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+type testEnv struct {
+	mux      *http.ServeMux
+	dbClient *mockapi.MockBatchDBClient
+	files    *fsapi.FSFilesClient
+}
+
+func setupGeminiApiHandlerForTest(t *testing.T) *testEnv {
+	t.Helper()
+	files, err := fsapi.NewFSFilesClient(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create files client: %v", err)
+	}
+	env := &testEnv{
+		mux:      http.NewServeMux(),
+		dbClient: mockapi.NewMockBatchDBClient(),
+		files:    files,
+	}
+	config := &common.ServerConfig{BatchTTLSeconds: 3600}
+	fileDBClient := mockapi.NewMockBatchFileDBClient()
+	batches := batch.NewBatchApiHandler(config, env.dbClient, fileDBClient, mockapi.NewMockBatchPriorityQueueClient(),
+		mockapi.NewMockBatchEventChannelClient(), mockapi.NewMockBatchStatusClient(), files)
+	common.RegisterHandler(env.mux, NewGeminiApiHandler(config, fileDBClient, files, batches))
+	return env
+}
+
+func (env *testEnv) do(t *testing.T, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	env.mux.ServeHTTP(rr, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rr
+}
+
+func (env *testEnv) storeFile(t *testing.T, fileID string, lines ...string) {
+	t.Helper()
+	location := sharedbatch.FileLocation(common.DefaultTenantID, fileID)
+	if _, err := env.files.Store(context.Background(), location, 0, strings.NewReader(strings.Join(lines, "\n")+"\n")); err != nil {
+		t.Fatalf("Failed to store file %s: %v", fileID, err)
+	}
+}
+
+const testBatchRequest = `{"batch":{"displayName":"my-batch","inputConfig":{"requests":{"requests":[
+	{"request":{"contents":[{"parts":[{"text":"Hello"}]}]},"metadata":{"key":"greeting"}},
+	{"request":{"contents":[{"role":"user","parts":[{"text":"Bye"}]}],"generationConfig":{"maxOutputTokens":5}}}
+]}}}}`
+
+func TestGeminiHandler(t *testing.T) {
+
+	t.Run("BatchLifecycle", func(t *testing.T) {
+		env := setupGeminiApiHandlerForTest(t)
+
+		rr := env.do(t, http.MethodPost, "/v1beta/models/m1:batchGenerateContent", testBatchRequest)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var op Operation
+		if err := json.NewDecoder(rr.Body).Decode(&op); err != nil {
+			t.Fatalf("Failed to decode operation: %v", err)
+		}
+		if !strings.HasPrefix(op.Name, batchNamePrefix) || op.Done || op.Metadata.State != BatchStatePending ||
+			op.Metadata.Model != "models/m1" || op.Metadata.DisplayName != "my-batch" {
+			t.Fatalf("unexpected operation: %+v %+v", op, op.Metadata)
+		}
+		batchID := strings.TrimPrefix(op.Name, batchNamePrefix)
+
+		// the requests are stored as chat completions
+		jobs, _, err := env.dbClient.Get(context.Background(), []string{batchID}, nil, api.TagsLogicalCondNa, true, 0, 1)
+		if err != nil || len(jobs) != 1 {
+			t.Fatalf("Failed to get batch %s: %v", batchID, err)
+		}
+		created, err := batch.JobToBatch(jobs[0])
+		if err != nil {
+			t.Fatalf("Failed to convert job: %v", err)
+		}
+		reader, _, err := env.files.Retrieve(context.Background(), sharedbatch.FileLocation(common.DefaultTenantID, created.InputFileID))
+		if err != nil {
+			t.Fatalf("Failed to retrieve input file: %v", err)
+		}
+		input, _ := io.ReadAll(reader)
+		reader.(io.Closer).Close()
+		lines := strings.Split(strings.TrimSpace(string(input)), "\n")
+		if len(lines) != 2 || !strings.Contains(lines[0], `"custom_id":"greeting"`) ||
+			!strings.Contains(lines[1], `"custom_id":"request-2"`) || !strings.Contains(lines[1], `"max_tokens":5`) {
+			t.Fatalf("unexpected input file:\n%s", input)
+		}
+
+		// complete the batch, the second request failed
+		env.storeFile(t, "file_output",
+			`{"id":"r1","custom_id":"greeting","response":{"status_code":200,"request_id":"x","body":{"model":"m1","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}},"error":null}`)
+		env.storeFile(t, "file_error",
+			`{"id":"r2","custom_id":"request-2","response":{"status_code":429,"request_id":"y","body":{"error":{"message":"slow down"}}},"error":null}`)
+		completedAt := int64(1700000000)
+		status, _ := json.Marshal(openai.BatchStatusInfo{
+			Status:        openai.BatchStatusCompleted,
+			OutputFileID:  "file_output",
+			ErrorFileID:   "file_error",
+			CompletedAt:   &completedAt,
+			RequestCounts: openai.BatchRequestCounts{Total: 2, Completed: 1, Failed: 1},
+		})
+		jobs[0].Status = status
+		if err := env.dbClient.Update(context.Background(), jobs[0]); err != nil {
+			t.Fatalf("Failed to update batch: %v", err)
+		}
+
+		rr = env.do(t, http.MethodGet, "/v1beta/batches/"+batchID, "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		op = Operation{}
+		json.NewDecoder(rr.Body).Decode(&op)
+		if !op.Done || op.Metadata.State != BatchStateSucceeded || op.Metadata.BatchStats.FailedRequestCount != 1 || op.Response == nil {
+			t.Fatalf("unexpected operation: %+v %+v", op, op.Metadata)
+		}
+		responses := op.Response.InlinedResponses.InlinedResponses
+		if len(responses) != 2 {
+			t.Fatalf("responses = %d, want 2", len(responses))
+		}
+		if r := responses[0].Response; r == nil || r.Candidates[0].Content.Parts[0].Text != "Hi" ||
+			r.Candidates[0].FinishReason != "STOP" || r.UsageMetadata.TotalTokenCount != 2 || responses[0].Metadata[metadataKey] != "greeting" {
+			t.Errorf("unexpected first response: %+v", responses[0])
+		}
+		if e := responses[1].Error; e == nil || e.Code != rpcResourceExhausted || e.Message != "slow down" {
+			t.Errorf("unexpected second response: %+v", responses[1])
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		env := setupGeminiApiHandlerForTest(t)
+		rr := env.do(t, http.MethodPost, "/v1beta/models/m1:batchGenerateContent", testBatchRequest)
+		var op Operation
+		json.NewDecoder(rr.Body).Decode(&op)
+
+		rr = env.do(t, http.MethodPost, "/v1beta/"+op.Name+":cancel", "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		rr = env.do(t, http.MethodGet, "/v1beta/"+op.Name, "")
+		op = Operation{}
+		json.NewDecoder(rr.Body).Decode(&op)
+		if op.Metadata == nil || (op.Metadata.State != BatchStateRunning && op.Metadata.State != BatchStateCancelled) {
+			t.Errorf("unexpected operation after cancel: %+v", op.Metadata)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		env := setupGeminiApiHandlerForTest(t)
+		tests := []struct {
+			name           string
+			method         string
+			path           string
+			body           string
+			expectedStatus int
+		}{
+			{"unknown method", http.MethodPost, "/v1beta/models/m1:generateContent", testBatchRequest, http.StatusNotFound},
+			{"invalid body", http.MethodPost, "/v1beta/models/m1:batchGenerateContent", "{", http.StatusBadRequest},
+			{"no requests", http.MethodPost, "/v1beta/models/m1:batchGenerateContent", `{"batch":{}}`, http.StatusBadRequest},
+			{"input file", http.MethodPost, "/v1beta/models/m1:batchGenerateContent",
+				`{"batch":{"inputConfig":{"fileName":"files/abc"}}}`, http.StatusBadRequest},
+			{"unsupported tools", http.MethodPost, "/v1beta/models/m1:batchGenerateContent",
+				`{"batch":{"inputConfig":{"requests":{"requests":[{"request":{"contents":[{"parts":[{"text":"x"}]}],"tools":[{}]}}]}}}}`,
+				http.StatusBadRequest},
+			{"duplicate keys", http.MethodPost, "/v1beta/models/m1:batchGenerateContent",
+				`{"batch":{"inputConfig":{"requests":{"requests":[
+					{"request":{"contents":[{"parts":[{"text":"x"}]}]},"metadata":{"key":"k"}},
+					{"request":{"contents":[{"parts":[{"text":"y"}]}]},"metadata":{"key":"k"}}]}}}}`,
+				http.StatusBadRequest},
+			{"unknown batch", http.MethodGet, "/v1beta/batches/batch_unknown", "", http.StatusNotFound},
+			{"unknown batch method", http.MethodPost, "/v1beta/batches/batch_unknown:delete", "", http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := env.do(t, tt.method, tt.path, tt.body)
+				if rr.Code != tt.expectedStatus {
+					t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+				}
+				var resp ErrorResponse
+				if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || resp.Error.Code != tt.expectedStatus || resp.Error.Status == "" {
+					t.Errorf("unexpected error response: %+v (%v)", resp, err)
+				}
+			})
+		}
+	})
+
+	t.Run("OpenAIBatchNotFound", func(t *testing.T) {
+		env := setupGeminiApiHandlerForTest(t)
+		spec, _ := json.Marshal(openai.BatchSpec{Object: "batch", Endpoint: openai.EndpointChatCompletions})
+		status, _ := json.Marshal(openai.BatchStatusInfo{Status: openai.BatchStatusInProgress})
+		env.dbClient.Store(context.Background(), &api.BatchJob{
+			ID: "batch_openai", TTL: 3600, Spec: spec, Status: status,
+			Tags: []string{sharedbatch.TenantTag(common.DefaultTenantID)},
+		})
+		if rr := env.do(t, http.MethodGet, "/v1beta/batches/batch_openai", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d: %s", rr.Code, rr.Body.String())
+		}
+	})
+}
+
+func TestDelegateKeepsTenant(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/v1beta/models/m1:batchGenerateContent?dry_run=true", bytes.NewReader(nil))
+	req.Header.Set(common.TenantIDHeader, "tenant-a")
+	var tenant, query string
+	rec, err := delegate(func(w http.ResponseWriter, r *http.Request) {
+		tenant, query = common.GetTenantID(r), r.URL.RawQuery
+		w.WriteHeader(http.StatusAccepted)
+	}, req, nil)
+	if err != nil || rec.status != http.StatusAccepted {
+		t.Fatalf("unexpected delegation: %+v (%v)", rec, err)
+	}
+	if tenant != "tenant-a" || query != "" {
+		t.Errorf("delegated request has tenant %q and query %q", tenant, query)
+	}
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file defines the subset of the Google Gemini Batch API objects translated by the compatibility layer.
+package gemini
+
+import "encoding/json"
+
+// https://ai.google.dev/api/batch-mode
+
+// Content - The content of a turn of the conversation.
+type Content struct {
+	// optional. The producer of the content, either `user` or `model`.
+	Role string `json:"role,omitempty"`
+
+	// required. The parts of the content.
+	Parts []Part `json:"parts"`
+}
+
+// Part - A part of a content. Only text and image parts are translated.
+type Part struct {
+	// optional. Inline text.
+	Text string `json:"text,omitempty"`
+
+	// optional. Inline media bytes.
+	InlineData *Blob `json:"inlineData,omitempty"`
+
+	// optional. Media referenced by its URI.
+	FileData *FileData `json:"fileData,omitempty"`
+
+	// optional. Parts of function calling and code execution, which are not supported.
+	FunctionCall     json.RawMessage `json:"functionCall,omitempty"`
+	FunctionResponse json.RawMessage `json:"functionResponse,omitempty"`
+	ExecutableCode   json.RawMessage `json:"executableCode,omitempty"`
+}
+
+// Blob - Inline media bytes.
+type Blob struct {
+	// required. The IANA media type of the data, e.g. `image/png`.
+	MimeType string `json:"mimeType"`
+
+	// required. The base64 encoded bytes of the media.
+	Data string `json:"data"`
+}
+
+// FileData - Media referenced by its URI.
+type FileData struct {
+	// optional. The IANA media type of the data.
+	MimeType string `json:"mimeType,omitempty"`
+
+	// required. The URI of the media.
+	FileURI string `json:"fileUri"`
+}
+
+// GenerationConfig - The configuration options of the generation.
+type GenerationConfig struct {
+	StopSequences    []string `json:"stopSequences,omitempty"`
+	ResponseMimeType string   `json:"responseMimeType,omitempty"`
+	CandidateCount   *int     `json:"candidateCount,omitempty"`
+	MaxOutputTokens  *int     `json:"maxOutputTokens,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"topP,omitempty"`
+	TopK             *int     `json:"topK,omitempty"`
+	Seed             *int64   `json:"seed,omitempty"`
+	PresencePenalty  *float64 `json:"presencePenalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequencyPenalty,omitempty"`
+}
+
+// GenerateContentRequest - The body of a `generateContent` request.
+type GenerateContentRequest struct {
+	// optional. The model, `models/{model}`. It must be the model of the batch when set.
+	Model string `json:"model,omitempty"`
+
+	// required. The conversation.
+	Contents []Content `json:"contents"`
+
+	// optional. The system instruction.
+	SystemInstruction *Content `json:"systemInstruction,omitempty"`
+
+	// optional. The configuration of the generation.
+	GenerationConfig *GenerationConfig `json:"generationConfig,omitempty"`
+
+	// optional. Safety settings have no equivalent in the OpenAI API and are ignored.
+	SafetySettings json.RawMessage `json:"safetySettings,omitempty"`
+
+	// optional. Tools and cached contents, which are not supported.
+	Tools         json.RawMessage `json:"tools,omitempty"`
+	ToolConfig    json.RawMessage `json:"toolConfig,omitempty"`
+	CachedContent string          `json:"cachedContent,omitempty"`
+}
+
+// Candidate - A response candidate generated by the model.
+type Candidate struct {
+	Content      Content `json:"content"`
+	FinishReason string  `json:"finishReason,omitempty"`
+	Index        int     `json:"index"`
+}
+
+// UsageMetadata - The token usage of a `generateContent` request.
+type UsageMetadata struct {
+	PromptTokenCount     int64 `json:"promptTokenCount"`
+	CandidatesTokenCount int64 `json:"candidatesTokenCount"`
+	TotalTokenCount      int64 `json:"totalTokenCount"`
+}
+
+// GenerateContentResponse - The response of a `generateContent` request.
+type GenerateContentResponse struct {
+	Candidates    []Candidate    `json:"candidates"`
+	UsageMetadata *UsageMetadata `json:"usageMetadata,omitempty"`
+	ModelVersion  string         `json:"modelVersion,omitempty"`
+}
+
+// Status - An error, following google.rpc.Status.
+type Status struct {
+	// required. The gRPC status code, or the HTTP status code in the error responses of the API.
+	Code int `json:"code"`
+
+	// required. The error message.
+	Message string `json:"message"`
+
+	// optional. The name of the gRPC status code, only set in the error responses of the API.
+	Status string `json:"status,omitempty"`
+}
+
+// ErrorResponse - The body of the error responses of the API.
+type ErrorResponse struct {
+	Error Status `json:"error"`
+}
+
+// InlinedRequest - A request of a batch inlined in the batch creation request.
+type InlinedRequest struct {
+	// required. The request.
+	Request GenerateContentRequest `json:"request"`
+
+	// optional. The metadata of the request. Its `key` identifies the request in the responses.
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// InlinedRequests - The requests of a batch inlined in the batch creation request.
+type InlinedRequests struct {
+	Requests []InlinedRequest `json:"requests"`
+}
+
+// InputConfig - The input of a batch. Only inlined requests are supported.
+type InputConfig struct {
+	// optional. The inlined requests.
+	Requests *InlinedRequests `json:"requests,omitempty"`
+
+	// optional. The name of an uploaded input file, which is not supported.
+	FileName string `json:"fileName,omitempty"`
+}
+
+// GenerateContentBatch - A batch of `generateContent` requests.
+type GenerateContentBatch struct {
+	Type        string       `json:"@type,omitempty"`
+	Name        string       `json:"name,omitempty"`
+	Model       string       `json:"model,omitempty"`
+	DisplayName string       `json:"displayName,omitempty"`
+	InputConfig *InputConfig `json:"inputConfig,omitempty"`
+	CreateTime  string       `json:"createTime,omitempty"`
+	EndTime     string       `json:"endTime,omitempty"`
+	UpdateTime  string       `json:"updateTime,omitempty"`
+	BatchStats  *BatchStats  `json:"batchStats,omitempty"`
+	State       string       `json:"state,omitempty"`
+}
+
+// BatchGenerateContentRequest - The body of a `batchGenerateContent` request.
+type BatchGenerateContentRequest struct {
+	Batch GenerateContentBatch `json:"batch"`
+}
+
+// BatchStats - The request counts of a batch. The counts are strings, following the JSON mapping of int64.
+type BatchStats struct {
+	RequestCount           int64 `json:"requestCount,string"`
+	SuccessfulRequestCount int64 `json:"successfulRequestCount,string"`
+	FailedRequestCount     int64 `json:"failedRequestCount,string"`
+	PendingRequestCount    int64 `json:"pendingRequestCount,string"`
+}
+
+// InlinedResponse - The response of an inlined request, or its error.
+type InlinedResponse struct {
+	Response *GenerateContentResponse `json:"response,omitempty"`
+	Error    *Status                  `json:"error,omitempty"`
+	Metadata map[string]any           `json:"metadata,omitempty"`
+}
+
+// InlinedResponses - The responses of the inlined requests, in the order of the requests.
+type InlinedResponses struct {
+	InlinedResponses []InlinedResponse `json:"inlinedResponses"`
+}
+
+// GenerateContentBatchOutput - The output of a batch.
+type GenerateContentBatchOutput struct {
+	Type             string            `json:"@type"`
+	InlinedResponses *InlinedResponses `json:"inlinedResponses,omitempty"`
+}
+
+// Operation - The long-running operation of a batch.
+type Operation struct {
+	Name     string                      `json:"name"`
+	Metadata *GenerateContentBatch       `json:"metadata"`
+	Done     bool                        `json:"done,omitempty"`
+	Response *GenerateContentBatchOutput `json:"response,omitempty"`
+	Error    *Status                     `json:"error,omitempty"`
+}
+
+// Type URLs of the batch objects
+const (
+	TypeGenerateContentBatch       = "type.googleapis.com/google.ai.generativelanguage.v1beta.GenerateContentBatch"
+	TypeGenerateContentBatchOutput = "type.googleapis.com/google.ai.generativelanguage.v1beta.GenerateContentBatchOutput"
+)
+
+// States of a batch
+const (
+	BatchStatePending   = "BATCH_STATE_PENDING"
+	BatchStateRunning   = "BATCH_STATE_RUNNING"
+	BatchStateSucceeded = "BATCH_STATE_SUCCEEDED"
+	BatchStateFailed    = "BATCH_STATE_FAILED"
+	BatchStateCancelled = "BATCH_STATE_CANCELLED"
+	BatchStateExpired   = "BATCH_STATE_EXPIRED"
+)
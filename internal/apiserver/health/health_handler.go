@@ -15,24 +15,54 @@ limitations under the License.
 */
 
 // The file provides HTTP handlers for health check endpoints.
-// It implements simple health check endpoints for monitoring server status.
+// It implements a liveness endpoint, a readiness endpoint that optionally checks the server dependencies,
+// and the version endpoint.
 package health
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/store"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/version"
 )
 
 const (
 	HealthPath = "/health"
+	ReadyPath  = "/ready"
+
+	queryParamVerbose = "verbose"
+
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
 )
 
+// DependencyStatus is the result of checking a single dependency.
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ReadinessResponse is the verbose response of the readiness endpoint.
+type ReadinessResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies,omitempty"`
+}
+
 type HealthApiHandler struct {
+	// the clients checked by the readiness endpoint
+	dependencies *store.Clientset
 }
 
-func NewHealthApiHandler() *HealthApiHandler {
-	return &HealthApiHandler{}
+// NewHealthApiHandler creates the health handler. When dependencies is nil or empty, the readiness endpoint
+// only reports that the server is up.
+func NewHealthApiHandler(dependencies *store.Clientset) *HealthApiHandler {
+	return &HealthApiHandler{
+		dependencies: dependencies,
+	}
 }
 
 func (c *HealthApiHandler) GetRoutes() []common.Route {
@@ -47,6 +77,26 @@ func (c *HealthApiHandler) GetRoutes() []common.Route {
 			Pattern:     HealthPath,
 			HandlerFunc: c.HealthHandler,
 		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     ReadyPath,
+			HandlerFunc: c.ReadyHandler,
+		},
+		{
+			Method:      http.MethodHead,
+			Pattern:     ReadyPath,
+			HandlerFunc: c.ReadyHandler,
+		},
+		{
+			Method:      http.MethodGet,
+			Pattern:     version.Path,
+			HandlerFunc: version.Handler,
+		},
+		{
+			Method:      http.MethodHead,
+			Pattern:     version.Path,
+			HandlerFunc: version.Handler,
+		},
 	}
 }
 
@@ -55,3 +105,60 @@ func (c *HealthApiHandler) HealthHandler(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
 }
+
+// ReadyHandler runs the dependency checks and returns 503 if any of them fails.
+// With ?verbose it returns the status of each dependency as JSON.
+func (c *HealthApiHandler) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := logging.GetRequestLogger(r)
+
+	resp := ReadinessResponse{
+		Status:       StatusOK,
+		Dependencies: c.checkDependencies(ctx),
+	}
+	for name, dep := range resp.Dependencies {
+		if dep.Status != StatusOK {
+			resp.Status = StatusUnavailable
+			logger.Info("readiness check failed", "dependency", name, "error", dep.Error)
+		}
+	}
+
+	code := http.StatusOK
+	if resp.Status != StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+
+	if _, verbose := r.URL.Query()[queryParamVerbose]; verbose {
+		common.WriteJSONResponse(ctx, w, code, resp)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(code)
+	if code == http.StatusOK {
+		w.Write([]byte("OK"))
+	} else {
+		w.Write([]byte("NOT READY"))
+	}
+}
+
+// checkDependencies runs the health check of the clients.
+func (c *HealthApiHandler) checkDependencies(ctx context.Context) map[string]DependencyStatus {
+	checks := c.dependencies.HealthCheck(ctx)
+	if len(checks) == 0 {
+		return nil
+	}
+
+	results := make(map[string]DependencyStatus, len(checks))
+	for name, check := range checks {
+		status := DependencyStatus{
+			Status:    StatusOK,
+			LatencyMs: check.Latency.Milliseconds(),
+		}
+		if !check.Healthy() {
+			status.Status = StatusUnavailable
+			status.Error = check.Err.Error()
+		}
+		results[name] = status
+	}
+	return results
+}
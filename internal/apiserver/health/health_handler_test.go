@@ -18,16 +18,38 @@ limitations under the License.
 package health
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	mockapi "github.com/llm-d-incubation/batch-gateway/internal/database/mock"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/store"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/version"
 )
 
+// unreachableClient is a dependency whose backend is down.
+type unreachableClient struct{}
+
+func (c *unreachableClient) GetContext(parentCtx context.Context, timeLimit time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parentCtx, timeLimit)
+}
+
+func (c *unreachableClient) Ping(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func (c *unreachableClient) Close() error {
+	return nil
+}
+
 func TestHealthHandler(t *testing.T) {
 	mux := http.NewServeMux()
-	handler := NewHealthApiHandler()
+	handler := NewHealthApiHandler(nil)
 	common.RegisterHandler(mux, handler)
 
 	tests := []struct {
@@ -94,8 +116,93 @@ func TestHealthHandler(t *testing.T) {
 	}
 }
 
+func TestReadyHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		dependencies   map[string]store.BatchClientAdmin
+		expectedStatus int
+	}{
+		{
+			name:           "no dependency checks",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "all dependencies reachable",
+			dependencies: map[string]store.BatchClientAdmin{
+				"database": mockapi.NewMockBatchDBClient(),
+				"queue":    mockapi.NewMockBatchPriorityQueueClient(),
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "dependency unreachable",
+			dependencies: map[string]store.BatchClientAdmin{
+				"database": mockapi.NewMockBatchDBClient(),
+				"queue":    &unreachableClient{},
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var clientset *store.Clientset
+			if tt.dependencies != nil {
+				clientset = store.NewClientset()
+				for name, dep := range tt.dependencies {
+					clientset.Add(name, dep)
+				}
+			}
+			mux := http.NewServeMux()
+			common.RegisterHandler(mux, NewHealthApiHandler(clientset))
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadyPath, nil))
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			w = httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ReadyPath+"?verbose", nil))
+			if w.Code != tt.expectedStatus {
+				t.Errorf("verbose: expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			var resp ReadinessResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if len(resp.Dependencies) != len(tt.dependencies) {
+				t.Errorf("expected %d dependencies, got %d", len(tt.dependencies), len(resp.Dependencies))
+			}
+			if queue, ok := resp.Dependencies["queue"]; ok && tt.expectedStatus != http.StatusOK {
+				if queue.Status != StatusUnavailable || queue.Error == "" {
+					t.Errorf("unexpected queue status: %+v", queue)
+				}
+			}
+		})
+	}
+}
+
+func TestVersionRoute(t *testing.T) {
+	mux := http.NewServeMux()
+	common.RegisterHandler(mux, NewHealthApiHandler(nil))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, version.Path, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var info version.Info
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	if info != version.Get() {
+		t.Errorf("expected %+v, got %+v", version.Get(), info)
+	}
+}
+
 func BenchmarkHealthHandler(b *testing.B) {
-	handler := NewHealthApiHandler()
+	handler := NewHealthApiHandler(nil)
 	req := httptest.NewRequest(http.MethodGet, HealthPath, nil)
 
 	b.ResetTimer()
@@ -18,9 +18,17 @@ limitations under the License.
 package metrics
 
 import (
+	"sync/atomic"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/labels"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/version"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// ExemplarTraceIDLabel is the label of the exemplars holding the ID of the trace they link to.
+const ExemplarTraceIDLabel = "trace_id"
+
 var (
 	httpRequestsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -43,20 +51,107 @@ var (
 			Help: "Current number of HTTP requests being processed by the api server",
 		},
 	)
+	httpRequestsShedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "http_requests_shed_total",
+			Help: "Total number of HTTP requests rejected by the api server because of the in-flight requests limit",
+		},
+	)
+	batchesCreatedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "batches_created_total",
+			Help: "Total number of batches created, by tenant and endpoint",
+		},
+		[]string{"tenant", "endpoint"},
+	)
+	tenantUploadedBytesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tenant_uploaded_bytes_total",
+			Help: "Total number of bytes of the files uploaded, by tenant",
+		},
+		[]string{"tenant"},
+	)
+	batchValidationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "batch_validation_duration_seconds",
+			Help:    "Time spent validating the input files of the batches, by size bucket and result (valid, invalid)",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+		},
+		[]string{"size_bucket", "result"},
+	)
+	jobPublishFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "job_publish_failures_total",
+			Help: "Total number of batch jobs the api server failed to write to the database and queue, by operation and failed stage (store, enqueue, compensate)",
+		},
+		[]string{"operation", "stage"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(httpRequestsTotal)
 	prometheus.MustRegister(httpRequestDuration)
 	prometheus.MustRegister(httpRequestsInFlight)
+	prometheus.MustRegister(httpRequestsShedTotal)
+	prometheus.MustRegister(batchesCreatedTotal)
+	prometheus.MustRegister(tenantUploadedBytesTotal)
+	prometheus.MustRegister(batchValidationDuration)
+	prometheus.MustRegister(jobPublishFailuresTotal)
+	prometheus.MustRegister(version.NewBuildInfoGauge())
+	tenantLabels.Store(labels.NewConfig().NewTenantLimiter())
+}
+
+// tenantLabels bounds the distinct tenants labeling the metrics, see SetLabelLimits.
+var tenantLabels atomic.Pointer[labels.Limiter]
+
+// SetLabelLimits configures the bounds of the distinct tenants labeling the metrics. It is called once
+// at startup, before requests are served.
+func SetLabelLimits(cfg labels.Config) {
+	tenantLabels.Store(cfg.NewTenantLimiter())
 }
 
 func RecordRequestStart() {
 	httpRequestsInFlight.Inc()
 }
 
-func RecordRequestFinish(method, path, status string, durationSeconds float64) {
+// RecordRequestFinish records a finished request. The duration is observed with an exemplar linking to the
+// trace of the request if traceID is set; exemplars are exposed in the OpenMetrics format only.
+func RecordRequestFinish(method, path, status string, durationSeconds float64, traceID string) {
 	httpRequestsInFlight.Dec()
 	httpRequestsTotal.WithLabelValues(method, path, status).Inc()
-	httpRequestDuration.WithLabelValues(method, path, status).Observe(durationSeconds)
+	observer := httpRequestDuration.WithLabelValues(method, path, status)
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && traceID != "" {
+		exemplarObserver.ObserveWithExemplar(durationSeconds, prometheus.Labels{ExemplarTraceIDLabel: traceID})
+		return
+	}
+	observer.Observe(durationSeconds)
+}
+
+func RecordRequestShed() {
+	httpRequestsShedTotal.Inc()
+}
+
+// RecordBatchCreated counts a batch created by a tenant.
+func RecordBatchCreated(tenantID, endpoint string) {
+	batchesCreatedTotal.WithLabelValues(tenantLabels.Load().Value(tenantID), endpoint).Inc()
+}
+
+// RecordFileStored adds the bytes of a file uploaded by a tenant.
+func RecordFileStored(tenantID string, bytes int64) {
+	tenantUploadedBytesTotal.WithLabelValues(tenantLabels.Load().Value(tenantID)).Add(float64(bytes))
+}
+
+// RecordBatchValidation observes the time spent validating the input file of a batch of lines lines.
+func RecordBatchValidation(duration time.Duration, lines int, valid bool) {
+	result := "valid"
+	if !valid {
+		result = "invalid"
+	}
+	batchValidationDuration.WithLabelValues(labels.SizeBucket(lines), result).Observe(duration.Seconds())
+}
+
+// RecordJobPublishFailure counts a batch job that failed to be written to the database and queue.
+// stage is the failed step, see api.PublishStage.
+func RecordJobPublishFailure(operation, stage string) {
+	jobPublishFailuresTotal.WithLabelValues(operation, stage).Inc()
 }
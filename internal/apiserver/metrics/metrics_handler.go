@@ -21,6 +21,7 @@ import (
 	"net/http"
 
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -29,10 +30,16 @@ const (
 )
 
 type MetricsApiHandler struct {
+	handler http.Handler
 }
 
+// NewMetricsApiHandler returns the handler of the metrics endpoint. The metrics are served in the OpenMetrics format,
+// which carries the exemplars of the histograms, to scrapers that accept it.
 func NewMetricsApiHandler() *MetricsApiHandler {
-	return &MetricsApiHandler{}
+	return &MetricsApiHandler{
+		handler: promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+			promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})),
+	}
 }
 
 func (c *MetricsApiHandler) GetRoutes() []common.Route {
@@ -46,5 +53,5 @@ func (c *MetricsApiHandler) GetRoutes() []common.Route {
 }
 
 func (c *MetricsApiHandler) MetricsHandler(w http.ResponseWriter, r *http.Request) {
-	promhttp.Handler().ServeHTTP(w, r)
+	c.handler.ServeHTTP(w, r)
 }
@@ -0,0 +1,95 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file implements access log middleware writing one JSON line per sampled request.
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/health"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/version"
+)
+
+type accessLogEntry struct {
+	Time       string  `json:"time"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Status     int     `json:"status"`
+	LatencyMs  float64 `json:"latency_ms"`
+	Bytes      int64   `json:"bytes"`
+	Tenant     string  `json:"tenant"`
+	RequestID  string  `json:"request_id"`
+	RemoteAddr string  `json:"remote_addr"`
+	UserAgent  string  `json:"user_agent,omitempty"`
+}
+
+// AccessLogMiddleware writes an access log entry to out for a sampled fraction of the requests.
+// Failed requests (status 4xx and 5xx) are always logged. Health, readiness, metrics and version requests,
+// and requests to excludePaths, are never logged.
+// It must be wrapped by RequestMiddleware, so the request ID is available.
+func AccessLogMiddleware(sampleRate float64, excludePaths []string, out io.Writer) func(http.Handler) http.Handler {
+	excluded := map[string]bool{
+		health.HealthPath:   true,
+		health.ReadyPath:    true,
+		metrics.MetricsPath: true,
+		version.Path:        true,
+	}
+	for _, p := range excludePaths {
+		excluded[p] = true
+	}
+	var mu sync.Mutex
+	enc := json.NewEncoder(out)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if excluded[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			if rw.statusCode < http.StatusBadRequest && rand.Float64() >= sampleRate {
+				return
+			}
+			entry := accessLogEntry{
+				Time:       start.UTC().Format(time.RFC3339Nano),
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Status:     rw.statusCode,
+				LatencyMs:  float64(time.Since(start).Microseconds()) / 1000,
+				Bytes:      rw.bytes,
+				Tenant:     common.GetTenantID(r),
+				RequestID:  GetRequestIDFromContext(r.Context()),
+				RemoteAddr: r.RemoteAddr,
+				UserAgent:  r.UserAgent(),
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			enc.Encode(entry)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for the access log middleware.
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/health"
+)
+
+func TestAccessLogMiddleware(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte("hello"))
+	})
+
+	tests := []struct {
+		name       string
+		sampleRate float64
+		path       string
+		wantLogged bool
+	}{
+		{name: "logged", sampleRate: 1, path: "/v1/batches", wantLogged: true},
+		{name: "sampled out", sampleRate: 0, path: "/v1/batches", wantLogged: false},
+		{name: "failure always logged", sampleRate: 0, path: "/fail", wantLogged: true},
+		{name: "health excluded", sampleRate: 1, path: health.HealthPath, wantLogged: false},
+		{name: "configured exclusion", sampleRate: 1, path: "/excluded", wantLogged: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			h := RequestMiddleware(AccessLogMiddleware(tt.sampleRate, []string{"/excluded"}, out)(handler))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(requestIDHeader, "req-1")
+			req.Header.Set(common.TenantIDHeader, "tenant-a")
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if logged := out.Len() > 0; logged != tt.wantLogged {
+				t.Fatalf("logged = %v, want %v", logged, tt.wantLogged)
+			}
+			if !tt.wantLogged {
+				return
+			}
+			if lines := strings.Count(out.String(), "\n"); lines != 1 {
+				t.Errorf("expected 1 log line, got %d", lines)
+			}
+			var entry accessLogEntry
+			if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+				t.Fatalf("Failed to unmarshal access log entry: %v", err)
+			}
+			if entry.Method != http.MethodGet || entry.Path != tt.path || entry.Tenant != "tenant-a" ||
+				entry.RequestID != "req-1" || entry.Bytes != 5 {
+				t.Errorf("unexpected access log entry: %+v", entry)
+			}
+			if tt.path == "/fail" && entry.Status != http.StatusNotFound {
+				t.Errorf("Status = %v, want %v", entry.Status, http.StatusNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file implements audit middleware emitting an audit event for every mutating API call.
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/audit"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// maxAuditCaptureBytes bounds the part of a response body kept to find the ID of a created resource.
+const maxAuditCaptureBytes = 4096
+
+// resourcePathParams are the path parameters identifying the resource of an API call, in order of precedence.
+var resourcePathParams = []string{"batch_id", "file_id", "upload_id"}
+
+// AuditMiddleware emits an audit event to sink for every mutating (POST, PUT, PATCH, DELETE) request.
+// It must wrap the handler that routes the request (the ServeMux), directly or through middlewares passing the
+// request on unchanged, so the matched route and path parameters are available, and be wrapped by RequestMiddleware.
+func AuditMiddleware(sink audit.Sink) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rw := &auditResponseWriter{responseWriter: responseWriter{ResponseWriter: w, statusCode: http.StatusOK}}
+			next.ServeHTTP(rw, r)
+
+			event := &audit.Event{
+				Time:      start.UTC().Format(time.RFC3339Nano),
+				RequestID: GetRequestIDFromContext(r.Context()),
+				Actor: audit.Actor{
+					Tenant:     common.GetTenantID(r),
+					RemoteAddr: r.RemoteAddr,
+					UserAgent:  r.UserAgent(),
+				},
+				Action:     r.Pattern,
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				ResourceID: resourceID(r, rw),
+				Status:     rw.statusCode,
+				Result:     audit.ResultSuccess,
+			}
+			if rw.statusCode >= http.StatusBadRequest {
+				event.Result = audit.ResultFailure
+			}
+			if err := sink.Emit(r.Context(), event); err != nil {
+				logger := logging.GetRequestLogger(r)
+				logger.Error(err, "failed to emit audit event", "action", event.Action, "path", event.Path)
+			}
+		})
+	}
+}
+
+// resourceID returns the ID of the resource an API call acted on: the path parameter identifying it,
+// or the ID of the resource returned by a successful create call.
+func resourceID(r *http.Request, rw *auditResponseWriter) string {
+	for _, name := range resourcePathParams {
+		if id := r.PathValue(name); id != "" {
+			return id
+		}
+	}
+	if rw.statusCode >= http.StatusBadRequest {
+		return ""
+	}
+	return responseObjectID(rw.captured.Bytes())
+}
+
+// responseObjectID returns the top-level "id" field of a JSON object, which may be truncated after the field.
+func responseObjectID(body []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return ""
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return ""
+		}
+		if key == "id" {
+			var id string
+			json.Unmarshal(value, &id)
+			return id
+		}
+	}
+	return ""
+}
+
+// auditResponseWriter captures the status code and the beginning of the response body.
+type auditResponseWriter struct {
+	responseWriter
+	captured bytes.Buffer
+}
+
+func (rw *auditResponseWriter) Write(b []byte) (int, error) {
+	if room := maxAuditCaptureBytes - rw.captured.Len(); room > 0 {
+		rw.captured.Write(b[:min(room, len(b))])
+	}
+	return rw.responseWriter.Write(b)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for the audit middleware.
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/audit"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/batches", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"object":"batch","metadata":{"id":"nested"},"id":"batch_1","status":"validating"}`))
+	})
+	mux.HandleFunc("POST /v1/batches/{batch_id}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /v1/batches", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantAudit  bool
+		wantAction string
+		wantID     string
+		wantResult string
+	}{
+		{name: "create", method: http.MethodPost, path: "/v1/batches", wantAudit: true,
+			wantAction: "POST /v1/batches", wantID: "batch_1", wantResult: audit.ResultSuccess},
+		{name: "failed cancel", method: http.MethodPost, path: "/v1/batches/batch_2/cancel", wantAudit: true,
+			wantAction: "POST /v1/batches/{batch_id}/cancel", wantID: "batch_2", wantResult: audit.ResultFailure},
+		{name: "read not audited", method: http.MethodGet, path: "/v1/batches", wantAudit: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			h := RequestMiddleware(AuditMiddleware(audit.NewLogSink(out))(RecoveryMiddleware(mux)))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(requestIDHeader, "req-1")
+			req.Header.Set(common.TenantIDHeader, "tenant-a")
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if audited := out.Len() > 0; audited != tt.wantAudit {
+				t.Fatalf("audited = %v, want %v", audited, tt.wantAudit)
+			}
+			if !tt.wantAudit {
+				return
+			}
+			var event audit.Event
+			if err := json.Unmarshal(out.Bytes(), &event); err != nil {
+				t.Fatalf("Failed to unmarshal audit event: %v", err)
+			}
+			if event.Action != tt.wantAction || event.ResourceID != tt.wantID || event.Result != tt.wantResult {
+				t.Errorf("unexpected audit event: %+v", event)
+			}
+			if event.Actor.Tenant != "tenant-a" || event.RequestID != "req-1" || event.Path != tt.path {
+				t.Errorf("unexpected audit event: %+v", event)
+			}
+		})
+	}
+}
+
+func TestResponseObjectID(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "first field", body: `{"id":"file_1","object":"file"}`, want: "file_1"},
+		{name: "truncated after id", body: `{"object":"file","id":"file_1","filename":"inp`, want: "file_1"},
+		{name: "no id", body: `{"object":"file"}`, want: ""},
+		{name: "not an object", body: `["id"]`, want: ""},
+		{name: "empty", body: ``, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := responseObjectID([]byte(tt.body)); got != tt.want {
+				t.Errorf("responseObjectID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
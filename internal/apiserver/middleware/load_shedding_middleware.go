@@ -0,0 +1,59 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file implements load shedding middleware bounding the number of in-flight requests.
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/health"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/version"
+)
+
+// LoadSheddingMiddleware rejects requests with 503 and a Retry-After header while maxInFlight requests are
+// being processed. Health, readiness, metrics and version requests are never rejected nor counted, so probes keep
+// working under load.
+func LoadSheddingMiddleware(maxInFlight int, retryAfter time.Duration) func(http.Handler) http.Handler {
+	slots := make(chan struct{}, maxInFlight)
+	retryAfterSeconds := strconv.Itoa(max(1, int(retryAfter.Round(time.Second).Seconds())))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case health.HealthPath, health.ReadyPath, metrics.MetricsPath, version.Path:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+				next.ServeHTTP(w, r)
+			default:
+				metrics.RecordRequestShed()
+				w.Header().Set("Retry-After", retryAfterSeconds)
+				apiErr := openai.NewAPIError(http.StatusServiceUnavailable, "", "The server is overloaded, please retry later", nil)
+				common.WriteAPIError(r.Context(), w, apiErr)
+			}
+		})
+	}
+}
@@ -24,9 +24,12 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/health"
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/metrics"
 	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/slowop"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/tracing"
 	"k8s.io/klog/v2"
 )
 
@@ -55,9 +58,18 @@ func RequestMiddleware(next http.Handler) http.Handler {
 		w.Header().Set(requestIDHeader, requestID)
 
 		// Create request logger with request ID
-		logger := klog.FromContext(r.Context()).WithValues("requestID", requestID)
+		logger := klog.FromContext(r.Context()).WithValues("requestID", requestID, "tenant", common.GetTenantID(r))
+
+		// Attach the client's trace context, so that batches can be linked back to the submission trace
+		traceContext := tracing.FromHeader(r.Header)
+		if traceContext != nil {
+			logger = logger.WithValues("traceID", traceContext.TraceID())
+		}
+
 		ctx := klog.NewContext(r.Context(), logger)
 		ctx = context.WithValue(ctx, requestIDKey, requestID)
+		ctx = tracing.NewContext(ctx, traceContext)
+		ctx, _ = slowop.NewContext(ctx)
 
 		// Wrap response writer to capture status code
 		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
@@ -70,19 +82,21 @@ func RequestMiddleware(next http.Handler) http.Handler {
 		)
 
 		defer func() {
-			duration := time.Since(start).Seconds()
+			duration := time.Since(start)
 			status := strconv.Itoa(rw.statusCode)
-			metrics.RecordRequestFinish(r.Method, r.URL.Path, status, duration)
+			metrics.RecordRequestFinish(r.Method, r.URL.Path, status, duration.Seconds(), tracing.SampledTraceID(ctx))
+			slowop.Observe(ctx, slowop.Handler, r.Method+" "+r.URL.Path, duration, "status", rw.statusCode)
 		}()
 
 		next.ServeHTTP(rw, r.WithContext(ctx))
 	})
 }
 
-// responseWriter wraps http.ResponseWriter to capture status code
+// responseWriter wraps http.ResponseWriter to capture status code and response size
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	bytes      int64
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -90,6 +104,12 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
 // GetRequestID retrieves the request ID from the context.
 func GetRequestIDFromContext(ctx context.Context) string {
 	if requestID, ok := ctx.Value(requestIDKey).(string); ok {
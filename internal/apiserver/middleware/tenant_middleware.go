@@ -0,0 +1,111 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file implements the middleware identifying the tenant of the requests.
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/kube"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// geminiAPIKeyHeader and geminiAPIKeyParam carry the API key of the requests of the Gemini API clients.
+const (
+	geminiAPIKeyHeader = "x-goog-api-key"
+	geminiAPIKeyParam  = "key"
+)
+
+// TenantMiddleware identifies the tenant of the requests from their API key, or from the tenant header when it
+// is set by a client acting for the tenants or by a trusted gateway, and sets the tenant header to it, so the
+// handlers can scope the files and batches they access to the tenant of the request. A tenant header the caller
+// can't be trusted with is rejected.
+func TenantMiddleware(config *common.ServerConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get(common.TenantIDHeader)
+			key := apiKey(r)
+			tenantID, authenticated := tenantOfKey(config, key)
+			switch {
+			case authenticated:
+				if header != "" && header != tenantID {
+					writeTenantError(w, r, http.StatusForbidden, fmt.Sprintf("%s header doesn't match the tenant of the API key", common.TenantIDHeader))
+					return
+				}
+			case header == "":
+			case !config.TrustTenantHeader && !isDelegateKey(config, key):
+				writeTenantError(w, r, http.StatusForbidden, fmt.Sprintf("%s header is only accepted from a trusted gateway or a delegate API key", common.TenantIDHeader))
+				return
+			case !batch.ValidTenantID(header):
+				writeTenantError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid %s header", common.TenantIDHeader))
+				return
+			default:
+				tenantID = header
+			}
+			if tenantID != "" {
+				r.Header.Set(common.TenantIDHeader, tenantID)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// apiKey returns the API key of the request: its bearer token, or the key sent by the Gemini API clients.
+func apiKey(r *http.Request) string {
+	if token := kube.BearerToken(r); token != "" {
+		return token
+	}
+	if key := r.Header.Get(geminiAPIKeyHeader); key != "" {
+		return key
+	}
+	return r.URL.Query().Get(geminiAPIKeyParam)
+}
+
+// isDelegateKey reports whether the API key is one of the keys of the clients acting for the tenants, comparing it
+// with all the keys in constant time.
+func isDelegateKey(config *common.ServerConfig, token string) bool {
+	found := false
+	for _, key := range config.TenantDelegateKeys {
+		value := key.Key.Value()
+		if token != "" && value != "" && subtle.ConstantTimeCompare([]byte(token), []byte(value)) == 1 {
+			found = true
+		}
+	}
+	return found
+}
+
+// tenantOfKey returns the tenant of the API key, comparing the token with all the keys in constant time.
+func tenantOfKey(config *common.ServerConfig, token string) (tenantID string, ok bool) {
+	if token == "" {
+		return "", false
+	}
+	for _, key := range config.TenantAPIKeys {
+		value := key.Key.Value()
+		if value != "" && subtle.ConstantTimeCompare([]byte(token), []byte(value)) == 1 && !ok {
+			tenantID, ok = key.Tenant, true
+		}
+	}
+	return tenantID, ok
+}
+
+func writeTenantError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	common.WriteAPIError(r.Context(), w, openai.NewAPIError(status, "", msg, nil))
+}
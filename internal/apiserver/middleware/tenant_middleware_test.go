@@ -0,0 +1,91 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for the tenant middleware.
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/settings"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	var tenant string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = common.GetTenantID(r)
+		w.WriteHeader(http.StatusOK)
+	})
+	keys := []common.TenantAPIKeyConfig{{Tenant: "team-a", Key: settings.NewSecret("key-a")}}
+	delegateKeys := []common.TenantDelegateKeyConfig{{Name: "controller", Key: settings.NewSecret("key-controller")}}
+
+	tests := []struct {
+		name       string
+		trusted    bool
+		tenantID   string
+		token      string
+		keyHeader  string
+		query      string
+		want       int
+		wantTenant string
+	}{
+		{name: "no header", want: http.StatusOK, wantTenant: common.DefaultTenantID},
+		{name: "untrusted header", tenantID: "team-b", want: http.StatusForbidden},
+		{name: "trusted header", trusted: true, tenantID: "team-a.prod_1", want: http.StatusOK, wantTenant: "team-a.prod_1"},
+		{name: "path separator", trusted: true, tenantID: "../team-a", want: http.StatusBadRequest},
+		{name: "leading dot", trusted: true, tenantID: ".team", want: http.StatusBadRequest},
+		{name: "too long", trusted: true, tenantID: strings.Repeat("a", 64), want: http.StatusBadRequest},
+		{name: "api key", token: "key-a", want: http.StatusOK, wantTenant: "team-a"},
+		{name: "api key with its tenant", token: "key-a", tenantID: "team-a", want: http.StatusOK, wantTenant: "team-a"},
+		{name: "api key with another tenant", trusted: true, token: "key-a", tenantID: "team-b", want: http.StatusForbidden},
+		{name: "unknown key", token: "key-b", want: http.StatusOK, wantTenant: common.DefaultTenantID},
+		{name: "unknown key with header", token: "key-b", tenantID: "team-a", want: http.StatusForbidden},
+		{name: "delegate key", token: "key-controller", tenantID: "team-b", want: http.StatusOK, wantTenant: "team-b"},
+		{name: "delegate key without header", token: "key-controller", want: http.StatusOK, wantTenant: common.DefaultTenantID},
+		{name: "delegate key with invalid header", token: "key-controller", tenantID: "../team-b", want: http.StatusBadRequest},
+		{name: "gemini key header", token: "key-a", keyHeader: "x-goog-api-key", want: http.StatusOK, wantTenant: "team-a"},
+		{name: "gemini key parameter", query: "?key=key-a", want: http.StatusOK, wantTenant: "team-a"},
+		{name: "gemini delegate key", token: "key-controller", keyHeader: "x-goog-api-key", tenantID: "team-b", want: http.StatusOK, wantTenant: "team-b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := TenantMiddleware(&common.ServerConfig{TenantAPIKeys: keys, TenantDelegateKeys: delegateKeys, TrustTenantHeader: tt.trusted})(next)
+			req := httptest.NewRequest(http.MethodGet, "/v1/batches"+tt.query, nil)
+			if tt.tenantID != "" {
+				req.Header.Set(common.TenantIDHeader, tt.tenantID)
+			}
+			switch {
+			case tt.keyHeader != "":
+				req.Header.Set(tt.keyHeader, tt.token)
+			case tt.token != "":
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			tenant = ""
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("expected status %d, got %d", tt.want, w.Code)
+			}
+			if tenant != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", tenant, tt.wantTenant)
+			}
+		})
+	}
+}
@@ -19,33 +19,75 @@ package server
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
-	"time"
+	"os"
 
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/admin"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/audit"
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/batch"
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/files"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/gemini"
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/health"
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/metrics"
 	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/middleware"
+	dbapi "github.com/llm-d-incubation/batch-gateway/internal/database/api"
 	mockapi "github.com/llm-d-incubation/batch-gateway/internal/database/mock"
+	filesapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
+	fsapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/fs"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/kube"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/store"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/redact"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/slowop"
+	utls "github.com/llm-d-incubation/batch-gateway/internal/util/tls"
 	"k8s.io/klog/v2"
 )
 
 type Server struct {
-	logger klog.Logger
-	config *common.ServerConfig
+	logger    klog.Logger
+	config    *common.ServerConfig
+	clients   *Clients
+	auditSink audit.Sink
+}
+
+// Clients are the storage backends used by the server.
+type Clients struct {
+	DB         dbapi.BatchDBClient
+	FileDB     dbapi.BatchFileDBClient
+	Queue      dbapi.BatchPriorityQueueClient
+	DeadLetter dbapi.BatchDeadLetterClient
+	Event      dbapi.BatchEventChannelClient
+	Status     dbapi.BatchStatusClient
+	Files      filesapi.BatchFilesClient
+}
+
+// Clientset returns the set of the clients, checked by the readiness endpoint.
+func (c *Clients) Clientset() *store.Clientset {
+	clientset := store.NewClientset()
+	clientset.Add("database", c.DB)
+	clientset.Add("file_database", c.FileDB)
+	clientset.Add("queue", c.Queue)
+	clientset.Add("dead_letter", c.DeadLetter)
+	clientset.Add("events", c.Event)
+	clientset.Add("status", c.Status)
+	clientset.Add("files_store", c.Files)
+	return clientset
 }
 
 func New(config *common.ServerConfig) (*Server, error) {
+	return NewWithClients(config, nil)
+}
+
+// NewWithClients creates a server using the given clients, e.g. to share them with a processor running in the
+// same process. The default clients are used when clients is nil.
+func NewWithClients(config *common.ServerConfig, clients *Clients) (*Server, error) {
 	if config == nil {
 		return nil, fmt.Errorf("config cannot be nil")
 	}
 	logger := klog.Background().WithName("api_server")
-	return &Server{config: config, logger: logger}, nil
+	return &Server{config: config, clients: clients, logger: logger}, nil
 }
 
 // Start the HTTP server.
@@ -58,7 +100,11 @@ func (s *Server) Start(ctx context.Context) error {
 		return err
 	}
 
-	handler := s.buildHandler()
+	handler, err := s.buildHandler()
+	if err != nil {
+		logger.Error(err, "failed to build handler")
+		return err
+	}
 
 	httpserver := &http.Server{
 		Handler: handler,
@@ -66,23 +112,12 @@ func (s *Server) Start(ctx context.Context) error {
 
 	// Enable TLS if cert and key are provided
 	if s.config.SSLEnabled() {
-		cert, err := tls.LoadX509KeyPair(s.config.SSLCertFile, s.config.SSLKeyFile)
+		tlsConfig, err := utls.GetServerTlsConfig(s.config.SSLCertFile, s.config.SSLKeyFile, s.config.SSLClientCAFile)
 		if err != nil {
 			return err
 		}
-		httpserver.TLSConfig = &tls.Config{
-			Certificates: []tls.Certificate{cert},
-			MinVersion:   tls.VersionTLS12,
-			CipherSuites: []uint16{
-				tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
-				tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
-				tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
-			},
-		}
-		s.logger.Info("server TLS configured")
+		httpserver.TLSConfig = tlsConfig
+		s.logger.Info("server TLS configured", "client_auth", s.config.SSLClientCAFile != "")
 	} else if s.config.SSLCertFile != "" || s.config.SSLKeyFile != "" {
 		err := fmt.Errorf("both tls-cert-file and tls-private-key-file must be provided to enable TLS")
 		return err
@@ -93,13 +128,18 @@ func (s *Server) Start(ctx context.Context) error {
 		<-ctx.Done()
 		logger.Info("shutting down")
 
-		shutdownCtx, cancelFn := context.WithTimeout(context.Background(), 60*time.Second)
+		shutdownCtx, cancelFn := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
 		defer cancelFn()
 		if err := httpserver.Shutdown(shutdownCtx); err != nil {
 			logger.Error(err, "failed to gracefully shutdown")
 		} else {
 			logger.Info("shutdown complete")
 		}
+		if s.auditSink != nil {
+			if err := s.auditSink.Close(); err != nil {
+				logger.Error(err, "failed to close audit sink")
+			}
+		}
 	}()
 
 	logger.Info("starting", "addr", ln.Addr().String())
@@ -118,20 +158,42 @@ func (s *Server) Start(ctx context.Context) error {
 	return nil
 }
 
-func (s *Server) buildHandler() http.Handler {
+func (s *Server) buildHandler() (http.Handler, error) {
 	mux := http.NewServeMux()
 
-	// TODO: change to actual implementation
-	dbClient := mockapi.NewMockBatchDBClient()
-	eventClient := mockapi.NewMockBatchEventChannelClient()
-	queueClient := mockapi.NewMockBatchPriorityQueueClient()
-	statusClient := mockapi.NewMockBatchStatusClient()
+	clients := s.clients
+	if clients == nil {
+		var err error
+		if clients, err = s.defaultClients(); err != nil {
+			return nil, err
+		}
+	}
+	dbClient := dbapi.NewTimedDBClient(clients.DB)
+	fileDBClient := dbapi.NewTimedFileDBClient(clients.FileDB)
+	eventClient := clients.Event
+	queueClient := clients.Queue
+	deadLetterClient := clients.DeadLetter
+	payloadCipher, err := s.config.PayloadEncryption.Cipher()
+	if err != nil {
+		return nil, err
+	}
+	statusClient := dbapi.NewEncryptedStatusClient(clients.Status, payloadCipher)
+	filesClient := clients.Files
 
 	// register handlers
-	healthHandler := health.NewHealthApiHandler()
+	var dependencies *store.Clientset
+	if s.config.ReadinessChecksEnabled {
+		dependencies = clients.Clientset()
+	}
+	healthHandler := health.NewHealthApiHandler(dependencies)
+	metrics.SetLabelLimits(s.config.Observability.MetricLabels)
+	slowop.SetThresholds(s.config.Observability.SlowOps)
+	if err := redact.Configure(s.config.Observability.Redaction); err != nil {
+		return nil, fmt.Errorf("observability.redaction: %w", err)
+	}
 	metricsHandler := metrics.NewMetricsApiHandler()
-	filesHandler := files.NewFilesApiHandler()
-	batchHandler := batch.NewBatchApiHandler(s.config, dbClient, queueClient, eventClient, statusClient)
+	filesHandler := files.NewFilesApiHandler(s.config, fileDBClient, filesClient)
+	batchHandler := batch.NewBatchApiHandler(s.config, dbClient, fileDBClient, queueClient, eventClient, statusClient, filesClient)
 
 	handlers := []common.ApiHandler{
 		healthHandler,
@@ -139,6 +201,22 @@ func (s *Server) buildHandler() http.Handler {
 		filesHandler,
 		batchHandler,
 	}
+	if s.config.GeminiAPIEnabled {
+		handlers = append(handlers, gemini.NewGeminiApiHandler(s.config, fileDBClient, filesClient, batchHandler))
+		s.logger.Info("gemini batch api enabled")
+	}
+	if s.config.AdminEnabled() {
+		adminHandler := admin.NewAdminApiHandler(s.config, dbClient, fileDBClient, queueClient, deadLetterClient, eventClient, statusClient)
+		if len(s.config.AdminServiceAccounts) > 0 {
+			reviewer, err := kube.NewInClusterTokenReviewer()
+			if err != nil {
+				return nil, fmt.Errorf("admin_service_accounts: %w", err)
+			}
+			adminHandler.SetTokenReviewer(reviewer)
+		}
+		handlers = append(handlers, adminHandler)
+		s.logger.Info("admin api enabled", "prefix", admin.AdminPathPrefix)
+	}
 	for _, c := range handlers {
 		common.RegisterHandler(mux, c)
 	}
@@ -146,12 +224,47 @@ func (s *Server) buildHandler() http.Handler {
 	// register middlewares
 	var h http.Handler
 	h = middleware.RecoveryMiddleware(mux) // Innermost, catches panics from business logic
+	switch s.config.AuditSink {
+	case audit.SinkLog:
+		s.auditSink = audit.NewLogSink(os.Stdout)
+	case audit.SinkFiles:
+		s.auditSink = audit.NewFilesSink(filesClient, s.config.AuditFlushInterval)
+	}
+	if s.auditSink != nil {
+		h = middleware.AuditMiddleware(s.auditSink)(h) // Audit mutating calls, needs the route matched by the mux
+		s.logger.Info("audit enabled", "sink", s.config.AuditSink)
+	}
+	if s.config.MaxInFlightRequests > 0 {
+		h = middleware.LoadSheddingMiddleware(s.config.MaxInFlightRequests, s.config.LoadShedRetryAfter)(h) // Reject requests above the in-flight limit
+	}
+	if s.config.AccessLogEnabled {
+		h = middleware.AccessLogMiddleware(s.config.AccessLogSampleRate, s.config.AccessLogExcludePaths, os.Stdout)(h) // Access log, uses the request ID
+	}
 	//h = middleware.BodySizeLimitMiddleware(h) //  Limit request body size
 	//h = middleware.AuthorizationMiddleware(h) //  Check permissions
 	//h = middleware.AuthenticationMiddleware(h) // Verify API key/JWT
-	h = middleware.RequestMiddleware(h) // Request ID, logging, metrics
+	h = middleware.RequestMiddleware(h)          // Request ID, logging, metrics
+	h = middleware.TenantMiddleware(s.config)(h) // Identify the tenant the handlers scope files and batches to, before it's logged
 	//h = middleware.RateLimitMiddleware(h)      // Early Rejection
 	h = middleware.SecurityHeadersMiddleware(h) // Outermost, affects all responses
 
-	return h
+	return h, nil
+}
+
+func (s *Server) defaultClients() (*Clients, error) {
+	filesClient, err := fsapi.NewFSFilesClient(s.config.FileStore.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create files client: %w", err)
+	}
+
+	// TODO: change to actual implementation
+	return &Clients{
+		DB:         mockapi.NewMockBatchDBClient(),
+		FileDB:     mockapi.NewMockBatchFileDBClient(),
+		Queue:      mockapi.NewMockBatchPriorityQueueClient(),
+		DeadLetter: mockapi.NewMockBatchDeadLetterClient(),
+		Event:      mockapi.NewMockBatchEventChannelClient(),
+		Status:     mockapi.NewMockBatchStatusClient(),
+		Files:      filesClient,
+	}, nil
 }
@@ -0,0 +1,160 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file provides a client of the batch endpoints of the API server, used by the controller.
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// BatchAPIError is the error response of the API server to a batch request.
+type BatchAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *BatchAPIError) Error() string {
+	if e.Message == "" {
+		return http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %s", http.StatusText(e.StatusCode), e.Message)
+}
+
+// isClientError tells whether the API server rejected the request, which fails again unless it changes.
+func isClientError(err error) bool {
+	apiErr := &BatchAPIError{}
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 &&
+		apiErr.StatusCode != http.StatusTooManyRequests
+}
+
+// isNotFound tells whether the API server reported that the batch doesn't exist.
+func isNotFound(err error) bool {
+	apiErr := &BatchAPIError{}
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// BatchClient calls the batch endpoints of an API server on behalf of tenants.
+type BatchClient struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewBatchClient creates a client of the API server at baseURL. The API key is sent as a bearer token
+// when it isn't empty. The default HTTP client is used when httpClient is nil.
+func NewBatchClient(baseURL, apiKey string, httpClient *http.Client) *BatchClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &BatchClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: httpClient,
+	}
+}
+
+// CreateBatch creates a batch of the tenant.
+func (c *BatchClient) CreateBatch(ctx context.Context, tenantID string, req *openai.CreateBatchRequest) (*openai.Batch, error) {
+	batch := &openai.Batch{}
+	if err := c.do(ctx, tenantID, http.MethodPost, "/v1/batches", req, batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// GetBatch returns a batch of the tenant.
+func (c *BatchClient) GetBatch(ctx context.Context, tenantID, batchID string) (*openai.Batch, error) {
+	batch := &openai.Batch{}
+	if err := c.do(ctx, tenantID, http.MethodGet, "/v1/batches/"+url.PathEscape(batchID), nil, batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// CancelBatch cancels a batch of the tenant.
+func (c *BatchClient) CancelBatch(ctx context.Context, tenantID, batchID string) (*openai.Batch, error) {
+	batch := &openai.Batch{}
+	if err := c.do(ctx, tenantID, http.MethodPost, "/v1/batches/"+url.PathEscape(batchID)+"/cancel", nil, batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// FindBatch returns the most recent batch of the tenant with the metadata key set to value,
+// or nil if there is none.
+func (c *BatchClient) FindBatch(ctx context.Context, tenantID, key, value string) (*openai.Batch, error) {
+	query := url.Values{"limit": {"1"}, "metadata[" + key + "]": {value}}
+	resp := &openai.ListBatchResponse{}
+	if err := c.do(ctx, tenantID, http.MethodGet, "/v1/batches?"+query.Encode(), nil, resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, nil
+	}
+	return &resp.Data[0], nil
+}
+
+// do sends a request of the tenant to the endpoint at path, with the JSON encoding of body if it isn't nil,
+// and decodes the JSON response into out. The API error of a failed request is returned as a *BatchAPIError.
+func (c *BatchClient) do(ctx context.Context, tenantID, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(common.TenantIDHeader, tenantID)
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		errResp := &openai.ErrorResponse{}
+		_ = json.NewDecoder(resp.Body).Decode(errResp)
+		return fmt.Errorf("%s %s: %w", method, path, &BatchAPIError{StatusCode: resp.StatusCode, Message: errResp.Error.Message})
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: invalid response: %w", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file defines the BatchJob custom resource, the Kubernetes-native interface of the batch gateway.
+// The CustomResourceDefinition is in deploy/crd.
+package controller
+
+import "github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+
+// The group, version and resource of the BatchJob custom resource.
+const (
+	Group    = "batch.llm-d.ai"
+	Version  = "v1alpha1"
+	Kind     = "BatchJob"
+	Resource = "batchjobs"
+
+	// Finalizer keeps a deleted BatchJob until the controller has cancelled its batch.
+	Finalizer = Group + "/cancel-batch"
+)
+
+// The metadata keys identifying the BatchJob that created a batch.
+const (
+	MetadataKeyUID  = "k8s_batchjob_uid"
+	MetadataKeyName = "k8s_batchjob"
+)
+
+// ObjectMeta holds the fields of the Kubernetes object metadata used by the controller.
+type ObjectMeta struct {
+	Name              string   `json:"name"`
+	Namespace         string   `json:"namespace"`
+	UID               string   `json:"uid"`
+	ResourceVersion   string   `json:"resourceVersion"`
+	Generation        int64    `json:"generation"`
+	DeletionTimestamp *string  `json:"deletionTimestamp,omitempty"`
+	Finalizers        []string `json:"finalizers,omitempty"`
+}
+
+// BatchJob is a batch submitted through Kubernetes. The controller creates the batch via the API server
+// and mirrors its status back into the resource.
+type BatchJob struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Metadata   ObjectMeta     `json:"metadata"`
+	Spec       BatchJobSpec   `json:"spec"`
+	Status     BatchJobStatus `json:"status"`
+}
+
+// BatchJobSpec is the batch to create. Only Cancel can change once the batch is created.
+type BatchJobSpec struct {
+	// required. The ID of an uploaded file that contains the requests of the batch.
+	InputFileID string `json:"inputFileID"`
+
+	// required. The endpoint used by all the requests of the batch.
+	Endpoint openai.Endpoint `json:"endpoint"`
+
+	// optional. The time frame within which the batch should be processed, defaults to 24h.
+	CompletionWindow string `json:"completionWindow,omitempty"`
+
+	// optional. Key-value pairs attached to the batch.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// optional. The priority of the batch; batches with higher priority are processed first.
+	Priority int `json:"priority,omitempty"`
+
+	// optional. Cancels the batch when set to true.
+	Cancel bool `json:"cancel,omitempty"`
+}
+
+// BatchJobStatus mirrors the status of the batch.
+type BatchJobStatus struct {
+	// The ID of the batch created for the BatchJob.
+	BatchID string `json:"batchID,omitempty"`
+
+	// The status of the batch.
+	Phase openai.BatchStatus `json:"phase,omitempty"`
+
+	// The IDs of the output and error files of the batch.
+	OutputFileID string `json:"outputFileID,omitempty"`
+	ErrorFileID  string `json:"errorFileID,omitempty"`
+
+	// The request counts of the batch.
+	RequestCounts *openai.BatchRequestCounts `json:"requestCounts,omitempty"`
+
+	// Why the batch couldn't be created or failed. Always set so that a merge patch clears it.
+	Message string `json:"message"`
+
+	// The generation of the spec the batch was last reconciled with.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// batchJobList is the response of the list endpoint of the BatchJobs.
+type batchJobList struct {
+	Metadata struct {
+		Continue string `json:"continue"`
+	} `json:"metadata"`
+	Items []BatchJob `json:"items"`
+}
+
+// hasFinalizer tells whether the BatchJob has the controller finalizer.
+func (j *BatchJob) hasFinalizer() bool {
+	for _, finalizer := range j.Metadata.Finalizers {
+		if finalizer == Finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// key identifies the BatchJob in the logs and in the metadata of its batch.
+func (j *BatchJob) key() string {
+	return j.Metadata.Namespace + "/" + j.Metadata.Name
+}
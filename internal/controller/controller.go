@@ -0,0 +1,223 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file implements the controller of the BatchJob custom resources. It creates the batch of every
+// BatchJob via the API server, mirrors the batch status back into the BatchJob and cancels the batch
+// when the BatchJob is cancelled or deleted.
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// DefaultCompletionWindow is the completion window of the batches of the BatchJobs that don't set one.
+const DefaultCompletionWindow = "24h"
+
+// errBatchNotFound reports that the batch recorded in the status of a BatchJob doesn't exist anymore.
+var errBatchNotFound = errors.New("the batch of the BatchJob doesn't exist")
+
+// Controller reconciles the BatchJobs with their batches. The batches are created on behalf of
+// the tenant named after the namespace of the BatchJob.
+type Controller struct {
+	kube      *KubeClient
+	batches   *BatchClient
+	namespace string
+}
+
+// New creates a controller of the BatchJobs of the namespace, or of all the namespaces when namespace is empty.
+func New(kube *KubeClient, batches *BatchClient, namespace string) *Controller {
+	return &Controller{
+		kube:      kube,
+		batches:   batches,
+		namespace: namespace,
+	}
+}
+
+// Run reconciles the BatchJobs every interval until the context is cancelled.
+func (c *Controller) Run(ctx context.Context, interval time.Duration) {
+	logger := klog.FromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := c.Sync(ctx); err != nil && ctx.Err() == nil {
+			logger.V(logging.ERROR).Error(err, "Failed to list the BatchJobs")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync reconciles all the BatchJobs once. The failure to reconcile a BatchJob is logged and retried
+// by the next sync.
+func (c *Controller) Sync(ctx context.Context) error {
+	logger := klog.FromContext(ctx)
+	jobs, err := c.kube.ListBatchJobs(ctx, c.namespace)
+	if err != nil {
+		return err
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if err := c.reconcile(ctx, job); err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to reconcile BatchJob", "batchJob", job.key())
+		}
+	}
+	return nil
+}
+
+func (c *Controller) reconcile(ctx context.Context, job *BatchJob) error {
+	logger := klog.FromContext(ctx).WithValues("batchJob", job.key())
+	tenantID := job.Metadata.Namespace
+
+	if job.Metadata.DeletionTimestamp != nil {
+		if !job.hasFinalizer() {
+			return nil
+		}
+		if !job.Status.Phase.IsFinal() {
+			batch, err := c.findBatch(ctx, job)
+			if err != nil && !errors.Is(err, errBatchNotFound) {
+				return err
+			}
+			if batch != nil && !batch.Status.IsFinal() {
+				if _, err := c.batches.CancelBatch(ctx, tenantID, batch.ID); err != nil && !isClientError(err) {
+					return fmt.Errorf("failed to cancel the batch: %w", err)
+				}
+				logger.V(logging.INFO).Info("Cancelled the batch of the deleted BatchJob", "batchID", batch.ID)
+			}
+		}
+		finalizers := slices.DeleteFunc(slices.Clone(job.Metadata.Finalizers), func(f string) bool { return f == Finalizer })
+		_, err := c.kube.PatchFinalizers(ctx, job, finalizers)
+		return err
+	}
+
+	// the batch of a BatchJob in a final phase doesn't change anymore
+	if job.Status.Phase.IsFinal() {
+		return nil
+	}
+	if !job.hasFinalizer() {
+		updated, err := c.kube.PatchFinalizers(ctx, job, append(slices.Clone(job.Metadata.Finalizers), Finalizer))
+		if err != nil {
+			return err
+		}
+		job = updated
+	}
+
+	status := job.Status
+	status.ObservedGeneration = job.Metadata.Generation
+	batch, err := c.findBatch(ctx, job)
+	if err != nil {
+		return err
+	}
+	switch {
+	case batch == nil && job.Spec.Cancel:
+		status.Phase = openai.BatchStatusCancelled
+		status.Message = "cancelled before the batch was created"
+	case batch == nil:
+		batch, err = c.batches.CreateBatch(ctx, tenantID, createBatchRequest(job))
+		if isClientError(err) {
+			// the batch is rejected until the BatchJob is recreated, as its spec is immutable
+			status.Phase = openai.BatchStatusFailed
+			status.Message = fmt.Sprintf("failed to create the batch: %v", err)
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create the batch: %w", err)
+		}
+		logger.V(logging.INFO).Info("Created the batch of the BatchJob", "batchID", batch.ID)
+	case job.Spec.Cancel && !batch.Status.IsFinal() && batch.Status != openai.BatchStatusCancelling:
+		cancelled, err := c.batches.CancelBatch(ctx, tenantID, batch.ID)
+		if err != nil && !isClientError(err) {
+			return fmt.Errorf("failed to cancel the batch: %w", err)
+		}
+		if err == nil {
+			batch = cancelled
+			logger.V(logging.INFO).Info("Cancelled the batch of the BatchJob", "batchID", batch.ID)
+		}
+	}
+	if batch != nil {
+		mirrorBatchStatus(&status, batch)
+	}
+
+	if reflect.DeepEqual(status, job.Status) {
+		return nil
+	}
+	_, err = c.kube.PatchStatus(ctx, job, &status)
+	return err
+}
+
+// findBatch returns the batch of the BatchJob, or nil if it isn't created yet. The batch is looked up
+// by its metadata when the BatchJob status doesn't record it, e.g. because the update of the status
+// failed after the batch was created.
+func (c *Controller) findBatch(ctx context.Context, job *BatchJob) (*openai.Batch, error) {
+	tenantID := job.Metadata.Namespace
+	if job.Status.BatchID != "" {
+		batch, err := c.batches.GetBatch(ctx, tenantID, job.Status.BatchID)
+		if isNotFound(err) {
+			return nil, fmt.Errorf("%w: %s", errBatchNotFound, job.Status.BatchID)
+		}
+		return batch, err
+	}
+	return c.batches.FindBatch(ctx, tenantID, MetadataKeyUID, job.Metadata.UID)
+}
+
+// createBatchRequest returns the request creating the batch of the BatchJob. The metadata of the batch
+// identifies the BatchJob.
+func createBatchRequest(job *BatchJob) *openai.CreateBatchRequest {
+	metadata := make(map[string]string, len(job.Spec.Metadata)+2)
+	for k, v := range job.Spec.Metadata {
+		metadata[k] = v
+	}
+	metadata[MetadataKeyUID] = job.Metadata.UID
+	metadata[MetadataKeyName] = job.key()
+
+	completionWindow := job.Spec.CompletionWindow
+	if completionWindow == "" {
+		completionWindow = DefaultCompletionWindow
+	}
+	return &openai.CreateBatchRequest{
+		InputFileID:      job.Spec.InputFileID,
+		Endpoint:         job.Spec.Endpoint,
+		CompletionWindow: completionWindow,
+		Metadata:         metadata,
+		Priority:         job.Spec.Priority,
+	}
+}
+
+// mirrorBatchStatus copies the status of the batch into the BatchJob status.
+func mirrorBatchStatus(status *BatchJobStatus, batch *openai.Batch) {
+	counts := batch.RequestCounts
+	status.BatchID = batch.ID
+	status.Phase = batch.Status
+	status.OutputFileID = batch.OutputFileID
+	status.ErrorFileID = batch.ErrorFileID
+	status.RequestCounts = &counts
+	status.Message = ""
+	if batch.Errors != nil && len(batch.Errors.Data) > 0 {
+		status.Message = batch.Errors.Data[0].Message
+	}
+}
@@ -0,0 +1,387 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for the BatchJob controller, against fake Kubernetes and batch APIs.
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/common"
+	"github.com/llm-d-incubation/batch-gateway/internal/apiserver/middleware"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/settings"
+)
+
+// testDelegateKey is the API key of the controller, allowed to act for the tenants by the API server.
+const testDelegateKey = "controller-key"
+
+// fakeKube serves the BatchJobs of the Kubernetes API from memory.
+type fakeKube struct {
+	mu   sync.Mutex
+	jobs map[string]*BatchJob
+}
+
+func (f *fakeKube) handler() http.Handler {
+	prefix := "/apis/" + Group + "/" + Version
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+prefix+"/"+Resource, func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		list := &batchJobList{Items: []BatchJob{}}
+		for _, job := range f.jobs {
+			list.Items = append(list.Items, *job)
+		}
+		json.NewEncoder(w).Encode(list)
+	})
+	mux.HandleFunc("PATCH "+prefix+"/namespaces/{namespace}/"+Resource+"/{name}", func(w http.ResponseWriter, r *http.Request) {
+		f.patch(w, r, func(job *BatchJob) error {
+			patch := &struct {
+				Metadata ObjectMeta `json:"metadata"`
+			}{}
+			if err := json.NewDecoder(r.Body).Decode(patch); err != nil {
+				return err
+			}
+			if patch.Metadata.ResourceVersion != job.Metadata.ResourceVersion {
+				return fmt.Errorf("conflict")
+			}
+			job.Metadata.Finalizers = patch.Metadata.Finalizers
+			return nil
+		})
+	})
+	mux.HandleFunc("PATCH "+prefix+"/namespaces/{namespace}/"+Resource+"/{name}/status", func(w http.ResponseWriter, r *http.Request) {
+		f.patch(w, r, func(job *BatchJob) error {
+			patch := &struct {
+				Status BatchJobStatus `json:"status"`
+			}{}
+			if err := json.NewDecoder(r.Body).Decode(patch); err != nil {
+				return err
+			}
+			job.Status = patch.Status
+			return nil
+		})
+	})
+	return mux
+}
+
+func (f *fakeKube) patch(w http.ResponseWriter, r *http.Request, apply func(job *BatchJob) error) {
+	if r.Header.Get("Content-Type") != "application/merge-patch+json" {
+		http.Error(w, `{"message":"unsupported patch"}`, http.StatusUnsupportedMediaType)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	job, ok := f.jobs[r.PathValue("namespace")+"/"+r.PathValue("name")]
+	if !ok {
+		http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+		return
+	}
+	if err := apply(job); err != nil {
+		http.Error(w, fmt.Sprintf(`{"message":%q}`, err.Error()), http.StatusConflict)
+		return
+	}
+	job.Metadata.ResourceVersion += "1"
+	// a BatchJob being deleted is removed with its last finalizer
+	if job.Metadata.DeletionTimestamp != nil && len(job.Metadata.Finalizers) == 0 {
+		delete(f.jobs, job.key())
+	}
+	json.NewEncoder(w).Encode(job)
+}
+
+func (f *fakeKube) add(job *BatchJob) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	job.Metadata.ResourceVersion = "1"
+	job.Metadata.Generation = 1
+	f.jobs[job.key()] = job
+}
+
+func (f *fakeKube) get(key string) *BatchJob {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	job, ok := f.jobs[key]
+	if !ok {
+		return nil
+	}
+	copied := *job
+	return &copied
+}
+
+// fakeBatches serves the batch endpoints of the API server from memory.
+type fakeBatches struct {
+	mu      sync.Mutex
+	batches map[string]*openai.Batch
+	tenants map[string]string
+}
+
+func (f *fakeBatches) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/batches", func(w http.ResponseWriter, r *http.Request) {
+		req := &openai.CreateBatchRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil || !strings.HasPrefix(req.InputFileID, "file-") {
+			writeError(w, http.StatusBadRequest, "input file not found")
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		batch := &openai.Batch{ID: fmt.Sprintf("batch_%d", len(f.batches)+1)}
+		batch.InputFileID = req.InputFileID
+		batch.Endpoint = req.Endpoint
+		batch.CompletionWindow = req.CompletionWindow
+		batch.Metadata = req.Metadata
+		batch.Status = openai.BatchStatusValidating
+		f.batches[batch.ID] = batch
+		f.tenants[batch.ID] = r.Header.Get(common.TenantIDHeader)
+		json.NewEncoder(w).Encode(batch)
+	})
+	mux.HandleFunc("GET /v1/batches", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		resp := &openai.ListBatchResponse{Object: "list", Data: []openai.Batch{}}
+		for id, batch := range f.batches {
+			if f.tenants[id] == r.Header.Get(common.TenantIDHeader) &&
+				batch.Metadata[MetadataKeyUID] == r.URL.Query().Get("metadata["+MetadataKeyUID+"]") {
+				resp.Data = append(resp.Data, *batch)
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("GET /v1/batches/{batch_id}", func(w http.ResponseWriter, r *http.Request) {
+		if batch := f.get(r.PathValue("batch_id"), r.Header.Get(common.TenantIDHeader)); batch != nil {
+			json.NewEncoder(w).Encode(batch)
+			return
+		}
+		writeError(w, http.StatusNotFound, "batch not found")
+	})
+	mux.HandleFunc("POST /v1/batches/{batch_id}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		batch, ok := f.batches[r.PathValue("batch_id")]
+		if !ok || f.tenants[batch.ID] != r.Header.Get(common.TenantIDHeader) {
+			writeError(w, http.StatusNotFound, "batch not found")
+			return
+		}
+		if batch.Status.IsFinal() {
+			writeError(w, http.StatusBadRequest, "batch cannot be cancelled")
+			return
+		}
+		batch.Status = openai.BatchStatusCancelling
+		json.NewEncoder(w).Encode(batch)
+	})
+	return mux
+}
+
+func (f *fakeBatches) get(batchID, tenantID string) *openai.Batch {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	batch, ok := f.batches[batchID]
+	if !ok || f.tenants[batchID] != tenantID {
+		return nil
+	}
+	copied := *batch
+	return &copied
+}
+
+func (f *fakeBatches) set(batchID string, update func(batch *openai.Batch)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	update(f.batches[batchID])
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(openai.ErrorResponse{Error: openai.APIError{Code: code, Message: message}})
+}
+
+func setupControllerForTest(t *testing.T) (*Controller, *fakeKube, *fakeBatches) {
+	t.Helper()
+	return setupControllerWithKeyForTest(t, testDelegateKey)
+}
+
+// setupControllerWithKeyForTest sets up a controller sending the API key to the batch API, whose tenant is
+// identified by the tenant middleware of the API server.
+func setupControllerWithKeyForTest(t *testing.T, apiKey string) (*Controller, *fakeKube, *fakeBatches) {
+	t.Helper()
+	kube := &fakeKube{jobs: make(map[string]*BatchJob)}
+	kubeServer := httptest.NewServer(kube.handler())
+	t.Cleanup(kubeServer.Close)
+	batches := &fakeBatches{batches: make(map[string]*openai.Batch), tenants: make(map[string]string)}
+	config := &common.ServerConfig{
+		TenantAPIKeys:      []common.TenantAPIKeyConfig{{Tenant: "tenant-a", Key: settings.NewSecret("tenant-a-key")}},
+		TenantDelegateKeys: []common.TenantDelegateKeyConfig{{Name: "controller", Key: settings.NewSecret(testDelegateKey)}},
+	}
+	batchServer := httptest.NewServer(middleware.TenantMiddleware(config)(batches.handler()))
+	t.Cleanup(batchServer.Close)
+	c := New(NewKubeClient(kubeServer.URL, "", nil), NewBatchClient(batchServer.URL, apiKey, nil), "")
+	return c, kube, batches
+}
+
+func newTestBatchJob(namespace, name, inputFileID string) *BatchJob {
+	return &BatchJob{
+		APIVersion: Group + "/" + Version,
+		Kind:       Kind,
+		Metadata:   ObjectMeta{Namespace: namespace, Name: name, UID: "uid-" + name},
+		Spec: BatchJobSpec{
+			InputFileID: inputFileID,
+			Endpoint:    openai.EndpointChatCompletions,
+			Metadata:    map[string]string{"team": "a"},
+		},
+	}
+}
+
+func syncForTest(t *testing.T, c *Controller) {
+	t.Helper()
+	if err := c.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+}
+
+func TestController(t *testing.T) {
+	t.Run("CreateAndMirrorStatus", func(t *testing.T) {
+		c, kube, batches := setupControllerForTest(t)
+		kube.add(newTestBatchJob("tenant-a", "job", "file-1"))
+		syncForTest(t, c)
+
+		job := kube.get("tenant-a/job")
+		if !job.hasFinalizer() {
+			t.Errorf("expected the finalizer to be added, got %v", job.Metadata.Finalizers)
+		}
+		if job.Status.BatchID == "" || job.Status.Phase != openai.BatchStatusValidating || job.Status.ObservedGeneration != 1 {
+			t.Fatalf("unexpected status %+v", job.Status)
+		}
+		batch := batches.get(job.Status.BatchID, "tenant-a")
+		if batch == nil {
+			t.Fatalf("expected the batch to be created in the tenant of the namespace")
+		}
+		if batch.CompletionWindow != DefaultCompletionWindow || batch.Metadata["team"] != "a" ||
+			batch.Metadata[MetadataKeyUID] != "uid-job" || batch.Metadata[MetadataKeyName] != "tenant-a/job" {
+			t.Errorf("unexpected batch %+v", batch)
+		}
+
+		batches.set(batch.ID, func(batch *openai.Batch) {
+			batch.Status = openai.BatchStatusCompleted
+			batch.OutputFileID = "file-out"
+			batch.RequestCounts = openai.BatchRequestCounts{Total: 2, Completed: 2}
+		})
+		syncForTest(t, c)
+		job = kube.get("tenant-a/job")
+		if job.Status.Phase != openai.BatchStatusCompleted || job.Status.OutputFileID != "file-out" ||
+			job.Status.RequestCounts == nil || job.Status.RequestCounts.Completed != 2 {
+			t.Errorf("expected the completed batch status to be mirrored, got %+v", job.Status)
+		}
+		if len(batches.batches) != 1 {
+			t.Errorf("expected a single batch, got %d", len(batches.batches))
+		}
+	})
+
+	t.Run("AdoptExistingBatch", func(t *testing.T) {
+		c, kube, batches := setupControllerForTest(t)
+		kube.add(newTestBatchJob("tenant-a", "job", "file-1"))
+		syncForTest(t, c)
+		batchID := kube.get("tenant-a/job").Status.BatchID
+
+		// the status update was lost after the batch was created
+		kube.jobs["tenant-a/job"].Status = BatchJobStatus{}
+		syncForTest(t, c)
+		if job := kube.get("tenant-a/job"); job.Status.BatchID != batchID {
+			t.Errorf("expected the batch %s to be adopted, got %+v", batchID, job.Status)
+		}
+		if len(batches.batches) != 1 {
+			t.Errorf("expected a single batch, got %d", len(batches.batches))
+		}
+	})
+
+	t.Run("RejectedBatch", func(t *testing.T) {
+		c, kube, batches := setupControllerForTest(t)
+		kube.add(newTestBatchJob("tenant-a", "job", "missing"))
+		syncForTest(t, c)
+		job := kube.get("tenant-a/job")
+		if job.Status.Phase != openai.BatchStatusFailed || !strings.Contains(job.Status.Message, "input file not found") {
+			t.Errorf("expected the BatchJob to fail, got %+v", job.Status)
+		}
+		syncForTest(t, c)
+		if len(batches.batches) != 0 {
+			t.Errorf("expected no batch, got %d", len(batches.batches))
+		}
+	})
+
+	t.Run("NotDelegated", func(t *testing.T) {
+		// the key of a tenant can't act for the tenants of the other namespaces
+		for _, apiKey := range []string{"", "tenant-a-key"} {
+			c, kube, batches := setupControllerWithKeyForTest(t, apiKey)
+			kube.add(newTestBatchJob("tenant-b", "job", "file-1"))
+			syncForTest(t, c)
+			if job := kube.get("tenant-b/job"); job.Status.BatchID != "" {
+				t.Errorf("key %q: expected the requests of the controller to be rejected, got %+v", apiKey, job.Status)
+			}
+			if len(batches.batches) != 0 {
+				t.Errorf("key %q: expected no batch, got %d", apiKey, len(batches.batches))
+			}
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		c, kube, batches := setupControllerForTest(t)
+		kube.add(newTestBatchJob("tenant-a", "job", "file-1"))
+		syncForTest(t, c)
+
+		kube.jobs["tenant-a/job"].Spec.Cancel = true
+		syncForTest(t, c)
+		job := kube.get("tenant-a/job")
+		if job.Status.Phase != openai.BatchStatusCancelling {
+			t.Errorf("expected the batch to be cancelling, got %+v", job.Status)
+		}
+		if batch := batches.get(job.Status.BatchID, "tenant-a"); batch.Status != openai.BatchStatusCancelling {
+			t.Errorf("expected the batch to be cancelled, got %s", batch.Status)
+		}
+
+		// a BatchJob cancelled before its batch is created never creates it
+		cancelled := newTestBatchJob("tenant-a", "cancelled", "file-1")
+		cancelled.Spec.Cancel = true
+		kube.add(cancelled)
+		syncForTest(t, c)
+		if job := kube.get("tenant-a/cancelled"); job.Status.Phase != openai.BatchStatusCancelled || job.Status.BatchID != "" {
+			t.Errorf("expected the BatchJob to be cancelled, got %+v", job.Status)
+		}
+		if len(batches.batches) != 1 {
+			t.Errorf("expected a single batch, got %d", len(batches.batches))
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		c, kube, batches := setupControllerForTest(t)
+		kube.add(newTestBatchJob("tenant-a", "job", "file-1"))
+		syncForTest(t, c)
+		batchID := kube.get("tenant-a/job").Status.BatchID
+
+		deletedAt := "2026-01-01T00:00:00Z"
+		kube.jobs["tenant-a/job"].Metadata.DeletionTimestamp = &deletedAt
+		syncForTest(t, c)
+		if job := kube.get("tenant-a/job"); job != nil {
+			t.Errorf("expected the finalizer to be removed, got %v", job.Metadata.Finalizers)
+		}
+		if batch := batches.get(batchID, "tenant-a"); batch.Status != openai.BatchStatusCancelling {
+			t.Errorf("expected the batch of the deleted BatchJob to be cancelled, got %s", batch.Status)
+		}
+	})
+}
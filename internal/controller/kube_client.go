@@ -0,0 +1,162 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file provides a minimal client of the Kubernetes API for the BatchJob custom resources.
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// listPageSize is the number of BatchJobs requested per page.
+const listPageSize = 500
+
+// KubeClient reads and updates the BatchJobs through the Kubernetes API.
+type KubeClient struct {
+	baseURL    string
+	tokenFile  string
+	httpClient *http.Client
+}
+
+// NewKubeClient creates a client of the Kubernetes API server at baseURL. The bearer token is read from
+// tokenFile on every request, as the service account tokens are rotated; no token is sent when tokenFile
+// is empty, e.g. through kubectl proxy. The default HTTP client is used when httpClient is nil.
+func NewKubeClient(baseURL, tokenFile string, httpClient *http.Client) *KubeClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &KubeClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		tokenFile:  tokenFile,
+		httpClient: httpClient,
+	}
+}
+
+// ListBatchJobs returns the BatchJobs of the namespace, or of all the namespaces when namespace is empty.
+func (c *KubeClient) ListBatchJobs(ctx context.Context, namespace string) ([]BatchJob, error) {
+	var jobs []BatchJob
+	query := url.Values{"limit": {fmt.Sprint(listPageSize)}}
+	for {
+		list := &batchJobList{}
+		if err := c.do(ctx, http.MethodGet, c.resourcePath(namespace, "")+"?"+query.Encode(), "", nil, list); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, list.Items...)
+		if list.Metadata.Continue == "" {
+			return jobs, nil
+		}
+		query.Set("continue", list.Metadata.Continue)
+	}
+}
+
+// PatchStatus replaces the status of the BatchJob, returning the updated BatchJob.
+func (c *KubeClient) PatchStatus(ctx context.Context, job *BatchJob, status *BatchJobStatus) (*BatchJob, error) {
+	patch := map[string]any{"status": status}
+	updated := &BatchJob{}
+	path := c.resourcePath(job.Metadata.Namespace, job.Metadata.Name) + "/status"
+	if err := c.do(ctx, http.MethodPatch, path, "application/merge-patch+json", patch, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// PatchFinalizers sets the finalizers of the BatchJob, returning the updated BatchJob. The patch fails
+// with a conflict if the BatchJob has changed since it was read.
+func (c *KubeClient) PatchFinalizers(ctx context.Context, job *BatchJob, finalizers []string) (*BatchJob, error) {
+	if finalizers == nil {
+		finalizers = []string{}
+	}
+	patch := map[string]any{"metadata": map[string]any{
+		"finalizers":      finalizers,
+		"resourceVersion": job.Metadata.ResourceVersion,
+	}}
+	updated := &BatchJob{}
+	path := c.resourcePath(job.Metadata.Namespace, job.Metadata.Name)
+	if err := c.do(ctx, http.MethodPatch, path, "application/merge-patch+json", patch, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// resourcePath returns the path of the BatchJob, or of the BatchJobs of the namespace when name is empty.
+func (c *KubeClient) resourcePath(namespace, name string) string {
+	path := "/apis/" + Group + "/" + Version
+	if namespace != "" {
+		path += "/namespaces/" + url.PathEscape(namespace)
+	}
+	path += "/" + Resource
+	if name != "" {
+		path += "/" + url.PathEscape(name)
+	}
+	return path
+}
+
+// do sends a request to the endpoint at path, with the JSON encoding of body if it isn't nil,
+// and decodes the JSON response into out.
+func (c *KubeClient) do(ctx context.Context, method, path, contentType string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.tokenFile != "" {
+		token, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read the Kubernetes API token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// the Kubernetes API returns a Status object describing the error
+		status := &struct {
+			Message string `json:"message"`
+		}{}
+		if err := json.NewDecoder(resp.Body).Decode(status); err != nil || status.Message == "" {
+			return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+		}
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, status.Message)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: invalid response: %w", method, path, err)
+	}
+	return nil
+}
@@ -20,12 +20,20 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"time"
 
 	"github.com/llm-d-incubation/batch-gateway/internal/shared/store"
 )
 
+var (
+	// ErrLeaseNotFound is returned when renewing or acknowledging a lease that expired and was reclaimed,
+	// or that doesn't exist.
+	ErrLeaseNotFound = errors.New("lease not found")
+)
+
 // -- Batch jobs metadata store --
 
 type BatchJob struct {
@@ -35,6 +43,8 @@ type BatchJob struct {
 	Tags   []string  // [optional, updatable, returned by get, parsed by DB] A list of tags that enable to select jobs based on the tags' contents. The tags must not contain ';;', which is the separator.
 	Spec   []byte    // [optional, immutable, returned optionally by get, opaque to DB] The static part of the batch job (serialized), including the job's specification.
 	Status []byte    // [optional, updatable, returned by get, opaque to DB] The dynamic part of the batch job (serialized), including its status.
+
+	TraceContext map[string]string // [optional, immutable, returned optionally by get, opaque to DB] The W3C trace context headers (traceparent, tracestate) of the request that created the job.
 }
 
 func (bj *BatchJob) IsValid() error {
@@ -98,20 +108,116 @@ var TagsLogicalCondNames = map[TagsLogicalCond]string{
 	TagsLogicalCondOr:  "or",
 }
 
+// -- Batch files metadata store --
+
+type BatchFile struct {
+	ID   string   // [mandatory, immutable, returned by get, parsed by DB, must be unique] ID of the file.
+	TTL  int      // [mandatory, immutable, not returned by get, parsed by DB] The number of seconds to set for the TTL of the DB record.
+	Tags []string // [optional, immutable, returned by get, parsed by DB] A list of tags that enable to select files based on the tags' contents.
+	Spec []byte   // [optional, immutable, returned by get, opaque to DB] The file object (serialized).
+}
+
+func (bf *BatchFile) IsValid() error {
+	if len(bf.ID) == 0 {
+		return fmt.Errorf("ID is empty")
+	}
+	if bf.TTL <= 0 {
+		return fmt.Errorf("TTL is invalid for ID %s", bf.ID)
+	}
+	return nil
+}
+
+// BatchFileDBClient enables to manage file metadata objects in persistent storage.
+type BatchFileDBClient interface {
+	store.BatchClientAdmin
+
+	// Store stores a file metadata object.
+	// Returns the ID of the file in the database.
+	Store(ctx context.Context, file *BatchFile) (ID string, err error)
+
+	// Get gets file metadata objects.
+	// The semantics of IDs, tags, tagsLogicalCond, start, limit and cursor are the same as in BatchDBClient.Get.
+	Get(ctx context.Context, IDs []string, tags []string, tagsLogicalCond TagsLogicalCond, start, limit int) (
+		files []*BatchFile, cursor int, err error)
+
+	// Delete deletes file metadata objects.
+	Delete(ctx context.Context, IDs []string) (deletedIDs []string, err error)
+}
+
 // -- Batch jobs priority queue --
 
 type BatchJobPriority struct {
-	ID  string    // ID of the batch job.
-	SLO time.Time // The SLO value determines the priority of the job.
+	ID       string    // ID of the batch job.
+	SLO      time.Time // The SLO value determines the priority of jobs with the same Priority, earlier SLO first.
+	Priority int       // Jobs with higher Priority are dequeued first.
+
+	TraceContext map[string]string // The W3C trace context headers of the request that created the job. Optional.
+
+	Attempts int // The number of times the job was dequeued and could not be processed.
+
+	Failures []BatchDeliveryFailure // The failures of the delivery attempts of the job, oldest first.
+
+	Queue string // The name of the queue the job was dequeued from, when consuming from several queues. Optional.
+
+	Shard *BatchJobShard // The range of lines of the job processed by this object, whose ID is then unique to the shard. Optional.
+}
+
+// BatchDeliveryFailure is a failed attempt to process a job.
+type BatchDeliveryFailure struct {
+	Time  time.Time // When the attempt failed.
+	Error string    // Why the attempt failed.
+}
+
+// BatchJobShard is a range of lines of a batch job processed as a task of its own,
+// so the lines of a large job can be processed by several processors.
+type BatchJobShard struct {
+	JobID     string // ID of the batch job.
+	Index     int    // Index of the shard in the job, from 0.
+	FirstLine int64  // Index of the first line of the shard in the input file of the job.
+	Lines     int64  // Number of lines of the shard.
+}
+
+// Before reports whether the job priority object should be dequeued before other.
+func (jp *BatchJobPriority) Before(other *BatchJobPriority) bool {
+	if jp.Priority != other.Priority {
+		return jp.Priority > other.Priority
+	}
+	return jp.SLO.Before(other.SLO)
+}
+
+// QueuePartition is a part of a priority queue, consumed by one of several consumers: the job priority objects
+// whose ID hashes to Index modulo Count. The ID of the shard of a job is unique to the shard, so the shards of
+// a job are spread over the partitions. A Count of 0 or 1 is the whole queue.
+type QueuePartition struct {
+	Index int
+	Count int
+}
+
+// Contains reports whether the job priority object with the ID belongs to the partition.
+func (p QueuePartition) Contains(ID string) bool {
+	if p.Count <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(ID))
+	return int(h.Sum32()%uint32(p.Count)) == p.Index
 }
 
 // BatchPriorityQueueClient enables to perform operations on a priority queue of jobs.
 type BatchPriorityQueueClient interface {
 	store.BatchClientAdmin
 
-	// Enqueue adds a job priority object to the queue.
+	// Enqueue adds a job priority object to the queue. The ID of the object identifies it in the queue: an object
+	// whose ID is already waiting in the queue (delayed included) is not added again, so a job published twice,
+	// e.g. when the publisher retries after a timeout, is delivered once.
 	Enqueue(ctx context.Context, jobPriority *BatchJobPriority) error
 
+	// EnqueueDelayed adds a job priority object to the queue like Enqueue, but the object is returned by Dequeue
+	// and Lease, and counted by Len, only once delay elapsed, e.g. to back off before retrying a job.
+	// The object is kept by the queue while delayed, so it isn't lost if the caller exits. Remove deletes it.
+	// A delay of zero is equivalent to Enqueue.
+	EnqueueDelayed(ctx context.Context, jobPriority *BatchJobPriority, delay time.Duration) error
+
 	// Dequeue returns the job priority objects at the head of the queue,
 	// up to the maximum number of objects specified in maxObjs.
 	// The function blocks up to the timeout value for a job priority object to be available.
@@ -121,6 +227,52 @@ type BatchPriorityQueueClient interface {
 
 	// Remove deletes a job priority object from the queue.
 	Remove(ctx context.Context, jobPriority *BatchJobPriority) error
+
+	// Len returns the number of job priority objects in the queue, not including leased objects.
+	Len(ctx context.Context) (int, error)
+
+	// Lease returns the job priority objects at the head of the queue like Dequeue, but instead of removing
+	// the objects, it leases them for leaseTTL. Leased objects are not returned by Dequeue and Lease.
+	// A lease must be renewed with RenewLease before it expires, and acknowledged with AckLease when the job
+	// doesn't need to be processed anymore. Expired leases are returned to the queue by ReclaimExpiredLeases.
+	Lease(ctx context.Context, timeout time.Duration, maxObjs int, leaseTTL time.Duration) (
+		jobPriorities []*BatchJobPriority, err error)
+
+	// LeasePartition leases the job priority objects of the partition at the head of the queue like Lease.
+	// The objects of the other partitions keep their place in the queue.
+	LeasePartition(ctx context.Context, timeout time.Duration, maxObjs int, leaseTTL time.Duration, partition QueuePartition) (
+		jobPriorities []*BatchJobPriority, err error)
+
+	// RenewLease extends the lease of a job priority object by leaseTTL from now.
+	// ErrLeaseNotFound is returned if the object is not leased, e.g. the lease expired and was reclaimed.
+	RenewLease(ctx context.Context, ID string, leaseTTL time.Duration) error
+
+	// IsLeased returns whether a job priority object is leased, i.e. it was delivered and its lease was neither
+	// acknowledged nor reclaimed yet.
+	IsLeased(ctx context.Context, ID string) (bool, error)
+
+	// AckLease ends the lease of a job priority object, removing the object.
+	// ErrLeaseNotFound is returned if the object is not leased.
+	AckLease(ctx context.Context, ID string) error
+
+	// ReclaimExpiredLeases returns the objects whose lease expired to the queue, incrementing their Attempts and
+	// adding the expiry to their Failures. Returns the reclaimed objects.
+	ReclaimExpiredLeases(ctx context.Context) (jobPriorities []*BatchJobPriority, err error)
+
+	// Stats returns the number of objects waiting, delayed and leased in the queue, and the age of the oldest
+	// waiting object.
+	Stats(ctx context.Context) (*BatchQueueStats, error)
+}
+
+// BatchQueueStats are statistics of a priority queue.
+type BatchQueueStats struct {
+	Waiting int // The number of objects that can be dequeued, as returned by Len.
+	Delayed int // The number of objects enqueued with a delay that didn't elapse yet.
+	Leased  int // The number of leased objects, i.e. delivered and not acknowledged yet.
+
+	// OldestEnqueuedAt is the time the oldest waiting object was added to the queue, or became visible when it
+	// was delayed. Zero when no object is waiting.
+	OldestEnqueuedAt time.Time
 }
 
 // -- Batch jobs events and channels --
@@ -128,16 +280,28 @@ type BatchPriorityQueueClient interface {
 type BatchEventType int
 
 const (
-	BatchEventCancel BatchEventType = iota // Cancel a job.
-	BatchEventPause                        // Pause a job.
-	BatchEventResume                       // Resume a job.
-	BatchEventMaxVal                       // [Internal] Indicates the max value for the enum. Don't use this value.
+	BatchEventCancel   BatchEventType = iota // Cancel a job.
+	BatchEventPause                          // Pause a job.
+	BatchEventResume                         // Resume a job.
+	BatchEventProgress                       // Progress of a job, published by the processor.
+	BatchEventMaxVal                         // [Internal] Indicates the max value for the enum. Don't use this value.
 )
 
 type BatchEvent struct {
 	ID   string         // [mandatory] ID of the job.
 	Type BatchEventType // [mandatory] Event type.
 	TTL  int            // [mandatory] TTL in seconds for the event. Must be the same for all the events sent for the same job ID. Set this for sending an event. This is not returned for the event consumer.
+
+	Progress *BatchProgress // [mandatory for BatchEventProgress] The progress of the job.
+}
+
+// BatchProgress is the progress of a job being processed.
+type BatchProgress struct {
+	Total      int64     // Number of requests read so far.
+	Completed  int64     // Number of requests that completed successfully.
+	Failed     int64     // Number of requests that failed.
+	Throughput float64   // Requests completed or failed per second since the previous progress event.
+	Time       time.Time // When the progress was measured.
 }
 
 func (be *BatchEvent) IsValid() error {
@@ -150,6 +314,9 @@ func (be *BatchEvent) IsValid() error {
 	if be.TTL <= 0 {
 		return fmt.Errorf("TTL is invalid for ID %s", be.ID)
 	}
+	if be.Type == BatchEventProgress && be.Progress == nil {
+		return fmt.Errorf("progress is missing for ID %s", be.ID)
+	}
 	return nil
 }
 
@@ -189,3 +356,28 @@ type BatchStatusClient interface {
 	// Delete removes the status data for a job.
 	Delete(ctx context.Context, ID string) error
 }
+
+// -- Batch jobs dead-letter queue --
+
+type BatchDeadLetter struct {
+	ID             string            // [mandatory] ID of the batch job.
+	JobPriority    *BatchJobPriority // [mandatory] The priority queue object of the job, restored when the job is requeued.
+	Error          string            // [optional] The error of the last delivery attempt.
+	DeadLetteredAt time.Time         // [mandatory] The time the job was moved to the dead-letter queue.
+}
+
+// BatchDeadLetterClient enables to manage the jobs that repeatedly failed to be processed,
+// so that they can be inspected and requeued by an operator.
+type BatchDeadLetterClient interface {
+	store.BatchClientAdmin
+
+	// Add adds a job to the dead-letter queue, replacing an existing entry of the same job.
+	Add(ctx context.Context, deadLetter *BatchDeadLetter) error
+
+	// List returns the jobs in the dead-letter queue, in the order they were added.
+	List(ctx context.Context) (deadLetters []*BatchDeadLetter, err error)
+
+	// Remove removes a job from the dead-letter queue, returning the removed entry.
+	// If the job is not in the dead-letter queue (nil, nil) is returned.
+	Remove(ctx context.Context, ID string) (deadLetter *BatchDeadLetter, err error)
+}
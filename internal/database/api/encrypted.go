@@ -0,0 +1,63 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements the status client encrypting the payloads it stores.
+
+package api
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/encryption"
+)
+
+// encryptedStatusClient encrypts the status data of the jobs, see NewEncryptedStatusClient.
+type encryptedStatusClient struct {
+	BatchStatusClient
+	cipher *encryption.PayloadCipher
+}
+
+// NewEncryptedStatusClient returns a client encrypting the data stored by client with cipher, since it may hold
+// request content, e.g. the cached inference responses. The IDs and TTLs stay in cleartext, and the data is bound
+// to its ID. Data stored in cleartext before the encryption was enabled is read as is. A nil client, or a nil
+// cipher, leaves client unchanged.
+func NewEncryptedStatusClient(client BatchStatusClient, cipher *encryption.PayloadCipher) BatchStatusClient {
+	if client == nil || cipher == nil {
+		return client
+	}
+	return &encryptedStatusClient{BatchStatusClient: client, cipher: cipher}
+}
+
+func (c *encryptedStatusClient) Set(ctx context.Context, ID string, TTL int, data []byte) error {
+	encrypted, err := c.cipher.Encrypt(data, []byte(ID))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt the status of %s: %w", ID, err)
+	}
+	return c.BatchStatusClient.Set(ctx, ID, TTL, encrypted)
+}
+
+func (c *encryptedStatusClient) Get(ctx context.Context, ID string) ([]byte, error) {
+	data, err := c.BatchStatusClient.Get(ctx, ID)
+	if err != nil || data == nil {
+		return data, err
+	}
+	decrypted, err := c.cipher.Decrypt(data, []byte(ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the status of %s: %w", ID, err)
+	}
+	return decrypted, nil
+}
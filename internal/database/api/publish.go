@@ -0,0 +1,98 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file provides the helpers writing a job to the database and publishing it to the priority queue together.
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// The steps of a job publication, see PublishError.
+const (
+	PublishStageStore      = "store"
+	PublishStageEnqueue    = "enqueue"
+	PublishStageCompensate = "compensate"
+)
+
+// PublishError is returned when a job couldn't be published. Stage is the step that failed. When the job
+// couldn't be enqueued, the database write is compensated, and Compensated tells whether it succeeded.
+type PublishError struct {
+	Stage       string
+	Err         error
+	Compensated bool
+}
+
+func (e *PublishError) Error() string {
+	if e.Stage == PublishStageEnqueue && !e.Compensated {
+		return fmt.Sprintf("job publication failed at %s, and the database write was not reverted: %v", e.Stage, e.Err)
+	}
+	return fmt.Sprintf("job publication failed at %s: %v", e.Stage, e.Err)
+}
+
+func (e *PublishError) Unwrap() error {
+	return e.Err
+}
+
+// PublishStage returns the step of the job publication that failed with err, or "" if err isn't a PublishError.
+// A failed compensation is reported as PublishStageCompensate.
+func PublishStage(err error) string {
+	var publishErr *PublishError
+	if !errors.As(err, &publishErr) {
+		return ""
+	}
+	if publishErr.Stage == PublishStageEnqueue && !publishErr.Compensated {
+		return PublishStageCompensate
+	}
+	return publishErr.Stage
+}
+
+// StoreAndEnqueue stores a new job in the database and enqueues its priority object, so a processor processes it.
+// If the job can't be enqueued, it is deleted from the database: the job is then either published, or not created
+// at all, and no batch is left that no processor will ever process. If the deletion fails too, the job is left
+// in the database without being queued, until the processors expire it as stuck.
+func StoreAndEnqueue(ctx context.Context, dbClient BatchDBClient, queueClient BatchPriorityQueueClient,
+	job *BatchJob, jobPriority *BatchJobPriority,
+) error {
+	if _, err := dbClient.Store(ctx, job); err != nil {
+		return &PublishError{Stage: PublishStageStore, Err: err}
+	}
+	if err := queueClient.Enqueue(ctx, jobPriority); err != nil {
+		_, deleteErr := dbClient.Delete(context.WithoutCancel(ctx), []string{job.ID})
+		return &PublishError{Stage: PublishStageEnqueue, Err: err, Compensated: deleteErr == nil}
+	}
+	return nil
+}
+
+// UpdateAndEnqueue updates the dynamic part of a job in the database and enqueues its priority object, e.g. to
+// process a job again. If the job can't be enqueued, its previous status is restored. If the restore fails too,
+// the job is left in the database with the new status without being queued, until the processors expire it as stuck.
+func UpdateAndEnqueue(ctx context.Context, dbClient BatchDBClient, queueClient BatchPriorityQueueClient,
+	job *BatchJob, previousStatus []byte, jobPriority *BatchJobPriority,
+) error {
+	if err := dbClient.Update(ctx, job); err != nil {
+		return &PublishError{Stage: PublishStageStore, Err: err}
+	}
+	if err := queueClient.Enqueue(ctx, jobPriority); err != nil {
+		job.Status = previousStatus
+		restoreErr := dbClient.Update(context.WithoutCancel(ctx), job)
+		return &PublishError{Stage: PublishStageEnqueue, Err: err, Compensated: restoreErr == nil}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements the clients logging the slow operations of the batch and file databases.
+
+package api
+
+import (
+	"context"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/slowop"
+)
+
+// timedDBClient tracks the operations of a batch jobs DB client, see slowop.
+type timedDBClient struct {
+	BatchDBClient
+}
+
+// NewTimedDBClient returns a client tracking the operations of client, so the slow ones are logged and the time
+// spent in them is part of the breakdown of slow API requests. A nil client is returned unchanged.
+func NewTimedDBClient(client BatchDBClient) BatchDBClient {
+	if client == nil {
+		return nil
+	}
+	return &timedDBClient{BatchDBClient: client}
+}
+
+func (c *timedDBClient) Store(ctx context.Context, job *BatchJob) (string, error) {
+	defer slowop.Track(ctx, slowop.Database, "store_job")()
+	return c.BatchDBClient.Store(ctx, job)
+}
+
+func (c *timedDBClient) Get(ctx context.Context, IDs []string, tags []string, tagsLogicalCond TagsLogicalCond,
+	includeStatic bool, start, limit int) ([]*BatchJob, int, error) {
+	defer slowop.Track(ctx, slowop.Database, "get_jobs")()
+	return c.BatchDBClient.Get(ctx, IDs, tags, tagsLogicalCond, includeStatic, start, limit)
+}
+
+func (c *timedDBClient) Update(ctx context.Context, job *BatchJob) error {
+	defer slowop.Track(ctx, slowop.Database, "update_job")()
+	return c.BatchDBClient.Update(ctx, job)
+}
+
+func (c *timedDBClient) Delete(ctx context.Context, IDs []string) ([]string, error) {
+	defer slowop.Track(ctx, slowop.Database, "delete_jobs")()
+	return c.BatchDBClient.Delete(ctx, IDs)
+}
+
+// timedFileDBClient tracks the operations of a file metadata DB client, see slowop.
+type timedFileDBClient struct {
+	BatchFileDBClient
+}
+
+// NewTimedFileDBClient returns a client tracking the operations of client, see NewTimedDBClient.
+func NewTimedFileDBClient(client BatchFileDBClient) BatchFileDBClient {
+	if client == nil {
+		return nil
+	}
+	return &timedFileDBClient{BatchFileDBClient: client}
+}
+
+func (c *timedFileDBClient) Store(ctx context.Context, file *BatchFile) (string, error) {
+	defer slowop.Track(ctx, slowop.Database, "store_file")()
+	return c.BatchFileDBClient.Store(ctx, file)
+}
+
+func (c *timedFileDBClient) Get(ctx context.Context, IDs []string, tags []string, tagsLogicalCond TagsLogicalCond,
+	start, limit int) ([]*BatchFile, int, error) {
+	defer slowop.Track(ctx, slowop.Database, "get_files")()
+	return c.BatchFileDBClient.Get(ctx, IDs, tags, tagsLogicalCond, start, limit)
+}
+
+func (c *timedFileDBClient) Delete(ctx context.Context, IDs []string) ([]string, error) {
+	defer slowop.Track(ctx, slowop.Database, "delete_files")()
+	return c.BatchFileDBClient.Delete(ctx, IDs)
+}
@@ -53,7 +53,7 @@ func (m *MockBatchDBClient) Get(ctx context.Context, IDs []string, tags []string
 		}
 	} else {
 		m.jobs.Range(func(key, value any) bool {
-			if job, ok := value.(*api.BatchJob); ok {
+			if job, ok := value.(*api.BatchJob); ok && matchTags(job.Tags, tags, tagsLogicalCond) {
 				results = append(results, job)
 				if len(results) >= limit && limit > 0 {
 					return false
@@ -88,6 +88,10 @@ func (m *MockBatchDBClient) GetContext(parentCtx context.Context, timeLimit time
 	return context.WithTimeout(parentCtx, timeLimit)
 }
 
+func (m *MockBatchDBClient) Ping(ctx context.Context) error {
+	return nil
+}
+
 func (m *MockBatchDBClient) Close() error {
 	m.jobs.Clear()
 	return nil
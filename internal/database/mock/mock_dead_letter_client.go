@@ -0,0 +1,88 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file provides in-memory mock implementations for BatchDeadLetterClient.
+package mock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/database/api"
+)
+
+type MockBatchDeadLetterClient struct {
+	mu          sync.Mutex
+	deadLetters []*api.BatchDeadLetter
+}
+
+func NewMockBatchDeadLetterClient() *MockBatchDeadLetterClient {
+	return &MockBatchDeadLetterClient{
+		deadLetters: make([]*api.BatchDeadLetter, 0),
+	}
+}
+
+func (m *MockBatchDeadLetterClient) Add(ctx context.Context, deadLetter *api.BatchDeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(deadLetter.ID)
+	m.deadLetters = append(m.deadLetters, deadLetter)
+	return nil
+}
+
+func (m *MockBatchDeadLetterClient) List(ctx context.Context) ([]*api.BatchDeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]*api.BatchDeadLetter, len(m.deadLetters))
+	copy(result, m.deadLetters)
+	return result, nil
+}
+
+func (m *MockBatchDeadLetterClient) Remove(ctx context.Context, ID string) (*api.BatchDeadLetter, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.remove(ID), nil
+}
+
+func (m *MockBatchDeadLetterClient) remove(ID string) *api.BatchDeadLetter {
+	for i, deadLetter := range m.deadLetters {
+		if deadLetter.ID == ID {
+			m.deadLetters = append(m.deadLetters[:i], m.deadLetters[i+1:]...)
+			return deadLetter
+		}
+	}
+	return nil
+}
+
+func (m *MockBatchDeadLetterClient) GetContext(parentCtx context.Context, timeLimit time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parentCtx, timeLimit)
+}
+
+func (m *MockBatchDeadLetterClient) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *MockBatchDeadLetterClient) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.deadLetters = nil
+	return nil
+}
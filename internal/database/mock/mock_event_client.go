@@ -123,6 +123,10 @@ func (m *MockBatchEventChannelClient) GetContext(parentCtx context.Context, time
 	return context.WithTimeout(parentCtx, timeLimit)
 }
 
+func (m *MockBatchEventChannelClient) Ping(ctx context.Context) error {
+	return nil
+}
+
 func (m *MockBatchEventChannelClient) Close() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -0,0 +1,89 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file provides in-memory mock implementations for BatchFileDBClient.
+package mock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/database/api"
+)
+
+type MockBatchFileDBClient struct {
+	files sync.Map
+}
+
+func NewMockBatchFileDBClient() *MockBatchFileDBClient {
+	return &MockBatchFileDBClient{}
+}
+
+func (m *MockBatchFileDBClient) Store(ctx context.Context, file *api.BatchFile) (string, error) {
+	m.files.Store(file.ID, file)
+	return file.ID, nil
+}
+
+func (m *MockBatchFileDBClient) Get(ctx context.Context, IDs []string, tags []string, tagsLogicalCond api.TagsLogicalCond, start, limit int) ([]*api.BatchFile, int, error) {
+	var results []*api.BatchFile
+
+	// If IDs are specified, get by IDs
+	if len(IDs) > 0 {
+		for _, id := range IDs {
+			if value, ok := m.files.Load(id); ok {
+				if file, ok := value.(*api.BatchFile); ok {
+					results = append(results, file)
+				}
+			}
+		}
+	} else {
+		m.files.Range(func(key, value any) bool {
+			if file, ok := value.(*api.BatchFile); ok && matchTags(file.Tags, tags, tagsLogicalCond) {
+				results = append(results, file)
+				if len(results) >= limit && limit > 0 {
+					return false
+				}
+			}
+			return true
+		})
+	}
+
+	return results, 0, nil
+}
+
+func (m *MockBatchFileDBClient) Delete(ctx context.Context, IDs []string) ([]string, error) {
+	var deleted []string
+	for _, id := range IDs {
+		if _, ok := m.files.LoadAndDelete(id); ok {
+			deleted = append(deleted, id)
+		}
+	}
+	return deleted, nil
+}
+
+func (m *MockBatchFileDBClient) GetContext(parentCtx context.Context, timeLimit time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parentCtx, timeLimit)
+}
+
+func (m *MockBatchFileDBClient) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *MockBatchFileDBClient) Close() error {
+	m.files.Clear()
+	return nil
+}
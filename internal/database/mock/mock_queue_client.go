@@ -14,7 +14,7 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-// The file provides in-memory mock implementations for BatchDBClient.
+// The file provides in-memory mock implementations for BatchPriorityQueueClient.
 package mock
 
 import (
@@ -27,13 +27,29 @@ import (
 )
 
 type MockBatchPriorityQueueClient struct {
-	mu    sync.Mutex
-	queue []*api.BatchJobPriority
+	mu         sync.Mutex
+	queue      []*api.BatchJobPriority
+	enqueuedAt map[*api.BatchJobPriority]time.Time
+	leases     map[string]*mockLease
+	delayed    []*mockDelayed
+}
+
+type mockLease struct {
+	jobPriority *api.BatchJobPriority
+	expiresAt   time.Time
+}
+
+// mockDelayed is an object enqueued with a delay, added to the queue once visibleAt is reached.
+type mockDelayed struct {
+	jobPriority *api.BatchJobPriority
+	visibleAt   time.Time
 }
 
 func NewMockBatchPriorityQueueClient() *MockBatchPriorityQueueClient {
 	return &MockBatchPriorityQueueClient{
-		queue: make([]*api.BatchJobPriority, 0),
+		queue:      make([]*api.BatchJobPriority, 0),
+		enqueuedAt: make(map[*api.BatchJobPriority]time.Time),
+		leases:     make(map[string]*mockLease),
 	}
 }
 
@@ -41,10 +57,69 @@ func (m *MockBatchPriorityQueueClient) Enqueue(ctx context.Context, jobPriority
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Insert in sorted order by SLO (earlier SLO = higher priority)
+	if !m.waiting(jobPriority.ID) {
+		m.enqueue(jobPriority)
+	}
+	return nil
+}
+
+func (m *MockBatchPriorityQueueClient) EnqueueDelayed(ctx context.Context, jobPriority *api.BatchJobPriority, delay time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.waiting(jobPriority.ID) {
+		return nil
+	}
+	if delay <= 0 {
+		m.enqueue(jobPriority)
+		return nil
+	}
+	m.delayed = append(m.delayed, &mockDelayed{jobPriority: jobPriority, visibleAt: time.Now().Add(delay)})
+	return nil
+}
+
+// promoteDelayed adds the delayed objects whose delay elapsed to the queue. Must be called with mu held.
+func (m *MockBatchPriorityQueueClient) promoteDelayed() {
+	now := time.Now()
+	pending := m.delayed[:0]
+	for _, d := range m.delayed {
+		if d.visibleAt.After(now) {
+			pending = append(pending, d)
+			continue
+		}
+		m.enqueue(d.jobPriority)
+	}
+	clear(m.delayed[len(pending):])
+	m.delayed = pending
+}
+
+// waiting returns whether an object with the ID is in the queue or delayed. Must be called with mu held.
+func (m *MockBatchPriorityQueueClient) waiting(ID string) bool {
+	for _, jp := range m.queue {
+		if jp.ID == ID {
+			return true
+		}
+	}
+	for _, d := range m.delayed {
+		if d.jobPriority.ID == ID {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MockBatchPriorityQueueClient) enqueue(jobPriority *api.BatchJobPriority) {
+	// a duplicate of a queued object, e.g. a reclaimed lease of a job published twice, is dropped
+	for _, jp := range m.queue {
+		if jp.ID == jobPriority.ID {
+			return
+		}
+	}
+
+	// Insert in sorted order by priority, then by SLO (earlier SLO = higher priority)
 	insertIdx := len(m.queue)
 	for i, jp := range m.queue {
-		if jobPriority.SLO.Before(jp.SLO) {
+		if jobPriority.Before(jp) {
 			insertIdx = i
 			break
 		}
@@ -54,25 +129,54 @@ func (m *MockBatchPriorityQueueClient) Enqueue(ctx context.Context, jobPriority
 	m.queue = append(m.queue, nil)
 	copy(m.queue[insertIdx+1:], m.queue[insertIdx:])
 	m.queue[insertIdx] = jobPriority
-
-	return nil
+	m.enqueuedAt[jobPriority] = time.Now()
 }
 
 func (m *MockBatchPriorityQueueClient) Dequeue(ctx context.Context, timeout time.Duration, maxObjs int) ([]*api.BatchJobPriority, error) {
+	return m.dequeue(ctx, timeout, maxObjs, 0, api.QueuePartition{})
+}
+
+func (m *MockBatchPriorityQueueClient) Lease(ctx context.Context, timeout time.Duration, maxObjs int, leaseTTL time.Duration) ([]*api.BatchJobPriority, error) {
+	return m.dequeue(ctx, timeout, maxObjs, leaseTTL, api.QueuePartition{})
+}
+
+func (m *MockBatchPriorityQueueClient) LeasePartition(
+	ctx context.Context, timeout time.Duration, maxObjs int, leaseTTL time.Duration, partition api.QueuePartition,
+) ([]*api.BatchJobPriority, error) {
+	return m.dequeue(ctx, timeout, maxObjs, leaseTTL, partition)
+}
+
+// dequeue removes the objects of the partition from the head of the queue, leasing them if leaseTTL is not zero.
+func (m *MockBatchPriorityQueueClient) dequeue(
+	ctx context.Context, timeout time.Duration, maxObjs int, leaseTTL time.Duration, partition api.QueuePartition,
+) ([]*api.BatchJobPriority, error) {
 	deadline := time.Now().Add(timeout)
 
 	for {
 		m.mu.Lock()
-		if len(m.queue) > 0 {
-			// Determine how many objects to return
-			count := min(maxObjs, len(m.queue))
+		m.promoteDelayed()
 
-			// Get the first 'count' items (highest priority)
-			result := make([]*api.BatchJobPriority, count)
-			copy(result, m.queue[:count])
+		// Get the first maxObjs objects of the partition (highest priority), the others keep their place
+		var result []*api.BatchJobPriority
+		remaining := m.queue[:0:0]
+		for _, jp := range m.queue {
+			if len(result) < maxObjs && partition.Contains(jp.ID) {
+				result = append(result, jp)
+			} else {
+				remaining = append(remaining, jp)
+			}
+		}
+		if len(result) > 0 {
+			m.queue = remaining
+			for _, jp := range result {
+				delete(m.enqueuedAt, jp)
+			}
 
-			// Remove them from the queue
-			m.queue = m.queue[count:]
+			if leaseTTL > 0 {
+				for _, jp := range result {
+					m.leases[jp.ID] = &mockLease{jobPriority: jp, expiresAt: time.Now().Add(leaseTTL)}
+				}
+			}
 
 			m.mu.Unlock()
 			return result, nil
@@ -107,6 +211,13 @@ func (m *MockBatchPriorityQueueClient) Remove(ctx context.Context, jobPriority *
 		if jp.ID == jobPriority.ID {
 			// Remove the item
 			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			delete(m.enqueuedAt, jp)
+			return nil
+		}
+	}
+	for i, d := range m.delayed {
+		if d.jobPriority.ID == jobPriority.ID {
+			m.delayed = append(m.delayed[:i], m.delayed[i+1:]...)
 			return nil
 		}
 	}
@@ -114,14 +225,93 @@ func (m *MockBatchPriorityQueueClient) Remove(ctx context.Context, jobPriority *
 	return fmt.Errorf("job with ID '%s' not found in queue", jobPriority.ID)
 }
 
+func (m *MockBatchPriorityQueueClient) Len(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.promoteDelayed()
+	return len(m.queue), nil
+}
+
+func (m *MockBatchPriorityQueueClient) RenewLease(ctx context.Context, ID string, leaseTTL time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lease, ok := m.leases[ID]
+	if !ok {
+		return api.ErrLeaseNotFound
+	}
+	lease.expiresAt = time.Now().Add(leaseTTL)
+	return nil
+}
+
+func (m *MockBatchPriorityQueueClient) IsLeased(ctx context.Context, ID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.leases[ID]
+	return ok, nil
+}
+
+func (m *MockBatchPriorityQueueClient) AckLease(ctx context.Context, ID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.leases[ID]; !ok {
+		return api.ErrLeaseNotFound
+	}
+	delete(m.leases, ID)
+	return nil
+}
+
+func (m *MockBatchPriorityQueueClient) ReclaimExpiredLeases(ctx context.Context) ([]*api.BatchJobPriority, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	reclaimed := make([]*api.BatchJobPriority, 0)
+	for ID, lease := range m.leases {
+		if lease.expiresAt.After(now) {
+			continue
+		}
+		delete(m.leases, ID)
+		lease.jobPriority.Attempts++
+		lease.jobPriority.Failures = append(lease.jobPriority.Failures, api.BatchDeliveryFailure{Time: now, Error: "lease expired"})
+		m.enqueue(lease.jobPriority)
+		reclaimed = append(reclaimed, lease.jobPriority)
+	}
+	return reclaimed, nil
+}
+
+func (m *MockBatchPriorityQueueClient) Stats(ctx context.Context) (*api.BatchQueueStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.promoteDelayed()
+	stats := &api.BatchQueueStats{Waiting: len(m.queue), Delayed: len(m.delayed), Leased: len(m.leases)}
+	for _, enqueuedAt := range m.enqueuedAt {
+		if stats.OldestEnqueuedAt.IsZero() || enqueuedAt.Before(stats.OldestEnqueuedAt) {
+			stats.OldestEnqueuedAt = enqueuedAt
+		}
+	}
+	return stats, nil
+}
+
 func (m *MockBatchPriorityQueueClient) GetContext(parentCtx context.Context, timeLimit time.Duration) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(parentCtx, timeLimit)
 }
 
+func (m *MockBatchPriorityQueueClient) Ping(ctx context.Context) error {
+	return nil
+}
+
 func (m *MockBatchPriorityQueueClient) Close() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.queue = nil
+	m.enqueuedAt = nil
+	m.leases = nil
+	m.delayed = nil
 	return nil
 }
@@ -80,6 +80,10 @@ func (m *MockBatchStatusClient) GetContext(parentCtx context.Context, timeLimit
 	return context.WithTimeout(parentCtx, timeLimit)
 }
 
+func (m *MockBatchStatusClient) Ping(ctx context.Context) error {
+	return nil
+}
+
 func (m *MockBatchStatusClient) Close() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -0,0 +1,46 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file provides tag matching shared by the in-memory mock implementations.
+package mock
+
+import (
+	"slices"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/database/api"
+)
+
+// matchTags reports whether an object with objTags is selected by the tags and the logical condition.
+// Empty tags select every object.
+func matchTags(objTags []string, tags []string, cond api.TagsLogicalCond) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	if cond == api.TagsLogicalCondOr {
+		for _, tag := range tags {
+			if slices.Contains(objTags, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, tag := range tags {
+		if !slices.Contains(objTags, tag) {
+			return false
+		}
+	}
+	return true
+}
@@ -20,12 +20,20 @@ package api
 
 import (
 	"context"
+	"errors"
 	"io"
 	"time"
 
 	"github.com/llm-d-incubation/batch-gateway/internal/shared/store"
 )
 
+var (
+	ErrFileNotFound   = errors.New("file not found")
+	ErrFileTooLarge   = errors.New("file exceeds the size limit")
+	ErrUploadNotFound = errors.New("upload not found")
+	ErrPartNotFound   = errors.New("upload part not found")
+)
+
 type BatchFileMetadata struct {
 	Location string    // Absolute location of the file.
 	Size     int64     // The size of the file in bytes.
@@ -36,15 +44,59 @@ type BatchFilesClient interface {
 	store.BatchClientAdmin
 
 	// Store stores a file in the files storage.
+	// The reader is consumed as a stream. If more than fileSizeLimit bytes are read, the partially stored data is
+	// removed and ErrFileTooLarge is returned. A fileSizeLimit of zero or less means no limit.
 	Store(ctx context.Context, location string, fileSizeLimit int64, reader io.Reader) (
 		fileMd *BatchFileMetadata, err error)
 
 	// Retrieve retrieves a file from the files storage.
+	// If the returned reader implements io.Closer, the caller must close it.
+	// ErrFileNotFound is returned if the file doesn't exist.
 	Retrieve(ctx context.Context, location string) (reader io.Reader, fileMd *BatchFileMetadata, err error)
 
 	// List lists the files in the specified location. Location here is a pattern.
 	List(ctx context.Context, location string) (files []BatchFileMetadata, err error)
 
 	// Delete deletes the file in the specified location.
+	// ErrFileNotFound is returned if the file doesn't exist.
 	Delete(ctx context.Context, location string) (err error)
 }
+
+// BatchFilesRangeRetriever is implemented by files stores that can retrieve a byte range of a file, so that
+// parts of large files can be read without transferring the whole file.
+type BatchFilesRangeRetriever interface {
+	// RetrieveRange retrieves at most length bytes of a file, starting at offset.
+	// If the returned reader implements io.Closer, the caller must close it.
+	// ErrFileNotFound is returned if the file doesn't exist.
+	RetrieveRange(ctx context.Context, location string, offset, length int64) (
+		reader io.Reader, fileMd *BatchFileMetadata, err error)
+}
+
+// BatchFilesMultipartUploader is implemented by files stores that can assemble a file from parts uploaded
+// separately, e.g. with S3 multipart uploads. Parts can be re-uploaded, so an interrupted upload can be resumed.
+type BatchFilesMultipartUploader interface {
+	// CreateMultipartUpload starts a multipart upload of a file to the specified location.
+	CreateMultipartUpload(ctx context.Context, location string) (uploadID string, err error)
+
+	// UploadPart stores a part of a multipart upload, replacing a previously uploaded part with the same number.
+	// Part numbers start at 1. If more than partSizeLimit bytes are read, ErrFileTooLarge is returned.
+	// ErrUploadNotFound is returned if the upload doesn't exist.
+	UploadPart(ctx context.Context, location, uploadID string, partNumber int, partSizeLimit int64, reader io.Reader) (
+		size int64, err error)
+
+	// CompleteMultipartUpload assembles the file from the parts, in ascending order of the part numbers,
+	// and discards the upload. ErrPartNotFound is returned if a part wasn't uploaded.
+	CompleteMultipartUpload(ctx context.Context, location, uploadID string, partNumbers []int) (
+		fileMd *BatchFileMetadata, err error)
+
+	// AbortMultipartUpload discards a multipart upload and its parts.
+	// ErrUploadNotFound is returned if the upload doesn't exist.
+	AbortMultipartUpload(ctx context.Context, location, uploadID string) error
+}
+
+// BatchFilesPresigner is implemented by files stores that can issue presigned URLs, so clients download
+// files directly from the storage instead of through the API server.
+type BatchFilesPresigner interface {
+	// PresignRetrieve returns a URL granting read access to the file in the specified location until expiry.
+	PresignRetrieve(ctx context.Context, location string, expiry time.Duration) (url string, err error)
+}
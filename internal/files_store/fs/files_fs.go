@@ -17,3 +17,227 @@ limitations under the License.
 // This file implements the batch files storage interface using file system.
 
 package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/slowop"
+)
+
+const (
+	defaultTimeLimit = 30 * time.Second
+	tmpFilePrefix    = ".upload-"
+)
+
+// FSFilesClient stores files under a root directory. Locations are paths relative to the root directory.
+type FSFilesClient struct {
+	rootDir string
+}
+
+func NewFSFilesClient(rootDir string) (*FSFilesClient, error) {
+	if rootDir == "" {
+		return nil, fmt.Errorf("root directory cannot be empty")
+	}
+	absDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(absDir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create root directory: %w", err)
+	}
+	return &FSFilesClient{rootDir: absDir}, nil
+}
+
+// path resolves a location to an absolute path, rejecting locations that escape the root directory.
+func (c *FSFilesClient) path(location string) (string, error) {
+	if location == "" {
+		return "", fmt.Errorf("location cannot be empty")
+	}
+	p := filepath.Join(c.rootDir, filepath.FromSlash(location))
+	if p != c.rootDir && !strings.HasPrefix(p, c.rootDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("location %q is outside of the root directory", location)
+	}
+	return p, nil
+}
+
+func (c *FSFilesClient) Store(ctx context.Context, location string, fileSizeLimit int64, reader io.Reader) (*api.BatchFileMetadata, error) {
+	defer slowop.Track(ctx, slowop.FileStore, "store")()
+	p, err := c.path(location)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return nil, err
+	}
+
+	// write to a temporary file first, so a partial upload is never visible at the final location
+	tmp, err := os.CreateTemp(filepath.Dir(p), tmpFilePrefix+"*")
+	if err != nil {
+		return nil, err
+	}
+	tmpName := tmp.Name()
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmpName)
+	}
+
+	src := reader
+	if fileSizeLimit > 0 {
+		src = io.LimitReader(reader, fileSizeLimit+1)
+	}
+	n, err := io.Copy(tmp, &ctxReader{ctx: ctx, r: src})
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	if fileSizeLimit > 0 && n > fileSizeLimit {
+		cleanup()
+		return nil, api.ErrFileTooLarge
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return nil, err
+	}
+	if err := os.Rename(tmpName, p); err != nil {
+		os.Remove(tmpName)
+		return nil, err
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	return &api.BatchFileMetadata{
+		Location: location,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+	}, nil
+}
+
+func (c *FSFilesClient) Retrieve(ctx context.Context, location string) (io.Reader, *api.BatchFileMetadata, error) {
+	defer slowop.Track(ctx, slowop.FileStore, "retrieve")()
+	p, err := c.path(location)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, api.ErrFileNotFound
+		}
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, &api.BatchFileMetadata{
+		Location: location,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+	}, nil
+}
+
+func (c *FSFilesClient) RetrieveRange(ctx context.Context, location string, offset, length int64) (io.Reader, *api.BatchFileMetadata, error) {
+	reader, md, err := c.Retrieve(ctx, location)
+	if err != nil {
+		return nil, nil, err
+	}
+	f := reader.(*os.File)
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.NewSectionReader(f, offset, length), f}, md, nil
+}
+
+func (c *FSFilesClient) List(ctx context.Context, location string) ([]api.BatchFileMetadata, error) {
+	defer slowop.Track(ctx, slowop.FileStore, "list")()
+	pattern, err := c.path(location)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]api.BatchFileMetadata, 0, len(matches))
+	for _, m := range matches {
+		if strings.HasPrefix(filepath.Base(m), tmpFilePrefix) {
+			continue
+		}
+		info, err := os.Stat(m)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		rel, err := filepath.Rel(c.rootDir, m)
+		if err != nil {
+			continue
+		}
+		files = append(files, api.BatchFileMetadata{
+			Location: filepath.ToSlash(rel),
+			Size:     info.Size(),
+			ModTime:  info.ModTime(),
+		})
+	}
+	return files, nil
+}
+
+func (c *FSFilesClient) Delete(ctx context.Context, location string) error {
+	defer slowop.Track(ctx, slowop.FileStore, "delete")()
+	p, err := c.path(location)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return api.ErrFileNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *FSFilesClient) GetContext(parentCtx context.Context, timeLimit time.Duration) (context.Context, context.CancelFunc) {
+	if timeLimit <= 0 {
+		timeLimit = defaultTimeLimit
+	}
+	return context.WithTimeout(parentCtx, timeLimit)
+}
+
+func (c *FSFilesClient) Ping(ctx context.Context) error {
+	info, err := os.Stat(c.rootDir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", c.rootDir)
+	}
+	return nil
+}
+
+func (c *FSFilesClient) Close() error {
+	return nil
+}
+
+// ctxReader stops reading once the context is done, so an aborted upload doesn't keep writing to disk.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *ctxReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements multipart uploads for the file system files storage.
+
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/google/uuid"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
+)
+
+// multipartDir holds the parts of the multipart uploads in progress, one directory per upload.
+const multipartDir = ".multipart"
+
+func (c *FSFilesClient) CreateMultipartUpload(ctx context.Context, location string) (string, error) {
+	if _, err := c.path(location); err != nil {
+		return "", err
+	}
+	uploadID := uuid.NewString()
+	dir, err := c.path(path.Join(multipartDir, uploadID))
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+func (c *FSFilesClient) UploadPart(ctx context.Context, location, uploadID string, partNumber int, partSizeLimit int64, reader io.Reader) (int64, error) {
+	if partNumber < 1 {
+		return 0, fmt.Errorf("invalid part number %d", partNumber)
+	}
+	if _, err := c.uploadDir(uploadID); err != nil {
+		return 0, err
+	}
+	md, err := c.Store(ctx, partLocation(uploadID, partNumber), partSizeLimit, reader)
+	if err != nil {
+		return 0, err
+	}
+	return md.Size, nil
+}
+
+func (c *FSFilesClient) CompleteMultipartUpload(ctx context.Context, location, uploadID string, partNumbers []int) (*api.BatchFileMetadata, error) {
+	dir, err := c.uploadDir(uploadID)
+	if err != nil {
+		return nil, err
+	}
+
+	readers := make([]io.Reader, 0, len(partNumbers))
+	for _, partNumber := range partNumbers {
+		reader, _, err := c.Retrieve(ctx, partLocation(uploadID, partNumber))
+		if err != nil {
+			if errors.Is(err, api.ErrFileNotFound) {
+				err = fmt.Errorf("%w: part %d", api.ErrPartNotFound, partNumber)
+			}
+			closeAll(readers)
+			return nil, err
+		}
+		readers = append(readers, reader)
+	}
+	md, err := c.Store(ctx, location, 0, io.MultiReader(readers...))
+	closeAll(readers)
+	if err != nil {
+		return nil, err
+	}
+	os.RemoveAll(dir)
+	return md, nil
+}
+
+func (c *FSFilesClient) AbortMultipartUpload(ctx context.Context, location, uploadID string) error {
+	dir, err := c.uploadDir(uploadID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// uploadDir returns the directory of an upload in progress.
+func (c *FSFilesClient) uploadDir(uploadID string) (string, error) {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return "", api.ErrUploadNotFound
+	}
+	dir, err := c.path(path.Join(multipartDir, uploadID))
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", api.ErrUploadNotFound
+		}
+		return "", err
+	}
+	return dir, nil
+}
+
+func partLocation(uploadID string, partNumber int) string {
+	return path.Join(multipartDir, uploadID, fmt.Sprintf("%05d", partNumber))
+}
+
+func closeAll(readers []io.Reader) {
+	for _, r := range readers {
+		if closer, ok := r.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Test for the file system files storage.
+
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
+)
+
+func TestFSFilesClient(t *testing.T) {
+	ctx := context.Background()
+	client, err := NewFSFilesClient(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	t.Run("store and retrieve", func(t *testing.T) {
+		md, err := client.Store(ctx, "dir/f1", 100, strings.NewReader("hello"))
+		if err != nil {
+			t.Fatalf("Store() error: %v", err)
+		}
+		if md.Size != 5 {
+			t.Errorf("Size = %d, want 5", md.Size)
+		}
+
+		reader, md, err := client.Retrieve(ctx, "dir/f1")
+		if err != nil {
+			t.Fatalf("Retrieve() error: %v", err)
+		}
+		defer reader.(io.Closer).Close()
+		data, _ := io.ReadAll(reader)
+		if string(data) != "hello" || md.Size != 5 {
+			t.Errorf("Retrieve() = %q (%d bytes), want %q", data, md.Size, "hello")
+		}
+	})
+
+	t.Run("retrieve range", func(t *testing.T) {
+		if _, err := client.Store(ctx, "ranged", 0, strings.NewReader("hello world")); err != nil {
+			t.Fatalf("Store() error: %v", err)
+		}
+		reader, md, err := client.RetrieveRange(ctx, "ranged", 6, 3)
+		if err != nil {
+			t.Fatalf("RetrieveRange() error: %v", err)
+		}
+		defer reader.(io.Closer).Close()
+		data, _ := io.ReadAll(reader)
+		if string(data) != "wor" || md.Size != 11 {
+			t.Errorf("RetrieveRange() = %q (%d bytes), want %q", data, md.Size, "wor")
+		}
+		if _, _, err := client.RetrieveRange(ctx, "missing", 0, 1); !errors.Is(err, api.ErrFileNotFound) {
+			t.Errorf("RetrieveRange() of missing file error = %v, want %v", err, api.ErrFileNotFound)
+		}
+	})
+
+	t.Run("multipart upload", func(t *testing.T) {
+		uploadID, err := client.CreateMultipartUpload(ctx, "assembled")
+		if err != nil {
+			t.Fatalf("CreateMultipartUpload() error: %v", err)
+		}
+		// parts are uploaded out of order, and a part is re-uploaded
+		for _, part := range []struct {
+			number int
+			data   string
+		}{{2, "world"}, {1, "xxxxxx"}, {1, "hello "}} {
+			if _, err := client.UploadPart(ctx, "assembled", uploadID, part.number, 10, strings.NewReader(part.data)); err != nil {
+				t.Fatalf("UploadPart(%d) error: %v", part.number, err)
+			}
+		}
+		if _, err := client.UploadPart(ctx, "assembled", uploadID, 3, 2, strings.NewReader("too large")); !errors.Is(err, api.ErrFileTooLarge) {
+			t.Errorf("UploadPart() of a large part error = %v, want %v", err, api.ErrFileTooLarge)
+		}
+		if _, err := client.CompleteMultipartUpload(ctx, "assembled", uploadID, []int{1, 2, 4}); !errors.Is(err, api.ErrPartNotFound) {
+			t.Errorf("CompleteMultipartUpload() with a missing part error = %v, want %v", err, api.ErrPartNotFound)
+		}
+
+		md, err := client.CompleteMultipartUpload(ctx, "assembled", uploadID, []int{1, 2})
+		if err != nil {
+			t.Fatalf("CompleteMultipartUpload() error: %v", err)
+		}
+		reader, _, err := client.Retrieve(ctx, "assembled")
+		if err != nil {
+			t.Fatalf("Retrieve() error: %v", err)
+		}
+		defer reader.(io.Closer).Close()
+		if data, _ := io.ReadAll(reader); string(data) != "hello world" || md.Size != 11 {
+			t.Errorf("assembled file = %q (%d bytes), want %q", data, md.Size, "hello world")
+		}
+
+		// the upload is discarded once completed
+		if err := client.AbortMultipartUpload(ctx, "assembled", uploadID); !errors.Is(err, api.ErrUploadNotFound) {
+			t.Errorf("AbortMultipartUpload() after completion error = %v, want %v", err, api.ErrUploadNotFound)
+		}
+		if _, err := client.UploadPart(ctx, "assembled", "../escape", 1, 0, strings.NewReader("x")); !errors.Is(err, api.ErrUploadNotFound) {
+			t.Errorf("UploadPart() with invalid upload ID error = %v, want %v", err, api.ErrUploadNotFound)
+		}
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		_, err := client.Store(ctx, "big", 4, strings.NewReader("hello"))
+		if !errors.Is(err, api.ErrFileTooLarge) {
+			t.Fatalf("Store() error = %v, want %v", err, api.ErrFileTooLarge)
+		}
+		if _, _, err := client.Retrieve(ctx, "big"); !errors.Is(err, api.ErrFileNotFound) {
+			t.Errorf("Retrieve() error = %v, want %v", err, api.ErrFileNotFound)
+		}
+		entries, _ := os.ReadDir(client.rootDir)
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), tmpFilePrefix) {
+				t.Errorf("temporary file was not removed: %s", e.Name())
+			}
+		}
+	})
+
+	t.Run("list and delete", func(t *testing.T) {
+		for _, loc := range []string{"list/a", "list/b"} {
+			if _, err := client.Store(ctx, loc, 0, strings.NewReader(loc)); err != nil {
+				t.Fatalf("Store() error: %v", err)
+			}
+		}
+		files, err := client.List(ctx, "list/*")
+		if err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		if len(files) != 2 {
+			t.Fatalf("List() returned %d files, want 2", len(files))
+		}
+		if err := client.Delete(ctx, "list/a"); err != nil {
+			t.Fatalf("Delete() error: %v", err)
+		}
+		if err := client.Delete(ctx, "list/a"); !errors.Is(err, api.ErrFileNotFound) {
+			t.Errorf("Delete() error = %v, want %v", err, api.ErrFileNotFound)
+		}
+	})
+
+	t.Run("location outside of root", func(t *testing.T) {
+		if _, err := client.Store(ctx, "../escape", 0, strings.NewReader("x")); err == nil {
+			t.Error("Store() expected error for location outside of the root directory")
+		}
+	})
+}
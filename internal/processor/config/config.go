@@ -19,25 +19,199 @@ limitations under the License.
 package config
 
 import (
+	"fmt"
+	"net/url"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
-	"gopkg.in/yaml.v3"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/kube"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/settings"
 )
 
+// ProcessorConfig is the configuration of the processor: the common blocks and the processor section of the
+// configuration file.
 type ProcessorConfig struct {
-	// TaskWaitTime is the timeout parameter used when dequeueing from the priority queue
-	// This should be shorter than PollInterval
+	settings.Common `yaml:"-"`
+
+	// TaskWaitTime is the time a dequeue from the priority queue blocks waiting for a job, when the processor
+	// consumes a single queue. Several queues are polled without blocking, so an empty queue doesn't hold back the others.
 	TaskWaitTime time.Duration `yaml:"task_wait_time"`
 
-	// NumWorkers is the fixed number of worker goroutines spawned to process jobs
+	// NumWorkers is the number of worker goroutines spawned to process jobs.
+	// It is the maximum number of workers when autoscaling is enabled.
 	NumWorkers int `yaml:"num_workers"`
 
+	// AutoscaleEnabled enables scaling the number of active workers between MinWorkers and NumWorkers.
+	// Every AutoscaleInterval, workers are added for the jobs waiting in the queue, and removed when idle or when
+	// the fraction of inference requests failing with retryable (upstream) errors exceeds AutoscaleMaxErrorRate.
+	AutoscaleEnabled      bool          `yaml:"autoscale_enabled"`
+	MinWorkers            int           `yaml:"min_workers"`
+	AutoscaleInterval     time.Duration `yaml:"autoscale_interval"`
+	AutoscaleMaxErrorRate float64       `yaml:"autoscale_max_error_rate"`
+
 	// MaxJobConcurrency defines how many lines within a single job are processed concurrently
 	MaxJobConcurrency int `yaml:"max_job_concurrency"`
 
-	// PollInterval defines how frequently the processor checks the database for new jobs
-	PollInterval time.Duration `yaml:"poll_interval"`
+	// MaxConcurrentRequests bounds the inference requests in flight across all the jobs of the processor (0 means
+	// no limit other than MaxJobConcurrency per job). Above the limit, the lines of the batch closest to the end of
+	// its completion window are dispatched first.
+	MaxConcurrentRequests int `yaml:"max_concurrent_requests"`
+
+	// SaturationThreshold is the number of consecutive rate limited (429) requests to a model after which dispatch
+	// to the model is paused (0 disables pausing). Dispatch is also paused when the gateway returns a Retry-After
+	// backoff. The pause starts at SaturationPause and doubles while the model stays saturated, up to
+	// SaturationMaxPause; dispatch resumes automatically when the pause ends.
+	SaturationThreshold int           `yaml:"saturation_threshold"`
+	SaturationPause     time.Duration `yaml:"saturation_pause"`
+	SaturationMaxPause  time.Duration `yaml:"saturation_max_pause"`
+
+	// MemoryThrottleRatio throttles the dispatch of lines when the memory used by the processor exceeds this fraction
+	// of MemoryLimit (0 disables throttling): a job doesn't read or start lines while one of its lines is in flight,
+	// until the memory use drops, preventing OOM kills on batches with large lines. MemoryLimit is in bytes; when 0,
+	// the memory limit of the container (cgroup) or GOMEMLIMIT is used, and throttling is disabled without either.
+	// The memory use is sampled at most every MemoryCheckInterval.
+	MemoryThrottleRatio float64       `yaml:"memory_throttle_ratio"`
+	MemoryLimit         int64         `yaml:"memory_limit"`
+	MemoryCheckInterval time.Duration `yaml:"memory_check_interval"`
+
+	// RateLimits bound the requests per second and the tokens per minute sent to each endpoint (a model served by
+	// the inference gateway), shared by all the jobs of the processor. The limits of the model "*" apply to the
+	// models without limits of their own. Tokens are estimated from the request before it is sent and corrected
+	// with the usage reported in the response. Limits with a target apply to the batches of that inference target
+	// only, the others to the batches without target.
+	RateLimits []RateLimitConfig `yaml:"rate_limits"`
+
+	// InferenceGateways are the inference gateways the requests are sent to, by model, so batches can span several
+	// model-serving stacks. The requests of models not served by any gateway are sent to the gateway serving the
+	// model "*", or to the default inference client when there is none.
+	// Gateways with a target serve the batches created for that inference target (a cluster or region) only, with
+	// their own endpoint, credentials and rate limits; the requests of models no gateway of the target serves fail.
+	InferenceGateways []InferenceGatewayConfig `yaml:"inference_gateways"`
+
+	// SpeculativeGateway is the inference gateway the tail stragglers of a job are sent to as well, to keep the first
+	// response (empty disables speculative retries): once all the lines of a job were dispatched and at most
+	// SpeculativeTailLines are in flight, the lines in flight for longer than SpeculativeDelay are sent again to the
+	// gateway, and the request that didn't complete first is canceled. It must be one of InferenceGateways; only the
+	// stragglers of the batches of its target are sent to it.
+	SpeculativeGateway   string        `yaml:"speculative_gateway"`
+	SpeculativeTailLines int           `yaml:"speculative_tail_lines"`
+	SpeculativeDelay     time.Duration `yaml:"speculative_delay"`
+
+	// RetryMaxAttempts is the maximum number of attempts of an inference request failing with a retryable error
+	// (rate limited or server error), including the first one. Attempts are spaced by an exponential backoff
+	// starting at RetryInitialBackoff and capped at RetryMaxBackoff. Batches can override these with their retry policy.
+	RetryMaxAttempts    int           `yaml:"retry_max_attempts"`
+	RetryInitialBackoff time.Duration `yaml:"retry_initial_backoff"`
+	RetryMaxBackoff     time.Duration `yaml:"retry_max_backoff"`
+
+	// RequestTimeout is the timeout of an inference request attempt, and the maximum timeout of any line.
+	// A line can set a shorter timeout with timeout_seconds. Otherwise, when RequestTimeoutPerToken is set, the
+	// timeout of a line limiting its output tokens (max_tokens) is RequestTimeoutBase plus RequestTimeoutPerToken
+	// per token.
+	RequestTimeout         time.Duration `yaml:"request_timeout"`
+	RequestTimeoutBase     time.Duration `yaml:"request_timeout_base"`
+	RequestTimeoutPerToken time.Duration `yaml:"request_timeout_per_token"`
+
+	// MaxImageBytes is the maximum size of an image file referenced by a chat completion request (image_url.file_id),
+	// inlined as a base64 data URL before the request is sent. MaxLinePayloadBytes is the maximum size of a request
+	// once its images are inlined (0 means no limit). The lines above the limits fail.
+	MaxImageBytes       int64 `yaml:"max_image_bytes"`
+	MaxLinePayloadBytes int64 `yaml:"max_line_payload_bytes"`
+
+	// DedupCacheTTL caches the successful responses in the status store (Redis) for this time, keyed by the hash of
+	// the endpoint and the body of their request, so the identical requests of the same or other batches reuse the
+	// response instead of being sent to the model (0 disables the cache). It suits deterministic workloads such as
+	// evaluations, as the responses of sampled requests are reused as well. Responses larger than
+	// DedupMaxResponseBytes are not cached.
+	DedupCacheTTL         time.Duration `yaml:"dedup_cache_ttl"`
+	DedupMaxResponseBytes int           `yaml:"dedup_max_response_bytes"`
+
+	// AbortFailureRatio fails a job early when more than this fraction of its first AbortSampleLines lines fail with
+	// non-retryable errors (e.g. invalid lines or requests), as its payloads are likely systematically bad
+	// (0 disables aborting). The output and error files of the lines processed until then are kept.
+	AbortFailureRatio float64 `yaml:"abort_failure_ratio"`
+	AbortSampleLines  int     `yaml:"abort_sample_lines"`
+
+	// ProcessorID identifies the processor instance in the heartbeats of its workers. Defaults to the host name.
+	ProcessorID string `yaml:"processor_id"`
+
+	// LeaseTTL is the visibility timeout of a dequeued job. The lease is renewed while the job is processed;
+	// if the processor crashes, the lease expires and the job is returned to the queue for another processor.
+	LeaseTTL time.Duration `yaml:"lease_ttl"`
+
+	// StuckBatchTimeout expires the batches still validating, in progress or finalizing this long after the end of
+	// their completion window while no worker processes them, e.g. their queue entry was lost (0 disables it).
+	// A batch still queued past its completion window is expired as soon as it is dequeued, so a stuck batch would
+	// otherwise never reach a final status. The batches are checked every StuckBatchCheckInterval.
+	StuckBatchTimeout       time.Duration `yaml:"stuck_batch_timeout"`
+	StuckBatchCheckInterval time.Duration `yaml:"stuck_batch_check_interval"`
+
+	// DrainTimeout bounds the time the requests in flight are given to finish on shutdown. Lines that were not
+	// started are left for the next delivery of the job, which resumes it from the results stored when draining.
+	// It should leave time to store the results within the termination grace period of the pod.
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+
+	// DrainEndpointEnabled serves the drain endpoint on the observability address, for the preStop hook of the
+	// pod: it puts the processor into drain mode and blocks until the jobs in progress are back in the queue.
+	DrainEndpointEnabled bool `yaml:"drain_endpoint_enabled"`
+	// DrainServiceAccounts are the Kubernetes service accounts (system:serviceaccount:<namespace>:<name>) allowed
+	// to call the drain endpoint with their token, authenticated with the TokenReview API of the cluster, so the
+	// automation of the cluster can drain processors without static keys. The endpoint is open when empty.
+	DrainServiceAccounts []string `yaml:"drain_service_accounts"`
+
+	// EventFlushTimeout bounds the time the pending lifecycle events are given to be forwarded to the event sinks
+	// once the workers stopped. ShutdownTimeout bounds the time the requests in flight to the observability server
+	// are given to finish. The processor exits within about DrainTimeout + EventFlushTimeout of a shutdown signal.
+	EventFlushTimeout time.Duration `yaml:"event_flush_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+
+	// ShardLines splits the jobs with more input lines into shards of ShardLines lines (0 disables sharding).
+	// The shards are queued as tasks of their own, so the processor replicas process the lines of a large job
+	// together; once all its shards are processed, the job is queued again and their results are merged.
+	// The request counts of a sharded job are updated when its shards are merged.
+	ShardLines int64 `yaml:"shard_lines"`
+
+	// PrefetchInput leases the next job ahead of a free worker once a job in progress dispatched all its lines while
+	// all the workers are busy, and downloads and validates its input file while the lines in flight complete, so
+	// the job is started without waiting for its input as soon as a worker is released.
+	PrefetchInput bool `yaml:"prefetch_input"`
+
+	// MaxDeliveryAttempts is the number of times a job is dequeued and fails to be processed (e.g. its data can't
+	// be fetched, its processing panics, or its lease expires) before it is moved to the dead-letter queue
+	MaxDeliveryAttempts int `yaml:"max_delivery_attempts"`
+	// RequeueBackoff is the delay before a job that failed to be processed is returned to the queue, doubled at
+	// every delivery attempt up to RequeueMaxBackoff. The delay is kept by the queue, so a requeued job isn't lost
+	// if the processor exits meanwhile. Jobs are requeued immediately when 0.
+	RequeueBackoff    time.Duration `yaml:"requeue_backoff"`
+	RequeueMaxBackoff time.Duration `yaml:"requeue_max_backoff"`
+
+	// Queues are the priority queues the processor consumes jobs from, e.g. per priority class or per tenant.
+	// When jobs are waiting in several queues, each queue gets a share of the dequeued jobs proportional to its
+	// weight; a queue without waiting jobs doesn't hold back the others.
+	Queues []QueueConfig `yaml:"queues"`
+	// PartitionCount partitions the consumption of the queues among the processor replicas: a processor only
+	// leases the jobs whose ID hashes to its PartitionIndex, so each job goes to a predictable replica. The jobs of a
+	// partition wait while its processor is down. PartitionCount 0 or 1 disables partitioning. PartitionIndex -1 takes
+	// the index from the ordinal suffix of the host name, e.g. 2 for the pod batch-processor-2 of a StatefulSet.
+	PartitionIndex int `yaml:"partition_index"`
+	PartitionCount int `yaml:"partition_count"`
+	// QueueMetricsInterval is how often the depth, delayed and leased jobs, and age of the oldest waiting job of
+	// the queues are read and exported as gauges (0 disables the gauges).
+	QueueMetricsInterval time.Duration `yaml:"queue_metrics_interval"`
+
+	// PollMinInterval and PollInterval bound the time the processor waits before polling the queues again when
+	// they have no jobs: the wait starts at PollMinInterval and doubles while the queues stay empty, up to
+	// PollInterval. The queues are polled again immediately after a job is dequeued.
+	PollMinInterval time.Duration `yaml:"poll_min_interval"`
+	PollInterval    time.Duration `yaml:"poll_interval"`
+
+	// ConfigReloadInterval is how often the configuration file is checked for changes (0 disables reloading).
+	// Changes to NumWorkers, PollInterval, RateLimits and Features are applied without restarting the processor;
+	// the other settings take effect on restart.
+	ConfigReloadInterval time.Duration `yaml:"config_reload_interval"`
 
 	// QueueTimeBucket defines exponential bucket configs for queue wait time metric
 	QueueTimeBucket BucketConfig `yaml:"queue_time_bucket"`
@@ -45,9 +219,161 @@ type ProcessorConfig struct {
 	// ProcessTimeBucket defines exponential bucket configs for process time metric
 	ProcessTimeBucket BucketConfig `yaml:"process_time_bucket"`
 
+	// ProgressUpdateInterval defines how frequently the request counts of a job in progress are written to the database.
+	// Progress is only written when the job finishes if 0.
+	ProgressUpdateInterval time.Duration `yaml:"progress_update_interval"`
+
+	// ProgressEventLines and ProgressEventInterval define how often progress events of a job in progress are
+	// published to the event channel, e.g. for progress streaming and webhooks: every ProgressEventLines processed
+	// lines and every ProgressEventInterval (0 disables the trigger). No events are published if both are 0.
+	ProgressEventLines    int           `yaml:"progress_event_lines"`
+	ProgressEventInterval time.Duration `yaml:"progress_event_interval"`
+
+	// EventSinks receive the lifecycle events of the batches, i.e. their changes of status, so external systems
+	// can react to them without polling the API.
+	EventSinks []EventSinkConfig `yaml:"event_sinks"`
+
+	// EventArchive stores the lifecycle events of the batches in the files store, as a durable history for audits
+	// and replay.
+	EventArchive EventArchiveConfig `yaml:"event_archive"`
+
+	// OutputShardMaxLines is the maximum number of lines per output and error file shard (0 means no limit)
+	OutputShardMaxLines int64 `yaml:"output_shard_max_lines"`
+
+	// OutputShardMaxBytes is the maximum size in bytes of an output and error file shard (0 means no limit)
+	OutputShardMaxBytes int64 `yaml:"output_shard_max_bytes"`
+
+	// Chaos injects faults into the inference requests, to exercise the resilience of the processor in tests.
+	// It is ignored unless the processor is started in chaos mode.
+	Chaos ChaosConfig `yaml:"chaos"`
+
 	Addr        string `yaml:"addr"`
 	SSLCertFile string `yaml:"ssl_cert_file"`
 	SSLKeyFile  string `yaml:"ssl_key_file"`
+
+	// SSLClientCAFile is the CA used to verify client certificates of the observability server.
+	// Client certificates are not required when empty.
+	SSLClientCAFile string `yaml:"ssl_client_ca_file"`
+}
+
+// DefaultQueueName is the name of the queue the processor consumes from when no queues are configured.
+const DefaultQueueName = "default"
+
+type QueueConfig struct {
+	Name   string `yaml:"name"`
+	Weight int    `yaml:"weight"`
+}
+
+// DefaultRateLimitModel is the model name of the rate limits applying to models without limits of their own.
+const DefaultRateLimitModel = "*"
+
+type RateLimitConfig struct {
+	// Target is the inference target the limits apply to, empty for the batches without target
+	Target            string  `yaml:"target"`
+	Model             string  `yaml:"model"`
+	RequestsPerSecond float64 `yaml:"requests_per_second"` // 0 means no limit
+	TokensPerMinute   int     `yaml:"tokens_per_minute"`   // 0 means no limit
+}
+
+// The formats of the events sent to event sinks: JSON objects, or CloudEvents in structured mode.
+const (
+	EventFormatJSON        = "json"
+	EventFormatCloudEvents = "cloudevents"
+)
+
+// eventSinkStatuses are the statuses the processor moves batches to.
+var eventSinkStatuses = []openai.BatchStatus{
+	openai.BatchStatusInProgress,
+	openai.BatchStatusFinalizing,
+	openai.BatchStatusCompleted,
+	openai.BatchStatusFailed,
+	openai.BatchStatusExpired,
+}
+
+type EventSinkConfig struct {
+	Name string `yaml:"name"`
+	// URL is the HTTP endpoint the events are POSTed to
+	URL string `yaml:"url"`
+	// Format is the format of the events, one of the EventFormat constants; JSON when empty
+	Format string `yaml:"format"`
+	// Statuses are the batch statuses whose events are sent, all when empty
+	Statuses []string `yaml:"statuses"`
+	// AuthToken is the token sent to the sink as bearer token, or a reference to it, see settings.Secret.
+	// AuthTokenFile is the file holding it. None is sent when both are empty.
+	AuthToken     settings.Secret `yaml:"auth_token"`
+	AuthTokenFile string          `yaml:"auth_token_file"`
+	// Timeout bounds each delivery attempt of an event, 10s when 0
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// EventArchiveConfig configures the archive of the lifecycle events of the batches. The events are buffered and
+// written every Interval as gzip-compressed JSONL objects under Prefix/<YYYY-MM-DD>/, by the day of the events.
+type EventArchiveConfig struct {
+	// Interval is the time between two writes of the buffered events, the archive is disabled when 0
+	Interval time.Duration `yaml:"interval"`
+	// Prefix is the location of the archive in the files store
+	Prefix string `yaml:"prefix"`
+	// MaxBufferedEvents bounds the events kept while the files store is unavailable, the oldest are dropped
+	MaxBufferedEvents int `yaml:"max_buffered_events"`
+}
+
+// DefaultGatewayModel is the model served by the gateway receiving the requests of models not served by another one.
+const DefaultGatewayModel = "*"
+
+type InferenceGatewayConfig struct {
+	Name string `yaml:"name"`
+	// Target is the inference target whose batches the gateway serves, empty for the batches without target
+	Target string `yaml:"target"`
+	// URL is the base URL of the gateway, the request path (e.g. /v1/chat/completions) is appended to it
+	URL    string   `yaml:"url"`
+	Models []string `yaml:"models"`
+
+	// Provider is the API of the gateway, one of the Provider constants; OpenAI when empty. The requests and
+	// responses of the other providers are translated from and to the OpenAI chat completions API.
+	Provider string `yaml:"provider"`
+	// ProviderModels maps the models of the batches to the model IDs of the provider, the models not listed
+	// keep their name
+	ProviderModels map[string]string `yaml:"provider_models"`
+
+	// APIKey is the API key sent to the gateway as bearer token, or a reference to it, see settings.Secret.
+	// APIKeyFile is the file holding it. None is sent when both are empty.
+	APIKey     settings.Secret `yaml:"api_key"`
+	APIKeyFile string          `yaml:"api_key_file"`
+	// TLS settings of the connections to the gateway; the system CAs are used when CACertFile is empty
+	CACertFile         string `yaml:"ca_cert_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+
+	// the retry settings of the requests sent to the gateway, 0 keeps the processor's
+	RetryMaxAttempts    int           `yaml:"retry_max_attempts"`
+	RetryInitialBackoff time.Duration `yaml:"retry_initial_backoff"`
+	RetryMaxBackoff     time.Duration `yaml:"retry_max_backoff"`
+}
+
+// The APIs of the inference gateways. Bedrock and Vertex AI serve Anthropic models with the Anthropic messages
+// API (InvokeModel and rawPredict); the API key of their gateway is sent as bearer token. The URL of a Vertex AI
+// gateway includes the project and the location, e.g. https://us-east5-aiplatform.googleapis.com/v1/projects/p/locations/us-east5.
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+	ProviderBedrock   = "bedrock"
+	ProviderVertex    = "vertex"
+)
+
+// ChaosConfig sets the rates of the faults injected into the inference requests in chaos mode.
+// Rates are fractions of the requests, from 0 to 1.
+type ChaosConfig struct {
+	// FailureRate fails requests with FailureStatusCode, without sending them
+	FailureRate       float64 `yaml:"failure_rate"`
+	FailureStatusCode int     `yaml:"failure_status_code"`
+	// DelayRate delays requests by Delay before they are sent
+	DelayRate float64       `yaml:"delay_rate"`
+	Delay     time.Duration `yaml:"delay"`
+	// CrashRate exits the processor abruptly, without draining, when a request is sent
+	CrashRate float64 `yaml:"crash_rate"`
+	// Seed makes the injected faults reproducible, a random seed is used when 0
+	Seed int64 `yaml:"seed"`
 }
 
 type BucketConfig struct {
@@ -60,25 +386,15 @@ func (pc *ProcessorConfig) SSLEnabled() bool {
 	return pc.SSLCertFile != "" && pc.SSLKeyFile != ""
 }
 
-// LoadFromYaml loads the configuration from a YAML file.
+// LoadFromYAML loads the common blocks and the processor section of a configuration file. Unknown keys are errors.
 func (pc *ProcessorConfig) LoadFromYAML(filePath string) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	decoder := yaml.NewDecoder(file)
-	if err := decoder.Decode(pc); err != nil {
-		return err
-	}
-	return nil
+	return settings.Load(filePath, settings.SectionProcessor, &pc.Common, pc)
 }
 
 // NewConfig returns a new ProcessorConfig with default values.
-// TaskWaitTime has to be shorter than poll interval
 func NewConfig() *ProcessorConfig {
 	return &ProcessorConfig{
+		Common:       settings.NewCommon(),
 		PollInterval: 5 * time.Second,
 		TaskWaitTime: 1 * time.Second,
 		ProcessTimeBucket: BucketConfig{
@@ -92,13 +408,58 @@ func NewConfig() *ProcessorConfig {
 			BucketCount:  10,
 		},
 
-		MaxJobConcurrency: 10,
-		NumWorkers:        1,
-		Addr:              ":9090",
+		MaxJobConcurrency:       10,
+		MaxDeliveryAttempts:     3,
+		RequeueBackoff:          5 * time.Second,
+		RequeueMaxBackoff:       5 * time.Minute,
+		LeaseTTL:                time.Minute,
+		DrainTimeout:            20 * time.Second,
+		EventFlushTimeout:       10 * time.Second,
+		ShutdownTimeout:         5 * time.Second,
+		StuckBatchTimeout:       time.Hour,
+		StuckBatchCheckInterval: 10 * time.Minute,
+		PrefetchInput:           true,
+		PollMinInterval:         100 * time.Millisecond,
+		QueueMetricsInterval:    15 * time.Second,
+		ConfigReloadInterval:    10 * time.Second,
+		RequestTimeout:          10 * time.Minute,
+		RequestTimeoutBase:      30 * time.Second,
+		RequestTimeoutPerToken:  50 * time.Millisecond,
+		RetryMaxAttempts:        3,
+		SpeculativeTailLines:    10,
+		SpeculativeDelay:        30 * time.Second,
+		ProgressEventLines:      1000,
+		ProgressEventInterval:   30 * time.Second,
+		AbortSampleLines:        100,
+		MaxImageBytes:           20 * 1024 * 1024,
+		MaxLinePayloadBytes:     50 * 1024 * 1024,
+		DedupMaxResponseBytes:   1024 * 1024,
+		RetryInitialBackoff:     time.Second,
+		RetryMaxBackoff:         30 * time.Second,
+		Queues:                  []QueueConfig{{Name: DefaultQueueName, Weight: 1}},
+		PartitionIndex:          -1,
+		SaturationThreshold:     5,
+		SaturationPause:         time.Second,
+		SaturationMaxPause:      time.Minute,
+		MemoryThrottleRatio:     0.85,
+		MemoryCheckInterval:     100 * time.Millisecond,
+		NumWorkers:              1,
+		MinWorkers:              1,
+		AutoscaleInterval:       10 * time.Second,
+		AutoscaleMaxErrorRate:   0.2,
+		ProgressUpdateInterval:  5 * time.Second,
+		OutputShardMaxLines:     1000000,
+		OutputShardMaxBytes:     500 * 1024 * 1024,
+		EventArchive:            EventArchiveConfig{Prefix: "events", MaxBufferedEvents: 100000},
+		Addr:                    ":9090",
+		Chaos:                   ChaosConfig{FailureStatusCode: 503},
 	}
 }
 
 func (c *ProcessorConfig) Validate() error {
+	if err := c.Common.Validate(); err != nil {
+		return err
+	}
 	if c.SSLEnabled() {
 		if _, err := os.Stat(c.SSLCertFile); err != nil {
 			return err
@@ -107,5 +468,282 @@ func (c *ProcessorConfig) Validate() error {
 			return err
 		}
 	}
+	if c.SSLClientCAFile != "" {
+		if !c.SSLEnabled() {
+			return fmt.Errorf("ssl_client_ca_file requires ssl_cert_file and ssl_key_file")
+		}
+		if _, err := os.Stat(c.SSLClientCAFile); err != nil {
+			return err
+		}
+	}
+	if c.MaxConcurrentRequests < 0 {
+		return fmt.Errorf("max_concurrent_requests cannot be negative")
+	}
+	if c.SaturationThreshold < 0 {
+		return fmt.Errorf("saturation_threshold cannot be negative")
+	}
+	if c.SaturationThreshold > 0 && (c.SaturationPause <= 0 || c.SaturationMaxPause < c.SaturationPause) {
+		return fmt.Errorf("saturation_pause must be positive and not greater than saturation_max_pause")
+	}
+	if c.RetryMaxAttempts < 1 {
+		return fmt.Errorf("retry_max_attempts must be at least 1")
+	}
+	if c.RetryInitialBackoff <= 0 || c.RetryMaxBackoff < c.RetryInitialBackoff {
+		return fmt.Errorf("retry_initial_backoff must be positive and not greater than retry_max_backoff")
+	}
+	// the gateways of a target serve distinct models, and so do the gateways without target
+	type targetModel struct{ target, model string }
+	gatewayNames := make(map[string]bool, len(c.InferenceGateways))
+	gatewayTargets := map[string]bool{}
+	gatewayModels := map[targetModel]string{}
+	for _, gateway := range c.InferenceGateways {
+		if gateway.Name == "" {
+			return fmt.Errorf("inference gateway name must not be empty")
+		}
+		if gatewayNames[gateway.Name] {
+			return fmt.Errorf("inference gateway %q is configured more than once", gateway.Name)
+		}
+		gatewayNames[gateway.Name] = true
+		if u, err := url.Parse(gateway.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url of inference gateway %q must be an http or https URL", gateway.Name)
+		}
+		if len(gateway.Models) == 0 {
+			return fmt.Errorf("inference gateway %q must serve at least one model", gateway.Name)
+		}
+		if gateway.Target != "" {
+			gatewayTargets[gateway.Target] = true
+		}
+		for _, model := range gateway.Models {
+			if model == "" {
+				return fmt.Errorf("models of inference gateway %q must not be empty", gateway.Name)
+			}
+			key := targetModel{gateway.Target, model}
+			if other, ok := gatewayModels[key]; ok {
+				return fmt.Errorf("model %q is served by inference gateways %q and %q", model, other, gateway.Name)
+			}
+			gatewayModels[key] = gateway.Name
+		}
+		switch gateway.Provider {
+		case "", ProviderOpenAI, ProviderAnthropic, ProviderBedrock, ProviderVertex:
+		default:
+			return fmt.Errorf("provider %q of inference gateway %q is not supported", gateway.Provider, gateway.Name)
+		}
+		if gateway.APIKey.IsSet() && gateway.APIKeyFile != "" {
+			return fmt.Errorf("api_key and api_key_file of inference gateway %q cannot be set together", gateway.Name)
+		}
+		if gateway.APIKeyFile != "" {
+			if _, err := os.Stat(gateway.APIKeyFile); err != nil {
+				return err
+			}
+		}
+		if (gateway.CertFile == "") != (gateway.KeyFile == "") {
+			return fmt.Errorf("cert_file and key_file of inference gateway %q must be set together", gateway.Name)
+		}
+		if gateway.RetryMaxAttempts < 0 || gateway.RetryInitialBackoff < 0 || gateway.RetryMaxBackoff < 0 {
+			return fmt.Errorf("retry settings of inference gateway %q cannot be negative", gateway.Name)
+		}
+		initialBackoff, maxBackoff := c.RetryInitialBackoff, c.RetryMaxBackoff
+		if gateway.RetryInitialBackoff > 0 {
+			initialBackoff = gateway.RetryInitialBackoff
+		}
+		if gateway.RetryMaxBackoff > 0 {
+			maxBackoff = gateway.RetryMaxBackoff
+		}
+		if maxBackoff < initialBackoff {
+			return fmt.Errorf("retry_initial_backoff of inference gateway %q must not be greater than its retry_max_backoff", gateway.Name)
+		}
+	}
+	rateLimitModels := make(map[targetModel]bool, len(c.RateLimits))
+	for _, limit := range c.RateLimits {
+		if limit.Model == "" {
+			return fmt.Errorf("rate limit model must not be empty")
+		}
+		if limit.Target != "" && !gatewayTargets[limit.Target] {
+			return fmt.Errorf("rate limits of model %q are set for target %q, which no inference gateway serves", limit.Model, limit.Target)
+		}
+		key := targetModel{limit.Target, limit.Model}
+		if rateLimitModels[key] {
+			return fmt.Errorf("rate limits of model %q are configured more than once", limit.Model)
+		}
+		rateLimitModels[key] = true
+		if limit.RequestsPerSecond < 0 || limit.TokensPerMinute < 0 {
+			return fmt.Errorf("rate limits of model %q cannot be negative", limit.Model)
+		}
+	}
+	for _, account := range c.DrainServiceAccounts {
+		if !strings.HasPrefix(account, kube.ServiceAccountPrefix) {
+			return fmt.Errorf("drain_service_accounts must be %s<namespace>:<name> usernames, got %q", kube.ServiceAccountPrefix, account)
+		}
+	}
+	if c.SpeculativeGateway != "" {
+		if !gatewayNames[c.SpeculativeGateway] {
+			return fmt.Errorf("speculative_gateway %q is not an inference gateway", c.SpeculativeGateway)
+		}
+		if c.SpeculativeTailLines < 1 || c.SpeculativeDelay <= 0 {
+			return fmt.Errorf("speculative_tail_lines and speculative_delay must be positive when speculative_gateway is set")
+		}
+	}
+	if c.MemoryThrottleRatio < 0 || c.MemoryThrottleRatio >= 1 {
+		return fmt.Errorf("memory_throttle_ratio must be between 0 and 1")
+	}
+	if c.MemoryLimit < 0 {
+		return fmt.Errorf("memory_limit must not be negative")
+	}
+	if c.MemoryThrottleRatio > 0 && c.MemoryCheckInterval <= 0 {
+		return fmt.Errorf("memory_check_interval must be positive when memory_throttle_ratio is set")
+	}
+	if c.RequestTimeout <= 0 {
+		return fmt.Errorf("request_timeout must be positive")
+	}
+	if c.RequestTimeoutBase < 0 || c.RequestTimeoutPerToken < 0 {
+		return fmt.Errorf("request_timeout_base and request_timeout_per_token cannot be negative")
+	}
+	if c.MaxImageBytes <= 0 {
+		return fmt.Errorf("max_image_bytes must be positive")
+	}
+	if c.MaxLinePayloadBytes < 0 {
+		return fmt.Errorf("max_line_payload_bytes must not be negative")
+	}
+	if c.DedupCacheTTL < 0 {
+		return fmt.Errorf("dedup_cache_ttl must not be negative")
+	}
+	if c.DedupCacheTTL > 0 && c.DedupMaxResponseBytes <= 0 {
+		return fmt.Errorf("dedup_max_response_bytes must be positive when dedup_cache_ttl is set")
+	}
+	if c.AbortFailureRatio < 0 || c.AbortFailureRatio >= 1 {
+		return fmt.Errorf("abort_failure_ratio must be between 0 and 1")
+	}
+	if c.AbortFailureRatio > 0 && c.AbortSampleLines < 1 {
+		return fmt.Errorf("abort_sample_lines must be at least 1 when abort_failure_ratio is set")
+	}
+	if c.ProgressEventLines < 0 || c.ProgressEventInterval < 0 {
+		return fmt.Errorf("progress_event_lines and progress_event_interval cannot be negative")
+	}
+	sinkNames := make(map[string]bool, len(c.EventSinks))
+	for _, sink := range c.EventSinks {
+		if sink.Name == "" {
+			return fmt.Errorf("event sink name must not be empty")
+		}
+		if sinkNames[sink.Name] {
+			return fmt.Errorf("event sink %q is configured more than once", sink.Name)
+		}
+		sinkNames[sink.Name] = true
+		if u, err := url.Parse(sink.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("url of event sink %q must be an http or https URL", sink.Name)
+		}
+		switch sink.Format {
+		case "", EventFormatJSON, EventFormatCloudEvents:
+		default:
+			return fmt.Errorf("format of event sink %q must be one of %s, %s", sink.Name, EventFormatJSON, EventFormatCloudEvents)
+		}
+		for _, status := range sink.Statuses {
+			if !slices.Contains(eventSinkStatuses, openai.BatchStatus(status)) {
+				return fmt.Errorf("event sink %q has unknown status %q", sink.Name, status)
+			}
+		}
+		if sink.AuthToken.IsSet() && sink.AuthTokenFile != "" {
+			return fmt.Errorf("auth_token and auth_token_file of event sink %q cannot be set together", sink.Name)
+		}
+		if sink.Timeout < 0 {
+			return fmt.Errorf("timeout of event sink %q cannot be negative", sink.Name)
+		}
+	}
+	if c.EventArchive.Interval < 0 {
+		return fmt.Errorf("event_archive.interval cannot be negative")
+	}
+	if c.EventArchive.Interval > 0 && (c.EventArchive.Prefix == "" || c.EventArchive.MaxBufferedEvents <= 0) {
+		return fmt.Errorf("event_archive.prefix and event_archive.max_buffered_events must be set when event_archive.interval is set")
+	}
+	if c.ConfigReloadInterval < 0 {
+		return fmt.Errorf("config_reload_interval must not be negative")
+	}
+	if c.LeaseTTL <= 0 {
+		return fmt.Errorf("lease_ttl must be positive")
+	}
+	if c.StuckBatchTimeout < 0 {
+		return fmt.Errorf("stuck_batch_timeout must not be negative")
+	}
+	if c.StuckBatchTimeout > 0 && c.StuckBatchCheckInterval <= 0 {
+		return fmt.Errorf("stuck_batch_check_interval must be positive when stuck_batch_timeout is set")
+	}
+	if c.DrainTimeout < 0 {
+		return fmt.Errorf("drain_timeout must not be negative")
+	}
+	if c.EventFlushTimeout <= 0 {
+		return fmt.Errorf("event_flush_timeout must be positive")
+	}
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("shutdown_timeout must be positive")
+	}
+	if c.QueueMetricsInterval < 0 {
+		return fmt.Errorf("queue_metrics_interval cannot be negative")
+	}
+	if c.PollMinInterval <= 0 || c.PollInterval < c.PollMinInterval {
+		return fmt.Errorf("poll_min_interval must be positive and not greater than poll_interval")
+	}
+	for name, rate := range map[string]float64{
+		"chaos.failure_rate": c.Chaos.FailureRate, "chaos.delay_rate": c.Chaos.DelayRate, "chaos.crash_rate": c.Chaos.CrashRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1", name)
+		}
+	}
+	if c.Chaos.FailureRate > 0 && (c.Chaos.FailureStatusCode < 400 || c.Chaos.FailureStatusCode > 599) {
+		return fmt.Errorf("chaos.failure_status_code must be an HTTP error status code")
+	}
+	if c.Chaos.Delay < 0 {
+		return fmt.Errorf("chaos.delay must not be negative")
+	}
+	if c.ShardLines < 0 {
+		return fmt.Errorf("shard_lines must not be negative")
+	}
+	if c.TaskWaitTime < 0 {
+		return fmt.Errorf("task_wait_time must not be negative")
+	}
+	if c.MaxDeliveryAttempts < 1 {
+		return fmt.Errorf("max_delivery_attempts must be at least 1")
+	}
+	if c.RequeueBackoff < 0 {
+		return fmt.Errorf("requeue_backoff cannot be negative")
+	}
+	if c.RequeueBackoff > 0 && c.RequeueMaxBackoff < c.RequeueBackoff {
+		return fmt.Errorf("requeue_max_backoff must be at least requeue_backoff")
+	}
+	if len(c.Queues) == 0 {
+		return fmt.Errorf("at least one queue must be configured")
+	}
+	queueNames := make(map[string]bool, len(c.Queues))
+	for _, queue := range c.Queues {
+		if queue.Name == "" {
+			return fmt.Errorf("queue name must not be empty")
+		}
+		if queueNames[queue.Name] {
+			return fmt.Errorf("queue %q is configured more than once", queue.Name)
+		}
+		queueNames[queue.Name] = true
+		if queue.Weight < 1 {
+			return fmt.Errorf("weight of queue %q must be at least 1", queue.Name)
+		}
+	}
+	if c.PartitionCount < 0 {
+		return fmt.Errorf("partition_count must not be negative")
+	}
+	if c.PartitionCount > 1 && (c.PartitionIndex < -1 || c.PartitionIndex >= c.PartitionCount) {
+		return fmt.Errorf("partition_index must be between 0 and partition_count - 1, or -1 for the host name ordinal")
+	}
+	if c.AutoscaleEnabled {
+		if c.MinWorkers < 1 || c.MinWorkers > c.NumWorkers {
+			return fmt.Errorf("min_workers must be between 1 and num_workers")
+		}
+		if c.AutoscaleInterval <= 0 {
+			return fmt.Errorf("autoscale_interval must be positive")
+		}
+		if c.AutoscaleMaxErrorRate < 0 || c.AutoscaleMaxErrorRate > 1 {
+			return fmt.Errorf("autoscale_max_error_rate must be between 0 and 1")
+		}
+	}
+	if c.OutputShardMaxLines < 0 || c.OutputShardMaxBytes < 0 {
+		return fmt.Errorf("output_shard_max_lines and output_shard_max_bytes must not be negative")
+	}
 	return nil
 }
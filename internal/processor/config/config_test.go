@@ -0,0 +1,76 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The unit tests of the processor's configuration.
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadFromYAML(t *testing.T) {
+	t.Run("ConfigFiles", func(t *testing.T) {
+		// the configuration files of the repository, the API server's only has the common blocks for the processor
+		for _, path := range []string{"../../../cmd/batch-processor/config.yaml", "../../../cmd/apiserver/config.yaml"} {
+			cfg := NewConfig()
+			if err := cfg.LoadFromYAML(path); err != nil {
+				t.Fatalf("LoadFromYAML(%s) error = %v", path, err)
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Errorf("Validate() of %s error = %v", path, err)
+			}
+		}
+	})
+
+	t.Run("Env", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte("file_store:\n  dir: /data/files\nprocessor:\n  num_workers: 4\n"), 0o600); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+		t.Setenv("BATCH_GATEWAY_PROCESSOR_NUM_WORKERS", "8")
+		t.Setenv("BATCH_GATEWAY_PROCESSOR_EVENT_ARCHIVE_INTERVAL", "5m")
+		t.Setenv("BATCH_GATEWAY_FILE_STORE_DIR", "/mnt/files")
+		cfg := NewConfig()
+		if err := cfg.LoadFromYAML(path); err != nil {
+			t.Fatalf("LoadFromYAML() error = %v", err)
+		}
+		if cfg.NumWorkers != 8 || cfg.EventArchive.Interval != 5*time.Minute || cfg.FileStore.Dir != "/mnt/files" {
+			t.Errorf("environment variables not applied: num_workers %d, event_archive.interval %s, file_store.dir %s",
+				cfg.NumWorkers, cfg.EventArchive.Interval, cfg.FileStore.Dir)
+		}
+	})
+
+	t.Run("Flat", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte("num_workers: 4\nfiles_dir: /data/files\n"), 0o600); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+		err := NewConfig().LoadFromYAML(path)
+		if err == nil {
+			t.Fatal("LoadFromYAML() of a file without sections succeeded")
+		}
+		for _, want := range []string{`did you mean "processor.num_workers"?`, `did you mean "file_store.dir"?`} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("LoadFromYAML() error = %v, want %q", err, want)
+			}
+		}
+	})
+}
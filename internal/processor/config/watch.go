@@ -0,0 +1,74 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The watcher reloading the processor's configuration file when it changes.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// Watch checks the configuration file every interval until ctx is done, and calls apply with the new configuration
+// whenever the content of the file changes. The file is compared by content rather than modification time, as
+// a mounted ConfigMap is updated by swapping a symbolic link. An invalid configuration is logged and not applied.
+func Watch(ctx context.Context, filePath string, interval time.Duration, apply func(context.Context, *ProcessorConfig)) {
+	logger := klog.FromContext(ctx)
+	last, err := os.ReadFile(filePath)
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to read config file, changes are not reloaded", "path", filePath)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			// the file may be briefly missing while it is replaced
+			logger.V(logging.DEBUG).Info("Failed to read config file", "path", filePath, "err", err)
+			continue
+		}
+		if bytes.Equal(data, last) {
+			continue
+		}
+		last = data
+
+		cfg := NewConfig()
+		if err := cfg.LoadFromYAML(filePath); err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to load changed config file, keeping the current configuration", "path", filePath)
+			continue
+		}
+		if err := cfg.Validate(); err != nil {
+			logger.V(logging.ERROR).Error(err, "Invalid changed config, keeping the current configuration", "path", filePath)
+			continue
+		}
+		logger.V(logging.INFO).Info("Config file changed, reloading", "path", filePath)
+		apply(ctx, cfg)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The unit tests of the configuration watcher.
+
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("processor:\n  num_workers: 2\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	applied := make(chan *ProcessorConfig, 10)
+	go Watch(ctx, path, 5*time.Millisecond, func(_ context.Context, cfg *ProcessorConfig) { applied <- cfg })
+	// let the watcher read the initial content before changing it
+	time.Sleep(20 * time.Millisecond)
+
+	expectApplied := func(numWorkers int) {
+		t.Helper()
+		select {
+		case cfg := <-applied:
+			if cfg.NumWorkers != numWorkers {
+				t.Errorf("NumWorkers = %d, want %d", cfg.NumWorkers, numWorkers)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("changed config not applied")
+		}
+	}
+
+	os.WriteFile(path, []byte("processor:\n  num_workers: 4\n"), 0o600)
+	expectApplied(4)
+
+	// an invalid config is not applied
+	os.WriteFile(path, []byte("processor:\n  num_workers: 4\n  lease_ttl: \"0s\"\n"), 0o600)
+	os.WriteFile(path+".tmp", []byte("processor:\n  num_workers: 8\n"), 0o600)
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case cfg := <-applied:
+		t.Fatalf("invalid config applied: %+v", cfg)
+	default:
+	}
+
+	// the file is replaced, as when a ConfigMap is updated
+	os.Rename(path+".tmp", path)
+	expectApplied(8)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The adapters translating the requests, responses and errors of the inference providers.
+
+package inference
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+)
+
+// ProviderAdapter translates the OpenAI requests of the batches to the API of an inference provider, and the
+// responses and errors of the provider back to the OpenAI API.
+type ProviderAdapter interface {
+	// Request returns the path, appended to the URL of the gateway, and the body of the request sent to the provider.
+	Request(req *batch.InferenceRequest) (path string, body []byte, err error)
+	// SetHeaders sets the headers of a request, authenticating it with the API key of the gateway when not empty.
+	SetHeaders(header http.Header, apiKey string)
+	// Response returns the OpenAI response of a successful response of the provider.
+	Response(req *batch.InferenceRequest, body []byte) ([]byte, error)
+	// Error returns the message and the OpenAI error body of an error response of the provider, nil if the body
+	// can't be translated.
+	Error(statusCode int, body []byte) (message string, openAIBody []byte)
+}
+
+// NewProviderAdapter returns the adapter of a provider, mapping the models of the batches to the model IDs of the
+// provider with models.
+func NewProviderAdapter(provider string, models map[string]string) (ProviderAdapter, error) {
+	switch provider {
+	case "", config.ProviderOpenAI:
+		return openAIAdapter{}, nil
+	case config.ProviderAnthropic, config.ProviderBedrock, config.ProviderVertex:
+		return &anthropicAdapter{provider: provider, models: models}, nil
+	default:
+		return nil, fmt.Errorf("provider %q is not supported", provider)
+	}
+}
+
+// openAIAdapter sends the requests unchanged to an OpenAI-compatible gateway.
+type openAIAdapter struct{}
+
+func (openAIAdapter) Request(req *batch.InferenceRequest) (string, []byte, error) {
+	body, err := json.Marshal(req.Params)
+	if err != nil {
+		return "", nil, err
+	}
+	endpoint := req.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	return endpoint, body, nil
+}
+
+func (openAIAdapter) SetHeaders(header http.Header, apiKey string) {
+	if apiKey != "" {
+		header.Set("Authorization", "Bearer "+apiKey)
+	}
+}
+
+func (openAIAdapter) Response(_ *batch.InferenceRequest, body []byte) ([]byte, error) {
+	return body, nil
+}
+
+func (openAIAdapter) Error(_ int, body []byte) (string, []byte) {
+	if !json.Valid(body) {
+		return "", nil
+	}
+	return errorMessage(body), body
+}
+
+// errorMessage returns the message of an error body in the OpenAI shape, {"error": {"message": ...}}.
+func errorMessage(body []byte) string {
+	var errResp struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &errResp) != nil {
+		return ""
+	}
+	return errResp.Error.Message
+}
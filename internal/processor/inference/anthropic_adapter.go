@@ -0,0 +1,420 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The adapter translating the OpenAI chat completion requests to the Anthropic messages API, served by Anthropic,
+// Bedrock and Vertex AI.
+
+package inference
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+const (
+	// the versions of the messages API, sent as header to Anthropic and in the body to Bedrock and Vertex AI
+	anthropicVersion        = "2023-06-01"
+	bedrockAnthropicVersion = "bedrock-2023-05-31"
+	vertexAnthropicVersion  = "vertex-2023-10-16"
+
+	// anthropicDefaultMaxTokens is the max_tokens of the requests not limiting their output, as the messages API
+	// requires it
+	anthropicDefaultMaxTokens = 4096
+)
+
+// anthropicAdapter translates the chat completion requests to the messages API of the Anthropic models.
+type anthropicAdapter struct {
+	provider string
+	models   map[string]string
+}
+
+func (a *anthropicAdapter) Request(req *batch.InferenceRequest) (string, []byte, error) {
+	if req.Endpoint != "" && req.Endpoint != DefaultEndpoint {
+		return "", nil, fmt.Errorf("endpoint %s is not supported by the %s provider", req.Endpoint, a.provider)
+	}
+	message, err := anthropicRequest(req.Params)
+	if err != nil {
+		return "", nil, err
+	}
+	model := req.Model
+	if id, ok := a.models[model]; ok {
+		model = id
+	}
+	var path string
+	switch a.provider {
+	case config.ProviderBedrock:
+		path = "/model/" + url.PathEscape(model) + "/invoke"
+		message["anthropic_version"] = bedrockAnthropicVersion
+	case config.ProviderVertex:
+		path = "/publishers/anthropic/models/" + url.PathEscape(model) + ":rawPredict"
+		message["anthropic_version"] = vertexAnthropicVersion
+	default:
+		path = "/v1/messages"
+		message["model"] = model
+	}
+	body, err := json.Marshal(message)
+	return path, body, err
+}
+
+func (a *anthropicAdapter) SetHeaders(header http.Header, apiKey string) {
+	if a.provider != config.ProviderAnthropic {
+		if apiKey != "" {
+			header.Set("Authorization", "Bearer "+apiKey)
+		}
+		return
+	}
+	header.Set("anthropic-version", anthropicVersion)
+	if apiKey != "" {
+		header.Set("x-api-key", apiKey)
+	}
+}
+
+// anthropicRequest returns the messages API request of the parameters of a chat completion request.
+func anthropicRequest(params map[string]interface{}) (map[string]interface{}, error) {
+	message := map[string]interface{}{"max_tokens": anthropicDefaultMaxTokens}
+	for _, key := range []string{"max_tokens", "max_completion_tokens"} {
+		if maxTokens, ok := params[key]; ok {
+			message["max_tokens"] = maxTokens
+		}
+	}
+	for _, key := range []string{"temperature", "top_p"} {
+		if value, ok := params[key]; ok {
+			message[key] = value
+		}
+	}
+	switch stop := params["stop"].(type) {
+	case string:
+		message["stop_sequences"] = []string{stop}
+	case []interface{}:
+		message["stop_sequences"] = stop
+	}
+	if user, ok := params["user"].(string); ok {
+		message["metadata"] = map[string]interface{}{"user_id": user}
+	}
+
+	messages, ok := params["messages"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("messages must be an array")
+	}
+	var system []string
+	var turns []anthropicTurn
+	for i, m := range messages {
+		msg, ok := m.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("messages[%d] must be an object", i)
+		}
+		role, _ := msg["role"].(string)
+		switch role {
+		case "system", "developer":
+			text, err := textContent(msg["content"])
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d]: %w", i, err)
+			}
+			system = append(system, text)
+		case "user", "assistant":
+			blocks, err := contentBlocks(msg["content"])
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d]: %w", i, err)
+			}
+			if role == "assistant" {
+				calls, err := toolUseBlocks(msg["tool_calls"])
+				if err != nil {
+					return nil, fmt.Errorf("messages[%d]: %w", i, err)
+				}
+				blocks = append(blocks, calls...)
+			}
+			turns = appendTurn(turns, role, blocks)
+		case "tool":
+			text, err := textContent(msg["content"])
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d]: %w", i, err)
+			}
+			// the tool results are sent by the user
+			turns = appendTurn(turns, "user", []interface{}{map[string]interface{}{
+				"type": "tool_result", "tool_use_id": msg["tool_call_id"], "content": text,
+			}})
+		default:
+			return nil, fmt.Errorf("messages[%d]: role %q is not supported", i, role)
+		}
+	}
+	message["messages"] = turns
+	if len(system) > 0 {
+		message["system"] = strings.Join(system, "\n\n")
+	}
+
+	if tools, ok := params["tools"].([]interface{}); ok && len(tools) > 0 {
+		translated := make([]interface{}, 0, len(tools))
+		for i, t := range tools {
+			tool, _ := t.(map[string]interface{})
+			function, ok := tool["function"].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("tools[%d] must be a function", i)
+			}
+			schema, ok := function["parameters"]
+			if !ok {
+				schema = map[string]interface{}{"type": "object"}
+			}
+			translatedTool := map[string]interface{}{"name": function["name"], "input_schema": schema}
+			if description, ok := function["description"]; ok {
+				translatedTool["description"] = description
+			}
+			translated = append(translated, translatedTool)
+		}
+		message["tools"] = translated
+	}
+	switch choice := params["tool_choice"].(type) {
+	case string:
+		types := map[string]string{"auto": "auto", "required": "any", "none": "none"}
+		if t, ok := types[choice]; ok {
+			message["tool_choice"] = map[string]interface{}{"type": t}
+		}
+	case map[string]interface{}:
+		if function, ok := choice["function"].(map[string]interface{}); ok {
+			message["tool_choice"] = map[string]interface{}{"type": "tool", "name": function["name"]}
+		}
+	}
+	return message, nil
+}
+
+// anthropicTurn is a message of the messages API.
+type anthropicTurn struct {
+	Role    string        `json:"role"`
+	Content []interface{} `json:"content"`
+}
+
+// appendTurn appends the content blocks of a message, merged with the previous message of the same role as the
+// roles of the messages API must alternate.
+func appendTurn(turns []anthropicTurn, role string, blocks []interface{}) []anthropicTurn {
+	if n := len(turns); n > 0 && turns[n-1].Role == role {
+		turns[n-1].Content = append(turns[n-1].Content, blocks...)
+		return turns
+	}
+	return append(turns, anthropicTurn{Role: role, Content: blocks})
+}
+
+// textContent returns the text of a message content, a string or text parts.
+func textContent(content interface{}) (string, error) {
+	blocks, err := contentBlocks(content)
+	if err != nil {
+		return "", err
+	}
+	texts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		b := block.(map[string]interface{})
+		if b["type"] != "text" {
+			return "", fmt.Errorf("content must be text")
+		}
+		texts = append(texts, b["text"].(string))
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// contentBlocks returns the content blocks of a message content, a string or text and image parts.
+func contentBlocks(content interface{}) ([]interface{}, error) {
+	switch content := content.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []interface{}{map[string]interface{}{"type": "text", "text": content}}, nil
+	case []interface{}:
+		blocks := make([]interface{}, 0, len(content))
+		for i, p := range content {
+			part, _ := p.(map[string]interface{})
+			switch part["type"] {
+			case "text":
+				text, _ := part["text"].(string)
+				blocks = append(blocks, map[string]interface{}{"type": "text", "text": text})
+			case "image_url":
+				imageURL, _ := part["image_url"].(map[string]interface{})
+				u, _ := imageURL["url"].(string)
+				source, err := imageSource(u)
+				if err != nil {
+					return nil, fmt.Errorf("content[%d]: %w", i, err)
+				}
+				blocks = append(blocks, map[string]interface{}{"type": "image", "source": source})
+			default:
+				return nil, fmt.Errorf("content[%d]: type %v is not supported", i, part["type"])
+			}
+		}
+		return blocks, nil
+	default:
+		return nil, fmt.Errorf("content must be a string or an array")
+	}
+}
+
+// imageSource returns the source of an image block of an image URL, a base64 data URL or an http(s) URL.
+func imageSource(u string) (map[string]interface{}, error) {
+	data, ok := strings.CutPrefix(u, "data:")
+	if !ok {
+		return map[string]interface{}{"type": "url", "url": u}, nil
+	}
+	mediaType, data, ok := strings.Cut(data, ";base64,")
+	if !ok {
+		return nil, fmt.Errorf("image data URL must be base64 encoded")
+	}
+	return map[string]interface{}{"type": "base64", "media_type": mediaType, "data": data}, nil
+}
+
+// toolUseBlocks returns the tool use blocks of the tool calls of an assistant message.
+func toolUseBlocks(toolCalls interface{}) ([]interface{}, error) {
+	calls, _ := toolCalls.([]interface{})
+	blocks := make([]interface{}, 0, len(calls))
+	for i, c := range calls {
+		call, _ := c.(map[string]interface{})
+		function, _ := call["function"].(map[string]interface{})
+		arguments, _ := function["arguments"].(string)
+		var input interface{} = map[string]interface{}{}
+		if arguments != "" {
+			if err := json.Unmarshal([]byte(arguments), &input); err != nil {
+				return nil, fmt.Errorf("tool_calls[%d]: arguments must be JSON: %w", i, err)
+			}
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "tool_use", "id": call["id"], "name": function["name"], "input": input,
+		})
+	}
+	return blocks, nil
+}
+
+// anthropicMessage is a response of the messages API.
+type anthropicMessage struct {
+	ID      string `json:"id"`
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		ID    string          `json:"id"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// chatCompletion is the chat completion response translated from a messages API response.
+type chatCompletion struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []chatChoice `json:"choices"`
+	Usage   struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+type chatChoice struct {
+	Index        int         `json:"index"`
+	Message      chatMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+type chatMessage struct {
+	Role      string         `json:"role"`
+	Content   *string        `json:"content"`
+	ToolCalls []chatToolCall `json:"tool_calls,omitempty"`
+}
+
+type chatToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// anthropicFinishReasons maps the stop reasons of the messages API to the finish reasons of chat completions.
+var anthropicFinishReasons = map[string]string{
+	"end_turn":      "stop",
+	"stop_sequence": "stop",
+	"max_tokens":    "length",
+	"tool_use":      "tool_calls",
+	"refusal":       "content_filter",
+}
+
+func (a *anthropicAdapter) Response(req *batch.InferenceRequest, body []byte) ([]byte, error) {
+	var message anthropicMessage
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, fmt.Errorf("invalid %s response: %w", a.provider, err)
+	}
+	completion := chatCompletion{
+		ID:      message.ID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   req.Model,
+	}
+	completion.Choices = make([]chatChoice, 1)
+	choice := &completion.Choices[0]
+	choice.Message.Role = "assistant"
+	choice.FinishReason = anthropicFinishReasons[message.StopReason]
+	if choice.FinishReason == "" {
+		choice.FinishReason = "stop"
+	}
+	var texts []string
+	for _, block := range message.Content {
+		switch block.Type {
+		case "text":
+			texts = append(texts, block.Text)
+		case "tool_use":
+			call := chatToolCall{ID: block.ID, Type: "function"}
+			call.Function.Name = block.Name
+			call.Function.Arguments = string(block.Input)
+			choice.Message.ToolCalls = append(choice.Message.ToolCalls, call)
+		}
+	}
+	if len(texts) > 0 {
+		text := strings.Join(texts, "")
+		choice.Message.Content = &text
+	}
+	completion.Usage.PromptTokens = message.Usage.InputTokens
+	completion.Usage.CompletionTokens = message.Usage.OutputTokens
+	completion.Usage.TotalTokens = message.Usage.InputTokens + message.Usage.OutputTokens
+	return json.Marshal(completion)
+}
+
+// Error translates the error responses of Anthropic ({"error": {"message": ...}}) and Bedrock ({"message": ...}).
+func (a *anthropicAdapter) Error(statusCode int, body []byte) (string, []byte) {
+	message := errorMessage(body)
+	if message == "" {
+		var errResp struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &errResp) == nil {
+			message = errResp.Message
+		}
+	}
+	if message == "" {
+		return "", nil
+	}
+	openAIBody, err := json.Marshal(openai.ErrorResponse{Error: openai.NewAPIError(statusCode, "", message, nil)})
+	if err != nil {
+		return message, nil
+	}
+	return message, openAIBody
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The unit tests of the Anthropic messages API adapter.
+
+package inference
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/settings"
+)
+
+// chatRequest returns the parameters of a chat completion request, as decoded from a batch line.
+func chatRequest(t *testing.T, body string) map[string]interface{} {
+	t.Helper()
+	var params map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &params); err != nil {
+		t.Fatalf("invalid request body: %v", err)
+	}
+	return params
+}
+
+func TestAnthropicAdapter(t *testing.T) {
+	ctx := context.Background()
+	params := `{
+		"model": "claude",
+		"max_completion_tokens": 100,
+		"temperature": 0.5,
+		"stop": "END",
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is this?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBORw0KGgo="}}
+			]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":\"png\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "an image format"}
+		],
+		"tools": [{"type": "function", "function": {"name": "lookup", "parameters": {"type": "object"}}}],
+		"tool_choice": "required"
+	}`
+	wantMessage := `{
+		"model": "claude-3-5",
+		"max_tokens": 100,
+		"temperature": 0.5,
+		"stop_sequences": ["END"],
+		"system": "Be brief.",
+		"messages": [
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is this?"},
+				{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "iVBORw0KGgo="}}
+			]},
+			{"role": "assistant", "content": [
+				{"type": "tool_use", "id": "call_1", "name": "lookup", "input": {"q": "png"}}
+			]},
+			{"role": "user", "content": [
+				{"type": "tool_result", "tool_use_id": "call_1", "content": "an image format"}
+			]}
+		],
+		"tools": [{"name": "lookup", "input_schema": {"type": "object"}}],
+		"tool_choice": {"type": "any"}
+	}`
+	response := `{
+		"id": "msg_1",
+		"type": "message",
+		"role": "assistant",
+		"content": [{"type": "text", "text": "A PNG image."}],
+		"stop_reason": "end_turn",
+		"usage": {"input_tokens": 20, "output_tokens": 5}
+	}`
+
+	tests := []struct {
+		provider    string
+		wantPath    string
+		wantHeaders map[string]string
+		// the fields of the body differing from wantMessage, removed when nil
+		wantFields map[string]interface{}
+	}{
+		{
+			provider:    config.ProviderAnthropic,
+			wantPath:    "/v1/messages",
+			wantHeaders: map[string]string{"x-api-key": "secret", "anthropic-version": anthropicVersion},
+		},
+		{
+			provider:    config.ProviderBedrock,
+			wantPath:    "/model/claude-3-5/invoke",
+			wantHeaders: map[string]string{"Authorization": "Bearer secret"},
+			wantFields:  map[string]interface{}{"model": nil, "anthropic_version": bedrockAnthropicVersion},
+		},
+		{
+			provider:    config.ProviderVertex,
+			wantPath:    "/publishers/anthropic/models/claude-3-5:rawPredict",
+			wantHeaders: map[string]string{"Authorization": "Bearer secret"},
+			wantFields:  map[string]interface{}{"model": nil, "anthropic_version": vertexAnthropicVersion},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.wantPath {
+					t.Errorf("path = %s, want %s", r.URL.Path, tt.wantPath)
+				}
+				for name, value := range tt.wantHeaders {
+					if got := r.Header.Get(name); got != value {
+						t.Errorf("%s = %q, want %q", name, got, value)
+					}
+				}
+				body, _ := io.ReadAll(r.Body)
+				var got map[string]interface{}
+				json.Unmarshal(body, &got)
+				want := chatRequest(t, wantMessage)
+				for field, value := range tt.wantFields {
+					if value == nil {
+						delete(want, field)
+					} else {
+						want[field] = value
+					}
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("request body = %s", body)
+				}
+				w.Write([]byte(response))
+			}))
+			defer server.Close()
+
+			adapter, err := NewProviderAdapter(tt.provider, map[string]string{"claude": "claude-3-5"})
+			if err != nil {
+				t.Fatalf("NewProviderAdapter() error = %v", err)
+			}
+			client := NewHTTPClient(HTTPClientConfig{URL: server.URL, APIKey: settings.NewSecret("secret"), Adapter: adapter})
+			resp, inferenceErr := client.Generate(ctx, &batch.InferenceRequest{
+				RequestID: "req-1",
+				Model:     "claude",
+				Params:    chatRequest(t, params),
+				Endpoint:  DefaultEndpoint,
+			})
+			if inferenceErr != nil {
+				t.Fatalf("Generate() error = %v", inferenceErr)
+			}
+			var completion chatCompletion
+			if err := json.Unmarshal(resp.Response, &completion); err != nil {
+				t.Fatalf("invalid response %s: %v", resp.Response, err)
+			}
+			if completion.Object != "chat.completion" || completion.Model != "claude" || len(completion.Choices) != 1 {
+				t.Fatalf("unexpected response: %s", resp.Response)
+			}
+			choice := completion.Choices[0]
+			if choice.Message.Content == nil || *choice.Message.Content != "A PNG image." || choice.FinishReason != "stop" {
+				t.Errorf("unexpected choice: %s", resp.Response)
+			}
+			if completion.Usage.TotalTokens != 25 {
+				t.Errorf("usage = %+v, want 25 total tokens", completion.Usage)
+			}
+		})
+	}
+
+	t.Run("ToolUseResponse", func(t *testing.T) {
+		adapter := &anthropicAdapter{provider: config.ProviderAnthropic}
+		body, err := adapter.Response(&batch.InferenceRequest{Model: "claude"}, []byte(`{
+			"id": "msg_2",
+			"content": [{"type": "tool_use", "id": "toolu_1", "name": "lookup", "input": {"q": "png"}}],
+			"stop_reason": "tool_use"
+		}`))
+		if err != nil {
+			t.Fatalf("Response() error = %v", err)
+		}
+		var completion chatCompletion
+		json.Unmarshal(body, &completion)
+		choice := completion.Choices[0]
+		if choice.FinishReason != "tool_calls" || choice.Message.Content != nil || len(choice.Message.ToolCalls) != 1 {
+			t.Fatalf("unexpected response: %s", body)
+		}
+		if call := choice.Message.ToolCalls[0]; call.ID != "toolu_1" || call.Function.Name != "lookup" ||
+			call.Function.Arguments != `{"q": "png"}` {
+			t.Errorf("unexpected tool call: %+v", call)
+		}
+	})
+
+	t.Run("ErrorResponse", func(t *testing.T) {
+		for name, body := range map[string]string{
+			"anthropic": `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`,
+			"bedrock":   `{"message":"slow down"}`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusTooManyRequests)
+					w.Write([]byte(body))
+				}))
+				defer server.Close()
+
+				adapter, _ := NewProviderAdapter(config.ProviderBedrock, nil)
+				client := NewHTTPClient(HTTPClientConfig{URL: server.URL, Adapter: adapter})
+				_, inferenceErr := client.Generate(ctx, &batch.InferenceRequest{Model: "claude", Params: chatRequest(t, `{"messages":[]}`)})
+				if inferenceErr == nil || inferenceErr.Category != batch.ErrCategoryRateLimit || inferenceErr.Message != "slow down" {
+					t.Fatalf("Generate() error = %+v, want a rate limit error", inferenceErr)
+				}
+				// the error body is an OpenAI error
+				if message := errorMessage(inferenceErr.Body); message != "slow down" {
+					t.Errorf("error body = %s, want an OpenAI error", inferenceErr.Body)
+				}
+			})
+		}
+	})
+
+	t.Run("InvalidRequests", func(t *testing.T) {
+		adapter := &anthropicAdapter{provider: config.ProviderAnthropic}
+		for name, req := range map[string]*batch.InferenceRequest{
+			"endpoint":   {Endpoint: "/v1/embeddings", Params: chatRequest(t, `{"messages":[]}`)},
+			"messages":   {Params: chatRequest(t, `{"messages":"hello"}`)},
+			"role":       {Params: chatRequest(t, `{"messages":[{"role":"function","content":"x"}]}`)},
+			"content":    {Params: chatRequest(t, `{"messages":[{"role":"user","content":[{"type":"input_audio"}]}]}`)},
+			"image":      {Params: chatRequest(t, `{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/png,abc"}}]}]}`)},
+			"system":     {Params: chatRequest(t, `{"messages":[{"role":"system","content":[{"type":"image_url","image_url":{"url":"https://x"}}]}]}`)},
+			"tool_calls": {Params: chatRequest(t, `{"messages":[{"role":"assistant","tool_calls":[{"function":{"arguments":"{"}}]}]}`)},
+		} {
+			if _, _, err := adapter.Request(req); err == nil {
+				t.Errorf("%s: Request() succeeded, want an error", name)
+			}
+		}
+	})
+
+	t.Run("UnsupportedProvider", func(t *testing.T) {
+		if _, err := NewGatewayClient(config.InferenceGatewayConfig{Name: "a", URL: "http://localhost", Provider: "cohere"}); err == nil {
+			t.Errorf("NewGatewayClient() succeeded with an unsupported provider")
+		}
+	})
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The inference client injecting faults into the requests of another client, for resilience tests.
+
+package inference
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// ChaosCrashExitCode is the exit code of a processor crashed by an injected fault.
+const ChaosCrashExitCode = 137
+
+// ChaosClient injects failures, delays and crashes into the inference requests of the client it wraps, so the
+// leases, checkpoints and retries of the processor can be exercised. It must only be used in tests.
+type ChaosClient struct {
+	client batch.InferenceClient
+	cfg    config.ChaosConfig
+
+	mu   sync.Mutex
+	rand *rand.Rand
+
+	// crash exits the processor, it is replaced in tests
+	crash func()
+}
+
+// NewChaosClient returns a client injecting the faults of cfg into the requests sent with client.
+func NewChaosClient(client batch.InferenceClient, cfg config.ChaosConfig) *ChaosClient {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &ChaosClient{
+		client: client,
+		cfg:    cfg,
+		rand:   rand.New(rand.NewSource(seed)),
+		crash: func() {
+			klog.Flush()
+			os.Exit(ChaosCrashExitCode)
+		},
+	}
+}
+
+func (c *ChaosClient) Generate(ctx context.Context, req *batch.InferenceRequest) (*batch.InferenceResponse, *batch.InferenceError) {
+	logger := klog.FromContext(ctx)
+	crash, delay, fail := c.faults()
+	if crash {
+		logger.V(logging.WARNING).Info("Chaos: crashing the processor", "requestID", req.RequestID)
+		c.crash()
+	}
+	if delay {
+		logger.V(logging.DEBUG).Info("Chaos: delaying request", "requestID", req.RequestID, "delay", c.cfg.Delay)
+		select {
+		case <-ctx.Done():
+			return nil, &batch.InferenceError{Category: batch.ErrCategoryServer, Message: ctx.Err().Error(), RawError: ctx.Err()}
+		case <-time.After(c.cfg.Delay):
+		}
+	}
+	if fail {
+		logger.V(logging.DEBUG).Info("Chaos: failing request", "requestID", req.RequestID, "statusCode", c.cfg.FailureStatusCode)
+		message := http.StatusText(c.cfg.FailureStatusCode)
+		return nil, &batch.InferenceError{
+			Category:   errorCategory(c.cfg.FailureStatusCode),
+			Message:    message,
+			StatusCode: c.cfg.FailureStatusCode,
+			RawError:   fmt.Errorf("chaos: injected %d response: %s", c.cfg.FailureStatusCode, message),
+		}
+	}
+	return c.client.Generate(ctx, req)
+}
+
+// faults draws the faults injected into a request.
+func (c *ChaosClient) faults() (crash, delay, fail bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	crash = c.rand.Float64() < c.cfg.CrashRate
+	delay = c.rand.Float64() < c.cfg.DelayRate
+	fail = c.rand.Float64() < c.cfg.FailureRate
+	return crash, delay, fail
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The unit tests of the fault injecting inference client.
+
+package inference
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+)
+
+// countingClient counts the requests it receives and responds successfully.
+type countingClient struct {
+	requests int
+}
+
+func (c *countingClient) Generate(ctx context.Context, req *batch.InferenceRequest) (*batch.InferenceResponse, *batch.InferenceError) {
+	c.requests++
+	return &batch.InferenceResponse{RequestID: req.RequestID, Response: []byte(`{}`)}, nil
+}
+
+func TestChaosClient(t *testing.T) {
+	ctx := context.Background()
+	req := &batch.InferenceRequest{RequestID: "req-1", Model: "m1"}
+
+	t.Run("NoFaults", func(t *testing.T) {
+		upstream := &countingClient{}
+		client := NewChaosClient(upstream, config.ChaosConfig{})
+		client.crash = func() { t.Fatalf("unexpected crash") }
+		for i := 0; i < 10; i++ {
+			if _, err := client.Generate(ctx, req); err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+		}
+		if upstream.requests != 10 {
+			t.Errorf("upstream received %d requests, want 10", upstream.requests)
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		upstream := &countingClient{}
+		client := NewChaosClient(upstream, config.ChaosConfig{FailureRate: 1, FailureStatusCode: http.StatusTooManyRequests})
+		_, err := client.Generate(ctx, req)
+		if err == nil || err.Category != batch.ErrCategoryRateLimit || err.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("Generate() error = %+v, want an injected rate limit error", err)
+		}
+		if upstream.requests != 0 {
+			t.Errorf("failed request was sent upstream")
+		}
+	})
+
+	t.Run("Delay", func(t *testing.T) {
+		upstream := &countingClient{}
+		client := NewChaosClient(upstream, config.ChaosConfig{DelayRate: 1, Delay: 30 * time.Millisecond})
+		start := time.Now()
+		if _, err := client.Generate(ctx, req); err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+			t.Errorf("Generate() took %v, want at least the injected delay", elapsed)
+		}
+
+		// the delay is interrupted when the request is cancelled
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		client = NewChaosClient(upstream, config.ChaosConfig{DelayRate: 1, Delay: time.Hour})
+		if _, err := client.Generate(cancelled, req); err == nil {
+			t.Errorf("Generate() of a cancelled request succeeded")
+		}
+	})
+
+	t.Run("Crash", func(t *testing.T) {
+		crashed := false
+		client := NewChaosClient(&countingClient{}, config.ChaosConfig{CrashRate: 1})
+		client.crash = func() { crashed = true }
+		client.Generate(ctx, req)
+		if !crashed {
+			t.Errorf("expected the processor to crash")
+		}
+	})
+
+	t.Run("ReproducibleWithSeed", func(t *testing.T) {
+		outcomes := func() []bool {
+			client := NewChaosClient(&countingClient{}, config.ChaosConfig{FailureRate: 0.5, FailureStatusCode: 503, Seed: 42})
+			var failed []bool
+			for i := 0; i < 20; i++ {
+				_, err := client.Generate(ctx, req)
+				failed = append(failed, err != nil)
+			}
+			return failed
+		}
+		first := outcomes()
+		if !slices.Equal(first, outcomes()) {
+			t.Errorf("faults differ with the same seed")
+		}
+		if !slices.Contains(first, true) || !slices.Contains(first, false) {
+			t.Errorf("faults = %v, want some failed requests", first)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The inference client answering requests without a model server, for local development.
+
+package inference
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// EchoClient answers chat completion requests with the content of their last message, and completion requests
+// with their prompt. Requests to other endpoints are answered with their parameters. It must only be used in
+// development.
+type EchoClient struct{}
+
+func NewEchoClient() *EchoClient {
+	return &EchoClient{}
+}
+
+func (c *EchoClient) Generate(ctx context.Context, req *batch.InferenceRequest) (*batch.InferenceResponse, *batch.InferenceError) {
+	if err := ctx.Err(); err != nil {
+		return nil, &batch.InferenceError{Category: batch.ErrCategoryServer, Message: err.Error(), RawError: err}
+	}
+	response := map[string]interface{}{
+		"id":      "echo-" + req.RequestID,
+		"created": time.Now().Unix(),
+		"model":   req.Model,
+	}
+	switch openai.Endpoint(req.Endpoint) {
+	case openai.EndpointChatCompletions:
+		response["object"] = "chat.completion"
+		response["choices"] = []interface{}{map[string]interface{}{
+			"index":         0,
+			"message":       map[string]interface{}{"role": "assistant", "content": lastMessageContent(req.Params)},
+			"finish_reason": "stop",
+		}}
+	case openai.EndpointCompletions:
+		response["object"] = "text_completion"
+		response["choices"] = []interface{}{map[string]interface{}{
+			"index":         0,
+			"text":          fmt.Sprint(req.Params["prompt"]),
+			"finish_reason": "stop",
+		}}
+	default:
+		response["object"] = "echo"
+		response["request"] = req.Params
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, &batch.InferenceError{Category: batch.ErrCategoryInvalidReq, Message: err.Error(), RawError: err}
+	}
+	return &batch.InferenceResponse{RequestID: req.RequestID, Response: body, RawData: response}, nil
+}
+
+// lastMessageContent returns the content of the last message of chat completion parameters.
+func lastMessageContent(params map[string]interface{}) interface{} {
+	messages, _ := params["messages"].([]interface{})
+	if len(messages) == 0 {
+		return ""
+	}
+	message, _ := messages[len(messages)-1].(map[string]interface{})
+	if content, ok := message["content"]; ok {
+		return content
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The unit tests of the development inference client.
+
+package inference
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+)
+
+func TestEchoClient(t *testing.T) {
+	client := NewEchoClient()
+	generate := func(t *testing.T, endpoint string, params map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		resp, err := client.Generate(context.Background(), &batch.InferenceRequest{
+			RequestID: "req-1", Model: "m1", Endpoint: endpoint, Params: params,
+		})
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(resp.Response, &body); err != nil {
+			t.Fatalf("invalid response %s: %v", resp.Response, err)
+		}
+		if body["model"] != "m1" {
+			t.Errorf("model = %v, want m1", body["model"])
+		}
+		return body
+	}
+
+	t.Run("ChatCompletions", func(t *testing.T) {
+		body := generate(t, "/v1/chat/completions", map[string]interface{}{"messages": []interface{}{
+			map[string]interface{}{"role": "system", "content": "be brief"},
+			map[string]interface{}{"role": "user", "content": "hello"},
+		}})
+		choice := body["choices"].([]interface{})[0].(map[string]interface{})
+		if body["object"] != "chat.completion" || choice["message"].(map[string]interface{})["content"] != "hello" {
+			t.Errorf("unexpected response %v", body)
+		}
+	})
+
+	t.Run("Completions", func(t *testing.T) {
+		body := generate(t, "/v1/completions", map[string]interface{}{"prompt": "once upon a time"})
+		choice := body["choices"].([]interface{})[0].(map[string]interface{})
+		if body["object"] != "text_completion" || choice["text"] != "once upon a time" {
+			t.Errorf("unexpected response %v", body)
+		}
+	})
+
+	t.Run("OtherEndpoint", func(t *testing.T) {
+		body := generate(t, "/v1/embeddings", map[string]interface{}{"input": "text"})
+		if body["object"] != "echo" || body["request"].(map[string]interface{})["input"] != "text" {
+			t.Errorf("unexpected response %v", body)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := client.Generate(ctx, &batch.InferenceRequest{RequestID: "req-1"}); err == nil || !err.IsRetryable() {
+			t.Errorf("Generate() error = %v, want a retryable error", err)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The mock inference server answering requests with the development inference client.
+
+package inference
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// NewEchoHandler returns the handler of an OpenAI-compatible mock inference server, answering the POST requests
+// to the /v1/ endpoints like EchoClient. It must only be used in development.
+func NewEchoHandler() http.Handler {
+	client := NewEchoClient()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v1/") {
+			writeEchoError(w, openai.NewAPIError(http.StatusNotFound, "", "unknown endpoint "+r.URL.Path, nil))
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeEchoError(w, openai.NewAPIError(http.StatusMethodNotAllowed, "", "method not allowed", nil))
+			return
+		}
+		var params map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			writeEchoError(w, openai.NewAPIError(http.StatusBadRequest, "", "invalid request body: "+err.Error(), nil))
+			return
+		}
+
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		model, _ := params["model"].(string)
+		resp, inferenceErr := client.Generate(r.Context(), &batch.InferenceRequest{
+			RequestID: requestID,
+			Model:     model,
+			Params:    params,
+			Endpoint:  r.URL.Path,
+		})
+		if inferenceErr != nil {
+			writeEchoError(w, openai.NewAPIError(http.StatusInternalServerError, "", inferenceErr.Message, nil))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(RequestIDHeader, resp.RequestID)
+		w.WriteHeader(http.StatusOK)
+		w.Write(resp.Response)
+	})
+}
+
+func writeEchoError(w http.ResponseWriter, apiErr openai.APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.Code)
+	json.NewEncoder(w).Encode(openai.ErrorResponse{Error: apiErr})
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The unit tests of the mock inference server.
+
+package inference
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+)
+
+func TestEchoHandler(t *testing.T) {
+	server := httptest.NewServer(NewEchoHandler())
+	defer server.Close()
+
+	t.Run("HTTPClient", func(t *testing.T) {
+		client := NewHTTPClient(HTTPClientConfig{URL: server.URL})
+		resp, err := client.Generate(context.Background(), &batch.InferenceRequest{
+			RequestID: "req-1",
+			Model:     "m1",
+			Endpoint:  "/v1/chat/completions",
+			Params: map[string]interface{}{"model": "m1", "messages": []interface{}{
+				map[string]interface{}{"role": "user", "content": "hello"},
+			}},
+		})
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(resp.Response, &body); err != nil {
+			t.Fatalf("invalid response %s: %v", resp.Response, err)
+		}
+		choice := body["choices"].([]interface{})[0].(map[string]interface{})
+		if body["model"] != "m1" || choice["message"].(map[string]interface{})["content"] != "hello" {
+			t.Errorf("unexpected response %v", body)
+		}
+		if resp.RequestID == "" {
+			t.Errorf("expected the request ID of the server")
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			method, path, body string
+			want               int
+		}{
+			{http.MethodGet, "/v1/chat/completions", "", http.StatusMethodNotAllowed},
+			{http.MethodPost, "/v1/chat/completions", "{", http.StatusBadRequest},
+			{http.MethodPost, "/health", "{}", http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("%s %s: %v", tt.method, tt.path, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The inference client sending requests to an OpenAI-compatible inference gateway over HTTP.
+
+package inference
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/settings"
+	tlsutil "github.com/llm-d-incubation/batch-gateway/internal/util/tls"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/tracing"
+)
+
+const (
+	// DefaultEndpoint is the path requests without an endpoint are sent to.
+	DefaultEndpoint = "/v1/chat/completions"
+
+	// RequestIDHeader is the response header carrying the ID the gateway gave to the request.
+	RequestIDHeader = "x-request-id"
+
+	// maxResponseBytes bounds the size of a response body.
+	maxResponseBytes = 64 << 20
+)
+
+type HTTPClientConfig struct {
+	// URL is the base URL of the gateway, the endpoint of the request is appended to it
+	URL string
+	// APIKey is sent as bearer token when set, its value is read at every request so a refreshed key is used
+	APIKey settings.Secret
+	// TLSConfig is the TLS configuration of the connections to the gateway, the default one when nil
+	TLSConfig *tls.Config
+	// Adapter translates the requests to the API of the gateway, OpenAI when nil
+	Adapter ProviderAdapter
+}
+
+// HTTPClient sends inference requests to an OpenAI-compatible inference gateway, or to the gateway of another
+// provider through its adapter.
+type HTTPClient struct {
+	baseURL string
+	apiKey  settings.Secret
+	adapter ProviderAdapter
+	client  *http.Client
+}
+
+func NewHTTPClient(cfg HTTPClientConfig) *HTTPClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.TLSConfig != nil {
+		transport.TLSClientConfig = cfg.TLSConfig
+	}
+	adapter := cfg.Adapter
+	if adapter == nil {
+		adapter = openAIAdapter{}
+	}
+	return &HTTPClient{
+		baseURL: strings.TrimSuffix(cfg.URL, "/"),
+		apiKey:  cfg.APIKey,
+		adapter: adapter,
+		// requests are bounded by their context, see InferenceClient.Generate
+		client: &http.Client{Transport: transport},
+	}
+}
+
+// NewGatewayClient returns the client of a configured inference gateway, reading its credentials.
+func NewGatewayClient(gateway config.InferenceGatewayConfig) (*HTTPClient, error) {
+	adapter, err := NewProviderAdapter(gateway.Provider, gateway.ProviderModels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure inference gateway %q: %w", gateway.Name, err)
+	}
+	cfg := HTTPClientConfig{URL: gateway.URL, APIKey: gateway.APIKey, Adapter: adapter}
+	if gateway.APIKeyFile != "" {
+		key, err := os.ReadFile(gateway.APIKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API key of inference gateway %q: %w", gateway.Name, err)
+		}
+		cfg.APIKey = settings.NewSecret(strings.TrimSpace(string(key)))
+	}
+	if gateway.CACertFile != "" || gateway.CertFile != "" || gateway.InsecureSkipVerify {
+		tlsConfig, err := tlsutil.GetTlsConfig(tlsutil.LOAD_TYPE_CLIENT, gateway.InsecureSkipVerify,
+			gateway.CertFile, gateway.KeyFile, gateway.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure TLS of inference gateway %q: %w", gateway.Name, err)
+		}
+		cfg.TLSConfig = tlsConfig
+	}
+	return NewHTTPClient(cfg), nil
+}
+
+func (c *HTTPClient) Generate(ctx context.Context, req *batch.InferenceRequest) (*batch.InferenceResponse, *batch.InferenceError) {
+	path, body, err := c.adapter.Request(req)
+	if err != nil {
+		return nil, &batch.InferenceError{Category: batch.ErrCategoryInvalidReq, Message: err.Error(), RawError: err}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, &batch.InferenceError{Category: batch.ErrCategoryInvalidReq, Message: err.Error(), RawError: err}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	c.adapter.SetHeaders(httpReq.Header, c.apiKey.Value())
+	for name, value := range req.Headers {
+		httpReq.Header.Set(name, value)
+	}
+	if tc := tracing.FromContext(ctx); tc != nil {
+		tc.Inject(httpReq.Header)
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		category := batch.ErrCategoryServer
+		if ctx.Err() != nil {
+			category = batch.ErrCategoryUnknown
+		}
+		return nil, &batch.InferenceError{Category: category, Message: err.Error(), RawError: err}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, &batch.InferenceError{
+			Category:   batch.ErrCategoryServer,
+			Message:    fmt.Sprintf("failed to read response: %v", err),
+			RawError:   err,
+			StatusCode: resp.StatusCode,
+		}
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, c.responseError(resp, data)
+	}
+	if data, err = c.adapter.Response(req, data); err != nil {
+		return nil, &batch.InferenceError{
+			Category:   batch.ErrCategoryServer,
+			Message:    fmt.Sprintf("failed to translate response: %v", err),
+			RawError:   err,
+			StatusCode: resp.StatusCode,
+		}
+	}
+	requestID := resp.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = req.RequestID
+	}
+	return &batch.InferenceResponse{
+		RequestID: requestID,
+		Response:  data,
+	}, nil
+}
+
+// responseError returns the inference error of an error response of the gateway, its body translated to an
+// OpenAI error.
+func (c *HTTPClient) responseError(resp *http.Response, data []byte) *batch.InferenceError {
+	inferenceErr := &batch.InferenceError{
+		Category:   errorCategory(resp.StatusCode),
+		Message:    http.StatusText(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		RetryAfter: retryAfter(resp.Header.Get("Retry-After")),
+	}
+	message, body := c.adapter.Error(resp.StatusCode, data)
+	inferenceErr.Body = body
+	if message != "" {
+		inferenceErr.Message = message
+	}
+	inferenceErr.RawError = fmt.Errorf("inference gateway responded %d: %s", resp.StatusCode, inferenceErr.Message)
+	return inferenceErr
+}
+
+func errorCategory(statusCode int) batch.ErrorCategory {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return batch.ErrCategoryRateLimit
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return batch.ErrCategoryAuth
+	case statusCode == http.StatusRequestTimeout || statusCode >= http.StatusInternalServerError:
+		return batch.ErrCategoryServer
+	case statusCode >= http.StatusBadRequest:
+		return batch.ErrCategoryInvalidReq
+	default:
+		return batch.ErrCategoryUnknown
+	}
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date, returning 0 if it is missing or invalid.
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
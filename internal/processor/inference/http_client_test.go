@@ -0,0 +1,146 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The unit tests of the HTTP inference client.
+
+package inference
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/settings"
+)
+
+func TestHTTPClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/completions" {
+				t.Errorf("path = %s, want /v1/completions", r.URL.Path)
+			}
+			if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+				t.Errorf("Authorization = %q, want bearer token", got)
+			}
+			if got := r.Header.Get(batch.SLOTTFTHeader); got != "1000" {
+				t.Errorf("%s = %q, want 1000", batch.SLOTTFTHeader, got)
+			}
+			body, _ := io.ReadAll(r.Body)
+			var params map[string]interface{}
+			if err := json.Unmarshal(body, &params); err != nil || params["model"] != "m1" {
+				t.Errorf("unexpected request body: %s", body)
+			}
+			w.Header().Set(RequestIDHeader, "gw-req-1")
+			w.Write([]byte(`{"object":"text_completion"}`))
+		}))
+		defer server.Close()
+
+		client := NewHTTPClient(HTTPClientConfig{URL: server.URL + "/", APIKey: settings.NewSecret("secret")})
+		resp, inferenceErr := client.Generate(ctx, &batch.InferenceRequest{
+			RequestID: "req-1",
+			Model:     "m1",
+			Params:    map[string]interface{}{"model": "m1"},
+			Endpoint:  "/v1/completions",
+			Headers:   map[string]string{batch.SLOTTFTHeader: "1000"},
+		})
+		if inferenceErr != nil {
+			t.Fatalf("Generate() error = %v", inferenceErr)
+		}
+		if resp.RequestID != "gw-req-1" || string(resp.Response) != `{"object":"text_completion"}` {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+	})
+
+	t.Run("ErrorResponses", func(t *testing.T) {
+		tests := []struct {
+			name         string
+			status       int
+			retryAfter   string
+			body         string
+			wantCategory batch.ErrorCategory
+			wantMessage  string
+			wantBackoff  time.Duration
+		}{
+			{name: "rate limited", status: 429, retryAfter: "3", body: `{"error":{"message":"slow down"}}`,
+				wantCategory: batch.ErrCategoryRateLimit, wantMessage: "slow down", wantBackoff: 3 * time.Second},
+			{name: "server error", status: 503, body: "unavailable",
+				wantCategory: batch.ErrCategoryServer, wantMessage: "Service Unavailable"},
+			{name: "unauthorized", status: 401, wantCategory: batch.ErrCategoryAuth, wantMessage: "Unauthorized"},
+			{name: "invalid request", status: 400, body: `{"error":{"message":"bad model"}}`,
+				wantCategory: batch.ErrCategoryInvalidReq, wantMessage: "bad model"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.body))
+				}))
+				defer server.Close()
+
+				_, inferenceErr := NewHTTPClient(HTTPClientConfig{URL: server.URL}).Generate(ctx, &batch.InferenceRequest{})
+				if inferenceErr == nil {
+					t.Fatalf("Generate() succeeded, want an error")
+				}
+				if inferenceErr.Category != tt.wantCategory || inferenceErr.StatusCode != tt.status ||
+					inferenceErr.Message != tt.wantMessage || inferenceErr.RetryAfter != tt.wantBackoff {
+					t.Errorf("unexpected error: %+v", inferenceErr)
+				}
+				if json.Valid([]byte(tt.body)) != (inferenceErr.Body != nil) {
+					t.Errorf("Body = %s, want the JSON body of the response", inferenceErr.Body)
+				}
+			})
+		}
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		_, inferenceErr := NewHTTPClient(HTTPClientConfig{URL: server.URL}).Generate(ctx, &batch.InferenceRequest{})
+		if inferenceErr == nil || !inferenceErr.IsRetryable() {
+			t.Errorf("Generate() error = %+v, want a retryable error", inferenceErr)
+		}
+	})
+
+	t.Run("GatewayCredentials", func(t *testing.T) {
+		keyFile := filepath.Join(t.TempDir(), "api-key")
+		os.WriteFile(keyFile, []byte("secret\n"), 0o600)
+
+		client, err := NewGatewayClient(config.InferenceGatewayConfig{Name: "a", URL: "http://localhost", APIKeyFile: keyFile})
+		if err != nil {
+			t.Fatalf("NewGatewayClient() error = %v", err)
+		}
+		if client.apiKey.Value() != "secret" {
+			t.Errorf("apiKey = %q, want the content of the key file", client.apiKey.Value())
+		}
+		if _, err := NewGatewayClient(config.InferenceGatewayConfig{Name: "b", APIKeyFile: keyFile + ".missing"}); err == nil {
+			t.Errorf("NewGatewayClient() succeeded with a missing key file")
+		}
+	})
+}
@@ -21,9 +21,13 @@ package metrics
 import (
 	"net/http"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// NewMetricsHandler returns the handler of the metrics endpoint. The metrics are served in the OpenMetrics format,
+// which carries the exemplars of the histograms, to scrapers that accept it.
 func NewMetricsHandler() http.Handler {
-	return promhttp.Handler()
+	return promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))
 }
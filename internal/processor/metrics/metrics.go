@@ -17,9 +17,12 @@ limitations under the License.
 package metrics
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/labels"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/version"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -28,34 +31,22 @@ const (
 	// result labels
 	ResultSuccess = "success"
 	ResultFailed  = "failed"
+	ResultDropped = "dropped"
 
 	// reason lables
 	ReasonUnknown     = "unknown"
 	ReasonUserError   = "user_error"   // method, request validation failed.. etc.,
 	ReasonSystemError = "system_error" // SLO failed, system error.. etc.,
 
-	// size bucket labels
-	Bucket100   = "100"   // less than 100 lines
-	Bucket1000  = "1000"  // less than 1000 lines
-	Bucket10000 = "10000" // less than 10000 lines
-	Bucket30000 = "30000" // less than 30000 lines
-	BucketLarge = "large" // more than 30000 lines
-)
+	// ExemplarTraceIDLabel is the label of the exemplars holding the ID of the trace they link to
+	ExemplarTraceIDLabel = "trace_id"
 
-func GetSizeBucket(totalLines int) string {
-	switch {
-	case totalLines < 100:
-		return Bucket100
-	case totalLines < 1000:
-		return Bucket1000
-	case totalLines < 10000:
-		return Bucket10000
-	case totalLines < 30000:
-		return Bucket30000
-	default:
-		return BucketLarge
-	}
-}
+	// lifecycle stage labels
+	StageQueued     = "queued"     // from the creation of a batch to the start of its processing
+	StageProcessing = "processing" // from the start of the processing to finalizing, or to the final status
+	StageFinalizing = "finalizing" // from finalizing to the final status
+	StageTotal      = "total"      // from the creation of a batch to its final status
+)
 
 var (
 	jobsProcessed         *prometheus.CounterVec
@@ -64,9 +55,41 @@ var (
 	totalWorkers          prometheus.Gauge
 	activeWorkers         prometheus.Gauge
 	jobErrorsModelTotal   *prometheus.CounterVec
+	jobsDeadLettered      prometheus.Counter
+	endpointPauses        *prometheus.CounterVec
+	speculativeRequests   *prometheus.CounterVec
+	tokensUsed            *prometheus.CounterVec
+	tenantRequests        *prometheus.CounterVec
+	tenantBatches         *prometheus.CounterVec
+	tenantResultBytes     *prometheus.CounterVec
+	dedupCacheHits        *prometheus.CounterVec
+	batchesReaped         *prometheus.CounterVec
+	tasksReclaimed        *prometheus.CounterVec
+	jobsDequeued          *prometheus.CounterVec
+	requestRetries        *prometheus.CounterVec
+	rateLimitedRequests   *prometheus.CounterVec
+	batchDuration         *prometheus.HistogramVec
+	batchStageDuration    *prometheus.HistogramVec
+	batchThroughput       *prometheus.HistogramVec
+	timeToFirstLine       *prometheus.HistogramVec
+	inferenceDuration     *prometheus.HistogramVec
+	inferenceFailures     *prometheus.CounterVec
+	queueJobs             *prometheus.GaugeVec
+	queueOldestJobAge     *prometheus.GaugeVec
+	eventSinkDeliveries   *prometheus.CounterVec
+	eventsArchived        *prometheus.CounterVec
+	memoryThrottled       prometheus.Gauge
+	memoryUsage           prometheus.Gauge
+
+	// bound the distinct models and tenants labeling the metrics
+	modelLabels  *labels.Limiter
+	tenantLabels *labels.Limiter
 )
 
 func InitMetrics(cfg config.ProcessorConfig) error {
+	modelLabels = cfg.Observability.MetricLabels.NewModelLimiter()
+	tenantLabels = cfg.Observability.MetricLabels.NewTenantLimiter()
+
 	// number of jobs processed : TODO:: add tenantID?
 	jobsProcessed = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -76,11 +99,11 @@ func InitMetrics(cfg config.ProcessorConfig) error {
 	)
 
 	// total number of workers for utilization %
-	// this is set once on initialization
+	// this is set on initialization, and updated by the autoscaler
 	totalWorkers = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "total_workers",
-			Help: "Total number of configured workers",
+			Help: "Total number of workers that may process jobs",
 		},
 	)
 	totalWorkers.Set(float64(cfg.NumWorkers))
@@ -102,6 +125,127 @@ func InitMetrics(cfg config.ProcessorConfig) error {
 		[]string{"model"},
 	)
 
+	// jobs moved to the dead-letter queue
+	jobsDeadLettered = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "jobs_dead_lettered_total",
+			Help: "Total number of jobs moved to the dead-letter queue after repeated delivery failures",
+		},
+	)
+
+	// dispatch pauses of saturated endpoints
+	endpointPauses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "endpoint_pauses_total",
+			Help: "Total number of times dispatch to a saturated endpoint was paused",
+		},
+		[]string{"model"},
+	)
+
+	// straggler requests sent to the speculative gateway, by whether their response was kept
+	speculativeRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "speculative_requests_total",
+			Help: "Total number of straggler requests sent to the speculative gateway, by whether their response was kept",
+		},
+		[]string{"model", "kept"},
+	)
+
+	// tokens used by the requests of the batches, by tenant
+	tokensUsed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tokens_total",
+			Help: "Total number of tokens used by the requests of the batches, by tenant and type (input or output)",
+		},
+		[]string{"tenantID", "type"},
+	)
+
+	// usage of the tenants, for chargeback
+	tenantRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tenant_requests_total",
+			Help: "Total number of requests of the batches processed, by tenant and result (success, failed)",
+		},
+		[]string{"tenantID", "result"},
+	)
+	tenantBatches = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tenant_batches_total",
+			Help: "Total number of batches that reached a final status, by tenant and status",
+		},
+		[]string{"tenantID", "status"},
+	)
+	tenantResultBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tenant_result_bytes_total",
+			Help: "Total number of bytes of the output and error files stored for the batches, by tenant",
+		},
+		[]string{"tenantID"},
+	)
+
+	// requests answered from the dedup cache
+	dedupCacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dedup_cache_hits_total",
+			Help: "Total number of requests answered with the cached response of an identical request",
+		},
+		[]string{"model"},
+	)
+
+	// stuck batches expired by the reaper
+	batchesReaped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "batches_reaped_total",
+			Help: "Total number of batches expired after being stuck past their completion window, by the status they were stuck in",
+		},
+		[]string{"status"},
+	)
+
+	// expired leases of jobs returned to the queue
+	tasksReclaimed = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tasks_reclaimed_total",
+			Help: "Total number of jobs returned to the queue after their lease expired, by processor whose worker died",
+		},
+		[]string{"processor"},
+	)
+
+	// jobs dequeued from each of the consumed queues
+	jobsDequeued = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "jobs_dequeued_total",
+			Help: "Total number of jobs dequeued, by queue",
+		},
+		[]string{"queue"},
+	)
+
+	// inference requests attempted again after a retryable error
+	requestRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "request_retries_total",
+			Help: "Total number of retried inference requests, by model",
+		},
+		[]string{"model"},
+	)
+
+	// inference requests held back by the processor's rate limits
+	rateLimitedRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limited_requests_total",
+			Help: "Total number of inference requests delayed by the rate limits, by model",
+		},
+		[]string{"model"},
+	)
+
+	// inference requests failed after their last attempt
+	inferenceFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "inference_failures_total",
+			Help: "Total number of failed inference requests, by model and error category",
+		},
+		[]string{"model", "category"},
+	)
+
 	// job processing duratino
 	jobProcessingDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -125,17 +269,148 @@ func InitMetrics(cfg config.ProcessorConfig) error {
 				cfg.QueueTimeBucket.BucketFactor,
 				cfg.QueueTimeBucket.BucketCount,
 			),
-		}, []string{"tenantID"},
+		}, []string{"model"},
+	)
+
+	// duration of batches from the start of their processing to their final status
+	batchDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "batch_duration_seconds",
+			Help: "Time from the start of the processing of a batch to its final status",
+			Buckets: prometheus.ExponentialBuckets(
+				cfg.ProcessTimeBucket.BucketStart,
+				cfg.ProcessTimeBucket.BucketFactor,
+				cfg.ProcessTimeBucket.BucketCount,
+			),
+		}, []string{"model", "status"},
+	)
+
+	// duration of the lifecycle stages of batches, from 0.1s to about a day
+	batchStageDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "batch_stage_duration_seconds",
+			Help:    "Time batches spent in each lifecycle stage (queued, processing, finalizing, total), by size bucket and final status",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 21),
+		}, []string{"stage", "size_bucket", "status"},
+	)
+
+	// lines processed per second by each delivery of a batch
+	batchThroughput = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "batch_lines_per_second",
+			Help:    "Number of lines of a batch processed per second",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 16),
+		}, []string{"model"},
+	)
+
+	// time until the first line of a batch is processed
+	timeToFirstLine = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "batch_time_to_first_line_seconds",
+			Help: "Time from the start of the processing of a batch to the result of its first line",
+			Buckets: prometheus.ExponentialBuckets(
+				cfg.QueueTimeBucket.BucketStart,
+				cfg.QueueTimeBucket.BucketFactor,
+				cfg.QueueTimeBucket.BucketCount,
+			),
+		}, []string{"model"},
+	)
+
+	// duration of each attempt of an inference request
+	inferenceDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "inference_request_duration_seconds",
+			Help:    "Duration of the attempts of inference requests, by model and result (success, failed)",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 14),
+		}, []string{"model", "result"},
+	)
+
+	// jobs in each of the consumed queues
+	queueJobs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "queue_jobs",
+			Help: "Number of jobs in the queue, by queue and state (waiting, delayed, leased)",
+		},
+		[]string{"queue", "state"},
+	)
+
+	// age of the oldest job waiting in each of the consumed queues
+	queueOldestJobAge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "queue_oldest_job_age_seconds",
+			Help: "Time the oldest job waiting in the queue has been waiting, 0 when no job is waiting, by queue",
+		},
+		[]string{"queue"},
+	)
+
+	// batch lifecycle events forwarded to the event sinks
+	eventSinkDeliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "event_sink_deliveries_total",
+			Help: "Total number of batch lifecycle events forwarded to event sinks, by sink and result (success, failed, dropped)",
+		},
+		[]string{"sink", "result"},
+	)
+
+	// batch lifecycle events written to the event archive
+	eventsArchived = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "events_archived_total",
+			Help: "Total number of batch lifecycle events written to the event archive in the files store, by result (success, failed, dropped)",
+		},
+		[]string{"result"},
+	)
+
+	// whether the dispatch of lines is throttled because the memory use approaches its limit
+	memoryThrottled = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "memory_throttled",
+			Help: "Whether the dispatch of lines is throttled because the memory use approaches its limit (0 or 1)",
+		},
+	)
+
+	// memory used by the processor, as sampled by the memory throttling
+	memoryUsage = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "memory_usage_bytes",
+			Help: "Memory used by the processor, in bytes",
+		},
 	)
 
 	// metrics to register
 	metricsToRegister := []prometheus.Collector{
+		version.NewBuildInfoGauge(),
 		jobProcessingDuration,
 		jobQueueWaitDuration,
 		totalWorkers,
 		activeWorkers,
 		jobsProcessed,
 		jobErrorsModelTotal,
+		jobsDeadLettered,
+		endpointPauses,
+		speculativeRequests,
+		tokensUsed,
+		tenantRequests,
+		tenantBatches,
+		tenantResultBytes,
+		dedupCacheHits,
+		batchesReaped,
+		tasksReclaimed,
+		jobsDequeued,
+		requestRetries,
+		rateLimitedRequests,
+		batchDuration,
+		batchStageDuration,
+		batchThroughput,
+		timeToFirstLine,
+		inferenceDuration,
+		inferenceFailures,
+		queueJobs,
+		queueOldestJobAge,
+		eventSinkDeliveries,
+		eventsArchived,
+		memoryThrottled,
+		memoryUsage,
 	}
 
 	for _, metric := range metricsToRegister {
@@ -152,9 +427,9 @@ func InitMetrics(cfg config.ProcessorConfig) error {
 
 // Recorder funcs
 
-// RecordQueueWait observes the queue time
-func RecordQueueWaitDuration(duration time.Duration, tenantID string) {
-	jobQueueWaitDuration.WithLabelValues(tenantID).Observe(duration.Seconds())
+// RecordQueueWaitDuration observes the time a batch of a model waited before its processing started.
+func RecordQueueWaitDuration(duration time.Duration, model string) {
+	jobQueueWaitDuration.WithLabelValues(modelLabels.Value(model)).Observe(duration.Seconds())
 }
 
 // RecordJobProcessed increments the total processed jobs count.
@@ -162,9 +437,15 @@ func RecordJobProcessed(result string, reason string) {
 	jobsProcessed.WithLabelValues(result, reason).Inc()
 }
 
-// RecordJobProcessingDuration observes the time taken to process a job.
-func RecordJobProcessingDuration(duration time.Duration, tenantID string, sizeBucket string) {
-	jobProcessingDuration.WithLabelValues(tenantID, sizeBucket).Observe(duration.Seconds())
+// RecordJobProcessingDuration observes the time taken to process a job, with an exemplar linking to the trace
+// of the job if traceID is set.
+func RecordJobProcessingDuration(duration time.Duration, tenantID string, sizeBucket string, traceID string) {
+	observe(jobProcessingDuration.WithLabelValues(tenantLabels.Value(tenantID), sizeBucket), duration.Seconds(), traceID)
+}
+
+// SetTotalWorkers sets the gauge for the number of workers that may process jobs.
+func SetTotalWorkers(n int) {
+	totalWorkers.Set(float64(n))
 }
 
 // IncActiveWorkers increments the gauge for active workers.
@@ -179,5 +460,144 @@ func DecActiveWorkers() {
 
 // RecordJobError increments the error count for a specific model.
 func RecordJobError(model string) {
-	jobErrorsModelTotal.WithLabelValues(model).Inc()
+	jobErrorsModelTotal.WithLabelValues(modelLabels.Value(model)).Inc()
+}
+
+// RecordJobDeadLettered increments the count of jobs moved to the dead-letter queue.
+func RecordJobDeadLettered() {
+	jobsDeadLettered.Inc()
+}
+
+// RecordEndpointPause increments the count of dispatch pauses of a saturated endpoint.
+func RecordEndpointPause(model string) {
+	endpointPauses.WithLabelValues(modelLabels.Value(model)).Inc()
+}
+
+// RecordSpeculativeRequest increments the count of straggler requests sent to the speculative gateway.
+func RecordSpeculativeRequest(model string, kept bool) {
+	speculativeRequests.WithLabelValues(modelLabels.Value(model), strconv.FormatBool(kept)).Inc()
+}
+
+// RecordTokenUsage adds the input and output tokens used by the requests of a batch of a tenant.
+func RecordTokenUsage(tenantID string, inputTokens, outputTokens int64) {
+	tenant := tenantLabels.Value(tenantID)
+	tokensUsed.WithLabelValues(tenant, "input").Add(float64(inputTokens))
+	tokensUsed.WithLabelValues(tenant, "output").Add(float64(outputTokens))
+}
+
+// RecordTenantRequests adds the requests of a batch of a tenant that succeeded and failed.
+func RecordTenantRequests(tenantID string, succeeded, failed int64) {
+	tenant := tenantLabels.Value(tenantID)
+	tenantRequests.WithLabelValues(tenant, ResultSuccess).Add(float64(succeeded))
+	tenantRequests.WithLabelValues(tenant, ResultFailed).Add(float64(failed))
+}
+
+// RecordTenantBatch increments the count of batches of a tenant that reached a final status.
+func RecordTenantBatch(tenantID string, status string) {
+	tenantBatches.WithLabelValues(tenantLabels.Value(tenantID), status).Inc()
+}
+
+// RecordTenantResultBytes adds the bytes of the result files stored for a batch of a tenant.
+func RecordTenantResultBytes(tenantID string, bytes int64) {
+	tenantResultBytes.WithLabelValues(tenantLabels.Value(tenantID)).Add(float64(bytes))
+}
+
+// RecordDedupCacheHit increments the count of requests answered from the dedup cache.
+func RecordDedupCacheHit(model string) {
+	dedupCacheHits.WithLabelValues(modelLabels.Value(model)).Inc()
+}
+
+// RecordBatchReaped increments the count of stuck batches expired by the reaper.
+func RecordBatchReaped(status string) {
+	batchesReaped.WithLabelValues(status).Inc()
+}
+
+// SetQueueStats sets the gauges of the jobs in a queue.
+func SetQueueStats(queue string, waiting, delayed, leased int, oldestJobAge time.Duration) {
+	queueJobs.WithLabelValues(queue, "waiting").Set(float64(waiting))
+	queueJobs.WithLabelValues(queue, "delayed").Set(float64(delayed))
+	queueJobs.WithLabelValues(queue, "leased").Set(float64(leased))
+	queueOldestJobAge.WithLabelValues(queue).Set(oldestJobAge.Seconds())
+}
+
+// RecordEventSinkDelivery increments the count of batch lifecycle events forwarded to an event sink.
+func RecordEventSinkDelivery(sink, result string) {
+	eventSinkDeliveries.WithLabelValues(sink, result).Inc()
+}
+
+// RecordEventsArchived adds the batch lifecycle events written to the event archive, failed to be written or dropped.
+func RecordEventsArchived(result string, events int) {
+	eventsArchived.WithLabelValues(result).Add(float64(events))
+}
+
+// SetMemoryUsage sets the gauges of the memory used by the processor and whether dispatch is throttled.
+func SetMemoryUsage(bytes int64, throttled bool) {
+	memoryUsage.Set(float64(bytes))
+	if throttled {
+		memoryThrottled.Set(1)
+	} else {
+		memoryThrottled.Set(0)
+	}
+}
+
+// RecordTaskReclaimed increments the count of jobs returned to the queue after the worker processing them died.
+func RecordTaskReclaimed(processorID string) {
+	tasksReclaimed.WithLabelValues(processorID).Inc()
+}
+
+// RecordJobDequeued increments the count of jobs dequeued from a queue.
+func RecordJobDequeued(queue string) {
+	jobsDequeued.WithLabelValues(queue).Inc()
+}
+
+// RecordRequestRetry increments the count of retried inference requests of a model.
+func RecordRequestRetry(model string) {
+	requestRetries.WithLabelValues(modelLabels.Value(model)).Inc()
+}
+
+// RecordRateLimitedRequest increments the count of inference requests of a model delayed by the rate limits.
+func RecordRateLimitedRequest(model string) {
+	rateLimitedRequests.WithLabelValues(modelLabels.Value(model)).Inc()
+}
+
+// RecordBatchDuration observes the time from the start of the processing of a batch to its final status,
+// with an exemplar linking to the trace of the batch if traceID is set.
+func RecordBatchDuration(duration time.Duration, model string, status string, traceID string) {
+	observe(batchDuration.WithLabelValues(modelLabels.Value(model), status), duration.Seconds(), traceID)
+}
+
+// RecordBatchStageDuration observes the time a batch that reached its final status spent in a lifecycle stage.
+func RecordBatchStageDuration(stage string, duration time.Duration, sizeBucket string, status string) {
+	batchStageDuration.WithLabelValues(stage, sizeBucket, status).Observe(duration.Seconds())
+}
+
+// RecordInferenceDuration observes the duration of an attempt of an inference request of a model, with an exemplar
+// linking to the trace of the request if traceID is set.
+func RecordInferenceDuration(duration time.Duration, model string, result string, traceID string) {
+	observe(inferenceDuration.WithLabelValues(modelLabels.Value(model), result), duration.Seconds(), traceID)
+}
+
+// observe adds the value to the histogram, with an exemplar labeled with the trace ID if it is set, so a
+// spike of the histogram leads to a representative trace. Exemplars are exposed in the OpenMetrics format only.
+func observe(observer prometheus.Observer, value float64, traceID string) {
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && traceID != "" {
+		exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{ExemplarTraceIDLabel: traceID})
+		return
+	}
+	observer.Observe(value)
+}
+
+// RecordBatchThroughput observes the number of lines of a batch processed per second.
+func RecordBatchThroughput(linesPerSecond float64, model string) {
+	batchThroughput.WithLabelValues(modelLabels.Value(model)).Observe(linesPerSecond)
+}
+
+// RecordTimeToFirstLine observes the time from the start of the processing of a batch to the result of its first line.
+func RecordTimeToFirstLine(duration time.Duration, model string) {
+	timeToFirstLine.WithLabelValues(modelLabels.Value(model)).Observe(duration.Seconds())
+}
+
+// RecordInferenceFailure increments the count of failed inference requests of a model by error category.
+func RecordInferenceFailure(model string, category string) {
+	inferenceFailures.WithLabelValues(modelLabels.Value(model), category).Inc()
 }
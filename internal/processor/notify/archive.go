@@ -0,0 +1,178 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The sink archiving the lifecycle events of batches in the files store.
+
+package notify
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"k8s.io/klog/v2"
+
+	filesapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// ArchiveSinkName is the name of the archive sink in the delivery metrics and logs.
+const ArchiveSinkName = "archive"
+
+// ArchiveSink buffers the events and writes them to the files store every interval, as gzip-compressed JSONL
+// objects partitioned by the day of the events: <prefix>/<YYYY-MM-DD>/<write time>-<id>.jsonl.gz.
+// The events buffered when a write fails are written with the next one.
+type ArchiveSink struct {
+	files     filesapi.BatchFilesClient
+	prefix    string
+	maxEvents int
+	logger    klog.Logger
+
+	mu     sync.Mutex
+	events []*Event
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// NewArchiveSink returns a sink archiving the events in files, and starts its periodic writes.
+func NewArchiveSink(cfg config.EventArchiveConfig, files filesapi.BatchFilesClient) *ArchiveSink {
+	s := &ArchiveSink{
+		files:     files,
+		prefix:    cfg.Prefix,
+		maxEvents: cfg.MaxBufferedEvents,
+		logger:    klog.Background().WithName("notify").WithValues("sink", ArchiveSinkName),
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go s.run(cfg.Interval)
+	return s
+}
+
+func (s *ArchiveSink) Name() string {
+	return ArchiveSinkName
+}
+
+// Send buffers the event until the next write. The oldest events are dropped when the buffer is full.
+func (s *ArchiveSink) Send(ctx context.Context, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.events) >= s.maxEvents {
+		dropped := len(s.events) - s.maxEvents + 1
+		s.events = s.events[dropped:]
+		metrics.RecordEventsArchived(metrics.ResultDropped, dropped)
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+// Close stops the periodic writes and writes the buffered events.
+func (s *ArchiveSink) Close(ctx context.Context) error {
+	close(s.stop)
+	<-s.stopped
+	return s.Flush(ctx)
+}
+
+func (s *ArchiveSink) run(interval time.Duration) {
+	defer close(s.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Flush(context.Background()); err != nil {
+				s.logger.V(logging.ERROR).Error(err, "Failed to archive batch events")
+			}
+		}
+	}
+}
+
+// Flush writes the buffered events, one object per day of the events. The events of the objects that couldn't be
+// written are kept in the buffer.
+func (s *ArchiveSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	events := s.events
+	s.events = nil
+	s.mu.Unlock()
+	if len(events) == 0 {
+		return nil
+	}
+
+	days := map[string][]*Event{}
+	var order []string
+	for _, event := range events {
+		day := event.Time.UTC().Format(time.DateOnly)
+		if _, ok := days[day]; !ok {
+			order = append(order, day)
+		}
+		days[day] = append(days[day], event)
+	}
+	var failed []*Event
+	var firstErr error
+	now := time.Now().UTC()
+	for _, day := range order {
+		if err := s.write(ctx, day, now, days[day]); err != nil {
+			failed = append(failed, days[day]...)
+			if firstErr == nil {
+				firstErr = err
+			}
+			metrics.RecordEventsArchived(metrics.ResultFailed, len(days[day]))
+			continue
+		}
+		metrics.RecordEventsArchived(metrics.ResultSuccess, len(days[day]))
+	}
+	if len(failed) > 0 {
+		// the events published during the write follow the ones to write again
+		s.mu.Lock()
+		s.events = append(failed, s.events...)
+		if len(s.events) > s.maxEvents {
+			dropped := len(s.events) - s.maxEvents
+			s.events = s.events[dropped:]
+			metrics.RecordEventsArchived(metrics.ResultDropped, dropped)
+		}
+		s.mu.Unlock()
+	}
+	return firstErr
+}
+
+// write stores the events of a day as an object of the archive.
+func (s *ArchiveSink) write(ctx context.Context, day string, now time.Time, events []*Event) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	location := fmt.Sprintf("%s/%s/%s-%s.jsonl.gz", s.prefix, day, now.Format("20060102T150405Z"), uuid.NewString()[:8])
+	if _, err := s.files.Store(ctx, location, 0, &buf); err != nil {
+		return fmt.Errorf("failed to store %s: %w", location, err)
+	}
+	return nil
+}
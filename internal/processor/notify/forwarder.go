@@ -0,0 +1,163 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The forwarder delivering the lifecycle events of batches to the sinks in the background.
+
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+const (
+	// maxPendingEvents bounds the events waiting for delivery to a sink, the events published when it is full are dropped.
+	maxPendingEvents = 1000
+
+	// the attempts to deliver an event to a sink, and the delay before the first retry, doubled on each retry
+	maxSendAttempts  = 3
+	initialSendDelay = time.Second
+)
+
+// Forwarder delivers the published events to its sinks in the background, each sink from its own goroutine so
+// a slow sink doesn't hold back the others. Events are delivered at least once per sink when it responds,
+// in the order they were published.
+type Forwarder struct {
+	logger klog.Logger
+	queues []*sinkQueue
+
+	// cancels the deliveries in progress when Close gives up waiting for the pending events
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type sinkQueue struct {
+	sink   Sink
+	events chan *Event
+}
+
+// NewForwarder returns a forwarder delivering events to the sinks, and starts its delivery goroutines.
+func NewForwarder(sinks []Sink) *Forwarder {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &Forwarder{
+		logger: klog.Background().WithName("notify"),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for _, sink := range sinks {
+		q := &sinkQueue{sink: sink, events: make(chan *Event, maxPendingEvents)}
+		f.queues = append(f.queues, q)
+		f.wg.Add(1)
+		go f.run(q)
+	}
+	return f
+}
+
+// Publish queues the event for delivery to every sink without blocking. A nil forwarder discards the event.
+func (f *Forwarder) Publish(event *Event) {
+	if f == nil {
+		return
+	}
+	for _, q := range f.queues {
+		select {
+		case q.events <- event:
+		default:
+			metrics.RecordEventSinkDelivery(q.sink.Name(), metrics.ResultDropped)
+			f.logger.V(logging.WARNING).Info("Dropped batch event, too many events are pending delivery",
+				"sink", q.sink.Name(), "batchID", event.BatchID, "status", event.Status)
+		}
+	}
+}
+
+// Close stops accepting events and waits until the pending events are delivered or ctx is done, in which case
+// the remaining events are dropped. Events must not be published after Close.
+func (f *Forwarder) Close(ctx context.Context) {
+	if f == nil {
+		return
+	}
+	f.closeOnce.Do(func() {
+		for _, q := range f.queues {
+			close(q.events)
+		}
+	})
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		f.cancel()
+		<-done
+	}
+	f.cancel()
+}
+
+// sinkCloser is implemented by the sinks holding events, they are closed once all the events were sent to them.
+type sinkCloser interface {
+	Close(ctx context.Context) error
+}
+
+func (f *Forwarder) run(q *sinkQueue) {
+	defer f.wg.Done()
+	defer func() {
+		if closer, ok := q.sink.(sinkCloser); ok {
+			if err := closer.Close(f.ctx); err != nil {
+				f.logger.V(logging.ERROR).Error(err, "Failed to close event sink", "sink", q.sink.Name())
+			}
+		}
+	}()
+	for event := range q.events {
+		if f.ctx.Err() != nil {
+			metrics.RecordEventSinkDelivery(q.sink.Name(), metrics.ResultDropped)
+			continue
+		}
+		if err := f.send(q.sink, event); err != nil {
+			metrics.RecordEventSinkDelivery(q.sink.Name(), metrics.ResultFailed)
+			f.logger.V(logging.ERROR).Error(err, "Failed to deliver batch event",
+				"sink", q.sink.Name(), "batchID", event.BatchID, "status", event.Status)
+			continue
+		}
+		metrics.RecordEventSinkDelivery(q.sink.Name(), metrics.ResultSuccess)
+	}
+}
+
+// send delivers the event to the sink, retrying with an exponential backoff when it fails.
+func (f *Forwarder) send(sink Sink, event *Event) error {
+	delay := initialSendDelay
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = sink.Send(f.ctx, event); err == nil || attempt == maxSendAttempts {
+			return err
+		}
+		select {
+		case <-f.ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
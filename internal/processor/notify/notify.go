@@ -0,0 +1,192 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The lifecycle events of batches and the sinks forwarding them to external systems.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/settings"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/features"
+)
+
+const (
+	// EventTypePrefix prefixes the status of the batch in the type of the CloudEvents, e.g. "io.llm-d.batch.completed".
+	EventTypePrefix = "io.llm-d.batch."
+
+	// EventSource is the source of the CloudEvents.
+	EventSource = "batch-gateway/processor"
+
+	defaultSinkTimeout = 10 * time.Second
+
+	// maxErrorBodySize bounds the part of the response body of a failed delivery kept in the error.
+	maxErrorBodySize = 512
+)
+
+// Event is the lifecycle event of a batch, published when the processor changes its status.
+type Event struct {
+	ID            string                     `json:"id"`
+	Time          time.Time                  `json:"time"`
+	BatchID       string                     `json:"batch_id"`
+	Status        openai.BatchStatus         `json:"status"`
+	RequestCounts *openai.BatchRequestCounts `json:"request_counts,omitempty"`
+	OutputFileID  string                     `json:"output_file_id,omitempty"`
+	ErrorFileID   string                     `json:"error_file_id,omitempty"`
+	Errors        *openai.BatchErrors        `json:"errors,omitempty"`
+}
+
+// NewEvent returns the event of the batch moved to the status of statusInfo.
+func NewEvent(batchID string, statusInfo *openai.BatchStatusInfo) *Event {
+	event := &Event{
+		ID:           uuid.NewString(),
+		Time:         time.Now().UTC(),
+		BatchID:      batchID,
+		Status:       statusInfo.Status,
+		OutputFileID: statusInfo.OutputFileID,
+		ErrorFileID:  statusInfo.ErrorFileID,
+		Errors:       statusInfo.Errors,
+	}
+	if statusInfo.Status.IsFinal() {
+		counts := statusInfo.RequestCounts
+		event.RequestCounts = &counts
+	}
+	return event
+}
+
+// cloudEvent is an event in the structured mode of the CloudEvents HTTP binding.
+type cloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	Type            string    `json:"type"`
+	Source          string    `json:"source"`
+	ID              string    `json:"id"`
+	Time            time.Time `json:"time"`
+	Subject         string    `json:"subject"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            *Event    `json:"data"`
+}
+
+// Sink receives the lifecycle events of batches. Send is called by a single goroutine, see Forwarder.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, event *Event) error
+}
+
+// WebhookSink POSTs the events to an HTTP endpoint, as JSON objects or as CloudEvents in structured mode.
+type WebhookSink struct {
+	name     string
+	url      string
+	format   string
+	statuses []openai.BatchStatus
+	token    settings.Secret
+	client   *http.Client
+}
+
+func NewWebhookSink(cfg config.EventSinkConfig) (*WebhookSink, error) {
+	s := &WebhookSink{
+		name:   cfg.Name,
+		url:    cfg.URL,
+		format: cfg.Format,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+	if s.format == "" {
+		s.format = config.EventFormatJSON
+	}
+	if s.client.Timeout == 0 {
+		s.client.Timeout = defaultSinkTimeout
+	}
+	for _, status := range cfg.Statuses {
+		s.statuses = append(s.statuses, openai.BatchStatus(status))
+	}
+	s.token = cfg.AuthToken
+	if cfg.AuthTokenFile != "" {
+		data, err := os.ReadFile(cfg.AuthTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read auth token file of event sink %q: %w", cfg.Name, err)
+		}
+		s.token = settings.NewSecret(strings.TrimSpace(string(data)))
+	}
+	return s, nil
+}
+
+func (s *WebhookSink) Name() string {
+	return s.name
+}
+
+// Send POSTs the event, unless the sink isn't configured for the status of the batch or the webhooks feature is
+// disabled.
+func (s *WebhookSink) Send(ctx context.Context, event *Event) error {
+	if len(s.statuses) > 0 && !slices.Contains(s.statuses, event.Status) {
+		return nil
+	}
+	if !features.Enabled(features.Webhooks) {
+		return nil
+	}
+
+	var body any = event
+	contentType := "application/json"
+	if s.format == config.EventFormatCloudEvents {
+		body = &cloudEvent{
+			SpecVersion:     "1.0",
+			Type:            EventTypePrefix + string(event.Status),
+			Source:          EventSource,
+			ID:              event.ID,
+			Time:            event.Time,
+			Subject:         event.BatchID,
+			DataContentType: "application/json",
+			Data:            event,
+		}
+		contentType = "application/cloudevents+json"
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if token := s.token.Value(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("event sink %q responded with status %d: %s", s.name, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
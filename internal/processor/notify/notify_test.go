@@ -0,0 +1,332 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The unit tests of the event sinks and the forwarder.
+
+package notify
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	fsapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/fs"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/features"
+)
+
+// recordingServer records the requests it receives, and answers the first failures with a 503.
+type recordingServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	failures int
+	requests []*recordedRequest
+}
+
+type recordedRequest struct {
+	header http.Header
+	body   map[string]any
+}
+
+func newRecordingServer(t *testing.T, failures int) *recordingServer {
+	s := &recordingServer{failures: failures}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.failures > 0 {
+			s.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode event: %v", err)
+		}
+		s.requests = append(s.requests, &recordedRequest{header: r.Header, body: body})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *recordingServer) received() []*recordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*recordedRequest(nil), s.requests...)
+}
+
+func completedEvent(batchID string) *Event {
+	return NewEvent(batchID, &openai.BatchStatusInfo{
+		Status:        openai.BatchStatusCompleted,
+		OutputFileID:  "file_out",
+		RequestCounts: openai.BatchRequestCounts{Total: 3, Completed: 2, Failed: 1},
+	})
+}
+
+func TestWebhookSink(t *testing.T) {
+	ctx := context.Background()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		server := newRecordingServer(t, 0)
+		sink, err := NewWebhookSink(config.EventSinkConfig{Name: "hook", URL: server.URL, AuthTokenFile: tokenFile})
+		if err != nil {
+			t.Fatalf("NewWebhookSink() error = %v", err)
+		}
+		if err := sink.Send(ctx, completedEvent("batch_1")); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+
+		requests := server.received()
+		if len(requests) != 1 {
+			t.Fatalf("expected 1 request, got %d", len(requests))
+		}
+		if got := requests[0].header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+		}
+		if got := requests[0].header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+		body := requests[0].body
+		if body["batch_id"] != "batch_1" || body["status"] != "completed" || body["output_file_id"] != "file_out" {
+			t.Errorf("unexpected event: %v", body)
+		}
+		counts, _ := body["request_counts"].(map[string]any)
+		if counts["failed"] != float64(1) {
+			t.Errorf("unexpected request counts: %v", body["request_counts"])
+		}
+	})
+
+	t.Run("CloudEvents", func(t *testing.T) {
+		server := newRecordingServer(t, 0)
+		sink, err := NewWebhookSink(config.EventSinkConfig{Name: "hook", URL: server.URL, Format: config.EventFormatCloudEvents})
+		if err != nil {
+			t.Fatalf("NewWebhookSink() error = %v", err)
+		}
+		event := completedEvent("batch_1")
+		if err := sink.Send(ctx, event); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+
+		requests := server.received()
+		if len(requests) != 1 {
+			t.Fatalf("expected 1 request, got %d", len(requests))
+		}
+		if got := requests[0].header.Get("Content-Type"); got != "application/cloudevents+json" {
+			t.Errorf("Content-Type = %q, want application/cloudevents+json", got)
+		}
+		if got := requests[0].header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization = %q, want none", got)
+		}
+		body := requests[0].body
+		if body["specversion"] != "1.0" || body["type"] != EventTypePrefix+"completed" ||
+			body["id"] != event.ID || body["subject"] != "batch_1" || body["source"] != EventSource {
+			t.Errorf("unexpected cloud event: %v", body)
+		}
+		data, _ := body["data"].(map[string]any)
+		if data["batch_id"] != "batch_1" {
+			t.Errorf("unexpected cloud event data: %v", body["data"])
+		}
+	})
+
+	t.Run("StatusFilter", func(t *testing.T) {
+		server := newRecordingServer(t, 0)
+		sink, err := NewWebhookSink(config.EventSinkConfig{Name: "hook", URL: server.URL, Statuses: []string{"failed"}})
+		if err != nil {
+			t.Fatalf("NewWebhookSink() error = %v", err)
+		}
+		if err := sink.Send(ctx, completedEvent("batch_1")); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		if requests := server.received(); len(requests) != 0 {
+			t.Errorf("expected the completed event to be filtered out, got %d requests", len(requests))
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		features.Set(features.Config{features.Webhooks: false})
+		defer features.Set(nil)
+		server := newRecordingServer(t, 0)
+		sink, err := NewWebhookSink(config.EventSinkConfig{Name: "hook", URL: server.URL})
+		if err != nil {
+			t.Fatalf("NewWebhookSink() error = %v", err)
+		}
+		if err := sink.Send(ctx, completedEvent("batch_1")); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		if requests := server.received(); len(requests) != 0 {
+			t.Errorf("expected no event with the webhooks feature disabled, got %d requests", len(requests))
+		}
+	})
+
+	t.Run("ErrorStatus", func(t *testing.T) {
+		server := newRecordingServer(t, 1)
+		sink, err := NewWebhookSink(config.EventSinkConfig{Name: "hook", URL: server.URL})
+		if err != nil {
+			t.Fatalf("NewWebhookSink() error = %v", err)
+		}
+		if err := sink.Send(ctx, completedEvent("batch_1")); err == nil {
+			t.Error("expected Send() to fail on a 503 response")
+		}
+	})
+
+	t.Run("MissingTokenFile", func(t *testing.T) {
+		_, err := NewWebhookSink(config.EventSinkConfig{Name: "hook", URL: "http://localhost", AuthTokenFile: filepath.Join(t.TempDir(), "missing")})
+		if err == nil {
+			t.Error("expected NewWebhookSink() to fail when the token file is missing")
+		}
+	})
+}
+
+func TestForwarder(t *testing.T) {
+	if err := metrics.InitMetrics(*config.NewConfig()); err != nil {
+		t.Fatalf("Failed to init metrics: %v", err)
+	}
+
+	// the first sink fails once, the event is delivered by the retry
+	flaky := newRecordingServer(t, 1)
+	healthy := newRecordingServer(t, 0)
+	var sinks []Sink
+	for name, server := range map[string]*recordingServer{"flaky": flaky, "healthy": healthy} {
+		sink, err := NewWebhookSink(config.EventSinkConfig{Name: name, URL: server.URL})
+		if err != nil {
+			t.Fatalf("NewWebhookSink() error = %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	forwarder := NewForwarder(sinks)
+	forwarder.Publish(completedEvent("batch_1"))
+	forwarder.Publish(completedEvent("batch_2"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	forwarder.Close(ctx)
+
+	for name, server := range map[string]*recordingServer{"flaky": flaky, "healthy": healthy} {
+		requests := server.received()
+		if len(requests) != 2 {
+			t.Fatalf("sink %s: expected 2 events, got %d", name, len(requests))
+		}
+		// the events are delivered in the order they were published
+		if requests[0].body["batch_id"] != "batch_1" || requests[1].body["batch_id"] != "batch_2" {
+			t.Errorf("sink %s: unexpected order of events: %v, %v", name, requests[0].body, requests[1].body)
+		}
+	}
+
+	// a nil forwarder, i.e. without sinks, discards the events
+	var none *Forwarder
+	none.Publish(completedEvent("batch_3"))
+	none.Close(ctx)
+}
+
+func TestArchiveSink(t *testing.T) {
+	if err := metrics.InitMetrics(*config.NewConfig()); err != nil {
+		t.Fatalf("Failed to init metrics: %v", err)
+	}
+	files, err := fsapi.NewFSFilesClient(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create files client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// readArchive returns the batch IDs of the archived events of a day, by object
+	readArchive := func(day string) [][]string {
+		t.Helper()
+		objects, err := files.List(ctx, "events/"+day+"/*.jsonl.gz")
+		if err != nil {
+			t.Fatalf("Failed to list archive: %v", err)
+		}
+		var batchIDs [][]string
+		for _, object := range objects {
+			reader, _, err := files.Retrieve(ctx, object.Location)
+			if err != nil {
+				t.Fatalf("Failed to retrieve %s: %v", object.Location, err)
+			}
+			zr, err := gzip.NewReader(reader)
+			if err != nil {
+				t.Fatalf("Archive object %s isn't gzip-compressed: %v", object.Location, err)
+			}
+			var ids []string
+			dec := json.NewDecoder(zr)
+			for dec.More() {
+				var event Event
+				if err := dec.Decode(&event); err != nil {
+					t.Fatalf("Failed to decode event of %s: %v", object.Location, err)
+				}
+				ids = append(ids, event.BatchID)
+			}
+			reader.(io.Closer).Close()
+			batchIDs = append(batchIDs, ids)
+		}
+		return batchIDs
+	}
+	eventAt := func(batchID string, at time.Time) *Event {
+		event := completedEvent(batchID)
+		event.Time = at
+		return event
+	}
+	day1 := time.Date(2026, 3, 1, 23, 59, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Minute)
+
+	t.Run("PartitionedByDay", func(t *testing.T) {
+		sink := NewArchiveSink(config.EventArchiveConfig{Interval: time.Hour, Prefix: "events", MaxBufferedEvents: 10}, files)
+		forwarder := NewForwarder([]Sink{sink})
+		forwarder.Publish(eventAt("batch_1", day1))
+		forwarder.Publish(eventAt("batch_2", day2))
+		forwarder.Publish(eventAt("batch_3", day1))
+		// the buffered events are written when the forwarder is closed
+		forwarder.Close(ctx)
+
+		if got := readArchive("2026-03-01"); !reflect.DeepEqual(got, [][]string{{"batch_1", "batch_3"}}) {
+			t.Errorf("archive of 2026-03-01 = %v", got)
+		}
+		if got := readArchive("2026-03-02"); !reflect.DeepEqual(got, [][]string{{"batch_2"}}) {
+			t.Errorf("archive of 2026-03-02 = %v", got)
+		}
+	})
+
+	t.Run("BufferLimit", func(t *testing.T) {
+		day := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+		sink := NewArchiveSink(config.EventArchiveConfig{Interval: time.Hour, Prefix: "events", MaxBufferedEvents: 2}, files)
+		for _, batchID := range []string{"batch_1", "batch_2", "batch_3"} {
+			if err := sink.Send(ctx, eventAt(batchID, day)); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+		}
+		if err := sink.Close(ctx); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		// the oldest event was dropped
+		if got := readArchive("2026-04-01"); !reflect.DeepEqual(got, [][]string{{"batch_2", "batch_3"}}) {
+			t.Errorf("archive of 2026-04-01 = %v", got)
+		}
+	})
+}
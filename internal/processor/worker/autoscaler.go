@@ -0,0 +1,108 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the autoscaler adjusting the number of active workers to the queue depth and upstream errors.
+
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// minRequestsForErrorRate is the number of inference requests in an interval below which the error rate is ignored
+const minRequestsForErrorRate = 10
+
+// inferenceStats counts the inference requests and the ones failing with upstream (retryable) errors.
+type inferenceStats struct {
+	requests       atomic.Int64
+	upstreamErrors atomic.Int64
+}
+
+func (s *inferenceStats) record(err *batch.InferenceError) {
+	s.requests.Add(1)
+	if err != nil && err.IsRetryable() {
+		s.upstreamErrors.Add(1)
+	}
+}
+
+// errorRate returns the fraction of the requests that failed with upstream errors since the last call.
+func (s *inferenceStats) errorRate() float64 {
+	requests := s.requests.Swap(0)
+	upstreamErrors := s.upstreamErrors.Swap(0)
+	if requests < minRequestsForErrorRate {
+		return 0
+	}
+	return float64(upstreamErrors) / float64(requests)
+}
+
+// desiredWorkers returns the number of workers for the current load, between minWorkers and maxWorkers.
+// Workers are removed quickly while upstream errors are high, added for all the queued jobs, and removed
+// one at a time while idle.
+func desiredWorkers(current, busy, queueDepth, minWorkers, maxWorkers int, errorRate, maxErrorRate float64) int {
+	var desired int
+	switch {
+	case errorRate > maxErrorRate:
+		desired = current - max(1, current/4)
+	case queueDepth > 0:
+		desired = max(current, busy+queueDepth)
+	default:
+		desired = max(busy, current-1)
+	}
+	return max(minWorkers, min(desired, maxWorkers))
+}
+
+// runAutoscaler adjusts the number of active workers every autoscale interval until ctx is done.
+func (p *Processor) runAutoscaler(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	ticker := time.NewTicker(p.cfg.AutoscaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p.autoscale(ctx, logger)
+	}
+}
+
+func (p *Processor) autoscale(ctx context.Context, logger klog.Logger) {
+	queueDepth, err := p.queues.len(ctx)
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to get the queue depth, not scaling workers")
+		return
+	}
+	errorRate := p.inferenceStats.errorRate()
+	busy, current := p.workerPool.Stats()
+
+	desired := desiredWorkers(current, busy, queueDepth, p.cfg.MinWorkers, p.workerPool.Size(), errorRate, p.cfg.AutoscaleMaxErrorRate)
+	if desired == current {
+		return
+	}
+	desired = p.workerPool.SetLimit(desired)
+	metrics.SetTotalWorkers(desired)
+	logger.V(logging.INFO).Info("Scaled workers",
+		"from", current, "to", desired, "busy", busy, "queueDepth", queueDepth, "upstreamErrorRate", errorRate)
+}
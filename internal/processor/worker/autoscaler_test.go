@@ -0,0 +1,198 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the worker autoscaling.
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	mockapi "github.com/llm-d-incubation/batch-gateway/internal/database/mock"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"k8s.io/klog/v2"
+)
+
+func TestDesiredWorkers(t *testing.T) {
+	tests := []struct {
+		name       string
+		current    int
+		busy       int
+		queueDepth int
+		errorRate  float64
+		want       int
+	}{
+		{name: "scale up for queued jobs", current: 2, busy: 2, queueDepth: 3, want: 5},
+		{name: "scale up bounded by max", current: 4, busy: 4, queueDepth: 20, want: 10},
+		{name: "queued jobs with idle workers", current: 6, busy: 2, queueDepth: 1, want: 6},
+		{name: "scale down when idle", current: 6, busy: 2, want: 5},
+		{name: "keep busy workers", current: 3, busy: 3, want: 3},
+		{name: "scale down bounded by min", current: 2, busy: 0, want: 2},
+		{name: "back off on upstream errors", current: 8, busy: 8, queueDepth: 5, errorRate: 0.5, want: 6},
+		{name: "back off bounded by min", current: 2, busy: 2, errorRate: 0.5, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := desiredWorkers(tt.current, tt.busy, tt.queueDepth, 2, 10, tt.errorRate, 0.2); got != tt.want {
+				t.Errorf("desiredWorkers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferenceStatsErrorRate(t *testing.T) {
+	stats := &inferenceStats{}
+	for i := 0; i < 5; i++ {
+		stats.record(&batch.InferenceError{Category: batch.ErrCategoryRateLimit})
+	}
+	if rate := stats.errorRate(); rate != 0 {
+		t.Errorf("errorRate() with few requests = %v, want 0", rate)
+	}
+
+	for i := 0; i < 10; i++ {
+		var err *batch.InferenceError
+		switch i % 5 {
+		case 0:
+			err = &batch.InferenceError{Category: batch.ErrCategoryServer}
+		case 1:
+			err = &batch.InferenceError{Category: batch.ErrCategoryInvalidReq} // not an upstream error
+		}
+		stats.record(err)
+	}
+	if rate := stats.errorRate(); rate != 0.2 {
+		t.Errorf("errorRate() = %v, want 0.2", rate)
+	}
+	if rate := stats.errorRate(); rate != 0 {
+		t.Errorf("errorRate() after reset = %v, want 0", rate)
+	}
+}
+
+func TestWorkerPoolLimit(t *testing.T) {
+	pool := NewWorkerPool(3)
+	if limit := pool.SetLimit(1); limit != 1 {
+		t.Fatalf("SetLimit(1) = %v, want 1", limit)
+	}
+	id, ok := pool.TryAcquire()
+	if !ok || id != 1 {
+		t.Fatalf("TryAcquire() = %v, %v, want 1, true", id, ok)
+	}
+	if _, ok := pool.TryAcquire(); ok {
+		t.Fatalf("TryAcquire() above the limit succeeded")
+	}
+
+	// raising the limit wakes up a waiting acquisition
+	acquired := make(chan int)
+	go func() {
+		id, _ := pool.Acquire(context.Background())
+		acquired <- id
+	}()
+	pool.SetLimit(5)
+	select {
+	case id := <-acquired:
+		if id != 2 {
+			t.Errorf("Acquire() = %v, want 2", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Acquire() did not return after raising the limit")
+	}
+	if busy, limit := pool.Stats(); busy != 2 || limit != 3 {
+		t.Errorf("Stats() = %v, %v, want 2, 3", busy, limit)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pool.SetLimit(1)
+	if _, ok := pool.Acquire(ctx); ok {
+		t.Errorf("Acquire() above the limit succeeded")
+	}
+	pool.Release(1)
+	pool.Release(2)
+	pool.WaitAll()
+}
+
+func TestWorkerPoolResize(t *testing.T) {
+	pool := NewWorkerPool(3)
+	for want := 1; want <= 3; want++ {
+		if id, ok := pool.TryAcquire(); !ok || id != want {
+			t.Fatalf("TryAcquire() = %v, %v, want %v, true", id, ok, want)
+		}
+	}
+
+	// busy workers above the new size are not stopped, they are removed once released
+	if limit := pool.Resize(2); limit != 2 {
+		t.Errorf("Resize(2) = %v, want 2", limit)
+	}
+	pool.Release(3)
+	pool.Release(2)
+	if id, ok := pool.TryAcquire(); !ok || id != 2 {
+		t.Fatalf("TryAcquire() = %v, %v, want 2, true", id, ok)
+	}
+	if _, ok := pool.TryAcquire(); ok {
+		t.Fatalf("TryAcquire() above the new size succeeded")
+	}
+
+	// growing adds the missing workers, the limit is raised separately
+	pool.Resize(4)
+	if _, ok := pool.TryAcquire(); ok {
+		t.Fatalf("TryAcquire() above the limit succeeded")
+	}
+	pool.SetLimit(4)
+	for want := 3; want <= 4; want++ {
+		if id, ok := pool.TryAcquire(); !ok || id != want {
+			t.Fatalf("TryAcquire() = %v, %v, want %v, true", id, ok, want)
+		}
+	}
+	if busy, limit := pool.Stats(); busy != 4 || limit != 4 || pool.Size() != 4 {
+		t.Errorf("Stats() = %v, %v, Size() = %v, want 4, 4, 4", busy, limit, pool.Size())
+	}
+	for id := 1; id <= 4; id++ {
+		pool.Release(id)
+	}
+	pool.WaitAll()
+}
+
+func TestAutoscale(t *testing.T) {
+	queue := mockapi.NewMockBatchPriorityQueueClient()
+	clients := NewProcessorClients(mockapi.NewMockBatchDBClient(), mockapi.NewMockBatchFileDBClient(), queue, mockapi.NewMockBatchDeadLetterClient(),
+		mockapi.NewMockBatchStatusClient(), mockapi.NewMockBatchEventChannelClient(), nil, &fakeInferenceClient{})
+	cfg := config.NewConfig()
+	cfg.NumWorkers = 8
+	cfg.MinWorkers = 2
+	cfg.AutoscaleEnabled = true
+	p := NewProcessor(cfg, &clients)
+	ctx := context.Background()
+	logger := klog.FromContext(ctx)
+
+	if _, limit := p.workerPool.Stats(); limit != 2 {
+		t.Fatalf("initial limit = %v, want 2", limit)
+	}
+	for i := 0; i < 4; i++ {
+		queue.Enqueue(ctx, &db.BatchJobPriority{ID: string(rune('a' + i)), SLO: time.Now().Add(time.Hour)})
+	}
+	p.autoscale(ctx, logger)
+	if _, limit := p.workerPool.Stats(); limit != 4 {
+		t.Errorf("limit with queued jobs = %v, want 4", limit)
+	}
+
+	queue.Dequeue(ctx, 0, 4)
+	p.autoscale(ctx, logger)
+	if _, limit := p.workerPool.Stats(); limit != 3 {
+		t.Errorf("limit when idle = %v, want 3", limit)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the stopping of the jobs whose batch is cancelled, or failed by an operator, while they are
+// processed.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"k8s.io/klog/v2"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// errCancelled stops the processing of a job when its batch is cancelled, or failed by an operator.
+var errCancelled = errors.New("the batch was cancelled")
+
+// storedStatus reads the status of the job from the database, which the API server changes when the batch is
+// cancelled, or failed by an operator, while the job is processed.
+func (p *Processor) storedStatus(ctx context.Context, jobID string) (*openai.BatchStatusInfo, error) {
+	jobs, _, err := p.clients.database.Get(ctx, []string{jobID}, nil, db.TagsLogicalCondNa, false, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("job %s not found", jobID)
+	}
+	statusInfo := &openai.BatchStatusInfo{}
+	if err := json.Unmarshal(jobs[0].Status, statusInfo); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job status: %w", err)
+	}
+	return statusInfo, nil
+}
+
+// refreshStatus reads the stored status of the job before the processor writes it, so the status set by the API
+// server isn't overwritten. It returns true if the batch was moved to a final status, e.g. failed by an operator:
+// statusInfo is set to the stored status and the job must not be written anymore. The status of a batch being
+// cancelled is set to cancelling.
+func (p *Processor) refreshStatus(ctx context.Context, jobID string, statusInfo *openai.BatchStatusInfo) (final bool) {
+	logger := klog.FromContext(ctx)
+	stored, err := p.storedStatus(ctx, jobID)
+	if err != nil {
+		// the status is written anyway, the batch is unlikely to have been stopped meanwhile
+		logger.V(logging.WARNING).Info("Failed to read stored job status", "jobID", jobID, "error", err.Error())
+		return false
+	}
+	switch {
+	case stored.Status.IsFinal():
+		logger.V(logging.INFO).Info("Job was finalized while processed, its status is kept", "jobID", jobID, "status", stored.Status)
+		*statusInfo = *stored
+		return true
+	case stored.Status == openai.BatchStatusCancelling:
+		statusInfo.Status = openai.BatchStatusCancelling
+		statusInfo.CancellingAt = stored.CancellingAt
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the stopping of the jobs cancelled, or failed by an operator.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+func TestStoppedJob(t *testing.T) {
+	ctx := context.Background()
+	// stop sets the stored status of the paused job as the API server does, and sends a cancel event if event is set
+	stop := func(t *testing.T, env *testEnv, jobID string, status openai.BatchStatus, event bool) {
+		t.Helper()
+		jobs, _, err := env.dbClient.Get(ctx, []string{jobID}, nil, db.TagsLogicalCondNa, true, 0, 1)
+		if err != nil || len(jobs) != 1 {
+			t.Fatalf("Failed to get job %s: %v", jobID, err)
+		}
+		statusInfo := &openai.BatchStatusInfo{}
+		json.Unmarshal(jobs[0].Status, statusInfo)
+		statusInfo.Status = status
+		jobs[0].Status, _ = json.Marshal(statusInfo)
+		if err := env.dbClient.Update(ctx, jobs[0]); err != nil {
+			t.Fatalf("Failed to update job %s: %v", jobID, err)
+		}
+		if event {
+			env.processor.clients.event.ProducerSendEvents(ctx, []db.BatchEvent{{ID: jobID, Type: db.BatchEventCancel, TTL: jobStatusTTL}})
+		}
+	}
+	// process processes the job paused until it's stopped, resuming it after the stop if resume is set
+	process := func(t *testing.T, env *testEnv, job *db.BatchJob, stopJob func(), resume bool) {
+		t.Helper()
+		p := env.processor
+		if err := p.clients.status.Set(ctx, batch.PausedKey(job.ID), jobStatusTTL, []byte("1")); err != nil {
+			t.Fatalf("Failed to store pause state: %v", err)
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			p.processJob(ctx, 1, job)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		stopJob()
+		if resume {
+			p.clients.event.ProducerSendEvents(ctx, []db.BatchEvent{{ID: job.ID, Type: db.BatchEventResume, TTL: jobStatusTTL}})
+		}
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("job not stopped")
+		}
+	}
+
+	t.Run("FailedByOperator", func(t *testing.T) {
+		inference := &modelRecordingClient{}
+		env := setupProcessorForTest(t, 2, inference)
+		job := env.storeJob(t, "batch-1", time.Now().Add(time.Hour), "m1", "m1")
+		process(t, env, job, func() { stop(t, env, job.ID, openai.BatchStatusFailed, true) }, false)
+
+		if received := inference.received(); len(received) != 0 {
+			t.Errorf("got %d requests after the job was failed, want 0", len(received))
+		}
+		if status := env.getStatus(t, job.ID); status.Status != openai.BatchStatusFailed {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusFailed)
+		}
+	})
+
+	t.Run("FailedWithoutEvent", func(t *testing.T) {
+		// the job is processed, the status set by the operator isn't overwritten
+		env := setupProcessorForTest(t, 2, &modelRecordingClient{})
+		job := env.storeJob(t, "batch-2", time.Now().Add(time.Hour), "m1", "m1")
+		process(t, env, job, func() { stop(t, env, job.ID, openai.BatchStatusFailed, false) }, true)
+
+		if status := env.getStatus(t, job.ID); status.Status != openai.BatchStatusFailed {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusFailed)
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		inference := &modelRecordingClient{}
+		env := setupProcessorForTest(t, 2, inference)
+		job := env.storeJob(t, "batch-3", time.Now().Add(time.Hour), "m1", "m1")
+		process(t, env, job, func() { stop(t, env, job.ID, openai.BatchStatusCancelling, true) }, false)
+
+		if received := inference.received(); len(received) != 0 {
+			t.Errorf("got %d requests after the job was cancelled, want 0", len(received))
+		}
+		status := env.getStatus(t, job.ID)
+		if status.Status != openai.BatchStatusCancelled || status.CancelledAt == nil {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusCancelled)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the cache of the responses reused by identical requests.
+package worker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// dedupKeyPrefix prefixes the hash of a request in the status store key of its cached response.
+const dedupKeyPrefix = "dedup:"
+
+// dedupKey returns the status store key of the cached response of a request of the tenant routed to the gateway,
+// empty when the cache is disabled. The responses are only reused within a tenant and an inference gateway.
+// The parameters are hashed encoded as JSON, whose object keys are sorted, so the formatting of the line doesn't
+// change the key.
+func (p *Processor) dedupKey(tenantID string, gateway *inferenceGateway, endpoint string, params map[string]interface{}) string {
+	if p.cfg.DedupCacheTTL <= 0 {
+		return ""
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return ""
+	}
+	hash := sha256.New()
+	for _, field := range []string{tenantID, gateway.target, gateway.name, endpoint} {
+		hash.Write([]byte(field))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+	return dedupKeyPrefix + hex.EncodeToString(hash.Sum(nil))
+}
+
+// cachedResponse returns the cached response of the request of key, nil if there is none.
+func (p *Processor) cachedResponse(ctx context.Context, key string) []byte {
+	if key == "" {
+		return nil
+	}
+	data, err := p.clients.status.Get(ctx, key)
+	if err != nil {
+		// the request is sent to the model
+		klog.FromContext(ctx).V(logging.WARNING).Info("Failed to read cached response", "error", err.Error())
+		return nil
+	}
+	return data
+}
+
+// cacheResponse caches the response of the request of key, unless it exceeds DedupMaxResponseBytes.
+func (p *Processor) cacheResponse(ctx context.Context, key string, response []byte) {
+	if key == "" || len(response) == 0 || len(response) > p.cfg.DedupMaxResponseBytes {
+		return
+	}
+	ttl := max(int(p.cfg.DedupCacheTTL.Seconds()), 1)
+	if err := p.clients.status.Set(ctx, key, ttl, response); err != nil {
+		klog.FromContext(ctx).V(logging.WARNING).Info("Failed to cache response", "error", err.Error())
+	}
+}
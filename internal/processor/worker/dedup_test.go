@@ -0,0 +1,121 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the cache of the responses of identical requests.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDedupKey(t *testing.T) {
+	env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+	p := env.processor
+	decode := func(body string) map[string]interface{} {
+		var params map[string]interface{}
+		json.Unmarshal([]byte(body), &params)
+		return params
+	}
+
+	gateway := &inferenceGateway{name: "g1"}
+
+	if key := p.dedupKey("team-a", gateway, "/v1/chat/completions", decode(`{"model":"m"}`)); key != "" {
+		t.Errorf("dedupKey() = %q with the cache disabled, want none", key)
+	}
+
+	p.cfg.DedupCacheTTL = time.Hour
+	key := p.dedupKey("team-a", gateway, "/v1/chat/completions", decode(`{"model":"m","temperature":0}`))
+	if key == "" {
+		t.Fatal("dedupKey() is empty with the cache enabled")
+	}
+	if other := p.dedupKey("team-a", gateway, "/v1/chat/completions", decode(`{ "temperature": 0, "model": "m" }`)); other != key {
+		t.Errorf("dedupKey() of the same request formatted differently = %q, want %q", other, key)
+	}
+	if other := p.dedupKey("team-a", gateway, "/v1/completions", decode(`{"model":"m","temperature":0}`)); other == key {
+		t.Errorf("dedupKey() of the same body on another endpoint = %q, want another key", other)
+	}
+	if other := p.dedupKey("team-a", gateway, "/v1/chat/completions", decode(`{"model":"m","temperature":1}`)); other == key {
+		t.Errorf("dedupKey() of another body = %q, want another key", other)
+	}
+	if other := p.dedupKey("team-b", gateway, "/v1/chat/completions", decode(`{"model":"m","temperature":0}`)); other == key {
+		t.Errorf("dedupKey() of the same request of another tenant = %q, want another key", other)
+	}
+	other := p.dedupKey("team-a", &inferenceGateway{name: "g2", target: "t"}, "/v1/chat/completions", decode(`{"model":"m","temperature":0}`))
+	if other == key {
+		t.Errorf("dedupKey() of the same request routed to another gateway = %q, want another key", other)
+	}
+}
+
+func TestDedupCache(t *testing.T) {
+	t.Run("Reused", func(t *testing.T) {
+		client := &modelRecordingClient{}
+		env := setupProcessorForTest(t, 1, client)
+		env.processor.cfg.DedupCacheTTL = time.Hour
+
+		// the requests of model m are identical, within and across batches
+		first := env.storeJob(t, "batch-1", time.Now().Add(time.Hour), "m", "n", "m")
+		env.processor.processJob(context.Background(), 1, first)
+		second := env.storeJob(t, "batch-2", time.Now().Add(time.Hour), "m")
+		env.processor.processJob(context.Background(), 1, second)
+
+		if got := client.received(); len(got) != 2 {
+			t.Errorf("model received %v, want [m n]", got)
+		}
+		for _, job := range []string{first.ID, second.ID} {
+			status := env.getStatus(t, job)
+			if status.RequestCounts.Failed != 0 || status.RequestCounts.Completed != status.RequestCounts.Total {
+				t.Errorf("RequestCounts of %s = %+v, want all completed", job, status.RequestCounts)
+			}
+		}
+		lines := env.readResultFile(t, env.getStatus(t, second.ID).OutputFileID)
+		if len(lines) != 1 || string(lines[0].Response.Body) != `{}` {
+			t.Errorf("output lines = %+v, want the cached response", lines)
+		}
+	})
+
+	t.Run("Tenants", func(t *testing.T) {
+		client := &modelRecordingClient{}
+		env := setupProcessorForTest(t, 1, client)
+		env.processor.cfg.DedupCacheTTL = time.Hour
+
+		// the batches of the tenants have identical lines
+		for _, tenantID := range []string{"team-a", "team-b"} {
+			job := env.storeTenantJob(t, tenantID, "batch-"+tenantID, time.Now().Add(time.Hour), "m")
+			env.processor.processJob(context.Background(), 1, job)
+		}
+
+		if got := client.received(); len(got) != 2 {
+			t.Errorf("model received %v, want [m m]", got)
+		}
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		client := &modelRecordingClient{}
+		env := setupProcessorForTest(t, 1, client)
+		env.processor.cfg.DedupCacheTTL = time.Hour
+		env.processor.cfg.DedupMaxResponseBytes = 1
+
+		job := env.storeJob(t, "batch-3", time.Now().Add(time.Hour), "m", "m")
+		env.processor.processJob(context.Background(), 1, job)
+
+		if got := client.received(); len(got) != 2 {
+			t.Errorf("model received %v, want [m m]", got)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the dispatcher bounding the inference requests in flight across jobs, earliest deadline first.
+
+package worker
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// dispatcher limits the number of inference requests in flight across all the jobs of the processor.
+// When the limit is reached, a released slot is handed to the waiting request with the earliest deadline,
+// so lines of batches nearing the end of their completion window are sent first.
+type dispatcher struct {
+	mu       sync.Mutex
+	capacity int
+	inUse    int
+	waiters  waiterQueue
+	seq      uint64
+}
+
+// newDispatcher returns a dispatcher allowing capacity requests in flight, or nil (no limit) if capacity is 0.
+func newDispatcher(capacity int) *dispatcher {
+	if capacity <= 0 {
+		return nil
+	}
+	return &dispatcher{capacity: capacity}
+}
+
+// acquire waits for a slot, returning false if ctx is done first. A nil dispatcher always grants a slot.
+func (d *dispatcher) acquire(ctx context.Context, deadline time.Time) bool {
+	if d == nil {
+		return ctx.Err() == nil
+	}
+
+	d.mu.Lock()
+	if d.inUse < d.capacity && len(d.waiters) == 0 {
+		d.inUse++
+		d.mu.Unlock()
+		return true
+	}
+	d.seq++
+	w := &waiter{deadline: deadline, seq: d.seq, ready: make(chan struct{})}
+	heap.Push(&d.waiters, w)
+	d.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return true
+	case <-ctx.Done():
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if w.index >= 0 {
+			heap.Remove(&d.waiters, w.index)
+			return false
+		}
+		// the slot was handed over while giving up, pass it on
+		d.releaseLocked()
+		return false
+	}
+}
+
+// release frees a slot acquired with acquire.
+func (d *dispatcher) release() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.releaseLocked()
+}
+
+func (d *dispatcher) releaseLocked() {
+	if len(d.waiters) == 0 {
+		d.inUse--
+		return
+	}
+	w := heap.Pop(&d.waiters).(*waiter)
+	close(w.ready)
+}
+
+type waiter struct {
+	deadline time.Time
+	seq      uint64 // requests with the same deadline are served in arrival order
+	index    int    // index in the queue, -1 once the slot was handed over
+	ready    chan struct{}
+}
+
+// waiterQueue implements heap.Interface, ordered by deadline.
+type waiterQueue []*waiter
+
+func (q waiterQueue) Len() int { return len(q) }
+
+func (q waiterQueue) Less(i, j int) bool {
+	if !q[i].deadline.Equal(q[j].deadline) {
+		return q[i].deadline.Before(q[j].deadline)
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waiterQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waiterQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waiterQueue) Pop() any {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*q = old[:n-1]
+	return w
+}
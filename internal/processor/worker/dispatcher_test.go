@@ -0,0 +1,103 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the deadline ordered dispatcher.
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatcher(t *testing.T) {
+	t.Run("EarliestDeadlineFirst", func(t *testing.T) {
+		d := newDispatcher(1)
+		ctx := context.Background()
+		now := time.Now()
+		if !d.acquire(ctx, now) {
+			t.Fatalf("acquire() = false, want true")
+		}
+
+		// queue waiters with deadlines in reverse order, waiting until each is queued
+		order := make(chan int, 3)
+		var wg sync.WaitGroup
+		for i, offset := range []time.Duration{3 * time.Hour, time.Hour, 2 * time.Hour} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.acquire(ctx, now.Add(offset))
+				order <- i
+				d.release()
+			}()
+			waitForWaiters(t, d, i+1)
+		}
+		d.release()
+
+		for _, want := range []int{1, 2, 0} {
+			if got := <-order; got != want {
+				t.Errorf("dispatched waiter %d, want %d", got, want)
+			}
+		}
+		wg.Wait()
+		if d.inUse != 0 {
+			t.Errorf("inUse = %d, want 0", d.inUse)
+		}
+	})
+
+	t.Run("CancelledWaiter", func(t *testing.T) {
+		d := newDispatcher(1)
+		d.acquire(context.Background(), time.Now())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan bool)
+		go func() { done <- d.acquire(ctx, time.Now()) }()
+		waitForWaiters(t, d, 1)
+		cancel()
+		if <-done {
+			t.Errorf("acquire() with cancelled context = true, want false")
+		}
+
+		d.release()
+		if d.inUse != 0 || len(d.waiters) != 0 {
+			t.Errorf("inUse = %d, waiters = %d, want 0, 0", d.inUse, len(d.waiters))
+		}
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		d := newDispatcher(0)
+		for i := 0; i < 3; i++ {
+			if !d.acquire(context.Background(), time.Time{}) {
+				t.Fatalf("acquire() = false, want true")
+			}
+		}
+		d.release()
+	})
+}
+
+func waitForWaiters(t *testing.T, d *dispatcher, n int) {
+	t.Helper()
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		d.mu.Lock()
+		queued := len(d.waiters)
+		d.mu.Unlock()
+		if queued == n {
+			return
+		}
+	}
+	t.Fatalf("expected %d waiters", n)
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the draining of jobs on shutdown and the checkpoints their processing is resumed from.
+
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// errDraining stops the processing of a job when the processor shuts down.
+var errDraining = errors.New("processor is draining")
+
+const (
+	// checkpointKeyPrefix prefixes the job ID in the status store key of the checkpoint of a drained job.
+	checkpointKeyPrefix = "checkpoint:"
+
+	// checkpointLocationPrefix is the files store location under which the results of drained jobs are kept.
+	checkpointLocationPrefix = "checkpoints/"
+)
+
+// jobCheckpoint records the progress of a job drained on shutdown, so the next delivery of the job
+// only processes the lines that have no result yet.
+type jobCheckpoint struct {
+	// Lines is the number of input lines read before draining; the lines after them were not started.
+	Lines     int64 `json:"lines"`
+	Succeeded int   `json:"succeeded"`
+	Failed    int   `json:"failed"`
+
+	// the files store locations of the output and error lines written before draining
+	OutputFiles []string `json:"output_files,omitempty"`
+	ErrorFiles  []string `json:"error_files,omitempty"`
+
+	// Aborted is the reason the job was aborted by one of its shards, if it was
+	Aborted string `json:"aborted,omitempty"`
+}
+
+func checkpointKey(jobID string) string {
+	return checkpointKeyPrefix + jobID
+}
+
+// withDrainDeadline returns a context cancelled with errDraining DrainTimeout after the processor started draining,
+// bounding the time the requests in flight are given to finish.
+func (p *Processor) withDrainDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(p.draining, func() {
+		select {
+		case <-ctx.Done():
+		case <-time.After(p.cfg.DrainTimeout):
+		}
+		cancel(errDraining)
+	})
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// Drain makes the processor stop claiming tasks and drain the jobs in progress as on shutdown, e.g. from the
+// preStop hook of its pod. It waits until the drained jobs are back in the queue, returning the error of ctx if
+// it's done first. The processor keeps running without processing jobs until it's stopped.
+func (p *Processor) Drain(ctx context.Context) error {
+	if !p.isDraining() {
+		klog.FromContext(ctx).V(logging.INFO).Info("Draining processor")
+		p.startDrain()
+	}
+	if !p.workerPool.WaitIdle(ctx) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// isDraining reports whether the processor is shutting down.
+func (p *Processor) isDraining() bool {
+	return p.draining.Err() != nil
+}
+
+// saveCheckpoint stores the results of a drained job and records how far its input file was read.
+// The files of an earlier checkpoint are deleted, as their lines are part of the new one.
+func (p *Processor) saveCheckpoint(
+	ctx context.Context, jobID string, lines int64, results *jobResults, metadata batch.JobResultMetadata, previous *jobCheckpoint,
+) error {
+	prefix := fmt.Sprintf("%s%s/%d_", checkpointLocationPrefix, jobID, time.Now().UnixNano())
+	checkpoint := &jobCheckpoint{
+		Lines:     lines,
+		Succeeded: metadata.Succeeded,
+		Failed:    metadata.Failed,
+	}
+	var err error
+	if checkpoint.OutputFiles, err = p.storeCheckpointFiles(ctx, results.output, prefix+"output"); err != nil {
+		return err
+	}
+	if checkpoint.ErrorFiles, err = p.storeCheckpointFiles(ctx, results.errors, prefix+"error"); err != nil {
+		p.deleteCheckpointFiles(ctx, checkpoint)
+		return err
+	}
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		p.deleteCheckpointFiles(ctx, checkpoint)
+		return err
+	}
+	if err := p.clients.status.Set(ctx, checkpointKey(jobID), jobStatusTTL, data); err != nil {
+		p.deleteCheckpointFiles(ctx, checkpoint)
+		return fmt.Errorf("failed to store checkpoint: %w", err)
+	}
+	if previous != nil {
+		p.deleteCheckpointFiles(ctx, previous)
+	}
+	return nil
+}
+
+// storeCheckpointFiles stores the shards of a result file under prefix, returning their locations.
+func (p *Processor) storeCheckpointFiles(ctx context.Context, w *resultWriter, prefix string) ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	locations := make([]string, 0, len(w.shards))
+	for i, shard := range w.shards {
+		if _, err := shard.file.Seek(0, io.SeekStart); err != nil {
+			return locations, err
+		}
+		location := fmt.Sprintf("%s_%05d.jsonl", prefix, i+1)
+		if _, err := p.clients.files.Store(ctx, location, 0, shard.file); err != nil {
+			return locations, fmt.Errorf("failed to store checkpoint file %s: %w", location, err)
+		}
+		locations = append(locations, location)
+	}
+	return locations, nil
+}
+
+// loadCheckpoint returns the checkpoint of a job drained on an earlier delivery, or nil if there is none.
+func (p *Processor) loadCheckpoint(ctx context.Context, jobID string) (*jobCheckpoint, error) {
+	data, err := p.clients.status.Get(ctx, checkpointKey(jobID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checkpoint: %w", err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+	checkpoint := &jobCheckpoint{}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal checkpoint: %w", err)
+	}
+	return checkpoint, nil
+}
+
+// restoreCheckpoint writes the result lines of the checkpoint to results and the counts of their lines to progress.
+func (p *Processor) restoreCheckpoint(
+	ctx context.Context, checkpoint *jobCheckpoint, results *jobResults, progress *jobProgress,
+) error {
+	restore := func(locations []string, succeeded bool) error {
+		for _, location := range locations {
+			reader, _, err := p.clients.files.Retrieve(ctx, location)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve checkpoint file %s: %w", location, err)
+			}
+			err = results.restore(reader, succeeded)
+			if closer, ok := reader.(io.Closer); ok {
+				closer.Close()
+			}
+			if err != nil {
+				return fmt.Errorf("failed to restore checkpoint file %s: %w", location, err)
+			}
+		}
+		return nil
+	}
+	if err := restore(checkpoint.OutputFiles, true); err != nil {
+		return err
+	}
+	if err := restore(checkpoint.ErrorFiles, false); err != nil {
+		return err
+	}
+	progress.restore(batch.JobResultMetadata{
+		Total:     checkpoint.Succeeded + checkpoint.Failed,
+		Succeeded: checkpoint.Succeeded,
+		Failed:    checkpoint.Failed,
+	})
+	return nil
+}
+
+// clearCheckpoint deletes the checkpoint of a job once its processing is over.
+func (p *Processor) clearCheckpoint(ctx context.Context, jobID string, checkpoint *jobCheckpoint) {
+	if checkpoint == nil {
+		return
+	}
+	if err := p.clients.status.Delete(ctx, checkpointKey(jobID)); err != nil {
+		klog.FromContext(ctx).V(logging.ERROR).Error(err, "Failed to delete checkpoint", "jobID", jobID)
+	}
+	p.deleteCheckpointFiles(ctx, checkpoint)
+}
+
+func (p *Processor) deleteCheckpointFiles(ctx context.Context, checkpoint *jobCheckpoint) {
+	logger := klog.FromContext(ctx)
+	for _, location := range slices.Concat(checkpoint.OutputFiles, checkpoint.ErrorFiles) {
+		if err := p.clients.files.Delete(ctx, location); err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to delete checkpoint file", "location", location)
+		}
+	}
+}
+
+// requeueDrained puts a job drained on shutdown back to the queue. Draining isn't a failed delivery,
+// so the delivery attempts of the job are unchanged.
+func (p *Processor) requeueDrained(ctx context.Context, task *db.BatchJobPriority) {
+	logger := klog.FromContext(ctx)
+	if err := p.queues.client(task.Queue).Enqueue(ctx, task); err != nil {
+		// the lease is kept, so the job is reclaimed when it expires
+		logger.V(logging.ERROR).Error(err, "Failed to requeue drained job", "jobID", task.ID)
+		return
+	}
+	p.ackLease(ctx, task)
+	logger.V(logging.INFO).Info("Requeued drained job", "jobID", task.ID)
+}
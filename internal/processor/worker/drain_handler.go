@@ -0,0 +1,76 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the drain endpoint, called by the preStop hook of the processor pod so that evictions
+// don't lose the work in progress.
+
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	// DrainPath is the path of the drain endpoint, served on the observability address.
+	DrainPath = "/drain"
+
+	// DefaultDrainWaitTimeout bounds the time the drain endpoint waits for the jobs in progress to be drained,
+	// unless the request sets the timeout query parameter.
+	DefaultDrainWaitTimeout = time.Minute
+)
+
+// DrainResponse is the response of the drain endpoint.
+type DrainResponse struct {
+	// Drained is true once the jobs in progress are drained and back in the queue.
+	Drained bool `json:"drained"`
+
+	// ActiveJobs is the number of jobs still in progress.
+	ActiveJobs int `json:"active_jobs"`
+}
+
+// DrainHandler puts the processor into drain mode and blocks until the jobs in progress are drained, for at most
+// the duration of the timeout query parameter. It answers 200 once drained, and 503 if the jobs are still in
+// progress at the deadline. GET is accepted, as it's the only method of the HTTP preStop hooks.
+func (p *Processor) DrainHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	timeout := DefaultDrainWaitTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "invalid timeout: must be a positive duration", http.StatusBadRequest)
+			return
+		}
+		timeout = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	err := p.Drain(ctx)
+	busy, _ := p.workerPool.Stats()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(&DrainResponse{Drained: err == nil, ActiveJobs: busy})
+}
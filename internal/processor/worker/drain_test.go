@@ -0,0 +1,178 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the draining of jobs on shutdown.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// resetDrain makes a drained processor process jobs again, as a new processor would.
+func resetDrain(p *Processor) {
+	p.draining, p.startDrain = context.WithCancel(context.Background())
+}
+
+func TestDrain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ResumedFromCheckpoint", func(t *testing.T) {
+		env := setupProcessorForTest(t, 2, &fakeInferenceClient{delay: 50 * time.Millisecond})
+		p := env.processor
+		job := env.storeJob(t, "batch-1", time.Now().Add(time.Hour), "m1", "m1", "m1", "m1", "m1", "m1", "m1", "m1")
+
+		time.AfterFunc(75*time.Millisecond, p.startDrain)
+		if drained := p.processJob(ctx, 1, job); !drained {
+			t.Fatalf("expected the job to be drained")
+		}
+		checkpoint, err := p.loadCheckpoint(ctx, job.ID)
+		if err != nil || checkpoint == nil {
+			t.Fatalf("loadCheckpoint() = %+v, %v, want a checkpoint", checkpoint, err)
+		}
+		// the lines in flight when draining finished, the others were not started
+		if checkpoint.Succeeded < 2 || checkpoint.Succeeded >= 8 || int64(checkpoint.Succeeded) != checkpoint.Lines {
+			t.Errorf("unexpected checkpoint: %+v", checkpoint)
+		}
+		if status := env.getStatus(t, job.ID); status.Status != openai.BatchStatusInProgress {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusInProgress)
+		}
+
+		resetDrain(p)
+		if drained := p.processJob(ctx, 1, job); drained {
+			t.Fatalf("expected the resumed job to complete")
+		}
+		status := env.getStatus(t, job.ID)
+		if status.Status != openai.BatchStatusCompleted {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusCompleted)
+		}
+		want := openai.BatchRequestCounts{Total: 8, Completed: 8}
+		if status.RequestCounts != want {
+			t.Errorf("RequestCounts = %+v, want %+v", status.RequestCounts, want)
+		}
+		// every line has a single result
+		seen := map[string]bool{}
+		for _, line := range env.readResultFile(t, status.OutputFileID) {
+			if seen[line.CustomID] {
+				t.Errorf("duplicate result of %s", line.CustomID)
+			}
+			seen[line.CustomID] = true
+		}
+		if len(seen) != 8 {
+			t.Errorf("got results of %d lines, want 8", len(seen))
+		}
+		if checkpoint, _ := p.loadCheckpoint(ctx, job.ID); checkpoint != nil {
+			t.Errorf("checkpoint not cleared: %+v", checkpoint)
+		}
+	})
+
+	t.Run("InterruptedAtDrainDeadline", func(t *testing.T) {
+		inference := &fakeInferenceClient{delay: time.Second}
+		env := setupProcessorForTest(t, 2, inference)
+		p := env.processor
+		p.cfg.DrainTimeout = 20 * time.Millisecond
+		job := env.storeJob(t, "batch-2", time.Now().Add(time.Hour), "m1", "m1", "m1", "m1")
+
+		time.AfterFunc(20*time.Millisecond, p.startDrain)
+		if drained := p.processJob(ctx, 1, job); !drained {
+			t.Fatalf("expected the job to be drained")
+		}
+		checkpoint, _ := p.loadCheckpoint(ctx, job.ID)
+		if checkpoint == nil || checkpoint.Lines != 2 || checkpoint.Succeeded != 0 || checkpoint.Failed != 0 {
+			t.Fatalf("unexpected checkpoint: %+v", checkpoint)
+		}
+
+		// the interrupted lines are processed again
+		inference.delay = 0
+		resetDrain(p)
+		p.processJob(ctx, 1, job)
+		status := env.getStatus(t, job.ID)
+		want := openai.BatchRequestCounts{Total: 4, Completed: 4}
+		if status.Status != openai.BatchStatusCompleted || status.RequestCounts != want {
+			t.Errorf("got %v with %+v, want %v with %+v", status.Status, status.RequestCounts, openai.BatchStatusCompleted, want)
+		}
+		if output := env.readResultFile(t, status.OutputFileID); len(output) != 4 {
+			t.Errorf("got %d output lines, want 4", len(output))
+		}
+	})
+
+	t.Run("Requeued", func(t *testing.T) {
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+		p := env.processor
+		queue := p.clients.priorityQueue
+		queue.Enqueue(ctx, &db.BatchJobPriority{ID: "batch-3", SLO: time.Now().Add(time.Hour), Attempts: 1})
+		task := p.getTaskFromQueue(ctx)
+
+		p.requeueDrained(ctx, task)
+		if err := queue.RenewLease(ctx, task.ID, time.Minute); err != db.ErrLeaseNotFound {
+			t.Errorf("RenewLease() after requeue error = %v, want %v", err, db.ErrLeaseNotFound)
+		}
+		if task := p.getTaskFromQueue(ctx); task == nil || task.ID != "batch-3" || task.Attempts != 1 {
+			t.Errorf("expected the drained job back in the queue with unchanged attempts, got %+v", task)
+		}
+	})
+	t.Run("Endpoint", func(t *testing.T) {
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{delay: 50 * time.Millisecond})
+		p := env.processor
+		p.cfg.PollMinInterval = 5 * time.Millisecond
+		p.cfg.PollInterval = 10 * time.Millisecond
+		p.cfg.QueueMetricsInterval = 0
+		queue := p.clients.priorityQueue
+		env.storeJob(t, "batch-4", time.Now().Add(time.Hour), "m1", "m1", "m1", "m1", "m1", "m1", "m1", "m1")
+		queue.Enqueue(ctx, &db.BatchJobPriority{ID: "batch-4", SLO: time.Now().Add(time.Hour)})
+
+		rr := httptest.NewRecorder()
+		p.DrainHandler(rr, httptest.NewRequest(http.MethodGet, DrainPath+"?timeout=abc", nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("got status %d for an invalid timeout, want %d", rr.Code, http.StatusBadRequest)
+		}
+
+		loopCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- p.RunPollingLoop(loopCtx) }()
+		defer func() {
+			cancel()
+			<-done
+		}()
+		for start := time.Now(); time.Since(start) < time.Second; time.Sleep(5 * time.Millisecond) {
+			if busy, _ := p.workerPool.Stats(); busy == 1 {
+				break
+			}
+		}
+
+		rr = httptest.NewRecorder()
+		p.DrainHandler(rr, httptest.NewRequest(http.MethodGet, DrainPath+"?timeout=5s", nil))
+		resp := &DrainResponse{}
+		if err := json.NewDecoder(rr.Body).Decode(resp); err != nil || rr.Code != http.StatusOK || !resp.Drained || resp.ActiveJobs != 0 {
+			t.Fatalf("got status %d with %+v (%v), want the processor drained", rr.Code, resp, err)
+		}
+		if checkpoint, _ := p.loadCheckpoint(ctx, "batch-4"); checkpoint == nil {
+			t.Errorf("expected a checkpoint of the drained job")
+		}
+		// the drained job stays in the queue, the drained processor doesn't claim it again
+		time.Sleep(50 * time.Millisecond)
+		if depth, _ := queue.Len(ctx); depth != 1 {
+			t.Errorf("got queue depth %d, want the drained job back in the queue", depth)
+		}
+	})
+}
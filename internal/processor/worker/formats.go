@@ -0,0 +1,146 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the encoders of the result files in the output formats other than JSONL.
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// resultRowsPerWrite is the number of rows buffered before they are written to a Parquet file.
+const resultRowsPerWrite = 1024
+
+// resultRow is a result line in the Parquet format. The response body is kept as JSON text, so the results
+// of all endpoints share the same schema.
+type resultRow struct {
+	ID           string  `parquet:"id"`
+	CustomID     string  `parquet:"custom_id"`
+	StatusCode   *int32  `parquet:"status_code,optional"`
+	RequestID    *string `parquet:"request_id,optional"`
+	Body         *string `parquet:"body,optional"`
+	ErrorCode    *string `parquet:"error_code,optional"`
+	ErrorMessage *string `parquet:"error_message,optional"`
+}
+
+func newResultRow(line *openai.BatchRequestOutput) resultRow {
+	row := resultRow{ID: line.ID, CustomID: line.CustomID}
+	if resp := line.Response; resp != nil {
+		statusCode := int32(resp.StatusCode)
+		body := string(resp.Body)
+		row.StatusCode, row.RequestID, row.Body = &statusCode, &resp.RequestID, &body
+	}
+	if line.Error != nil {
+		row.ErrorCode, row.ErrorMessage = &line.Error.Code, &line.Error.Message
+	}
+	return row
+}
+
+// encodeResults writes the lines of a JSONL result file in the given format.
+func encodeResults(w io.Writer, r io.Reader, format openai.OutputFormat) error {
+	switch format {
+	case openai.OutputFormatJSON:
+		return encodeResultsJSON(w, r)
+	case openai.OutputFormatParquet:
+		return encodeResultsParquet(w, r)
+	default:
+		_, err := io.Copy(w, r)
+		return err
+	}
+}
+
+// eachResultLine calls fn with each line of a JSONL result file, without its trailing newline.
+func eachResultLine(r io.Reader, fn func(line []byte) error) error {
+	br := bufio.NewReader(r)
+	for {
+		line, readErr := br.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if err := fn(line); err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// encodeResultsJSON writes the lines of a result file as a JSON array, one element per line.
+func encodeResultsJSON(w io.Writer, r io.Reader) error {
+	bw := bufio.NewWriter(w)
+	empty := true
+	err := eachResultLine(r, func(line []byte) error {
+		sep := ",\n"
+		if empty {
+			sep, empty = "[\n", false
+		}
+		if _, err := bw.WriteString(sep); err != nil {
+			return err
+		}
+		_, err := bw.Write(line)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	end := "\n]\n"
+	if empty {
+		end = "[]\n"
+	}
+	if _, err := bw.WriteString(end); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// encodeResultsParquet writes the lines of a result file as the rows of a Parquet file.
+func encodeResultsParquet(w io.Writer, r io.Reader) error {
+	pw := parquet.NewGenericWriter[resultRow](w)
+	rows := make([]resultRow, 0, resultRowsPerWrite)
+	flush := func() error {
+		_, err := pw.Write(rows)
+		rows = rows[:0]
+		return err
+	}
+	err := eachResultLine(r, func(data []byte) error {
+		var line openai.BatchRequestOutput
+		if err := json.Unmarshal(data, &line); err != nil {
+			return err
+		}
+		rows = append(rows, newResultRow(&line))
+		if len(rows) == resultRowsPerWrite {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return err
+	}
+	return pw.Close()
+}
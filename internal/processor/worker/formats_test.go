@@ -0,0 +1,88 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the encoders of the output formats.
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+const testResultLines = `{"id":"batch_req_1","custom_id":"req-1","response":{"status_code":200,"request_id":"r1","body":{"ok":true}},"error":null}
+{"id":"batch_req_2","custom_id":"req-2","response":null,"error":{"code":"batch_expired","message":"expired"}}
+`
+
+func TestEncodeResults(t *testing.T) {
+
+	t.Run("JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := encodeResults(&buf, strings.NewReader(testResultLines), openai.OutputFormatJSON); err != nil {
+			t.Fatalf("encodeResults failed: %v", err)
+		}
+		var lines []openai.BatchRequestOutput
+		if err := json.Unmarshal(buf.Bytes(), &lines); err != nil {
+			t.Fatalf("output is not a JSON array: %v\n%s", err, buf.String())
+		}
+		if len(lines) != 2 || lines[0].CustomID != "req-1" || lines[1].Error == nil {
+			t.Errorf("unexpected lines: %+v", lines)
+		}
+	})
+
+	t.Run("EmptyJSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := encodeResults(&buf, strings.NewReader(""), openai.OutputFormatJSON); err != nil {
+			t.Fatalf("encodeResults failed: %v", err)
+		}
+		if buf.String() != "[]\n" {
+			t.Errorf("output = %q, want an empty array", buf.String())
+		}
+	})
+
+	t.Run("Parquet", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := encodeResults(&buf, strings.NewReader(testResultLines), openai.OutputFormatParquet); err != nil {
+			t.Fatalf("encodeResults failed: %v", err)
+		}
+		rows, err := parquet.Read[resultRow](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("failed to read Parquet file: %v", err)
+		}
+		if len(rows) != 2 {
+			t.Fatalf("rows = %d, want 2", len(rows))
+		}
+		if rows[0].CustomID != "req-1" || rows[0].StatusCode == nil || *rows[0].StatusCode != 200 ||
+			rows[0].Body == nil || *rows[0].Body != `{"ok":true}` || rows[0].ErrorCode != nil {
+			t.Errorf("unexpected row of a response: %+v", rows[0])
+		}
+		if rows[1].StatusCode != nil || rows[1].ErrorCode == nil || *rows[1].ErrorCode != "batch_expired" {
+			t.Errorf("unexpected row of an error: %+v", rows[1])
+		}
+	})
+
+	t.Run("InvalidLine", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := encodeResults(&buf, strings.NewReader("not json\n"), openai.OutputFormatParquet); err == nil {
+			t.Error("expected an error for an invalid result line")
+		}
+	})
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the routing of inference requests to the inference gateways serving their models.
+
+package worker
+
+import (
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+)
+
+// defaultGatewayName is the name of the default inference client, serving the models no gateway is configured for.
+const defaultGatewayName = "default"
+
+// inferenceGateway is the client of an inference gateway and the retry settings of the requests sent to it,
+// 0 meaning the processor's.
+type inferenceGateway struct {
+	name   string
+	target string
+	client batch.InferenceClient
+
+	retryMaxAttempts    int
+	retryInitialBackoff time.Duration
+	retryMaxBackoff     time.Duration
+}
+
+// gatewayRouter maps the models to the inference gateways serving them.
+type gatewayRouter struct {
+	byModel map[string]*inferenceGateway
+	// fallback serves the models not served by another gateway, nil in the routers of the targets without a
+	// gateway serving the model "*"
+	fallback *inferenceGateway
+	// speculative receives the tail stragglers of the jobs as well, nil when speculative retries are disabled
+	speculative *inferenceGateway
+	// targets are the routers of the batches of each inference target
+	targets map[string]*gatewayRouter
+}
+
+func newGatewayRouter(cfg *config.ProcessorConfig, clients *ProcessorClients) *gatewayRouter {
+	r := &gatewayRouter{
+		byModel: map[string]*inferenceGateway{},
+		targets: map[string]*gatewayRouter{},
+		fallback: &inferenceGateway{
+			name:   defaultGatewayName,
+			client: clients.inference,
+		},
+	}
+	for _, gatewayCfg := range cfg.InferenceGateways {
+		gateway := &inferenceGateway{
+			name:                gatewayCfg.Name,
+			target:              gatewayCfg.Target,
+			client:              clients.inferenceClients[gatewayCfg.Name],
+			retryMaxAttempts:    gatewayCfg.RetryMaxAttempts,
+			retryInitialBackoff: gatewayCfg.RetryInitialBackoff,
+			retryMaxBackoff:     gatewayCfg.RetryMaxBackoff,
+		}
+		if gatewayCfg.Name == cfg.SpeculativeGateway {
+			r.speculative = gateway
+		}
+		routes := r
+		if gatewayCfg.Target != "" {
+			if routes = r.targets[gatewayCfg.Target]; routes == nil {
+				routes = &gatewayRouter{byModel: map[string]*inferenceGateway{}}
+				r.targets[gatewayCfg.Target] = routes
+			}
+		}
+		for _, model := range gatewayCfg.Models {
+			if model == config.DefaultGatewayModel {
+				routes.fallback = gateway
+				continue
+			}
+			routes.byModel[model] = gateway
+		}
+	}
+	return r
+}
+
+// hasTarget reports whether the batches of the inference target can be routed, the batches without target
+// always can.
+func (r *gatewayRouter) hasTarget(target string) bool {
+	_, ok := r.targets[target]
+	return target == "" || ok
+}
+
+// route returns the gateway serving the model for the batches of the inference target, nil if none does.
+func (r *gatewayRouter) route(target, model string) *inferenceGateway {
+	if target != "" {
+		routes, ok := r.targets[target]
+		if !ok {
+			return nil
+		}
+		return routes.route("", model)
+	}
+	if gateway, ok := r.byModel[model]; ok {
+		return gateway
+	}
+	return r.fallback
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the routing of inference requests to inference gateways.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// modelRecordingClient records the models of the requests it receives.
+type modelRecordingClient struct {
+	mu     sync.Mutex
+	models []string
+}
+
+func (c *modelRecordingClient) Generate(ctx context.Context, req *batch.InferenceRequest) (*batch.InferenceResponse, *batch.InferenceError) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.models = append(c.models, req.Model)
+	return &batch.InferenceResponse{RequestID: "req-" + req.RequestID, Response: []byte(`{}`)}, nil
+}
+
+func (c *modelRecordingClient) received() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.models
+}
+
+func TestGatewayRouting(t *testing.T) {
+	gatewayA := &modelRecordingClient{}
+	gatewayB := &modelRecordingClient{}
+
+	setup := func(t *testing.T, defaultClient batch.InferenceClient, gateways ...config.InferenceGatewayConfig) *testEnv {
+		env := setupProcessorForTest(t, 1, defaultClient)
+		p := env.processor
+		p.clients.AddInferenceClient("a", gatewayA)
+		p.clients.AddInferenceClient("b", gatewayB)
+		p.cfg.InferenceGateways = gateways
+		p.gateways = newGatewayRouter(p.cfg, p.clients)
+		return env
+	}
+
+	t.Run("ByModel", func(t *testing.T) {
+		gatewayA.models, gatewayB.models = nil, nil
+		defaultClient := &modelRecordingClient{}
+		env := setup(t, defaultClient,
+			config.InferenceGatewayConfig{Name: "a", Models: []string{"m1"}},
+			config.InferenceGatewayConfig{Name: "b", Models: []string{"m2", "m3"}},
+		)
+		job := env.storeJob(t, "batch-1", time.Now().Add(time.Hour), "m1", "m2", "m3", "m4")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		if status := env.getStatus(t, job.ID); status.RequestCounts.Completed != 4 {
+			t.Errorf("RequestCounts = %+v, want 4 completed", status.RequestCounts)
+		}
+		if got := gatewayA.received(); len(got) != 1 || got[0] != "m1" {
+			t.Errorf("gateway a received %v, want [m1]", got)
+		}
+		if got := gatewayB.received(); len(got) != 2 {
+			t.Errorf("gateway b received %v, want [m2 m3]", got)
+		}
+		// models not served by a gateway are sent to the default client
+		if got := defaultClient.received(); len(got) != 1 || got[0] != "m4" {
+			t.Errorf("default client received %v, want [m4]", got)
+		}
+	})
+
+	t.Run("DefaultGateway", func(t *testing.T) {
+		gatewayA.models, gatewayB.models = nil, nil
+		env := setup(t, nil,
+			config.InferenceGatewayConfig{Name: "a", Models: []string{"m1"}},
+			config.InferenceGatewayConfig{Name: "b", Models: []string{config.DefaultGatewayModel}},
+		)
+		if err := env.processor.prepare(context.Background()); err != nil {
+			t.Fatalf("prepare() error = %v", err)
+		}
+		job := env.storeJob(t, "batch-2", time.Now().Add(time.Hour), "m1", "m2")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		if status := env.getStatus(t, job.ID); status.Status != openai.BatchStatusCompleted {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusCompleted)
+		}
+		if got := gatewayB.received(); len(got) != 1 || got[0] != "m2" {
+			t.Errorf("default gateway received %v, want [m2]", got)
+		}
+	})
+
+	t.Run("Targets", func(t *testing.T) {
+		gatewayA.models, gatewayB.models = nil, nil
+		defaultClient := &modelRecordingClient{}
+		env := setup(t, defaultClient,
+			config.InferenceGatewayConfig{Name: "a", Models: []string{"m1"}},
+			config.InferenceGatewayConfig{Name: "b", Target: "east", Models: []string{"m1"}},
+		)
+		targeted := func(jobID, target string) *db.BatchJob {
+			job := env.storeJob(t, jobID, time.Now().Add(time.Hour), "m1", "m2")
+			spec := &openai.BatchSpec{}
+			json.Unmarshal(job.Spec, spec)
+			spec.InferenceTarget = target
+			job.Spec, _ = json.Marshal(spec)
+			return job
+		}
+
+		// the models the target doesn't serve fail instead of being sent to the default client
+		job := targeted("batch-3", "east")
+		env.processor.processJob(context.Background(), 1, job)
+		if status := env.getStatus(t, job.ID); status.RequestCounts.Completed != 1 || status.RequestCounts.Failed != 1 {
+			t.Errorf("RequestCounts = %+v, want 1 completed and 1 failed", status.RequestCounts)
+		}
+		if got := gatewayB.received(); len(got) != 1 || got[0] != "m1" {
+			t.Errorf("gateway of target east received %v, want [m1]", got)
+		}
+		if got := gatewayA.received(); len(got) != 0 {
+			t.Errorf("gateway without target received %v, want none", got)
+		}
+		if got := defaultClient.received(); len(got) != 0 {
+			t.Errorf("default client received %v, want none", got)
+		}
+
+		// the batches of targets the processor doesn't serve fail
+		job = targeted("batch-4", "west")
+		env.processor.processJob(context.Background(), 1, job)
+		if status := env.getStatus(t, job.ID); status.Status != openai.BatchStatusFailed {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusFailed)
+		}
+	})
+
+	t.Run("MissingClient", func(t *testing.T) {
+		env := setup(t, &modelRecordingClient{}, config.InferenceGatewayConfig{Name: "c", Models: []string{"m1"}})
+		if err := env.processor.prepare(context.Background()); err == nil {
+			t.Errorf("prepare() succeeded without the client of gateway c")
+		}
+	})
+
+	t.Run("NoDefaultClient", func(t *testing.T) {
+		env := setup(t, nil, config.InferenceGatewayConfig{Name: "a", Models: []string{"m1"}})
+		if err := env.processor.prepare(context.Background()); err == nil {
+			t.Errorf("prepare() succeeded without a client serving the models without a gateway")
+		}
+	})
+}
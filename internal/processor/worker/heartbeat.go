@@ -0,0 +1,91 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the heartbeats of the workers processing jobs, recorded in the status store.
+
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// workerHeartbeat records that a worker of a processor is alive and processing a job.
+type workerHeartbeat struct {
+	ProcessorID string    `json:"processor_id"`
+	WorkerID    int       `json:"worker_id"`
+	JobID       string    `json:"job_id"`
+	Time        time.Time `json:"time"`
+}
+
+// processorID returns the ID identifying the processor instance in heartbeats: the configured ID,
+// or the host name (the pod name on Kubernetes).
+func processorID(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return uuid.NewString()
+}
+
+// recordHeartbeat records that the worker is processing the job. The heartbeat outlives the lease of the job,
+// so it can still be read when the job is reclaimed after its worker died.
+func (p *Processor) recordHeartbeat(ctx context.Context, workerID int, jobID string) {
+	data, err := json.Marshal(&workerHeartbeat{
+		ProcessorID: p.id,
+		WorkerID:    workerID,
+		JobID:       jobID,
+		Time:        time.Now().UTC(),
+	})
+	if err != nil {
+		return
+	}
+	ttl := int(math.Ceil((2 * p.cfg.LeaseTTL).Seconds()))
+	if err := p.clients.status.Set(ctx, batch.HeartbeatKey(jobID), ttl, data); err != nil {
+		klog.FromContext(ctx).V(logging.ERROR).Error(err, "Failed to record worker heartbeat", "jobID", jobID)
+	}
+}
+
+// clearHeartbeat removes the heartbeat of the worker that finished processing the job.
+func (p *Processor) clearHeartbeat(ctx context.Context, jobID string) {
+	if err := p.clients.status.Delete(ctx, batch.HeartbeatKey(jobID)); err != nil {
+		klog.FromContext(ctx).V(logging.ERROR).Error(err, "Failed to clear worker heartbeat", "jobID", jobID)
+	}
+}
+
+// lastHeartbeat returns the last heartbeat of the worker that processed the job, or nil if there is none.
+func (p *Processor) lastHeartbeat(ctx context.Context, jobID string) *workerHeartbeat {
+	data, err := p.clients.status.Get(ctx, batch.HeartbeatKey(jobID))
+	if err != nil || data == nil {
+		return nil
+	}
+	heartbeat := &workerHeartbeat{}
+	if err := json.Unmarshal(data, heartbeat); err != nil {
+		return nil
+	}
+	return heartbeat
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the inlining of the image files referenced by chat completion requests.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+)
+
+// inlineImages replaces the file references of the image content parts of a chat completion request with base64
+// data URLs of the files. It fails if the request exceeds MaxLinePayloadBytes once its images are inlined, size
+// being the size of the request before.
+func (p *Processor) inlineImages(ctx context.Context, tenantID string, params map[string]interface{}, size int) error {
+	var added int64
+	err := batch.ForEachImageURL(params, func(imageURL map[string]any, param string) error {
+		fileID, _ := imageURL[batch.ImageFileIDField].(string)
+		if fileID == "" {
+			return nil
+		}
+		data, err := p.readImage(ctx, tenantID, fileID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", param, err)
+		}
+		mediaType := http.DetectContentType(data)
+		if !batch.IsSupportedImageType(mediaType) {
+			return fmt.Errorf("%s: file %s is not a supported image, its type is %s", param, fileID, mediaType)
+		}
+		url := batch.ImageDataURL(mediaType, data)
+		delete(imageURL, batch.ImageFileIDField)
+		imageURL["url"] = url
+		added += int64(len(url) - len(fileID))
+		return nil
+	})
+	if err == nil && p.cfg.MaxLinePayloadBytes > 0 && int64(size)+added > p.cfg.MaxLinePayloadBytes {
+		err = fmt.Errorf("the request exceeds the maximum size of %d bytes", p.cfg.MaxLinePayloadBytes)
+	}
+	return err
+}
+
+// readImage reads an image file from the files store, up to MaxImageBytes.
+func (p *Processor) readImage(ctx context.Context, tenantID, fileID string) ([]byte, error) {
+	if !batch.ValidFileID(fileID) {
+		return nil, fmt.Errorf("invalid image file ID %q", fileID)
+	}
+	reader, _, err := p.clients.files.Retrieve(ctx, batch.FileLocation(tenantID, fileID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve image file %s: %w", fileID, err)
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, p.cfg.MaxImageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image file %s: %w", fileID, err)
+	}
+	if int64(len(data)) > p.cfg.MaxImageBytes {
+		return nil, fmt.Errorf("image file %s exceeds the maximum size of %d bytes", fileID, p.cfg.MaxImageBytes)
+	}
+	return data, nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains tests for the inlining of image files.
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+)
+
+func TestInlineImages(t *testing.T) {
+	ctx := context.Background()
+	env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+	p := env.processor
+	p.cfg.MaxImageBytes = 64
+	p.cfg.MaxLinePayloadBytes = 1024
+
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 16)
+	for id, data := range map[string]string{
+		"file_png":   png,
+		"file_text":  "this is not an image",
+		"file_large": "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 64),
+	} {
+		if _, err := env.files.Store(ctx, id, 0, strings.NewReader(data)); err != nil {
+			t.Fatalf("Failed to store %s: %v", id, err)
+		}
+	}
+
+	request := func(imageURL map[string]any) map[string]interface{} {
+		return map[string]interface{}{
+			"model": "model",
+			"messages": []any{map[string]any{
+				"role": "user",
+				"content": []any{
+					map[string]any{"type": "text", "text": "describe"},
+					map[string]any{"type": "image_url", "image_url": imageURL},
+				},
+			}},
+		}
+	}
+	imageURL := func(params map[string]interface{}) map[string]any {
+		parts := params["messages"].([]any)[0].(map[string]any)["content"].([]any)
+		return parts[1].(map[string]any)["image_url"].(map[string]any)
+	}
+
+	t.Run("Inlined", func(t *testing.T) {
+		params := request(map[string]any{batch.ImageFileIDField: "file_png", "detail": "low"})
+		if err := p.inlineImages(ctx, "", params, 100); err != nil {
+			t.Fatalf("inlineImages failed: %v", err)
+		}
+		got := imageURL(params)
+		if want := batch.ImageDataURL("image/png", []byte(png)); got["url"] != want {
+			t.Errorf("url = %v, want %s", got["url"], want)
+		}
+		if _, ok := got[batch.ImageFileIDField]; ok {
+			t.Error("file_id was not removed")
+		}
+		if got["detail"] != "low" {
+			t.Errorf("detail = %v, want low", got["detail"])
+		}
+	})
+
+	t.Run("URLUntouched", func(t *testing.T) {
+		params := request(map[string]any{"url": "https://example.com/cat.png"})
+		if err := p.inlineImages(ctx, "", params, 100); err != nil {
+			t.Fatalf("inlineImages failed: %v", err)
+		}
+		if got := imageURL(params)["url"]; got != "https://example.com/cat.png" {
+			t.Errorf("url = %v", got)
+		}
+	})
+
+	for _, tc := range []struct {
+		name   string
+		fileID string
+		size   int
+		want   string
+	}{
+		{"Missing", "file_missing", 100, "failed to retrieve image file file_missing"},
+		{"NotAnImage", "file_text", 100, "is not a supported image"},
+		{"ImageTooLarge", "file_large", 100, "exceeds the maximum size of 64 bytes"},
+		{"PayloadTooLarge", "file_png", 1000, "the request exceeds the maximum size of 1024 bytes"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := p.inlineImages(ctx, "", request(map[string]any{batch.ImageFileIDField: tc.fileID}), tc.size)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("inlineImages error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the collection of the lifecycle metrics of a batch.
+package worker
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/labels"
+)
+
+const (
+	// unknownModel labels the metrics of a batch none of whose lines was processed.
+	unknownModel = "unknown"
+	// mixedModels labels the metrics of a batch whose lines target several models.
+	mixedModels = "mixed"
+)
+
+// jobMetrics collects the lifecycle metrics of a delivery of a job. The batch-level metrics are labeled by
+// the model of the batch's lines.
+type jobMetrics struct {
+	start        time.Time
+	inProgressAt time.Time
+	// createdAt is the creation time of the batch in seconds since the epoch, 0 if unknown
+	createdAt int64
+	// queueWait is the time the batch waited before its processing started, set on its first delivery only
+	queueWait time.Duration
+	// traceID links the exemplar of the batch duration to the trace of the batch, if it is sampled
+	traceID string
+
+	mu    sync.Mutex
+	model string
+	lines int
+}
+
+// newJobMetrics starts collecting the metrics of a job whose status is statusInfo, before it is set in progress.
+func newJobMetrics(spec *openai.BatchSpec, statusInfo *openai.BatchStatusInfo) *jobMetrics {
+	now := time.Now()
+	jm := &jobMetrics{start: now, inProgressAt: now, createdAt: spec.CreatedAt, queueWait: -1}
+	if statusInfo.InProgressAt != nil {
+		jm.inProgressAt = time.Unix(*statusInfo.InProgressAt, 0)
+	} else if spec.CreatedAt > 0 {
+		jm.queueWait = max(now.Sub(time.Unix(spec.CreatedAt, 0)), 0)
+	}
+	return jm
+}
+
+// lineProcessed counts a line of model that was sent to inference. The queue wait and the time to the first line
+// are observed with the first line of the batch's first delivery.
+func (jm *jobMetrics) lineProcessed(model string) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	if model != "" {
+		switch jm.model {
+		case "":
+			jm.model = model
+		case model:
+		default:
+			jm.model = mixedModels
+		}
+	}
+	jm.lines++
+	if jm.lines == 1 && jm.queueWait >= 0 {
+		label := modelLabel(model)
+		metrics.RecordQueueWaitDuration(jm.queueWait, label)
+		metrics.RecordTimeToFirstLine(time.Since(jm.start), label)
+	}
+}
+
+// observe records the throughput of the delivery and, once the batch reached its final status, its duration and
+// the durations of its lifecycle stages.
+func (jm *jobMetrics) observe(statusInfo *openai.BatchStatusInfo) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	label := modelLabel(jm.model)
+	if elapsed := time.Since(jm.start); jm.lines > 0 && elapsed > 0 {
+		metrics.RecordBatchThroughput(float64(jm.lines)/elapsed.Seconds(), label)
+	}
+	if statusInfo.Status.IsFinal() {
+		metrics.RecordBatchDuration(time.Since(jm.inProgressAt), label, string(statusInfo.Status), jm.traceID)
+		jm.observeStages(statusInfo)
+	}
+}
+
+// observeStages records the durations of the lifecycle stages of a batch in its final status.
+func (jm *jobMetrics) observeStages(statusInfo *openai.BatchStatusInfo) {
+	sizeBucket := labels.SizeBucket(int(statusInfo.RequestCounts.Total))
+	for stage, duration := range stageDurations(jm.createdAt, statusInfo) {
+		metrics.RecordBatchStageDuration(stage, duration, sizeBucket, string(statusInfo.Status))
+	}
+}
+
+// stageDurations returns the durations of the lifecycle stages of a batch created at createdAt, in its final
+// status, from the times the batch entered them. The stages the batch skipped, e.g. finalizing when it failed
+// while its lines were processed, are not returned.
+func stageDurations(createdAt int64, statusInfo *openai.BatchStatusInfo) map[string]time.Duration {
+	finishedAt := finalStatusTime(statusInfo)
+	if finishedAt == nil {
+		return nil
+	}
+	durations := map[string]time.Duration{}
+	add := func(stage string, from, to int64) {
+		if from > 0 && to >= from {
+			durations[stage] = time.Duration(to-from) * time.Second
+		}
+	}
+
+	add(metrics.StageTotal, createdAt, *finishedAt)
+	if statusInfo.InProgressAt == nil {
+		return durations
+	}
+	add(metrics.StageQueued, createdAt, *statusInfo.InProgressAt)
+	if statusInfo.FinalizingAt == nil {
+		add(metrics.StageProcessing, *statusInfo.InProgressAt, *finishedAt)
+		return durations
+	}
+	add(metrics.StageProcessing, *statusInfo.InProgressAt, *statusInfo.FinalizingAt)
+	add(metrics.StageFinalizing, *statusInfo.FinalizingAt, *finishedAt)
+	return durations
+}
+
+// finalStatusTime returns the time a batch reached its final status, nil if it isn't set.
+func finalStatusTime(statusInfo *openai.BatchStatusInfo) *int64 {
+	switch statusInfo.Status {
+	case openai.BatchStatusCompleted:
+		return statusInfo.CompletedAt
+	case openai.BatchStatusFailed:
+		return statusInfo.FailedAt
+	case openai.BatchStatusExpired:
+		return statusInfo.ExpiredAt
+	case openai.BatchStatusCancelled:
+		return statusInfo.CancelledAt
+	}
+	return nil
+}
+
+func modelLabel(model string) string {
+	if model == "" {
+		return unknownModel
+	}
+	return model
+}
+
+// requestModel returns the model of a request, or an empty string if its body has none.
+func requestModel(req *openai.BatchRequestInput) string {
+	var body struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		return ""
+	}
+	return body.Model
+}
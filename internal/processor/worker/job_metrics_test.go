@@ -0,0 +1,116 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the collection of the lifecycle metrics of a batch.
+package worker
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+func TestJobMetrics(t *testing.T) {
+	if err := metrics.InitMetrics(*config.NewConfig()); err != nil {
+		t.Fatalf("failed to init metrics: %v", err)
+	}
+
+	t.Run("FirstDelivery", func(t *testing.T) {
+		createdAt := time.Now().Add(-time.Minute)
+		jm := newJobMetrics(&openai.BatchSpec{CreatedAt: createdAt.Unix()}, &openai.BatchStatusInfo{})
+		if jm.queueWait < time.Minute-time.Second || jm.queueWait > time.Minute+time.Second {
+			t.Errorf("queueWait = %v, want about 1m", jm.queueWait)
+		}
+	})
+
+	t.Run("Resumed", func(t *testing.T) {
+		inProgressAt := time.Now().Add(-time.Hour).Unix()
+		jm := newJobMetrics(&openai.BatchSpec{CreatedAt: inProgressAt - 60}, &openai.BatchStatusInfo{InProgressAt: &inProgressAt})
+		if jm.queueWait >= 0 {
+			t.Errorf("queueWait = %v, want none on a later delivery", jm.queueWait)
+		}
+		if !jm.inProgressAt.Equal(time.Unix(inProgressAt, 0)) {
+			t.Errorf("inProgressAt = %v, want %v", jm.inProgressAt, time.Unix(inProgressAt, 0))
+		}
+	})
+
+	t.Run("ModelLabel", func(t *testing.T) {
+		jm := newJobMetrics(&openai.BatchSpec{}, &openai.BatchStatusInfo{})
+		if got := modelLabel(jm.model); got != unknownModel {
+			t.Errorf("model = %q, want %q", got, unknownModel)
+		}
+		jm.lineProcessed("")
+		jm.lineProcessed("m1")
+		jm.lineProcessed("m1")
+		if jm.model != "m1" || jm.lines != 3 {
+			t.Errorf("model = %q, lines = %d, want m1, 3", jm.model, jm.lines)
+		}
+		jm.lineProcessed("m2")
+		jm.lineProcessed("m1")
+		if jm.model != mixedModels {
+			t.Errorf("model = %q, want %q", jm.model, mixedModels)
+		}
+		jm.observe(&openai.BatchStatusInfo{Status: openai.BatchStatusCompleted})
+	})
+
+	t.Run("Stages", func(t *testing.T) {
+		createdAt := time.Now().Add(-time.Hour).Unix()
+		inProgressAt, finalizingAt, completedAt := createdAt+60, createdAt+600, createdAt+660
+		completed := &openai.BatchStatusInfo{
+			Status:        openai.BatchStatusCompleted,
+			InProgressAt:  &inProgressAt,
+			FinalizingAt:  &finalizingAt,
+			CompletedAt:   &completedAt,
+			RequestCounts: openai.BatchRequestCounts{Total: 5000},
+		}
+		failedAt := inProgressAt + 30
+		failed := &openai.BatchStatusInfo{Status: openai.BatchStatusFailed, InProgressAt: &inProgressAt, FailedAt: &failedAt}
+
+		for _, tc := range []struct {
+			name       string
+			statusInfo *openai.BatchStatusInfo
+			want       map[string]time.Duration
+		}{
+			{"Completed", completed, map[string]time.Duration{
+				metrics.StageQueued: time.Minute, metrics.StageProcessing: 9 * time.Minute,
+				metrics.StageFinalizing: time.Minute, metrics.StageTotal: 11 * time.Minute,
+			}},
+			// a batch failed while its lines were processed skips finalizing
+			{"Failed", failed, map[string]time.Duration{
+				metrics.StageQueued: time.Minute, metrics.StageProcessing: 30 * time.Second, metrics.StageTotal: 90 * time.Second,
+			}},
+			{"NotFinal", &openai.BatchStatusInfo{Status: openai.BatchStatusInProgress, InProgressAt: &inProgressAt}, nil},
+		} {
+			got := stageDurations(createdAt, tc.statusInfo)
+			if !maps.Equal(got, tc.want) {
+				t.Errorf("%s: stage durations = %v, want %v", tc.name, got, tc.want)
+			}
+		}
+		newJobMetrics(&openai.BatchSpec{CreatedAt: createdAt}, completed).observe(completed)
+	})
+
+	t.Run("RequestModel", func(t *testing.T) {
+		for body, want := range map[string]string{`{"model":"m1","prompt":"hi"}`: "m1", `{"prompt":"hi"}`: "", `not json`: ""} {
+			if got := requestModel(&openai.BatchRequestInput{Body: []byte(body)}); got != want {
+				t.Errorf("requestModel(%s) = %q, want %q", body, got, want)
+			}
+		}
+	})
+}
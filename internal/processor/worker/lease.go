@@ -0,0 +1,127 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the lease handling of dequeued jobs: heartbeat renewal, acknowledgement and reclaim.
+
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// keepLease renews the lease of a job every third of the lease TTL until stop is called or ctx is done,
+// recording a heartbeat of the worker processing the job with every renewal.
+// If the lease is lost, e.g. it expired and the job was reclaimed by another processor, onLost is called
+// so the job isn't processed twice.
+func (p *Processor) keepLease(ctx context.Context, task *db.BatchJobPriority, workerID int, onLost func()) (stop func()) {
+	logger := klog.FromContext(ctx)
+	queue, jobID := p.queues.client(task.Queue), task.ID
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	p.recordHeartbeat(ctx, workerID, jobID)
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(p.cfg.LeaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			err := queue.RenewLease(ctx, jobID, p.cfg.LeaseTTL)
+			if errors.Is(err, db.ErrLeaseNotFound) {
+				logger.V(logging.WARNING).Info("Lost the lease of the job, stopping its processing", "jobID", jobID)
+				onLost()
+				return
+			}
+			if err != nil {
+				// retried with the next heartbeat, before the lease expires
+				logger.V(logging.ERROR).Error(err, "Failed to renew the lease of the job", "jobID", jobID)
+				continue
+			}
+			p.recordHeartbeat(ctx, workerID, jobID)
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// ackLease ends the lease of a job that doesn't need to be processed anymore.
+func (p *Processor) ackLease(ctx context.Context, task *db.BatchJobPriority) {
+	logger := klog.FromContext(ctx)
+	err := p.queues.client(task.Queue).AckLease(ctx, task.ID)
+	if err != nil && !errors.Is(err, db.ErrLeaseNotFound) {
+		logger.V(logging.ERROR).Error(err, "Failed to acknowledge the lease of the job", "jobID", task.ID)
+	}
+	p.clearHeartbeat(ctx, task.ID)
+}
+
+// runLeaseReclaimer returns the jobs with expired leases to the queue until ctx is done.
+func (p *Processor) runLeaseReclaimer(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	ticker := time.NewTicker(p.cfg.LeaseTTL / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, queue := range p.queues.queues {
+			reclaimed, err := queue.client.ReclaimExpiredLeases(ctx)
+			if err != nil {
+				logger.V(logging.ERROR).Error(err, "Failed to reclaim expired leases", "queue", queue.name)
+				continue
+			}
+			for _, task := range reclaimed {
+				p.reportReclaimed(ctx, task, queue.name)
+			}
+		}
+	}
+}
+
+// reportReclaimed reports a job reclaimed after its lease expired, identifying the dead worker that was processing
+// it from its last heartbeat.
+func (p *Processor) reportReclaimed(ctx context.Context, task *db.BatchJobPriority, queue string) {
+	logger := klog.FromContext(ctx)
+	deadProcessor := "unknown"
+	if heartbeat := p.lastHeartbeat(ctx, task.ID); heartbeat != nil {
+		deadProcessor = heartbeat.ProcessorID
+		logger.V(logging.WARNING).Info("Reclaimed job of a worker that died mid-task", "jobID", task.ID, "queue", queue,
+			"attempts", task.Attempts, "processorID", heartbeat.ProcessorID, "workerID", heartbeat.WorkerID,
+			"lastHeartbeat", heartbeat.Time)
+		p.clearHeartbeat(ctx, task.ID)
+	} else {
+		logger.V(logging.INFO).Info("Reclaimed job with expired lease", "jobID", task.ID, "queue", queue, "attempts", task.Attempts)
+	}
+	metrics.RecordTaskReclaimed(deadProcessor)
+}
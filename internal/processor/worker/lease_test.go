@@ -0,0 +1,164 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the lease handling of dequeued jobs.
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+)
+
+func TestLease(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("RenewedWhileProcessing", func(t *testing.T) {
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+		p := env.processor
+		p.cfg.LeaseTTL = 30 * time.Millisecond
+		queue := p.clients.priorityQueue
+		queue.Enqueue(ctx, &db.BatchJobPriority{ID: "batch-1", SLO: time.Now().Add(time.Hour)})
+
+		task := p.getTaskFromQueue(ctx)
+		if task == nil {
+			t.Fatalf("expected a task in the queue")
+		}
+		stop := p.keepLease(ctx, task, 1, func() { t.Errorf("lease lost while renewed") })
+		time.Sleep(100 * time.Millisecond)
+		if reclaimed, _ := queue.ReclaimExpiredLeases(ctx); len(reclaimed) != 0 {
+			t.Errorf("reclaimed %d jobs with a renewed lease", len(reclaimed))
+		}
+		heartbeat := p.lastHeartbeat(ctx, "batch-1")
+		if heartbeat == nil || heartbeat.ProcessorID != p.id || heartbeat.WorkerID != 1 || heartbeat.JobID != "batch-1" {
+			t.Fatalf("unexpected heartbeat: %+v", heartbeat)
+		}
+
+		// the processor stops renewing, e.g. it crashed
+		stop()
+		time.Sleep(50 * time.Millisecond)
+		reclaimed, _ := queue.ReclaimExpiredLeases(ctx)
+		if len(reclaimed) != 1 || reclaimed[0].ID != "batch-1" || reclaimed[0].Attempts != 1 {
+			t.Fatalf("unexpected reclaimed jobs: %+v", reclaimed)
+		}
+		if task := p.getTaskFromQueue(ctx); task == nil || task.ID != "batch-1" {
+			t.Errorf("expected the reclaimed job back in the queue, got %+v", task)
+		}
+	})
+
+	t.Run("Lost", func(t *testing.T) {
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+		p := env.processor
+		p.cfg.LeaseTTL = 30 * time.Millisecond
+		queue := p.clients.priorityQueue
+		queue.Enqueue(ctx, &db.BatchJobPriority{ID: "batch-2", SLO: time.Now().Add(time.Hour)})
+		task := p.getTaskFromQueue(ctx)
+
+		// another processor reclaimed the job
+		queue.AckLease(ctx, task.ID)
+
+		lost := make(chan struct{})
+		stop := p.keepLease(ctx, task, 1, func() { close(lost) })
+		defer stop()
+		select {
+		case <-lost:
+		case <-time.After(time.Second):
+			t.Fatalf("lost lease not detected")
+		}
+	})
+
+	t.Run("Acknowledged", func(t *testing.T) {
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+		p := env.processor
+		queue := p.clients.priorityQueue
+		queue.Enqueue(ctx, &db.BatchJobPriority{ID: "batch-3", SLO: time.Now().Add(time.Hour)})
+		task := p.getTaskFromQueue(ctx)
+
+		p.ackLease(ctx, task)
+		if err := queue.RenewLease(ctx, task.ID, time.Minute); err != db.ErrLeaseNotFound {
+			t.Errorf("RenewLease() after ack error = %v, want %v", err, db.ErrLeaseNotFound)
+		}
+		if depth, _ := queue.Len(ctx); depth != 0 {
+			t.Errorf("queue depth = %d, want 0", depth)
+		}
+		if heartbeat := p.lastHeartbeat(ctx, task.ID); heartbeat != nil {
+			t.Errorf("heartbeat not cleared after ack: %+v", heartbeat)
+		}
+	})
+
+	t.Run("ReclaimedFromDeadWorker", func(t *testing.T) {
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+		p := env.processor
+		p.cfg.ProcessorID = "processor-0"
+		p.id = processorID(p.cfg.ProcessorID)
+		p.cfg.LeaseTTL = 30 * time.Millisecond
+		queue := p.clients.priorityQueue
+		queue.Enqueue(ctx, &db.BatchJobPriority{ID: "batch-5", SLO: time.Now().Add(time.Hour)})
+		task := p.getTaskFromQueue(ctx)
+
+		// the worker dies mid-task, leaving its last heartbeat behind
+		stop := p.keepLease(ctx, task, 2, func() {})
+		stop()
+		time.Sleep(50 * time.Millisecond)
+
+		reclaimerCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			p.runLeaseReclaimer(reclaimerCtx)
+		}()
+		defer func() {
+			cancel()
+			<-done
+		}()
+
+		for start := time.Now(); time.Since(start) < time.Second; time.Sleep(5 * time.Millisecond) {
+			// the heartbeat of the dead worker is cleared once the reclaimed job is reported
+			if depth, _ := queue.Len(ctx); depth == 1 && p.lastHeartbeat(ctx, task.ID) == nil {
+				return
+			}
+		}
+		t.Errorf("expected the job of the dead worker back in the queue and its heartbeat cleared")
+	})
+
+	t.Run("InterruptedTooOften", func(t *testing.T) {
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+		p := env.processor
+		p.cfg.PollInterval = 10 * time.Millisecond
+		p.clients.priorityQueue.Enqueue(ctx, &db.BatchJobPriority{
+			ID:       "batch-4",
+			SLO:      time.Now().Add(time.Hour),
+			Attempts: p.cfg.MaxDeliveryAttempts,
+		})
+
+		loopCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- p.RunPollingLoop(loopCtx) }()
+		defer func() {
+			cancel()
+			<-done
+		}()
+
+		for start := time.Now(); time.Since(start) < time.Second; time.Sleep(5 * time.Millisecond) {
+			if deadLetters, _ := p.clients.deadLetter.List(ctx); len(deadLetters) == 1 {
+				return
+			}
+		}
+		t.Errorf("expected the job to be moved to the dead-letter queue")
+	})
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the memory guard throttling the dispatch of lines when the memory use approaches its limit.
+package worker
+
+import (
+	"context"
+	"math"
+	"os"
+	"runtime/debug"
+	runtimemetrics "runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+)
+
+const (
+	// memoryResumeRatio is the fraction of the throttling threshold the memory use must drop below to stop
+	// throttling, so dispatch doesn't flap around the threshold.
+	memoryResumeRatio = 0.9
+
+	// the memory limit files of cgroup v2 and v1
+	cgroupV2MemoryLimitFile = "/sys/fs/cgroup/memory.max"
+	cgroupV1MemoryLimitFile = "/sys/fs/cgroup/memory/memory.limit_in_bytes"
+)
+
+// memoryGuard throttles the dispatch of lines while the memory used by the processor is above a threshold:
+// a job only starts a line when none of its lines is in flight, so every job progresses while the memory held
+// by the lines in flight is released.
+type memoryGuard struct {
+	threshold int64
+	interval  time.Duration
+	usage     func() int64
+
+	mu        sync.Mutex
+	sampledAt time.Time
+	throttled bool
+}
+
+// newMemoryGuard returns a guard throttling dispatch above ratio of limit, or nil (never throttle) if ratio or
+// limit is 0.
+func newMemoryGuard(ratio float64, limit int64, interval time.Duration) *memoryGuard {
+	if ratio <= 0 || limit <= 0 {
+		return nil
+	}
+	return &memoryGuard{
+		threshold: int64(ratio * float64(limit)),
+		interval:  interval,
+		usage:     memoryUsage,
+	}
+}
+
+// isThrottled reports whether dispatch is throttled, sampling the memory use if the last sample is older than
+// the check interval.
+func (g *memoryGuard) isThrottled() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if time.Since(g.sampledAt) < g.interval {
+		return g.throttled
+	}
+	usage := g.usage()
+	g.sampledAt = time.Now()
+	if g.throttled {
+		g.throttled = usage >= int64(memoryResumeRatio*float64(g.threshold))
+	} else {
+		g.throttled = usage >= g.threshold
+	}
+	metrics.SetMemoryUsage(usage, g.throttled)
+	return g.throttled
+}
+
+// wait blocks while dispatch is throttled and idle returns false, returning false if ctx is done first.
+func (g *memoryGuard) wait(ctx context.Context, idle func() bool) bool {
+	if g == nil {
+		return ctx.Err() == nil
+	}
+	for g.isThrottled() && !idle() {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(g.interval):
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// memoryUsage returns the memory obtained from the OS by the Go runtime and not released to it.
+func memoryUsage() int64 {
+	samples := []runtimemetrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	runtimemetrics.Read(samples)
+	return int64(samples[0].Value.Uint64() - samples[1].Value.Uint64())
+}
+
+// memoryLimit returns the configured memory limit, or the memory limit of the container (cgroup), or GOMEMLIMIT,
+// or 0 if there is none.
+func memoryLimit(configured int64) int64 {
+	if configured > 0 {
+		return configured
+	}
+	for _, file := range []string{cgroupV2MemoryLimitFile, cgroupV1MemoryLimitFile} {
+		if limit := readMemoryLimit(file); limit > 0 {
+			return limit
+		}
+	}
+	if limit := debug.SetMemoryLimit(-1); limit != math.MaxInt64 {
+		return limit
+	}
+	return 0
+}
+
+// readMemoryLimit returns the memory limit in a cgroup limit file, or 0 if the file doesn't exist or sets no limit.
+func readMemoryLimit(file string) int64 {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return 0
+	}
+	limit, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	// "max" in cgroup v2, a value close to the max int64 in cgroup v1
+	if err != nil || limit <= 0 || limit >= math.MaxInt64/2 {
+		return 0
+	}
+	return limit
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the memory guard.
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryGuard(t *testing.T) {
+	if g := newMemoryGuard(0.8, 0, time.Millisecond); g != nil {
+		t.Errorf("expected no guard without memory limit")
+	}
+	if g := newMemoryGuard(0, 1000, time.Millisecond); g != nil {
+		t.Errorf("expected no guard when throttling is disabled")
+	}
+
+	var usage atomic.Int64
+	g := newMemoryGuard(0.8, 1000, time.Millisecond)
+	g.usage = usage.Load
+
+	for _, step := range []struct {
+		usage     int64
+		throttled bool
+	}{
+		{usage: 700, throttled: false},
+		{usage: 800, throttled: true},
+		// throttling stops below 90% of the threshold
+		{usage: 750, throttled: true},
+		{usage: 710, throttled: false},
+		{usage: 750, throttled: false},
+	} {
+		usage.Store(step.usage)
+		time.Sleep(2 * time.Millisecond)
+		if throttled := g.isThrottled(); throttled != step.throttled {
+			t.Errorf("usage %d: isThrottled() = %v, want %v", step.usage, throttled, step.throttled)
+		}
+	}
+
+	t.Run("Wait", func(t *testing.T) {
+		usage.Store(900)
+		time.Sleep(2 * time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if g.wait(ctx, func() bool { return false }) {
+			t.Error("expected wait to block while throttled and lines are in flight")
+		}
+		// a job without lines in flight isn't throttled
+		if !g.wait(context.Background(), func() bool { return true }) {
+			t.Error("expected wait to return without lines in flight")
+		}
+
+		time.AfterFunc(10*time.Millisecond, func() { usage.Store(100) })
+		if !g.wait(context.Background(), func() bool { return false }) {
+			t.Error("expected wait to return once the memory use dropped")
+		}
+	})
+}
+
+func TestReadMemoryLimit(t *testing.T) {
+	dir := t.TempDir()
+	for content, want := range map[string]int64{
+		"max\n":                 0,
+		"1073741824\n":          1073741824,
+		"9223372036854771712\n": 0, // no limit in cgroup v1
+		"invalid":               0,
+	} {
+		file := filepath.Join(dir, "memory.max")
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if limit := readMemoryLimit(file); limit != want {
+			t.Errorf("readMemoryLimit(%q) = %d, want %d", content, limit, want)
+		}
+	}
+	if limit := readMemoryLimit(filepath.Join(dir, "missing")); limit != 0 {
+		t.Errorf("readMemoryLimit() of a missing file = %d, want 0", limit)
+	}
+	if limit := memoryLimit(2048); limit != 2048 {
+		t.Errorf("memoryLimit() = %d, want the configured limit", limit)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the pausing of the dispatch of the lines of a job by an operator, and the watching of the
+// events of the job.
+package worker
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/klog/v2"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// pauseGate holds the dispatch of the lines of a job while the job is paused.
+// A nil gate never pauses.
+type pauseGate struct {
+	mu sync.Mutex
+	// closed when the job is resumed, nil while the job isn't paused
+	resumed chan struct{}
+}
+
+func (g *pauseGate) pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed == nil {
+		g.resumed = make(chan struct{})
+	}
+}
+
+func (g *pauseGate) resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed != nil {
+		close(g.resumed)
+		g.resumed = nil
+	}
+}
+
+func (g *pauseGate) paused() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.resumed != nil
+}
+
+// wait blocks while the job is paused, returning false if ctx is done first.
+func (g *pauseGate) wait(ctx context.Context) bool {
+	if g == nil {
+		return ctx.Err() == nil
+	}
+	for {
+		g.mu.Lock()
+		resumed := g.resumed
+		g.mu.Unlock()
+		if resumed == nil {
+			return ctx.Err() == nil
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-resumed:
+		}
+	}
+}
+
+// watchEvents returns the pause gate of a job, following the pause and resume events of the job until the returned
+// function is called. The gate starts paused if the job was paused before its processing started. cancel is called
+// on the cancel events of the job.
+func (p *Processor) watchEvents(ctx context.Context, jobID string, cancel func()) (gate *pauseGate, stop func()) {
+	logger := klog.FromContext(ctx)
+	gate = &pauseGate{}
+
+	// the channel is opened before the pause state is read, so a resume sent in between isn't missed
+	events, err := p.clients.event.ConsumerGetChannel(ctx, jobID)
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to get event channel, pause, resume and cancel events are ignored", "jobID", jobID)
+	}
+	if paused, err := p.clients.status.Get(ctx, batch.PausedKey(jobID)); err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to get pause state", "jobID", jobID)
+	} else if paused != nil {
+		logger.V(logging.INFO).Info("Job is paused", "jobID", jobID)
+		gate.pause()
+	}
+	if events == nil {
+		return gate, func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case event, ok := <-events.Events:
+				if !ok {
+					return
+				}
+				switch event.Type {
+				case db.BatchEventPause:
+					logger.V(logging.INFO).Info("Pausing job", "jobID", jobID)
+					gate.pause()
+				case db.BatchEventResume:
+					logger.V(logging.INFO).Info("Resuming job", "jobID", jobID)
+					gate.resume()
+				case db.BatchEventCancel:
+					logger.V(logging.INFO).Info("Stopping cancelled job", "jobID", jobID)
+					cancel()
+				}
+			}
+		}
+	}()
+	return gate, func() {
+		close(done)
+		<-stopped
+		events.CloseFn()
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the pausing of jobs.
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+func TestPauseGate(t *testing.T) {
+	var nilGate *pauseGate
+	if !nilGate.wait(context.Background()) {
+		t.Error("expected a nil gate not to pause")
+	}
+
+	gate := &pauseGate{}
+	gate.pause()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if gate.wait(ctx) {
+		t.Error("expected wait to block while paused")
+	}
+
+	time.AfterFunc(10*time.Millisecond, gate.resume)
+	if !gate.wait(context.Background()) {
+		t.Error("expected wait to return once resumed")
+	}
+	if gate.paused() {
+		t.Error("expected the gate to be resumed")
+	}
+}
+
+func TestPausedJob(t *testing.T) {
+	ctx := context.Background()
+	pause := func(t *testing.T, env *testEnv, jobID string) {
+		t.Helper()
+		if err := env.processor.clients.status.Set(ctx, batch.PausedKey(jobID), jobStatusTTL, []byte("1")); err != nil {
+			t.Fatalf("Failed to store pause state: %v", err)
+		}
+	}
+
+	t.Run("Resumed", func(t *testing.T) {
+		inference := &modelRecordingClient{}
+		env := setupProcessorForTest(t, 2, inference)
+		p := env.processor
+		job := env.storeJob(t, "batch-1", time.Now().Add(time.Hour), "m1", "m1", "m1")
+		pause(t, env, job.ID)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			p.processJob(ctx, 1, job)
+		}()
+		time.Sleep(50 * time.Millisecond)
+		if received := inference.received(); len(received) != 0 {
+			t.Fatalf("got %d requests while paused, want 0", len(received))
+		}
+		if status := env.getStatus(t, job.ID); status.Status != openai.BatchStatusInProgress {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusInProgress)
+		}
+
+		p.clients.event.ProducerSendEvents(ctx, []db.BatchEvent{{ID: job.ID, Type: db.BatchEventResume, TTL: jobStatusTTL}})
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("job not processed after resume")
+		}
+		status := env.getStatus(t, job.ID)
+		want := openai.BatchRequestCounts{Total: 3, Completed: 3}
+		if status.Status != openai.BatchStatusCompleted || status.RequestCounts != want {
+			t.Errorf("got %v with %+v, want %v with %+v", status.Status, status.RequestCounts, openai.BatchStatusCompleted, want)
+		}
+	})
+
+	t.Run("DrainedWhilePaused", func(t *testing.T) {
+		env := setupProcessorForTest(t, 2, &modelRecordingClient{})
+		p := env.processor
+		job := env.storeJob(t, "batch-2", time.Now().Add(time.Hour), "m1", "m1")
+		pause(t, env, job.ID)
+
+		time.AfterFunc(20*time.Millisecond, p.startDrain)
+		if drained := p.processJob(ctx, 1, job); !drained {
+			t.Fatalf("expected the paused job to be drained")
+		}
+		checkpoint, _ := p.loadCheckpoint(ctx, job.ID)
+		if checkpoint == nil || checkpoint.Lines != 0 {
+			t.Errorf("unexpected checkpoint: %+v", checkpoint)
+		}
+	})
+}
@@ -0,0 +1,271 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the prefetching of the next job and its input file while the last lines of a job complete.
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"k8s.io/klog/v2"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// prefetcher holds the job leased ahead of a free worker. At most one job is leased ahead at a time.
+type prefetcher struct {
+	mu sync.Mutex
+	// the context of the polling loop taking the jobs leased ahead, nil until the loop runs
+	ctx context.Context
+	// the job leased ahead, nil if there is none
+	next *prefetchedJob
+	// a job is being leased ahead or was leased ahead and not taken yet
+	busy bool
+}
+
+// prefetchedJob is a job leased ahead of a free worker, whose input file is downloaded to a temporary file.
+type prefetchedJob struct {
+	task *db.BatchJobPriority
+	job  *db.BatchJob
+
+	// done if the lease was lost before the job was taken, or once the job is released
+	ctx       context.Context
+	cancel    context.CancelFunc
+	stopLease func()
+
+	fileID string
+	// closed once the input file is downloaded or failed to be
+	ready chan struct{}
+	// the downloaded input file, nil if it failed to be downloaded
+	input *os.File
+}
+
+// prefetchedJobKey is the context key of the prefetched job processed by a worker.
+type prefetchedJobKey struct{}
+
+// withPrefetchedJob returns a context carrying the prefetched job whose input file is read by processLines.
+func withPrefetchedJob(ctx context.Context, pj *prefetchedJob) context.Context {
+	if pj == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, prefetchedJobKey{}, pj)
+}
+
+// prefetchNext leases the next job ahead of a free worker and downloads its input file, if all the workers are
+// busy. A free worker leases the next job itself.
+func (p *Processor) prefetchNext() {
+	if !p.cfg.PrefetchInput || p.isDraining() {
+		return
+	}
+	if busy, limit := p.workerPool.Stats(); busy < limit {
+		return
+	}
+	p.prefetch.mu.Lock()
+	defer p.prefetch.mu.Unlock()
+	if p.prefetch.busy || p.prefetch.ctx == nil {
+		return
+	}
+	p.prefetch.busy = true
+	go p.leaseAhead(p.prefetch.ctx)
+}
+
+// leaseAhead leases the next job and downloads its input file, keeping the lease of the job until it is taken.
+func (p *Processor) leaseAhead(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	task := p.getTaskFromQueue(ctx)
+	if task == nil {
+		p.clearPrefetch()
+		return
+	}
+	job, err := p.getJobData(ctx, task)
+	if err != nil {
+		p.clearPrefetch()
+		return
+	}
+	if job.TraceContext == nil {
+		job.TraceContext = task.TraceContext
+	}
+
+	pj := &prefetchedJob{task: task, job: job, ready: make(chan struct{})}
+	pj.ctx, pj.cancel = context.WithCancel(ctx)
+	pj.stopLease = p.keepLease(pj.ctx, task, 0, pj.cancel)
+	p.prefetch.mu.Lock()
+	p.prefetch.next = pj
+	p.prefetch.mu.Unlock()
+	if p.isDraining() {
+		close(pj.ready)
+		p.dropPrefetched(ctx)
+		return
+	}
+	logger.V(logging.DEBUG).Info("Leased job ahead of a free worker", "jobID", task.ID)
+
+	defer close(pj.ready)
+	spec, _, err := decodeJob(job)
+	if err != nil {
+		// the job fails when it is processed
+		return
+	}
+	// the input of a job to be sharded is read by the shards
+	if task.Shard == nil && p.cfg.ShardLines > 0 {
+		return
+	}
+	pj.fileID = batch.FileLocation(spec.TenantID, spec.InputFileID)
+	pj.input, err = p.downloadInput(pj.ctx, pj.fileID)
+	if err != nil {
+		// the input file is retrieved again when the job is processed
+		logger.V(logging.WARNING).Info("Failed to prefetch input file", "jobID", task.ID, "error", err.Error())
+	}
+}
+
+func (p *Processor) clearPrefetch() {
+	p.prefetch.mu.Lock()
+	defer p.prefetch.mu.Unlock()
+	p.prefetch.busy = false
+}
+
+// downloadInput copies the input file to a temporary file, validating that its lines are JSON.
+func (p *Processor) downloadInput(ctx context.Context, fileID string) (*os.File, error) {
+	logger := klog.FromContext(ctx)
+	reader, _, err := p.clients.files.Retrieve(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve input file %s: %w", fileID, err)
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	f, err := os.CreateTemp("", "prefetched_input_*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+
+	var lines, invalid int
+	br := bufio.NewReader(reader)
+	for {
+		if err := ctx.Err(); err != nil {
+			removeFile(f)
+			return nil, err
+		}
+		line, readErr := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			lines++
+			if !json.Valid(line) {
+				invalid++
+			}
+		}
+		if _, err := f.Write(line); err != nil {
+			removeFile(f)
+			return nil, err
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			removeFile(f)
+			return nil, fmt.Errorf("failed to read input file %s: %w", fileID, readErr)
+		}
+	}
+	logger.V(logging.DEBUG).Info("Prefetched input file", "fileID", fileID, "lines", lines, "invalidLines", invalid)
+	return f, nil
+}
+
+// takePrefetched returns the job leased ahead of a free worker, or nil if there is none or its lease was lost.
+func (p *Processor) takePrefetched(ctx context.Context) *prefetchedJob {
+	p.prefetch.mu.Lock()
+	pj := p.prefetch.next
+	if pj != nil {
+		p.prefetch.next = nil
+		p.prefetch.busy = false
+	}
+	p.prefetch.mu.Unlock()
+	if pj == nil {
+		return nil
+	}
+
+	// the worker processing the job keeps the lease from now on
+	pj.stopLease()
+	if pj.ctx.Err() != nil {
+		klog.FromContext(ctx).V(logging.WARNING).Info("Lost the lease of the job leased ahead", "jobID", pj.task.ID)
+		pj.release()
+		return nil
+	}
+	return pj
+}
+
+// dropPrefetched puts the job leased ahead back to the queue, e.g. when the processor shuts down.
+func (p *Processor) dropPrefetched(ctx context.Context) {
+	pj := p.takePrefetched(ctx)
+	if pj == nil {
+		return
+	}
+	pj.release()
+	p.requeueDrained(ctx, pj.task)
+}
+
+// inputReader returns the prefetched input file if it is the one of the job processed with ctx, waiting for it to be
+// downloaded, or nil if there is none.
+func (pj *prefetchedJob) inputReader(ctx context.Context, fileID string) io.Reader {
+	if pj == nil {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-pj.ready:
+	}
+	if pj.input == nil || pj.fileID != fileID {
+		return nil
+	}
+	if _, err := pj.input.Seek(0, io.SeekStart); err != nil {
+		return nil
+	}
+	return pj.input
+}
+
+// release stops the download of the input file and deletes it.
+func (pj *prefetchedJob) release() {
+	if pj == nil {
+		return
+	}
+	pj.cancel()
+	<-pj.ready
+	if pj.input != nil {
+		removeFile(pj.input)
+	}
+}
+
+// retrieveInput returns the input file of the job, prefetched if it was.
+func (p *Processor) retrieveInput(ctx context.Context, fileID string) (io.Reader, bool, error) {
+	pj, _ := ctx.Value(prefetchedJobKey{}).(*prefetchedJob)
+	if reader := pj.inputReader(ctx, fileID); reader != nil {
+		return reader, true, nil
+	}
+	reader, _, err := p.clients.files.Retrieve(ctx, fileID)
+	return reader, false, err
+}
+
+func removeFile(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
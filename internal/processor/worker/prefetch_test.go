@@ -0,0 +1,118 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the prefetching of the next job.
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// waitPrefetched waits until a job was leased ahead and its input file downloaded.
+func waitPrefetched(t *testing.T, p *Processor) *prefetchedJob {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		p.prefetch.mu.Lock()
+		pj := p.prefetch.next
+		p.prefetch.mu.Unlock()
+		if pj != nil {
+			<-pj.ready
+			return pj
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("no job was leased ahead")
+	return nil
+}
+
+func TestPrefetch(t *testing.T) {
+	ctx := context.Background()
+	setup := func(t *testing.T) (*testEnv, *db.BatchJob) {
+		env := setupProcessorForTest(t, 2, &fakeInferenceClient{})
+		p := env.processor
+		p.cfg.TaskWaitTime = 0
+		p.prefetch.ctx = ctx
+		next := env.storeJob(t, "batch-next", time.Now().Add(time.Hour), "m1", "m1", "m1")
+		p.clients.priorityQueue.Enqueue(ctx, &db.BatchJobPriority{ID: next.ID, SLO: next.SLO})
+		return env, next
+	}
+
+	t.Run("InputPrefetched", func(t *testing.T) {
+		env, next := setup(t)
+		p := env.processor
+		p.workerPool = NewWorkerPool(1)
+		p.workerPool.TryAcquire()
+
+		p.prefetchNext()
+		waitPrefetched(t, p)
+		pj := p.takePrefetched(ctx)
+		if pj == nil || pj.task.ID != next.ID || pj.input == nil {
+			t.Fatalf("takePrefetched() = %+v, want the next job with its input", pj)
+		}
+		defer pj.release()
+		if err := p.clients.priorityQueue.RenewLease(ctx, next.ID, time.Minute); err != nil {
+			t.Errorf("RenewLease() error = %v, want the job leased", err)
+		}
+
+		// the job is processed from the prefetched input
+		if err := env.files.Delete(ctx, "file_"+next.ID); err != nil {
+			t.Fatalf("Failed to delete input file: %v", err)
+		}
+		p.processJob(withPrefetchedJob(ctx, pj), 1, pj.job)
+		status := env.getStatus(t, next.ID)
+		want := openai.BatchRequestCounts{Total: 3, Completed: 3}
+		if status.Status != openai.BatchStatusCompleted || status.RequestCounts != want {
+			t.Errorf("got %v with %+v, want %v with %+v", status.Status, status.RequestCounts, openai.BatchStatusCompleted, want)
+		}
+	})
+
+	t.Run("NotWhileWorkersAreFree", func(t *testing.T) {
+		env, _ := setup(t)
+		p := env.processor
+
+		p.prefetchNext()
+		time.Sleep(20 * time.Millisecond)
+		if pj := p.takePrefetched(ctx); pj != nil {
+			t.Errorf("takePrefetched() = %+v, want nil", pj)
+		}
+		if depth, _ := p.clients.priorityQueue.Len(ctx); depth != 1 {
+			t.Errorf("queue depth = %d, want 1", depth)
+		}
+	})
+
+	t.Run("RequeuedOnDrain", func(t *testing.T) {
+		env, next := setup(t)
+		p := env.processor
+		p.workerPool = NewWorkerPool(1)
+		p.workerPool.TryAcquire()
+
+		p.prefetchNext()
+		pj := waitPrefetched(t, p)
+		p.dropPrefetched(ctx)
+		if _, err := pj.input.Stat(); err == nil {
+			t.Error("expected the prefetched input to be deleted")
+		}
+		if task := p.getTaskFromQueue(ctx); task == nil || task.ID != next.ID || task.Attempts != 0 {
+			t.Errorf("expected the job back in the queue with unchanged attempts, got %+v", task)
+		}
+	})
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the tracking and reporting of the progress of a job.
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/features"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// jobProgress counts the lines of a job as they are read and processed.
+type jobProgress struct {
+	mu       sync.Mutex
+	metadata batch.JobResultMetadata
+
+	// processed is signaled every processedEvery processed lines, if processedEvery is set
+	processed      chan struct{}
+	processedEvery int
+}
+
+func newJobProgress(processedEvery int) *jobProgress {
+	return &jobProgress{
+		processed:      make(chan struct{}, 1),
+		processedEvery: processedEvery,
+	}
+}
+
+func (jp *jobProgress) addLine() {
+	jp.mu.Lock()
+	defer jp.mu.Unlock()
+	jp.metadata.Total++
+}
+
+// removeLine uncounts a line that was read but left for the next delivery of the job.
+func (jp *jobProgress) removeLine() {
+	jp.mu.Lock()
+	defer jp.mu.Unlock()
+	jp.metadata.Total--
+}
+
+func (jp *jobProgress) record(succeeded bool) {
+	jp.mu.Lock()
+	defer jp.mu.Unlock()
+	if succeeded {
+		jp.metadata.Succeeded++
+	} else {
+		jp.metadata.Failed++
+	}
+	if jp.processedEvery > 0 && (jp.metadata.Succeeded+jp.metadata.Failed)%jp.processedEvery == 0 {
+		select {
+		case jp.processed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// restore sets the counts of the lines processed on an earlier delivery of the job.
+func (jp *jobProgress) restore(metadata batch.JobResultMetadata) {
+	jp.mu.Lock()
+	defer jp.mu.Unlock()
+	jp.metadata = metadata
+}
+
+func (jp *jobProgress) snapshot() batch.JobResultMetadata {
+	jp.mu.Lock()
+	defer jp.mu.Unlock()
+	return jp.metadata
+}
+
+// failureSample counts the lines failing with non-retryable errors among the first lines of a job,
+// to detect a job whose payloads are systematically bad.
+type failureSample struct {
+	mu          sync.Mutex
+	size        int
+	maxFailures int
+	lines       int
+	failures    int
+}
+
+// newFailureSample returns a sample of the first size lines tolerating up to ratio of failures,
+// or nil if ratio is 0.
+func newFailureSample(size int, ratio float64) *failureSample {
+	if ratio <= 0 {
+		return nil
+	}
+	return &failureSample{size: size, maxFailures: int(ratio * float64(size))}
+}
+
+// add counts a processed line, returning true when the failures of the sample exceed the tolerated ratio.
+// It returns true at most once.
+func (fs *failureSample) add(failed bool) bool {
+	if fs == nil {
+		return false
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.lines >= fs.size {
+		return false
+	}
+	fs.lines++
+	if failed {
+		fs.failures++
+		return fs.failures == fs.maxFailures+1
+	}
+	return false
+}
+
+func requestCounts(metadata batch.JobResultMetadata) openai.BatchRequestCounts {
+	return openai.BatchRequestCounts{
+		Total:     int64(metadata.Total),
+		Completed: int64(metadata.Succeeded),
+		Failed:    int64(metadata.Failed),
+	}
+}
+
+// reportProgress periodically writes the request counts of the job to the database, until the returned
+// function is called. statusInfo must not be used by the caller until then.
+func (p *Processor) reportProgress(
+	ctx context.Context, job *db.BatchJob, statusInfo *openai.BatchStatusInfo, progress *jobProgress,
+) (stop func()) {
+	interval := p.cfg.ProgressUpdateInterval
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var last openai.BatchRequestCounts
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				counts := requestCounts(progress.snapshot())
+				if counts == last {
+					continue
+				}
+				// the status of a batch cancelled, or failed by an operator, isn't overwritten
+				if stored, err := p.storedStatus(ctx, job.ID); err == nil && stored.Status != statusInfo.Status {
+					continue
+				}
+				statusInfo.RequestCounts = counts
+				p.updateJob(ctx, job, statusInfo)
+				last = counts
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// publishProgress publishes progress events of the job to the event channel every ProgressEventLines processed
+// lines and every ProgressEventInterval, and once more when the returned function is called.
+func (p *Processor) publishProgress(ctx context.Context, job *db.BatchJob, progress *jobProgress) (stop func()) {
+	if (p.cfg.ProgressEventLines <= 0 && p.cfg.ProgressEventInterval <= 0) || !features.Enabled(features.ProgressEvents) {
+		return func() {}
+	}
+	logger := klog.FromContext(ctx)
+	ttl := job.TTL
+	if ttl <= 0 {
+		ttl = jobStatusTTL
+	}
+
+	var last batch.JobResultMetadata
+	lastTime := time.Now()
+	publish := func() {
+		metadata := progress.snapshot()
+		if metadata == last {
+			return
+		}
+		now := time.Now()
+		processed := metadata.Succeeded + metadata.Failed - last.Succeeded - last.Failed
+		event := db.BatchEvent{
+			ID:   job.ID,
+			Type: db.BatchEventProgress,
+			TTL:  ttl,
+			Progress: &db.BatchProgress{
+				Total:      int64(metadata.Total),
+				Completed:  int64(metadata.Succeeded),
+				Failed:     int64(metadata.Failed),
+				Throughput: float64(processed) / max(now.Sub(lastTime).Seconds(), 0.001),
+				Time:       now.UTC(),
+			},
+		}
+		if _, err := p.clients.event.ProducerSendEvents(ctx, []db.BatchEvent{event}); err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to publish job progress", "jobID", job.ID)
+		}
+		last, lastTime = metadata, now
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		var tick <-chan time.Time
+		if p.cfg.ProgressEventInterval > 0 {
+			ticker := time.NewTicker(p.cfg.ProgressEventInterval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				publish()
+				return
+			case <-tick:
+			case <-progress.processed:
+			}
+			publish()
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the weighted consumption of jobs from several priority queues.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// weightedQueue is a priority queue consumed with a share of the jobs proportional to its weight.
+type weightedQueue struct {
+	name   string
+	weight int
+	client db.BatchPriorityQueueClient
+
+	// current is the smooth weighted round-robin credit of the queue
+	current int
+}
+
+// queueSet leases jobs from several priority queues by smooth weighted round-robin. The queues are tried in order
+// of their credit, so a queue without waiting jobs passes its turn to the next one, and only the queue a job is
+// leased from is charged for it: while all the queues have waiting jobs, each gets a share proportional to its
+// weight, without consecutive picks of the same queue bunching up.
+type queueSet struct {
+	mu     sync.Mutex
+	queues []*weightedQueue
+	total  int
+
+	// the partition of the queues the jobs are leased from, set before the polling loop starts
+	partition db.QueuePartition
+}
+
+// newQueueSet returns the queues of cfgs, with their clients registered in clients.
+// Queues without a client are left out; the processor's pre-flight check reports them.
+func newQueueSet(cfgs []config.QueueConfig, clients *ProcessorClients) *queueSet {
+	qs := &queueSet{}
+	for _, cfg := range cfgs {
+		client := clients.queue(cfg.Name)
+		if client == nil {
+			continue
+		}
+		qs.queues = append(qs.queues, &weightedQueue{name: cfg.Name, weight: cfg.Weight, client: client})
+		qs.total += cfg.Weight
+	}
+	return qs
+}
+
+// lease leases the next job, or returns nil if no queue has waiting jobs.
+// The name of the queue the job was leased from is set in the Queue field of the job.
+// A single queue is waited on up to wait for a job to be available; several queues are polled without blocking,
+// as waiting on an empty queue would hold back the jobs of the others.
+func (qs *queueSet) lease(ctx context.Context, wait, leaseTTL time.Duration) (*db.BatchJobPriority, error) {
+	qs.mu.Lock()
+	defer qs.mu.Unlock()
+
+	order := make([]*weightedQueue, len(qs.queues))
+	copy(order, qs.queues)
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].current+order[i].weight > order[j].current+order[j].weight
+	})
+
+	if len(qs.queues) > 1 {
+		wait = 0
+	}
+	var firstErr error
+	for _, q := range order {
+		tasks, err := q.client.LeasePartition(ctx, wait, 1, leaseTTL, qs.partition) // get only one job
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to lease from queue %s: %w", q.name, err)
+			}
+			continue
+		}
+		if len(tasks) == 0 {
+			continue
+		}
+		for _, other := range qs.queues {
+			other.current += other.weight
+		}
+		q.current -= qs.total
+		tasks[0].Queue = q.name
+		return tasks[0], nil
+	}
+	return nil, firstErr
+}
+
+// queuePartition returns the partition of the queues consumed by the processor. The index -1 is the ordinal
+// suffix of the host name, e.g. of the pod of a StatefulSet.
+func queuePartition(index, count int, hostname func() (string, error)) (db.QueuePartition, error) {
+	if count <= 1 {
+		return db.QueuePartition{}, nil
+	}
+	if index == -1 {
+		name, err := hostname()
+		if err != nil {
+			return db.QueuePartition{}, fmt.Errorf("failed to get the host name: %w", err)
+		}
+		sep := strings.LastIndex(name, "-")
+		index, err = strconv.Atoi(name[sep+1:])
+		if sep < 0 || err != nil || index < 0 {
+			return db.QueuePartition{}, fmt.Errorf("the host name %q has no ordinal suffix to take the partition index from", name)
+		}
+		if index >= count {
+			return db.QueuePartition{}, fmt.Errorf("the ordinal %d of the host name %q is not below partition_count %d", index, name, count)
+		}
+	}
+	return db.QueuePartition{Index: index, Count: count}, nil
+}
+
+// client returns the client of the named queue. Jobs without a known queue belong to the first queue.
+func (qs *queueSet) client(name string) db.BatchPriorityQueueClient {
+	for _, q := range qs.queues {
+		if q.name == name {
+			return q.client
+		}
+	}
+	return qs.queues[0].client
+}
+
+// len returns the number of jobs waiting in all the queues.
+func (qs *queueSet) len(ctx context.Context) (int, error) {
+	total := 0
+	for _, q := range qs.queues {
+		n, err := q.client.Len(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get length of queue %s: %w", q.name, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// recordStats exports the statistics of the queues as gauges. A queue whose statistics can't be read keeps its
+// previous values.
+func (qs *queueSet) recordStats(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	for _, q := range qs.queues {
+		stats, err := q.client.Stats(ctx)
+		if err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to get queue statistics", "queue", q.name)
+			continue
+		}
+		var oldestAge time.Duration
+		if !stats.OldestEnqueuedAt.IsZero() {
+			oldestAge = max(time.Since(stats.OldestEnqueuedAt), 0)
+		}
+		metrics.SetQueueStats(q.name, stats.Waiting, stats.Delayed, stats.Leased, oldestAge)
+	}
+}
+
+// runQueueMetrics exports the statistics of the queues every QueueMetricsInterval until ctx is done.
+func (p *Processor) runQueueMetrics(ctx context.Context) {
+	if p.cfg.QueueMetricsInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(p.cfg.QueueMetricsInterval)
+	defer ticker.Stop()
+	for {
+		p.queues.recordStats(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the weighted consumption of priority queues.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	mockapi "github.com/llm-d-incubation/batch-gateway/internal/database/mock"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+)
+
+func setupQueuesForTest(t *testing.T, queues ...config.QueueConfig) (*Processor, map[string]db.BatchPriorityQueueClient) {
+	t.Helper()
+	env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+	clients := map[string]db.BatchPriorityQueueClient{config.DefaultQueueName: env.processor.clients.priorityQueue}
+	for _, queue := range queues {
+		if queue.Name != config.DefaultQueueName {
+			clients[queue.Name] = mockapi.NewMockBatchPriorityQueueClient()
+			env.processor.clients.AddPriorityQueue(queue.Name, clients[queue.Name])
+		}
+	}
+	env.processor.cfg.Queues = queues
+	env.processor.queues = newQueueSet(queues, env.processor.clients)
+	return env.processor, clients
+}
+
+func fillQueue(ctx context.Context, queue db.BatchPriorityQueueClient, name string, n int) {
+	for i := 0; i < n; i++ {
+		queue.Enqueue(ctx, &db.BatchJobPriority{ID: fmt.Sprintf("%s-%d", name, i), SLO: time.Now().Add(time.Hour)})
+	}
+}
+
+func TestQueueSet(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("WeightedShares", func(t *testing.T) {
+		p, clients := setupQueuesForTest(t,
+			config.QueueConfig{Name: "high", Weight: 3},
+			config.QueueConfig{Name: "normal", Weight: 2},
+			config.QueueConfig{Name: config.DefaultQueueName, Weight: 1},
+		)
+		for name, queue := range clients {
+			fillQueue(ctx, queue, name, 20)
+		}
+
+		var order []string
+		counts := map[string]int{}
+		for i := 0; i < 12; i++ {
+			task := p.getTaskFromQueue(ctx)
+			if task == nil {
+				t.Fatalf("expected a task in the queues")
+			}
+			order = append(order, task.Queue)
+			counts[task.Queue]++
+		}
+		if counts["high"] != 6 || counts["normal"] != 4 || counts[config.DefaultQueueName] != 2 {
+			t.Errorf("dequeued jobs per queue = %v, want high:6 normal:4 default:2", counts)
+		}
+		// smooth round-robin interleaves the queues rather than draining the heaviest first
+		if order[0] != "high" || order[1] != "normal" || order[2] != "high" {
+			t.Errorf("dequeue order = %v, want high, normal, high first", order)
+		}
+	})
+
+	t.Run("EmptyQueuePassesItsTurn", func(t *testing.T) {
+		p, clients := setupQueuesForTest(t,
+			config.QueueConfig{Name: "high", Weight: 5},
+			config.QueueConfig{Name: config.DefaultQueueName, Weight: 1},
+		)
+		fillQueue(ctx, clients[config.DefaultQueueName], "low", 3)
+
+		for i := 0; i < 3; i++ {
+			if task := p.getTaskFromQueue(ctx); task == nil || task.Queue != config.DefaultQueueName {
+				t.Fatalf("getTaskFromQueue() = %+v, want a task of the default queue", task)
+			}
+		}
+		if task := p.getTaskFromQueue(ctx); task != nil {
+			t.Errorf("getTaskFromQueue() = %+v, want nil", task)
+		}
+		if depth, _ := p.queues.len(ctx); depth != 0 {
+			t.Errorf("queue depth = %d, want 0", depth)
+		}
+	})
+
+	t.Run("RequeueToOriginQueue", func(t *testing.T) {
+		p, clients := setupQueuesForTest(t,
+			config.QueueConfig{Name: "tenant-a", Weight: 1},
+			config.QueueConfig{Name: config.DefaultQueueName, Weight: 1},
+		)
+		p.cfg.RequeueBackoff = 0
+		fillQueue(ctx, clients["tenant-a"], "tenant-a", 1)
+
+		task := p.getTaskFromQueue(ctx)
+		if task == nil || task.Queue != "tenant-a" {
+			t.Fatalf("getTaskFromQueue() = %+v, want a task of tenant-a", task)
+		}
+		p.requeueOrDeadLetter(ctx, task, fmt.Errorf("transient"))
+		if depth, _ := clients["tenant-a"].Len(ctx); depth != 1 {
+			t.Errorf("tenant-a depth = %d, want 1", depth)
+		}
+		if depth, _ := clients[config.DefaultQueueName].Len(ctx); depth != 0 {
+			t.Errorf("default depth = %d, want 0", depth)
+		}
+	})
+
+	t.Run("DuplicateEnqueue", func(t *testing.T) {
+		p, clients := setupQueuesForTest(t, config.QueueConfig{Name: config.DefaultQueueName, Weight: 1})
+		queue := clients[config.DefaultQueueName]
+
+		// a job published twice is delivered once
+		fillQueue(ctx, queue, "dup", 2)
+		fillQueue(ctx, queue, "dup", 2)
+		if depth, _ := queue.Len(ctx); depth != 2 {
+			t.Errorf("queue depth = %d, want 2", depth)
+		}
+
+		// a job waiting for its requeue backoff isn't published again either
+		task := p.getTaskFromQueue(ctx)
+		p.requeueOrDeadLetter(ctx, task, fmt.Errorf("transient"))
+		queue.Enqueue(ctx, &db.BatchJobPriority{ID: task.ID, SLO: time.Now().Add(time.Hour)})
+		if depth, _ := queue.Len(ctx); depth != 1 {
+			t.Errorf("queue depth = %d, want 1", depth)
+		}
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		p, clients := setupQueuesForTest(t, config.QueueConfig{Name: config.DefaultQueueName, Weight: 1})
+		queue := clients[config.DefaultQueueName]
+		before := time.Now()
+		fillQueue(ctx, queue, "stats", 3)
+		p.getTaskFromQueue(ctx)
+		queue.EnqueueDelayed(ctx, &db.BatchJobPriority{ID: "delayed", SLO: time.Now()}, time.Hour)
+
+		stats, err := queue.Stats(ctx)
+		if err != nil {
+			t.Fatalf("Stats() error = %v", err)
+		}
+		if stats.Waiting != 2 || stats.Delayed != 1 || stats.Leased != 1 {
+			t.Errorf("Stats() = %+v, want 2 waiting, 1 delayed and 1 leased", stats)
+		}
+		if stats.OldestEnqueuedAt.Before(before) || stats.OldestEnqueuedAt.After(time.Now()) {
+			t.Errorf("OldestEnqueuedAt = %v, want the time the jobs were enqueued", stats.OldestEnqueuedAt)
+		}
+		p.queues.recordStats(ctx)
+
+		for range 2 {
+			p.getTaskFromQueue(ctx)
+		}
+		if stats, _ := queue.Stats(ctx); stats.Waiting != 0 || !stats.OldestEnqueuedAt.IsZero() {
+			t.Errorf("Stats() = %+v, want no waiting job", stats)
+		}
+	})
+
+	t.Run("BlockingSingleQueue", func(t *testing.T) {
+		p, clients := setupQueuesForTest(t, config.QueueConfig{Name: config.DefaultQueueName, Weight: 1})
+		p.cfg.TaskWaitTime = 5 * time.Second
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			fillQueue(ctx, clients[config.DefaultQueueName], "late", 1)
+		}()
+
+		// the dequeue waits for the job rather than returning empty
+		start := time.Now()
+		if task := p.getTaskFromQueue(ctx); task == nil || task.ID != "late-0" {
+			t.Fatalf("getTaskFromQueue() = %+v, want the job enqueued while waiting", task)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("getTaskFromQueue() took %v, want to return once the job is enqueued", elapsed)
+		}
+	})
+
+	t.Run("NoBlockingWithSeveralQueues", func(t *testing.T) {
+		p, _ := setupQueuesForTest(t,
+			config.QueueConfig{Name: "tenant-a", Weight: 1},
+			config.QueueConfig{Name: config.DefaultQueueName, Weight: 1},
+		)
+		p.cfg.TaskWaitTime = 5 * time.Second
+
+		start := time.Now()
+		if task := p.getTaskFromQueue(ctx); task != nil {
+			t.Fatalf("getTaskFromQueue() = %+v, want nil", task)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("getTaskFromQueue() took %v, want to return without waiting", elapsed)
+		}
+	})
+
+	t.Run("MissingQueueClient", func(t *testing.T) {
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+		env.processor.cfg.Queues = []config.QueueConfig{{Name: "unknown", Weight: 1}}
+		if err := env.processor.prepare(ctx); err == nil {
+			t.Errorf("prepare() with a missing queue client succeeded")
+		}
+	})
+}
+
+func TestQueuePartition(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Resolve", func(t *testing.T) {
+		hostname := func(name string) func() (string, error) {
+			return func() (string, error) { return name, nil }
+		}
+		tests := []struct {
+			name     string
+			index    int
+			count    int
+			hostname string
+			want     db.QueuePartition
+			wantErr  bool
+		}{
+			{name: "disabled", index: -1, count: 1, want: db.QueuePartition{}},
+			{name: "configured", index: 2, count: 4, want: db.QueuePartition{Index: 2, Count: 4}},
+			{name: "ordinal", index: -1, count: 4, hostname: "batch-processor-3", want: db.QueuePartition{Index: 3, Count: 4}},
+			{name: "no ordinal", index: -1, count: 4, hostname: "batch-processor", wantErr: true},
+			{name: "ordinal out of range", index: -1, count: 4, hostname: "batch-processor-4", wantErr: true},
+		}
+		for _, tt := range tests {
+			got, err := queuePartition(tt.index, tt.count, hostname(tt.hostname))
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("%s: queuePartition() = %+v, %v, want %+v (error %v)", tt.name, got, err, tt.want, tt.wantErr)
+			}
+		}
+	})
+
+	t.Run("DisjointConsumption", func(t *testing.T) {
+		queue := mockapi.NewMockBatchPriorityQueueClient()
+		fillQueue(ctx, queue, "job", 40)
+
+		// every job is leased by the processor of its partition only
+		leased := map[string]int{}
+		for index := 2; index >= 0; index-- {
+			partition := db.QueuePartition{Index: index, Count: 3}
+			for {
+				tasks, err := queue.LeasePartition(ctx, 0, 1, time.Minute, partition)
+				if err != nil {
+					t.Fatalf("LeasePartition() error = %v", err)
+				}
+				if len(tasks) == 0 {
+					break
+				}
+				if !partition.Contains(tasks[0].ID) {
+					t.Errorf("partition %d leased %s of another partition", index, tasks[0].ID)
+				}
+				leased[tasks[0].ID]++
+			}
+		}
+		if len(leased) != 40 {
+			t.Errorf("got %d leased jobs, want 40", len(leased))
+		}
+		for id, n := range leased {
+			if n != 1 {
+				t.Errorf("%s was leased %d times", id, n)
+			}
+		}
+	})
+}
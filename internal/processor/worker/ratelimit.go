@@ -0,0 +1,218 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the rate limiter of the inference requests sent to each endpoint.
+
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+)
+
+// promptParams are the request parameters holding the input of the model, depending on the endpoint.
+var promptParams = []string{"messages", "prompt", "input"}
+
+// charsPerToken is the approximate number of characters of a token, used to estimate the tokens of a prompt.
+const charsPerToken = 4
+
+// imageTokens is the approximate number of tokens of an image of a prompt, that of a high detail image.
+const imageTokens = 1000
+
+// tokenBucket is a token bucket refilled at rate tokens per second up to burst tokens. Reservations may take the
+// bucket below zero, later reservations then wait until it is refilled.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+// reserve takes n tokens, returning how long to wait until the tokens are available.
+func (b *tokenBucket) reserve(n float64, now time.Time) time.Duration {
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// refund gives back n tokens, or takes more if n is negative.
+func (b *tokenBucket) refund(n float64) {
+	b.tokens = min(b.burst, b.tokens+n)
+}
+
+// endpointLimiter holds the buckets of an endpoint, nil buckets don't limit.
+type endpointLimiter struct {
+	requests *tokenBucket
+	tokens   *tokenBucket
+}
+
+// rateLimiter bounds the requests per second and tokens per minute sent to each endpoint (a model served by the
+// inference gateway), across all the jobs of the processor. The endpoints of the inference targets are limited
+// separately from those of the batches without target.
+type rateLimiter struct {
+	limits map[string]config.RateLimitConfig
+
+	mu        sync.Mutex
+	endpoints map[string]*endpointLimiter
+	now       func() time.Time
+}
+
+// newRateLimiter returns a limiter enforcing limits, or nil (no limits) if limits is empty.
+func newRateLimiter(limits []config.RateLimitConfig) *rateLimiter {
+	if len(limits) == 0 {
+		return nil
+	}
+	rl := &rateLimiter{
+		limits:    make(map[string]config.RateLimitConfig, len(limits)),
+		endpoints: make(map[string]*endpointLimiter),
+		now:       time.Now,
+	}
+	for _, limit := range limits {
+		rl.limits[endpointName(limit.Target, limit.Model)] = limit
+	}
+	return rl
+}
+
+// endpointName returns the name of the endpoint serving the model for the batches of the inference target.
+func endpointName(target, model string) string {
+	if target == "" {
+		return model
+	}
+	return target + "/" + model
+}
+
+// endpoint returns the limiter of the model of the target, creating it on first use. Must be called with mu held.
+func (rl *rateLimiter) endpoint(target, model string) *endpointLimiter {
+	name := endpointName(target, model)
+	if limiter, ok := rl.endpoints[name]; ok {
+		return limiter
+	}
+	limit, ok := rl.limits[name]
+	if !ok {
+		limit = rl.limits[endpointName(target, config.DefaultRateLimitModel)]
+	}
+	limiter := &endpointLimiter{}
+	now := rl.now()
+	if limit.RequestsPerSecond > 0 {
+		limiter.requests = newTokenBucket(limit.RequestsPerSecond, max(limit.RequestsPerSecond, 1), now)
+	}
+	if limit.TokensPerMinute > 0 {
+		limiter.tokens = newTokenBucket(float64(limit.TokensPerMinute)/60, float64(limit.TokensPerMinute), now)
+	}
+	rl.endpoints[name] = limiter
+	return limiter
+}
+
+// wait blocks until a request of the estimated number of tokens can be sent to the model of the target,
+// returning false if ctx is done first. The reservation is released if ctx is done.
+func (rl *rateLimiter) wait(ctx context.Context, target, model string, tokens int) bool {
+	if rl == nil {
+		return ctx.Err() == nil
+	}
+	rl.mu.Lock()
+	limiter := rl.endpoint(target, model)
+	now := rl.now()
+	var delay time.Duration
+	if limiter.requests != nil {
+		delay = limiter.requests.reserve(1, now)
+	}
+	if limiter.tokens != nil {
+		delay = max(delay, limiter.tokens.reserve(float64(tokens), now))
+	}
+	rl.mu.Unlock()
+
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+	metrics.RecordRateLimitedRequest(model)
+	if sleep(ctx, delay) {
+		return true
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if limiter.requests != nil {
+		limiter.requests.refund(1)
+	}
+	if limiter.tokens != nil {
+		limiter.tokens.refund(float64(tokens))
+	}
+	return false
+}
+
+// adjust corrects the tokens reserved for a request to the model of the target with the tokens it actually used.
+func (rl *rateLimiter) adjust(target, model string, estimated, actual int) {
+	if rl == nil || estimated == actual {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if limiter := rl.endpoint(target, model); limiter.tokens != nil {
+		limiter.tokens.refund(float64(estimated - actual))
+	}
+}
+
+// estimateTokens estimates the tokens used by a request: the tokens of its input, approximated from its size,
+// and its output token limit. The images of the input are counted imageTokens each rather than by their size.
+func estimateTokens(params map[string]interface{}) int {
+	tokens := 0
+	for _, name := range promptParams {
+		if value, ok := params[name]; ok {
+			data, _ := json.Marshal(value)
+			tokens += len(data) / charsPerToken
+		}
+	}
+	batch.ForEachImageURL(params, func(imageURL map[string]any, _ string) error {
+		url, _ := imageURL["url"].(string)
+		tokens += imageTokens - len(url)/charsPerToken
+		return nil
+	})
+	tokens = max(tokens, 0)
+	for _, name := range maxTokensParams {
+		if maxTokens, ok := params[name].(float64); ok && maxTokens > 0 {
+			tokens += int(maxTokens)
+			break
+		}
+	}
+	return max(tokens, 1)
+}
+
+// usedTokens returns the tokens used by a request as reported in the usage of its response, or false if the
+// response has no usage.
+func usedTokens(response []byte) (int, bool) {
+	var body struct {
+		Usage *struct {
+			TotalTokens int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(response, &body); err != nil || body.Usage == nil {
+		return 0, false
+	}
+	return body.Usage.TotalTokens, true
+}
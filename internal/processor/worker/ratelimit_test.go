@@ -0,0 +1,144 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the rate limiter of inference requests.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Now()
+	b := newTokenBucket(2, 2, start)
+
+	for i, want := range []time.Duration{0, 0, 500 * time.Millisecond, time.Second} {
+		if got := b.reserve(1, start); got != want {
+			t.Errorf("reserve #%d = %v, want %v", i+1, got, want)
+		}
+	}
+	// refilled at 2 tokens per second, from -2 tokens
+	if got := b.reserve(1, start.Add(1500*time.Millisecond)); got != 0 {
+		t.Errorf("reserve after refill = %v, want 0", got)
+	}
+	// never refilled above the burst
+	if got := b.reserve(3, start.Add(time.Hour)); got != 500*time.Millisecond {
+		t.Errorf("reserve above burst = %v, want 500ms", got)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	rl := newRateLimiter([]config.RateLimitConfig{
+		{Model: config.DefaultRateLimitModel, RequestsPerSecond: 1},
+		{Model: "m1", TokensPerMinute: 600},
+		{Target: "east", Model: config.DefaultRateLimitModel, TokensPerMinute: 60},
+	})
+	rl.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	t.Run("NoLimits", func(t *testing.T) {
+		var none *rateLimiter
+		if !none.wait(ctx, "", "m1", 100) {
+			t.Errorf("wait() without limits = false")
+		}
+		none.adjust("", "m1", 100, 10)
+	})
+
+	t.Run("DefaultLimits", func(t *testing.T) {
+		if !rl.wait(ctx, "", "m2", 1) {
+			t.Fatalf("first wait() = false")
+		}
+		// the second request must wait a second, the reservation is released when ctx is done
+		waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		if rl.wait(waitCtx, "", "m2", 1) {
+			t.Errorf("wait() above the rate = true")
+		}
+		if tokens := rl.endpoints["m2"].requests.tokens; tokens != 0 {
+			t.Errorf("requests tokens after cancelled wait = %v, want 0", tokens)
+		}
+		if rl.endpoints["m2"].tokens != nil {
+			t.Errorf("model without token limit has a tokens bucket")
+		}
+	})
+
+	t.Run("TokenLimits", func(t *testing.T) {
+		if !rl.wait(ctx, "", "m1", 500) {
+			t.Fatalf("wait() = false")
+		}
+		if rl.endpoints["m1"].requests != nil {
+			t.Errorf("model with its own limits has the default requests bucket")
+		}
+		// the request used fewer tokens than estimated
+		rl.adjust("", "m1", 500, 100)
+		if tokens := rl.endpoints["m1"].tokens.tokens; tokens != 500 {
+			t.Errorf("tokens after adjust = %v, want 500", tokens)
+		}
+	})
+
+	t.Run("Targets", func(t *testing.T) {
+		// the models of a target are limited separately, by the limits of the target only
+		if !rl.wait(ctx, "east", "m1", 50) {
+			t.Fatalf("wait() = false")
+		}
+		limiter := rl.endpoints["east/m1"]
+		if limiter == nil || limiter.requests != nil || limiter.tokens.burst != 60 {
+			t.Errorf("limiter of m1 of target east = %+v, want the default limits of the target", limiter)
+		}
+		if tokens := rl.endpoints["m1"].tokens.tokens; tokens != 500 {
+			t.Errorf("tokens of m1 without target = %v, want 500", tokens)
+		}
+		if !rl.wait(ctx, "west", "m1", 1) {
+			t.Errorf("wait() of a target without limits = false")
+		}
+	})
+}
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		want   int
+	}{
+		{name: "chat", params: `{"model":"m1","messages":[{"role":"user","content":"hello world, how are you?"}],"max_tokens":100}`, want: 113},
+		{name: "embeddings", params: `{"model":"m1","input":"0123456789abcdef"}`, want: 4},
+		{name: "empty", params: `{"model":"m1"}`, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]interface{}{}
+			json.Unmarshal([]byte(tt.params), &params)
+			if got := estimateTokens(params); got != tt.want {
+				t.Errorf("estimateTokens() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsedTokens(t *testing.T) {
+	if got, ok := usedTokens([]byte(`{"object":"chat.completion","usage":{"prompt_tokens":5,"total_tokens":42}}`)); !ok || got != 42 {
+		t.Errorf("usedTokens() = %v, %v, want 42, true", got, ok)
+	}
+	if _, ok := usedTokens([]byte(`{"object":"chat.completion"}`)); ok {
+		t.Errorf("usedTokens() of a response without usage = true")
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the reaper expiring the batches stuck in a non-final status.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// reaperPageSize is the number of batches read from the database at once by the reaper.
+const reaperPageSize = 100
+
+// runReaper expires the stuck batches every StuckBatchCheckInterval until ctx is done, if StuckBatchTimeout is set.
+func (p *Processor) runReaper(ctx context.Context) {
+	if p.cfg.StuckBatchTimeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(p.cfg.StuckBatchCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p.reapStuckBatches(ctx)
+	}
+}
+
+// reapStuckBatches expires the batches still validating, in progress or finalizing StuckBatchTimeout after the
+// end of their completion window, whose last worker heartbeat expired, and returns their number.
+func (p *Processor) reapStuckBatches(ctx context.Context) int {
+	logger := klog.FromContext(ctx)
+	deadline := time.Now().Add(-p.cfg.StuckBatchTimeout)
+	reaped := 0
+	for start := 0; ; {
+		jobs, cursor, err := p.clients.database.Get(ctx, nil, nil, db.TagsLogicalCondNa, false, start, reaperPageSize)
+		if err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to list batches to reap")
+			return reaped
+		}
+		for _, job := range jobs {
+			if ctx.Err() != nil {
+				return reaped
+			}
+			if job.SLO.Before(deadline) && p.reapIfStuck(ctx, job) {
+				reaped++
+			}
+		}
+		if cursor == 0 || len(jobs) == 0 {
+			return reaped
+		}
+		start = cursor
+	}
+}
+
+// reapIfStuck expires the job if it is validating, in progress or finalizing and no worker processes it.
+func (p *Processor) reapIfStuck(ctx context.Context, job *db.BatchJob) bool {
+	logger := klog.FromContext(ctx).WithValues("jobID", job.ID)
+	// the static part of the job is not read
+	statusInfo := &openai.BatchStatusInfo{}
+	if err := json.Unmarshal(job.Status, statusInfo); err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to decode the status of the batch to reap")
+		return false
+	}
+	stuckStatus := statusInfo.Status
+	switch stuckStatus {
+	case openai.BatchStatusValidating, openai.BatchStatusInProgress, openai.BatchStatusFinalizing:
+	default:
+		return false
+	}
+	if p.lastHeartbeat(ctx, job.ID) != nil {
+		return false
+	}
+
+	now := time.Now().UTC().Unix()
+	statusInfo.Status = openai.BatchStatusExpired
+	statusInfo.ExpiredAt = &now
+	addBatchError(statusInfo, "batch_stuck",
+		"The batch was not processed to completion and no worker was processing it after its completion window ended.")
+	p.setJobStatus(ctx, job, statusInfo)
+
+	// the consumers of the progress of the batch see its last counts
+	ttl := job.TTL
+	if ttl <= 0 {
+		ttl = jobStatusTTL
+	}
+	if _, err := p.clients.event.ProducerSendEvents(ctx, []db.BatchEvent{{
+		ID:   job.ID,
+		Type: db.BatchEventProgress,
+		TTL:  ttl,
+		Progress: &db.BatchProgress{
+			Total:     statusInfo.RequestCounts.Total,
+			Completed: statusInfo.RequestCounts.Completed,
+			Failed:    statusInfo.RequestCounts.Failed,
+			Time:      time.Now().UTC(),
+		},
+	}}); err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to publish the progress of the reaped batch")
+	}
+	metrics.RecordBatchReaped(string(stuckStatus))
+	logger.V(logging.WARNING).Info("Expired stuck batch", "status", stuckStatus, "slo", job.SLO)
+	return true
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the reaper of stuck batches.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+func TestReapStuckBatches(t *testing.T) {
+	ctx := context.Background()
+	env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+	p := env.processor
+	p.cfg.StuckBatchTimeout = time.Hour
+
+	store := func(id string, slo time.Time, status openai.BatchStatus) *db.BatchJob {
+		job := env.storeJob(t, id, slo, "m")
+		job.Status, _ = json.Marshal(openai.BatchStatusInfo{
+			Status:        status,
+			RequestCounts: openai.BatchRequestCounts{Total: 4, Completed: 3},
+		})
+		env.dbClient.Update(ctx, job)
+		return job
+	}
+	longAgo := time.Now().Add(-2 * time.Hour)
+	stuck := store("batch-stuck", longAgo, openai.BatchStatusInProgress)
+	validating := store("batch-validating", longAgo, openai.BatchStatusValidating)
+	processed := store("batch-processed", longAgo, openai.BatchStatusInProgress)
+	p.recordHeartbeat(ctx, 1, processed.ID)
+	recent := store("batch-recent", time.Now().Add(-10*time.Minute), openai.BatchStatusInProgress)
+	completed := store("batch-completed", longAgo, openai.BatchStatusCompleted)
+	cancelling := store("batch-cancelling", longAgo, openai.BatchStatusCancelling)
+
+	events, err := p.clients.event.ConsumerGetChannel(ctx, stuck.ID)
+	if err != nil {
+		t.Fatalf("ConsumerGetChannel() error = %v", err)
+	}
+	defer events.CloseFn()
+
+	if reaped := p.reapStuckBatches(ctx); reaped != 2 {
+		t.Errorf("reapStuckBatches() = %d, want 2", reaped)
+	}
+
+	for _, job := range []*db.BatchJob{stuck, validating} {
+		status := env.getStatus(t, job.ID)
+		if status.Status != openai.BatchStatusExpired || status.ExpiredAt == nil {
+			t.Errorf("status of %s = %s, want expired", job.ID, status.Status)
+		}
+		if status.Errors == nil || len(status.Errors.Data) != 1 || status.Errors.Data[0].Code != "batch_stuck" {
+			t.Errorf("errors of %s = %+v, want batch_stuck", job.ID, status.Errors)
+		}
+		if data, _ := p.clients.status.Get(ctx, job.ID); string(data) != string(batch.StatusExpired) {
+			t.Errorf("status store of %s = %q, want expired", job.ID, data)
+		}
+	}
+	for job, want := range map[*db.BatchJob]openai.BatchStatus{
+		processed:  openai.BatchStatusInProgress,
+		recent:     openai.BatchStatusInProgress,
+		completed:  openai.BatchStatusCompleted,
+		cancelling: openai.BatchStatusCancelling,
+	} {
+		if status := env.getStatus(t, job.ID); status.Status != want {
+			t.Errorf("status of %s = %s, want %s", job.ID, status.Status, want)
+		}
+	}
+
+	select {
+	case event := <-events.Events:
+		if event.Type != db.BatchEventProgress || event.Progress == nil || event.Progress.Completed != 3 {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	default:
+		t.Error("no progress event was published for the reaped batch")
+	}
+}
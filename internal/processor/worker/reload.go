@@ -0,0 +1,75 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the application of configuration changes at runtime.
+
+package worker
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/features"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// Reload applies the changes of the settings of cfg that can be changed at runtime: the max number of workers,
+// the poll interval, the rate limits of the models and the feature flags. The jobs in progress are not
+// interrupted; when the max number of workers is lowered, busy workers above it are removed once their job is
+// done, and the feature flags apply to the next jobs.
+// The other settings take effect on restart.
+func (p *Processor) Reload(ctx context.Context, cfg *config.ProcessorConfig) {
+	logger := klog.FromContext(ctx)
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	if numWorkers := p.workerPool.Size(); cfg.NumWorkers != numWorkers {
+		limit := p.workerPool.Resize(cfg.NumWorkers)
+		if !p.cfg.AutoscaleEnabled {
+			limit = p.workerPool.SetLimit(cfg.NumWorkers)
+		}
+		metrics.SetTotalWorkers(limit)
+		logger.V(logging.INFO).Info("Reloaded max number of workers", "from", numWorkers, "to", cfg.NumWorkers, "limit", limit)
+	}
+	if pollInterval := time.Duration(p.pollInterval.Load()); cfg.PollInterval != pollInterval {
+		p.pollInterval.Store(int64(cfg.PollInterval))
+		logger.V(logging.INFO).Info("Reloaded poll interval", "from", pollInterval, "to", cfg.PollInterval)
+	}
+	if !slices.Equal(cfg.RateLimits, p.rateLimits) {
+		// the buckets of the new limits start full
+		p.rateLimiter.Store(newRateLimiter(cfg.RateLimits))
+		p.rateLimits = cfg.RateLimits
+		logger.V(logging.INFO).Info("Reloaded rate limits", "rateLimits", cfg.RateLimits)
+	}
+
+	if changed := features.Set(cfg.Features); len(changed) > 0 {
+		logger.V(logging.INFO).Info("Reloaded feature flags", "changed", changed, "features", features.States())
+	}
+
+	// the startup configuration with the reloaded settings, to find the other changes
+	reloaded := *p.cfg
+	reloaded.NumWorkers, reloaded.PollInterval, reloaded.RateLimits = cfg.NumWorkers, cfg.PollInterval, cfg.RateLimits
+	reloaded.Features = cfg.Features
+	if !reflect.DeepEqual(&reloaded, cfg) {
+		logger.V(logging.WARNING).Info("Config changes other than num_workers, poll_interval, rate_limits and features take effect on restart")
+	}
+}
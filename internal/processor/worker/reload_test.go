@@ -0,0 +1,71 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the application of configuration changes at runtime.
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/features"
+)
+
+func TestReload(t *testing.T) {
+	ctx := context.Background()
+	env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+	p := env.processor
+
+	cfg := *p.cfg
+	cfg.NumWorkers = p.cfg.NumWorkers + 5
+	cfg.PollInterval = time.Second
+	cfg.RateLimits = []config.RateLimitConfig{{Model: "m1", RequestsPerSecond: 5}}
+	p.Reload(ctx, &cfg)
+
+	if _, limit := p.workerPool.Stats(); limit != cfg.NumWorkers || p.workerPool.Size() != cfg.NumWorkers {
+		t.Errorf("limit = %v, size = %v, want %v", limit, p.workerPool.Size(), cfg.NumWorkers)
+	}
+	if got := time.Duration(p.pollInterval.Load()); got != time.Second {
+		t.Errorf("poll interval = %v, want 1s", got)
+	}
+	limiter := p.rateLimiter.Load()
+	if limiter == nil || limiter.limits["m1"].RequestsPerSecond != 5 {
+		t.Fatalf("rate limits not reloaded: %+v", limiter)
+	}
+
+	// unchanged rate limits keep their buckets
+	p.Reload(ctx, &cfg)
+	if p.rateLimiter.Load() != limiter {
+		t.Errorf("rate limiter replaced although its limits didn't change")
+	}
+
+	// removing the limits disables rate limiting
+	cfg.RateLimits = nil
+	p.Reload(ctx, &cfg)
+	if limiter := p.rateLimiter.Load(); limiter != nil {
+		t.Errorf("rate limiter = %+v, want none", limiter)
+	}
+
+	// feature flags
+	defer features.Set(nil)
+	cfg.Features = features.Config{features.ShardedOutputs: false}
+	p.Reload(ctx, &cfg)
+	if features.Enabled(features.ShardedOutputs) {
+		t.Errorf("sharded_outputs still enabled after reload")
+	}
+}
@@ -0,0 +1,495 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the writers of the output and error files of a job.
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/tracing"
+)
+
+// resultShard is a local temporary file holding a part of a result file.
+type resultShard struct {
+	file  *os.File
+	lines int64
+	bytes int64
+}
+
+// resultWriter buffers the lines of an output or error file in local temporary files
+// until the job is finalized and the files are uploaded to the files store.
+// A new shard is started whenever the current one reaches maxLines or maxBytes (zero means no limit).
+type resultWriter struct {
+	mu       sync.Mutex
+	pattern  string
+	maxLines int64
+	maxBytes int64
+	shards   []*resultShard
+}
+
+func newResultWriter(pattern string, maxLines, maxBytes int64) *resultWriter {
+	return &resultWriter{
+		pattern:  pattern,
+		maxLines: maxLines,
+		maxBytes: maxBytes,
+	}
+}
+
+func (w *resultWriter) write(line *openai.BatchRequestOutput) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	return w.writeLine(append(data, '\n'))
+}
+
+// writeLine writes an encoded line, including its trailing newline.
+func (w *resultWriter) writeLine(data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	shard, err := w.currentShard(int64(len(data)))
+	if err != nil {
+		return err
+	}
+	if _, err := shard.file.Write(data); err != nil {
+		return err
+	}
+	shard.lines++
+	shard.bytes += int64(len(data))
+	return nil
+}
+
+// currentShard returns the shard that the next line of size n should be written to.
+func (w *resultWriter) currentShard(n int64) (*resultShard, error) {
+	if len(w.shards) > 0 {
+		shard := w.shards[len(w.shards)-1]
+		full := (w.maxLines > 0 && shard.lines >= w.maxLines) ||
+			(w.maxBytes > 0 && shard.lines > 0 && shard.bytes+n > w.maxBytes)
+		if !full {
+			return shard, nil
+		}
+	}
+	f, err := os.CreateTemp("", w.pattern)
+	if err != nil {
+		return nil, err
+	}
+	shard := &resultShard{file: f}
+	w.shards = append(w.shards, shard)
+	return shard, nil
+}
+
+func (w *resultWriter) numLines() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var lines int64
+	for _, shard := range w.shards {
+		lines += shard.lines
+	}
+	return lines
+}
+
+func (w *resultWriter) close() {
+	for _, shard := range w.shards {
+		shard.file.Close()
+		os.Remove(shard.file.Name())
+	}
+}
+
+// jobResults holds the output and error files of a job.
+// Result lines are keyed by (batch ID, custom_id): a request has at most one line in the output and error
+// files together, whose ID is derived from the key, so a request delivered more than once never produces
+// duplicate lines. A successful response supersedes an earlier error line of the same request.
+type jobResults struct {
+	batchID string
+	output  *resultWriter
+	errors  *resultWriter
+
+	mu sync.Mutex
+	// outcomes records, by custom_id, whether a result line was written to the output file (true)
+	// or to the error file (false)
+	outcomes map[string]bool
+	// superseded is set when the error file holds lines of requests that later succeeded
+	superseded bool
+	// usage is the token usage of the successful requests, delivered the usage of those sent by this delivery
+	// of the job, as opposed to restored from an earlier one
+	usage     openai.BatchUsage
+	delivered openai.BatchUsage
+	// deliveredSucceeded and deliveredFailed count the result lines written by this delivery of the job
+	deliveredSucceeded int64
+	deliveredFailed    int64
+}
+
+func newJobResults(batchID string, maxLines, maxBytes int64) *jobResults {
+	return &jobResults{
+		batchID:  batchID,
+		output:   newResultWriter("batch-output-*.jsonl", maxLines, maxBytes),
+		errors:   newResultWriter("batch-errors-*.jsonl", maxLines, maxBytes),
+		outcomes: map[string]bool{},
+	}
+}
+
+func (r *jobResults) writeResponse(customID string, resp *openai.BatchRequestResponse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	succeeded, seen := r.outcomes[customID]
+	if succeeded {
+		return nil
+	}
+	if err := r.output.write(&openai.BatchRequestOutput{
+		ID:       r.lineID(customID),
+		CustomID: customID,
+		Response: resp,
+	}); err != nil {
+		return err
+	}
+	r.record(customID, true)
+	r.superseded = r.superseded || seen
+	r.deliveredSucceeded++
+	if usage, ok := responseUsage(resp.Body); ok {
+		addUsage(&r.usage, usage)
+		addUsage(&r.delivered, usage)
+	}
+	return nil
+}
+
+// writeFailedResponse writes a request that failed with an HTTP error response to the error file.
+func (r *jobResults) writeFailedResponse(customID string, resp *openai.BatchRequestResponse) error {
+	return r.writeErrorLine(&openai.BatchRequestOutput{
+		CustomID: customID,
+		Response: resp,
+	})
+}
+
+// writeError writes a request that failed before a response was received to the error file.
+func (r *jobResults) writeError(customID, code, message string) error {
+	return r.writeErrorLine(&openai.BatchRequestOutput{
+		CustomID: customID,
+		Error: &openai.BatchRequestError{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
+
+// writeErrorLine writes line to the error file, unless the request already has a result line.
+func (r *jobResults) writeErrorLine(line *openai.BatchRequestOutput) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, seen := r.outcomes[line.CustomID]; seen {
+		return nil
+	}
+	line.ID = r.lineID(line.CustomID)
+	if err := r.errors.write(line); err != nil {
+		return err
+	}
+	r.record(line.CustomID, false)
+	r.deliveredFailed++
+	return nil
+}
+
+// restore writes the result lines of an output (succeeded) or error file stored on an earlier delivery of the job.
+func (r *jobResults) restore(reader io.Reader, succeeded bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w := r.errors
+	if succeeded {
+		w = r.output
+	}
+	br := bufio.NewReader(reader)
+	for {
+		data, readErr := br.ReadBytes('\n')
+		if len(data) > 0 {
+			line := &openai.BatchRequestOutput{}
+			if err := json.Unmarshal(data, line); err != nil {
+				return err
+			}
+			if err := w.writeLine(data); err != nil {
+				return err
+			}
+			r.record(line.CustomID, succeeded)
+			if succeeded && line.Response != nil {
+				if usage, ok := responseUsage(line.Response.Body); ok {
+					addUsage(&r.usage, usage)
+				}
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// totalUsage returns the token usage of the successful requests of the job.
+func (r *jobResults) totalUsage() *openai.BatchUsage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	usage := r.usage
+	return &usage
+}
+
+// deliveredUsage returns the token usage of the successful requests sent by this delivery of the job.
+func (r *jobResults) deliveredUsage() openai.BatchUsage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.delivered
+}
+
+// deliveredRequests returns the number of requests that succeeded and failed during this delivery of the job.
+func (r *jobResults) deliveredRequests() (succeeded, failed int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.deliveredSucceeded, r.deliveredFailed
+}
+
+// hasResult reports whether the request already has a result line.
+func (r *jobResults) hasResult(customID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, seen := r.outcomes[customID]
+	return seen
+}
+
+// record remembers the outcome of a request. Lines without a custom_id (e.g. invalid lines) can't be keyed.
+func (r *jobResults) record(customID string, succeeded bool) {
+	if customID != "" {
+		r.outcomes[customID] = succeeded
+	}
+}
+
+// lineID returns the ID of the result line of a request, which is stable across deliveries of the batch.
+func (r *jobResults) lineID(customID string) string {
+	if customID == "" {
+		return newRequestID()
+	}
+	return fmt.Sprintf("batch_req_%s", uuid.NewSHA1(uuid.NameSpaceURL, []byte(r.batchID+"/"+customID)))
+}
+
+// finalize removes the error lines of requests that later succeeded, so every request has a single result line.
+// It must be called once all lines are processed, before the result files are stored.
+func (r *jobResults) finalize() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.superseded {
+		return nil
+	}
+
+	kept := newResultWriter(r.errors.pattern, r.errors.maxLines, r.errors.maxBytes)
+	for _, shard := range r.errors.shards {
+		if err := r.copyErrorLines(kept, shard); err != nil {
+			kept.close()
+			return err
+		}
+	}
+	r.errors.close()
+	r.errors = kept
+	r.superseded = false
+	return nil
+}
+
+// copyErrorLines copies the lines of shard whose request didn't succeed to w.
+func (r *jobResults) copyErrorLines(w *resultWriter, shard *resultShard) error {
+	if _, err := shard.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	br := bufio.NewReader(shard.file)
+	for {
+		data, readErr := br.ReadBytes('\n')
+		if len(data) > 0 {
+			line := &openai.BatchRequestOutput{}
+			if err := json.Unmarshal(data, line); err != nil {
+				return err
+			}
+			if !r.outcomes[line.CustomID] {
+				if err := w.writeLine(data); err != nil {
+					return err
+				}
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+func (r *jobResults) close() {
+	r.output.close()
+	r.errors.close()
+}
+
+func newRequestID() string {
+	return fmt.Sprintf("batch_req_%s", uuid.NewString())
+}
+
+// storeResultFiles uploads the shards of a result file to the files store, encoded in the output format.
+// A single shard is named <name>.<ext>, multiple shards are named <name>_<index>.<ext>.
+func (p *Processor) storeResultFiles(
+	ctx context.Context, tenantID string, w *resultWriter, name string, format openai.OutputFormat, ttl int,
+) ([]openai.BatchOutputShard, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	shards := make([]openai.BatchOutputShard, 0, len(w.shards))
+	for i, shard := range w.shards {
+		if _, err := shard.file.Seek(0, io.SeekStart); err != nil {
+			return shards, err
+		}
+		filename := name + format.Extension()
+		if len(w.shards) > 1 {
+			filename = fmt.Sprintf("%s_%05d%s", name, i+1, format.Extension())
+		}
+		fileID, size, err := p.storeResultFile(ctx, tenantID, shard.file, filename, format, ttl)
+		if err != nil {
+			return shards, err
+		}
+		shards = append(shards, openai.BatchOutputShard{
+			FileID: fileID,
+			Index:  i,
+			Lines:  shard.lines,
+			Bytes:  size,
+		})
+	}
+	return shards, nil
+}
+
+// storeResultFile uploads a JSONL result file encoded in the output format.
+func (p *Processor) storeResultFile(
+	ctx context.Context, tenantID string, reader io.Reader, filename string, format openai.OutputFormat, ttl int,
+) (string, int64, error) {
+	if format == "" || format == openai.OutputFormatJSONL {
+		return p.storeFile(ctx, tenantID, reader, filename, format.ContentType(), ttl)
+	}
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(encodeResults(pw, reader, format))
+	}()
+	fileID, size, err := p.storeFile(ctx, tenantID, pr, filename, format.ContentType(), ttl)
+	// unblocks the encoding if the store failed before reading the whole file
+	pr.CloseWithError(io.ErrClosedPipe)
+	<-done
+	return fileID, size, err
+}
+
+// storeResults uploads the output and error files of a job, and the manifest listing their shards when a file
+// has more than one shard; manifestFileID is empty otherwise.
+func (p *Processor) storeResults(ctx context.Context, jobID, tenantID string, results *jobResults, format openai.OutputFormat, ttl int) (
+	outputShards, errorShards []openai.BatchOutputShard, manifestFileID string, err error,
+) {
+	ctx, span := tracing.StartSpan(ctx, "upload_output")
+	defer func() {
+		span.SetAttribute("output_shards", len(outputShards))
+		span.SetAttribute("error_shards", len(errorShards))
+		span.End(err)
+	}()
+
+	if outputShards, err = p.storeResultFiles(ctx, tenantID, results.output, jobID+"_output", format, ttl); err != nil {
+		return outputShards, nil, "", err
+	}
+	if errorShards, err = p.storeResultFiles(ctx, tenantID, results.errors, jobID+"_error", format, ttl); err != nil {
+		return outputShards, errorShards, "", err
+	}
+	if len(outputShards) > 1 || len(errorShards) > 1 {
+		manifestFileID, err = p.storeManifest(ctx, tenantID, &openai.BatchOutputManifest{
+			Object:  openai.BatchOutputManifestObject,
+			BatchID: jobID,
+			Output:  outputShards,
+			Errors:  errorShards,
+		}, ttl)
+	}
+	return outputShards, errorShards, manifestFileID, err
+}
+
+func shardFileIDs(shards []openai.BatchOutputShard) []string {
+	ids := make([]string, len(shards))
+	for i, shard := range shards {
+		ids[i] = shard.FileID
+	}
+	return ids
+}
+
+// storeManifest uploads the manifest listing the shards of the output and error files.
+func (p *Processor) storeManifest(ctx context.Context, tenantID string, manifest *openai.BatchOutputManifest, ttl int) (string, error) {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	fileID, _, err := p.storeFile(ctx, tenantID, bytes.NewReader(data), manifest.BatchID+"_manifest.json", openai.OutputFormatJSON.ContentType(), ttl)
+	return fileID, err
+}
+
+// storeFile uploads a file of a tenant to the files store and registers its metadata, so it can be
+// retrieved through the files API.
+func (p *Processor) storeFile(
+	ctx context.Context, tenantID string, reader io.Reader, filename, contentType string, ttl int,
+) (string, int64, error) {
+	fileID := fmt.Sprintf("file_%s", uuid.NewString())
+	location := batch.FileLocation(tenantID, fileID)
+	md, err := p.clients.files.Store(ctx, location, 0, reader)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to store %s: %w", filename, err)
+	}
+
+	fileObj := openai.FileObject{
+		ID:        fileID,
+		Bytes:     md.Size,
+		CreatedAt: time.Now().UTC().Unix(),
+		Filename:  filename,
+		Object:    "file",
+		Purpose:   openai.FileObjectPurposeBatchOutput,
+		Status:    openai.FileObjectStatusProcessed,
+
+		ContentType: contentType,
+	}
+	spec, err := json.Marshal(fileObj)
+	if err != nil {
+		return "", 0, err
+	}
+	if _, err := p.clients.fileDatabase.Store(ctx, &db.BatchFile{
+		ID:   fileID,
+		TTL:  ttl,
+		Spec: spec,
+		Tags: []string{batch.TenantTag(tenantID)},
+	}); err != nil {
+		p.clients.files.Delete(ctx, location)
+		return "", 0, fmt.Errorf("failed to store metadata of %s: %w", filename, err)
+	}
+	return fileID, md.Size, nil
+}
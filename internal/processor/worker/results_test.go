@@ -0,0 +1,117 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the result writers.
+package worker
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+func readResultLines(t *testing.T, w *resultWriter) []openai.BatchRequestOutput {
+	t.Helper()
+	var lines []openai.BatchRequestOutput
+	for _, shard := range w.shards {
+		if _, err := shard.file.Seek(0, io.SeekStart); err != nil {
+			t.Fatalf("failed to seek shard: %v", err)
+		}
+		scanner := bufio.NewScanner(shard.file)
+		for scanner.Scan() {
+			var line openai.BatchRequestOutput
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("failed to decode result line: %v", err)
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestJobResults(t *testing.T) {
+	resp := &openai.BatchRequestResponse{StatusCode: 200, Body: json.RawMessage(`{}`)}
+
+	t.Run("DuplicateDeliveries", func(t *testing.T) {
+		results := newJobResults("batch-1", 0, 0)
+		defer results.close()
+
+		for range 2 {
+			results.writeResponse("ok", resp)
+			results.writeError("failed", openai.BatchRequestErrorInvalidLine, "bad line")
+		}
+		// an error after a success is dropped
+		results.writeError("ok", openai.BatchRequestErrorExpired, "expired")
+		if err := results.finalize(); err != nil {
+			t.Fatalf("finalize() error = %v", err)
+		}
+
+		output := readResultLines(t, results.output)
+		if len(output) != 1 || output[0].CustomID != "ok" {
+			t.Errorf("output lines = %+v, want a single line of ok", output)
+		}
+		errors := readResultLines(t, results.errors)
+		if len(errors) != 1 || errors[0].CustomID != "failed" {
+			t.Errorf("error lines = %+v, want a single line of failed", errors)
+		}
+	})
+
+	t.Run("SuccessSupersedesError", func(t *testing.T) {
+		results := newJobResults("batch-1", 1, 0)
+		defer results.close()
+
+		results.writeError("a", openai.BatchRequestErrorInvalidLine, "bad line")
+		results.writeFailedResponse("b", &openai.BatchRequestResponse{StatusCode: 500})
+		results.writeError("c", openai.BatchRequestErrorInvalidLine, "bad line")
+		results.writeResponse("b", resp)
+		if err := results.finalize(); err != nil {
+			t.Fatalf("finalize() error = %v", err)
+		}
+
+		errors := readResultLines(t, results.errors)
+		if len(errors) != 2 || errors[0].CustomID != "a" || errors[1].CustomID != "c" {
+			t.Errorf("error lines = %+v, want lines of a and c", errors)
+		}
+		if got := results.errors.numLines(); got != 2 {
+			t.Errorf("numLines() = %d, want 2", got)
+		}
+		if got := len(results.output.shards); got != 1 {
+			t.Errorf("output shards = %d, want 1", got)
+		}
+	})
+
+	t.Run("StableLineIDs", func(t *testing.T) {
+		first := newJobResults("batch-1", 0, 0)
+		defer first.close()
+		second := newJobResults("batch-1", 0, 0)
+		defer second.close()
+		other := newJobResults("batch-2", 0, 0)
+		defer other.close()
+
+		if first.lineID("r1") != second.lineID("r1") {
+			t.Errorf("lineID() differs across deliveries of the same batch")
+		}
+		if first.lineID("r1") == first.lineID("r2") || first.lineID("r1") == other.lineID("r1") {
+			t.Errorf("lineID() collides for different requests")
+		}
+		if first.lineID("") == first.lineID("") {
+			t.Errorf("lineID() of lines without custom_id should be unique")
+		}
+	})
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the retry policy of the inference requests of a job.
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// retryCategories maps the retry_on values of a batch retry policy to the error categories they retry.
+var retryCategories = map[string]batch.ErrorCategory{
+	openai.RetryOnRateLimit:   batch.ErrCategoryRateLimit,
+	openai.RetryOnServerError: batch.ErrCategoryServer,
+}
+
+// retryPolicy is how the requests of a job failing with a retryable error are retried.
+type retryPolicy struct {
+	maxAttempts    int
+	retryOn        map[batch.ErrorCategory]bool
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// newRetryPolicy returns the processor's retry policy, with the fields set in the retry settings of the gateway
+// and then in the batch's policy overridden.
+func (p *Processor) newRetryPolicy(gateway *inferenceGateway, override *openai.RetryPolicy) *retryPolicy {
+	rp := &retryPolicy{
+		maxAttempts: p.cfg.RetryMaxAttempts,
+		retryOn: map[batch.ErrorCategory]bool{
+			batch.ErrCategoryRateLimit: true,
+			batch.ErrCategoryServer:    true,
+		},
+		initialBackoff: p.cfg.RetryInitialBackoff,
+		maxBackoff:     p.cfg.RetryMaxBackoff,
+	}
+	if gateway.retryMaxAttempts > 0 {
+		rp.maxAttempts = gateway.retryMaxAttempts
+	}
+	if gateway.retryInitialBackoff > 0 {
+		rp.initialBackoff = gateway.retryInitialBackoff
+	}
+	if gateway.retryMaxBackoff > 0 {
+		rp.maxBackoff = gateway.retryMaxBackoff
+	}
+	if override == nil {
+		return rp
+	}
+	if override.MaxAttempts > 0 {
+		rp.maxAttempts = override.MaxAttempts
+	}
+	if len(override.RetryOn) > 0 {
+		rp.retryOn = map[batch.ErrorCategory]bool{}
+		for _, category := range override.RetryOn {
+			rp.retryOn[retryCategories[category]] = true
+		}
+	}
+	if override.MaxBackoffSeconds > 0 {
+		rp.maxBackoff = time.Duration(override.MaxBackoffSeconds) * time.Second
+		rp.initialBackoff = min(rp.initialBackoff, rp.maxBackoff)
+	}
+	return rp
+}
+
+// retries reports whether a request whose attempt failed with err is attempted again.
+func (rp *retryPolicy) retries(attempt int, err *batch.InferenceError) bool {
+	return attempt < rp.maxAttempts && err.IsRetryable() && rp.retryOn[err.Category]
+}
+
+// backoff returns the delay before the attempt following the given one.
+func (rp *retryPolicy) backoff(attempt int) time.Duration {
+	backoff := rp.initialBackoff
+	for i := 1; i < attempt && backoff < rp.maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, rp.maxBackoff)
+}
+
+// sleep waits for d, returning false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the retry policy of inference requests.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// flakyInferenceClient fails the first attempts of every request with err.
+type flakyInferenceClient struct {
+	failures int
+	err      *batch.InferenceError
+
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+func (c *flakyInferenceClient) Generate(ctx context.Context, req *batch.InferenceRequest) (*batch.InferenceResponse, *batch.InferenceError) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.attempts == nil {
+		c.attempts = map[string]int{}
+	}
+	c.attempts[req.RequestID]++
+	if c.attempts[req.RequestID] <= c.failures {
+		return nil, c.err
+	}
+	return &batch.InferenceResponse{RequestID: "req-" + req.RequestID, Response: []byte(`{}`)}, nil
+}
+
+func TestRetryPolicy(t *testing.T) {
+	env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+	p := env.processor
+	rateLimited := &batch.InferenceError{Category: batch.ErrCategoryRateLimit}
+	serverErr := &batch.InferenceError{Category: batch.ErrCategoryServer}
+	invalid := &batch.InferenceError{Category: batch.ErrCategoryInvalidReq}
+
+	t.Run("Defaults", func(t *testing.T) {
+		rp := p.newRetryPolicy(p.gateways.fallback, nil)
+		if !rp.retries(1, rateLimited) || !rp.retries(2, serverErr) {
+			t.Errorf("retryable errors are not retried")
+		}
+		if rp.retries(3, serverErr) {
+			t.Errorf("retried after %d attempts", p.cfg.RetryMaxAttempts)
+		}
+		if rp.retries(1, invalid) {
+			t.Errorf("non retryable error is retried")
+		}
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		rp := p.newRetryPolicy(p.gateways.fallback, &openai.RetryPolicy{
+			MaxAttempts:       5,
+			RetryOn:           []string{openai.RetryOnRateLimit},
+			MaxBackoffSeconds: 3,
+		})
+		if !rp.retries(4, rateLimited) {
+			t.Errorf("rate limited request is not retried within max_attempts")
+		}
+		if rp.retries(1, serverErr) {
+			t.Errorf("server error is retried although not in retry_on")
+		}
+		for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 3 * time.Second, 10: 3 * time.Second} {
+			if got := rp.backoff(attempt); got != want {
+				t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+			}
+		}
+	})
+
+	t.Run("GatewaySettings", func(t *testing.T) {
+		gateway := &inferenceGateway{retryMaxAttempts: 5, retryMaxBackoff: 2 * time.Second}
+		rp := p.newRetryPolicy(gateway, nil)
+		if !rp.retries(4, serverErr) || rp.retries(5, serverErr) {
+			t.Errorf("requests to the gateway are not retried up to its retry_max_attempts")
+		}
+		if got := rp.backoff(1); got != p.cfg.RetryInitialBackoff {
+			t.Errorf("backoff(1) = %v, want the processor's %v", got, p.cfg.RetryInitialBackoff)
+		}
+		if got := rp.backoff(5); got != 2*time.Second {
+			t.Errorf("backoff(5) = %v, want the gateway's 2s", got)
+		}
+
+		// the batch's policy overrides the gateway's settings
+		if rp := p.newRetryPolicy(gateway, &openai.RetryPolicy{MaxAttempts: 2}); rp.retries(2, serverErr) {
+			t.Errorf("batch retry policy doesn't override the gateway's retry_max_attempts")
+		}
+	})
+
+	t.Run("BackoffCapBelowInitial", func(t *testing.T) {
+		p.cfg.RetryInitialBackoff = 5 * time.Second
+		defer func() { p.cfg.RetryInitialBackoff = time.Second }()
+		rp := p.newRetryPolicy(p.gateways.fallback, &openai.RetryPolicy{MaxBackoffSeconds: 2})
+		if got := rp.backoff(1); got != 2*time.Second {
+			t.Errorf("backoff(1) = %v, want 2s", got)
+		}
+	})
+}
+
+func TestProcessJobRetries(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           *batch.InferenceError
+		policy        *openai.RetryPolicy
+		wantCompleted int64
+		wantAttempts  int
+	}{
+		{name: "retried until success", err: &batch.InferenceError{Category: batch.ErrCategoryServer}, wantCompleted: 1, wantAttempts: 3},
+		{name: "retries disabled by the batch", err: &batch.InferenceError{Category: batch.ErrCategoryServer},
+			policy: &openai.RetryPolicy{MaxAttempts: 1}, wantCompleted: 0, wantAttempts: 1},
+		{name: "category not retried by the batch", err: &batch.InferenceError{Category: batch.ErrCategoryServer},
+			policy: &openai.RetryPolicy{RetryOn: []string{openai.RetryOnRateLimit}}, wantCompleted: 0, wantAttempts: 1},
+		{name: "not retryable", err: &batch.InferenceError{Category: batch.ErrCategoryInvalidReq}, wantCompleted: 0, wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inference := &flakyInferenceClient{failures: 2, err: tt.err}
+			env := setupProcessorForTest(t, 1, inference)
+			env.processor.cfg.RetryInitialBackoff = time.Millisecond
+			job := env.storeJob(t, "batch-1", time.Now().Add(time.Hour), "m1")
+			spec := &openai.BatchSpec{}
+			json.Unmarshal(job.Spec, spec)
+			spec.RetryPolicy = tt.policy
+			job.Spec, _ = json.Marshal(spec)
+
+			env.processor.processJob(context.Background(), 1, job)
+
+			status := env.getStatus(t, job.ID)
+			if status.RequestCounts.Completed != tt.wantCompleted {
+				t.Errorf("Completed = %d, want %d", status.RequestCounts.Completed, tt.wantCompleted)
+			}
+			if got := inference.attempts["req-0"]; got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
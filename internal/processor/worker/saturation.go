@@ -0,0 +1,130 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the saturation guard pausing dispatch to saturated inference endpoints.
+
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+)
+
+// saturationGuard pauses dispatch to an endpoint (a model served by the inference gateway) when it signals
+// saturation: a run of consecutive rate limited requests, or a request rejected with a Retry-After backoff.
+// Dispatch resumes automatically when the pause ends. Consecutive pauses double in length up to maxPause,
+// and a successful request resets the pause length.
+type saturationGuard struct {
+	threshold int
+	basePause time.Duration
+	maxPause  time.Duration
+
+	mu        sync.Mutex
+	endpoints map[string]*endpointState
+	now       func() time.Time
+}
+
+type endpointState struct {
+	rateLimited int // consecutive rate limited requests
+	pause       time.Duration
+	pausedUntil time.Time
+}
+
+// newSaturationGuard returns a guard pausing an endpoint after threshold consecutive rate limited requests,
+// or nil (never pause) if threshold is 0.
+func newSaturationGuard(threshold int, basePause, maxPause time.Duration) *saturationGuard {
+	if threshold <= 0 {
+		return nil
+	}
+	return &saturationGuard{
+		threshold: threshold,
+		basePause: basePause,
+		maxPause:  maxPause,
+		endpoints: make(map[string]*endpointState),
+		now:       time.Now,
+	}
+}
+
+// wait blocks while the endpoint is paused, returning false if ctx is done first.
+func (g *saturationGuard) wait(ctx context.Context, endpoint string) bool {
+	if g == nil {
+		return ctx.Err() == nil
+	}
+	for {
+		g.mu.Lock()
+		var remaining time.Duration
+		if state, ok := g.endpoints[endpoint]; ok {
+			remaining = state.pausedUntil.Sub(g.now())
+		}
+		g.mu.Unlock()
+		if remaining <= 0 {
+			return ctx.Err() == nil
+		}
+
+		timer := time.NewTimer(remaining)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
+// record updates the endpoint state with the outcome of a request, inferenceErr is nil on success.
+// Returns the pause started by the request, 0 if none.
+func (g *saturationGuard) record(endpoint string, inferenceErr *batch.InferenceError) time.Duration {
+	if g == nil {
+		return 0
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	state, ok := g.endpoints[endpoint]
+	if inferenceErr == nil || !inferenceErr.IsSaturated() {
+		if ok {
+			state.rateLimited = 0
+			if inferenceErr == nil {
+				state.pause = 0
+			}
+		}
+		return 0
+	}
+	if !ok {
+		state = &endpointState{}
+		g.endpoints[endpoint] = state
+	}
+	now := g.now()
+	if state.pausedUntil.After(now) {
+		// requests in flight when the pause started, the endpoint is already paused
+		return 0
+	}
+	state.rateLimited++
+	if state.rateLimited < g.threshold && inferenceErr.RetryAfter <= 0 {
+		return 0
+	}
+
+	state.pause = min(max(2*state.pause, g.basePause), g.maxPause)
+	pause := max(state.pause, inferenceErr.RetryAfter)
+	state.pausedUntil = now.Add(pause)
+	state.rateLimited = 0
+	metrics.RecordEndpointPause(endpoint)
+	return pause
+}
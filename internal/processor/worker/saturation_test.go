@@ -0,0 +1,114 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the saturation guard.
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+)
+
+func TestSaturationGuard(t *testing.T) {
+	rateLimited := &batch.InferenceError{Category: batch.ErrCategoryRateLimit}
+	serverErr := &batch.InferenceError{Category: batch.ErrCategoryServer}
+
+	t.Run("PauseAfterConsecutiveRateLimits", func(t *testing.T) {
+		g := newSaturationGuard(3, time.Second, 4*time.Second)
+		now := time.Now()
+		g.now = func() time.Time { return now }
+
+		g.record("m1", rateLimited)
+		g.record("m1", serverErr) // not consecutive
+		g.record("m1", rateLimited)
+		if pause := g.record("m1", rateLimited); pause != 0 {
+			t.Fatalf("pause after 2 consecutive rate limits = %v, want 0", pause)
+		}
+		if pause := g.record("m1", rateLimited); pause != time.Second {
+			t.Fatalf("pause = %v, want %v", pause, time.Second)
+		}
+		// in flight requests failing during the pause don't extend it
+		if pause := g.record("m1", rateLimited); pause != 0 {
+			t.Errorf("pause while paused = %v, want 0", pause)
+		}
+
+		// the pause doubles while the model stays saturated, up to the max
+		for _, want := range []time.Duration{2 * time.Second, 4 * time.Second, 4 * time.Second} {
+			now = now.Add(time.Minute)
+			var pause time.Duration
+			for i := 0; i < 3; i++ {
+				pause = g.record("m1", rateLimited)
+			}
+			if pause != want {
+				t.Errorf("pause = %v, want %v", pause, want)
+			}
+		}
+
+		// a success resets the pause length
+		now = now.Add(time.Minute)
+		g.record("m1", nil)
+		for i := 0; i < 3; i++ {
+			g.record("m1", rateLimited)
+		}
+		if state := g.endpoints["m1"]; state.pause != time.Second {
+			t.Errorf("pause after success = %v, want %v", state.pause, time.Second)
+		}
+	})
+
+	t.Run("RetryAfter", func(t *testing.T) {
+		g := newSaturationGuard(3, time.Second, time.Minute)
+		pause := g.record("m1", &batch.InferenceError{Category: batch.ErrCategoryRateLimit, RetryAfter: 5 * time.Second})
+		if pause != 5*time.Second {
+			t.Errorf("pause = %v, want %v", pause, 5*time.Second)
+		}
+	})
+
+	t.Run("WaitWhilePaused", func(t *testing.T) {
+		g := newSaturationGuard(1, 50*time.Millisecond, time.Second)
+		g.record("m1", rateLimited)
+
+		start := time.Now()
+		if !g.wait(context.Background(), "m1") {
+			t.Fatalf("wait() = false, want true")
+		}
+		if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+			t.Errorf("wait() returned after %v, want the pause to end", elapsed)
+		}
+		if !g.wait(context.Background(), "m2") {
+			t.Errorf("wait() for another model = false, want true")
+		}
+
+		g.record("m1", rateLimited)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if g.wait(ctx, "m1") {
+			t.Errorf("wait() with cancelled context = true, want false")
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		g := newSaturationGuard(0, time.Second, time.Minute)
+		if pause := g.record("m1", rateLimited); pause != 0 {
+			t.Errorf("pause = %v, want 0", pause)
+		}
+		if !g.wait(context.Background(), "m1") {
+			t.Errorf("wait() = false, want true")
+		}
+	})
+}
@@ -0,0 +1,404 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the processing of large jobs in shards, possibly by several processors.
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+const (
+	// shardPlanKeyPrefix prefixes the job ID in the status store key of the shards a job was split into.
+	shardPlanKeyPrefix = "shards:"
+
+	// shardResultKeyPrefix prefixes the shard task ID in the status store key of the result of a processed shard.
+	shardResultKeyPrefix = "shard:"
+
+	// shardLocationPrefix is the files store location under which the results of processed shards are kept.
+	shardLocationPrefix = "shards/"
+)
+
+// shardPlan records the shards a job was split into.
+type shardPlan struct {
+	Shards int   `json:"shards"`
+	Lines  int64 `json:"lines"`
+}
+
+// abortError is the abort of a job by one of its shards, recorded when the shards are merged.
+type abortError string
+
+func (e abortError) Error() string { return string(e) }
+
+func (e abortError) Is(target error) bool { return target == errTooManyFailures }
+
+func shardTaskID(jobID string, index int) string {
+	return fmt.Sprintf("%s_shard_%05d", jobID, index)
+}
+
+func shardPlanKey(jobID string) string {
+	return shardPlanKeyPrefix + jobID
+}
+
+func shardResultKey(jobID string, index int) string {
+	return shardResultKeyPrefix + shardTaskID(jobID, index)
+}
+
+// shardJob splits a job with more than ShardLines input lines into shards queued as tasks of their own, and
+// returns true if the lines of the job are processed by its shards. The results of each shard are stored like
+// the checkpoint of a drained job; once all the shards are processed, the job is queued again and its delivery
+// merges their results into its checkpoint, so processJob finalizes the job from it, processing the lines the
+// shards left without result.
+// It returns false if the job isn't sharded or its shards are merged, for processJob to process the job.
+func (p *Processor) shardJob(ctx context.Context, task *db.BatchJobPriority, job *db.BatchJob) bool {
+	if p.cfg.ShardLines <= 0 {
+		return false
+	}
+	logger := klog.FromContext(ctx).WithValues("jobID", job.ID)
+
+	// a job resuming from its checkpoint is past its split
+	if checkpoint, err := p.loadCheckpoint(ctx, job.ID); err != nil || checkpoint != nil {
+		return false
+	}
+	plan, err := p.loadShardPlan(ctx, job.ID)
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to load shard plan")
+		return false
+	}
+	if plan != nil {
+		if err := p.mergeShards(ctx, job.ID, plan); err != nil {
+			// another delivery of the job, the last shard to be processed queues the job again
+			logger.V(logging.DEBUG).Info("Shards of job not merged", "reason", err.Error())
+			return true
+		}
+		logger.V(logging.INFO).Info("Merged shards of job", "shards", plan.Shards)
+		return false
+	}
+
+	spec, statusInfo, err := decodeJob(job)
+	if err != nil || statusInfo.Status.IsFinal() {
+		return false
+	}
+	lines, err := p.countLines(ctx, batch.FileLocation(spec.TenantID, spec.InputFileID))
+	if err != nil || lines <= p.cfg.ShardLines {
+		return false
+	}
+
+	// the shards are queued before the plan is stored: a job redelivered before its plan is stored is split again,
+	// and its shards processed again, rather than never merged
+	plan = &shardPlan{Shards: int((lines + p.cfg.ShardLines - 1) / p.cfg.ShardLines), Lines: lines}
+	queue := p.queues.client(task.Queue)
+	for i := 0; i < plan.Shards; i++ {
+		firstLine := int64(i) * p.cfg.ShardLines
+		shardTask := &db.BatchJobPriority{
+			ID:           shardTaskID(job.ID, i),
+			SLO:          task.SLO,
+			Priority:     task.Priority,
+			TraceContext: task.TraceContext,
+			Queue:        task.Queue,
+			Shard: &db.BatchJobShard{
+				JobID:     job.ID,
+				Index:     i,
+				FirstLine: firstLine,
+				Lines:     min(p.cfg.ShardLines, lines-firstLine),
+			},
+		}
+		if err := queue.Enqueue(ctx, shardTask); err != nil {
+			// the queued shards are processed, but their results are not merged
+			logger.V(logging.ERROR).Error(err, "Failed to queue shard, processing the job unsharded", "shard", i)
+			return false
+		}
+	}
+	data, err := json.Marshal(plan)
+	if err == nil {
+		err = p.clients.status.Set(ctx, shardPlanKey(job.ID), jobStatusTTL, data)
+	}
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to store shard plan, processing the job unsharded")
+		return false
+	}
+
+	now := time.Now().UTC().Unix()
+	statusInfo.Status = openai.BatchStatusInProgress
+	statusInfo.InProgressAt = &now
+	p.setJobStatus(ctx, job, statusInfo)
+	logger.V(logging.INFO).Info("Split job into shards", "lines", lines, "shards", plan.Shards)
+
+	// the shards may all have been processed before the plan was stored, they are then merged right away
+	return p.mergeShards(ctx, job.ID, plan) != nil
+}
+
+// processShard processes the lines of a shard of a job and stores their results for the merge of the shards.
+// If the processor shuts down first, the results are discarded and drained is true: the shard must be put back
+// to the queue, to be processed from its start.
+func (p *Processor) processShard(ctx context.Context, workerId int, task *db.BatchJobPriority, job *db.BatchJob) (drained bool) {
+	shard := task.Shard
+	logger := klog.FromContext(ctx).WithValues("jobID", job.ID, "shard", shard.Index, "workerID", workerId)
+	ctx = klog.NewContext(ctx, logger)
+
+	spec, statusInfo, err := decodeJob(job)
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to decode job")
+		return false
+	}
+	if statusInfo.Status.IsFinal() {
+		logger.V(logging.INFO).Info("Skipping shard of job in final status", "status", statusInfo.Status)
+		return false
+	}
+	if result, err := p.loadShardResult(ctx, job.ID, shard.Index); err == nil && result != nil {
+		// redelivered after its results were stored
+		p.completeShards(ctx, task, job.ID)
+		return false
+	}
+
+	results := newJobResults(job.ID, 0, 0)
+	defer results.close()
+	defer recordUsage(tenantLabel(spec), results)
+	progress := newJobProgress(0)
+	jobMetrics := newJobMetrics(spec, statusInfo)
+	defer func() {
+		jobMetrics.observe(statusInfo)
+	}()
+
+	// the lines are no longer dispatched once the batch is cancelled, or failed by an operator
+	stopctx, stop := context.WithCancelCause(ctx)
+	defer stop(nil)
+	if statusInfo.Status == openai.BatchStatusCancelling {
+		stop(errCancelled)
+	}
+	linectx, cancel := context.WithDeadline(stopctx, job.SLO)
+	defer cancel()
+	windowElapsed := func() bool {
+		return ctx.Err() == nil && errors.Is(context.Cause(linectx), context.DeadlineExceeded)
+	}
+	pause, stopEvents := p.watchEvents(ctx, job.ID, func() { stop(errCancelled) })
+	_, err = p.processLines(linectx, spec, results, progress, jobMetrics, windowElapsed, nil, shard, pause)
+	stopEvents()
+	if ctx.Err() != nil {
+		logger.V(logging.INFO).Info("Stopping shard processing, the lease of the shard was lost")
+		return false
+	}
+	if errors.Is(err, errDraining) {
+		logger.V(logging.INFO).Info("Drained shard, its results are discarded")
+		return true
+	}
+	checkpoint := &jobCheckpoint{}
+	switch {
+	case errors.Is(err, errTooManyFailures):
+		logger.V(logging.WARNING).Info("Aborting job", "reason", err.Error())
+		checkpoint.Aborted = err.Error()
+	case errors.Is(err, errCancelled):
+		// the job is finalized as cancelled when the shards are merged
+		logger.V(logging.INFO).Info("Stopped processing of shard of cancelled job")
+	case err != nil:
+		// the lines of the shard without result are processed when the shards are merged
+		logger.V(logging.ERROR).Error(err, "Failed to process lines of shard")
+	}
+	if err := p.saveShardResult(ctx, shard, results, progress.snapshot(), checkpoint); err != nil {
+		// the lease is kept, so the shard is reclaimed and processed again
+		logger.V(logging.ERROR).Error(err, "Failed to store shard results")
+		return false
+	}
+	logger.V(logging.DEBUG).Info("Processed shard", "metadata", progress.snapshot())
+	p.completeShards(ctx, task, job.ID)
+	return false
+}
+
+// abandonShard stores an empty result for a shard that could not be processed, e.g. it was delivered too many
+// times, so the shards of the job are merged and its lines processed with the lines the other shards left.
+func (p *Processor) abandonShard(ctx context.Context, task *db.BatchJobPriority, cause error) {
+	logger := klog.FromContext(ctx)
+	if err := p.saveShardResult(ctx, task.Shard, nil, batch.JobResultMetadata{}, &jobCheckpoint{}); err != nil {
+		logger.V(logging.ERROR).Error(err, "CRITICAL: Failed to store result of abandoned shard", "jobID", task.Shard.JobID)
+		return
+	}
+	logger.V(logging.WARNING).Info("Abandoned shard, its lines are processed when the shards are merged",
+		"jobID", task.Shard.JobID, "shard", task.Shard.Index, "cause", cause.Error())
+	p.completeShards(ctx, task, task.Shard.JobID)
+}
+
+// saveShardResult stores the results of a processed shard, the aborted reason of checkpoint is kept.
+// results is nil for a shard without results.
+func (p *Processor) saveShardResult(
+	ctx context.Context, shard *db.BatchJobShard, results *jobResults, metadata batch.JobResultMetadata, checkpoint *jobCheckpoint,
+) error {
+	checkpoint.Succeeded, checkpoint.Failed = metadata.Succeeded, metadata.Failed
+	if results != nil {
+		prefix := fmt.Sprintf("%s%s/%05d_", shardLocationPrefix, shard.JobID, shard.Index)
+		var err error
+		if checkpoint.OutputFiles, err = p.storeCheckpointFiles(ctx, results.output, prefix+"output"); err != nil {
+			return err
+		}
+		if checkpoint.ErrorFiles, err = p.storeCheckpointFiles(ctx, results.errors, prefix+"error"); err != nil {
+			p.deleteCheckpointFiles(ctx, checkpoint)
+			return err
+		}
+	}
+	data, err := json.Marshal(checkpoint)
+	if err == nil {
+		err = p.clients.status.Set(ctx, shardResultKey(shard.JobID, shard.Index), jobStatusTTL, data)
+	}
+	if err != nil {
+		p.deleteCheckpointFiles(ctx, checkpoint)
+		return fmt.Errorf("failed to store shard result: %w", err)
+	}
+	return nil
+}
+
+// completeShards queues the job of a shard again once the results of all its shards are stored.
+// A job queued more than once by shards processed at the same time is merged once, the other deliveries of the
+// job find it resuming from its checkpoint or in a final status.
+func (p *Processor) completeShards(ctx context.Context, task *db.BatchJobPriority, jobID string) {
+	logger := klog.FromContext(ctx)
+	plan, err := p.loadShardPlan(ctx, jobID)
+	if err != nil || plan == nil {
+		// the job merges its shards if they were processed before its plan was stored
+		return
+	}
+	if _, err := p.loadShardResults(ctx, jobID, plan); err != nil {
+		return
+	}
+	jobTask := &db.BatchJobPriority{
+		ID:           jobID,
+		SLO:          task.SLO,
+		Priority:     task.Priority,
+		TraceContext: task.TraceContext,
+		Queue:        task.Queue,
+	}
+	if err := p.queues.client(task.Queue).Enqueue(ctx, jobTask); err != nil {
+		logger.V(logging.ERROR).Error(err, "CRITICAL: Failed to queue job to merge its shards", "jobID", jobID)
+		return
+	}
+	logger.V(logging.INFO).Info("All shards of job processed, queued job to merge them", "jobID", jobID)
+}
+
+// mergeShards stores the results of all the shards of a job as the checkpoint of the job, and deletes the
+// shard results. It fails if the results of some shards are not stored yet.
+func (p *Processor) mergeShards(ctx context.Context, jobID string, plan *shardPlan) error {
+	shardResults, err := p.loadShardResults(ctx, jobID, plan)
+	if err != nil {
+		return err
+	}
+	checkpoint := &jobCheckpoint{Lines: plan.Lines}
+	for _, result := range shardResults {
+		checkpoint.Succeeded += result.Succeeded
+		checkpoint.Failed += result.Failed
+		checkpoint.OutputFiles = append(checkpoint.OutputFiles, result.OutputFiles...)
+		checkpoint.ErrorFiles = append(checkpoint.ErrorFiles, result.ErrorFiles...)
+		if checkpoint.Aborted == "" {
+			checkpoint.Aborted = result.Aborted
+		}
+	}
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	if err := p.clients.status.Set(ctx, checkpointKey(jobID), jobStatusTTL, data); err != nil {
+		return fmt.Errorf("failed to store checkpoint: %w", err)
+	}
+
+	// the shard files are deleted with the checkpoint of the job
+	logger := klog.FromContext(ctx)
+	for i := 0; i < plan.Shards; i++ {
+		if err := p.clients.status.Delete(ctx, shardResultKey(jobID, i)); err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to delete shard result", "jobID", jobID, "shard", i)
+		}
+	}
+	if err := p.clients.status.Delete(ctx, shardPlanKey(jobID)); err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to delete shard plan", "jobID", jobID)
+	}
+	return nil
+}
+
+func (p *Processor) loadShardPlan(ctx context.Context, jobID string) (*shardPlan, error) {
+	data, err := p.clients.status.Get(ctx, shardPlanKey(jobID))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	plan := &shardPlan{}
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal shard plan: %w", err)
+	}
+	return plan, nil
+}
+
+// loadShardResult returns the result of a processed shard, or nil if the shard wasn't processed yet.
+func (p *Processor) loadShardResult(ctx context.Context, jobID string, index int) (*jobCheckpoint, error) {
+	data, err := p.clients.status.Get(ctx, shardResultKey(jobID, index))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	result := &jobCheckpoint{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal shard result: %w", err)
+	}
+	return result, nil
+}
+
+// loadShardResults returns the results of all the shards of plan, failing if a shard wasn't processed yet.
+func (p *Processor) loadShardResults(ctx context.Context, jobID string, plan *shardPlan) ([]*jobCheckpoint, error) {
+	shardResults := make([]*jobCheckpoint, plan.Shards)
+	for i := range shardResults {
+		result, err := p.loadShardResult(ctx, jobID, i)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			return nil, fmt.Errorf("shard %d is not processed yet", i)
+		}
+		shardResults[i] = result
+	}
+	return shardResults, nil
+}
+
+// countLines returns the number of lines of an input file, not counting blank lines like processLines.
+func (p *Processor) countLines(ctx context.Context, fileID string) (int64, error) {
+	reader, _, err := p.clients.files.Retrieve(ctx, fileID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve input file %s: %w", fileID, err)
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	var lines int64
+	br := bufio.NewReader(reader)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			lines++
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return lines, fmt.Errorf("failed to read input file %s: %w", fileID, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the processing of large jobs in shards.
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+func TestShards(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*testEnv, *modelRecordingClient) {
+		inference := &modelRecordingClient{}
+		env := setupProcessorForTest(t, 2, inference)
+		env.processor.cfg.ShardLines = 2
+		env.processor.cfg.TaskWaitTime = 0
+		return env, inference
+	}
+
+	// checkMerged checks that every line of the job has a single result.
+	checkMerged := func(t *testing.T, env *testEnv, jobID string, lines int) {
+		t.Helper()
+		status := env.getStatus(t, jobID)
+		if status.Status != openai.BatchStatusCompleted {
+			t.Fatalf("Status = %v, want %v", status.Status, openai.BatchStatusCompleted)
+		}
+		want := openai.BatchRequestCounts{Total: int64(lines), Completed: int64(lines)}
+		if status.RequestCounts != want {
+			t.Errorf("RequestCounts = %+v, want %+v", status.RequestCounts, want)
+		}
+		seen := map[string]bool{}
+		for _, line := range env.readResultFile(t, status.OutputFileID) {
+			if seen[line.CustomID] {
+				t.Errorf("duplicate result of %s", line.CustomID)
+			}
+			seen[line.CustomID] = true
+		}
+		if len(seen) != lines {
+			t.Errorf("got results of %d lines, want %d", len(seen), lines)
+		}
+		p := env.processor
+		if plan, _ := p.loadShardPlan(ctx, jobID); plan != nil {
+			t.Errorf("shard plan %+v not deleted", plan)
+		}
+		if checkpoint, _ := p.loadCheckpoint(ctx, jobID); checkpoint != nil {
+			t.Errorf("checkpoint %+v not deleted", checkpoint)
+		}
+	}
+
+	t.Run("SplitAndMerge", func(t *testing.T) {
+		env, inference := setup(t)
+		p := env.processor
+		job := env.storeJob(t, "batch-1", time.Now().Add(time.Hour), "m1", "m1", "m1", "m1", "m1")
+		p.clients.priorityQueue.Enqueue(ctx, &db.BatchJobPriority{ID: job.ID, SLO: job.SLO})
+
+		loopCtx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() { done <- p.RunPollingLoop(loopCtx) }()
+		defer func() {
+			cancel()
+			<-done
+		}()
+
+		// the final status is set in the status store after the job is updated, which the loop keeps writing until then
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+			if status, _ := p.clients.status.Get(ctx, job.ID); openai.BatchStatus(status).IsFinal() {
+				break
+			}
+		}
+		checkMerged(t, env, job.ID, 5)
+		if received := inference.received(); len(received) != 5 {
+			t.Errorf("sent %d requests, want 5", len(received))
+		}
+	})
+
+	t.Run("SmallJobNotSharded", func(t *testing.T) {
+		env, _ := setup(t)
+		p := env.processor
+		job := env.storeJob(t, "batch-2", time.Now().Add(time.Hour), "m1", "m1")
+
+		if p.shardJob(ctx, &db.BatchJobPriority{ID: job.ID, SLO: job.SLO}, job) {
+			t.Fatalf("shardJob() split a job of ShardLines lines")
+		}
+		if depth, _ := p.queues.len(ctx); depth != 0 {
+			t.Errorf("queue depth = %d, want 0", depth)
+		}
+	})
+
+	t.Run("AbandonedShard", func(t *testing.T) {
+		env, inference := setup(t)
+		p := env.processor
+		job := env.storeJob(t, "batch-3", time.Now().Add(time.Hour), "m1", "m1", "m1", "m1", "m1")
+
+		if !p.shardJob(ctx, &db.BatchJobPriority{ID: job.ID, SLO: job.SLO}, job) {
+			t.Fatalf("shardJob() didn't split the job")
+		}
+		if status := env.getStatus(t, job.ID); status.Status != openai.BatchStatusInProgress {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusInProgress)
+		}
+		for i := 0; i < 3; i++ {
+			task := p.getTaskFromQueue(ctx)
+			if task == nil || task.Shard == nil || task.Shard.Index != i {
+				t.Fatalf("getTaskFromQueue() = %+v, want shard %d", task, i)
+			}
+			if i == 1 {
+				// the shard keeps failing, its lines are left to the merge
+				p.deadLetter(ctx, task, errors.New("interrupted"))
+				continue
+			}
+			if drained := p.processShard(ctx, 1, task, job); drained {
+				t.Fatalf("processShard() drained")
+			}
+		}
+		if received := inference.received(); len(received) != 3 {
+			t.Errorf("shards sent %d requests, want 3", len(received))
+		}
+		if deadLetters, _ := p.clients.deadLetter.List(ctx); len(deadLetters) != 0 {
+			t.Errorf("dead-lettered %+v, want the shard abandoned", deadLetters)
+		}
+
+		// the last shard queued the job to merge the shards
+		task := p.getTaskFromQueue(ctx)
+		if task == nil || task.ID != job.ID || task.Shard != nil {
+			t.Fatalf("getTaskFromQueue() = %+v, want the job", task)
+		}
+		if p.shardJob(ctx, task, job) {
+			t.Fatalf("shardJob() didn't merge the shards")
+		}
+		p.processJob(ctx, 1, job)
+		checkMerged(t, env, job.ID, 5)
+		if received := inference.received(); len(received) != 5 {
+			t.Errorf("sent %d requests, want 5", len(received))
+		}
+	})
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the speculative retries of the tail stragglers of jobs.
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+)
+
+// stragglerTracker tracks the lines of a job in flight, so the slowest ones are sent to the speculative gateway
+// as well once the job reaches its tail: all its lines were dispatched and at most tailLines are in flight.
+// A nil tracker tracks nothing.
+type stragglerTracker struct {
+	tailLines int
+	delay     time.Duration
+
+	mu         sync.Mutex
+	inflight   map[*inflightLine]struct{}
+	dispatched bool
+}
+
+// inflightLine is a line in flight, hedge is closed when it becomes a straggler.
+type inflightLine struct {
+	started time.Time
+	hedge   chan struct{}
+	hedged  bool
+}
+
+// newStragglerTracker returns a tracker of the stragglers of a job, nil if speculative retries are disabled.
+func (p *Processor) newStragglerTracker() *stragglerTracker {
+	if p.gateways.speculative == nil {
+		return nil
+	}
+	return &stragglerTracker{
+		tailLines: p.cfg.SpeculativeTailLines,
+		delay:     p.cfg.SpeculativeDelay,
+		inflight:  map[*inflightLine]struct{}{},
+	}
+}
+
+// start tracks a line being dispatched.
+func (t *stragglerTracker) start() *inflightLine {
+	if t == nil {
+		return nil
+	}
+	line := &inflightLine{started: time.Now(), hedge: make(chan struct{})}
+	t.mu.Lock()
+	t.inflight[line] = struct{}{}
+	t.mu.Unlock()
+	return line
+}
+
+// done stops tracking a line once it completed.
+func (t *stragglerTracker) done(line *inflightLine) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	delete(t.inflight, line)
+	t.mu.Unlock()
+}
+
+// allDispatched records that all the lines of the job were dispatched.
+func (t *stragglerTracker) allDispatched() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.dispatched = true
+	t.mu.Unlock()
+}
+
+// run marks the lines in flight for longer than the delay as stragglers once the job reaches its tail, until ctx is done.
+func (t *stragglerTracker) run(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	ticker := time.NewTicker(max(t.delay/4, 10*time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			t.check(now)
+		}
+	}
+}
+
+func (t *stragglerTracker) check(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.dispatched || len(t.inflight) > t.tailLines {
+		return
+	}
+	for line := range t.inflight {
+		if !line.hedged && now.Sub(line.started) >= t.delay {
+			line.hedged = true
+			close(line.hedge)
+		}
+	}
+}
+
+// stragglerSignal returns the channel closed when the line becomes a straggler, nil (never closed) for an untracked line.
+func (l *inflightLine) stragglerSignal() <-chan struct{} {
+	if l == nil {
+		return nil
+	}
+	return l.hedge
+}
+
+// generateSpeculative sends an attempt of an inference request to the gateway serving its model, and to the
+// speculative gateway as well if the line becomes a straggler before a response is received. The first successful
+// response is kept and the other request is canceled; the error of the gateway serving the model is returned if
+// both fail.
+func (p *Processor) generateSpeculative(
+	ctx context.Context, gateway *inferenceGateway, req *batch.InferenceRequest, timeout time.Duration, line *inflightLine,
+) (*batch.InferenceResponse, *batch.InferenceError) {
+	speculative := p.gateways.speculative
+	if line == nil || speculative == nil || speculative == gateway || speculative.target != gateway.target {
+		return p.generate(ctx, gateway.client, req, timeout)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type outcome struct {
+		result      *batch.InferenceResponse
+		err         *batch.InferenceError
+		speculative bool
+	}
+	outcomes := make(chan outcome, 2)
+	send := func(client batch.InferenceClient, req *batch.InferenceRequest, speculative bool) {
+		go func() {
+			result, err := p.generate(ctx, client, req, timeout)
+			outcomes <- outcome{result: result, err: err, speculative: speculative}
+		}()
+	}
+	// generate sets the headers of the request, the speculative request is a copy
+	speculativeReq := *req
+	send(gateway.client, req, false)
+	pending := 1
+	straggler := line.stragglerSignal()
+	var primaryErr *batch.InferenceError
+	for {
+		select {
+		case <-straggler:
+			straggler = nil
+			send(speculative.client, &speculativeReq, true)
+			pending++
+		case o := <-outcomes:
+			pending--
+			if straggler == nil && (o.err == nil || pending == 0) {
+				// the speculative request was sent, its response is kept if it completed first
+				metrics.RecordSpeculativeRequest(req.Model, o.speculative && o.err == nil)
+			}
+			if o.err == nil {
+				return o.result, nil
+			}
+			if !o.speculative {
+				primaryErr = o.err
+			}
+			if pending == 0 {
+				return nil, primaryErr
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the speculative retries of the tail stragglers of jobs.
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// stuckModelClient never answers the requests of the model "stuck", and counts those canceled.
+type stuckModelClient struct {
+	canceled atomic.Int32
+}
+
+func (c *stuckModelClient) Generate(ctx context.Context, req *batch.InferenceRequest) (*batch.InferenceResponse, *batch.InferenceError) {
+	if req.Model == "stuck" {
+		<-ctx.Done()
+		c.canceled.Add(1)
+		return nil, &batch.InferenceError{Category: batch.ErrCategoryServer, Message: ctx.Err().Error(), RawError: ctx.Err()}
+	}
+	return &batch.InferenceResponse{RequestID: "req-" + req.RequestID, Response: []byte(`{}`)}, nil
+}
+
+func TestStragglerTracker(t *testing.T) {
+	tracker := &stragglerTracker{tailLines: 1, delay: time.Minute, inflight: map[*inflightLine]struct{}{}}
+	first, second := tracker.start(), tracker.start()
+	stragglers := func() (n int) {
+		for _, line := range []*inflightLine{first, second} {
+			select {
+			case <-line.stragglerSignal():
+				n++
+			default:
+			}
+		}
+		return n
+	}
+	later := time.Now().Add(2 * time.Minute)
+
+	tracker.check(later)
+	if n := stragglers(); n != 0 {
+		t.Errorf("%d stragglers before all the lines were dispatched, want 0", n)
+	}
+	tracker.allDispatched()
+	tracker.check(later)
+	if n := stragglers(); n != 0 {
+		t.Errorf("%d stragglers with more lines in flight than the tail, want 0", n)
+	}
+	tracker.done(first)
+	tracker.check(time.Now())
+	if n := stragglers(); n != 0 {
+		t.Errorf("%d stragglers before the delay, want 0", n)
+	}
+	tracker.check(later)
+	tracker.check(later)
+	if n := stragglers(); n != 1 {
+		t.Errorf("%d stragglers, want 1", n)
+	}
+
+	var disabled *stragglerTracker
+	if line := disabled.start(); line.stragglerSignal() != nil {
+		t.Errorf("untracked line has a straggler signal")
+	}
+}
+
+func TestSpeculativeRetry(t *testing.T) {
+	setup := func(t *testing.T, speculativeGateway string) (*testEnv, *stuckModelClient, *modelRecordingClient) {
+		primary := &stuckModelClient{}
+		speculative := &modelRecordingClient{}
+		env := setupProcessorForTest(t, 4, primary)
+		p := env.processor
+		p.clients.AddInferenceClient("speculative", speculative)
+		p.cfg.InferenceGateways = []config.InferenceGatewayConfig{{Name: "speculative", Models: []string{"other"}}}
+		p.cfg.SpeculativeGateway = speculativeGateway
+		p.cfg.SpeculativeTailLines = 1
+		p.cfg.SpeculativeDelay = 20 * time.Millisecond
+		p.gateways = newGatewayRouter(p.cfg, p.clients)
+		return env, primary, speculative
+	}
+
+	t.Run("StragglerSentAgain", func(t *testing.T) {
+		env, primary, speculative := setup(t, "speculative")
+		job := env.storeJob(t, "batch-1", time.Now().Add(time.Hour), "m", "m", "stuck")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		status := env.getStatus(t, job.ID)
+		if status.Status != openai.BatchStatusCompleted || status.RequestCounts.Completed != 3 {
+			t.Errorf("Status = %v, RequestCounts = %+v, want completed with 3 completed", status.Status, status.RequestCounts)
+		}
+		if got := speculative.received(); len(got) != 1 || got[0] != "stuck" {
+			t.Errorf("speculative gateway received %v, want [stuck]", got)
+		}
+		// the request of the straggler that didn't complete first is canceled
+		deadline := time.Now().Add(time.Second)
+		for primary.canceled.Load() == 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if n := primary.canceled.Load(); n != 1 {
+			t.Errorf("%d canceled requests, want 1", n)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		env, _, speculative := setup(t, "")
+		job := env.storeJob(t, "batch-2", time.Now().Add(time.Hour), "m", "stuck")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		env.processor.processJob(ctx, 1, job)
+
+		if got := speculative.received(); len(got) != 0 {
+			t.Errorf("speculative gateway received %v, want none", got)
+		}
+	})
+}
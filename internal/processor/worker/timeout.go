@@ -0,0 +1,44 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the timeout of the inference requests of a line.
+package worker
+
+import (
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// maxTokensParams are the request parameters bounding the output tokens, depending on the endpoint.
+var maxTokensParams = []string{"max_tokens", "max_completion_tokens", "max_output_tokens"}
+
+// lineTimeout returns the timeout of the inference request of a line: its own timeout if set, or the one derived
+// from its output token limit, bounded by the processor's RequestTimeout.
+func (p *Processor) lineTimeout(req *openai.BatchRequestInput, params map[string]interface{}) time.Duration {
+	timeout := p.cfg.RequestTimeout
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds * float64(time.Second))
+	} else if p.cfg.RequestTimeoutPerToken > 0 {
+		for _, name := range maxTokensParams {
+			if tokens, ok := params[name].(float64); ok && tokens > 0 {
+				timeout = p.cfg.RequestTimeoutBase + time.Duration(tokens)*p.cfg.RequestTimeoutPerToken
+				break
+			}
+		}
+	}
+	return min(timeout, p.cfg.RequestTimeout)
+}
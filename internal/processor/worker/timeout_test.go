@@ -0,0 +1,100 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the timeout of the inference requests of a line.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+func TestLineTimeout(t *testing.T) {
+	env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+	p := env.processor
+	p.cfg.RequestTimeout = 5 * time.Minute
+	p.cfg.RequestTimeoutBase = 10 * time.Second
+	p.cfg.RequestTimeoutPerToken = 100 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		line     string
+		noDerive bool
+		timeout  time.Duration
+	}{
+		{name: "default", line: `{"body":{"model":"m1"}}`, timeout: 5 * time.Minute},
+		{name: "line timeout", line: `{"body":{"model":"m1","max_tokens":100},"timeout_seconds":2.5}`, timeout: 2500 * time.Millisecond},
+		{name: "line timeout above maximum", line: `{"body":{"model":"m1"},"timeout_seconds":3600}`, timeout: 5 * time.Minute},
+		{name: "derived from max_tokens", line: `{"body":{"model":"m1","max_tokens":100}}`, timeout: 20 * time.Second},
+		{name: "derived from max_completion_tokens", line: `{"body":{"model":"m1","max_completion_tokens":50}}`, timeout: 15 * time.Second},
+		{name: "derived above maximum", line: `{"body":{"model":"m1","max_tokens":100000}}`, timeout: 5 * time.Minute},
+		{name: "derivation disabled", line: `{"body":{"model":"m1","max_tokens":100}}`, noDerive: true, timeout: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.cfg.RequestTimeoutPerToken = 100 * time.Millisecond
+			if tt.noDerive {
+				p.cfg.RequestTimeoutPerToken = 0
+			}
+			req := &openai.BatchRequestInput{}
+			if err := json.Unmarshal([]byte(tt.line), req); err != nil {
+				t.Fatalf("failed to decode line: %v", err)
+			}
+			params := map[string]interface{}{}
+			json.Unmarshal(req.Body, &params)
+			if got := p.lineTimeout(req, params); got != tt.timeout {
+				t.Errorf("lineTimeout() = %v, want %v", got, tt.timeout)
+			}
+		})
+	}
+}
+
+func TestProcessJobLineTimeout(t *testing.T) {
+	env := setupProcessorForTest(t, 2, &fakeInferenceClient{delay: 200 * time.Millisecond})
+	env.processor.cfg.RetryMaxAttempts = 1
+	input := `{"custom_id":"fast","method":"POST","url":"/v1/chat/completions","body":{"model":"m1"},"timeout_seconds":0.02}` + "\n" +
+		`{"custom_id":"slow","method":"POST","url":"/v1/chat/completions","body":{"model":"m1"}}` + "\n"
+	env.files.Store(context.Background(), "file_timeout", 0, strings.NewReader(input))
+	spec, _ := json.Marshal(openai.BatchSpec{
+		Object:           "batch",
+		Endpoint:         openai.EndpointChatCompletions,
+		InputFileID:      "file_timeout",
+		CompletionWindow: "24h",
+	})
+	status, _ := json.Marshal(openai.BatchStatusInfo{Status: openai.BatchStatusValidating})
+	job := &db.BatchJob{ID: "batch-timeout", SLO: time.Now().Add(time.Hour), TTL: 86400, Spec: spec, Status: status}
+	env.dbClient.Store(context.Background(), job)
+
+	env.processor.processJob(context.Background(), 1, job)
+
+	got := env.getStatus(t, job.ID)
+	want := openai.BatchRequestCounts{Total: 2, Completed: 1, Failed: 1}
+	if got.RequestCounts != want {
+		t.Fatalf("RequestCounts = %+v, want %+v", got.RequestCounts, want)
+	}
+	errors := env.readResultFile(t, got.ErrorFileID)
+	if len(errors) != 1 || errors[0].CustomID != "fast" || errors[0].Response == nil ||
+		errors[0].Response.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("error lines = %+v, want a gateway timeout of fast", errors)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains the accounting of the tokens used by the requests of a job, and of the usage of its tenant.
+package worker
+
+import (
+	"encoding/json"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// unknownTenant labels the token usage of the batches created without a tenant.
+const unknownTenant = "unknown"
+
+// responseUsage returns the token usage reported in a response body: prompt and completion tokens for the
+// chat completions, completions and embeddings APIs, input and output tokens for the responses API.
+func responseUsage(body []byte) (openai.BatchUsage, bool) {
+	var response struct {
+		Usage *struct {
+			PromptTokens       int64 `json:"prompt_tokens"`
+			CompletionTokens   int64 `json:"completion_tokens"`
+			InputTokens        int64 `json:"input_tokens"`
+			OutputTokens       int64 `json:"output_tokens"`
+			TotalTokens        int64 `json:"total_tokens"`
+			PromptTokenDetails *struct {
+				CachedTokens int64 `json:"cached_tokens"`
+			} `json:"prompt_tokens_details"`
+			InputTokenDetails *struct {
+				CachedTokens int64 `json:"cached_tokens"`
+			} `json:"input_tokens_details"`
+			CompletionTokenDetails *struct {
+				ReasoningTokens int64 `json:"reasoning_tokens"`
+			} `json:"completion_tokens_details"`
+			OutputTokenDetails *struct {
+				ReasoningTokens int64 `json:"reasoning_tokens"`
+			} `json:"output_tokens_details"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.Usage == nil {
+		return openai.BatchUsage{}, false
+	}
+	u := response.Usage
+	usage := openai.BatchUsage{
+		InputTokens:  u.PromptTokens + u.InputTokens,
+		OutputTokens: u.CompletionTokens + u.OutputTokens,
+		TotalTokens:  u.TotalTokens,
+	}
+	if u.PromptTokenDetails != nil {
+		usage.InputTokensDetails.CachedTokens = u.PromptTokenDetails.CachedTokens
+	} else if u.InputTokenDetails != nil {
+		usage.InputTokensDetails.CachedTokens = u.InputTokenDetails.CachedTokens
+	}
+	if u.CompletionTokenDetails != nil {
+		usage.OutputTokensDetails.ReasoningTokens = u.CompletionTokenDetails.ReasoningTokens
+	} else if u.OutputTokenDetails != nil {
+		usage.OutputTokensDetails.ReasoningTokens = u.OutputTokenDetails.ReasoningTokens
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	}
+	return usage, true
+}
+
+// addUsage adds the tokens of usage to total.
+func addUsage(total *openai.BatchUsage, usage openai.BatchUsage) {
+	total.InputTokens += usage.InputTokens
+	total.InputTokensDetails.CachedTokens += usage.InputTokensDetails.CachedTokens
+	total.OutputTokens += usage.OutputTokens
+	total.OutputTokensDetails.ReasoningTokens += usage.OutputTokensDetails.ReasoningTokens
+	total.TotalTokens += usage.TotalTokens
+}
+
+// tenantLabel returns the tenant labeling the metrics of a batch.
+func tenantLabel(spec *openai.BatchSpec) string {
+	if spec.TenantID == "" {
+		return unknownTenant
+	}
+	return spec.TenantID
+}
+
+// recordUsage adds the requests processed during a delivery of a job, and the tokens they used, to the counters
+// of its tenant.
+func recordUsage(tenantID string, results *jobResults) {
+	usage := results.deliveredUsage()
+	metrics.RecordTokenUsage(tenantID, usage.InputTokens, usage.OutputTokens)
+	succeeded, failed := results.deliveredRequests()
+	metrics.RecordTenantRequests(tenantID, succeeded, failed)
+}
+
+// resultBytes returns the size of the result files of a job.
+func resultBytes(shards ...[]openai.BatchOutputShard) int64 {
+	var total int64
+	for _, files := range shards {
+		for _, shard := range files {
+			total += shard.Bytes
+		}
+	}
+	return total
+}
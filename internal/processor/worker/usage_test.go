@@ -0,0 +1,121 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the accounting of the tokens used by the requests of a job.
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+// usageClient responds to every request with the same token usage.
+type usageClient struct{}
+
+func (usageClient) Generate(ctx context.Context, req *batch.InferenceRequest) (*batch.InferenceResponse, *batch.InferenceError) {
+	return &batch.InferenceResponse{
+		RequestID: "req-" + req.RequestID,
+		Response: []byte(`{"object":"chat.completion","usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15,` +
+			`"prompt_tokens_details":{"cached_tokens":4},"completion_tokens_details":{"reasoning_tokens":2}}}`),
+	}, nil
+}
+
+func TestResponseUsage(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		want   openai.BatchUsage
+		wantOK bool
+	}{
+		{
+			name:   "chat completion",
+			body:   `{"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15,"prompt_tokens_details":{"cached_tokens":4}}}`,
+			want:   openai.BatchUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15, InputTokensDetails: openai.BatchUsageInputTokensDetails{CachedTokens: 4}},
+			wantOK: true,
+		},
+		{
+			name:   "response",
+			body:   `{"usage":{"input_tokens":7,"output_tokens":3,"output_tokens_details":{"reasoning_tokens":1}}}`,
+			want:   openai.BatchUsage{InputTokens: 7, OutputTokens: 3, TotalTokens: 10, OutputTokensDetails: openai.BatchUsageOutputTokensDetails{ReasoningTokens: 1}},
+			wantOK: true,
+		},
+		{
+			name:   "embedding",
+			body:   `{"usage":{"prompt_tokens":8,"total_tokens":8}}`,
+			want:   openai.BatchUsage{InputTokens: 8, TotalTokens: 8},
+			wantOK: true,
+		},
+		{name: "no usage", body: `{"object":"chat.completion"}`},
+		{name: "invalid", body: `not json`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := responseUsage([]byte(tt.body))
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("responseUsage() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestJobUsage(t *testing.T) {
+	t.Run("Completed", func(t *testing.T) {
+		env := setupProcessorForTest(t, 2, usageClient{})
+		job := env.storeJob(t, "batch-1", time.Now().Add(time.Hour), "m", "m", "m")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		status := env.getStatus(t, job.ID)
+		want := openai.BatchUsage{
+			InputTokens:         30,
+			InputTokensDetails:  openai.BatchUsageInputTokensDetails{CachedTokens: 12},
+			OutputTokens:        15,
+			OutputTokensDetails: openai.BatchUsageOutputTokensDetails{ReasoningTokens: 6},
+			TotalTokens:         45,
+		}
+		if status.Usage == nil || *status.Usage != want {
+			t.Errorf("Usage = %+v, want %+v", status.Usage, want)
+		}
+	})
+
+	t.Run("Restored", func(t *testing.T) {
+		results := newJobResults("batch-2", 0, 0)
+		defer results.close()
+		restored := `{"id":"batch_req_1","custom_id":"req-0","response":{"status_code":200,"request_id":"r","body":{"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}}}` + "\n"
+		if err := results.restore(strings.NewReader(restored), true); err != nil {
+			t.Fatalf("restore() error = %v", err)
+		}
+		if err := results.writeResponse("req-1", &openai.BatchRequestResponse{
+			StatusCode: 200,
+			Body:       []byte(`{"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}`),
+		}); err != nil {
+			t.Fatalf("writeResponse() error = %v", err)
+		}
+
+		// the usage of the restored lines counts in the batch, not in the delivery
+		if total := results.totalUsage(); total.TotalTokens != 18 || total.InputTokens != 11 {
+			t.Errorf("totalUsage() = %+v, want 18 total tokens", total)
+		}
+		if delivered := results.deliveredUsage(); delivered.TotalTokens != 3 || delivered.OutputTokens != 2 {
+			t.Errorf("deliveredUsage() = %+v, want 3 total tokens", delivered)
+		}
+	})
+}
@@ -18,76 +18,237 @@ limitations under the License.
 package worker
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/klog/v2"
 
 	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	filesapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/api"
 	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
 	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/notify"
 	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/store"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/features"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/labels"
 	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/redact"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/slowop"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/tracing"
+)
+
+// errTooManyFailures aborts a job whose first lines fail with non-retryable errors above the configured ratio.
+var errTooManyFailures = errors.New("too many failed requests")
+
+const (
+	// TTL of the temporary job status entries
+	jobStatusTTL = 24 * 60 * 60
+
+	// TTL of the output and error files when the job doesn't carry one
+	defaultResultFileTTL = 30 * 24 * 60 * 60
 )
 
 type ProcessorClients struct {
 	database      db.BatchDBClient
+	fileDatabase  db.BatchFileDBClient
 	priorityQueue db.BatchPriorityQueueClient
+	deadLetter    db.BatchDeadLetterClient
 	status        db.BatchStatusClient
 	event         db.BatchEventChannelClient
+	files         filesapi.BatchFilesClient
 	inference     batch.InferenceClient
+
+	// additional priority queues by name, the default queue being priorityQueue
+	priorityQueues map[string]db.BatchPriorityQueueClient
+
+	// the clients of the inference gateways by name, serving the models they are configured for
+	inferenceClients map[string]batch.InferenceClient
+
+	// the sinks the lifecycle events of the batches are forwarded to
+	eventSinks []notify.Sink
 }
 
 func NewProcessorClients(
 	db db.BatchDBClient,
+	fileDB db.BatchFileDBClient,
 	pq db.BatchPriorityQueueClient,
+	dlq db.BatchDeadLetterClient,
 	status db.BatchStatusClient,
 	event db.BatchEventChannelClient,
+	files filesapi.BatchFilesClient,
 	inference batch.InferenceClient,
 ) ProcessorClients {
 	return ProcessorClients{
 		database:      db,
+		fileDatabase:  fileDB,
 		priorityQueue: pq,
+		deadLetter:    dlq,
 		status:        status,
 		event:         event,
+		files:         files,
 		inference:     inference,
 	}
 }
 
+// AddPriorityQueue registers the client of a priority queue the processor consumes from, in addition to the
+// default queue. The queues consumed from and their weights are configured by ProcessorConfig.Queues.
+func (pc *ProcessorClients) AddPriorityQueue(name string, client db.BatchPriorityQueueClient) {
+	if pc.priorityQueues == nil {
+		pc.priorityQueues = map[string]db.BatchPriorityQueueClient{}
+	}
+	pc.priorityQueues[name] = client
+}
+
+// AddInferenceClient registers the client of an inference gateway. The models served by each gateway
+// are configured by ProcessorConfig.InferenceGateways; the other models are served by the default inference client.
+func (pc *ProcessorClients) AddInferenceClient(gateway string, client batch.InferenceClient) {
+	if pc.inferenceClients == nil {
+		pc.inferenceClients = map[string]batch.InferenceClient{}
+	}
+	pc.inferenceClients[gateway] = client
+}
+
+// AddEventSink registers a sink the lifecycle events of the batches are forwarded to.
+func (pc *ProcessorClients) AddEventSink(sink notify.Sink) {
+	pc.eventSinks = append(pc.eventSinks, sink)
+}
+
+// AddHealthChecks registers the storage clients with the set checked by the readiness endpoint.
+func (pc *ProcessorClients) AddHealthChecks(clientset *store.Clientset) {
+	clientset.Add("database", pc.database)
+	clientset.Add("file_database", pc.fileDatabase)
+	clientset.Add("queue", pc.priorityQueue)
+	for name, client := range pc.priorityQueues {
+		clientset.Add("queue/"+name, client)
+	}
+	clientset.Add("dead_letter", pc.deadLetter)
+	clientset.Add("events", pc.event)
+	clientset.Add("status", pc.status)
+	clientset.Add("files_store", pc.files)
+}
+
+// queue returns the client of the named priority queue, or nil if it isn't registered.
+func (pc *ProcessorClients) queue(name string) db.BatchPriorityQueueClient {
+	if name == config.DefaultQueueName && pc.priorityQueue != nil {
+		return pc.priorityQueue
+	}
+	return pc.priorityQueues[name]
+}
+
 type Processor struct {
+	// identifies the processor instance in worker heartbeats
+	id string
+
 	cfg        *config.ProcessorConfig
 	workerPool *WorkerPool
 
 	clients *ProcessorClients
+
+	// the queues jobs are consumed from, by weight
+	queues *queueSet
+
+	// the inference gateways requests are sent to, by model
+	gateways *gatewayRouter
+
+	// inference request outcomes, used by the autoscaler
+	inferenceStats inferenceStats
+
+	// bounds the inference requests in flight across jobs, earliest batch deadline first
+	dispatcher *dispatcher
+
+	// pauses dispatch to saturated models
+	saturation *saturationGuard
+
+	// throttles dispatch when the memory use approaches its limit
+	memory *memoryGuard
+
+	// the next job, leased while the last lines of a job complete
+	prefetch prefetcher
+
+	// forwards the lifecycle events of the batches to the event sinks, nil without sinks
+	events *notify.Forwarder
+
+	// bounds the requests and tokens sent to each model, replaced when the rate limits are reloaded
+	rateLimiter atomic.Pointer[rateLimiter]
+
+	// the settings changed at runtime when the configuration is reloaded, see Reload
+	reloadMu     sync.Mutex
+	pollInterval atomic.Int64 // time.Duration
+	rateLimits   []config.RateLimitConfig
+
+	// done when the processor shuts down: no line is started anymore and the jobs in progress are drained
+	draining   context.Context
+	startDrain context.CancelFunc
 }
 
 func NewProcessor(
 	cfg *config.ProcessorConfig,
 	clients *ProcessorClients,
 ) *Processor {
-	return &Processor{
+	workerPool := NewWorkerPool(cfg.NumWorkers)
+	if cfg.AutoscaleEnabled {
+		// start small, workers are added as jobs are queued
+		workerPool.SetLimit(cfg.MinWorkers)
+	}
+	draining, startDrain := context.WithCancel(context.Background())
+	p := &Processor{
+		id:         processorID(cfg.ProcessorID),
 		cfg:        cfg,
-		workerPool: NewWorkerPool(cfg.NumWorkers),
+		workerPool: workerPool,
 		clients:    clients,
+		queues:     newQueueSet(cfg.Queues, clients),
+		gateways:   newGatewayRouter(cfg, clients),
+		dispatcher: newDispatcher(cfg.MaxConcurrentRequests),
+		saturation: newSaturationGuard(cfg.SaturationThreshold, cfg.SaturationPause, cfg.SaturationMaxPause),
+		memory:     newMemoryGuard(cfg.MemoryThrottleRatio, memoryLimit(cfg.MemoryLimit), cfg.MemoryCheckInterval),
+		draining:   draining,
+		startDrain: startDrain,
+		rateLimits: cfg.RateLimits,
 	}
+	if len(clients.eventSinks) > 0 {
+		p.events = notify.NewForwarder(clients.eventSinks)
+	}
+	p.rateLimiter.Store(newRateLimiter(cfg.RateLimits))
+	p.pollInterval.Store(int64(cfg.PollInterval))
+	return p
 }
 
 func (pc *ProcessorClients) Validate() error {
 	if pc.database == nil {
 		return fmt.Errorf("database client is missing")
 	}
+	if pc.fileDatabase == nil {
+		return fmt.Errorf("file database client is missing")
+	}
 	if pc.priorityQueue == nil {
 		return fmt.Errorf("priority queue client is missing")
 	}
+	if pc.deadLetter == nil {
+		return fmt.Errorf("dead-letter queue client is missing")
+	}
 	if pc.status == nil {
 		return fmt.Errorf("status client is missing")
 	}
 	if pc.event == nil {
 		return fmt.Errorf("event channel client is missing")
 	}
-	if pc.inference == nil {
+	if pc.files == nil {
+		return fmt.Errorf("files client is missing")
+	}
+	if pc.inference == nil && len(pc.inferenceClients) == 0 {
 		return fmt.Errorf("inference client is missing")
 	}
 	return nil
@@ -100,12 +261,33 @@ func (p *Processor) prepare(ctx context.Context) error {
 	if err := p.clients.Validate(); err != nil {
 		return fmt.Errorf("critical clients are missing in processor: %w", err)
 	}
+	for _, queue := range p.cfg.Queues {
+		if p.clients.queue(queue.Name) == nil {
+			return fmt.Errorf("critical clients are missing in processor: priority queue client of queue %q is missing", queue.Name)
+		}
+	}
+	for _, gateway := range p.cfg.InferenceGateways {
+		if p.clients.inferenceClients[gateway.Name] == nil {
+			return fmt.Errorf("critical clients are missing in processor: inference client of gateway %q is missing", gateway.Name)
+		}
+	}
+	if p.gateways.fallback.client == nil {
+		return fmt.Errorf("critical clients are missing in processor: no inference client serves the models without a gateway")
+	}
+
+	partition, err := queuePartition(p.cfg.PartitionIndex, p.cfg.PartitionCount, os.Hostname)
+	if err != nil {
+		return err
+	}
+	p.queues.mu.Lock()
+	p.queues.partition = partition
+	p.queues.mu.Unlock()
 
 	logger.V(logging.DEBUG).Info("Processor pre-flight check done", "max_workers", p.cfg.NumWorkers)
 	return nil
 }
 
-// TODO: events implementation (cancel, pause, resume)
+// TODO: events implementation (cancel)
 // RunPollingLoop runs the main job polling loop for the processor, try assign the job to the worker,
 func (p *Processor) RunPollingLoop(ctx context.Context) error {
 	if err := p.prepare(ctx); err != nil {
@@ -114,64 +296,130 @@ func (p *Processor) RunPollingLoop(ctx context.Context) error {
 	logger := klog.FromContext(ctx)
 	logger.V(logging.INFO).Info(
 		"Polling loop started",
-		"loopInterval", p.cfg.PollInterval,
+		"minPollInterval", p.cfg.PollMinInterval,
+		"maxPollInterval", p.cfg.PollInterval,
 		"maxWorkers", p.cfg.NumWorkers,
+		"autoscale", p.cfg.AutoscaleEnabled,
+		"partition", p.queues.partition,
 	)
 
-	// worker driven non-busy wait
+	if p.cfg.AutoscaleEnabled {
+		_, limit := p.workerPool.Stats()
+		metrics.SetTotalWorkers(limit)
+		go p.runAutoscaler(ctx)
+	}
+
+	// return the tasks of crashed processors to the queue, and expire the batches no processor will complete
+	go p.runLeaseReclaimer(ctx)
+	go p.runReaper(ctx)
+	go p.runQueueMetrics(ctx)
+
+	// the jobs in progress are drained on shutdown, and a job leased ahead of a free worker is put back to the queue
+	context.AfterFunc(ctx, p.startDrain)
+	workctx := context.WithoutCancel(ctx)
+	p.prefetch.mu.Lock()
+	p.prefetch.ctx = workctx
+	p.prefetch.mu.Unlock()
+	context.AfterFunc(p.draining, func() {
+		p.dropPrefetched(workctx)
+	})
+
+	// worker driven non-busy wait; the queues are polled again right away while they have jobs,
+	// and with an increasing backoff while they are empty
+	var idle time.Duration
 	for {
-		var workerId int
-		select {
-		case <-ctx.Done():
+		workerId, ok := p.workerPool.Acquire(ctx) // wait until at least one worker is available
+		if !ok {
+			return nil
+		}
+		// a drained processor doesn't claim tasks anymore, it waits to be stopped
+		if p.isDraining() {
+			p.workerPool.Release(workerId)
+			<-ctx.Done()
 			return nil
-		case id, ok := <-p.workerPool.workerIds: // wait until at least one worker is available
-			if !ok {
-				return nil
-			}
-			workerId = id
 		}
 
-		// check queue for available tasks
-		task := p.getTaskFromQueue(ctx)
+		// a job leased ahead while the workers were busy is started first, otherwise check queue for available tasks
+		prefetched := p.takePrefetched(ctx)
+		var task *db.BatchJobPriority
+		if prefetched != nil {
+			task = prefetched.task
+		} else {
+			task = p.getTaskFromQueue(ctx)
+		}
 
 		// when there's no waiting tasks in the queue
 		if task == nil {
 			p.workerPool.Release(workerId)
-			// wait for poll interval to protect db from frequent queueing
+			// back off to protect the db from frequent polling
+			idle = idleBackoff(idle, p.cfg.PollMinInterval, time.Duration(p.pollInterval.Load()))
 			select {
 			case <-ctx.Done():
 				return nil
-			case <-time.After(p.cfg.PollInterval):
+			case <-time.After(idle):
 				continue
 			}
 		}
+		idle = 0
 
-		// get detailed job info for processor
-		jobDbData, err := p.getJobData(ctx, task)
-		if err != nil {
+		// the processing of the job was interrupted too many times, e.g. it crashes the processor
+		if task.Attempts >= p.cfg.MaxDeliveryAttempts {
+			p.ackLease(ctx, task)
+			p.deadLetter(ctx, task, fmt.Errorf("job processing was interrupted %d times", task.Attempts))
+			prefetched.release()
 			p.workerPool.Release(workerId)
 			continue
 		}
 
-		// TODO:: get tenant id from job.Spec
-		// tenantID := "unknown"
-		// TODO:: job queue object should have enqueued at field (maybe updated at too)
-		// TODO:: metrics.RecordQueueWait(time.Since(task.EnqueuedAt), tenantID)
+		// get detailed job info for processor, fetched when the job was leased ahead
+		var jobDbData *db.BatchJob
+		if prefetched != nil {
+			jobDbData = prefetched.job
+		} else {
+			var err error
+			jobDbData, err = p.getJobData(ctx, task)
+			if err != nil {
+				p.workerPool.Release(workerId)
+				continue
+			}
+			if jobDbData.TraceContext == nil {
+				jobDbData.TraceContext = task.TraceContext
+			}
+		}
 
 		// process job
-		go func(wid int, j *db.BatchJob) {
+		go func(wid int, t *db.BatchJobPriority, j *db.BatchJob, pj *prefetchedJob) {
+			// the job is processed while the lease of its task is held; on shutdown it is drained rather than
+			// interrupted, so it is only cancelled if the lease is lost
+			jobctx, cancelJob := context.WithCancel(withPrefetchedJob(workctx, pj))
+			stopLease := p.keepLease(jobctx, t, wid, cancelJob)
+			drained := false
 			defer func() {
+				leaseLost := jobctx.Err() != nil
+				stopLease()
+				cancelJob()
+				pj.release()
 				if r := recover(); r != nil {
 					recoverErr := fmt.Errorf("%v", r)
-					logger.V(logging.ERROR).Error(recoverErr, "Panic recovered", "workerID", wid)
+					logger.V(logging.ERROR).Error(recoverErr, "Panic recovered", "workerID", wid, "jobID", t.ID)
+					p.requeueOrDeadLetter(workctx, t, fmt.Errorf("panic while processing job: %w", recoverErr))
+				} else if drained {
+					p.requeueDrained(workctx, t)
+				} else if !leaseLost {
+					p.ackLease(workctx, t)
 				}
-				p.workerPool.Release(wid)
+				// the worker is released last, an idle pool means the jobs are done
 				metrics.DecActiveWorkers()
+				p.workerPool.Release(wid)
 			}()
 
 			metrics.IncActiveWorkers()
-			p.processJob(ctx, wid, j)
-		}(workerId, jobDbData)
+			if t.Shard != nil {
+				drained = p.processShard(jobctx, wid, t, j)
+			} else if !p.shardJob(jobctx, t, j) {
+				drained = p.processJob(jobctx, wid, j)
+			}
+		}(workerId, task, jobDbData, prefetched)
 	}
 }
 
@@ -179,42 +427,53 @@ func (p *Processor) RunPollingLoop(ctx context.Context) error {
 func (p *Processor) getTaskFromQueue(ctx context.Context) *db.BatchJobPriority {
 	logger := klog.FromContext(ctx)
 
-	tasks, err := p.clients.priorityQueue.Dequeue(ctx, 0, 1) // get only one job without blocking the queue
-	if err != nil {
+	task, err := p.queues.lease(ctx, p.cfg.TaskWaitTime, p.cfg.LeaseTTL)
+	if err != nil && ctx.Err() == nil {
 		logger.V(logging.ERROR).Error(err, "Failed to dequeue a batch job")
-		return nil
 	}
 
 	// there's no backlog
-	if len(tasks) == 0 {
+	if task == nil {
 		logger.V(logging.TRACE).Info("No jobs to fetch")
 		return nil
 	}
 
-	logger.V(logging.DEBUG).Info("Successfully fetched a job", "jobID", tasks[0].ID)
-	return tasks[0]
+	logger.V(logging.DEBUG).Info("Successfully fetched a job", "jobID", task.ID, "queue", task.Queue)
+	metrics.RecordJobDequeued(task.Queue)
+	return task
+}
+
+// idleBackoff returns the time to wait before polling the queues again after they had no job, given the previous
+// wait (0 after a job was dequeued): it starts at minWait and doubles up to maxWait.
+func idleBackoff(previous, minWait, maxWait time.Duration) time.Duration {
+	if previous <= 0 {
+		return min(minWait, maxWait)
+	}
+	return min(2*previous, maxWait)
 }
 
 // getJobData gets job's db data
 func (p *Processor) getJobData(ctx context.Context, task *db.BatchJobPriority) (*db.BatchJob, error) {
 	logger := klog.FromContext(ctx)
 
-	// get only one job data
-	ids := []string{task.ID}
+	// get only one job data, a shard has the data of its job
+	jobID := task.ID
+	if task.Shard != nil {
+		jobID = task.Shard.JobID
+	}
+	ids := []string{jobID}
 	jobs, _, err := p.clients.database.Get(ctx, ids, nil, db.TagsLogicalCondNa, true, 0, 1)
 
 	// job db data does not exist or failed to fetch the data
 	if err != nil || len(jobs) == 0 {
 		jobDataErr := err
 		if len(jobs) == 0 {
-			jobDataErr = fmt.Errorf("Job data for %s does not exist", task.ID)
+			jobDataErr = fmt.Errorf("Job data for %s does not exist", jobID)
 		}
 		logger.V(logging.ERROR).Error(jobDataErr, "Failed to fetch detailed job info. re-queueing ID", "jobID", task.ID)
 
 		// can't process the job. put the task back to the queue.
-		if enqueueErr := p.clients.priorityQueue.Enqueue(ctx, task); enqueueErr != nil {
-			logger.V(logging.ERROR).Error(enqueueErr, "CRITICAL: Failed to re-enqueue job", "jobID", task.ID)
-		}
+		p.requeueOrDeadLetter(ctx, task, jobDataErr)
 		return nil, jobDataErr
 	}
 
@@ -222,158 +481,770 @@ func (p *Processor) getJobData(ctx context.Context, task *db.BatchJobPriority) (
 	return jobs[0], nil
 }
 
-// TODO:: complete job processing logic
-// read input file, streaming, line processing, result writing, etc.
-// TODO:: add event handling (cancel, pause, resume)
-// TODO:: add metrics (job duration, job processed, job result, job failure reason)
-// TODO:: add logging (job started, job finished, job failed)
-// TODO:: add error handling (error handling. inference request failed)
-// TODO:: add response handling (response handling + writing line to the output file ...)
-// TODO:: add output file writing (output file writing)
-// TODO:: add output file reading (output file reading)
-// TODO:: add output file closing (output file closing)
-func (p *Processor) processJob(ctx context.Context, workerId int, job *db.BatchJob) {
+// requeueOrDeadLetter puts a task that could not be processed back to the queue after a backoff, or moves it to
+// the dead-letter queue when it was delivered MaxDeliveryAttempts times, so a poisoned task isn't retried forever.
+func (p *Processor) requeueOrDeadLetter(ctx context.Context, task *db.BatchJobPriority, cause error) {
+	logger := klog.FromContext(ctx)
+
+	// the task is put back or dead-lettered, so the lease isn't needed anymore
+	p.ackLease(ctx, task)
+
+	task.Attempts++
+	task.Failures = append(task.Failures, db.BatchDeliveryFailure{Time: time.Now().UTC(), Error: cause.Error()})
+	if task.Attempts < p.cfg.MaxDeliveryAttempts {
+		delay := p.requeueBackoff(task.Attempts)
+		if err := p.queues.client(task.Queue).EnqueueDelayed(ctx, task, delay); err != nil {
+			logger.V(logging.ERROR).Error(err, "CRITICAL: Failed to re-enqueue job", "jobID", task.ID)
+			return
+		}
+		logger.V(logging.DEBUG).Info("Re-enqueued job", "jobID", task.ID, "attempts", task.Attempts, "delay", delay)
+		return
+	}
+	p.deadLetter(ctx, task, cause)
+}
+
+// requeueBackoff returns the delay before a task that failed the given number of delivery attempts is returned
+// to the queue.
+func (p *Processor) requeueBackoff(attempts int) time.Duration {
+	backoff := p.cfg.RequeueBackoff
+	for i := 1; i < attempts && backoff < p.cfg.RequeueMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, p.cfg.RequeueMaxBackoff)
+}
+
+// deadLetter moves a task to the dead-letter queue. The lines of a shard are left to the merge of the shards of
+// its job instead.
+func (p *Processor) deadLetter(ctx context.Context, task *db.BatchJobPriority, cause error) {
+	logger := klog.FromContext(ctx)
+	if task.Shard != nil {
+		p.abandonShard(ctx, task, cause)
+		return
+	}
+
+	deadLetter := &db.BatchDeadLetter{
+		ID:             task.ID,
+		JobPriority:    task,
+		Error:          cause.Error(),
+		DeadLetteredAt: time.Now().UTC(),
+	}
+	if err := p.clients.deadLetter.Add(ctx, deadLetter); err != nil {
+		logger.V(logging.ERROR).Error(err, "CRITICAL: Failed to move job to the dead-letter queue", "jobID", task.ID)
+		return
+	}
+	metrics.RecordJobDeadLettered()
+	logger.V(logging.WARNING).Info("Moved job to the dead-letter queue", "jobID", task.ID, "attempts", task.Attempts, "cause", cause.Error())
+}
+
+// processJob reads the input file of the job, sends its lines to the inference client and writes the
+// output and error files. The job is processed until the end of its completion window (the job's SLO);
+// lines that were not processed by then are reported as expired in the error file.
+// If the processor shuts down first, the results of the lines processed so far are stored in a checkpoint
+// the next delivery of the job resumes from, and drained is true: the job must be put back to the queue.
+// While the job is paused, its lines are not dispatched; the worker holds the job until it is resumed.
+// When the batch is cancelled, no more line is dispatched and the job is finalized as cancelled with the results
+// of the lines processed until then. A job failed by an operator is stopped and its status isn't written anymore.
+func (p *Processor) processJob(ctx context.Context, workerId int, job *db.BatchJob) (drained bool) {
 	// logger and ctx
 	logger := klog.FromContext(ctx).WithValues("jobID", job.ID, "workerID", workerId)
+
+	// the job's span is a child of the span of the request that created the batch
+	ctx, span := tracing.StartSpan(tracing.NewContext(ctx, tracing.FromCarrier(job.TraceContext)), "process_batch")
+	if span != nil {
+		span.SetAttribute("batch_id", job.ID)
+		logger = logger.WithValues("traceID", span.TraceContext.TraceID(), "spanID", span.TraceContext.SpanID(),
+			"parentSpanID", span.ParentSpanID)
+	}
+	defer func() {
+		span.End(nil)
+	}()
 	jobctx := klog.NewContext(ctx, logger)
 
 	// metrics
 	startTime := time.Now()
 	metadata := batch.JobResultMetadata{}
+	jobResult := metrics.ResultSuccess
+	jobFailureReason := metrics.ReasonUnknown
+	tenantID := unknownTenant
 	defer func() {
-		// TODO:: get tenant id from job.Spec (should be included in the job object)
-		// job result / failure reason for metric
-		// TODO:: how to check if the failure is on user or system
-		tenantID := "unknown"
-		jobFailureReason := metrics.ReasonUnknown
-		jobResult := metrics.ResultSuccess
-
-		metrics.RecordJobProcessingDuration(time.Since(startTime), tenantID, metrics.GetSizeBucket(metadata.Total))
+		metrics.RecordJobProcessingDuration(time.Since(startTime), tenantID, labels.SizeBucket(metadata.Total),
+			tracing.SampledTraceID(jobctx))
 		metrics.RecordJobProcessed(jobResult, jobFailureReason)
 	}()
 
-	// status update - inprogress (TTL 24h)
-	p.clients.status.Set(jobctx, job.ID, 24*60*60, []byte(batch.StatusInProgress))
-	logger.V(logging.DEBUG).Info("Worker started job", "workerID", workerId, "jobID", job.ID)
+	spec, statusInfo, err := decodeJob(job)
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to decode job")
+		jobResult, jobFailureReason = metrics.ResultFailed, metrics.ReasonSystemError
+		return
+	}
+	tenantID = tenantLabel(spec)
+	logger = logger.WithValues("tenant", tenantID)
+	jobctx = klog.NewContext(jobctx, logger)
+	if statusInfo.Status.IsFinal() {
+		logger.V(logging.INFO).Info("Skipping job in final status", "status", statusInfo.Status)
+		return
+	}
+	defer func() {
+		span.SetAttribute("status", string(statusInfo.Status))
+	}()
+	defer func() {
+		if statusInfo.Status.IsFinal() {
+			metrics.RecordTenantBatch(tenantID, string(statusInfo.Status))
+		}
+	}()
 
-	// TODO:: file validating
-	p.clients.status.Set(jobctx, job.ID, 24*60*60, []byte(batch.StatusValidating))
+	// the batches of an inference target are only processed by the processors serving it
+	if !p.gateways.hasTarget(spec.InferenceTarget) {
+		err := fmt.Errorf("inference target %q is not configured", spec.InferenceTarget)
+		logger.V(logging.ERROR).Error(err, "Failed to route job")
+		jobResult, jobFailureReason = metrics.ResultFailed, metrics.ReasonSystemError
+		p.failJob(jobctx, job, statusInfo, err)
+		return
+	}
 
-	// TODO:: download file, streaming
-	// check if the method in the request is allowed
-	// check if the model in the request is allowed (optional)
-	// set total request num in result obj + init other fields
-	// goroutine per one line reading
-	// limit goroutines using config's max job concurrency
-	sem := make(chan struct{}, p.cfg.MaxJobConcurrency)
-	var wg sync.WaitGroup
-	var mu sync.Mutex // for metadata update
+	// a job drained on an earlier delivery resumes from its checkpoint
+	checkpoint, err := p.loadCheckpoint(jobctx, job.ID)
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to load checkpoint")
+		jobResult, jobFailureReason = metrics.ResultFailed, metrics.ReasonSystemError
+		p.failJob(jobctx, job, statusInfo, err)
+		return
+	}
+	defer func() {
+		if statusInfo.Status.IsFinal() {
+			p.clearCheckpoint(jobctx, job.ID, checkpoint)
+		}
+	}()
 
-	// TODO:: mock file lines
-	lines := []string{"req1", "req2", "req3"}
+	jobMetrics := newJobMetrics(spec, statusInfo)
+	jobMetrics.traceID = tracing.SampledTraceID(jobctx)
+	defer func() {
+		jobMetrics.observe(statusInfo)
+	}()
 
-	// result metadata init
-	metadata = batch.JobResultMetadata{
-		Total:     len(lines),
-		Succeeded: 0,
-		Failed:    0,
+	// the lines are no longer dispatched once the batch is cancelled, or failed by an operator
+	stopctx, stop := context.WithCancelCause(jobctx)
+	defer stop(nil)
+	cancelled := statusInfo.Status == openai.BatchStatusCancelling
+	if cancelled {
+		stop(errCancelled)
+	} else {
+		// status update - inprogress
+		now := time.Now().UTC().Unix()
+		statusInfo.Status = openai.BatchStatusInProgress
+		if statusInfo.InProgressAt == nil {
+			statusInfo.InProgressAt = &now
+		}
+		p.setJobStatus(jobctx, job, statusInfo)
 	}
+	logger.V(logging.DEBUG).Info("Worker started job", "workerID", workerId, "jobID", job.ID, "resumed", checkpoint != nil)
 
-	// TODO:: read lines + process (mockup)
-	lineChan := make(chan string)
-	go func() {
-		for _, l := range lines {
-			lineChan <- l
+	shardMaxLines, shardMaxBytes := p.cfg.OutputShardMaxLines, p.cfg.OutputShardMaxBytes
+	if !features.Enabled(features.ShardedOutputs) {
+		shardMaxLines, shardMaxBytes = 0, 0
+	}
+	results := newJobResults(job.ID, shardMaxLines, shardMaxBytes)
+	defer results.close()
+	defer recordUsage(tenantID, results)
+	progress := newJobProgress(p.cfg.ProgressEventLines)
+	if checkpoint != nil {
+		if err := p.restoreCheckpoint(jobctx, checkpoint, results, progress); err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to restore checkpoint")
+			jobResult, jobFailureReason = metrics.ResultFailed, metrics.ReasonSystemError
+			p.failJob(jobctx, job, statusInfo, err)
+			return
 		}
-		close(lineChan)
-	}()
+	}
 
-	for line := range lineChan {
-		// check context termination
-		select {
-		case <-jobctx.Done():
-			logger.V(logging.INFO).Info("Stopping line processing due to shutdown")
+	// lines are processed until the end of the completion window
+	linectx, cancel := context.WithDeadline(stopctx, job.SLO)
+	defer cancel()
+	windowElapsed := func() bool {
+		return jobctx.Err() == nil && errors.Is(context.Cause(linectx), context.DeadlineExceeded)
+	}
+
+	// request counts are reported while lines are processed
+	stopProgress := p.reportProgress(jobctx, job, statusInfo, progress)
+	stopProgressEvents := p.publishProgress(jobctx, job, progress)
+	var lines int64
+	if checkpoint != nil && checkpoint.Aborted != "" {
+		// a shard aborted the job, the results of the lines processed by the shards until then are kept
+		err = abortError(checkpoint.Aborted)
+	} else {
+		pause, stopEvents := p.watchEvents(jobctx, job.ID, func() { stop(errCancelled) })
+		lines, err = p.processLines(linectx, spec, results, progress, jobMetrics, windowElapsed, checkpoint, nil, pause)
+		stopEvents()
+	}
+	stopProgressEvents()
+	stopProgress()
+	metadata = progress.snapshot()
+	if errors.Is(err, errCancelled) {
+		logger.V(logging.INFO).Info("Stopped processing of cancelled job", "lines", lines)
+		err = nil
+	}
+	if errors.Is(err, errDraining) && jobctx.Err() == nil {
+		if err := p.saveCheckpoint(jobctx, job.ID, lines, results, metadata, checkpoint); err != nil {
+			// the lease is kept, so the job is reclaimed and its lines are processed again from the last checkpoint
+			logger.V(logging.ERROR).Error(err, "Failed to store checkpoint of drained job")
+			return
+		}
+		statusInfo.RequestCounts = requestCounts(metadata)
+		statusInfo.Usage = results.totalUsage()
+		p.updateJob(jobctx, job, statusInfo)
+		logger.V(logging.INFO).Info("Drained job", "lines", lines, "metadata", metadata)
+		return true
+	}
+	// an aborted job is finalized, so the results of the lines processed until then are available
+	var abortErr error
+	if errors.Is(err, errTooManyFailures) {
+		logger.V(logging.WARNING).Info("Aborting job", "reason", err.Error(), "metadata", metadata)
+		abortErr, err = err, nil
+	}
+	if err != nil {
+		if jobctx.Err() != nil {
+			logger.V(logging.INFO).Info("Stopping job processing, the lease of the job was lost")
 			return
-		case sem <- struct{}{}: // wait here if max concurrency is reached
+		}
+		logger.V(logging.ERROR).Error(err, "Failed to process input file")
+		jobResult, jobFailureReason = metrics.ResultFailed, metrics.ReasonSystemError
+		p.failJob(jobctx, job, statusInfo, err)
+		return
+	}
+	if jobctx.Err() != nil {
+		logger.V(logging.INFO).Info("Stopping job processing, the lease of the job was lost")
+		return
+	}
+
+	// the batch may have been cancelled, or failed by an operator, while its lines were processed
+	if p.refreshStatus(jobctx, job.ID, statusInfo) {
+		return
+	}
+	cancelled = cancelled || statusInfo.Status == openai.BatchStatusCancelling
+
+	// status update - finalizing, a cancelled batch stays cancelling until it's cancelled
+	if !cancelled {
+		now := time.Now().UTC().Unix()
+		statusInfo.Status = openai.BatchStatusFinalizing
+		statusInfo.FinalizingAt = &now
+		p.setJobStatus(jobctx, job, statusInfo)
+	}
+
+	_, aggregateSpan := tracing.StartSpan(jobctx, "aggregate_results")
+	err = results.finalize()
+	aggregateSpan.End(err)
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to finalize error file")
+		jobResult, jobFailureReason = metrics.ResultFailed, metrics.ReasonSystemError
+		p.failJob(jobctx, job, statusInfo, err)
+		return
+	}
+	ttl := job.TTL
+	if ttl <= 0 {
+		ttl = defaultResultFileTTL
+	}
+	outputShards, errorShards, manifestFileID, err := p.storeResults(jobctx, job.ID, spec.TenantID, results, spec.OutputFormat, ttl)
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to store result files")
+		jobResult, jobFailureReason = metrics.ResultFailed, metrics.ReasonSystemError
+		p.failJob(jobctx, job, statusInfo, err)
+		return
+	}
+	metrics.RecordTenantResultBytes(tenantID, resultBytes(outputShards, errorShards))
+	if manifestFileID != "" {
+		statusInfo.OutputManifestFileID = manifestFileID
+		statusInfo.OutputFileIDs = shardFileIDs(outputShards)
+		statusInfo.ErrorFileIDs = shardFileIDs(errorShards)
+	}
+
+	// the batch may have been cancelled, or failed by an operator, while its results were stored
+	if p.refreshStatus(jobctx, job.ID, statusInfo) {
+		return
+	}
+	cancelled = cancelled || statusInfo.Status == openai.BatchStatusCancelling
+
+	// final status decision
+	// openai batch set the job as completed even there are some failures
+	// failed status is used when the file is not valid or the batch request is not started properly
+	now := time.Now().UTC().Unix()
+	finalStatus := batch.StatusCompleted
+	if cancelled {
+		finalStatus = batch.StatusCancelled
+		statusInfo.CancelledAt = &now
+	} else if abortErr != nil {
+		finalStatus = batch.StatusFailed
+		statusInfo.FailedAt = &now
+		addBatchError(statusInfo, "too_many_failures", abortErr.Error())
+		jobResult, jobFailureReason = metrics.ResultFailed, metrics.ReasonUserError
+	} else if windowElapsed() {
+		finalStatus = batch.StatusExpired
+		statusInfo.ExpiredAt = &now
+		jobResult, jobFailureReason = metrics.ResultFailed, metrics.ReasonSystemError
+		logger.V(logging.WARNING).Info("Job expired before all lines were processed", "metadata", metadata)
+	} else {
+		statusInfo.CompletedAt = &now
+		if !metadata.Validate() {
+			logger.V(logging.WARNING).Info("Job finished with partial failures", "jobID", job.ID, "metadata", metadata)
+		}
+	}
+	statusInfo.Status = openai.BatchStatus(finalStatus)
+	if len(outputShards) > 0 {
+		statusInfo.OutputFileID = outputShards[0].FileID
+	}
+	if len(errorShards) > 0 {
+		statusInfo.ErrorFileID = errorShards[0].FileID
+	}
+	statusInfo.RequestCounts = requestCounts(metadata)
+	statusInfo.Usage = results.totalUsage()
+
+	// db update
+	p.setJobStatus(jobctx, job, statusInfo)
+	logger.V(logging.INFO).Info("Job Processed", "jobID", job.ID, "status", finalStatus)
+	return
+}
+
+// processLines reads the input file and processes its lines with bounded concurrency.
+// Once ctx is done, the remaining lines are not sent to inference; if the completion window elapsed
+// they are written to the error file as expired.
+// When the processor drains, no line is started anymore and errDraining is returned once the lines in flight
+// finished or were interrupted at the drain deadline; lines is the number of lines read until then.
+// The lines of checkpoint that already have a result are skipped. Only the lines of shard are processed when it
+// is not nil. No line is started while the job is paused, the lines in flight are completed.
+func (p *Processor) processLines(
+	ctx context.Context, spec *openai.BatchSpec, results *jobResults, progress *jobProgress, jobMetrics *jobMetrics,
+	windowElapsed func() bool, checkpoint *jobCheckpoint, shard *db.BatchJobShard, pause *pauseGate,
+) (lines int64, err error) {
+	logger := klog.FromContext(ctx)
+
+	fetchctx, fetchSpan := tracing.StartSpan(ctx, "fetch_input")
+	fetchSpan.SetAttribute("file_id", spec.InputFileID)
+	reader, prefetched, err := p.retrieveInput(fetchctx, batch.FileLocation(spec.TenantID, spec.InputFileID))
+	fetchSpan.SetAttribute("prefetched", prefetched)
+	fetchSpan.End(err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve input file %s: %w", spec.InputFileID, err)
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	// the lines in flight are interrupted at the drain deadline
+	ctx, stopDrain := p.withDrainDeadline(ctx)
+	defer stopDrain()
+
+	// the job is aborted when too many of its first lines fail with non-retryable errors
+	ctx, abort := context.WithCancelCause(ctx)
+	defer abort(nil)
+
+	// no line is started once draining
+	dispatchctx, stopDispatch := context.WithCancel(ctx)
+	defer stopDispatch()
+	defer context.AfterFunc(p.draining, stopDispatch)()
+	// the failures are sampled in the first lines of the job, processed by its first shard
+	var sample *failureSample
+	if shard == nil || shard.FirstLine == 0 {
+		sample = newFailureSample(p.cfg.AbortSampleLines, p.cfg.AbortFailureRatio)
+	}
+
+	// the slowest lines of the tail of the job are sent to the speculative gateway as well
+	stragglers := p.newStragglerTracker()
+	trackctx, stopTracking := context.WithCancel(ctx)
+	defer stopTracking()
+	go stragglers.run(trackctx)
+
+	sem := make(chan struct{}, p.cfg.MaxJobConcurrency)
+	var wg sync.WaitGroup
+	idle := func() bool {
+		return len(sem) == 0
+	}
+	record := func(err error) {
+		progress.record(err == nil)
+		if sample.add(err != nil && !isRetryableFailure(err)) {
+			abort(fmt.Errorf("%w: more than %d of the first %d requests failed with non-retryable errors",
+				errTooManyFailures, sample.maxFailures, sample.size))
+		}
+	}
+	expire := func(customID string) {
+		if err := results.writeError(customID, openai.BatchRequestErrorExpired,
+			"This request could not be executed before the completion window expired."); err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to write error line", "customID", customID)
+		}
+		progress.record(false)
+	}
+
+	// handleLine dispatches a line, returning an error if processing stopped due to shutdown or the job was aborted.
+	handleLine := func(line []byte) error {
+		req := &openai.BatchRequestInput{}
+		unmarshalErr := json.Unmarshal(line, req)
+		// the lines read before the checkpoint were processed on an earlier delivery, unless they were interrupted
+		if checkpoint != nil && lines < checkpoint.Lines && (unmarshalErr != nil || results.hasResult(req.CustomID)) {
+			return nil
+		}
+		if p.isDraining() {
+			return errDraining
+		}
+
+		progress.addLine()
+		if unmarshalErr != nil {
+			if err := results.writeError(req.CustomID, openai.BatchRequestErrorInvalidLine, unmarshalErr.Error()); err != nil {
+				logger.V(logging.ERROR).Error(err, "Failed to write error line")
+			}
+			record(unmarshalErr)
+			return nil
+		}
+
+		// wait here while the job is paused, while the memory use is high and a line of the job is in flight,
+		// or if max concurrency is reached; the next line isn't read until then
+		if !pause.wait(dispatchctx) || !p.memory.wait(dispatchctx, idle) || !acquire(dispatchctx, sem) {
+			// the line was never started
+			if ctx.Err() == nil {
+				// the processor is draining, the line is left for the next delivery
+				progress.removeLine()
+				return errDraining
+			}
+			if !windowElapsed() {
+				return context.Cause(ctx)
+			}
+			expire(req.CustomID)
+			return nil
 		}
 		wg.Add(1)
-		go func(l string) {
+		go func() {
+			line := stragglers.start()
 			defer func() {
+				stragglers.done(line)
 				<-sem
 				wg.Done()
 			}()
-
-			// check again for signal in the goroutine
-			select {
-			case <-jobctx.Done():
+			err := p.processLine(ctx, req, results, spec, line)
+			if err != nil && ctx.Err() != nil {
+				// the line was interrupted, it expired unless the processing stopped due to shutdown or abort
+				if windowElapsed() {
+					expire(req.CustomID)
+				}
 				return
-			default:
 			}
-			// TODO:: line parsing
-			// TODO:: check allowed methods
-			// TODO:: request validation
+			jobMetrics.lineProcessed(requestModel(req))
+			record(err)
+		}()
+		return nil
+	}
 
-			// mock request
-			mockRequest := &batch.InferenceRequest{}
-			result, err := p.clients.inference.Generate(jobctx, mockRequest)
+	readLines := func() error {
+		br := bufio.NewReader(reader)
+		for {
+			line, readErr := br.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				if shard != nil && lines >= shard.FirstLine+shard.Lines {
+					return nil
+				}
+				if shard == nil || lines >= shard.FirstLine {
+					if err := handleLine(line); err != nil {
+						return err
+					}
+				}
+				lines++
+			}
+			if readErr == io.EOF {
+				return nil
+			}
+			if readErr != nil {
+				return fmt.Errorf("failed to read input file %s: %w", spec.InputFileID, readErr)
+			}
+		}
+	}
 
-			// shared resources (metadata / totaljoblines) lock
-			mu.Lock()
-			defer mu.Unlock()
+	// lines are parsed as they are dispatched, so the span includes the time waiting for free dispatch slots
+	_, parseSpan := tracing.StartSpan(ctx, "parse_input")
+	err = readLines()
+	parseSpan.SetAttribute("lines", lines)
+	parseSpan.End(err)
+	if err == nil {
+		// all the lines were dispatched, the next job is prefetched while the lines in flight complete
+		stragglers.allDispatched()
+		p.prefetchNext()
+	}
+	wg.Wait()
+	if err != nil {
+		return lines, err
+	}
+	// lines interrupted at the drain deadline are processed on the next delivery
+	if err := context.Cause(ctx); errors.Is(err, errTooManyFailures) || errors.Is(err, errDraining) {
+		return lines, err
+	}
+	return lines, nil
+}
 
-			if err != nil {
-				p.handleError(jobctx, err)
-				metadata.Failed++
-				return
+// isRetryableFailure reports whether a line failed with an error that may succeed if the line is sent again,
+// as opposed to an error caused by the line itself.
+func isRetryableFailure(err error) bool {
+	var inferenceErr *batch.InferenceError
+	return errors.As(err, &inferenceErr) && inferenceErr.IsRetryable()
+}
+
+// acquire takes a slot of the semaphore, returning false if ctx is done first.
+func acquire(ctx context.Context, sem chan struct{}) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case sem <- struct{}{}:
+		return true
+	}
+}
+
+// processLine sends a single request to the inference gateway serving its model for the inference target of the
+// batch and writes its result. Requests failing with a retryable error are attempted again as allowed by the
+// retry settings of the gateway, overridden by the batch's retry policy.
+// Once the line becomes a straggler of its job, its attempts are sent to the speculative gateway as well.
+// A request identical to one whose response is cached reuses the response without being sent.
+// It returns an error if the request failed; the failure is written to the error file
+// unless it was caused by ctx being done.
+func (p *Processor) processLine(
+	ctx context.Context, req *openai.BatchRequestInput, results *jobResults, spec *openai.BatchSpec,
+	line *inflightLine,
+) (err error) {
+	// the span of the line is the parent of the spans of the gateway handling its requests
+	ctx, span := tracing.StartSpan(ctx, "inference")
+	span.SetAttribute("custom_id", req.CustomID)
+	defer func() {
+		span.End(err)
+	}()
+
+	logger := klog.FromContext(ctx)
+	params := map[string]interface{}{}
+	if err := json.Unmarshal(req.Body, &params); err != nil {
+		results.writeError(req.CustomID, openai.BatchRequestErrorInvalidLine, err.Error())
+		return err
+	}
+	model, _ := params["model"].(string)
+	span.SetAttribute("model", model)
+	target := spec.InferenceTarget
+	gateway := p.gateways.route(target, model)
+	if gateway == nil {
+		err := fmt.Errorf("model %q is not served by inference target %q", model, target)
+		results.writeError(req.CustomID, openai.BatchRequestErrorInvalidLine, err.Error())
+		return err
+	}
+	// identical requests reuse the cached response
+	dedupKey := p.dedupKey(spec.TenantID, gateway, req.URL, params)
+	if cached := p.cachedResponse(ctx, dedupKey); cached != nil {
+		span.SetAttribute("cached", true)
+		metrics.RecordDedupCacheHit(model)
+		return results.writeResponse(req.CustomID, &openai.BatchRequestResponse{
+			StatusCode: http.StatusOK,
+			RequestID:  newRequestID(),
+			Body:       cached,
+		})
+	}
+	// the image files referenced by the request are sent inline
+	if err := p.inlineImages(ctx, spec.TenantID, params, len(req.Body)); err != nil {
+		results.writeError(req.CustomID, openai.BatchRequestErrorInvalidLine, err.Error())
+		return err
+	}
+
+	inferenceReq := &batch.InferenceRequest{
+		RequestID: req.CustomID,
+		Model:     model,
+		Params:    params,
+		Endpoint:  req.URL,
+	}
+	span.SetAttribute("gateway", gateway.name)
+	retry := p.newRetryPolicy(gateway, spec.RetryPolicy)
+	timeout := p.lineTimeout(req, params)
+	tokens := estimateTokens(params)
+	rateLimiter := p.rateLimiter.Load()
+	for attempt := 1; ; attempt++ {
+		span.SetAttribute("attempts", attempt)
+		waitStart := time.Now()
+		if !p.saturation.wait(ctx, endpointName(target, model)) {
+			return ctx.Err()
+		}
+		if !rateLimiter.wait(ctx, target, model, tokens) {
+			return ctx.Err()
+		}
+		sentAt := time.Now()
+		result, inferenceErr := p.generateSpeculative(ctx, gateway, inferenceReq, timeout, line)
+		inferenceResult := metrics.ResultSuccess
+		if inferenceErr != nil {
+			inferenceResult = metrics.ResultFailed
+		}
+		elapsed := time.Since(sentAt)
+		metrics.RecordInferenceDuration(elapsed, model, inferenceResult, tracing.SampledTraceID(ctx))
+		slowop.Observe(ctx, slowop.Inference, "generate", elapsed, "model", model, "gateway", gateway.name,
+			"customID", req.CustomID, "attempt", attempt, "result", inferenceResult, "wait", sentAt.Sub(waitStart))
+		if inferenceErr == nil {
+			p.inferenceStats.record(nil)
+			p.saturation.record(endpointName(target, model), nil)
+			if used, ok := usedTokens(result.Response); ok {
+				rateLimiter.adjust(target, model, tokens, used)
 			}
+			p.cacheResponse(ctx, dedupKey, result.Response)
+			return p.handleResponse(ctx, req, result, results)
+		}
+		// a failed request is assumed not to have used tokens
+		rateLimiter.adjust(target, model, tokens, 0)
+		if ctx.Err() != nil {
+			return inferenceErr
+		}
+		if pause := p.saturation.record(endpointName(target, model), inferenceErr); pause > 0 {
+			logger.V(logging.WARNING).Info("Model saturated, pausing dispatch", "model", model, "target", target, "pause", pause)
+		}
+		p.inferenceStats.record(inferenceErr)
 
-			if err := p.handleResponse(jobctx, result); err != nil {
-				metadata.Failed++
-			} else {
-				metadata.Succeeded++
+		if retry.retries(attempt, inferenceErr) {
+			backoff := retry.backoff(attempt)
+			logger.V(logging.DEBUG).Info("Retrying inference request",
+				"customID", req.CustomID, "attempt", attempt, "backoff", backoff, "error", redact.String(inferenceErr.Message))
+			metrics.RecordRequestRetry(model)
+			if !sleep(ctx, backoff) {
+				return ctx.Err()
 			}
-		}(line)
+			continue
+		}
 
+		p.handleError(ctx, inferenceErr)
+		metrics.RecordJobError(model)
+		metrics.RecordInferenceFailure(model, string(inferenceErr.Category))
+		if err := results.writeFailedResponse(req.CustomID, failedResponse(inferenceErr)); err != nil {
+			logger.V(logging.ERROR).Error(err, "Failed to write error line")
+		}
+		return inferenceErr
 	}
-	wg.Wait()
+}
 
-	// final status decision
-	// TODO:: final status decision (should be included in the job object)
-	// openai batch set the job as completed even there are some failures - should we do the same?
-	// failed status is used when the file is not valid or the batch request is not started properly
-	finalStatus := batch.StatusCompleted
-	if !metadata.Validate() {
-		logger.V(logging.WARNING).Info("Job finished with partial failures", "jobID", job.ID, "metadata", metadata)
-		// TODO:: finalStatus = batch.Failed
+// generate sends one attempt of an inference request once a dispatch slot is available, failing it with a
+// gateway timeout error if no response is received within timeout.
+// The time left until the deadline of ctx (the end of the job's completion window) is sent as the SLO header.
+func (p *Processor) generate(
+	ctx context.Context, client batch.InferenceClient, req *batch.InferenceRequest, timeout time.Duration,
+) (*batch.InferenceResponse, *batch.InferenceError) {
+	deadline, _ := ctx.Deadline()
+	if !p.dispatcher.acquire(ctx, deadline) {
+		return nil, &batch.InferenceError{Category: batch.ErrCategoryUnknown, Message: ctx.Err().Error(), RawError: ctx.Err()}
 	}
+	defer p.dispatcher.release()
 
-	// status update
-	p.clients.status.Set(jobctx, job.ID, 24*60*60, []byte(batch.StatusFinalizing))
+	req.Headers = nil
+	if !deadline.IsZero() {
+		req.Headers = map[string]string{
+			batch.SLOTTFTHeader: strconv.FormatInt(max(time.Until(deadline).Milliseconds(), 1), 10),
+		}
+	}
 
-	// db update (job.Status should be updated before this line)
-	if err := p.clients.database.Update(jobctx, job); err != nil {
-		logger.V(logging.ERROR).Error(err, "Failed to update final job status in DB", "jobID", job.ID)
+	attemptctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, inferenceErr := client.Generate(attemptctx, req)
+	if inferenceErr != nil && ctx.Err() == nil && errors.Is(attemptctx.Err(), context.DeadlineExceeded) {
+		return nil, &batch.InferenceError{
+			Category:   batch.ErrCategoryServer,
+			Message:    fmt.Sprintf("the request timed out after %v", timeout),
+			RawError:   inferenceErr,
+			StatusCode: http.StatusGatewayTimeout,
+		}
+	}
+	return result, inferenceErr
+}
+
+// failedResponse returns the response line of a request that failed with an inference error. The body is the
+// upstream error response, or an OpenAI error object built from the error when there is no upstream body.
+func failedResponse(inferenceErr *batch.InferenceError) *openai.BatchRequestResponse {
+	statusCode := inferenceErr.HTTPStatusCode()
+	body := json.RawMessage(inferenceErr.Body)
+	if !json.Valid(body) {
+		body, _ = json.Marshal(openai.ErrorResponse{
+			Error: openai.NewAPIError(statusCode, "", inferenceErr.Message, nil),
+		})
+	}
+	return &openai.BatchRequestResponse{
+		StatusCode: statusCode,
+		RequestID:  newRequestID(),
+		Body:       body,
 	}
-	p.clients.status.Set(jobctx, job.ID, 24*60*60, []byte(finalStatus))
-	logger.V(logging.INFO).Info("Job Processed", "jobID", job.ID, "status", finalStatus)
 }
 
 func (p *Processor) handleError(ctx context.Context, err error) {
-	// TODO:: error handling.
 	logger := klog.FromContext(ctx)
-	logger.V(logging.ERROR).Error(err, "Inference request failed")
+	logger.V(logging.ERROR).Error(redact.Error(err), "Inference request failed")
 }
 
-func (p *Processor) handleResponse(ctx context.Context, inferenceResponse *batch.InferenceResponse) error {
-	// TODO:: response handling + writing line to the output file ...
+func (p *Processor) handleResponse(ctx context.Context, req *openai.BatchRequestInput, inferenceResponse *batch.InferenceResponse, results *jobResults) error {
 	logger := klog.FromContext(ctx)
-	logger.V(logging.DEBUG).Info("Handling response")
-	return nil
+	logger.V(logging.DEBUG).Info("Handling response", "customID", req.CustomID)
+
+	return results.writeResponse(req.CustomID, &openai.BatchRequestResponse{
+		StatusCode: http.StatusOK,
+		RequestID:  inferenceResponse.RequestID,
+		Body:       inferenceResponse.Response,
+	})
 }
 
-// Stop gracefully stops the processor, waiting for all workers to finish.
+// decodeJob decodes the static and dynamic parts of the job.
+func decodeJob(job *db.BatchJob) (*openai.BatchSpec, *openai.BatchStatusInfo, error) {
+	spec := &openai.BatchSpec{}
+	if err := json.Unmarshal(job.Spec, spec); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal job spec: %w", err)
+	}
+	statusInfo := &openai.BatchStatusInfo{}
+	if len(job.Status) > 0 {
+		if err := json.Unmarshal(job.Status, statusInfo); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal job status: %w", err)
+		}
+	}
+	return spec, statusInfo, nil
+}
+
+// updateJob persists the dynamic part of the job to the database.
+func (p *Processor) updateJob(ctx context.Context, job *db.BatchJob, statusInfo *openai.BatchStatusInfo) {
+	logger := klog.FromContext(ctx)
+	data, err := json.Marshal(statusInfo)
+	if err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to marshal job status", "jobID", job.ID)
+		return
+	}
+	job.Status = data
+	if err := p.clients.database.Update(ctx, job); err != nil {
+		logger.V(logging.ERROR).Error(err, "Failed to update job status in DB", "jobID", job.ID)
+	}
+}
+
+// setJobStatus stores the job moved to the status of statusInfo, and publishes the change to the event sinks.
+func (p *Processor) setJobStatus(ctx context.Context, job *db.BatchJob, statusInfo *openai.BatchStatusInfo) {
+	p.updateJob(ctx, job, statusInfo)
+	p.clients.status.Set(ctx, job.ID, jobStatusTTL, []byte(statusInfo.Status))
+	p.events.Publish(notify.NewEvent(job.ID, statusInfo))
+}
+
+// failJob marks the job as failed with the given error.
+func (p *Processor) failJob(ctx context.Context, job *db.BatchJob, statusInfo *openai.BatchStatusInfo, cause error) {
+	now := time.Now().UTC().Unix()
+	statusInfo.Status = openai.BatchStatusFailed
+	statusInfo.FailedAt = &now
+	addBatchError(statusInfo, "processing_failed", cause.Error())
+	p.setJobStatus(ctx, job, statusInfo)
+}
+
+// addBatchError adds an error to the errors of the batch.
+func addBatchError(statusInfo *openai.BatchStatusInfo, code, message string) {
+	if statusInfo.Errors == nil {
+		statusInfo.Errors = &openai.BatchErrors{Object: "list"}
+	}
+	statusInfo.Errors.Data = append(statusInfo.Errors.Data, openai.BatchError{
+		Code:    code,
+		Message: message,
+	})
+}
+
+// Stop gracefully stops the processor, waiting for all workers to finish and for the pending events to be
+// forwarded to the event sinks, for at most EventFlushTimeout.
 func (p *Processor) Stop(ctx context.Context) {
 	logger := klog.FromContext(ctx)
 	p.workerPool.WaitAll()
 	logger.V(logging.INFO).Info("All workers have finished")
+
+	flushctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.cfg.EventFlushTimeout)
+	defer cancel()
+	p.events.Close(flushctx)
 }
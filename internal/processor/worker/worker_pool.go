@@ -14,47 +14,166 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-// file explains;;
+// this file contains the worker pool, which bounds the number of jobs processed concurrently.
 
 package worker
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
-// worker id is integer that starts with 1 to the max number of worker
+// idleCheckInterval is the interval at which WaitIdle checks whether the workers were released.
+const idleCheckInterval = 50 * time.Millisecond
+
+// worker id is integer that starts with 1 to the max number of worker.
+// at most limit workers are acquired at a time; the limit can be changed between 1 and the max number of workers.
+// The max number of workers can be changed with Resize, e.g. when the configuration is reloaded.
 type WorkerPool struct {
-	workerIds chan int
+	mu       sync.Mutex
+	free     []int        // ids of the workers not acquired
+	acquired map[int]bool // ids of the workers acquired
+	busy     int
+	limit    int
+	size     int // the max number of workers
+
+	// available is signaled when a worker may have become available
+	available chan struct{}
 	wg        sync.WaitGroup
 }
 
 func NewWorkerPool(maxWorkers int) *WorkerPool {
-	ids := make(chan int, maxWorkers)
-	for i := 1; i <= maxWorkers; i++ {
-		ids <- i // fill worker ids first
+	free := make([]int, 0, maxWorkers)
+	for i := maxWorkers; i >= 1; i-- {
+		free = append(free, i) // fill worker ids first, lowest id is acquired first
 	}
 	return &WorkerPool{
-		workerIds: ids,
+		free:      free,
+		acquired:  make(map[int]bool, maxWorkers),
+		limit:     maxWorkers,
+		size:      maxWorkers,
+		available: make(chan struct{}, 1),
 	}
 }
 
 // return worker id and bool showing if the acquisition was succesful
 // id 0 means the worker was not acquired
 func (wp *WorkerPool) TryAcquire() (int, bool) {
-	select {
-	case id := <-wp.workerIds:
-		wp.wg.Add(1)
-		return id, true
-	default:
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	if wp.busy >= wp.limit || len(wp.free) == 0 {
 		return 0, false
 	}
+	id := wp.free[len(wp.free)-1]
+	wp.free = wp.free[:len(wp.free)-1]
+	wp.acquired[id] = true
+	wp.busy++
+	wp.wg.Add(1)
+	return id, true
+}
+
+// Acquire waits until a worker is available, returning false if ctx is done first.
+func (wp *WorkerPool) Acquire(ctx context.Context) (int, bool) {
+	for {
+		if id, ok := wp.TryAcquire(); ok {
+			return id, true
+		}
+		select {
+		case <-ctx.Done():
+			return 0, false
+		case <-wp.available:
+		}
+	}
 }
 
 func (wp *WorkerPool) Release(id int) {
-	wp.workerIds <- id
+	wp.mu.Lock()
+	delete(wp.acquired, id)
+	if id <= wp.size { // the workers above the max number of workers are removed once released
+		wp.free = append(wp.free, id)
+	}
+	wp.busy--
+	wp.mu.Unlock()
 	wp.wg.Done()
+	wp.notify()
+}
+
+// SetLimit changes the number of workers that may be acquired at a time, bounded by 1 and the max number of workers.
+// Lowering the limit doesn't stop busy workers; no worker is acquired until enough of them are released.
+// Returns the new limit.
+func (wp *WorkerPool) SetLimit(limit int) int {
+	wp.mu.Lock()
+	limit = max(1, min(limit, wp.size))
+	wp.limit = limit
+	wp.mu.Unlock()
+	wp.notify()
+	return limit
+}
+
+// Resize changes the max number of workers, lowering the limit to it if needed. Lowering the max number
+// of workers doesn't stop busy workers; the workers above it are removed once released.
+// Returns the new limit.
+func (wp *WorkerPool) Resize(maxWorkers int) int {
+	wp.mu.Lock()
+	maxWorkers = max(1, maxWorkers)
+	free := wp.free[:0]
+	for _, id := range wp.free {
+		if id <= maxWorkers {
+			free = append(free, id)
+		}
+	}
+	for id := wp.size + 1; id <= maxWorkers; id++ {
+		if !wp.acquired[id] {
+			free = append([]int{id}, free...) // lowest id is acquired first
+		}
+	}
+	wp.free = free
+	wp.size = maxWorkers
+	wp.limit = min(wp.limit, maxWorkers)
+	limit := wp.limit
+	wp.mu.Unlock()
+	wp.notify()
+	return limit
+}
+
+// Size returns the max number of workers.
+func (wp *WorkerPool) Size() int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	return wp.size
+}
+
+// Stats returns the number of acquired workers and the current limit.
+func (wp *WorkerPool) Stats() (busy, limit int) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	return wp.busy, wp.limit
+}
+
+func (wp *WorkerPool) notify() {
+	select {
+	case wp.available <- struct{}{}:
+	default:
+	}
 }
 
 func (wp *WorkerPool) WaitAll() {
 	wp.wg.Wait()
 }
+
+// WaitIdle waits until no worker is acquired, returning false if ctx is done first.
+func (wp *WorkerPool) WaitIdle(ctx context.Context) bool {
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+	for {
+		if busy, _ := wp.Stats(); busy == 0 {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,751 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// this file contains unit tests for the worker job processing.
+package worker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	db "github.com/llm-d-incubation/batch-gateway/internal/database/api"
+	mockapi "github.com/llm-d-incubation/batch-gateway/internal/database/mock"
+	fsapi "github.com/llm-d-incubation/batch-gateway/internal/files_store/fs"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/config"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/metrics"
+	"github.com/llm-d-incubation/batch-gateway/internal/processor/notify"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/batch"
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/features"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/tracing"
+)
+
+func TestMain(m *testing.M) {
+	if err := metrics.InitMetrics(*config.NewConfig()); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to init metrics: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeInferenceClient echoes the request after an optional delay.
+type fakeInferenceClient struct {
+	delay time.Duration
+
+	mu      sync.Mutex
+	traceID string // trace ID of the last request
+	sloTTFT string // SLO header of the last request
+}
+
+func (c *fakeInferenceClient) Generate(ctx context.Context, req *batch.InferenceRequest) (*batch.InferenceResponse, *batch.InferenceError) {
+	c.mu.Lock()
+	if tc := tracing.FromContext(ctx); tc != nil {
+		c.traceID = tc.TraceID()
+	}
+	c.sloTTFT = req.Headers[batch.SLOTTFTHeader]
+	c.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return nil, &batch.InferenceError{Category: batch.ErrCategoryServer, Message: ctx.Err().Error(), RawError: ctx.Err()}
+	case <-time.After(c.delay):
+	}
+	if req.Model == "bad-model" {
+		return nil, &batch.InferenceError{Category: batch.ErrCategoryInvalidReq, Message: "model not found", StatusCode: 404}
+	}
+	return &batch.InferenceResponse{
+		RequestID: "req-" + req.RequestID,
+		Response:  []byte(`{"object":"chat.completion"}`),
+	}, nil
+}
+
+// spanRecorder collects the exported spans.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*tracing.Span
+}
+
+func (r *spanRecorder) Export(span *tracing.Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func TestIdleBackoff(t *testing.T) {
+	var waits []time.Duration
+	wait := time.Duration(0)
+	for i := 0; i < 5; i++ {
+		wait = idleBackoff(wait, 100*time.Millisecond, time.Second)
+		waits = append(waits, wait)
+	}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	if !slices.Equal(waits, want) {
+		t.Errorf("idle backoff = %v, want %v", waits, want)
+	}
+	// a job resets the backoff
+	if wait := idleBackoff(0, 100*time.Millisecond, time.Second); wait != 100*time.Millisecond {
+		t.Errorf("idle backoff after a job = %v, want 100ms", wait)
+	}
+	// the maximum wins over the minimum when it was lowered
+	if wait := idleBackoff(0, 100*time.Millisecond, 50*time.Millisecond); wait != 50*time.Millisecond {
+		t.Errorf("idle backoff = %v, want 50ms", wait)
+	}
+}
+
+type testEnv struct {
+	processor *Processor
+	dbClient  *mockapi.MockBatchDBClient
+	fileDB    *mockapi.MockBatchFileDBClient
+	files     *fsapi.FSFilesClient
+}
+
+func setupProcessorForTest(t *testing.T, concurrency int, inference batch.InferenceClient) *testEnv {
+	t.Helper()
+	files, err := fsapi.NewFSFilesClient(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create files client: %v", err)
+	}
+	env := &testEnv{
+		dbClient: mockapi.NewMockBatchDBClient(),
+		fileDB:   mockapi.NewMockBatchFileDBClient(),
+		files:    files,
+	}
+	clients := NewProcessorClients(env.dbClient, env.fileDB, mockapi.NewMockBatchPriorityQueueClient(), mockapi.NewMockBatchDeadLetterClient(),
+		mockapi.NewMockBatchStatusClient(), mockapi.NewMockBatchEventChannelClient(), files, inference)
+	cfg := config.NewConfig()
+	cfg.MaxJobConcurrency = concurrency
+	env.processor = NewProcessor(cfg, &clients)
+	return env
+}
+
+func (env *testEnv) storeJob(t *testing.T, jobID string, slo time.Time, models ...string) *db.BatchJob {
+	t.Helper()
+	return env.storeTenantJob(t, "", jobID, slo, models...)
+}
+
+// storeTenantJob stores a job of the tenant, whose input file is stored in the files of the tenant.
+func (env *testEnv) storeTenantJob(t *testing.T, tenantID, jobID string, slo time.Time, models ...string) *db.BatchJob {
+	t.Helper()
+	var sb strings.Builder
+	for i, model := range models {
+		fmt.Fprintf(&sb, `{"custom_id":"req-%d","method":"POST","url":"/v1/chat/completions","body":{"model":%q}}`+"\n", i, model)
+	}
+	inputFileID := "file_" + jobID
+	if _, err := env.files.Store(context.Background(), batch.FileLocation(tenantID, inputFileID), 0, strings.NewReader(sb.String())); err != nil {
+		t.Fatalf("Failed to store input file: %v", err)
+	}
+
+	spec, _ := json.Marshal(openai.BatchSpec{
+		Object:           "batch",
+		TenantID:         tenantID,
+		Endpoint:         openai.EndpointChatCompletions,
+		InputFileID:      inputFileID,
+		CompletionWindow: "24h",
+	})
+	status, _ := json.Marshal(openai.BatchStatusInfo{Status: openai.BatchStatusValidating})
+	job := &db.BatchJob{ID: jobID, SLO: slo, TTL: 86400, Spec: spec, Status: status}
+	env.dbClient.Store(context.Background(), job)
+	return job
+}
+
+func (env *testEnv) getStatus(t *testing.T, jobID string) *openai.BatchStatusInfo {
+	t.Helper()
+	jobs, _, err := env.dbClient.Get(context.Background(), []string{jobID}, nil, db.TagsLogicalCondNa, true, 0, 1)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("Failed to get job %s: %v", jobID, err)
+	}
+	statusInfo := &openai.BatchStatusInfo{}
+	if err := json.Unmarshal(jobs[0].Status, statusInfo); err != nil {
+		t.Fatalf("Failed to unmarshal job status: %v", err)
+	}
+	return statusInfo
+}
+
+func (env *testEnv) readResultFile(t *testing.T, fileID string) []openai.BatchRequestOutput {
+	t.Helper()
+	if fileID == "" {
+		return nil
+	}
+	if files, _, _ := env.fileDB.Get(context.Background(), []string{fileID}, nil, db.TagsLogicalCondNa, 0, 1); len(files) != 1 {
+		t.Errorf("metadata of file %s was not stored", fileID)
+	}
+	reader, _, err := env.files.Retrieve(context.Background(), fileID)
+	if err != nil {
+		t.Fatalf("Failed to retrieve file %s: %v", fileID, err)
+	}
+	defer reader.(io.Closer).Close()
+
+	var lines []openai.BatchRequestOutput
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var line openai.BatchRequestOutput
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Failed to unmarshal result line: %v", err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestProcessJob(t *testing.T) {
+
+	t.Run("Completed", func(t *testing.T) {
+		env := setupProcessorForTest(t, 2, &fakeInferenceClient{})
+		job := env.storeJob(t, "batch-1", time.Now().Add(time.Hour), "m1", "m1", "bad-model")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		status := env.getStatus(t, job.ID)
+		if status.Status != openai.BatchStatusCompleted || status.CompletedAt == nil {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusCompleted)
+		}
+		want := openai.BatchRequestCounts{Total: 3, Completed: 2, Failed: 1}
+		if status.RequestCounts != want {
+			t.Errorf("RequestCounts = %+v, want %+v", status.RequestCounts, want)
+		}
+		if output := env.readResultFile(t, status.OutputFileID); len(output) != 2 || output[0].Response == nil {
+			t.Errorf("unexpected output file lines: %+v", output)
+		}
+		errs := env.readResultFile(t, status.ErrorFileID)
+		if len(errs) != 1 || errs[0].CustomID != "req-2" || errs[0].Response == nil || errs[0].Error != nil {
+			t.Fatalf("unexpected error file lines: %+v", errs)
+		}
+		if errs[0].Response.StatusCode != 404 {
+			t.Errorf("StatusCode = %v, want 404", errs[0].Response.StatusCode)
+		}
+		var body openai.ErrorResponse
+		if err := json.Unmarshal(errs[0].Response.Body, &body); err != nil || body.Error.Message != "model not found" {
+			t.Errorf("unexpected error body: %s", errs[0].Response.Body)
+		}
+	})
+
+	t.Run("AbortedOnFailureRatio", func(t *testing.T) {
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+		env.processor.cfg.AbortFailureRatio = 0.3
+		env.processor.cfg.AbortSampleLines = 4
+		job := env.storeJob(t, "batch-1", time.Now().Add(time.Hour), "m1", "bad-model", "bad-model", "m1", "m1", "m1")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		status := env.getStatus(t, job.ID)
+		if status.Status != openai.BatchStatusFailed || status.FailedAt == nil {
+			t.Fatalf("Status = %v, want %v", status.Status, openai.BatchStatusFailed)
+		}
+		if status.Errors == nil || len(status.Errors.Data) != 1 || status.Errors.Data[0].Code != "too_many_failures" {
+			t.Errorf("unexpected batch errors: %+v", status.Errors)
+		}
+		if status.RequestCounts.Completed != 1 || status.RequestCounts.Failed != 2 {
+			t.Errorf("RequestCounts = %+v, want 1 completed and 2 failed", status.RequestCounts)
+		}
+		// the results of the lines processed before the abort are kept
+		if errs := env.readResultFile(t, status.ErrorFileID); len(errs) != 2 {
+			t.Errorf("error file lines = %d, want 2", len(errs))
+		}
+	})
+
+	t.Run("FailureRatioNotExceeded", func(t *testing.T) {
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+		env.processor.cfg.AbortFailureRatio = 0.3
+		env.processor.cfg.AbortSampleLines = 4
+		// the failures past the first lines don't abort the job
+		job := env.storeJob(t, "batch-1", time.Now().Add(time.Hour), "m1", "bad-model", "m1", "m1", "bad-model", "bad-model")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		status := env.getStatus(t, job.ID)
+		if status.Status != openai.BatchStatusCompleted {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusCompleted)
+		}
+		want := openai.BatchRequestCounts{Total: 6, Completed: 3, Failed: 3}
+		if status.RequestCounts != want {
+			t.Errorf("RequestCounts = %+v, want %+v", status.RequestCounts, want)
+		}
+	})
+
+	t.Run("SLOHeader", func(t *testing.T) {
+		inference := &fakeInferenceClient{}
+		env := setupProcessorForTest(t, 1, inference)
+		job := env.storeJob(t, "batch-slo", time.Now().Add(time.Hour), "m1")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		ttft, err := strconv.ParseInt(inference.sloTTFT, 10, 64)
+		if err != nil || ttft <= 0 || ttft > time.Hour.Milliseconds() {
+			t.Errorf("%s = %q, want the time to the batch deadline", batch.SLOTTFTHeader, inference.sloTTFT)
+		}
+	})
+
+	t.Run("ExpiredInQueue", func(t *testing.T) {
+		env := setupProcessorForTest(t, 2, &fakeInferenceClient{})
+		job := env.storeJob(t, "batch-2", time.Now().Add(-time.Minute), "m1", "m1")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		status := env.getStatus(t, job.ID)
+		if status.Status != openai.BatchStatusExpired || status.ExpiredAt == nil {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusExpired)
+		}
+		if status.OutputFileID != "" {
+			t.Errorf("OutputFileID = %v, want empty", status.OutputFileID)
+		}
+		errs := env.readResultFile(t, status.ErrorFileID)
+		if len(errs) != 2 {
+			t.Fatalf("expected 2 error lines, got %d", len(errs))
+		}
+		for _, line := range errs {
+			if line.Error == nil || line.Error.Code != openai.BatchRequestErrorExpired {
+				t.Errorf("unexpected error line: %+v", line)
+			}
+		}
+	})
+
+	t.Run("ExpiredWhileProcessing", func(t *testing.T) {
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{delay: 50 * time.Millisecond})
+		job := env.storeJob(t, "batch-3", time.Now().Add(75*time.Millisecond), "m1", "m1", "m1", "m1")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		status := env.getStatus(t, job.ID)
+		if status.Status != openai.BatchStatusExpired {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusExpired)
+		}
+		if status.RequestCounts.Total != 4 || status.RequestCounts.Completed+status.RequestCounts.Failed != 4 {
+			t.Errorf("unexpected RequestCounts: %+v", status.RequestCounts)
+		}
+		if status.RequestCounts.Completed == 0 || status.RequestCounts.Failed == 0 {
+			t.Errorf("expected both completed and expired lines, got %+v", status.RequestCounts)
+		}
+		output := env.readResultFile(t, status.OutputFileID)
+		errs := env.readResultFile(t, status.ErrorFileID)
+		if len(output)+len(errs) != 4 {
+			t.Errorf("expected 4 result lines, got %d output and %d error lines", len(output), len(errs))
+		}
+	})
+
+	t.Run("ShardedOutput", func(t *testing.T) {
+		env := setupProcessorForTest(t, 2, &fakeInferenceClient{})
+		env.processor.cfg.OutputShardMaxLines = 2
+		job := env.storeJob(t, "batch-4", time.Now().Add(time.Hour), "m1", "m1", "m1", "m1", "m1", "bad-model")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		status := env.getStatus(t, job.ID)
+		if status.Status != openai.BatchStatusCompleted {
+			t.Errorf("Status = %v, want %v", status.Status, openai.BatchStatusCompleted)
+		}
+		if len(status.OutputFileIDs) != 3 || status.OutputFileID != status.OutputFileIDs[0] {
+			t.Fatalf("unexpected output shards: %v (first %v)", status.OutputFileIDs, status.OutputFileID)
+		}
+		lines := 0
+		for _, fileID := range status.OutputFileIDs {
+			lines += len(env.readResultFile(t, fileID))
+		}
+		if lines != 5 {
+			t.Errorf("output lines = %d, want 5", lines)
+		}
+		if len(status.ErrorFileIDs) != 1 || status.ErrorFileID != status.ErrorFileIDs[0] {
+			t.Errorf("unexpected error shards: %v", status.ErrorFileIDs)
+		}
+
+		reader, _, err := env.files.Retrieve(context.Background(), status.OutputManifestFileID)
+		if err != nil {
+			t.Fatalf("Failed to retrieve manifest: %v", err)
+		}
+		defer reader.(io.Closer).Close()
+		var manifest openai.BatchOutputManifest
+		if err := json.NewDecoder(reader).Decode(&manifest); err != nil {
+			t.Fatalf("Failed to decode manifest: %v", err)
+		}
+		if manifest.BatchID != job.ID || len(manifest.Output) != 3 || len(manifest.Errors) != 1 {
+			t.Errorf("unexpected manifest: %+v", manifest)
+		}
+		for i, shard := range manifest.Output {
+			if shard.FileID != status.OutputFileIDs[i] || shard.Index != i || shard.Lines == 0 || shard.Bytes == 0 {
+				t.Errorf("unexpected manifest shard %d: %+v", i, shard)
+			}
+		}
+	})
+
+	t.Run("SingleShard", func(t *testing.T) {
+		env := setupProcessorForTest(t, 2, &fakeInferenceClient{})
+		job := env.storeJob(t, "batch-5", time.Now().Add(time.Hour), "m1", "m1")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		status := env.getStatus(t, job.ID)
+		if status.OutputFileID == "" || status.OutputFileIDs != nil || status.OutputManifestFileID != "" {
+			t.Errorf("unexpected sharding of a small output: %+v", status)
+		}
+	})
+
+	t.Run("ShardedOutputsDisabled", func(t *testing.T) {
+		features.Set(features.Config{features.ShardedOutputs: false})
+		defer features.Set(nil)
+		env := setupProcessorForTest(t, 2, &fakeInferenceClient{})
+		env.processor.cfg.OutputShardMaxLines = 2
+		job := env.storeJob(t, "batch-5b", time.Now().Add(time.Hour), "m1", "m1", "m1", "m1", "m1")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		status := env.getStatus(t, job.ID)
+		if status.OutputFileID == "" || status.OutputFileIDs != nil || status.OutputManifestFileID != "" {
+			t.Errorf("unexpected sharding with the sharded_outputs feature disabled: %+v", status)
+		}
+		if lines := len(env.readResultFile(t, status.OutputFileID)); lines != 5 {
+			t.Errorf("output lines = %d, want 5", lines)
+		}
+	})
+
+	t.Run("ParquetOutput", func(t *testing.T) {
+		env := setupProcessorForTest(t, 2, &fakeInferenceClient{})
+		job := env.storeJob(t, "batch-5c", time.Now().Add(time.Hour), "m1", "m1", "bad-model")
+		spec := openai.BatchSpec{}
+		json.Unmarshal(job.Spec, &spec)
+		spec.OutputFormat = openai.OutputFormatParquet
+		job.Spec, _ = json.Marshal(spec)
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		status := env.getStatus(t, job.ID)
+		if status.Status != openai.BatchStatusCompleted {
+			t.Fatalf("Status = %v, want %v", status.Status, openai.BatchStatusCompleted)
+		}
+		files, _, err := env.fileDB.Get(context.Background(), []string{status.OutputFileID}, nil, db.TagsLogicalCondNa, 0, 1)
+		if err != nil || len(files) != 1 {
+			t.Fatalf("Failed to get metadata of output file: %v", err)
+		}
+		var fileObj openai.FileObject
+		if err := json.Unmarshal(files[0].Spec, &fileObj); err != nil {
+			t.Fatalf("Failed to unmarshal file metadata: %v", err)
+		}
+		if fileObj.Filename != job.ID+"_output.parquet" || fileObj.ContentType != "application/vnd.apache.parquet" {
+			t.Errorf("unexpected output file: %+v", fileObj)
+		}
+		reader, md, err := env.files.Retrieve(context.Background(), status.OutputFileID)
+		if err != nil {
+			t.Fatalf("Failed to retrieve output file: %v", err)
+		}
+		defer reader.(io.Closer).Close()
+		rows, err := parquet.Read[resultRow](reader.(io.ReaderAt), md.Size)
+		if err != nil || len(rows) != 2 {
+			t.Errorf("unexpected output rows: %+v (%v)", rows, err)
+		}
+	})
+
+	t.Run("TraceContext", func(t *testing.T) {
+		inference := &fakeInferenceClient{}
+		env := setupProcessorForTest(t, 1, inference)
+		job := env.storeJob(t, "batch-6", time.Now().Add(time.Hour), "m1")
+		job.TraceContext = tracing.Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "").Carrier()
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		if inference.traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("inference trace ID = %v, want %v", inference.traceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+		}
+	})
+
+	t.Run("Spans", func(t *testing.T) {
+		exporter := &spanRecorder{}
+		tracing.SetExporter(exporter)
+		t.Cleanup(func() { tracing.SetExporter(nil) })
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+		job := env.storeJob(t, "batch-6b", time.Now().Add(time.Hour), "m1", "m1")
+		job.TraceContext = tracing.Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "").Carrier()
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		spans := map[string][]*tracing.Span{}
+		for _, span := range exporter.spans {
+			if span.TraceContext.TraceID() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+				t.Errorf("span %s is in trace %s", span.Name, span.TraceContext.TraceID())
+			}
+			spans[span.Name] = append(spans[span.Name], span)
+		}
+		for name, count := range map[string]int{
+			"process_batch": 1, "fetch_input": 1, "parse_input": 1, "inference": 2, "aggregate_results": 1, "upload_output": 1,
+		} {
+			if len(spans[name]) != count {
+				t.Fatalf("%d %s spans, want %d", len(spans[name]), name, count)
+			}
+		}
+		root := spans["process_batch"][0]
+		if root.ParentSpanID != "00f067aa0ba902b7" || root.Attributes()["status"] != string(openai.BatchStatusCompleted) {
+			t.Errorf("unexpected batch span: %+v", root)
+		}
+		for name, stages := range spans {
+			for _, span := range stages {
+				if name != "process_batch" && span.ParentSpanID != root.TraceContext.SpanID() {
+					t.Errorf("span %s is not a child of the batch span", name)
+				}
+			}
+		}
+		if line := spans["inference"][0]; line.Attributes()["model"] != "m1" || line.Attributes()["attempts"] != 1 {
+			t.Errorf("unexpected inference span attributes: %+v", line.Attributes())
+		}
+	})
+
+	t.Run("ProgressUpdates", func(t *testing.T) {
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{delay: 20 * time.Millisecond})
+		recorder := &statusRecordingDBClient{MockBatchDBClient: env.dbClient}
+		env.processor.clients.database = recorder
+		env.processor.cfg.ProgressUpdateInterval = 5 * time.Millisecond
+		job := env.storeJob(t, "batch-7", time.Now().Add(time.Hour), "m1", "m1", "m1", "m1", "m1")
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		var inProgress []openai.BatchRequestCounts
+		for _, status := range recorder.updates() {
+			if status.Status == openai.BatchStatusInProgress && status.RequestCounts.Completed > 0 {
+				inProgress = append(inProgress, status.RequestCounts)
+			}
+		}
+		if len(inProgress) == 0 {
+			t.Fatalf("expected request counts updates while in progress")
+		}
+		for i := 1; i < len(inProgress); i++ {
+			if inProgress[i].Completed < inProgress[i-1].Completed {
+				t.Errorf("request counts went backwards: %+v", inProgress)
+			}
+		}
+		if status := env.getStatus(t, job.ID); status.RequestCounts.Completed != 5 {
+			t.Errorf("final RequestCounts = %+v, want 5 completed", status.RequestCounts)
+		}
+	})
+
+	t.Run("ProgressEvents", func(t *testing.T) {
+		env := setupProcessorForTest(t, 1, &fakeInferenceClient{delay: 10 * time.Millisecond})
+		env.processor.cfg.ProgressEventLines = 2
+		env.processor.cfg.ProgressEventInterval = 0
+		job := env.storeJob(t, "batch-8", time.Now().Add(time.Hour), "m1", "m1", "m1", "m1", "m1")
+		events, err := env.processor.clients.event.ConsumerGetChannel(context.Background(), job.ID)
+		if err != nil {
+			t.Fatalf("ConsumerGetChannel() error = %v", err)
+		}
+		defer events.CloseFn()
+
+		env.processor.processJob(context.Background(), 1, job)
+
+		var progress []*db.BatchProgress
+		for len(events.Events) > 0 {
+			event := <-events.Events
+			if event.Type != db.BatchEventProgress || event.Progress == nil {
+				t.Fatalf("unexpected event: %+v", event)
+			}
+			progress = append(progress, event.Progress)
+		}
+		// every 2 processed lines, and when the lines are done
+		if len(progress) < 2 || len(progress) > 3 {
+			t.Fatalf("got %d progress events, want 2 to 3", len(progress))
+		}
+		for i := 1; i < len(progress); i++ {
+			if progress[i].Completed <= progress[i-1].Completed {
+				t.Errorf("progress went backwards: %+v then %+v", progress[i-1], progress[i])
+			}
+		}
+		if last := progress[len(progress)-1]; last.Completed != 5 || last.Total != 5 || last.Throughput <= 0 {
+			t.Errorf("last progress = %+v, want 5 of 5 completed", last)
+		}
+	})
+}
+
+// statusRecordingDBClient records the statuses of the job updates.
+type statusRecordingDBClient struct {
+	*mockapi.MockBatchDBClient
+
+	mu       sync.Mutex
+	statuses [][]byte
+}
+
+func (c *statusRecordingDBClient) Update(ctx context.Context, job *db.BatchJob) error {
+	c.mu.Lock()
+	c.statuses = append(c.statuses, job.Status)
+	c.mu.Unlock()
+	return c.MockBatchDBClient.Update(ctx, job)
+}
+
+func (c *statusRecordingDBClient) updates() []openai.BatchStatusInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	updates := make([]openai.BatchStatusInfo, 0, len(c.statuses))
+	for _, data := range c.statuses {
+		var status openai.BatchStatusInfo
+		json.Unmarshal(data, &status)
+		updates = append(updates, status)
+	}
+	return updates
+}
+
+// eventRecordingSink records the lifecycle events forwarded to it.
+type eventRecordingSink struct {
+	mu     sync.Mutex
+	events []*notify.Event
+}
+
+func (s *eventRecordingSink) Name() string {
+	return "recorder"
+}
+
+func (s *eventRecordingSink) Send(ctx context.Context, event *notify.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestJobEvents(t *testing.T) {
+	env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+	sink := &eventRecordingSink{}
+	env.processor.events = notify.NewForwarder([]notify.Sink{sink})
+	job := env.storeJob(t, "batch-1", time.Now().Add(time.Hour), "m1", "bad-model")
+
+	env.processor.processJob(context.Background(), 1, job)
+	env.processor.Stop(context.Background())
+
+	var statuses []openai.BatchStatus
+	for _, event := range sink.events {
+		if event.BatchID != job.ID {
+			t.Errorf("BatchID = %s, want %s", event.BatchID, job.ID)
+		}
+		statuses = append(statuses, event.Status)
+	}
+	want := []openai.BatchStatus{openai.BatchStatusInProgress, openai.BatchStatusFinalizing, openai.BatchStatusCompleted}
+	if !slices.Equal(statuses, want) {
+		t.Fatalf("event statuses = %v, want %v", statuses, want)
+	}
+	last := sink.events[len(sink.events)-1]
+	if last.RequestCounts == nil || *last.RequestCounts != (openai.BatchRequestCounts{Total: 2, Completed: 1, Failed: 1}) {
+		t.Errorf("RequestCounts = %+v, want 1 completed and 1 failed", last.RequestCounts)
+	}
+	if last.OutputFileID == "" || last.ErrorFileID == "" {
+		t.Errorf("expected the result files in the completed event, got %+v", last)
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+	p := env.processor
+	p.cfg.RequeueBackoff, p.cfg.RequeueMaxBackoff = 10*time.Millisecond, 20*time.Millisecond
+	p.clients.priorityQueue.Enqueue(ctx, &db.BatchJobPriority{ID: "batch_missing", SLO: time.Now().Add(time.Hour)})
+
+	// the job data doesn't exist, so every delivery attempt fails
+	for attempt := 1; attempt <= p.cfg.MaxDeliveryAttempts; attempt++ {
+		task := p.getTaskFromQueue(ctx)
+		if task == nil {
+			t.Fatalf("attempt %d: expected a task in the queue", attempt)
+		}
+		if _, err := p.getJobData(ctx, task); err == nil {
+			t.Fatalf("attempt %d: expected an error for missing job data", attempt)
+		}
+	}
+
+	if depth, _ := p.clients.priorityQueue.Len(ctx); depth != 0 {
+		t.Errorf("queue depth = %d, want 0", depth)
+	}
+	deadLetters, _ := p.clients.deadLetter.List(ctx)
+	if len(deadLetters) != 1 {
+		t.Fatalf("expected 1 dead-lettered job, got %d", len(deadLetters))
+	}
+	if dl := deadLetters[0]; dl.ID != "batch_missing" || dl.JobPriority.Attempts != p.cfg.MaxDeliveryAttempts || dl.Error == "" {
+		t.Errorf("unexpected dead letter: %+v", dl)
+	}
+	if failures := deadLetters[0].JobPriority.Failures; len(failures) != p.cfg.MaxDeliveryAttempts || failures[0].Error != deadLetters[0].Error {
+		t.Errorf("unexpected failure history: %+v", failures)
+	}
+}
+
+func TestRequeueBackoff(t *testing.T) {
+	ctx := context.Background()
+	env := setupProcessorForTest(t, 1, &fakeInferenceClient{})
+	p := env.processor
+	p.cfg.RequeueBackoff, p.cfg.RequeueMaxBackoff = time.Minute, 3*time.Minute
+
+	for attempts, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 3: 3 * time.Minute, 10: 3 * time.Minute} {
+		if got := p.requeueBackoff(attempts); got != want {
+			t.Errorf("requeueBackoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+
+	// the requeued job stays in the queue, but is not delivered before its backoff
+	task := &db.BatchJobPriority{ID: "batch-1", SLO: time.Now().Add(time.Hour)}
+	p.requeueOrDeadLetter(ctx, task, fmt.Errorf("transient"))
+	if depth, _ := p.clients.priorityQueue.Len(ctx); depth != 0 {
+		t.Errorf("queue depth = %d, want 0 during the backoff", depth)
+	}
+	if task.Attempts != 1 {
+		t.Errorf("attempts = %d, want 1", task.Attempts)
+	}
+	if err := p.clients.priorityQueue.Remove(ctx, task); err != nil {
+		t.Errorf("Remove() of the delayed job error = %v", err)
+	}
+
+	p.cfg.RequeueBackoff = 0
+	p.requeueOrDeadLetter(ctx, task, fmt.Errorf("transient"))
+	if depth, _ := p.clients.priorityQueue.Len(ctx); depth != 1 {
+		t.Errorf("queue depth = %d, want 1 without backoff", depth)
+	}
+}
+
+func TestFailedResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        *batch.InferenceError
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "upstream body",
+			err:        &batch.InferenceError{Category: batch.ErrCategoryInvalidReq, Message: "bad", StatusCode: 422, Body: []byte(`{"error":{"message":"upstream"}}`)},
+			wantStatus: 422,
+			wantBody:   `{"error":{"message":"upstream"}}`,
+		},
+		{
+			name:       "no response",
+			err:        &batch.InferenceError{Category: batch.ErrCategoryRateLimit, Message: "slow down"},
+			wantStatus: 429,
+			wantBody:   `{"error":{"code":429,"type":"RateLimitError","message":"slow down","param":null}}`,
+		},
+		{
+			name:       "invalid upstream body",
+			err:        &batch.InferenceError{Category: batch.ErrCategoryServer, Message: "boom", StatusCode: 502, Body: []byte("<html>")},
+			wantStatus: 502,
+			wantBody:   `{"error":{"code":502,"type":"InternalServerError","message":"boom","param":null}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := failedResponse(tt.err)
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %v, want %v", resp.StatusCode, tt.wantStatus)
+			}
+			if string(resp.Body) != tt.wantBody {
+				t.Errorf("Body = %s, want %s", resp.Body, tt.wantBody)
+			}
+		})
+	}
+}
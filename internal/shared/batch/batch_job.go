@@ -80,3 +80,21 @@ const (
 	StatusCancelling BatchStatus = BatchStatus(openai.BatchStatusCancelling)
 	StatusCancelled  BatchStatus = BatchStatus(openai.BatchStatusCancelled)
 )
+
+// PausedKeyPrefix prefixes the batch ID in the status store key recording that the processing of a batch is paused.
+// The value of the key is the Unix timestamp (in seconds) for when the batch was paused.
+const PausedKeyPrefix = "paused:"
+
+// PausedKey returns the status store key recording that the processing of the batch is paused.
+func PausedKey(batchID string) string {
+	return PausedKeyPrefix + batchID
+}
+
+// HeartbeatKeyPrefix prefixes the batch ID in the status store key of the heartbeat of the worker processing a batch.
+// The key outlives the lease of the batch, and is deleted when the worker is done with the batch.
+const HeartbeatKeyPrefix = "heartbeat:"
+
+// HeartbeatKey returns the status store key of the heartbeat of the worker processing the batch.
+func HeartbeatKey(batchID string) string {
+	return HeartbeatKeyPrefix + batchID
+}
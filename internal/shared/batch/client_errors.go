@@ -16,6 +16,11 @@ limitations under the License.
 
 package batch
 
+import (
+	"net/http"
+	"time"
+)
+
 type ErrorCategory string
 
 const (
@@ -27,9 +32,12 @@ const (
 )
 
 type InferenceError struct {
-	Category ErrorCategory
-	Message  string
-	RawError error // original error message
+	Category   ErrorCategory
+	Message    string
+	RawError   error         // original error message
+	StatusCode int           // HTTP status code of the upstream response, 0 if no response was received
+	Body       []byte        // JSON body of the upstream error response, if any
+	RetryAfter time.Duration // backoff requested by the upstream (Retry-After header), 0 if none
 }
 
 func (e *InferenceError) Error() string {
@@ -40,3 +48,26 @@ func (e *InferenceError) Error() string {
 func (e *InferenceError) IsRetryable() bool {
 	return e.Category == ErrCategoryRateLimit || e.Category == ErrCategoryServer
 }
+
+// HTTPStatusCode returns the status code of the upstream response, or the status code matching the error category
+// if no response was received.
+func (e *InferenceError) HTTPStatusCode() int {
+	if e.StatusCode != 0 {
+		return e.StatusCode
+	}
+	switch e.Category {
+	case ErrCategoryRateLimit:
+		return http.StatusTooManyRequests
+	case ErrCategoryInvalidReq:
+		return http.StatusBadRequest
+	case ErrCategoryAuth:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// IsSaturated reports whether the upstream rejected the request because it is saturated.
+func (e *InferenceError) IsSaturated() bool {
+	return e.Category == ErrCategoryRateLimit || e.StatusCode == http.StatusTooManyRequests
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// The file implements the handling of the image content parts of chat completion requests.
+
+package batch
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxImageBytes is the default maximum size of an image of a request, once decoded.
+const DefaultMaxImageBytes = 20 * 1024 * 1024
+
+// ImageFileIDField is the field of an image_url object referencing a file of the files store holding the image,
+// instead of its url. Extension: the processor inlines the file as a base64 data URL before sending the request.
+const ImageFileIDField = "file_id"
+
+// imageMediaTypes are the media types of the images supported in data URLs.
+var imageMediaTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ForEachImageURL calls fn with the image_url object of every image content part of the messages of a chat
+// completion request body, and the request parameter naming it. It stops at the first error returned by fn.
+// Parts that are not of the expected shape are passed as a nil image_url.
+func ForEachImageURL(body map[string]any, fn func(imageURL map[string]any, param string) error) error {
+	messages, _ := body["messages"].([]any)
+	for i, message := range messages {
+		message, _ := message.(map[string]any)
+		parts, _ := message["content"].([]any)
+		for j, part := range parts {
+			part, _ := part.(map[string]any)
+			if part["type"] != "image_url" {
+				continue
+			}
+			imageURL, _ := part["image_url"].(map[string]any)
+			if err := fn(imageURL, fmt.Sprintf("body.messages[%d].content[%d].image_url", i, j)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateImageURL checks that an image_url object has either an http(s) URL, a base64 data URL of a supported
+// image type of at most maxBytes once decoded, or a file reference.
+func ValidateImageURL(imageURL map[string]any, maxBytes int) error {
+	if imageURL == nil {
+		return fmt.Errorf("image_url must be an object")
+	}
+	url, _ := imageURL["url"].(string)
+	fileID, _ := imageURL[ImageFileIDField].(string)
+	if (url == "") == (fileID == "") {
+		return fmt.Errorf("image_url must have either a url or a %s", ImageFileIDField)
+	}
+	if fileID != "" {
+		if !ValidFileID(fileID) {
+			return fmt.Errorf("%s %q is not a valid file ID", ImageFileIDField, fileID)
+		}
+		return nil
+	}
+	if strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") {
+		return nil
+	}
+	data, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return fmt.Errorf("image url must be an http(s) URL or a base64 data URL")
+	}
+	mediaType, data, ok := strings.Cut(data, ";base64,")
+	if !ok {
+		return fmt.Errorf("image data URL must be base64 encoded")
+	}
+	if !imageMediaTypes[mediaType] {
+		return fmt.Errorf("image type %q is not supported, supported types are png, jpeg, gif and webp", mediaType)
+	}
+	if base64.StdEncoding.DecodedLen(len(data)) > maxBytes+2 { // decoded length is overestimated by up to 2 bytes
+		return fmt.Errorf("image exceeds the maximum size of %d bytes", maxBytes)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return fmt.Errorf("image data is not valid base64: %v", err)
+	}
+	if len(decoded) > maxBytes {
+		return fmt.Errorf("image exceeds the maximum size of %d bytes", maxBytes)
+	}
+	return nil
+}
+
+// ImageDataURL returns the base64 data URL of an image.
+func ImageDataURL(mediaType string, data []byte) string {
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// IsSupportedImageType reports whether images of the media type are supported.
+func IsSupportedImageType(mediaType string) bool {
+	return imageMediaTypes[mediaType]
+}
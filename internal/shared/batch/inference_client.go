@@ -18,14 +18,23 @@ package batch
 
 import "context"
 
+// InferenceClient sends inference requests.
+// Implementations sending HTTP requests should propagate the trace context of ctx (see tracing.FromContext)
+// as W3C trace context headers.
 type InferenceClient interface {
 	Generate(ctx context.Context, req *InferenceRequest) (*InferenceResponse, *InferenceError)
 }
 
+// SLOTTFTHeader is the request header carrying the time to first token objective in milliseconds,
+// used by the inference gateway to prioritize requests.
+const SLOTTFTHeader = "x-slo-ttft-ms"
+
 type InferenceRequest struct {
 	RequestID string                 // unique request id set by user
 	Model     string                 // model id (also inside Params)
 	Params    map[string]interface{} // parameters
+	Endpoint  string                 // URL path of the request, e.g. /v1/chat/completions
+	Headers   map[string]string      // additional HTTP headers to send with the request, e.g. SLOTTFTHeader
 }
 
 // Request Params example openai chat completion with tool calls:
@@ -0,0 +1,294 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file implements validation of batch input files (JSONL).
+
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+const (
+	DefaultMaxInputLines     = 50000
+	DefaultMaxInputLineBytes = 1024 * 1024
+	DefaultMaxInputErrors    = 100
+)
+
+// InputValidationOptions configures the limits applied by ValidateInput.
+// A zero value for any of the limits selects its default.
+type InputValidationOptions struct {
+	Endpoint      openai.Endpoint // If set, every line's url must match this endpoint.
+	MaxLines      int             // Maximum number of requests in the file.
+	MaxLineBytes  int             // Maximum size of a single line in bytes.
+	MaxErrors     int             // Maximum number of line errors collected before validation stops.
+	MaxImageBytes int             // Maximum size of an image of a chat completion request, once decoded.
+
+	// If set, every line's body must reference a model for which this function returns true.
+	IsModelAllowed func(model string) bool
+}
+
+func (o *InputValidationOptions) setDefaults() {
+	if o.MaxLines <= 0 {
+		o.MaxLines = DefaultMaxInputLines
+	}
+	if o.MaxLineBytes <= 0 {
+		o.MaxLineBytes = DefaultMaxInputLineBytes
+	}
+	if o.MaxErrors <= 0 {
+		o.MaxErrors = DefaultMaxInputErrors
+	}
+	if o.MaxImageBytes <= 0 {
+		o.MaxImageBytes = DefaultMaxImageBytes
+	}
+}
+
+// InputValidationReport is the result of validating a batch input file.
+type InputValidationReport struct {
+	Lines     int                 // Number of non-empty lines read.
+	Errors    []openai.BatchError // Per-line errors, capped by MaxErrors.
+	Truncated bool                // True if validation stopped early because MaxErrors was reached.
+	Models    map[string]int64    // Number of valid lines per model, the lines without a model are not counted.
+
+	// IDs of the image files referenced by the valid lines, whose owner must be checked before the batch is created.
+	ImageFileIDs map[string]bool
+}
+
+func (r *InputValidationReport) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// BatchErrors converts the report to the OpenAI batch errors object.
+func (r *InputValidationReport) BatchErrors() *openai.BatchErrors {
+	return &openai.BatchErrors{
+		Object: "list",
+		Data:   r.Errors,
+	}
+}
+
+var errLineTooLarge = errors.New("line too large")
+
+// ValidateInput reads a batch input file and validates each line against the OpenAI batch input format.
+// Validation errors of the content are returned in the report, the returned error is set only when reading fails.
+func ValidateInput(r io.Reader, opts InputValidationOptions) (*InputValidationReport, error) {
+	opts.setDefaults()
+
+	report := &InputValidationReport{Models: make(map[string]int64), ImageFileIDs: make(map[string]bool)}
+	reader := bufio.NewReader(r)
+	seen := make(map[string]int64)
+
+	addError := func(line int64, code, param, msg string) bool {
+		report.Errors = append(report.Errors, openai.BatchError{
+			Code:    code,
+			Message: msg,
+			Param:   param,
+			Line:    line,
+		})
+		if len(report.Errors) >= opts.MaxErrors {
+			report.Truncated = true
+			return false
+		}
+		return true
+	}
+
+	var lineNum int64
+	for {
+		data, err := readLine(reader, opts.MaxLineBytes)
+		if err != nil && err != io.EOF && err != errLineTooLarge {
+			return nil, err
+		}
+		if err == io.EOF && len(data) == 0 {
+			break
+		}
+		lineNum++
+
+		if err == errLineTooLarge {
+			report.Lines++
+			if !addError(lineNum, openai.BatchInputErrorLineTooLarge, "",
+				fmt.Sprintf("line exceeds the maximum size of %d bytes", opts.MaxLineBytes)) {
+				return report, nil
+			}
+			continue
+		}
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			if err == io.EOF {
+				break
+			}
+			continue
+		}
+		report.Lines++
+
+		if report.Lines > opts.MaxLines {
+			addError(lineNum, openai.BatchInputErrorTooManyRequests, "",
+				fmt.Sprintf("input file contains more than %d requests", opts.MaxLines))
+			return report, nil
+		}
+
+		if ok := validateLine(data, lineNum, &opts, seen, report, addError); !ok {
+			return report, nil
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if report.Lines == 0 {
+		addError(0, openai.BatchInputErrorEmptyFile, "", "input file contains no requests")
+	}
+
+	return report, nil
+}
+
+// validateLine validates a single non-empty line, counting it in the models of the report and recording its image
+// files if it is valid. It returns false if error collection should stop.
+func validateLine(data []byte, lineNum int64, opts *InputValidationOptions, seen map[string]int64, report *InputValidationReport,
+	addError func(line int64, code, param, msg string) bool) bool {
+
+	var req openai.BatchRequestInput
+	if err := json.Unmarshal(data, &req); err != nil {
+		return addError(lineNum, openai.BatchInputErrorInvalidJSON, "", "line is not a valid JSON object: "+err.Error())
+	}
+
+	if req.CustomID == "" {
+		return addError(lineNum, openai.BatchInputErrorMissingField, "custom_id", "custom_id is required")
+	}
+	if firstLine, ok := seen[req.CustomID]; ok {
+		return addError(lineNum, openai.BatchInputErrorDuplicateID, "custom_id",
+			fmt.Sprintf("custom_id %q is already used on line %d", req.CustomID, firstLine))
+	}
+	seen[req.CustomID] = lineNum
+
+	if req.Method == "" {
+		return addError(lineNum, openai.BatchInputErrorMissingField, "method", "method is required")
+	}
+	if req.Method != http.MethodPost {
+		return addError(lineNum, openai.BatchInputErrorInvalidMethod, "method",
+			fmt.Sprintf("method %q is not supported, only POST is supported", req.Method))
+	}
+
+	if req.URL == "" {
+		return addError(lineNum, openai.BatchInputErrorMissingField, "url", "url is required")
+	}
+	if opts.Endpoint != "" && req.URL != opts.Endpoint.String() {
+		return addError(lineNum, openai.BatchInputErrorInvalidURL, "url",
+			fmt.Sprintf("url %q does not match the batch endpoint %q", req.URL, opts.Endpoint))
+	}
+
+	if req.TimeoutSeconds < 0 {
+		return addError(lineNum, openai.BatchInputErrorInvalidTimeout, "timeout_seconds", "timeout_seconds cannot be negative")
+	}
+
+	body := bytes.TrimSpace(req.Body)
+	if len(body) == 0 || bytes.Equal(body, []byte("null")) {
+		return addError(lineNum, openai.BatchInputErrorMissingField, "body", "body is required")
+	}
+	if body[0] != '{' {
+		return addError(lineNum, openai.BatchInputErrorInvalidJSON, "body", "body must be a JSON object")
+	}
+
+	var imageFileIDs []string
+	if req.URL == openai.EndpointChatCompletions.String() && bytes.Contains(body, []byte(`"image_url"`)) {
+		var reqBody map[string]any
+		if err := json.Unmarshal(body, &reqBody); err != nil {
+			return addError(lineNum, openai.BatchInputErrorInvalidJSON, "body", "body is not a valid JSON object: "+err.Error())
+		}
+		var imageParam string
+		err := ForEachImageURL(reqBody, func(imageURL map[string]any, param string) error {
+			imageParam = param
+			if err := ValidateImageURL(imageURL, opts.MaxImageBytes); err != nil {
+				return err
+			}
+			if fileID, _ := imageURL[ImageFileIDField].(string); fileID != "" {
+				imageFileIDs = append(imageFileIDs, fileID)
+			}
+			return nil
+		})
+		if err != nil {
+			return addError(lineNum, openai.BatchInputErrorInvalidImage, imageParam, err.Error())
+		}
+	}
+
+	var reqBody struct {
+		Model string `json:"model"`
+	}
+	err := json.Unmarshal(body, &reqBody)
+	if opts.IsModelAllowed != nil {
+		if err != nil {
+			return addError(lineNum, openai.BatchInputErrorInvalidJSON, "body", "body is not a valid JSON object: "+err.Error())
+		}
+		if reqBody.Model == "" {
+			return addError(lineNum, openai.BatchInputErrorMissingField, "body.model", "body.model is required")
+		}
+		if !opts.IsModelAllowed(reqBody.Model) {
+			return addError(lineNum, openai.BatchInputErrorModelNotFound, "body.model",
+				fmt.Sprintf("model %q is not available", reqBody.Model))
+		}
+	}
+
+	if reqBody.Model != "" {
+		report.Models[reqBody.Model]++
+	}
+	for _, fileID := range imageFileIDs {
+		report.ImageFileIDs[fileID] = true
+	}
+	return true
+}
+
+// readLine reads a single line without the trailing newline.
+// If the line is longer than maxBytes, the rest of the line is discarded and errLineTooLarge is returned.
+func readLine(reader *bufio.Reader, maxBytes int) ([]byte, error) {
+	var line []byte
+	tooLarge := false
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if !tooLarge {
+			if len(line)+len(chunk) > maxBytes+1 { // +1 for the newline
+				tooLarge = true
+				line = nil
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if tooLarge {
+			if err == io.EOF {
+				return nil, errLineTooLarge
+			}
+			if err != nil {
+				return nil, err
+			}
+			return []byte{}, errLineTooLarge
+		}
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		if len(line) > maxBytes {
+			return []byte{}, errLineTooLarge
+		}
+		return line, err
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for the batch input file validation.
+package batch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+func inputLine(customID string) string {
+	return `{"custom_id":"` + customID + `","method":"POST","url":"/v1/chat/completions","body":{"model":"m1","messages":[]}}`
+}
+
+func imageLine(customID, imageURL string) string {
+	return `{"custom_id":"` + customID + `","method":"POST","url":"/v1/chat/completions","body":{"model":"m1","messages":[` +
+		`{"role":"user","content":[{"type":"text","text":"describe"},{"type":"image_url","image_url":` + imageURL + `}]}]}}`
+}
+
+func TestValidateInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		opts      InputValidationOptions
+		wantLines int
+		wantCodes []string
+		wantLine  []int64
+	}{
+		{
+			name:      "valid file",
+			input:     inputLine("r1") + "\n" + inputLine("r2") + "\n",
+			opts:      InputValidationOptions{Endpoint: openai.EndpointChatCompletions},
+			wantLines: 2,
+		},
+		{
+			name:      "valid file without trailing newline and with blank lines",
+			input:     inputLine("r1") + "\n\n" + inputLine("r2"),
+			wantLines: 2,
+		},
+		{
+			name:      "empty file",
+			input:     "",
+			wantCodes: []string{openai.BatchInputErrorEmptyFile},
+			wantLine:  []int64{0},
+		},
+		{
+			name:      "invalid json",
+			input:     inputLine("r1") + "\n{not json}\n",
+			wantLines: 2,
+			wantCodes: []string{openai.BatchInputErrorInvalidJSON},
+			wantLine:  []int64{2},
+		},
+		{
+			name:      "missing fields",
+			input:     `{"method":"POST","url":"/v1/chat/completions","body":{}}` + "\n" + `{"custom_id":"r2","url":"/v1/chat/completions","body":{}}` + "\n" + `{"custom_id":"r3","method":"POST","url":"/v1/chat/completions"}`,
+			wantLines: 3,
+			wantCodes: []string{openai.BatchInputErrorMissingField, openai.BatchInputErrorMissingField, openai.BatchInputErrorMissingField},
+			wantLine:  []int64{1, 2, 3},
+		},
+		{
+			name:      "duplicate custom_id",
+			input:     inputLine("r1") + "\n" + inputLine("r1") + "\n",
+			wantLines: 2,
+			wantCodes: []string{openai.BatchInputErrorDuplicateID},
+			wantLine:  []int64{2},
+		},
+		{
+			name:      "negative timeout",
+			input:     inputLine("r1") + "\n" + `{"custom_id":"r2","method":"POST","url":"/v1/chat/completions","body":{},"timeout_seconds":-1}`,
+			wantLines: 2,
+			wantCodes: []string{openai.BatchInputErrorInvalidTimeout},
+			wantLine:  []int64{2},
+		},
+		{
+			name:      "invalid method",
+			input:     `{"custom_id":"r1","method":"GET","url":"/v1/chat/completions","body":{}}`,
+			wantLines: 1,
+			wantCodes: []string{openai.BatchInputErrorInvalidMethod},
+			wantLine:  []int64{1},
+		},
+		{
+			name:      "url does not match endpoint",
+			input:     inputLine("r1"),
+			opts:      InputValidationOptions{Endpoint: openai.EndpointEmbeddings},
+			wantLines: 1,
+			wantCodes: []string{openai.BatchInputErrorInvalidURL},
+			wantLine:  []int64{1},
+		},
+		{
+			name:      "line too large",
+			input:     inputLine("r1") + "\n" + inputLine(strings.Repeat("x", 200)) + "\n" + inputLine("r3"),
+			opts:      InputValidationOptions{MaxLineBytes: 150},
+			wantLines: 3,
+			wantCodes: []string{openai.BatchInputErrorLineTooLarge},
+			wantLine:  []int64{2},
+		},
+		{
+			name:      "too many lines",
+			input:     inputLine("r1") + "\n" + inputLine("r2") + "\n" + inputLine("r3") + "\n",
+			opts:      InputValidationOptions{MaxLines: 2},
+			wantLines: 3,
+			wantCodes: []string{openai.BatchInputErrorTooManyRequests},
+			wantLine:  []int64{3},
+		},
+		{
+			name:  "model not allowed",
+			input: inputLine("r1") + "\n" + `{"custom_id":"r2","method":"POST","url":"/v1/chat/completions","body":{"model":"m2"}}` + "\n" + `{"custom_id":"r3","method":"POST","url":"/v1/chat/completions","body":{}}`,
+			opts: InputValidationOptions{
+				IsModelAllowed: func(model string) bool { return model == "m1" },
+			},
+			wantLines: 3,
+			wantCodes: []string{openai.BatchInputErrorModelNotFound, openai.BatchInputErrorMissingField},
+			wantLine:  []int64{2, 3},
+		},
+		{
+			name: "images",
+			input: imageLine("r1", `{"url":"data:image/png;base64,iVBORw0KGgo="}`) + "\n" +
+				imageLine("r2", `{"url":"https://example.com/cat.jpg","detail":"low"}`) + "\n" +
+				imageLine("r3", `{"file_id":"file-abc123"}`) + "\n" +
+				imageLine("r4", `{"url":"data:image/bmp;base64,Qk0="}`) + "\n" +
+				imageLine("r5", `{"url":"data:image/png;base64,not base64!"}`) + "\n" +
+				imageLine("r6", `{"url":"data:image/png;base64,iVBORw0KGgoAAAANSUhEUg=="}`) + "\n" +
+				imageLine("r7", `{"url":"https://example.com/cat.jpg","file_id":"file-abc123"}`) + "\n" +
+				imageLine("r8", `"ftp://example.com/cat.jpg"`) + "\n" +
+				imageLine("r9", `{"file_id":"../team-a/file-abc123"}`) + "\n",
+			opts:      InputValidationOptions{MaxImageBytes: 8},
+			wantLines: 9,
+			wantCodes: []string{
+				openai.BatchInputErrorInvalidImage, openai.BatchInputErrorInvalidImage, openai.BatchInputErrorInvalidImage,
+				openai.BatchInputErrorInvalidImage, openai.BatchInputErrorInvalidImage, openai.BatchInputErrorInvalidImage,
+			},
+			wantLine: []int64{4, 5, 6, 7, 8, 9},
+		},
+		{
+			name:      "errors are capped",
+			input:     "{\n{\n{\n{\n",
+			opts:      InputValidationOptions{MaxErrors: 2},
+			wantLines: 2,
+			wantCodes: []string{openai.BatchInputErrorInvalidJSON, openai.BatchInputErrorInvalidJSON},
+			wantLine:  []int64{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ValidateInput(strings.NewReader(tt.input), tt.opts)
+			if err != nil {
+				t.Fatalf("ValidateInput() unexpected error: %v", err)
+			}
+
+			if report.Lines != tt.wantLines {
+				t.Errorf("Lines = %d, want %d", report.Lines, tt.wantLines)
+			}
+			if report.Valid() != (len(tt.wantCodes) == 0) {
+				t.Errorf("Valid() = %v, errors: %+v", report.Valid(), report.Errors)
+			}
+			if len(report.Errors) != len(tt.wantCodes) {
+				t.Fatalf("got %d errors, want %d: %+v", len(report.Errors), len(tt.wantCodes), report.Errors)
+			}
+			for i, e := range report.Errors {
+				if e.Code != tt.wantCodes[i] {
+					t.Errorf("error[%d].Code = %s, want %s", i, e.Code, tt.wantCodes[i])
+				}
+				if e.Line != tt.wantLine[i] {
+					t.Errorf("error[%d].Line = %d, want %d", i, e.Line, tt.wantLine[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateInputModels(t *testing.T) {
+	input := inputLine("r1") + "\n" + inputLine("r2") + "\n" +
+		`{"custom_id":"r3","method":"POST","url":"/v1/chat/completions","body":{"model":"m2","messages":[]}}` + "\n" +
+		`{"custom_id":"r4","method":"GET","url":"/v1/chat/completions","body":{"model":"m2","messages":[]}}` + "\n"
+	report, err := ValidateInput(strings.NewReader(input), InputValidationOptions{})
+	if err != nil {
+		t.Fatalf("ValidateInput() unexpected error: %v", err)
+	}
+	if len(report.Models) != 2 || report.Models["m1"] != 2 || report.Models["m2"] != 1 {
+		t.Errorf("Models = %v, want the valid lines per model", report.Models)
+	}
+}
+
+func TestValidateInputImageFileIDs(t *testing.T) {
+	input := imageLine("r1", `{"file_id":"file-1"}`) + "\n" + imageLine("r2", `{"file_id":"file-1"}`) + "\n" +
+		imageLine("r3", `{"url":"https://example.com/cat.jpg"}`) + "\n" + imageLine("r4", `{"file_id":"file-2"}`) + "\n"
+	report, err := ValidateInput(strings.NewReader(input), InputValidationOptions{})
+	if err != nil {
+		t.Fatalf("ValidateInput() unexpected error: %v", err)
+	}
+	if len(report.ImageFileIDs) != 2 || !report.ImageFileIDs["file-1"] || !report.ImageFileIDs["file-2"] {
+		t.Errorf("ImageFileIDs = %v, want the image files of the lines", report.ImageFileIDs)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file implements the scoping of the files and the batch records of a tenant, so the tenants can't enumerate
+// or read each other's files and batches.
+
+package batch
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultTenantID is the tenant of the requests that don't specify one.
+	DefaultTenantID = "default"
+
+	// TenantTagPrefix prefixes the tenant in the database tag recording the tenant owning a batch or a file.
+	TenantTagPrefix = "tenant:"
+
+	// tenantsLocation is the location of the files of the tenants in the files store
+	tenantsLocation = "tenants/"
+)
+
+// tenantIDPattern restricts the tenant IDs to characters that are safe in file locations and database tags.
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,62}$`)
+
+// ValidTenantID reports whether the tenant ID can be used: 1 to 63 letters, digits, '_', '.' and '-',
+// starting with a letter or a digit.
+func ValidTenantID(tenantID string) bool {
+	return tenantIDPattern.MatchString(tenantID)
+}
+
+// ValidFileID reports whether the file ID can be located in the files of a tenant: it must not be empty nor contain
+// path separators or "..", which would locate the files of other tenants or other objects of the files store.
+func ValidFileID(fileID string) bool {
+	return fileID != "" && !strings.ContainsAny(fileID, `/\`) && !strings.Contains(fileID, "..")
+}
+
+// FileLocation returns the location in the files store of a file of the tenant, under the prefix of the tenant.
+// The files of the default tenant keep their ID as location, as before tenants were isolated.
+// The file ID must be valid, see ValidFileID.
+func FileLocation(tenantID, fileID string) string {
+	if tenantID == "" || tenantID == DefaultTenantID {
+		return fileID
+	}
+	return tenantsLocation + tenantID + "/" + fileID
+}
+
+// TenantTag returns the database tag recording that a batch or a file is owned by the tenant.
+func TenantTag(tenantID string) string {
+	if tenantID == "" {
+		tenantID = DefaultTenantID
+	}
+	return TenantTagPrefix + tenantID
+}
+
+// TenantOf returns the tenant owning a batch or a file, given its database tags. The records without tenant tag,
+// created before the tenants were isolated, are owned by no tenant and TenantOf returns an empty string, matching
+// the listings which select the records by their tenant tag.
+func TenantOf(tags []string) string {
+	for _, tag := range tags {
+		if tenantID, ok := strings.CutPrefix(tag, TenantTagPrefix); ok {
+			return tenantID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file provides the in-cluster configuration of the clients of the Kubernetes API.
+
+package kube
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/tls"
+)
+
+// The in-cluster configuration of the Kubernetes API client, mounted in every pod.
+const (
+	InClusterTokenFile  = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	InClusterCACertFile = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// requestTimeout is the timeout of the requests sent to the Kubernetes API server by the in-cluster clients.
+const requestTimeout = 10 * time.Second
+
+// InClusterServer returns the URL of the Kubernetes API server of the cluster the process runs in,
+// or an empty string outside of a cluster.
+func InClusterServer() string {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return ""
+	}
+	return "https://" + host + ":" + port
+}
+
+// inClusterHTTPClient returns an HTTP client verifying the Kubernetes API server with the in-cluster CA.
+func inClusterHTTPClient() (*http.Client, error) {
+	tlsConfig, err := tls.GetTlsConfig(tls.LOAD_TYPE_CLIENT, false, "", "", InClusterCACertFile)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		Timeout:   requestTimeout,
+	}, nil
+}
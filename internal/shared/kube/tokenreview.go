@@ -0,0 +1,227 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements the authentication of bearer tokens with the TokenReview API of Kubernetes, so the
+// service accounts of the cluster can call the internal endpoints without static keys.
+
+package kube
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+const (
+	// ServiceAccountPrefix prefixes the usernames of the service accounts, system:serviceaccount:<namespace>:<name>.
+	ServiceAccountPrefix = "system:serviceaccount:"
+
+	tokenReviewPath = "/apis/authentication.k8s.io/v1/tokenreviews"
+
+	// reviewCacheTTL is how long the result of the review of a token is reused.
+	reviewCacheTTL = time.Minute
+	// maxCachedReviews bounds the number of reviews cached; the cache is emptied when it is full.
+	maxCachedReviews = 1000
+)
+
+// UserInfo is the user a token authenticates, e.g. system:serviceaccount:<namespace>:<name> for the token
+// of a service account.
+type UserInfo struct {
+	Username string   `json:"username"`
+	UID      string   `json:"uid"`
+	Groups   []string `json:"groups"`
+}
+
+type tokenReview struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Spec       tokenReviewSpec   `json:"spec"`
+	Status     tokenReviewStatus `json:"status"`
+}
+
+type tokenReviewSpec struct {
+	Token string `json:"token"`
+}
+
+type tokenReviewStatus struct {
+	Authenticated bool     `json:"authenticated"`
+	User          UserInfo `json:"user"`
+	Error         string   `json:"error"`
+}
+
+type cachedReview struct {
+	user    *UserInfo
+	expires time.Time
+}
+
+// TokenReviewer authenticates bearer tokens with the TokenReview API of the Kubernetes API server. Its own
+// service account must be allowed to create TokenReviews, e.g. bound to the system:auth-delegator role.
+// The reviews are cached for a minute.
+type TokenReviewer struct {
+	baseURL    string
+	tokenFile  string
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cachedReview
+	now   func() time.Time
+}
+
+// NewTokenReviewer creates a reviewer sending the TokenReviews to the Kubernetes API server at baseURL,
+// authenticated with the token read from tokenFile (none is sent when empty). The default HTTP client is
+// used when httpClient is nil.
+func NewTokenReviewer(baseURL, tokenFile string, httpClient *http.Client) *TokenReviewer {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &TokenReviewer{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		tokenFile:  tokenFile,
+		httpClient: httpClient,
+		cache:      map[[sha256.Size]byte]cachedReview{},
+		now:        time.Now,
+	}
+}
+
+// NewInClusterTokenReviewer creates a reviewer of the Kubernetes API server of the cluster the process runs in.
+func NewInClusterTokenReviewer() (*TokenReviewer, error) {
+	server := InClusterServer()
+	if server == "" {
+		return nil, fmt.Errorf("token reviews require running in a Kubernetes cluster")
+	}
+	httpClient, err := inClusterHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	return NewTokenReviewer(server, InClusterTokenFile, httpClient), nil
+}
+
+// Review returns the user authenticated by the token, or nil if the token isn't valid.
+// An error is returned when the token couldn't be reviewed.
+func (r *TokenReviewer) Review(ctx context.Context, token string) (*UserInfo, error) {
+	if token == "" {
+		return nil, nil
+	}
+	key := sha256.Sum256([]byte(token))
+	r.mu.Lock()
+	cached, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && r.now().Before(cached.expires) {
+		return cached.user, nil
+	}
+
+	review, err := r.create(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	var user *UserInfo
+	if review.Status.Authenticated {
+		user = &review.Status.User
+	} else if review.Status.Error != "" {
+		klog.FromContext(ctx).V(logging.DEBUG).Info("Token rejected by the token review", "error", review.Status.Error)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.cache) >= maxCachedReviews {
+		clear(r.cache)
+	}
+	r.cache[key] = cachedReview{user: user, expires: r.now().Add(reviewCacheTTL)}
+	return user, nil
+}
+
+// create sends a TokenReview of the token to the Kubernetes API server and returns the reviewed TokenReview.
+func (r *TokenReviewer) create(ctx context.Context, token string) (*tokenReview, error) {
+	data, err := json.Marshal(&tokenReview{
+		APIVersion: "authentication.k8s.io/v1",
+		Kind:       "TokenReview",
+		Spec:       tokenReviewSpec{Token: token},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+tokenReviewPath, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if r.tokenFile != "" {
+		ownToken, err := os.ReadFile(r.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the Kubernetes API token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(ownToken)))
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token review failed: %s", resp.Status)
+	}
+	review := &tokenReview{}
+	if err := json.NewDecoder(resp.Body).Decode(review); err != nil {
+		return nil, fmt.Errorf("token review failed: invalid response: %w", err)
+	}
+	return review, nil
+}
+
+// BearerToken returns the bearer token of the Authorization header of the request, empty if there is none.
+func BearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return token
+}
+
+// Allowed reports whether the user is one of the allowed usernames.
+func Allowed(user *UserInfo, usernames []string) bool {
+	return user != nil && slices.Contains(usernames, user.Username)
+}
+
+// RequireServiceAccount rejects the requests that don't carry the token of one of the service accounts
+// (system:serviceaccount:<namespace>:<name> usernames) as bearer token, before calling next.
+func RequireServiceAccount(reviewer *TokenReviewer, serviceAccounts []string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := reviewer.Review(r.Context(), BearerToken(r))
+		if err != nil {
+			klog.FromContext(r.Context()).V(logging.ERROR).Error(err, "Failed to review token")
+			http.Error(w, "authentication unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if !Allowed(user, serviceAccounts) {
+			http.Error(w, "invalid or missing credentials", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
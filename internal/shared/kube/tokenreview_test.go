@@ -0,0 +1,148 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The unit tests of the token reviews.
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testServiceAccount = ServiceAccountPrefix + "ops:automation"
+
+// newFakeTokenReviewAPI serves TokenReviews, authenticating the token "valid" as testServiceAccount.
+func newFakeTokenReviewAPI(t *testing.T, reviews *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != tokenReviewPath {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer own-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		reviews.Add(1)
+		review := &tokenReview{}
+		json.NewDecoder(r.Body).Decode(review)
+		switch review.Spec.Token {
+		case "valid":
+			review.Status = tokenReviewStatus{Authenticated: true, User: UserInfo{Username: testServiceAccount}}
+		case "other":
+			review.Status = tokenReviewStatus{Authenticated: true, User: UserInfo{Username: ServiceAccountPrefix + "ops:other"}}
+		default:
+			review.Status = tokenReviewStatus{Error: "invalid token"}
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(review)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTokenReviewer(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("own-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var reviews atomic.Int32
+	server := newFakeTokenReviewAPI(t, &reviews)
+	ctx := context.Background()
+
+	t.Run("Review", func(t *testing.T) {
+		reviewer := NewTokenReviewer(server.URL, tokenFile, nil)
+		user, err := reviewer.Review(ctx, "valid")
+		if err != nil || user == nil || user.Username != testServiceAccount {
+			t.Fatalf("Review(valid) = %+v, %v, want %s", user, err, testServiceAccount)
+		}
+		if user, err := reviewer.Review(ctx, "invalid"); err != nil || user != nil {
+			t.Errorf("Review(invalid) = %+v, %v, want no user", user, err)
+		}
+		if user, err := reviewer.Review(ctx, ""); err != nil || user != nil {
+			t.Errorf("Review of an empty token = %+v, %v, want no user", user, err)
+		}
+	})
+
+	t.Run("Cache", func(t *testing.T) {
+		reviewer := NewTokenReviewer(server.URL, tokenFile, nil)
+		now := time.Now()
+		reviewer.now = func() time.Time { return now }
+		reviews.Store(0)
+		for range 3 {
+			reviewer.Review(ctx, "valid")
+			reviewer.Review(ctx, "invalid")
+		}
+		if got := reviews.Load(); got != 2 {
+			t.Errorf("reviews = %d, want 2", got)
+		}
+		// the reviews expire
+		now = now.Add(reviewCacheTTL)
+		reviewer.Review(ctx, "valid")
+		if got := reviews.Load(); got != 3 {
+			t.Errorf("reviews after expiry = %d, want 3", got)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		// the API server rejects the reviewer without its token
+		reviewer := NewTokenReviewer(server.URL, "", nil)
+		if _, err := reviewer.Review(ctx, "valid"); err == nil {
+			t.Errorf("Review() succeeded without the reviewer's token")
+		}
+	})
+
+	t.Run("RequireServiceAccount", func(t *testing.T) {
+		reviewer := NewTokenReviewer(server.URL, tokenFile, nil)
+		handler := RequireServiceAccount(reviewer, []string{testServiceAccount}, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		for auth, want := range map[string]int{
+			"Bearer valid":   http.StatusNoContent,
+			"Bearer other":   http.StatusUnauthorized,
+			"Bearer invalid": http.StatusUnauthorized,
+			"valid":          http.StatusUnauthorized,
+			"":               http.StatusUnauthorized,
+		} {
+			req := httptest.NewRequest(http.MethodPost, "/drain", nil)
+			if auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+			if rr.Code != want {
+				t.Errorf("auth %q: status = %d, want %d", auth, rr.Code, want)
+			}
+		}
+
+		unavailable := RequireServiceAccount(NewTokenReviewer(server.URL, "", nil), []string{testServiceAccount}, handler)
+		req := httptest.NewRequest(http.MethodPost, "/drain", nil)
+		req.Header.Set("Authorization", "Bearer valid")
+		rr := httptest.NewRecorder()
+		unavailable(rr, req)
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("status when the review fails = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements the allowlist of models that batches may reference.
+
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/shared/openai"
+)
+
+const (
+	DefaultRefreshInterval = 60 * time.Second
+	fetchTimeout           = 5 * time.Second
+)
+
+// Allowlist decides which models batches may reference.
+// Models can be configured statically, fetched from an OpenAI compatible `/v1/models` endpoint
+// (e.g. of the inference gateway), or both, in which case a model must be in the static list and
+// served by the endpoint.
+type Allowlist struct {
+	static          map[string]struct{}
+	modelsURL       string
+	refreshInterval time.Duration
+	httpClient      *http.Client
+
+	mu        sync.Mutex
+	fetched   map[string]struct{}
+	fetchedAt time.Time
+}
+
+func NewAllowlist(models []string, modelsURL string, refreshInterval time.Duration) *Allowlist {
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultRefreshInterval
+	}
+	a := &Allowlist{
+		modelsURL:       modelsURL,
+		refreshInterval: refreshInterval,
+		httpClient:      &http.Client{Timeout: fetchTimeout},
+	}
+	if len(models) > 0 {
+		a.static = make(map[string]struct{}, len(models))
+		for _, m := range models {
+			a.static[m] = struct{}{}
+		}
+	}
+	return a
+}
+
+// Enabled returns true if any restriction on models is configured.
+func (a *Allowlist) Enabled() bool {
+	return a != nil && (a.static != nil || a.modelsURL != "")
+}
+
+// Checker returns a function reporting whether a model is allowed.
+// The models endpoint is fetched if the cached list is stale. If fetching fails, the last fetched list is
+// used; an error is returned only if no list was ever fetched.
+func (a *Allowlist) Checker(ctx context.Context) (func(model string) bool, error) {
+	if !a.Enabled() {
+		return func(string) bool { return true }, nil
+	}
+
+	var fetched map[string]struct{}
+	if a.modelsURL != "" {
+		var err error
+		if fetched, err = a.fetchedModels(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(model string) bool {
+		if a.static != nil {
+			if _, ok := a.static[model]; !ok {
+				return false
+			}
+		}
+		if fetched != nil {
+			if _, ok := fetched[model]; !ok {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func (a *Allowlist) fetchedModels(ctx context.Context) (map[string]struct{}, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.fetched != nil && time.Since(a.fetchedAt) < a.refreshInterval {
+		return a.fetched, nil
+	}
+	models, err := a.fetch(ctx)
+	if err != nil {
+		if a.fetched != nil {
+			return a.fetched, nil
+		}
+		return nil, err
+	}
+	a.fetched = models
+	a.fetchedAt = time.Now()
+	return a.fetched, nil
+}
+
+func (a *Allowlist) fetch(ctx context.Context) (map[string]struct{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.modelsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch models: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch models: unexpected status %d", resp.StatusCode)
+	}
+
+	var list openai.ListModelsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode models: %w", err)
+	}
+	models := make(map[string]struct{}, len(list.Data))
+	for _, m := range list.Data {
+		models[m.ID] = struct{}{}
+	}
+	return models, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file contains unit tests for the models allowlist.
+
+package models
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAllowlist(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"object":"list","data":[{"id":"m1","object":"model"},{"id":"m2","object":"model"}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		models      []string
+		modelsURL   string
+		wantAllowed map[string]bool
+	}{
+		{
+			name:        "disabled",
+			wantAllowed: map[string]bool{"m1": true, "other": true},
+		},
+		{
+			name:        "static list",
+			models:      []string{"m1"},
+			wantAllowed: map[string]bool{"m1": true, "m2": false},
+		},
+		{
+			name:        "fetched list",
+			modelsURL:   server.URL,
+			wantAllowed: map[string]bool{"m1": true, "m2": true, "m3": false},
+		},
+		{
+			name:        "static and fetched lists",
+			models:      []string{"m2", "m3"},
+			modelsURL:   server.URL,
+			wantAllowed: map[string]bool{"m1": false, "m2": true, "m3": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowlist := NewAllowlist(tt.models, tt.modelsURL, 0)
+			isAllowed, err := allowlist.Checker(context.Background())
+			if err != nil {
+				t.Fatalf("Checker() unexpected error: %v", err)
+			}
+			for model, want := range tt.wantAllowed {
+				if got := isAllowed(model); got != want {
+					t.Errorf("isAllowed(%q) = %v, want %v", model, got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("fetch failure", func(t *testing.T) {
+		healthy.Store(false)
+		defer healthy.Store(true)
+		if _, err := NewAllowlist(nil, server.URL, 0).Checker(context.Background()); err == nil {
+			t.Errorf("Checker() expected error when models were never fetched")
+		}
+
+		// the last fetched list is used when refreshing fails
+		healthy.Store(true)
+		allowlist := NewAllowlist(nil, server.URL, time.Nanosecond)
+		if _, err := allowlist.Checker(context.Background()); err != nil {
+			t.Fatalf("Checker() unexpected error: %v", err)
+		}
+		healthy.Store(false)
+		isAllowed, err := allowlist.Checker(context.Background())
+		if err != nil {
+			t.Fatalf("Checker() unexpected error with stale list: %v", err)
+		}
+		if !isAllowed("m1") {
+			t.Errorf("isAllowed(%q) = false with stale list, want true", "m1")
+		}
+	})
+}
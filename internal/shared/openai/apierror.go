@@ -43,6 +43,9 @@ func NewAPIError(code int, errorType string, message string, param *string) APIE
 
 type ErrorResponse struct {
 	Error APIError `json:"error"`
+
+	// optional. Per-line errors of the batch input file, set when the input file failed validation.
+	Errors *BatchErrors `json:"errors,omitempty"`
 }
 
 func ErrorCodeToType(code int) string {
@@ -58,6 +61,8 @@ func ErrorCodeToType(code int) string {
 		errorType = "PermissionDeniedError"
 	case http.StatusNotFound:
 		errorType = "NotFoundError"
+	case http.StatusRequestEntityTooLarge:
+		errorType = "RequestTooLargeError"
 	case http.StatusUnprocessableEntity:
 		errorType = "UnprocessableEntityError"
 	case http.StatusTooManyRequests:
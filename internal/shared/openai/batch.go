@@ -19,7 +19,24 @@ package openai
 
 import (
 	"errors"
+	"fmt"
 	"time"
+	"unicode/utf8"
+)
+
+// Limits of the metadata that can be attached to a batch.
+const (
+	MaxMetadataPairs       = 16
+	MaxMetadataKeyLength   = 64
+	MaxMetadataValueLength = 512
+)
+
+// Limits and values of the retry policy of a batch.
+const (
+	MaxRetryAttempts = 10
+
+	RetryOnRateLimit   = "rate_limit"
+	RetryOnServerError = "server_error"
 )
 
 // https://platform.openai.com/docs/api-reference/batch
@@ -40,6 +57,44 @@ func (e Endpoint) String() string {
 	return string(e)
 }
 
+// OutputFormat - Extension. The format of the output and error files of a batch.
+type OutputFormat string
+
+// Supported output formats
+const (
+	OutputFormatJSONL   OutputFormat = "jsonl"
+	OutputFormatJSON    OutputFormat = "json"
+	OutputFormatParquet OutputFormat = "parquet"
+)
+
+func (f OutputFormat) String() string {
+	return string(f)
+}
+
+// Extension returns the file name extension of the format, including the dot.
+func (f OutputFormat) Extension() string {
+	switch f {
+	case OutputFormatJSON:
+		return ".json"
+	case OutputFormatParquet:
+		return ".parquet"
+	default:
+		return ".jsonl"
+	}
+}
+
+// ContentType returns the media type of the files written in the format.
+func (f OutputFormat) ContentType() string {
+	switch f {
+	case OutputFormatJSON:
+		return "application/json"
+	case OutputFormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/jsonl"
+	}
+}
+
 type BatchStatus string
 
 const (
@@ -79,6 +134,53 @@ type BatchSpec struct {
 
 	// required. The Unix timestamp (in seconds) for when the batch was created.
 	CreatedAt int64 `json:"created_at"`
+
+	// optional. Extension. The priority of the batch; batches with higher priority are processed first.
+	Priority int `json:"priority,omitempty"`
+
+	// optional. Extension. Overrides the processor's retry behavior for the requests of the batch.
+	RetryPolicy *RetryPolicy `json:"retry_policy,omitempty"`
+
+	// optional. Extension. The tenant that created the batch, its token usage is accounted to the tenant.
+	TenantID string `json:"tenant_id,omitempty"`
+
+	// optional. Extension. The number of requests of the input file per model, counted when the batch was created.
+	ModelRequestCounts map[string]int64 `json:"model_request_counts,omitempty"`
+
+	// optional. Extension. The inference target (cluster or region) the requests of the batch are sent to.
+	InferenceTarget string `json:"inference_target,omitempty"`
+
+	// optional. Extension. The format of the output and error files, JSONL when empty.
+	OutputFormat OutputFormat `json:"output_format,omitempty"`
+}
+
+// RetryPolicy - Extension. How the requests of a batch failing with a retryable error are retried.
+// Unset fields keep the processor defaults.
+type RetryPolicy struct {
+	// optional. The maximum number of attempts of a request, including the first one. 1 disables retries.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// optional. The error categories that are retried: `rate_limit` and/or `server_error`.
+	RetryOn []string `json:"retry_on,omitempty"`
+
+	// optional. The maximum delay in seconds between two attempts of a request.
+	MaxBackoffSeconds int64 `json:"max_backoff_seconds,omitempty"`
+}
+
+// Validate checks the retry policy against the supported values.
+func (p *RetryPolicy) Validate() error {
+	if p.MaxAttempts < 0 || p.MaxAttempts > MaxRetryAttempts {
+		return fmt.Errorf("retry_policy.max_attempts must be between 1 and %d", MaxRetryAttempts)
+	}
+	for _, category := range p.RetryOn {
+		if category != RetryOnRateLimit && category != RetryOnServerError {
+			return fmt.Errorf("retry_policy.retry_on contains unsupported category %q", category)
+		}
+	}
+	if p.MaxBackoffSeconds < 0 {
+		return errors.New("retry_policy.max_backoff_seconds cannot be negative")
+	}
+	return nil
 }
 
 type BatchStatusInfo struct {
@@ -91,6 +193,17 @@ type BatchStatusInfo struct {
 	// optional. The ID of the file containing the outputs of requests with errors.
 	ErrorFileID string `json:"error_file_id,omitempty"`
 
+	// extension. The IDs of all shards of the output file, set when the output file is sharded.
+	// OutputFileID is the first shard.
+	OutputFileIDs []string `json:"output_file_ids,omitempty"`
+
+	// extension. The IDs of all shards of the error file, set when the error file is sharded.
+	// ErrorFileID is the first shard.
+	ErrorFileIDs []string `json:"error_file_ids,omitempty"`
+
+	// extension. The ID of the manifest file listing the output and error file shards.
+	OutputManifestFileID string `json:"output_manifest_file_id,omitempty"`
+
 	// optional. The Unix timestamp (in seconds) for when the batch was cancelled.
 	CancelledAt *int64 `json:"cancelled_at,omitempty"`
 
@@ -234,6 +347,26 @@ type CreateBatchRequest struct {
 
 	// optional. The expiration policy for the output and/or error file that are generated for a batch.
 	OutputExpiresAfter *OutputExpiresAfter `json:"output_expires_after"`
+
+	// optional. Extension. The priority of the batch, defaults to 0. Batches with higher priority are
+	// processed first. The maximum priority is bounded per tenant by the server configuration.
+	Priority int `json:"priority,omitempty"`
+
+	// optional. Extension. Overrides the processor's retry behavior for the requests of the batch,
+	// e.g. to disable retries of expensive generations.
+	RetryPolicy *RetryPolicy `json:"retry_policy,omitempty"`
+
+	// optional. Extension. When true, the batch is validated (including its input file) and the batch
+	// object that would be created is returned, but nothing is stored or enqueued.
+	ValidateOnly bool `json:"validate_only,omitempty"`
+
+	// optional. Extension. The inference target (cluster or region) the requests of the batch are sent to,
+	// one of those configured on the server. The requests are sent to the default inference gateways when empty.
+	InferenceTarget string `json:"inference_target,omitempty"`
+
+	// optional. Extension. The format of the output and error files: `jsonl` (the default), `json` for a
+	// JSON array of the result lines, or `parquet` for a Parquet file with a row per result line.
+	OutputFormat OutputFormat `json:"output_format,omitempty"`
 }
 
 type OutputExpiresAfter struct {
@@ -253,9 +386,13 @@ func (r *CreateBatchRequest) Validate() error {
 		return errors.New("completion_window is required")
 	}
 
-	if _, err := time.ParseDuration(r.CompletionWindow); err != nil {
+	window, err := time.ParseDuration(r.CompletionWindow)
+	if err != nil {
 		return errors.New("completion_window must be a valid duration (e.g., 24h)")
 	}
+	if window <= 0 {
+		return errors.New("completion_window must be positive")
+	}
 
 	if r.Endpoint == "" {
 		return errors.New("endpoint is required")
@@ -276,6 +413,26 @@ func (r *CreateBatchRequest) Validate() error {
 		return errors.New("input_file_id is required")
 	}
 
+	if r.Priority < 0 {
+		return errors.New("priority cannot be negative")
+	}
+
+	if err := ValidateMetadata(r.Metadata); err != nil {
+		return err
+	}
+
+	if r.RetryPolicy != nil {
+		if err := r.RetryPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+
+	switch r.OutputFormat {
+	case "", OutputFormatJSONL, OutputFormatJSON, OutputFormatParquet:
+	default:
+		return errors.New("invalid output_format: " + string(r.OutputFormat))
+	}
+
 	if r.OutputExpiresAfter != nil {
 		if r.OutputExpiresAfter.Anchor == "" {
 			return errors.New("output_expires_after.anchor is required")
@@ -288,3 +445,22 @@ func (r *CreateBatchRequest) Validate() error {
 
 	return nil
 }
+
+// ValidateMetadata checks the metadata against the OpenAI limits.
+func ValidateMetadata(metadata map[string]string) error {
+	if len(metadata) > MaxMetadataPairs {
+		return fmt.Errorf("metadata can have at most %d key-value pairs", MaxMetadataPairs)
+	}
+	for k, v := range metadata {
+		if k == "" {
+			return errors.New("metadata keys cannot be empty")
+		}
+		if utf8.RuneCountInString(k) > MaxMetadataKeyLength {
+			return fmt.Errorf("metadata key %q exceeds the maximum length of %d characters", k, MaxMetadataKeyLength)
+		}
+		if utf8.RuneCountInString(v) > MaxMetadataValueLength {
+			return fmt.Errorf("metadata value of key %q exceeds the maximum length of %d characters", k, MaxMetadataValueLength)
+		}
+	}
+	return nil
+}
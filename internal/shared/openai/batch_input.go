@@ -0,0 +1,56 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file defines the batch input file line format matching the OpenAI specification.
+package openai
+
+import "encoding/json"
+
+// https://platform.openai.com/docs/api-reference/batch/request-input
+
+// BatchRequestInput - The per-line object of the batch input file.
+type BatchRequestInput struct {
+	// required. A developer-provided per-request id that will be used to match outputs to inputs. Must be unique for each request in a batch.
+	CustomID string `json:"custom_id"`
+
+	// required. The HTTP method to be used for the request. Currently only `POST` is supported.
+	Method string `json:"method"`
+
+	// required. The OpenAI API relative URL to be used for the request.
+	URL string `json:"url"`
+
+	// required. The JSON body of the request.
+	Body json.RawMessage `json:"body"`
+
+	// optional. Extension. The timeout of the request in seconds, overriding the processor's default, so short
+	// requests fail fast while long generations get the time they need. It is bounded by the processor's maximum.
+	TimeoutSeconds float64 `json:"timeout_seconds,omitempty"`
+}
+
+// Error codes reported for invalid lines of a batch input file.
+const (
+	BatchInputErrorInvalidJSON     = "invalid_json_line"
+	BatchInputErrorMissingField    = "missing_required_parameter"
+	BatchInputErrorInvalidMethod   = "invalid_method"
+	BatchInputErrorInvalidURL      = "invalid_url"
+	BatchInputErrorInvalidTimeout  = "invalid_timeout"
+	BatchInputErrorDuplicateID     = "duplicate_custom_id"
+	BatchInputErrorLineTooLarge    = "line_too_large"
+	BatchInputErrorTooManyRequests = "too_many_requests"
+	BatchInputErrorEmptyFile       = "empty_file"
+	BatchInputErrorModelNotFound   = "model_not_found"
+	BatchInputErrorInvalidImage    = "invalid_image"
+)
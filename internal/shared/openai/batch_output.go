@@ -0,0 +1,94 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file defines the batch output and error file line format matching the OpenAI specification.
+package openai
+
+import "encoding/json"
+
+// https://platform.openai.com/docs/api-reference/batch/request-output
+
+// BatchRequestOutput - The per-line object of the batch output and error files.
+type BatchRequestOutput struct {
+	// required. A unique id of the request line.
+	ID string `json:"id"`
+
+	// required. A developer-provided per-request id that will be used to match outputs to inputs.
+	CustomID string `json:"custom_id"`
+
+	// optional. The response of the request, null if the request failed before a response was received.
+	Response *BatchRequestResponse `json:"response"`
+
+	// optional. For requests that failed with a non-HTTP error, this will contain more information on the cause of the failure.
+	Error *BatchRequestError `json:"error"`
+}
+
+type BatchRequestResponse struct {
+	// required. The HTTP status code of the response.
+	StatusCode int `json:"status_code"`
+
+	// required. An unique identifier for the request.
+	RequestID string `json:"request_id"`
+
+	// required. The JSON body of the response.
+	Body json.RawMessage `json:"body"`
+}
+
+type BatchRequestError struct {
+	// required. A machine-readable error code.
+	Code string `json:"code"`
+
+	// required. A human-readable error message.
+	Message string `json:"message"`
+}
+
+// Error codes reported in the error file of a batch.
+const (
+	BatchRequestErrorExpired     = "batch_expired"
+	BatchRequestErrorInvalidLine = "invalid_request"
+)
+
+// BatchOutputManifest - The manifest listing the shards of the output and error files of a batch.
+// This is an extension of the OpenAI specification, published when a result file is split into shards.
+type BatchOutputManifest struct {
+	// required. The object type, which is always `batch.output_manifest`.
+	Object string `json:"object"`
+
+	// required. The ID of the batch.
+	BatchID string `json:"batch_id"`
+
+	// required. The shards of the output file, in order.
+	Output []BatchOutputShard `json:"output"`
+
+	// required. The shards of the error file, in order.
+	Errors []BatchOutputShard `json:"errors"`
+}
+
+const BatchOutputManifestObject = "batch.output_manifest"
+
+type BatchOutputShard struct {
+	// required. The ID of the file holding the shard.
+	FileID string `json:"file_id"`
+
+	// required. The zero based index of the shard.
+	Index int `json:"index"`
+
+	// required. The number of lines in the shard.
+	Lines int64 `json:"lines"`
+
+	// required. The size of the shard in bytes.
+	Bytes int64 `json:"bytes"`
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file defines the Models API data structures matching the OpenAI specification.
+package openai
+
+// https://platform.openai.com/docs/api-reference/models
+
+// Model - Describes a model offering that can be used with the API.
+type Model struct {
+	// required. The model identifier, which can be referenced in the API endpoints.
+	ID string `json:"id"`
+
+	// required. The object type, which is always "model".
+	Object string `json:"object"`
+
+	// required. The Unix timestamp (in seconds) when the model was created.
+	Created int64 `json:"created"`
+
+	// required. The organization that owns the model.
+	OwnedBy string `json:"owned_by"`
+}
+
+type ListModelsResponse struct {
+	// required. The type of object returned, must be `list`.
+	Object string `json:"object"`
+
+	// required. A list of models.
+	Data []Model `json:"data"`
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file defines the Uploads API data structures matching the OpenAI specification.
+package openai
+
+// https://platform.openai.com/docs/api-reference/uploads
+
+// The status of the upload, which can be either `pending`, `completed`, `cancelled`, or `expired`.
+type UploadStatus string
+
+const (
+	UploadStatusPending   UploadStatus = "pending"
+	UploadStatusCompleted UploadStatus = "completed"
+	UploadStatusCancelled UploadStatus = "cancelled"
+	UploadStatusExpired   UploadStatus = "expired"
+)
+
+// Upload - The Upload object can accept byte chunks in the form of Parts.
+type Upload struct {
+	// required. The Upload unique identifier, which can be referenced in API endpoints.
+	ID string `json:"id"`
+
+	// required. The object type, which is always `upload`.
+	Object string `json:"object"`
+
+	// required. The intended number of bytes to be uploaded.
+	Bytes int64 `json:"bytes"`
+
+	// required. The Unix timestamp (in seconds) for when the Upload was created.
+	CreatedAt int64 `json:"created_at"`
+
+	// required. The Unix timestamp (in seconds) for when the Upload will expire.
+	ExpiresAt int64 `json:"expires_at"`
+
+	// required. The name of the file to be uploaded.
+	Filename string `json:"filename"`
+
+	// required. The intended purpose of the file.
+	Purpose FileObjectPurpose `json:"purpose"`
+
+	// required. The status of the Upload.
+	Status UploadStatus `json:"status"`
+
+	// optional. The ready File object after the Upload is completed.
+	File *FileObject `json:"file,omitempty"`
+}
+
+// UploadPart - The upload Part represents a chunk of bytes we can add to an Upload object.
+type UploadPart struct {
+	// required. The upload Part unique identifier, which can be referenced in API endpoints.
+	ID string `json:"id"`
+
+	// required. The object type, which is always `upload.part`.
+	Object string `json:"object"`
+
+	// required. The Unix timestamp (in seconds) for when the Part was created.
+	CreatedAt int64 `json:"created_at"`
+
+	// required. The ID of the Upload object that this Part was added to.
+	UploadID string `json:"upload_id"`
+}
+
+type CreateUploadRequest struct {
+	// required. The name of the file to upload.
+	Filename string `json:"filename"`
+
+	// required. The intended purpose of the uploaded file.
+	Purpose FileObjectPurpose `json:"purpose"`
+
+	// required. The number of bytes in the file you are uploading.
+	Bytes int64 `json:"bytes"`
+
+	// required. The MIME type of the file.
+	MimeType string `json:"mime_type"`
+}
+
+type CompleteUploadRequest struct {
+	// required. The ordered list of Part IDs.
+	PartIDs []string `json:"part_ids"`
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements the overrides of the configuration by environment variables.
+
+package settings
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// EnvPrefix prefixes the environment variables overriding the configuration.
+const EnvPrefix = "BATCH_GATEWAY_"
+
+// EnvName returns the environment variable overriding the key at path, e.g. BATCH_GATEWAY_FILE_STORE_DIR for
+// file_store.dir and BATCH_GATEWAY_PROCESSOR_NUM_WORKERS for processor.num_workers.
+func EnvName(path string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// applyEnv overrides the keys of the common blocks and of the section of the component with the environment
+// variables named after their path. A variable replaces the value of its key: strings are taken as is, the other
+// values are parsed as YAML, e.g. "30s", "true" or `[{name: default, weight: 1}]` for a list. The items of lists
+// and the entries of maps are not overridden individually. Other variables with the prefix are ignored, as
+// Kubernetes defines some for the services named batch-gateway.
+func applyEnv(lookup func(string) (string, bool), component string, common *Common, section any) error {
+	if err := overrideFromEnv(lookup, EnvPrefix, reflect.ValueOf(common).Elem()); err != nil {
+		return err
+	}
+	return overrideFromEnv(lookup, EnvName(component)+"_", reflect.ValueOf(section).Elem())
+}
+
+// overrideFromEnv overrides the fields of the struct v, the environment variables of its keys having prefix.
+func overrideFromEnv(lookup func(string) (string, bool), prefix string, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(options, "inline") {
+			if err := overrideFromEnv(lookup, prefix, v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		envName := prefix + strings.ToUpper(name)
+		if field.Type.Kind() == reflect.Struct && !reflect.PointerTo(field.Type).Implements(textUnmarshalerType) {
+			if err := overrideFromEnv(lookup, envName+"_", v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		value, ok := lookup(envName)
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(v.Field(i), value); err != nil {
+			return fmt.Errorf("invalid environment variable %s: %w", envName, err)
+		}
+	}
+	return nil
+}
+
+// setFromEnv replaces the value of field with value.
+func setFromEnv(field reflect.Value, value string) error {
+	if field.Kind() == reflect.String {
+		field.SetString(value)
+		return nil
+	}
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+	parsed := reflect.New(field.Type())
+	decoder := yaml.NewDecoder(strings.NewReader(value))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(parsed.Interface()); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	field.Set(parsed.Elem())
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The unit tests of the environment variable overrides.
+
+package settings
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv(t *testing.T) {
+	type envSection struct {
+		Name      string            `yaml:"name"`
+		Workers   int               `yaml:"num_workers"`
+		Enabled   bool              `yaml:"enabled"`
+		Timeout   time.Duration     `yaml:"timeout"`
+		Queues    []testQueue       `yaml:"queues"`
+		Limits    map[string]int    `yaml:"limits"`
+		Retry     struct{ Max int } `yaml:"retry"`
+		Unchanged int               `yaml:"unchanged"`
+		Common    `yaml:"-"`
+	}
+	lookup := func(env map[string]string) func(string) (string, bool) {
+		return func(name string) (string, bool) {
+			value, ok := env[name]
+			return value, ok
+		}
+	}
+
+	t.Run("Overrides", func(t *testing.T) {
+		common := NewCommon()
+		section := envSection{Workers: 1, Limits: map[string]int{"a": 1}, Unchanged: 7}
+		env := map[string]string{
+			"BATCH_GATEWAY_FILE_STORE_DIR":                           "/data/files",
+			"BATCH_GATEWAY_OBSERVABILITY_SLOW_OPS_DATABASE":          "250ms",
+			"BATCH_GATEWAY_OBSERVABILITY_METRIC_LABELS_HASH_TENANTS": "true",
+			"BATCH_GATEWAY_PROCESSOR_NAME":                           "# not a comment",
+			"BATCH_GATEWAY_PROCESSOR_NUM_WORKERS":                    "8",
+			"BATCH_GATEWAY_PROCESSOR_ENABLED":                        "true",
+			"BATCH_GATEWAY_PROCESSOR_TIMEOUT":                        "1m30s",
+			"BATCH_GATEWAY_PROCESSOR_QUEUES":                         "[{name: high, weight: 3}, {name: low, weight: 1}]",
+			"BATCH_GATEWAY_PROCESSOR_LIMITS":                         "{b: 2}",
+			"BATCH_GATEWAY_PROCESSOR_RETRY_MAX":                      "5",
+			// the variables of other components and of Kubernetes services are ignored
+			"BATCH_GATEWAY_APISERVER_PORT": "9000",
+			"BATCH_GATEWAY_SERVICE_HOST":   "10.0.0.1",
+		}
+		if err := applyEnv(lookup(env), SectionProcessor, &common, &section); err != nil {
+			t.Fatalf("applyEnv() error = %v", err)
+		}
+		if common.FileStore.Dir != "/data/files" {
+			t.Errorf("FileStore.Dir = %q", common.FileStore.Dir)
+		}
+		if common.Observability.SlowOps.Database != 250*time.Millisecond || !common.Observability.MetricLabels.HashTenants {
+			t.Errorf("Observability = %+v", common.Observability)
+		}
+		want := envSection{
+			Name:      "# not a comment",
+			Workers:   8,
+			Enabled:   true,
+			Timeout:   90 * time.Second,
+			Queues:    []testQueue{{Name: "high", Weight: 3}, {Name: "low", Weight: 1}},
+			Limits:    map[string]int{"b": 2},
+			Unchanged: 7,
+		}
+		want.Retry.Max = 5
+		if !reflect.DeepEqual(section, want) {
+			t.Errorf("section = %+v, want %+v", section, want)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for name, value := range map[string]string{
+			"BATCH_GATEWAY_PROCESSOR_NUM_WORKERS": "many",
+			"BATCH_GATEWAY_PROCESSOR_QUEUES":      "[{name: high, wieght: 3}]",
+		} {
+			common, section := NewCommon(), envSection{}
+			err := applyEnv(lookup(map[string]string{name: value}), SectionProcessor, &common, &section)
+			if err == nil || !strings.Contains(err.Error(), name) {
+				t.Errorf("applyEnv() with %s=%s error = %v, want an error naming the variable", name, value, err)
+			}
+		}
+	})
+
+	t.Run("EnvName", func(t *testing.T) {
+		if got := EnvName("processor.num_workers"); got != "BATCH_GATEWAY_PROCESSOR_NUM_WORKERS" {
+			t.Errorf("EnvName() = %q", got)
+		}
+	})
+}
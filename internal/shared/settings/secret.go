@@ -0,0 +1,386 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements the secrets of the configuration, given as is or as references resolved at startup.
+
+package settings
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+)
+
+// schemes of the secret references
+const (
+	secretFile       = "file:"
+	secretVault      = "vault:"
+	secretKubernetes = "secretref:"
+)
+
+// ServiceAccountDir is the directory of the credentials of the pod's Kubernetes service account.
+const ServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// SecretsConfig configures the resolution of the secret references.
+type SecretsConfig struct {
+	// How often the references are resolved again, so rotated secrets are picked up without a restart
+	// (0 resolves them at startup only)
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+	// Address of the Vault server and file holding its token, VAULT_ADDR and VAULT_TOKEN when empty
+	VaultAddress   string `yaml:"vault_address"`
+	VaultTokenFile string `yaml:"vault_token_file"`
+}
+
+// Secret is a configuration value holding a secret, e.g. an API key or a password. In the configuration file, it
+// is either the secret itself or a reference to it:
+//
+//	file:<path>                          the content of a file, e.g. a mounted Kubernetes Secret
+//	vault:<path>#<key>                   a key of a secret of the Vault KV secrets engine (version 1 or 2),
+//	                                     e.g. vault:secret/data/batch-gateway#admin_api_key
+//	secretref:[<namespace>/]<name>#<key> a key of a Kubernetes Secret, read with the pod's service account, in the
+//	                                     namespace of the pod when none is given
+//
+// The references are resolved by ResolveSecrets. The secrets of a reference share its value, which is refreshed
+// in place, including the ones of a configuration decoded again, e.g. when it is reloaded.
+type Secret struct {
+	ref   string
+	value *secretValue
+}
+
+type secretValue struct {
+	mu    sync.RWMutex
+	value string
+}
+
+// references are the values of the secret references, by reference.
+var references sync.Map
+
+// NewSecret returns the secret of a configuration value, a secret or a reference.
+func NewSecret(text string) Secret {
+	if isSecretReference(text) {
+		value, _ := references.LoadOrStore(text, &secretValue{})
+		return Secret{ref: text, value: value.(*secretValue)}
+	}
+	return Secret{value: &secretValue{value: text}}
+}
+
+func isSecretReference(text string) bool {
+	for _, scheme := range []string{secretFile, secretVault, secretKubernetes} {
+		if strings.HasPrefix(text, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalText decodes the secret from the configuration file or an environment variable.
+func (s *Secret) UnmarshalText(text []byte) error {
+	*s = NewSecret(string(text))
+	return nil
+}
+
+// Value returns the secret, empty until its reference is resolved.
+func (s Secret) Value() string {
+	if s.value == nil {
+		return ""
+	}
+	s.value.mu.RLock()
+	defer s.value.mu.RUnlock()
+	return s.value.value
+}
+
+// IsSet returns whether the secret or its reference is configured.
+func (s Secret) IsSet() bool {
+	return s.ref != "" || s.Value() != ""
+}
+
+// String returns the reference of the secret, so that logging the configuration doesn't leak it.
+func (s Secret) String() string {
+	if s.ref != "" {
+		return s.ref
+	}
+	if s.IsSet() {
+		return "[redacted]"
+	}
+	return ""
+}
+
+// ResolveSecrets resolves the references of the Secret fields of configs, pointers to configurations. When
+// cfg.RefreshInterval is set, they are resolved again every interval until ctx is done; a failed refresh is
+// logged and the previous value kept.
+func ResolveSecrets(ctx context.Context, cfg SecretsConfig, configs ...any) error {
+	var secrets []Secret
+	for _, config := range configs {
+		secrets = collectSecrets(reflect.ValueOf(config), secrets)
+	}
+	// a reference used several times is resolved once
+	secrets = slices.CompactFunc(slices.SortedFunc(slices.Values(secrets), func(a, b Secret) int {
+		return strings.Compare(a.ref, b.ref)
+	}), func(a, b Secret) bool { return a.ref == b.ref })
+	if len(secrets) == 0 {
+		return nil
+	}
+	resolver, err := newSecretResolver(cfg)
+	if err != nil {
+		return err
+	}
+	for _, secret := range secrets {
+		if _, err := resolver.refresh(ctx, secret); err != nil {
+			return err
+		}
+	}
+	if cfg.RefreshInterval > 0 {
+		go resolver.run(ctx, cfg.RefreshInterval, secrets)
+	}
+	return nil
+}
+
+// collectSecrets appends the secret references reachable from v to secrets.
+func collectSecrets(v reflect.Value, secrets []Secret) []Secret {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			secrets = collectSecrets(v.Elem(), secrets)
+		}
+	case reflect.Struct:
+		if secret, ok := v.Interface().(Secret); ok {
+			if secret.ref != "" {
+				secrets = append(secrets, secret)
+			}
+			return secrets
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				secrets = collectSecrets(v.Field(i), secrets)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			secrets = collectSecrets(v.Index(i), secrets)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			secrets = collectSecrets(v.MapIndex(key), secrets)
+		}
+	}
+	return secrets
+}
+
+// secretResolver reads the secrets of the references.
+type secretResolver struct {
+	client *http.Client
+
+	vaultAddress string
+	vaultToken   string
+
+	// API server and service account of the Kubernetes Secrets
+	kubeAddress string
+	kubeDir     string
+	kubeClient  *http.Client
+}
+
+func newSecretResolver(cfg SecretsConfig) (*secretResolver, error) {
+	r := &secretResolver{
+		client:       &http.Client{Timeout: 10 * time.Second},
+		vaultAddress: cfg.VaultAddress,
+		vaultToken:   os.Getenv("VAULT_TOKEN"),
+		kubeDir:      ServiceAccountDir,
+	}
+	if r.vaultAddress == "" {
+		r.vaultAddress = os.Getenv("VAULT_ADDR")
+	}
+	if cfg.VaultTokenFile != "" {
+		token, err := os.ReadFile(cfg.VaultTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault token file: %w", err)
+		}
+		r.vaultToken = strings.TrimSpace(string(token))
+	}
+	if host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"); host != "" {
+		r.kubeAddress = "https://" + net.JoinHostPort(host, port)
+	}
+	return r, nil
+}
+
+// run refreshes the secrets every interval until ctx is done.
+func (r *secretResolver) run(ctx context.Context, interval time.Duration, secrets []Secret) {
+	logger := klog.FromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, secret := range secrets {
+			changed, err := r.refresh(ctx, secret)
+			if err != nil {
+				logger.V(logging.ERROR).Error(err, "Failed to refresh secret, keeping its value", "secret", secret.ref)
+			} else if changed {
+				logger.V(logging.INFO).Info("Secret changed", "secret", secret.ref)
+			}
+		}
+	}
+}
+
+// refresh resolves the reference of the secret, and returns whether its value changed.
+func (r *secretResolver) refresh(ctx context.Context, secret Secret) (bool, error) {
+	value, err := r.resolve(ctx, secret.ref)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve secret %s: %w", secret.ref, err)
+	}
+	secret.value.mu.Lock()
+	defer secret.value.mu.Unlock()
+	changed := secret.value.value != value
+	secret.value.value = value
+	return changed, nil
+}
+
+func (r *secretResolver) resolve(ctx context.Context, ref string) (string, error) {
+	if path, ok := strings.CutPrefix(ref, secretFile); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	var scheme, location string
+	var ok bool
+	if location, ok = strings.CutPrefix(ref, secretVault); ok {
+		scheme = secretVault
+	} else if location, ok = strings.CutPrefix(ref, secretKubernetes); ok {
+		scheme = secretKubernetes
+	}
+	path, key, found := strings.Cut(location, "#")
+	if !found || path == "" || key == "" {
+		return "", fmt.Errorf("the reference must be %s<path>#<key>", scheme)
+	}
+	if scheme == secretVault {
+		return r.vaultSecret(ctx, path, key)
+	}
+	return r.kubernetesSecret(ctx, path, key)
+}
+
+// vaultSecret reads a key of a secret of the Vault KV secrets engine.
+func (r *secretResolver) vaultSecret(ctx context.Context, path, key string) (string, error) {
+	if r.vaultAddress == "" {
+		return "", fmt.Errorf("the vault address is not configured")
+	}
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	header := http.Header{"X-Vault-Token": {r.vaultToken}}
+	if err := getJSON(ctx, r.client, strings.TrimSuffix(r.vaultAddress, "/")+"/v1/"+strings.TrimPrefix(path, "/"), header, &body); err != nil {
+		return "", err
+	}
+	data := body.Data
+	// the data of a secret of the version 2 engine is nested with its metadata
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, hasKey := data[key]; !hasKey {
+			data = nested
+		}
+	}
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("the secret has no string key %q", key)
+	}
+	return value, nil
+}
+
+// kubernetesSecret reads a key of a Kubernetes Secret, with the service account of the pod.
+func (r *secretResolver) kubernetesSecret(ctx context.Context, path, key string) (string, error) {
+	if r.kubeAddress == "" {
+		return "", fmt.Errorf("the Kubernetes API server is unknown, secretref references are resolved in a pod only")
+	}
+	namespace, name, found := strings.Cut(path, "/")
+	if !found {
+		data, err := os.ReadFile(filepath.Join(r.kubeDir, "namespace"))
+		if err != nil {
+			return "", fmt.Errorf("failed to read the namespace of the pod: %w", err)
+		}
+		namespace, name = strings.TrimSpace(string(data)), path
+	}
+	token, err := os.ReadFile(filepath.Join(r.kubeDir, "token"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the service account token: %w", err)
+	}
+	if r.kubeClient == nil {
+		r.kubeClient = r.client
+		if ca, err := os.ReadFile(filepath.Join(r.kubeDir, "ca.crt")); err == nil {
+			pool := x509.NewCertPool()
+			pool.AppendCertsFromPEM(ca)
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+			r.kubeClient = &http.Client{Timeout: r.client.Timeout, Transport: transport}
+		}
+	}
+
+	var secret struct {
+		Data map[string]string `json:"data"`
+	}
+	secretURL := fmt.Sprintf("%s/api/v1/namespaces/%s/secrets/%s", r.kubeAddress, url.PathEscape(namespace), url.PathEscape(name))
+	header := http.Header{"Authorization": {"Bearer " + strings.TrimSpace(string(token))}}
+	if err := getJSON(ctx, r.kubeClient, secretURL, header, &secret); err != nil {
+		return "", err
+	}
+	encoded, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s/%s has no key %q", namespace, name, key)
+	}
+	value, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("key %q of secret %s/%s is not base64 encoded: %w", key, namespace, name, err)
+	}
+	return strings.TrimSpace(string(value)), nil
+}
+
+// getJSON decodes the JSON response of a GET request.
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("GET %s: %s", req.URL.Path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The unit tests of the secrets.
+
+package settings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSecret(t *testing.T) {
+	t.Run("Literal", func(t *testing.T) {
+		secret := NewSecret("s3cret")
+		if secret.Value() != "s3cret" || !secret.IsSet() || secret.String() != "[redacted]" {
+			t.Errorf("literal secret: value %q, set %v, string %q", secret.Value(), secret.IsSet(), secret)
+		}
+		if empty := (Secret{}); empty.IsSet() || empty.Value() != "" {
+			t.Errorf("zero secret is set")
+		}
+	})
+
+	t.Run("Decode", func(t *testing.T) {
+		common := NewCommon()
+		data := "redis:\n  password: file:/run/secrets/redis\nprocessor: {}\n"
+		if err := Decode([]byte(data), SectionProcessor, &common, &testSection{}); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if common.Redis.Password.String() != "file:/run/secrets/redis" || common.Redis.Password.Value() != "" {
+			t.Errorf("Redis.Password = %q (value %q), want an unresolved reference", common.Redis.Password, common.Redis.Password.Value())
+		}
+
+		env := map[string]string{"BATCH_GATEWAY_REDIS_PASSWORD": "# not yaml"}
+		lookup := func(name string) (string, bool) { value, ok := env[name]; return value, ok }
+		if err := applyEnv(lookup, SectionProcessor, &common, &testSection{}); err != nil {
+			t.Fatalf("applyEnv() error = %v", err)
+		}
+		if common.Redis.Password.Value() != "# not yaml" {
+			t.Errorf("Redis.Password = %q, want the environment variable", common.Redis.Password.Value())
+		}
+	})
+
+	t.Run("File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "password")
+		if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+			t.Fatalf("failed to write secret file: %v", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		type config struct {
+			Key     Secret
+			Sinks   []struct{ Token Secret }
+			Unused  Secret
+			private Secret
+		}
+		cfg := &config{Key: NewSecret("file:" + path), Sinks: []struct{ Token Secret }{{Token: NewSecret("file:" + path)}}}
+		copied := *cfg
+		if err := ResolveSecrets(ctx, SecretsConfig{RefreshInterval: 10 * time.Millisecond}, cfg); err != nil {
+			t.Fatalf("ResolveSecrets() error = %v", err)
+		}
+		if cfg.Key.Value() != "first" || cfg.Sinks[0].Token.Value() != "first" || copied.Key.Value() != "first" {
+			t.Errorf("secrets not resolved: %q, %q, copy %q", cfg.Key.Value(), cfg.Sinks[0].Token.Value(), copied.Key.Value())
+		}
+
+		// a rotated secret is refreshed
+		if err := os.WriteFile(path, []byte("second\n"), 0o600); err != nil {
+			t.Fatalf("failed to write secret file: %v", err)
+		}
+		deadline := time.Now().Add(time.Second)
+		for cfg.Key.Value() != "second" && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if cfg.Key.Value() != "second" {
+			t.Errorf("secret not refreshed: %q", cfg.Key.Value())
+		}
+
+		// a configuration decoded again shares the resolved values, and is equal to the resolved one
+		reloaded := &config{Key: NewSecret("file:" + path), Sinks: []struct{ Token Secret }{{Token: NewSecret("file:" + path)}}}
+		if !reflect.DeepEqual(reloaded, cfg) || reloaded.Key.Value() != "second" {
+			t.Errorf("reloaded configuration = %+v (key %q), want the resolved one", reloaded, reloaded.Key.Value())
+		}
+		if !reflect.DeepEqual(NewSecret("s3cret"), NewSecret("s3cret")) {
+			t.Errorf("equal literal secrets are not deeply equal")
+		}
+
+		// the resolution of a missing file fails at startup
+		missing := &config{Key: NewSecret("file:" + path + ".missing")}
+		if err := ResolveSecrets(ctx, SecretsConfig{}, missing); err == nil {
+			t.Errorf("ResolveSecrets() of a missing file succeeded")
+		}
+	})
+
+	t.Run("Vault", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Vault-Token") != "root" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			switch r.URL.Path {
+			case "/v1/secret/data/batch-gateway": // version 2
+				fmt.Fprint(w, `{"data": {"data": {"admin_api_key": "from-kv2"}, "metadata": {"version": 3}}}`)
+			case "/v1/kv/batch-gateway": // version 1
+				fmt.Fprint(w, `{"data": {"admin_api_key": "from-kv1"}}`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer server.Close()
+		t.Setenv("VAULT_TOKEN", "root")
+
+		resolver, err := newSecretResolver(SecretsConfig{VaultAddress: server.URL})
+		if err != nil {
+			t.Fatalf("newSecretResolver() error = %v", err)
+		}
+		for ref, want := range map[string]string{
+			"vault:secret/data/batch-gateway#admin_api_key": "from-kv2",
+			"vault:kv/batch-gateway#admin_api_key":          "from-kv1",
+		} {
+			if got, err := resolver.resolve(context.Background(), ref); err != nil || got != want {
+				t.Errorf("resolve(%s) = %q, %v, want %q", ref, got, err, want)
+			}
+		}
+		for _, ref := range []string{"vault:secret/data/batch-gateway#missing", "vault:secret/data/other#key", "vault:secret/data/batch-gateway"} {
+			if _, err := resolver.resolve(context.Background(), ref); err == nil {
+				t.Errorf("resolve(%s) succeeded", ref)
+			}
+		}
+	})
+
+	t.Run("Kubernetes", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer sa-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Path != "/api/v1/namespaces/batch/secrets/gateway" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			// "s3cret", base64 encoded
+			fmt.Fprint(w, `{"kind": "Secret", "data": {"password": "czNjcmV0"}}`)
+		}))
+		defer server.Close()
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "token"), []byte("sa-token"), 0o600)
+		os.WriteFile(filepath.Join(dir, "namespace"), []byte("batch"), 0o600)
+
+		resolver := &secretResolver{client: server.Client(), kubeAddress: server.URL, kubeDir: dir}
+		for _, ref := range []string{"secretref:batch/gateway#password", "secretref:gateway#password"} {
+			if got, err := resolver.resolve(context.Background(), ref); err != nil || got != "s3cret" {
+				t.Errorf("resolve(%s) = %q, %v", ref, got, err)
+			}
+		}
+		if _, err := resolver.resolve(context.Background(), "secretref:gateway#missing"); err == nil {
+			t.Errorf("resolve() of a missing key succeeded")
+		}
+	})
+}
@@ -0,0 +1,399 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file defines the configuration schema shared by the components: the blocks of the configuration file
+// common to all of them, and the loading of the file with strict checking of its keys.
+
+package settings
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/encryption"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/features"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/labels"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/redact"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/redis"
+	"github.com/llm-d-incubation/batch-gateway/internal/util/slowop"
+)
+
+// Sections are the keys of the component sections of the configuration file.
+const (
+	SectionAPIServer = "apiserver"
+	SectionProcessor = "processor"
+)
+
+var sections = []string{SectionAPIServer, SectionProcessor}
+
+const (
+	FileStoreFS     = "fs"
+	DefaultFilesDir = "/tmp/batch-gateway/files"
+)
+
+// Common are the blocks of the configuration file shared by the components.
+type Common struct {
+	Redis         RedisConfig         `yaml:"redis"`
+	FileStore     FileStoreConfig     `yaml:"file_store"`
+	Database      DatabaseConfig      `yaml:"database"`
+	Observability ObservabilityConfig `yaml:"observability"`
+	Secrets       SecretsConfig       `yaml:"secrets"`
+	// Encryption of the payloads of the status store, which hold request content
+	PayloadEncryption PayloadEncryptionConfig `yaml:"payload_encryption"`
+	// States of the feature flags gating capabilities of the components
+	Features features.Config `yaml:"features"`
+}
+
+// RedisConfig configures the connection to redis. Redis is not used when URL is empty.
+type RedisConfig struct {
+	// redis://, rediss:// or unix:// URL
+	URL string `yaml:"url"`
+	// ACL user and its password, or the file containing it. They override the credentials of URL.
+	Username     string        `yaml:"username"`
+	Password     Secret        `yaml:"password"`
+	PasswordFile string        `yaml:"password_file"`
+	DB           int           `yaml:"db"`
+	Timeout      time.Duration `yaml:"timeout"`
+}
+
+// FileStoreConfig configures the store of the batch input and output files.
+type FileStoreConfig struct {
+	// Type of the store, only fs (a file system directory, shared by the components) is supported
+	Type string `yaml:"type"`
+	Dir  string `yaml:"dir"`
+}
+
+// DatabaseConfig configures the database of the batches and files. The database is not used when URL is empty.
+type DatabaseConfig struct {
+	// postgres:// or postgresql:// URL
+	URL string `yaml:"url"`
+}
+
+// PayloadEncryptionConfig configures the encryption of the payloads written to redis that may hold request content,
+// e.g. the cached inference responses. Payloads are not encrypted when KeyID is empty.
+type PayloadEncryptionConfig struct {
+	// ID of the key encrypting the payloads, stored in cleartext with them
+	KeyID string `yaml:"key_id"`
+	// Base64 encoded AES keys of 16, 24 or 32 bytes, by ID. The keys other than KeyID only decrypt, so the payloads
+	// written before a rotation of the key can still be read.
+	Keys map[string]Secret `yaml:"keys"`
+}
+
+// Enabled returns whether the payloads are encrypted.
+func (c PayloadEncryptionConfig) Enabled() bool {
+	return c.KeyID != ""
+}
+
+// Cipher returns the cipher of the payloads, nil if they are not encrypted. The references of the keys must be
+// resolved; the keys are read once, so a rotation requires a restart.
+func (c PayloadEncryptionConfig) Cipher() (*encryption.PayloadCipher, error) {
+	if !c.Enabled() {
+		return nil, nil
+	}
+	keys := make(map[string][]byte, len(c.Keys))
+	for id, secret := range c.Keys {
+		key, err := base64.StdEncoding.DecodeString(secret.Value())
+		if err != nil {
+			return nil, fmt.Errorf("payload_encryption.keys.%s is not base64 encoded: %w", id, err)
+		}
+		keys[id] = key
+	}
+	cipher, err := encryption.NewPayloadCipher(c.KeyID, keys)
+	if err != nil {
+		return nil, fmt.Errorf("payload_encryption: %w", err)
+	}
+	return cipher, nil
+}
+
+// ObservabilityConfig configures the metrics, logs and traces of the components.
+type ObservabilityConfig struct {
+	// Bounds the distinct models and tenants labeling the metrics.
+	MetricLabels labels.Config `yaml:"metric_labels"`
+	// Durations above which operations are logged as slow.
+	SlowOps slowop.Config `yaml:"slow_ops"`
+	// Redaction of the request and response content in the logs and the traces.
+	Redaction redact.Config `yaml:"redaction"`
+	// Log the spans of the processing stages of the batches submitted with a trace context.
+	LogSpans bool `yaml:"log_spans"`
+}
+
+// NewCommon returns the common blocks with default values.
+func NewCommon() Common {
+	return Common{
+		FileStore: FileStoreConfig{Type: FileStoreFS, Dir: DefaultFilesDir},
+		Observability: ObservabilityConfig{
+			MetricLabels: labels.NewConfig(),
+			SlowOps:      slowop.NewConfig(),
+		},
+	}
+}
+
+// Validate checks the common blocks. The errors name the invalid keys by their path in the file.
+func (c *Common) Validate() error {
+	if c.Redis.URL != "" {
+		if err := checkURL(c.Redis.URL, "redis", "rediss", "unix"); err != nil {
+			return fmt.Errorf("redis.url %w", err)
+		}
+	}
+	if c.Redis.DB < 0 || c.Redis.Timeout < 0 {
+		return fmt.Errorf("redis.db and redis.timeout cannot be negative")
+	}
+	if c.Database.URL != "" {
+		if err := checkURL(c.Database.URL, "postgres", "postgresql"); err != nil {
+			return fmt.Errorf("database.url %w", err)
+		}
+	}
+	if c.FileStore.Type != FileStoreFS {
+		return fmt.Errorf("file_store.type %q is not supported, it must be %s", c.FileStore.Type, FileStoreFS)
+	}
+	if c.FileStore.Dir == "" {
+		return fmt.Errorf("file_store.dir cannot be empty")
+	}
+	if c.Observability.MetricLabels.MaxModels < 0 || c.Observability.MetricLabels.MaxTenants < 0 {
+		return fmt.Errorf("observability.metric_labels.max_models and observability.metric_labels.max_tenants cannot be negative")
+	}
+	if err := c.Observability.SlowOps.Validate(); err != nil {
+		return fmt.Errorf("observability.slow_ops: %w", err)
+	}
+	if err := c.Observability.Redaction.Validate(); err != nil {
+		return fmt.Errorf("observability.redaction: %w", err)
+	}
+	if c.PayloadEncryption.Enabled() {
+		if _, ok := c.PayloadEncryption.Keys[c.PayloadEncryption.KeyID]; !ok {
+			return fmt.Errorf("payload_encryption.key_id %q is not one of payload_encryption.keys", c.PayloadEncryption.KeyID)
+		}
+	}
+	if c.Secrets.RefreshInterval < 0 {
+		return fmt.Errorf("secrets.refresh_interval cannot be negative")
+	}
+	if err := c.Features.Validate(); err != nil {
+		return fmt.Errorf("features: %w", err)
+	}
+	return nil
+}
+
+func checkURL(rawURL string, schemes ...string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("is not a valid URL: %w", err)
+	}
+	if !slices.Contains(schemes, u.Scheme) {
+		return fmt.Errorf("must be a %s:// URL", strings.Join(schemes, ":// or "))
+	}
+	return nil
+}
+
+// ClientConfig returns the configuration of the redis clients of a service.
+func (c RedisConfig) ClientConfig(serviceName string) *redis.RedisClientConfig {
+	return &redis.RedisClientConfig{
+		Url:          c.URL,
+		DbIdx:        c.DB,
+		Username:     c.Username,
+		Password:     c.Password.Value(),
+		PasswordFile: c.PasswordFile,
+		EnableTLS:    strings.HasPrefix(c.URL, "rediss://"),
+		ServiceName:  serviceName,
+		Timeout:      c.Timeout,
+	}
+}
+
+// Load reads the configuration file at path: the common blocks into common, and the section of the component into
+// section, a pointer to the configuration of the component. The values missing from the file are left unchanged.
+// The keys are then overridden by the BATCH_GATEWAY_* environment variables, see EnvName. The configuration is not
+// validated.
+func Load(path, component string, common *Common, section any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := Decode(data, component, common, section); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return applyEnv(os.LookupEnv, component, common, section)
+}
+
+// Decode decodes a configuration file like Load. Unknown keys in the common blocks and in the section of the
+// component are errors, reported with the closest known key. The sections of the other components are not decoded.
+func Decode(data []byte, component string, common *Common, section any) error {
+	if !slices.Contains(sections, component) {
+		return fmt.Errorf("unknown component section %q", component)
+	}
+	sectionValue := reflect.ValueOf(section)
+	if sectionValue.Kind() != reflect.Pointer || sectionValue.IsNil() {
+		return fmt.Errorf("the section of %s must be decoded into a non-nil pointer", component)
+	}
+
+	// the file is decoded in a single pass, so that the errors have the lines of the file: the common blocks are
+	// inlined, the section of the component is decoded into section and the other sections are kept as nodes
+	fields := []reflect.StructField{{Name: "Common", Type: reflect.TypeOf(Common{}), Tag: `yaml:",inline"`}}
+	for i, name := range sections {
+		typ := reflect.TypeOf(yaml.Node{})
+		if name == component {
+			typ = sectionValue.Type()
+		}
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("Section%d", i),
+			Type: typ,
+			Tag:  reflect.StructTag(fmt.Sprintf(`yaml:"%s"`, name)),
+		})
+	}
+	file := reflect.New(reflect.StructOf(fields)).Elem()
+	file.Field(0).Set(reflect.ValueOf(*common))
+	file.Field(1 + slices.Index(sections, component)).Set(sectionValue)
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(file.Addr().Interface()); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			return explainUnknownKeys(typeErr, file.Type(), component, sectionValue.Type())
+		}
+		return err
+	}
+	*common = file.Field(0).Interface().(Common)
+	return nil
+}
+
+// movedKeys are the common keys that were keys of the component sections.
+var movedKeys = map[string]string{
+	"files_dir":     "file_store.dir",
+	"database_url":  "database.url",
+	"metric_labels": "observability.metric_labels",
+	"slow_ops":      "observability.slow_ops",
+	"log_spans":     "observability.log_spans",
+}
+
+var unknownKeyPattern = regexp.MustCompile(`^(line \d+): field (\S+) not found in type (.+)$`)
+
+// explainUnknownKeys rewrites the errors of unknown keys with the closest known keys.
+func explainUnknownKeys(err *yaml.TypeError, fileType reflect.Type, component string, sectionType reflect.Type) error {
+	keys := map[string][]string{}
+	collectKeys(fileType, keys)
+	sectionKeys := keys[indirect(sectionType).String()]
+
+	explained := &yaml.TypeError{}
+	for _, msg := range err.Errors {
+		match := unknownKeyPattern.FindStringSubmatch(msg)
+		if match == nil {
+			explained.Errors = append(explained.Errors, msg)
+			continue
+		}
+		line, key, typeName := match[1], match[2], match[3]
+		var hint string
+		if typeName == fileType.String() {
+			// a key of the flat files of the components, before the common blocks and the sections
+			if moved, ok := movedKeys[key]; ok {
+				hint = moved
+			} else if slices.Contains(sectionKeys, key) {
+				hint = component + "." + key
+			}
+		}
+		if hint == "" {
+			hint = closest(key, keys[typeName])
+		}
+		msg = fmt.Sprintf("%s: unknown key %q", line, key)
+		if hint != "" {
+			msg += fmt.Sprintf(", did you mean %q?", hint)
+		}
+		explained.Errors = append(explained.Errors, msg)
+	}
+	return explained
+}
+
+// collectKeys collects the YAML keys of the struct types reachable from t, by type name.
+func collectKeys(t reflect.Type, keys map[string][]string) {
+	t = indirect(t)
+	if t.Kind() != reflect.Struct || t == reflect.TypeOf(yaml.Node{}) {
+		return
+	}
+	if _, ok := keys[t.String()]; ok {
+		return
+	}
+	keys[t.String()] = nil
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		collectKeys(field.Type, keys)
+		if strings.Contains(options, "inline") {
+			names = append(names, keys[indirect(field.Type).String()]...)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		names = append(names, name)
+	}
+	keys[t.String()] = names
+}
+
+// indirect returns the element type of pointers, slices and maps.
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	return t
+}
+
+// closest returns the known key closest to key, or an empty string when none is close enough to be a typo.
+func closest(key string, known []string) string {
+	best, bestDistance := "", len(key)/2+1
+	for _, candidate := range known {
+		if d := distance(key, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// distance returns the Levenshtein distance of a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The unit tests of the configuration schema.
+
+package settings
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/redact"
+)
+
+// testSection is the section of a component in the tests.
+type testSection struct {
+	NumWorkers int           `yaml:"num_workers"`
+	Queues     []testQueue   `yaml:"queues"`
+	Timeout    time.Duration `yaml:"timeout"`
+}
+
+type testQueue struct {
+	Name   string `yaml:"name"`
+	Weight int    `yaml:"weight"`
+}
+
+func TestDecode(t *testing.T) {
+	decode := func(data string) (Common, testSection, error) {
+		common := NewCommon()
+		section := testSection{NumWorkers: 1, Timeout: time.Minute}
+		err := Decode([]byte(data), SectionProcessor, &common, &section)
+		return common, section, err
+	}
+
+	t.Run("Sections", func(t *testing.T) {
+		common, section, err := decode(`
+file_store:
+  dir: /data/files
+observability:
+  slow_ops:
+    database: 2s
+apiserver:
+  port: "8000"
+  unknown_to_the_processor: true
+processor:
+  num_workers: 8
+  queues:
+    - name: default
+      weight: 2
+`)
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if common.FileStore.Dir != "/data/files" || common.FileStore.Type != FileStoreFS {
+			t.Errorf("FileStore = %+v, want the dir of the file and the default type", common.FileStore)
+		}
+		if common.Observability.SlowOps.Database != 2*time.Second || common.Observability.SlowOps.Handler != 5*time.Second {
+			t.Errorf("SlowOps = %+v, want the database threshold of the file and the default handler threshold", common.Observability.SlowOps)
+		}
+		if section.NumWorkers != 8 || section.Timeout != time.Minute || len(section.Queues) != 1 || section.Queues[0].Weight != 2 {
+			t.Errorf("section = %+v", section)
+		}
+		if err := common.Validate(); err != nil {
+			t.Errorf("Validate() error = %v", err)
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		common, section, err := decode("")
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		if common.FileStore.Dir != DefaultFilesDir || section.NumWorkers != 1 {
+			t.Errorf("defaults not kept: %+v, %+v", common, section)
+		}
+	})
+
+	t.Run("UnknownKeys", func(t *testing.T) {
+		tests := []struct {
+			name string
+			data string
+			want string
+		}{
+			{
+				name: "typo in the section",
+				data: "processor:\n  num_worker: 8\n",
+				want: `line 2: unknown key "num_worker", did you mean "num_workers"?`,
+			},
+			{
+				name: "typo in a list",
+				data: "processor:\n  queues:\n    - name: default\n      wieght: 2\n",
+				want: `line 4: unknown key "wieght", did you mean "weight"?`,
+			},
+			{
+				name: "typo in a common block",
+				data: "observability:\n  slow_ops:\n    databse: 2s\n",
+				want: `line 3: unknown key "databse", did you mean "database"?`,
+			},
+			{
+				name: "key of the section at the top level",
+				data: "num_workers: 8\n",
+				want: `line 1: unknown key "num_workers", did you mean "processor.num_workers"?`,
+			},
+			{
+				name: "moved key",
+				data: "files_dir: /data/files\n",
+				want: `line 1: unknown key "files_dir", did you mean "file_store.dir"?`,
+			},
+			{
+				name: "no close key",
+				data: "processor:\n  something_else: 1\n",
+				want: `line 2: unknown key "something_else"`,
+			},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				_, _, err := decode(tc.data)
+				if err == nil {
+					t.Fatalf("Decode() error = nil, want %q", tc.want)
+				}
+				if !strings.Contains(err.Error(), tc.want) {
+					t.Errorf("Decode() error = %q, want %q", err, tc.want)
+				}
+			})
+		}
+	})
+
+	t.Run("InvalidValue", func(t *testing.T) {
+		_, _, err := decode("processor:\n  num_workers: many\n")
+		if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("Decode() error = %v, want an error at line 2", err)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Common)
+		want   string
+	}{
+		{name: "defaults"},
+		{name: "redis url", modify: func(c *Common) { c.Redis.URL = "rediss://redis:6380" }},
+		{name: "database url", modify: func(c *Common) { c.Database.URL = "postgres://postgres:5432/batch" }},
+		{name: "redis scheme", modify: func(c *Common) { c.Redis.URL = "http://redis:6379" }, want: "redis.url must be a redis://"},
+		{name: "database scheme", modify: func(c *Common) { c.Database.URL = "mysql://db" }, want: "database.url must be a postgres://"},
+		{name: "file store type", modify: func(c *Common) { c.FileStore.Type = "s3" }, want: "file_store.type"},
+		{name: "file store dir", modify: func(c *Common) { c.FileStore.Dir = "" }, want: "file_store.dir cannot be empty"},
+		{name: "metric labels", modify: func(c *Common) { c.Observability.MetricLabels.MaxTenants = -1 }, want: "observability.metric_labels"},
+		{name: "slow ops", modify: func(c *Common) { c.Observability.SlowOps.Database = -time.Second }, want: "observability.slow_ops"},
+		{name: "redaction", modify: func(c *Common) {
+			c.Observability.Redaction.Rules = []redact.Rule{{Name: "broken", Pattern: "("}}
+		}, want: "observability.redaction"},
+		{name: "payload encryption", modify: func(c *Common) {
+			c.PayloadEncryption = PayloadEncryptionConfig{KeyID: "k1", Keys: map[string]Secret{"k1": NewSecret("file:/keys/k1")}}
+		}},
+		{name: "payload encryption key", modify: func(c *Common) {
+			c.PayloadEncryption = PayloadEncryptionConfig{KeyID: "k2", Keys: map[string]Secret{"k1": NewSecret("file:/keys/k1")}}
+		}, want: "payload_encryption.key_id"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			common := NewCommon()
+			if tc.modify != nil {
+				tc.modify(&common)
+			}
+			err := common.Validate()
+			if tc.want == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestPayloadEncryptionCipher(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	if cipher, err := (PayloadEncryptionConfig{}).Cipher(); cipher != nil || err != nil {
+		t.Errorf("Cipher() of disabled encryption = %v, %v, want nil", cipher, err)
+	}
+	cipher, err := PayloadEncryptionConfig{KeyID: "k1", Keys: map[string]Secret{"k1": NewSecret(key)}}.Cipher()
+	if err != nil || cipher == nil || cipher.KeyID() != "k1" {
+		t.Errorf("Cipher() = %v, %v, want a cipher of k1", cipher, err)
+	}
+	if _, err := (PayloadEncryptionConfig{KeyID: "k1", Keys: map[string]Secret{"k1": NewSecret("not base64!")}}).Cipher(); err == nil {
+		t.Error("Cipher() of a key that isn't base64 encoded succeeded")
+	}
+	if _, err := (PayloadEncryptionConfig{KeyID: "k1", Keys: map[string]Secret{"k1": NewSecret("c2hvcnQ=")}}).Cipher(); err == nil {
+		t.Error("Cipher() of a short key succeeded")
+	}
+}
@@ -32,6 +32,9 @@ type BatchClientAdmin interface {
 	// If no time limit is set, the context will be set with a default time limit.
 	GetContext(parentCtx context.Context, timeLimit time.Duration) (context.Context, context.CancelFunc)
 
+	// Ping checks that the backend of the client is reachable.
+	Ping(ctx context.Context) error
+
 	// Close closes the client.
 	Close() error
 }
@@ -0,0 +1,121 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file provides the aggregated health check of the storage clients of a component.
+
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultHealthCheckTimeout is the time limit of the health check of a single client.
+const DefaultHealthCheckTimeout = 2 * time.Second
+
+// ClientStatus is the result of the health check of a single client.
+type ClientStatus struct {
+	// Err is the error of the ping, nil if the client is healthy
+	Err error
+	// Latency is the time the ping took
+	Latency time.Duration
+}
+
+// Healthy reports whether the ping of the client succeeded.
+func (s ClientStatus) Healthy() bool {
+	return s.Err == nil
+}
+
+// Clientset is the set of the storage clients constructed by a component, by name.
+// It is safe for concurrent use, so clients may be added while the readiness endpoint is served.
+type Clientset struct {
+	// Timeout is the time limit of the health check of a single client
+	Timeout time.Duration
+
+	mu      sync.RWMutex
+	clients map[string]BatchClientAdmin
+}
+
+// NewClientset creates an empty client set.
+func NewClientset() *Clientset {
+	return &Clientset{
+		Timeout: DefaultHealthCheckTimeout,
+		clients: map[string]BatchClientAdmin{},
+	}
+}
+
+// Add registers a client under the given name, replacing the client previously registered under it.
+// Nil clients, i.e. backends the component isn't configured with, are skipped.
+func (c *Clientset) Add(name string, client BatchClientAdmin) {
+	if client == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clients[name] = client
+}
+
+// Len returns the number of clients in the set.
+func (c *Clientset) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.clients)
+}
+
+// HealthCheck pings all the clients concurrently, each within the time limit of the set,
+// and returns their status by name. It returns nil when the set is nil or empty.
+func (c *Clientset) HealthCheck(ctx context.Context) map[string]ClientStatus {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	clients := make(map[string]BatchClientAdmin, len(c.clients))
+	for name, client := range c.clients {
+		clients[name] = client
+	}
+	c.mu.RUnlock()
+	if len(clients) == 0 {
+		return nil
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]ClientStatus, len(clients))
+	)
+	for name, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := client.GetContext(ctx, c.Timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := client.Ping(checkCtx)
+			status := ClientStatus{Err: err, Latency: time.Since(start)}
+
+			mu.Lock()
+			results[name] = status
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file contains unit tests for the aggregated health check of the storage clients.
+
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testClient is a client whose ping returns err, or blocks until its context is done when hang is set.
+type testClient struct {
+	err  error
+	hang bool
+}
+
+func (c *testClient) GetContext(parentCtx context.Context, timeLimit time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parentCtx, timeLimit)
+}
+
+func (c *testClient) Ping(ctx context.Context) error {
+	if c.hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return c.err
+}
+
+func (c *testClient) Close() error {
+	return nil
+}
+
+func TestClientsetHealthCheck(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		var nilset *Clientset
+		if got := nilset.HealthCheck(context.Background()); got != nil {
+			t.Errorf("nil set: expected no status, got %v", got)
+		}
+		if got := NewClientset().HealthCheck(context.Background()); got != nil {
+			t.Errorf("empty set: expected no status, got %v", got)
+		}
+	})
+
+	t.Run("NilClient", func(t *testing.T) {
+		clientset := NewClientset()
+		var client BatchClientAdmin
+		clientset.Add("database", client)
+		if clientset.Len() != 0 {
+			t.Errorf("expected the nil client to be skipped, got %d clients", clientset.Len())
+		}
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		clientset := NewClientset()
+		clientset.Timeout = 50 * time.Millisecond
+		clientset.Add("database", &testClient{})
+		clientset.Add("queue", &testClient{err: errors.New("connection refused")})
+		clientset.Add("events", &testClient{hang: true})
+		clientset.Add("status", &testClient{hang: true})
+
+		start := time.Now()
+		status := clientset.HealthCheck(context.Background())
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected the clients to be checked concurrently within the timeout, took %v", elapsed)
+		}
+		if len(status) != 4 {
+			t.Fatalf("expected 4 statuses, got %v", status)
+		}
+		if !status["database"].Healthy() {
+			t.Errorf("expected database to be healthy, got %v", status["database"].Err)
+		}
+		if status["queue"].Healthy() || status["queue"].Err.Error() != "connection refused" {
+			t.Errorf("unexpected queue status: %+v", status["queue"])
+		}
+		for _, name := range []string{"events", "status"} {
+			if !errors.Is(status[name].Err, context.DeadlineExceeded) {
+				t.Errorf("expected %s to time out, got %v", name, status[name].Err)
+			}
+		}
+	})
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements the encryption of the payloads written to the shared storage, e.g. redis, with AES-GCM.
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// payloadHeader starts the encrypted payloads. A cleartext payload, JSON or text, never starts with a NUL byte.
+const payloadHeader = "\x00bge1"
+
+// maxKeyIDLen is the maximum length of a key ID, stored in a byte of the header.
+const maxKeyIDLen = 255
+
+// ErrUnknownKey is returned when a payload was encrypted with a key the cipher doesn't have.
+var ErrUnknownKey = errors.New("the payload is encrypted with an unknown key")
+
+// PayloadCipher encrypts payloads with AES-GCM. An encrypted payload is
+//
+//	payloadHeader | length of the key ID | key ID | nonce | ciphertext and tag
+//
+// so it can be decrypted by a cipher having the key, after the key used for encryption was rotated.
+// A PayloadCipher is safe for concurrent use.
+type PayloadCipher struct {
+	keyID string
+	aeads map[string]cipher.AEAD
+}
+
+// NewPayloadCipher returns a cipher encrypting with the key keyID of keys, and decrypting with any of keys.
+// The keys are AES keys of 16, 24 or 32 bytes, by ID.
+func NewPayloadCipher(keyID string, keys map[string][]byte) (*PayloadCipher, error) {
+	if _, ok := keys[keyID]; !ok {
+		return nil, fmt.Errorf("key %q is not one of the keys", keyID)
+	}
+	c := &PayloadCipher{keyID: keyID, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" || len(id) > maxKeyIDLen {
+			return nil, fmt.Errorf("key ID %q must have 1 to %d characters", id, maxKeyIDLen)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		c.aeads[id] = aead
+	}
+	return c, nil
+}
+
+// KeyID returns the ID of the key encrypting the payloads.
+func (c *PayloadCipher) KeyID() string {
+	return c.keyID
+}
+
+// Encrypt encrypts data. The associated data, e.g. the key the payload is stored under, is authenticated but not
+// encrypted: the payload can only be decrypted with the same associated data, so it can't be moved to another key.
+func (c *PayloadCipher) Encrypt(data, associated []byte) ([]byte, error) {
+	aead := c.aeads[c.keyID]
+	header := make([]byte, 0, len(payloadHeader)+1+len(c.keyID))
+	header = append(header, payloadHeader...)
+	header = append(header, byte(len(c.keyID)))
+	header = append(header, c.keyID...)
+
+	out := make([]byte, len(header), len(header)+aead.NonceSize()+len(data)+aead.Overhead())
+	copy(out, header)
+	nonce := out[len(header) : len(header)+aead.NonceSize()]
+	out = out[:len(header)+aead.NonceSize()]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(out, nonce, data, append(header, associated...)), nil
+}
+
+// Decrypt decrypts a payload encrypted by Encrypt with the same associated data. Cleartext payloads, e.g. written
+// before the encryption was enabled, are returned as is.
+func (c *PayloadCipher) Decrypt(data, associated []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	rest := data[len(payloadHeader):]
+	if len(rest) < 1 || len(rest) < 1+int(rest[0]) {
+		return nil, fmt.Errorf("the encrypted payload is truncated")
+	}
+	keyID := string(rest[1 : 1+int(rest[0])])
+	aead, ok := c.aeads[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	header := data[:len(payloadHeader)+1+len(keyID)]
+	sealed := data[len(header):]
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, fmt.Errorf("the encrypted payload is truncated")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, append(bytes.Clone(header), associated...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the payload with key %q: %w", keyID, err)
+	}
+	return plaintext, nil
+}
+
+// IsEncrypted reports whether data is a payload encrypted by a PayloadCipher.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(payloadHeader))
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file contains tests for the encryption of the payloads.
+
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPayloadCipher(t *testing.T) {
+	oldKey, newKey := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 16)
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		c, err := NewPayloadCipher("k1", map[string][]byte{"k1": oldKey})
+		if err != nil {
+			t.Fatalf("NewPayloadCipher failed: %v", err)
+		}
+		payload := []byte(`{"messages":[{"role":"user","content":"secret prompt"}]}`)
+		encrypted, err := c.Encrypt(payload, []byte("job-1"))
+		if err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+		if !IsEncrypted(encrypted) || bytes.Contains(encrypted, []byte("secret prompt")) {
+			t.Errorf("payload is not encrypted: %q", encrypted)
+		}
+		if !bytes.Contains(encrypted, []byte("k1")) {
+			t.Errorf("encrypted payload doesn't carry the key ID: %q", encrypted)
+		}
+		decrypted, err := c.Decrypt(encrypted, []byte("job-1"))
+		if err != nil || !bytes.Equal(decrypted, payload) {
+			t.Errorf("Decrypt = %q, %v, want %q", decrypted, err, payload)
+		}
+		if _, err := c.Decrypt(encrypted, []byte("job-2")); err == nil {
+			t.Error("Decrypt with other associated data succeeded")
+		}
+		tampered := bytes.Clone(encrypted)
+		tampered[len(tampered)-1] ^= 1
+		if _, err := c.Decrypt(tampered, []byte("job-1")); err == nil {
+			t.Error("Decrypt of a tampered payload succeeded")
+		}
+		if _, err := c.Decrypt(encrypted[:len(payloadHeader)+2], []byte("job-1")); err == nil {
+			t.Error("Decrypt of a truncated payload succeeded")
+		}
+	})
+
+	t.Run("Cleartext", func(t *testing.T) {
+		c, _ := NewPayloadCipher("k1", map[string][]byte{"k1": oldKey})
+		payload := []byte(`{"completed":10}`)
+		decrypted, err := c.Decrypt(payload, []byte("job-1"))
+		if err != nil || !bytes.Equal(decrypted, payload) {
+			t.Errorf("Decrypt = %q, %v, want the cleartext payload", decrypted, err)
+		}
+	})
+
+	t.Run("Rotation", func(t *testing.T) {
+		before, _ := NewPayloadCipher("k1", map[string][]byte{"k1": oldKey})
+		after, _ := NewPayloadCipher("k2", map[string][]byte{"k1": oldKey, "k2": newKey})
+		encrypted, _ := before.Encrypt([]byte("payload"), nil)
+		if decrypted, err := after.Decrypt(encrypted, nil); err != nil || string(decrypted) != "payload" {
+			t.Errorf("Decrypt after rotation = %q, %v", decrypted, err)
+		}
+		encrypted, _ = after.Encrypt([]byte("payload"), nil)
+		if _, err := before.Decrypt(encrypted, nil); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("Decrypt with an unknown key = %v, want ErrUnknownKey", err)
+		}
+	})
+
+	t.Run("InvalidKeys", func(t *testing.T) {
+		for name, keys := range map[string]map[string][]byte{
+			"missing key ID": {"k2": oldKey},
+			"short key":      {"k1": []byte("short")},
+			"empty key ID":   {"k1": oldKey, "": newKey},
+		} {
+			if _, err := NewPayloadCipher("k1", keys); err == nil {
+				t.Errorf("%s: NewPayloadCipher succeeded", name)
+			}
+		}
+	})
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements the feature flags gating capabilities of the components, so operators can roll them out
+// gradually, e.g. enabling them in a staging environment before production.
+
+package features
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// feature flags
+const (
+	// ProgressEvents publishes progress events of the jobs in progress to the event channel, for progress streaming
+	ProgressEvents = "progress_events"
+	// ShardedOutputs splits the output and error files into shards
+	ShardedOutputs = "sharded_outputs"
+	// Webhooks POSTs the lifecycle events of the batches to the event sinks
+	Webhooks = "webhooks"
+)
+
+// defaults are the states of the flags that are not configured.
+var defaults = map[string]bool{
+	ProgressEvents: true,
+	ShardedOutputs: true,
+	Webhooks:       true,
+}
+
+// Config are the states of the feature flags by name. The flags that are not listed have their default state.
+type Config map[string]bool
+
+// Validate checks that the flags are known.
+func (c Config) Validate() error {
+	for name := range c {
+		if _, ok := defaults[name]; !ok {
+			return fmt.Errorf("unknown feature flag %q, the flags are %s", name,
+				strings.Join(slices.Sorted(maps.Keys(defaults)), ", "))
+		}
+	}
+	return nil
+}
+
+// flags are the states of all the flags, the defaults until Set is called.
+var flags atomic.Pointer[map[string]bool]
+
+// Set sets the states of the flags, at startup and when the configuration is reloaded. It returns the names of
+// the flags whose state changed.
+func Set(cfg Config) []string {
+	states := maps.Clone(defaults)
+	maps.Copy(states, cfg)
+	previous := flags.Swap(&states)
+	if previous == nil {
+		previous = &defaults
+	}
+	var changed []string
+	for name, enabled := range states {
+		if (*previous)[name] != enabled {
+			changed = append(changed, name)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// Enabled returns whether the feature is enabled. The features are checked when they are used, e.g. when a job
+// starts, so that a reloaded flag applies to the next jobs.
+func Enabled(name string) bool {
+	if states := flags.Load(); states != nil {
+		return (*states)[name]
+	}
+	return defaults[name]
+}
+
+// States returns the states of all the flags.
+func States() map[string]bool {
+	if states := flags.Load(); states != nil {
+		return maps.Clone(*states)
+	}
+	return maps.Clone(defaults)
+}
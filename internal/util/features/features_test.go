@@ -0,0 +1,54 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The unit tests of the feature flags.
+
+package features
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFeatures(t *testing.T) {
+	t.Cleanup(func() { Set(nil) })
+
+	if !Enabled(Webhooks) || Enabled("unknown") {
+		t.Errorf("default states: webhooks %v, unknown %v", Enabled(Webhooks), Enabled("unknown"))
+	}
+
+	if changed := Set(Config{Webhooks: false, ShardedOutputs: true}); !reflect.DeepEqual(changed, []string{Webhooks}) {
+		t.Errorf("Set() changed = %v, want [%s]", changed, Webhooks)
+	}
+	if Enabled(Webhooks) || !Enabled(ShardedOutputs) || !Enabled(ProgressEvents) {
+		t.Errorf("States() = %v, want webhooks disabled only", States())
+	}
+
+	// the flags no longer listed are back to their default state
+	if changed := Set(Config{ProgressEvents: false}); !reflect.DeepEqual(changed, []string{ProgressEvents, Webhooks}) {
+		t.Errorf("Set() changed = %v", changed)
+	}
+	if !Enabled(Webhooks) || Enabled(ProgressEvents) {
+		t.Errorf("States() = %v, want progress_events disabled only", States())
+	}
+
+	if err := (Config{"webhook": true}).Validate(); err == nil {
+		t.Errorf("Validate() of an unknown flag succeeded")
+	}
+	if err := (Config{Webhooks: false}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements the bounded-cardinality values of the model and tenant labels of the metrics.
+
+package labels
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+)
+
+const (
+	// Other is the label value of the values beyond the limit of a label.
+	Other = "other"
+	// Unknown is the label value of an empty value, e.g. a batch created without a tenant.
+	Unknown = "unknown"
+
+	// DefaultMaxValues is the default limit of the distinct values of a label.
+	DefaultMaxValues = 100
+
+	// maxValueLength bounds the length of a label value, longer values are truncated
+	maxValueLength = 128
+	// hashedValueLength is the number of hex digits of the hash of a hashed label value
+	hashedValueLength = 12
+)
+
+// Config configures the model and tenant labels of the metrics.
+type Config struct {
+	// MaxModels and MaxTenants limit the distinct models and tenants labeling the metrics: the first values seen
+	// are kept, the later ones are labeled Other. Unlimited when 0.
+	MaxModels  int `yaml:"max_models"`
+	MaxTenants int `yaml:"max_tenants"`
+	// HashTenants labels the metrics with a hash of the tenant IDs instead of the IDs.
+	HashTenants bool `yaml:"hash_tenants"`
+}
+
+// NewConfig returns the default label configuration.
+func NewConfig() Config {
+	return Config{MaxModels: DefaultMaxValues, MaxTenants: DefaultMaxValues}
+}
+
+// Limiter bounds the distinct values of a metric label. The methods of a nil Limiter return the values unchanged,
+// except for empty values.
+type Limiter struct {
+	limit int
+	hash  bool
+
+	mu     sync.Mutex
+	values map[string]struct{}
+}
+
+// NewLimiter returns a limiter keeping the first limit distinct values, unlimited when limit is 0. The values
+// are hashed first if hash is set, e.g. to not expose tenant IDs.
+func NewLimiter(limit int, hash bool) *Limiter {
+	return &Limiter{limit: limit, hash: hash, values: map[string]struct{}{}}
+}
+
+// NewModelLimiter returns the limiter of the model labels.
+func (c Config) NewModelLimiter() *Limiter {
+	return NewLimiter(c.MaxModels, false)
+}
+
+// NewTenantLimiter returns the limiter of the tenant labels.
+func (c Config) NewTenantLimiter() *Limiter {
+	return NewLimiter(c.MaxTenants, c.HashTenants)
+}
+
+// Value returns the label value of v: Unknown if v is empty, Other if the limit of distinct values is reached
+// and v isn't one of them, and v, hashed or truncated, otherwise.
+func (l *Limiter) Value(v string) string {
+	if v == "" {
+		return Unknown
+	}
+	if l == nil {
+		return v
+	}
+	if l.hash {
+		sum := sha256.Sum256([]byte(v))
+		v = hex.EncodeToString(sum[:])[:hashedValueLength]
+	} else if len(v) > maxValueLength {
+		v = v[:maxValueLength]
+	}
+	// label values must be valid UTF-8, and the values come from user input, e.g. the model of a line
+	v = strings.ToValidUTF8(v, "")
+	if l.limit <= 0 {
+		return v
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.values[v]; ok {
+		return v
+	}
+	if len(l.values) >= l.limit {
+		return Other
+	}
+	l.values[v] = struct{}{}
+	return v
+}
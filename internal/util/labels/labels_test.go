@@ -0,0 +1,78 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file contains tests for the bounded-cardinality label values.
+
+package labels
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Run("Limit", func(t *testing.T) {
+		l := NewLimiter(2, false)
+		for _, tc := range []struct{ value, want string }{
+			{"m1", "m1"},
+			{"m2", "m2"},
+			{"m3", Other},
+			{"m1", "m1"},
+			{"", Unknown},
+		} {
+			if got := l.Value(tc.value); got != tc.want {
+				t.Errorf("Value(%q) = %q, want %q", tc.value, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		l := NewLimiter(0, false)
+		for _, v := range []string{"m1", "m2", "m3"} {
+			if got := l.Value(v); got != v {
+				t.Errorf("Value(%q) = %q", v, got)
+			}
+		}
+		long := strings.Repeat("x", 2*maxValueLength)
+		if got := l.Value(long); len(got) != maxValueLength {
+			t.Errorf("expected long value to be truncated to %d bytes, got %d", maxValueLength, len(got))
+		}
+		if got := l.Value("m\xff1"); got != "m1" {
+			t.Errorf("Value() of invalid UTF-8 = %q, want %q", got, "m1")
+		}
+	})
+
+	t.Run("Hash", func(t *testing.T) {
+		l := NewLimiter(1, true)
+		hashed := l.Value("tenant-a")
+		if hashed == "tenant-a" || len(hashed) != hashedValueLength {
+			t.Errorf("Value() = %q, want a hash of %d digits", hashed, hashedValueLength)
+		}
+		if got := l.Value("tenant-a"); got != hashed {
+			t.Errorf("Value() = %q, want the same hash %q", got, hashed)
+		}
+		if got := l.Value("tenant-b"); got != Other {
+			t.Errorf("Value() beyond the limit = %q, want %q", got, Other)
+		}
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		var l *Limiter
+		if got := l.Value("m1"); got != "m1" {
+			t.Errorf("Value() = %q, want m1", got)
+		}
+	})
+}
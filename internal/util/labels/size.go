@@ -0,0 +1,44 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements the size bucket label of the metrics of the batches.
+
+package labels
+
+// size bucket labels
+const (
+	SizeBucket100   = "100"   // less than 100 lines
+	SizeBucket1000  = "1000"  // less than 1000 lines
+	SizeBucket10000 = "10000" // less than 10000 lines
+	SizeBucket30000 = "30000" // less than 30000 lines
+	SizeBucketLarge = "large" // more than 30000 lines
+)
+
+// SizeBucket returns the size bucket label of a batch of totalLines lines.
+func SizeBucket(totalLines int) string {
+	switch {
+	case totalLines < 100:
+		return SizeBucket100
+	case totalLines < 1000:
+		return SizeBucket1000
+	case totalLines < 10000:
+		return SizeBucket10000
+	case totalLines < 30000:
+		return SizeBucket30000
+	default:
+		return SizeBucketLarge
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The file provides the formats of the log entries written by klog.
+
+package logging
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/go-logr/logr"
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/redact"
+)
+
+const (
+	// FormatText is the klog text format.
+	FormatText = "text"
+	// FormatJSON writes every log entry as a JSON object on a line.
+	FormatJSON = "json"
+
+	// LogFormatEnv sets the log format when the -log-format flag isn't set.
+	LogFormatEnv = "LOG_FORMAT"
+)
+
+// fieldNames are the names of the fields of the JSON log entries for the keys of the log calls identifying
+// the same thing under different names, so the entries of all components are queried with the same fields.
+var fieldNames = map[string]string{
+	"jobID":        "batch_id",
+	"batchID":      "batch_id",
+	"job_id":       "batch_id",
+	"requestID":    "request_id",
+	"tenantID":     "tenant",
+	"tenant_id":    "tenant",
+	"traceID":      "trace_id",
+	"spanID":       "span_id",
+	"parentSpanID": "parent_span_id",
+	"workerID":     "worker_id",
+	"processorID":  "processor_id",
+	"customID":     "custom_id",
+	"fileID":       "file_id",
+}
+
+// levelNames are the levels of the JSON log entries by klog verbosity, see the verbosity constants.
+var levelNames = map[int]string{
+	0:       "info",
+	ERROR:   "error",
+	WARNING: "warning",
+	INFO:    "info",
+	DEBUG:   "debug",
+	TRACE:   "trace",
+}
+
+// AddFlags registers the -log-format flag in fs, defaulting to the LOG_FORMAT environment variable, and returns
+// the value of the flag.
+func AddFlags(fs *flag.FlagSet) *string {
+	format := os.Getenv(LogFormatEnv)
+	if format == "" {
+		format = FormatText
+	}
+	return fs.String("log-format", format, fmt.Sprintf("Format of the log entries: %s or %s (env %s)", FormatText, FormatJSON, LogFormatEnv))
+}
+
+// Setup makes klog write the log entries in format. It must be called after the klog flags are parsed, before
+// goroutines log. In the JSON format, the entries are written to stderr with the fields time, level, msg, logger,
+// component and the key/value pairs of the log call.
+func Setup(format, component string) error {
+	switch format {
+	case "", FormatText:
+		return nil
+	case FormatJSON:
+		klog.SetLogger(NewJSONLogger(os.Stderr, component))
+		return nil
+	default:
+		return fmt.Errorf("log format must be one of %s, %s", FormatText, FormatJSON)
+	}
+}
+
+// NewJSONLogger returns a logger writing JSON log entries of the component to w. The verbosity of the entries
+// is checked by klog, the logger writes all of them.
+func NewJSONLogger(w io.Writer, component string) logr.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       slog.Level(-TRACE),
+		ReplaceAttr: replaceAttr,
+	})
+	return logr.FromSlogHandler(handler.WithAttrs([]slog.Attr{slog.String("component", component)}))
+}
+
+// replaceAttr names the levels after the klog verbosity, renames the keys identifying batches, requests
+// and tenants, and redacts the strings and errors, see redact.
+func replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	a = redactAttr(a)
+	if len(groups) > 0 {
+		return a
+	}
+	if a.Key == slog.LevelKey {
+		level, _ := a.Value.Any().(slog.Level)
+		if level >= slog.LevelError {
+			return slog.String(slog.LevelKey, "error")
+		}
+		// logr maps the verbosity v to the slog level -v
+		if name, ok := levelNames[-int(level)]; ok {
+			return slog.String(slog.LevelKey, name)
+		}
+		return slog.String(slog.LevelKey, "trace")
+	}
+	if name, ok := fieldNames[a.Key]; ok {
+		a.Key = name
+	}
+	return a
+}
+
+// redactAttr redacts the content of the string and error values.
+func redactAttr(a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(redact.String(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(redact.String(err.Error()))
+		}
+	}
+	return a
+}
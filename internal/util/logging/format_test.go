@@ -0,0 +1,107 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file contains tests for the JSON log format.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/redact"
+)
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf, "batch-processor").WithValues("jobID", "batch_1", "tenant", "t1")
+
+	logger.V(DEBUG).Info("Processing job", "requestID", "req_1", "lines", 3)
+	logger.V(ERROR).Error(errors.New("boom"), "Failed to process job")
+
+	dec := json.NewDecoder(&buf)
+	var entries []map[string]any
+	for dec.More() {
+		var entry map[string]any
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("log entry isn't JSON: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 log entries, got %d", len(entries))
+	}
+
+	for _, tc := range []struct {
+		entry map[string]any
+		want  map[string]any
+	}{
+		{entries[0], map[string]any{
+			"level": "debug", "msg": "Processing job", "component": "batch-processor",
+			"batch_id": "batch_1", "tenant": "t1", "request_id": "req_1", "lines": float64(3),
+		}},
+		{entries[1], map[string]any{
+			"level": "error", "msg": "Failed to process job", "component": "batch-processor",
+			"batch_id": "batch_1", "err": "boom",
+		}},
+	} {
+		for key, want := range tc.want {
+			if got := tc.entry[key]; got != want {
+				t.Errorf("%s: expected %v, got %v (entry %v)", key, want, got, tc.entry)
+			}
+		}
+	}
+	if _, ok := entries[0]["jobID"]; ok {
+		t.Errorf("jobID wasn't renamed: %v", entries[0])
+	}
+}
+
+func TestJSONLoggerRedaction(t *testing.T) {
+	if err := redact.Configure(redact.Config{
+		Fields: []string{"content"},
+		Rules:  []redact.Rule{{Name: "email", Pattern: `[a-z.]+@example\.com`}},
+	}); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+	t.Cleanup(func() { redact.Configure(redact.Config{}) })
+
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf, "batch-processor")
+	logger.V(ERROR).Error(errors.New(`upstream returned 400: {"content":"my prompt"}`), "Inference request failed",
+		"customID", "jane.doe@example.com")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log entry isn't JSON: %v", err)
+	}
+	if got, want := entry["err"], `upstream returned 400: {"content":"[REDACTED]"}`; got != want {
+		t.Errorf("err: expected %v, got %v", want, got)
+	}
+	if got := entry["custom_id"]; got != redact.Redacted {
+		t.Errorf("custom_id: expected %v, got %v", redact.Redacted, got)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	if err := Setup(FormatText, "apiserver"); err != nil {
+		t.Errorf("text format: %v", err)
+	}
+	if err := Setup("xml", "apiserver"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements the redaction of the request and response content before it reaches the logs, the traces
+// and the error messages, so a verbose log level doesn't leak the data of the customers.
+
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// Redacted replaces the redacted values.
+const Redacted = "[REDACTED]"
+
+// Rule replaces the matches of a regular expression.
+type Rule struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+	// Replacement of the matches, Redacted when empty. It may refer to the groups of the pattern, e.g. ${1}.
+	Replacement string `yaml:"replacement"`
+}
+
+// Config configures the redaction. Nothing is redacted when it is empty.
+type Config struct {
+	// Names of the JSON fields whose values are redacted, at any depth, e.g. messages or prompt
+	Fields []string `yaml:"fields"`
+	// Regular expressions whose matches are redacted, e.g. email addresses, applied in order after the fields
+	Rules []Rule `yaml:"rules"`
+}
+
+// Validate checks that the patterns of the rules are valid regular expressions.
+func (c Config) Validate() error {
+	_, err := New(c)
+	return err
+}
+
+// Redactor redacts the content of strings and errors. A nil Redactor redacts nothing.
+type Redactor struct {
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+	replaces []string
+}
+
+// New returns the redactor of the configuration, nil when it is empty.
+func New(cfg Config) (*Redactor, error) {
+	if len(cfg.Fields) == 0 && len(cfg.Rules) == 0 {
+		return nil, nil
+	}
+	r := &Redactor{fields: make(map[string]bool, len(cfg.Fields))}
+	for _, field := range cfg.Fields {
+		r.fields[field] = true
+	}
+	for i, rule := range cfg.Rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %w", i, rule.Name, err)
+		}
+		replace := rule.Replacement
+		if replace == "" {
+			replace = Redacted
+		}
+		r.patterns = append(r.patterns, pattern)
+		r.replaces = append(r.replaces, replace)
+	}
+	return r, nil
+}
+
+// String returns s with its content redacted: the fields of the JSON value ending s, e.g. an error body appended
+// to a message, then the matches of the rules.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	if len(r.fields) > 0 {
+		s = r.redactJSON(s)
+	}
+	for i, pattern := range r.patterns {
+		s = pattern.ReplaceAllString(s, r.replaces[i])
+	}
+	return s
+}
+
+func (r *Redactor) redactJSON(s string) string {
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return s
+	}
+	var value any
+	if err := json.Unmarshal([]byte(s[start:]), &value); err != nil {
+		return s
+	}
+	data, err := json.Marshal(r.redactValue(value))
+	if err != nil {
+		return s
+	}
+	return s[:start] + string(data)
+}
+
+func (r *Redactor) redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if r.fields[key] {
+				v[key] = Redacted
+			} else {
+				v[key] = r.redactValue(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = r.redactValue(item)
+		}
+	}
+	return value
+}
+
+// Error returns err with its message redacted, nil if err is nil. The returned error wraps err, so errors.Is and
+// errors.As still match it.
+func (r *Redactor) Error(err error) error {
+	if r == nil || err == nil {
+		return err
+	}
+	if _, ok := err.(*redactedError); ok {
+		return err
+	}
+	message := r.String(err.Error())
+	if message == err.Error() {
+		return err
+	}
+	return &redactedError{message: message, err: err}
+}
+
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// current is the redactor of the process, set by Configure.
+var current atomic.Pointer[Redactor]
+
+// Configure sets the redaction of the process, applied by String and Error.
+func Configure(cfg Config) error {
+	r, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	current.Store(r)
+	return nil
+}
+
+// String redacts s with the redaction of the process.
+func String(s string) string {
+	return current.Load().String(s)
+}
+
+// Error redacts err with the redaction of the process.
+func Error(err error) error {
+	return current.Load().Error(err)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file contains tests for the redaction of the request and response content.
+
+package redact
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	cfg := Config{
+		Fields: []string{"content", "prompt"},
+		Rules: []Rule{
+			{Name: "email", Pattern: `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`},
+			{Name: "card", Pattern: `\b(\d{4})\d{8}(\d{4})\b`, Replacement: "${1}********${2}"},
+		},
+	}
+	r, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	t.Run("String", func(t *testing.T) {
+		for _, tc := range []struct{ in, want string }{
+			{"no content", "no content"},
+			{"contact jane.doe@example.com", "contact " + Redacted},
+			{"card 4111111111111111", "card 4111********1111"},
+			{
+				`upstream returned 400: {"error":{"message":"bad prompt"},"prompt":"my secret"}`,
+				`upstream returned 400: {"error":{"message":"bad prompt"},"prompt":"[REDACTED]"}`,
+			},
+			{
+				`[{"role":"user","content":"call me at jane@example.com"}]`,
+				`[{"content":"[REDACTED]","role":"user"}]`,
+			},
+			{`not json: {"content": `, `not json: {"content": `},
+		} {
+			if got := r.String(tc.in); got != tc.want {
+				t.Errorf("String(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		cause := errors.New("request of jane@example.com failed")
+		err := r.Error(cause)
+		if strings.Contains(err.Error(), "jane@example.com") {
+			t.Errorf("Error() = %q, want the email redacted", err)
+		}
+		if !errors.Is(err, cause) {
+			t.Error("the redacted error doesn't wrap the error")
+		}
+		if r.Error(err) != err {
+			t.Error("a redacted error is redacted again")
+		}
+		if plain := errors.New("timeout"); r.Error(plain) != plain {
+			t.Error("an error without content is wrapped")
+		}
+		if r.Error(nil) != nil {
+			t.Error("Error(nil) is not nil")
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		r, err := New(Config{})
+		if r != nil || err != nil {
+			t.Fatalf("New of an empty config = %v, %v, want nil", r, err)
+		}
+		if got := r.String("jane@example.com"); got != "jane@example.com" {
+			t.Errorf("String() = %q, want the string unchanged", got)
+		}
+	})
+
+	t.Run("InvalidRule", func(t *testing.T) {
+		if err := (Config{Rules: []Rule{{Name: "broken", Pattern: "("}}}).Validate(); err == nil {
+			t.Error("Validate() of an invalid pattern succeeded")
+		}
+	})
+
+	t.Run("Configure", func(t *testing.T) {
+		t.Cleanup(func() { current.Store(nil) })
+		if err := Configure(cfg); err != nil {
+			t.Fatalf("Configure failed: %v", err)
+		}
+		if got := String("jane@example.com"); got != Redacted {
+			t.Errorf("String() = %q, want %q", got, Redacted)
+		}
+	})
+}
@@ -40,6 +40,9 @@ const (
 type RedisClientConfig struct {
 	Url             string
 	DbIdx           int
+	Username        string // ACL user to authenticate as. Overrides the user of Url.
+	Password        string // Password of the ACL user, or of the default user when Username is empty. Overrides the password of Url.
+	PasswordFile    string // File containing the password, e.g. a mounted secret. Used when Password is empty.
 	EnableTLS       bool
 	Insecure        bool
 	Certificates    *utls.Certificates
@@ -78,6 +81,22 @@ func NewRedisClient(ctx context.Context, cnf *RedisClientConfig) (*gredis.Client
 		logger.Error(err, "NewRedisClient")
 		return nil, err
 	}
+	if cnf.Username != "" {
+		redisOps.Username = cnf.Username
+	}
+	password := cnf.Password
+	if password == "" && cnf.PasswordFile != "" {
+		data, err := os.ReadFile(cnf.PasswordFile)
+		if err != nil {
+			err = fmt.Errorf("failed to read redis password file: %w", err)
+			logger.Error(err, "NewRedisClient")
+			return nil, err
+		}
+		password = strings.TrimSpace(string(data))
+	}
+	if password != "" {
+		redisOps.Password = password
+	}
 	if redisOps.ClientName == "" {
 		hostname, _ := os.Hostname()
 		if cnf.ServiceName != "" {
@@ -21,6 +21,7 @@ package redis_test
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/alicebob/miniredis/v2"
@@ -115,3 +116,43 @@ func TestRedisClient(t *testing.T) {
 		}
 	})
 }
+
+func TestRedisClientAuth(t *testing.T) {
+	minirds := miniredis.NewMiniRedis()
+	if err := minirds.Start(); err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	t.Cleanup(minirds.Close)
+	minirds.RequireUserAuth("batch", "s3cret")
+
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write password file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     redis.RedisClientConfig
+		wantErr bool
+	}{
+		{name: "credentials in url", cfg: redis.RedisClientConfig{Url: "redis://batch:s3cret@" + minirds.Addr()}},
+		{name: "username and password", cfg: redis.RedisClientConfig{Url: "redis://" + minirds.Addr(), Username: "batch", Password: "s3cret"}},
+		{name: "password file", cfg: redis.RedisClientConfig{Url: "redis://" + minirds.Addr(), Username: "batch", PasswordFile: passwordFile}},
+		{name: "password overrides url", cfg: redis.RedisClientConfig{Url: "redis://batch:wrong@" + minirds.Addr(), Password: "s3cret"}},
+		{name: "wrong password", cfg: redis.RedisClientConfig{Url: "redis://" + minirds.Addr(), Username: "batch", Password: "wrong"}, wantErr: true},
+		{name: "no credentials", cfg: redis.RedisClientConfig{Url: "redis://" + minirds.Addr()}, wantErr: true},
+		{name: "missing password file", cfg: redis.RedisClientConfig{Url: "redis://" + minirds.Addr(), Username: "batch", PasswordFile: passwordFile + ".missing"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.ServiceName = "test-service"
+			rds, err := redis.NewRedisClient(context.Background(), &tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRedisClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if rds != nil {
+				rds.Close()
+			}
+		})
+	}
+}
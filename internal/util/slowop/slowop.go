@@ -0,0 +1,175 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements the logging of the operations slower than the configured thresholds.
+
+package slowop
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+	"k8s.io/klog/v2"
+)
+
+// kinds of operations
+const (
+	Handler   = "handler"    // an API request
+	Inference = "inference"  // an attempt of an inference request
+	FileStore = "file_store" // an operation of the files store
+	Database  = "database"   // an operation of the batch or file database
+)
+
+// Config configures the thresholds above which an operation is logged as slow. A threshold of 0 disables the
+// logging of the operations of its kind.
+type Config struct {
+	Handler   time.Duration `yaml:"handler"`
+	Inference time.Duration `yaml:"inference"`
+	FileStore time.Duration `yaml:"file_store"`
+	Database  time.Duration `yaml:"database"`
+}
+
+// NewConfig returns the default thresholds.
+func NewConfig() Config {
+	return Config{
+		Handler:   5 * time.Second,
+		Inference: 2 * time.Minute,
+		FileStore: 5 * time.Second,
+		Database:  time.Second,
+	}
+}
+
+// Validate checks that the thresholds are not negative.
+func (c Config) Validate() error {
+	for kind, threshold := range c.thresholds() {
+		if threshold < 0 {
+			return fmt.Errorf("slow operation threshold of %s must be non-negative", kind)
+		}
+	}
+	return nil
+}
+
+func (c Config) thresholds() map[string]time.Duration {
+	return map[string]time.Duration{
+		Handler:   c.Handler,
+		Inference: c.Inference,
+		FileStore: c.FileStore,
+		Database:  c.Database,
+	}
+}
+
+// thresholds are the thresholds of the operations by kind, none until SetThresholds is called.
+var thresholds atomic.Pointer[map[string]time.Duration]
+
+// SetThresholds sets the thresholds above which the operations are logged as slow. It is called at startup,
+// before the operations are tracked.
+func SetThresholds(cfg Config) {
+	t := cfg.thresholds()
+	thresholds.Store(&t)
+}
+
+func threshold(kind string) time.Duration {
+	if t := thresholds.Load(); t != nil {
+		return (*t)[kind]
+	}
+	return 0
+}
+
+type breakdownKey struct{}
+
+// Breakdown accumulates the time spent in the operations of each kind tracked during an API request, so the log
+// entry of a slow request tells where the time went.
+type Breakdown struct {
+	mu         sync.Mutex
+	durations  map[string]time.Duration
+	operations map[string]int
+}
+
+// NewContext returns a context carrying a new breakdown of the operations tracked with it.
+func NewContext(ctx context.Context) (context.Context, *Breakdown) {
+	b := &Breakdown{durations: map[string]time.Duration{}, operations: map[string]int{}}
+	return context.WithValue(ctx, breakdownKey{}, b), b
+}
+
+func fromContext(ctx context.Context) *Breakdown {
+	b, _ := ctx.Value(breakdownKey{}).(*Breakdown)
+	return b
+}
+
+func (b *Breakdown) add(kind string, d time.Duration) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.durations[kind] += d
+	b.operations[kind]++
+}
+
+// keysAndValues returns the time spent in the operations of each kind and their number, as log key/value pairs.
+func (b *Breakdown) keysAndValues() []any {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var kv []any
+	for _, kind := range []string{Database, FileStore, Inference} {
+		if n := b.operations[kind]; n > 0 {
+			kv = append(kv, kind+"_ms", milliseconds(b.durations[kind]), kind+"_ops", n)
+		}
+	}
+	return kv
+}
+
+// Track starts timing an operation of kind, named op. The returned func ends the operation: its duration is
+// added to the breakdown of ctx, and it is logged as slow if it exceeds the threshold of kind, e.g.
+//
+//	defer slowop.Track(ctx, slowop.Database, "get_jobs")()
+func Track(ctx context.Context, kind, op string) func() {
+	start := time.Now()
+	return func() {
+		Observe(ctx, kind, op, time.Since(start))
+	}
+}
+
+// Observe adds an operation of kind that took duration to the breakdown of ctx, and logs it with the logger
+// of ctx as a warning with keysAndValues if it exceeds the threshold of kind. The time spent in the operations
+// of the breakdown of ctx is logged with a slow request.
+func Observe(ctx context.Context, kind, op string, duration time.Duration, keysAndValues ...any) {
+	b := fromContext(ctx)
+	if kind != Handler {
+		b.add(kind, duration)
+	}
+	t := threshold(kind)
+	if t <= 0 || duration <= t {
+		return
+	}
+	kv := append([]any{"kind", kind, "operation", op, "duration_ms", milliseconds(duration),
+		"threshold_ms", milliseconds(t)}, keysAndValues...)
+	if kind == Handler {
+		kv = append(kv, b.keysAndValues()...)
+	}
+	klog.FromContext(ctx).V(logging.WARNING).Info("Slow operation", kv...)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
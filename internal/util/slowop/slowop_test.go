@@ -0,0 +1,86 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file contains tests for the logging of the slow operations.
+
+package slowop
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/logging"
+	"k8s.io/klog/v2"
+)
+
+func TestObserve(t *testing.T) {
+	SetThresholds(Config{Handler: 100 * time.Millisecond, Database: 10 * time.Millisecond})
+	t.Cleanup(func() { SetThresholds(Config{}) })
+
+	var buf bytes.Buffer
+	ctx := klog.NewContext(context.Background(), logging.NewJSONLogger(&buf, "test"))
+	ctx, _ = NewContext(ctx)
+
+	Observe(ctx, Database, "get_jobs", 5*time.Millisecond)
+	Observe(ctx, Database, "update_job", 20*time.Millisecond)
+	Observe(ctx, FileStore, "store", time.Second) // no threshold
+	Observe(ctx, Handler, "POST /v1/batches", 50*time.Millisecond)
+	Observe(ctx, Handler, "POST /v1/batches", 2*time.Second, "status", 200)
+
+	var entries []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var entry map[string]any
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("log entry isn't JSON: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 slow operations logged, got %d: %v", len(entries), entries)
+	}
+	for key, want := range map[string]any{"kind": Database, "operation": "update_job", "duration_ms": 20.0, "threshold_ms": 10.0} {
+		if got := entries[0][key]; got != want {
+			t.Errorf("database operation: %s = %v, want %v", key, got, want)
+		}
+	}
+	for key, want := range map[string]any{
+		"kind": Handler, "duration_ms": 2000.0, "status": 200.0,
+		"database_ms": 25.0, "database_ops": 2.0, "file_store_ms": 1000.0, "file_store_ops": 1.0,
+	} {
+		if got := entries[1][key]; got != want {
+			t.Errorf("request: %s = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestTrackWithoutBreakdown(t *testing.T) {
+	// operations outside of a request are tracked without a breakdown, and not logged without thresholds
+	done := Track(context.Background(), Database, "get_jobs")
+	done()
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := NewConfig().Validate(); err != nil {
+		t.Errorf("default config: %v", err)
+	}
+	if err := (Config{Database: -time.Second}).Validate(); err == nil {
+		t.Error("expected an error for a negative threshold")
+	}
+}
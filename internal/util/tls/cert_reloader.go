@@ -0,0 +1,151 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file provides a server certificate loader that picks up rotated certificates.
+
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// DefaultCertCheckInterval is how often the certificate files are checked for changes.
+const DefaultCertCheckInterval = 10 * time.Second
+
+// CertReloader serves a certificate key pair and reloads it when the files change on disk,
+// e.g. when a mounted Kubernetes secret is rotated.
+type CertReloader struct {
+	certFile      string
+	keyFile       string
+	checkInterval time.Duration
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	certMod   time.Time
+	keyMod    time.Time
+	lastCheck time.Time
+}
+
+func NewCertReloader(certFile, keyFile string, checkInterval time.Duration) (*CertReloader, error) {
+	if checkInterval <= 0 {
+		checkInterval = DefaultCertCheckInterval
+	}
+	r := &CertReloader{
+		certFile:      certFile,
+		keyFile:       keyFile,
+		checkInterval: checkInterval,
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CertReloader) load() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("CertReloader: stat cert file failed: %v", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("CertReloader: stat key file failed: %v", err) // pragma: allowlist secret
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("CertReloader: LoadX509KeyPair failed: %v", err) // pragma: allowlist secret
+	}
+	r.cert = &cert
+	r.certMod = certInfo.ModTime()
+	r.keyMod = keyInfo.ModTime()
+	return nil
+}
+
+// maybeReload reloads the key pair if either file changed since the last load.
+// A failed reload keeps serving the previous certificate, since the cert and key are
+// usually not replaced atomically together.
+func (r *CertReloader) maybeReload() {
+	now := time.Now()
+	if now.Sub(r.lastCheck) < r.checkInterval {
+		return
+	}
+	r.lastCheck = now
+
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return
+	}
+	if certInfo.ModTime().Equal(r.certMod) && keyInfo.ModTime().Equal(r.keyMod) {
+		return
+	}
+	if err := r.load(); err != nil {
+		klog.Background().Error(err, "failed to reload certificate, keep using the previous one", "cert_file", r.certFile)
+		return
+	}
+	klog.Background().Info("certificate reloaded", "cert_file", r.certFile)
+}
+
+// GetCertificate is meant to be used as tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maybeReload()
+	return r.cert, nil
+}
+
+// GetServerTlsConfig returns a server tls config whose certificate is reloaded on rotation.
+// When clientCaCertFile is set, clients must present a certificate signed by that CA.
+func GetServerTlsConfig(certFile, keyFile, clientCaCertFile string) (*tls.Config, error) {
+	reloader, err := NewCertReloader(certFile, keyFile, DefaultCertCheckInterval)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+		},
+	}
+	if clientCaCertFile != "" {
+		ca, err := os.ReadFile(clientCaCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("GetServerTlsConfig: Could not read client CA certificate file: %v", err) // pragma: allowlist secret
+		}
+		certPool := x509.NewCertPool()
+		if ok := certPool.AppendCertsFromPEM(ca); !ok {
+			return nil, fmt.Errorf("GetServerTlsConfig: AppendCertsFromPEM failed") // pragma: allowlist secret
+		}
+		tlsConf.ClientCAs = certPool
+		tlsConf.ClientAuth = tls.RequireAndVerifyClientCert // pragma: allowlist secret
+	}
+	return tlsConf, nil
+}
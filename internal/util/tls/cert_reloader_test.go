@@ -0,0 +1,137 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file contains tests for the certificate reloader.
+
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestCert(t *testing.T, dir, commonName string, modTime time.Time) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey failed: %v", err)
+	}
+
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return certFile, keyFile
+}
+
+func commonNameOf(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate failed: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	certFile, keyFile := writeTestCert(t, dir, "first", now.Add(-time.Minute))
+
+	reloader, err := NewCertReloader(certFile, keyFile, time.Nanosecond)
+	if err != nil {
+		t.Fatalf("NewCertReloader failed: %v", err)
+	}
+	cert, _ := reloader.GetCertificate(nil)
+	if got := commonNameOf(t, cert); got != "first" {
+		t.Errorf("CommonName = %v, want %v", got, "first")
+	}
+
+	// rotate
+	writeTestCert(t, dir, "second", now)
+	cert, _ = reloader.GetCertificate(nil)
+	if got := commonNameOf(t, cert); got != "second" {
+		t.Errorf("CommonName after rotation = %v, want %v", got, "second")
+	}
+
+	// a broken key pair keeps the previous certificate
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(keyFile, now.Add(time.Minute), now.Add(time.Minute))
+	cert, _ = reloader.GetCertificate(nil)
+	if got := commonNameOf(t, cert); got != "second" {
+		t.Errorf("CommonName after failed reload = %v, want %v", got, "second")
+	}
+}
+
+func TestGetServerTlsConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir, "server", time.Now())
+
+	conf, err := GetServerTlsConfig(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("GetServerTlsConfig failed: %v", err)
+	}
+	if conf.ClientAuth != tls.NoClientCert {
+		t.Errorf("ClientAuth = %v, want %v", conf.ClientAuth, tls.NoClientCert)
+	}
+
+	conf, err = GetServerTlsConfig(certFile, keyFile, certFile)
+	if err != nil {
+		t.Fatalf("GetServerTlsConfig with client CA failed: %v", err)
+	}
+	if conf.ClientAuth != tls.RequireAndVerifyClientCert || conf.ClientCAs == nil {
+		t.Errorf("client certificate verification not configured")
+	}
+
+	if _, err := GetServerTlsConfig(certFile, keyFile, filepath.Join(dir, "missing.pem")); err == nil {
+		t.Errorf("expected error for missing client CA file")
+	}
+}
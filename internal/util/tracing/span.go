@@ -0,0 +1,153 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements the recording of spans, the timed operations of a trace, and their export.
+
+package tracing
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/llm-d-incubation/batch-gateway/internal/util/redact"
+)
+
+// Span is a timed operation of a trace. A span is created by StartSpan and exported once it ends.
+type Span struct {
+	Name         string
+	TraceContext *TraceContext // The trace context of the span, propagated to its children.
+	ParentSpanID string
+	StartTime    time.Time
+	EndTime      time.Time
+	Err          error
+
+	mu         sync.Mutex
+	attributes map[string]any
+}
+
+// Exporter receives the spans that ended.
+type Exporter interface {
+	Export(span *Span)
+}
+
+var exporter atomic.Pointer[Exporter]
+
+// SetExporter sets the exporter of the spans ending from now on. Spans are not exported if exporter is nil.
+func SetExporter(e Exporter) {
+	if e == nil {
+		exporter.Store(nil)
+		return
+	}
+	exporter.Store(&e)
+}
+
+// StartSpan starts a span named name, child of the span of the trace context carried by ctx, and returns
+// a copy of ctx carrying the trace context of the new span.
+// It returns ctx and a nil span if ctx carries no trace context; the methods of a nil span do nothing.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	parent := FromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		Name:         name,
+		TraceContext: parent.NewSpan(),
+		ParentSpanID: parent.SpanID(),
+		StartTime:    time.Now(),
+	}
+	return NewContext(ctx, span.TraceContext), span
+}
+
+// SetAttribute sets an attribute describing the operation of the span.
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attributes == nil {
+		s.attributes = map[string]any{}
+	}
+	s.attributes[key] = value
+}
+
+// Attributes returns a copy of the attributes of the span.
+func (s *Span) Attributes() map[string]any {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.attributes)
+}
+
+// End ends the span, failed with err if it is not nil, and exports it.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.EndTime = time.Now()
+	s.Err = err
+	if e := exporter.Load(); e != nil {
+		(*e).Export(s)
+	}
+}
+
+// Duration returns the duration of a span that ended.
+func (s *Span) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// LogExporter exports spans as log entries.
+type LogExporter struct {
+	logger klog.Logger
+}
+
+// NewLogExporter returns an exporter writing spans to logger.
+func NewLogExporter(logger klog.Logger) *LogExporter {
+	return &LogExporter{logger: logger}
+}
+
+func (e *LogExporter) Export(span *Span) {
+	keysAndValues := []any{
+		"name", span.Name,
+		"traceID", span.TraceContext.TraceID(),
+		"spanID", span.TraceContext.SpanID(),
+		"parentSpanID", span.ParentSpanID,
+		"start", span.StartTime.UTC().Format(time.RFC3339Nano),
+		"duration", span.Duration(),
+		"attributes", redactAttributes(span.Attributes()),
+	}
+	if span.Err != nil {
+		keysAndValues = append(keysAndValues, "err", redact.String(span.Err.Error()))
+	}
+	e.logger.Info("Span", keysAndValues...)
+}
+
+// redactAttributes redacts the content of the string attributes, see redact.
+func redactAttributes(attributes map[string]any) map[string]any {
+	for key, value := range attributes {
+		if s, ok := value.(string); ok {
+			attributes[key] = redact.String(s)
+		}
+	}
+	return attributes
+}
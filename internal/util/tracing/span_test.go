@@ -0,0 +1,74 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file contains tests for the recording of spans.
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (e *recordingExporter) Export(span *Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+func TestStartSpan(t *testing.T) {
+	exporter := &recordingExporter{}
+	SetExporter(exporter)
+	t.Cleanup(func() { SetExporter(nil) })
+
+	// without a trace context, no span is recorded
+	ctx, span := StartSpan(context.Background(), "untraced")
+	if span != nil || FromContext(ctx) != nil {
+		t.Fatalf("StartSpan() without trace context = %+v", span)
+	}
+	span.SetAttribute("key", "value")
+	span.End(nil)
+
+	parent := Parse(testTraceParent, "vendor=value")
+	ctx, span = StartSpan(NewContext(context.Background(), parent), "parent")
+	_, child := StartSpan(ctx, "child")
+	child.SetAttribute("lines", 3)
+	child.End(errors.New("failed"))
+	span.End(nil)
+
+	if span.TraceContext.TraceID() != parent.TraceID() || span.ParentSpanID != parent.SpanID() {
+		t.Errorf("span %+v is not a child of %+v", span.TraceContext, parent)
+	}
+	if FromContext(ctx) != span.TraceContext {
+		t.Errorf("context doesn't carry the trace context of the span")
+	}
+	if child.TraceContext.TraceID() != parent.TraceID() || child.ParentSpanID != span.TraceContext.SpanID() {
+		t.Errorf("child %+v is not a child of %+v", child.TraceContext, span.TraceContext)
+	}
+	if child.Attributes()["lines"] != 3 || child.Err == nil || child.Duration() < 0 {
+		t.Errorf("unexpected child span: %+v", child)
+	}
+	if len(exporter.spans) != 2 || exporter.spans[0] != child || exporter.spans[1] != span {
+		t.Errorf("exported spans = %+v, want child and parent", exporter.spans)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements W3C trace context (https://www.w3.org/TR/trace-context/) propagation.
+
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+
+	traceParentVersion = "00"
+	traceIDLength      = 32
+	spanIDLength       = 16
+
+	// sampledFlag is the trace flag set when the caller may record the trace
+	sampledFlag = 0x01
+)
+
+type contextKey struct{}
+
+// TraceContext is a W3C trace context, i.e. the traceparent and tracestate headers of a request.
+type TraceContext struct {
+	TraceParent string
+	TraceState  string
+}
+
+// Parse returns the trace context of the given headers, or nil if traceparent is missing or malformed.
+func Parse(traceParent, traceState string) *TraceContext {
+	traceParent = strings.ToLower(strings.TrimSpace(traceParent))
+	if !validTraceParent(traceParent) {
+		return nil
+	}
+	return &TraceContext{TraceParent: traceParent, TraceState: strings.TrimSpace(traceState)}
+}
+
+// FromHeader returns the trace context of HTTP headers, or nil if there is none.
+func FromHeader(h http.Header) *TraceContext {
+	return Parse(h.Get(TraceParentHeader), h.Get(TraceStateHeader))
+}
+
+// FromCarrier returns the trace context stored in a carrier map, or nil if there is none.
+func FromCarrier(carrier map[string]string) *TraceContext {
+	if carrier == nil {
+		return nil
+	}
+	return Parse(carrier[TraceParentHeader], carrier[TraceStateHeader])
+}
+
+// Carrier returns the trace context as a map of its headers, used to store it with batch records and queue messages.
+func (tc *TraceContext) Carrier() map[string]string {
+	if tc == nil {
+		return nil
+	}
+	carrier := map[string]string{TraceParentHeader: tc.TraceParent}
+	if tc.TraceState != "" {
+		carrier[TraceStateHeader] = tc.TraceState
+	}
+	return carrier
+}
+
+// Inject sets the trace context headers on h.
+func (tc *TraceContext) Inject(h http.Header) {
+	if tc == nil {
+		return
+	}
+	h.Set(TraceParentHeader, tc.TraceParent)
+	if tc.TraceState != "" {
+		h.Set(TraceStateHeader, tc.TraceState)
+	}
+}
+
+// TraceID returns the ID of the trace.
+func (tc *TraceContext) TraceID() string {
+	return tc.TraceParent[3 : 3+traceIDLength]
+}
+
+// SpanID returns the ID of the span, which is the parent of spans created from this context.
+func (tc *TraceContext) SpanID() string {
+	return tc.TraceParent[4+traceIDLength : 4+traceIDLength+spanIDLength]
+}
+
+// Sampled returns whether the trace is sampled, i.e. its spans are likely recorded by the tracing backend.
+func (tc *TraceContext) Sampled() bool {
+	flags, err := strconv.ParseUint(tc.TraceParent[len(tc.TraceParent)-2:], 16, 8)
+	return err == nil && flags&sampledFlag != 0
+}
+
+// NewSpan returns a trace context for a new span in the same trace, whose parent is the span of tc.
+func (tc *TraceContext) NewSpan() *TraceContext {
+	flags := tc.TraceParent[len(tc.TraceParent)-2:]
+	return &TraceContext{
+		TraceParent: strings.Join([]string{traceParentVersion, tc.TraceID(), newSpanID(), flags}, "-"),
+		TraceState:  tc.TraceState,
+	}
+}
+
+// NewContext returns a copy of ctx carrying the trace context.
+func NewContext(ctx context.Context, tc *TraceContext) context.Context {
+	if tc == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, tc)
+}
+
+// FromContext returns the trace context carried by ctx, or nil if there is none.
+func FromContext(ctx context.Context) *TraceContext {
+	tc, _ := ctx.Value(contextKey{}).(*TraceContext)
+	return tc
+}
+
+// SampledTraceID returns the ID of the trace carried by ctx if it is sampled, e.g. to link a metric exemplar
+// to the trace, or "" otherwise.
+func SampledTraceID(ctx context.Context) string {
+	tc := FromContext(ctx)
+	if tc == nil || !tc.Sampled() {
+		return ""
+	}
+	return tc.TraceID()
+}
+
+// validTraceParent checks the version 00 format: {version}-{trace-id}-{parent-id}-{trace-flags}.
+// Trace and parent IDs of all zeros are invalid.
+func validTraceParent(traceParent string) bool {
+	parts := strings.Split(traceParent, "-")
+	if len(parts) != 4 || parts[0] != traceParentVersion {
+		return false
+	}
+	if !isHex(parts[1], traceIDLength) || !isHex(parts[2], spanIDLength) || !isHex(parts[3], 2) {
+		return false
+	}
+	return strings.Trim(parts[1], "0") != "" && strings.Trim(parts[2], "0") != ""
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func newSpanID() string {
+	b := make([]byte, spanIDLength/2)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file contains tests for the W3C trace context propagation.
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceParent string
+		valid       bool
+	}{
+		{name: "valid", traceParent: testTraceParent, valid: true},
+		{name: "upper case", traceParent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01", valid: true},
+		{name: "empty", traceParent: ""},
+		{name: "unknown version", traceParent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "short trace id", traceParent: "00-4bf92f3577b34da6-00f067aa0ba902b7-01"},
+		{name: "zero trace id", traceParent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "zero span id", traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{name: "not hex", traceParent: "00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.traceParent, "") != nil; got != tt.valid {
+				t.Errorf("Parse(%q) valid = %v, want %v", tt.traceParent, got, tt.valid)
+			}
+		})
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	h := http.Header{}
+	h.Set(TraceParentHeader, testTraceParent)
+	h.Set(TraceStateHeader, "vendor=value")
+
+	tc := FromHeader(h)
+	if tc == nil {
+		t.Fatalf("FromHeader returned nil")
+	}
+	if tc.TraceID() != "4bf92f3577b34da6a3ce929d0e0e4736" || tc.SpanID() != "00f067aa0ba902b7" {
+		t.Errorf("unexpected IDs: trace %s span %s", tc.TraceID(), tc.SpanID())
+	}
+
+	// round trip through a carrier
+	if got := FromCarrier(tc.Carrier()); *got != *tc {
+		t.Errorf("FromCarrier(Carrier()) = %+v, want %+v", got, tc)
+	}
+
+	span := tc.NewSpan()
+	if span.TraceID() != tc.TraceID() || span.SpanID() == tc.SpanID() || span.TraceState != tc.TraceState {
+		t.Errorf("unexpected new span %+v of %+v", span, tc)
+	}
+	if Parse(span.TraceParent, "") == nil {
+		t.Errorf("new span traceparent %q is invalid", span.TraceParent)
+	}
+
+	out := http.Header{}
+	span.Inject(out)
+	if out.Get(TraceParentHeader) != span.TraceParent || out.Get(TraceStateHeader) != "vendor=value" {
+		t.Errorf("unexpected injected headers: %v", out)
+	}
+}
+
+func TestSampledTraceID(t *testing.T) {
+	ctx := context.Background()
+	if got := SampledTraceID(ctx); got != "" {
+		t.Errorf("SampledTraceID() without trace context = %q, want none", got)
+	}
+	if got := SampledTraceID(NewContext(ctx, Parse(testTraceParent, ""))); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("SampledTraceID() of sampled trace = %q", got)
+	}
+	notSampled := Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", "")
+	if got := SampledTraceID(NewContext(ctx, notSampled)); got != "" {
+		t.Errorf("SampledTraceID() of trace not sampled = %q, want none", got)
+	}
+}
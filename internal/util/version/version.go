@@ -0,0 +1,97 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file provides the version and build information of the binaries.
+
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The version, git SHA and build date of the binary, set at build time, e.g.:
+//
+//	go build -ldflags "-X github.com/llm-d-incubation/batch-gateway/internal/util/version.version=v0.1.0"
+//
+// The git SHA and build date default to the VCS information stamped by go build, when available.
+var (
+	version   = "dev"
+	gitCommit = ""
+	buildDate = ""
+)
+
+// Path is the path of the version endpoint of the components.
+const Path = "/version"
+
+// Info is the version and build information of a binary.
+type Info struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the version and build information of the running binary.
+func Get() Info {
+	info := Info{
+		Version:   version,
+		GitCommit: gitCommit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+	}
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range buildInfo.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.GitCommit == "":
+				info.GitCommit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// NewBuildInfoGauge returns the build_info metric, always 1, labeled with the version and build information.
+func NewBuildInfoGauge() prometheus.Gauge {
+	info := Get()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "build_info",
+		Help: "Version and build information of the binary, always 1",
+		ConstLabels: prometheus.Labels{
+			"version":    info.Version,
+			"git_sha":    info.GitCommit,
+			"build_date": info.BuildDate,
+			"go_version": info.GoVersion,
+		},
+	})
+	gauge.Set(1)
+	return gauge
+}
+
+// Handler serves the version and build information as JSON.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		json.NewEncoder(w).Encode(Get())
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2026 The llm-d Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file contains the unit tests of the version and build information.
+
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestVersion(t *testing.T) {
+	defer func(v, c, d string) { version, gitCommit, buildDate = v, c, d }(version, gitCommit, buildDate)
+	version, gitCommit, buildDate = "v1.2.3", "abc123", "2026-01-02T03:04:05Z"
+
+	want := Info{Version: "v1.2.3", GitCommit: "abc123", BuildDate: "2026-01-02T03:04:05Z", GoVersion: runtime.Version()}
+	if got := Get(); got != want {
+		t.Errorf("Get() = %+v, want %+v", got, want)
+	}
+
+	t.Run("Handler", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		Handler(w, httptest.NewRequest(http.MethodGet, Path, nil))
+		var got Info
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		if w.Code != http.StatusOK || got != want {
+			t.Errorf("got status %d and %+v, want %+v", w.Code, got, want)
+		}
+	})
+
+	t.Run("BuildInfoGauge", func(t *testing.T) {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(NewBuildInfoGauge())
+		expected := `
+# HELP build_info Version and build information of the binary, always 1
+# TYPE build_info gauge
+build_info{build_date="2026-01-02T03:04:05Z",git_sha="abc123",go_version="` + runtime.Version() + `",version="v1.2.3"} 1
+`
+		if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "build_info"); err != nil {
+			t.Error(err)
+		}
+	})
+}